@@ -17,10 +17,13 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	certaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/cert_access"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/jwt_access"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
@@ -68,6 +71,7 @@ func main() {
 	var oauthCallbackPort int
 	var antigravityLogin bool
 	var kimiLogin bool
+	var oauthLogin string
 	var projectID string
 	var vertexImport string
 	var configPath string
@@ -80,7 +84,21 @@ func main() {
 	var backupEnv bool
 	var backupConfig bool
 	var backupAuths bool
+	var backupUsageDB bool
+	var backupPassphrase string
 	var restoreAuthsMode string
+	var encryptSecrets bool
+	var decryptSecrets bool
+	var rotateEncryptionKey bool
+	var generateEncryptionKey bool
+	var exportCredentials string
+	var importCredentials string
+	var bundlePassphrase string
+	var bundleNames string
+	var bundleReplace bool
+	var bundleDropProxy bool
+	var bundleDryRun bool
+	var generateBundleKey bool
 	var tuiMode bool
 	var standalone bool
 
@@ -96,6 +114,7 @@ func main() {
 	flag.IntVar(&oauthCallbackPort, "oauth-callback-port", 0, "Override OAuth callback port (defaults to provider-specific port)")
 	flag.BoolVar(&antigravityLogin, "antigravity-login", false, "Login to Antigravity using OAuth")
 	flag.BoolVar(&kimiLogin, "kimi-login", false, "Login to Kimi using OAuth")
+	flag.StringVar(&oauthLogin, "oauth-login", "", "Login to a provider declared under oauth-providers by name")
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
@@ -112,8 +131,26 @@ func main() {
 	flag.BoolVar(&backupEnv, "backup-env", false, "Include .env in backup")
 	flag.BoolVar(&backupConfig, "backup-config", true, "Include config.yaml in backup")
 	flag.BoolVar(&backupAuths, "backup-auths", true, "Include auths folder in backup")
+	flag.BoolVar(&backupUsageDB, "backup-usage-db", false, "Include a snapshot of the usage records database in backup")
+	flag.StringVar(&backupPassphrase, "backup-passphrase", "", "Passphrase to encrypt or decrypt backups (defaults to backup.passphrase)")
 	flag.StringVar(&restoreAuthsMode, "restore-auths-mode", "overwrite", "Auths restore mode: overwrite or incremental")
 
+	// Encryption at rest flags
+	flag.BoolVar(&encryptSecrets, "encrypt-secrets", false, "Encrypt auth files and config secrets with ENCRYPTION_KEY")
+	flag.BoolVar(&decryptSecrets, "decrypt-secrets", false, "Decrypt auth files and config secrets back to plaintext")
+	flag.BoolVar(&rotateEncryptionKey, "rotate-encryption-key", false, "Re-wrap encrypted data under the current ENCRYPTION_KEY")
+	flag.BoolVar(&generateEncryptionKey, "generate-encryption-key", false, "Print a new random encryption key and exit")
+
+	// Credential bundle flags
+	flag.StringVar(&exportCredentials, "export-credentials", "", "Export auth files to a credential bundle file")
+	flag.StringVar(&importCredentials, "import-credentials", "", "Import auth files from a credential bundle file")
+	flag.StringVar(&bundlePassphrase, "bundle-passphrase", "", "Passphrase to encrypt or decrypt credential bundles (defaults to credential-bundles.passphrase)")
+	flag.StringVar(&bundleNames, "bundle-names", "", "Comma-separated auth file names to export or import (default: all)")
+	flag.BoolVar(&bundleReplace, "bundle-replace", false, "Replace credentials for accounts that already exist on import")
+	flag.BoolVar(&bundleDropProxy, "bundle-drop-proxy", false, "Drop per-credential proxy settings on import")
+	flag.BoolVar(&bundleDryRun, "bundle-dry-run", false, "Report what an import would do without writing anything")
+	flag.BoolVar(&generateBundleKey, "generate-bundle-key", false, "Print a new credential bundle signing key and exit")
+
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
 		_, _ = fmt.Fprintf(out, "Usage of %s\n", os.Args[0])
@@ -144,6 +181,15 @@ func main() {
 	// Parse the command-line flags.
 	flag.Parse()
 
+	if generateEncryptionKey {
+		cmd.DoGenerateEncryptionKey()
+		return
+	}
+	if generateBundleKey {
+		cmd.DoGenerateBundleKey()
+		return
+	}
+
 	// Core application variables.
 	var err error
 	var cfg *config.Config
@@ -183,6 +229,17 @@ func main() {
 		}
 	}
 
+	// Load the encryption keyring before any config or auth file is read.
+	keyring, errKeyring := encryption.LoadKeyringFromEnv()
+	if errKeyring != nil {
+		log.Errorf("failed to load encryption key: %v", errKeyring)
+		return
+	}
+	if keyring != nil {
+		encryption.SetDefault(keyring)
+		log.Infof("encryption at rest enabled, key id: %s", keyring.KeyID())
+	}
+
 	lookupEnv := func(keys ...string) (string, bool) {
 		for _, key := range keys {
 			if value, ok := os.LookupEnv(key); ok {
@@ -475,27 +532,58 @@ func main() {
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register(&cfg.SDKConfig)
+	jwtaccess.Register(&cfg.SDKConfig)
+	certaccess.Register(cfg)
 
 	// Handle different command modes based on the provided flags.
 
 	if backupList {
 		// List available backups
+		if backupPath == "" && cfg != nil {
+			backupPath = cfg.Backup.Path
+		}
 		cmd.ListBackups(backupPath)
 	} else if backupCreate {
 		// Create a backup
 		cmd.DoBackup(cfg, configFilePath, &cmd.BackupOptions{
-			Name:          backupName,
-			BackupPath:    backupPath,
-			IncludeEnv:    backupEnv,
-			IncludeConfig: backupConfig,
-			IncludeAuths:  backupAuths,
+			Name:           backupName,
+			BackupPath:     backupPath,
+			IncludeEnv:     backupEnv,
+			IncludeConfig:  backupConfig,
+			IncludeAuths:   backupAuths,
+			IncludeUsageDB: backupUsageDB,
+			Passphrase:     backupPassphrase,
 		})
 	} else if backupRestore != "" {
 		// Restore from a backup
 		cmd.DoRestore(cfg, configFilePath, backupRestore, &cmd.RestoreOptions{
 			BackupPath: backupPath,
 			AuthsMode:  restoreAuthsMode,
+			Passphrase: backupPassphrase,
 		})
+	} else if encryptSecrets {
+		cmd.DoSecrets(cfg, configFilePath, cmd.SecretsEncrypt)
+	} else if decryptSecrets {
+		cmd.DoSecrets(cfg, configFilePath, cmd.SecretsDecrypt)
+	} else if rotateEncryptionKey {
+		cmd.DoSecrets(cfg, configFilePath, cmd.SecretsRotate)
+	} else if exportCredentials != "" || importCredentials != "" {
+		bundleOpts := &cmd.CredentialBundleOptions{
+			Passphrase: bundlePassphrase,
+			Replace:    bundleReplace,
+			DropProxy:  bundleDropProxy,
+			DryRun:     bundleDryRun,
+		}
+		for _, name := range strings.Split(bundleNames, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				bundleOpts.Names = append(bundleOpts.Names, name)
+			}
+		}
+		if exportCredentials != "" {
+			cmd.DoExportCredentials(cfg, exportCredentials, bundleOpts)
+		} else {
+			cmd.DoImportCredentials(cfg, importCredentials, bundleOpts)
+		}
 	} else if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
@@ -522,6 +610,8 @@ func main() {
 		cmd.DoIFlowCookieAuth(cfg, options)
 	} else if kimiLogin {
 		cmd.DoKimiLogin(cfg, options)
+	} else if oauthLogin != "" {
+		cmd.DoOAuth2Login(cfg, oauthLogin, options)
 	} else {
 		// In cloud deploy mode without config file, just wait for shutdown signals
 		if isCloudDeploy && !configFileExists {
@@ -0,0 +1,121 @@
+// Package main is a minimal out-of-process executor plugin. It answers OpenAI chat
+// completion requests by echoing the last user message, both as a single response and
+// as a stream of SSE lines.
+//
+// Build it and declare it in config.yaml:
+//
+//	plugins:
+//	  - name: "echo"
+//	    command: "/path/to/executor-plugin"
+//	    format: "openai"
+//	    models:
+//	      - name: "echo-1"
+//
+// The proxy passes the socket path in CLIPROXY_PLUGIN_SOCKET. Plugins in other languages
+// implement the same newline-delimited JSON-RPC protocol documented in sdk/cliproxy/plugin.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	clipexec "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/plugin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type echoHandler struct{}
+
+// Execute returns a chat completion whose content is the last user message.
+func (echoHandler) Execute(_ context.Context, params *plugin.ExecuteParams) (*plugin.ExecuteResult, error) {
+	text := lastUserMessage(params.Payload)
+	body := `{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}]}`
+	body, _ = sjson.Set(body, "id", fmt.Sprintf("echo-%d", time.Now().UnixNano()))
+	body, _ = sjson.Set(body, "model", params.Model)
+	body, _ = sjson.Set(body, "choices.0.message.content", text)
+	body, _ = sjson.Set(body, "usage.prompt_tokens", len(strings.Fields(text)))
+	body, _ = sjson.Set(body, "usage.completion_tokens", len(strings.Fields(text)))
+	body, _ = sjson.Set(body, "usage.total_tokens", 2*len(strings.Fields(text)))
+	return &plugin.ExecuteResult{Payload: body}, nil
+}
+
+// ExecuteStream streams the last user message one word per chunk.
+func (echoHandler) ExecuteStream(ctx context.Context, params *plugin.ExecuteParams) (*plugin.ExecuteResult, <-chan clipexec.StreamChunk, error) {
+	words := strings.Fields(lastUserMessage(params.Payload))
+	id := fmt.Sprintf("echo-%d", time.Now().UnixNano())
+	out := make(chan clipexec.StreamChunk)
+	go func() {
+		defer close(out)
+		send := func(line string) bool {
+			select {
+			case out <- clipexec.StreamChunk{Payload: []byte(line)}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for i, word := range words {
+			chunk := `{"object":"chat.completion.chunk","choices":[{"index":0,"delta":{}}]}`
+			chunk, _ = sjson.Set(chunk, "id", id)
+			chunk, _ = sjson.Set(chunk, "model", params.Model)
+			if i > 0 {
+				word = " " + word
+			}
+			chunk, _ = sjson.Set(chunk, "choices.0.delta.content", word)
+			if !send("data: " + chunk) {
+				return
+			}
+		}
+		final := `{"object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`
+		final, _ = sjson.Set(final, "id", id)
+		final, _ = sjson.Set(final, "model", params.Model)
+		if send("data: " + final) {
+			send("data: [DONE]")
+		}
+	}()
+	return &plugin.ExecuteResult{}, out, nil
+}
+
+// ListModels reports the models this plugin serves when discovery is enabled.
+func (echoHandler) ListModels(context.Context, *plugin.Auth) (*plugin.ListModelsResult, error) {
+	return &plugin.ListModelsResult{Models: []string{"echo-1"}}, nil
+}
+
+func lastUserMessage(payload string) string {
+	var text string
+	gjson.Get(payload, "messages").ForEach(func(_, msg gjson.Result) bool {
+		if msg.Get("role").String() != "user" {
+			return true
+		}
+		content := msg.Get("content")
+		if content.IsArray() {
+			var parts []string
+			content.ForEach(func(_, part gjson.Result) bool {
+				if part.Get("type").String() == "text" {
+					parts = append(parts, part.Get("text").String())
+				}
+				return true
+			})
+			text = strings.Join(parts, " ")
+		} else {
+			text = content.String()
+		}
+		return true
+	})
+	return text
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := plugin.Serve(ctx, "", echoHandler{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,129 @@
+// Package certaccess implements the built-in "client-cert" access provider, which
+// authenticates clients by a TLS client certificate verified against tls.client-ca.
+package certaccess
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	log "github.com/sirupsen/logrus"
+)
+
+// verifier is the client certificate policy served to new TLS handshakes.
+type verifier struct {
+	pool *x509.CertPool
+	mode tls.ClientAuthType
+}
+
+var current atomic.Pointer[verifier]
+
+// Register loads the client CA bundle and registers the client-cert provider when
+// client certificate verification is enabled; otherwise it unregisters the provider.
+// The CA bundle and mode apply to new TLS handshakes without a restart.
+func Register(cfg *config.Config) {
+	if cfg == nil || !cfg.TLS.ClientCertsEnabled() {
+		current.Store(nil)
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeClientCert)
+		return
+	}
+	pool, err := loadCertPool(cfg.TLS.ClientCA)
+	if err != nil {
+		// Keep the previous bundle on a bad edit; with none, trust no certificates.
+		log.Errorf("tls.client-ca: %v", err)
+		if prev := current.Load(); prev != nil {
+			pool = prev.pool
+		} else {
+			pool = x509.NewCertPool()
+		}
+	}
+	mode := tls.RequireAndVerifyClientCert
+	if cfg.TLS.ClientAuth == config.TLSClientAuthOptional {
+		mode = tls.VerifyClientCertIfGiven
+	}
+	current.Store(&verifier{pool: pool, mode: mode})
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeClientCert, defaultProvider)
+}
+
+// ServerTLSConfig loads the server certificate and returns the TLS settings for the
+// HTTPS listener. Client certificate verification follows the settings last passed to
+// Register.
+func ServerTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+	certificates := []tls.Certificate{cert}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: certificates,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			v := current.Load()
+			if v == nil {
+				// Returning nil keeps the base config (no client certificates).
+				return nil, nil
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: certificates,
+				NextProtos:   []string{"h2", "http/1.1"},
+				ClientAuth:   v.mode,
+				ClientCAs:    v.pool,
+			}, nil
+		},
+	}, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("client CA bundle contains no PEM certificates")
+	}
+	return pool, nil
+}
+
+var defaultProvider = &provider{}
+
+type provider struct{}
+
+func (p *provider) Identifier() string {
+	return sdkaccess.AccessProviderTypeClientCert
+}
+
+// Authenticate accepts requests whose TLS connection carries a verified client
+// certificate. The certificate subject becomes the principal.
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	subject := leaf.Subject.String()
+	if subject == "" {
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	identity := leaf.Subject.CommonName
+	if identity == "" {
+		identity = subject
+	}
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: subject,
+		Metadata: map[string]string{
+			"source":                   "tls-client-cert",
+			"cert-serial":              leaf.SerialNumber.Text(16),
+			"cert-issuer":              leaf.Issuer.String(),
+			sdkaccess.MetadataIdentity: identity,
+		},
+	}, nil
+}
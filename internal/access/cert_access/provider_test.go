@@ -0,0 +1,126 @@
+package certaccess
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issue(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerCert := key, template
+	if parent != nil {
+		signer, signerCert = parent.key, parent.cert
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestClientCertificatePrincipal(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "test ca"}, IsCA: true,
+		BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	server := issue(t, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, ca)
+	client := issue(t, &x509.Certificate{SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "build-agent", Organization: []string{"Corp"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, ca)
+
+	caPath := filepath.Join(dir, "ca.pem")
+	writePEM(t, caPath, "CERTIFICATE", ca.der)
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	writePEM(t, certPath, "CERTIFICATE", server.der)
+	serverKey, _ := x509.MarshalECPrivateKey(server.key)
+	writePEM(t, keyPath, "EC PRIVATE KEY", serverKey)
+
+	cfg := &config.Config{TLS: config.TLSConfig{Enable: true, Cert: certPath, Key: keyPath, ClientCA: caPath, ClientAuth: config.TLSClientAuthRequire}}
+	Register(cfg)
+	defer Register(nil)
+
+	tlsConfig, err := ServerTLSConfig(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("server tls config: %v", err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, authErr := defaultProvider.Authenticate(r.Context(), r)
+		if authErr != nil {
+			http.Error(w, authErr.Message, authErr.HTTPStatusCode())
+			return
+		}
+		_, _ = io.WriteString(w, result.Principal+"|"+result.Metadata[sdkaccess.MetadataIdentity])
+	})}
+	go func() { _ = srv.Serve(listener) }()
+	defer func() { _ = srv.Close() }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certs []tls.Certificate) (string, error) {
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+		resp, err := httpClient.Get("https://" + listener.Addr().String() + "/")
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), nil
+	}
+
+	body, err := get([]tls.Certificate{{Certificate: [][]byte{client.der}, PrivateKey: client.key}})
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	if body != "CN=build-agent,O=Corp|build-agent" {
+		t.Fatalf("principal = %q", body)
+	}
+	if _, err = get(nil); err == nil {
+		t.Fatalf("request without client certificate succeeded")
+	}
+
+	// Optional mode accepts connections without a certificate; the provider then reports no credentials.
+	cfg.TLS.ClientAuth = config.TLSClientAuthOptional
+	Register(cfg)
+	if body, err = get(nil); err != nil || body != "Missing API key\n" {
+		t.Fatalf("optional mode without certificate: %q, %v", body, err)
+	}
+}
@@ -2,8 +2,11 @@ package configaccess
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
 	"net/http"
 	"strings"
+	"time"
 
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -28,21 +31,29 @@ func Register(cfg *sdkconfig.SDKConfig) {
 	)
 }
 
+// keyEntry is an accepted client key. Presented keys are hashed and compared
+// against digest, so hashed and literal config entries are checked the same way.
+type keyEntry struct {
+	digest []byte
+	// principal identifies the key downstream: the literal key, or the stored
+	// "sha256:..." digest for hashed keys.
+	principal string
+	expiresAt time.Time
+	tenant    string
+}
+
 type provider struct {
 	name string
-	keys map[string]struct{}
+	keys []keyEntry
+	now  func() time.Time
 }
 
-func newProvider(name string, keys []string) *provider {
+func newProvider(name string, keys []keyEntry) *provider {
 	providerName := strings.TrimSpace(name)
 	if providerName == "" {
 		providerName = sdkaccess.DefaultAccessProviderName
 	}
-	keySet := make(map[string]struct{}, len(keys))
-	for _, key := range keys {
-		keySet[key] = struct{}{}
-	}
-	return &provider{name: providerName, keys: keySet}
+	return &provider{name: providerName, keys: keys, now: time.Now}
 }
 
 func (p *provider) Identifier() string {
@@ -85,17 +96,26 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		{queryAuthToken, "query-auth-token"},
 	}
 
+	now := p.now()
 	for _, candidate := range candidates {
 		if candidate.value == "" {
 			continue
 		}
-		if _, ok := p.keys[candidate.value]; ok {
+		if entry := p.match(candidate.value); entry != nil {
+			// Expired keys are deactivated automatically.
+			if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+				return nil, sdkaccess.NewInvalidCredentialError()
+			}
+			metadata := map[string]string{
+				"source": candidate.source,
+			}
+			if entry.tenant != "" {
+				metadata[sdkaccess.MetadataTenant] = entry.tenant
+			}
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
-				Principal: candidate.value,
-				Metadata: map[string]string{
-					"source": candidate.source,
-				},
+				Principal: entry.principal,
+				Metadata:  metadata,
 			}, nil
 		}
 	}
@@ -103,6 +123,18 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	return nil, sdkaccess.NewInvalidCredentialError()
 }
 
+// match compares the digest of value against every configured key in constant time.
+func (p *provider) match(value string) *keyEntry {
+	sum := sha256.Sum256([]byte(value))
+	var found *keyEntry
+	for i := range p.keys {
+		if subtle.ConstantTimeCompare(sum[:], p.keys[i].digest) == 1 && found == nil {
+			found = &p.keys[i]
+		}
+	}
+	return found
+}
+
 func extractBearerToken(header string) string {
 	if header == "" {
 		return ""
@@ -117,13 +149,14 @@ func extractBearerToken(header string) string {
 	return strings.TrimSpace(parts[1])
 }
 
-func normalizeKeys(entries []sdkconfig.ApiKeyEntry) []string {
+func normalizeKeys(entries []sdkconfig.ApiKeyEntry) []keyEntry {
 	if len(entries) == 0 {
 		return nil
 	}
-	normalized := make([]string, 0, len(entries))
+	normalized := make([]keyEntry, 0, len(entries))
 	seen := make(map[string]struct{}, len(entries))
-	for _, entry := range entries {
+	for i := range entries {
+		entry := &entries[i]
 		if !entry.IsActive {
 			continue
 		}
@@ -135,7 +168,12 @@ func normalizeKeys(entries []sdkconfig.ApiKeyEntry) []string {
 			continue
 		}
 		seen[trimmedKey] = struct{}{}
-		normalized = append(normalized, trimmedKey)
+		normalized = append(normalized, keyEntry{
+			digest:    entry.APIKeyDigest(),
+			principal: trimmedKey,
+			expiresAt: entry.ExpiresAtTime(),
+			tenant:    strings.TrimSpace(entry.Tenant),
+		})
 	}
 	if len(normalized) == 0 {
 		return nil
@@ -0,0 +1,49 @@
+package configaccess
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestProviderHashedAndExpiringKeys(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	p := newProvider("", normalizeKeys([]sdkconfig.ApiKeyEntry{
+		{Key: "literal-key", IsActive: true},
+		{Key: config.HashAPIKey("cpk_abcdefgh_secret"), Prefix: "cpk_abcdefgh", IsActive: true},
+		{Key: "expired-key", IsActive: true, ExpiresAt: now.Add(-time.Minute).Format(time.RFC3339)},
+		{Key: "future-key", IsActive: true, ExpiresAt: now.Add(time.Hour).Format(time.RFC3339)},
+		{Key: "disabled-key"},
+	}))
+	p.now = func() time.Time { return now }
+
+	cases := []struct {
+		key       string
+		principal string
+	}{
+		{"literal-key", "literal-key"},
+		{"cpk_abcdefgh_secret", config.HashAPIKey("cpk_abcdefgh_secret")},
+		{"future-key", "future-key"},
+		{"expired-key", ""},
+		{"disabled-key", ""},
+		{config.HashAPIKey("cpk_abcdefgh_secret"), ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/v1/models", nil)
+		req.Header.Set("Authorization", "Bearer "+tc.key)
+		result, authErr := p.Authenticate(context.Background(), req)
+		if tc.principal == "" {
+			if authErr == nil {
+				t.Fatalf("%q: accepted, want rejection", tc.key)
+			}
+			continue
+		}
+		if authErr != nil || result == nil || result.Principal != tc.principal {
+			t.Fatalf("%q: result %+v, err %v", tc.key, result, authErr)
+		}
+	}
+}
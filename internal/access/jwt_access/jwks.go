@@ -0,0 +1,254 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	maxJWKSSize = 1 << 20
+	// minJWKSRefresh bounds how often an unknown key ID may trigger a refetch.
+	minJWKSRefresh = 30 * time.Second
+)
+
+// jwk is a single public key from a JSON Web Key Set.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet caches the keys of a JWKS loaded from a URL or file.
+type keySet struct {
+	url    string
+	file   string
+	ttl    time.Duration
+	client *http.Client
+
+	// fetch collapses concurrent refreshes into one request; mu only guards the cache
+	// so lookups with known keys never wait on the network.
+	fetch singleflight.Group
+
+	mu        sync.Mutex
+	keys      []publicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(url, file string, ttl time.Duration) *keySet {
+	return &keySet{
+		url:    url,
+		file:   file,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// lookup returns the candidate keys for kid, refreshing the set when it is stale or
+// when kid is unknown and the last refresh is old enough.
+func (s *keySet) lookup(ctx context.Context, kid string) ([]publicKey, error) {
+	matches, fetchedAt, cached := s.match(kid)
+	if fetchedAt.IsZero() || time.Since(fetchedAt) >= s.ttl {
+		if err := s.refresh(ctx, fetchedAt); err != nil && cached == 0 {
+			return nil, err
+		}
+		matches, fetchedAt, _ = s.match(kid)
+	}
+	if len(matches) == 0 && kid != "" && time.Since(fetchedAt) >= minJWKSRefresh {
+		if err := s.refresh(ctx, fetchedAt); err != nil {
+			return nil, err
+		}
+		matches, _, _ = s.match(kid)
+	}
+	return matches, nil
+}
+
+// match returns the cached keys for kid (all keys when kid is empty), the time of the
+// last refresh attempt and the number of cached keys.
+func (s *keySet) match(kid string) ([]publicKey, time.Time, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kid == "" {
+		return s.keys, s.fetchedAt, len(s.keys)
+	}
+	var out []publicKey
+	for _, k := range s.keys {
+		if k.kid == kid {
+			out = append(out, k)
+		}
+	}
+	return out, s.fetchedAt, len(s.keys)
+}
+
+// refresh reloads the key set unless another caller already did so after seen.
+func (s *keySet) refresh(ctx context.Context, seen time.Time) error {
+	_, err, _ := s.fetch.Do("jwks", func() (any, error) {
+		s.mu.Lock()
+		done := s.fetchedAt.After(seen)
+		s.mu.Unlock()
+		if done {
+			return nil, nil
+		}
+
+		// The fetch is shared with other waiting requests, so one client going away
+		// must not cancel it for the rest.
+		data, err := s.read(context.WithoutCancel(ctx))
+		var keys []publicKey
+		if err == nil {
+			keys, err = parseJWKS(data)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// Record the attempt even on failure so an unreachable endpoint is not hammered.
+		s.fetchedAt = time.Now()
+		if err != nil {
+			return nil, err
+		}
+		s.keys = keys
+		return nil, nil
+	})
+	return err
+}
+
+func (s *keySet) read(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file: %w", err)
+		}
+		return data, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build jwks request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("read jwks: %w", err)
+	}
+	return data, nil
+}
+
+// parseJWKS decodes the signing keys of a JWKS document. Keys with an unsupported
+// type or a non-signature use are skipped.
+func parseJWKS(data []byte) ([]publicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	keys := make([]publicKey, 0, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, publicKey{kid: k.Kid, alg: k.Alg, key: pub})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
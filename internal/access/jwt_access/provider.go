@@ -0,0 +1,418 @@
+// Package jwtaccess implements the built-in "jwt" access provider, which authenticates
+// clients with JWT/OIDC bearer tokens verified against a JSON Web Key Set.
+package jwtaccess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultPrincipalClaim = "sub"
+	defaultJWKSCacheTTL   = time.Hour
+	defaultLeeway         = time.Minute
+)
+
+// settings is the provider-specific config block of an access provider entry.
+type settings struct {
+	Issuer   string     `json:"issuer"`
+	Audience stringList `json:"audience"`
+	JWKSURL  string     `json:"jwks-url"`
+	JWKSFile string     `json:"jwks-file"`
+	// JWKSCacheSeconds is how long fetched keys are reused; <= 0 uses one hour.
+	JWKSCacheSeconds int        `json:"jwks-cache-seconds"`
+	Algorithms       stringList `json:"algorithms"`
+	// LeewaySeconds tolerates clock skew on exp/nbf/iat; < 0 disables it, 0 uses 60.
+	LeewaySeconds int `json:"leeway-seconds"`
+	// PrincipalClaim selects the claim (gjson path) used as the request principal.
+	PrincipalClaim string `json:"principal-claim"`
+	// IdentityClaim selects a display identity for usage records; defaults to the principal.
+	IdentityClaim string `json:"identity-claim"`
+	// MetadataClaims are copied into the access result metadata under their path name.
+	MetadataClaims stringList `json:"metadata-claims"`
+	// ModelsClaim names a claim listing the models the client may use.
+	ModelsClaim string `json:"models-claim"`
+	// AllowedModels restricts every client of this provider. When ModelsClaim is also
+	// present, a claimed model must match this list as well.
+	AllowedModels stringList `json:"allowed-models"`
+	// Tenant assigns every client of this provider to a tenant.
+	Tenant string `json:"tenant"`
+	// TenantClaim names a claim holding the client's tenant; Tenant takes precedence.
+	TenantClaim string `json:"tenant-claim"`
+}
+
+// stringList accepts either a single string or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = splitList(single)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+var (
+	registeredMu sync.Mutex
+	registered   = make(map[string]*provider)
+)
+
+// Register creates, updates or removes jwt providers to match cfg.Access.Providers.
+// Providers whose settings are unchanged are kept so their JWKS cache survives reloads.
+func Register(cfg *sdkconfig.SDKConfig) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	seen := make(map[string]struct{})
+	if cfg != nil {
+		for i := range cfg.Access.Providers {
+			entry := &cfg.Access.Providers[i]
+			if !strings.EqualFold(strings.TrimSpace(entry.Type), sdkaccess.AccessProviderTypeJWT) {
+				continue
+			}
+			name := strings.TrimSpace(entry.Name)
+			if name == "" {
+				name = sdkaccess.AccessProviderTypeJWT
+			}
+			key := registryKey(name)
+			if _, dup := seen[key]; dup {
+				log.Warnf("jwt access provider %q is defined more than once; ignoring duplicate", name)
+				continue
+			}
+			opts, err := parseSettings(entry.Config)
+			if err != nil {
+				log.Errorf("jwt access provider %q: %v", name, err)
+				continue
+			}
+			seen[key] = struct{}{}
+			if existing := registered[key]; existing != nil && reflect.DeepEqual(existing.opts, opts) {
+				sdkaccess.RegisterProvider(key, existing)
+				continue
+			}
+			p := newProvider(name, opts)
+			registered[key] = p
+			sdkaccess.RegisterProvider(key, p)
+		}
+	}
+	for key := range registered {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		delete(registered, key)
+		sdkaccess.UnregisterProvider(key)
+	}
+}
+
+func registryKey(name string) string {
+	return sdkaccess.AccessProviderTypeJWT + ":" + name
+}
+
+func parseSettings(raw map[string]any) (settings, error) {
+	var opts settings
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return opts, fmt.Errorf("invalid config: %w", err)
+	}
+	if err = json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("invalid config: %w", err)
+	}
+	opts.Issuer = strings.TrimSpace(opts.Issuer)
+	opts.JWKSURL = strings.TrimSpace(opts.JWKSURL)
+	opts.JWKSFile = strings.TrimSpace(opts.JWKSFile)
+	if (opts.JWKSURL == "") == (opts.JWKSFile == "") {
+		return opts, errors.New("exactly one of jwks-url or jwks-file is required")
+	}
+	// Without an issuer or audience check any token signed by the same keys would be
+	// accepted, including tokens the IdP issued for unrelated applications.
+	if opts.Issuer == "" && len(opts.Audience) == 0 {
+		return opts, errors.New("at least one of issuer or audience is required")
+	}
+	if strings.TrimSpace(opts.PrincipalClaim) == "" {
+		opts.PrincipalClaim = defaultPrincipalClaim
+	}
+	for _, alg := range opts.Algorithms {
+		if !containsString(supportedAlgorithms, alg) {
+			return opts, fmt.Errorf("unsupported algorithm %q", alg)
+		}
+	}
+	if len(opts.Algorithms) == 0 {
+		opts.Algorithms = supportedAlgorithms
+	}
+	return opts, nil
+}
+
+type provider struct {
+	name string
+	opts settings
+	keys *keySet
+	now  func() time.Time
+}
+
+func newProvider(name string, opts settings) *provider {
+	ttl := defaultJWKSCacheTTL
+	if opts.JWKSCacheSeconds > 0 {
+		ttl = time.Duration(opts.JWKSCacheSeconds) * time.Second
+	}
+	return &provider{
+		name: name,
+		opts: opts,
+		keys: newKeySet(opts.JWKSURL, opts.JWKSFile, ttl),
+		now:  time.Now,
+	}
+}
+
+func (p *provider) Identifier() string {
+	if p == nil || p.name == "" {
+		return sdkaccess.AccessProviderTypeJWT
+	}
+	return p.name
+}
+
+// Authenticate validates a bearer JWT. Requests without a JWT-shaped bearer token are
+// left to other providers so API keys keep working alongside tokens.
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	token = strings.TrimSpace(token)
+	if !looksLikeJWT(token) {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+
+	claims, err := p.verify(ctx, token)
+	if err != nil {
+		log.Debugf("jwt access provider %q rejected token: %v", p.name, err)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	principal := claimString(claims, p.opts.PrincipalClaim)
+	if principal == "" {
+		log.Debugf("jwt access provider %q: token has no %q claim", p.name, p.opts.PrincipalClaim)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	metadata := map[string]string{
+		"source":  "authorization",
+		"subject": claims.Get("sub").String(),
+		"issuer":  claims.Get("iss").String(),
+	}
+	identity := principal
+	if p.opts.IdentityClaim != "" {
+		if v := claimString(claims, p.opts.IdentityClaim); v != "" {
+			identity = v
+		}
+	}
+	metadata[sdkaccess.MetadataIdentity] = identity
+	for _, path := range p.opts.MetadataClaims {
+		if v := claimString(claims, path); v != "" {
+			metadata[path] = v
+		}
+	}
+	if models, restricted := p.allowedModels(claims); restricted {
+		metadata[sdkaccess.MetadataAllowedModels] = strings.Join(models, ",")
+	}
+	// The tenant only comes from the dedicated settings, never from metadata-claims.
+	delete(metadata, sdkaccess.MetadataTenant)
+	tenant := strings.TrimSpace(p.opts.Tenant)
+	if tenant == "" && p.opts.TenantClaim != "" {
+		tenant = claimString(claims, p.opts.TenantClaim)
+	}
+	if tenant != "" {
+		metadata[sdkaccess.MetadataTenant] = tenant
+	}
+
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principal,
+		Metadata:  metadata,
+	}, nil
+}
+
+// verify checks the signature and the registered claims and returns the payload.
+func (p *provider) verify(ctx context.Context, token string) (gjson.Result, error) {
+	tok, err := parseToken(token)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	if !containsString(p.opts.Algorithms, tok.header.Alg) {
+		return gjson.Result{}, fmt.Errorf("algorithm %q not allowed", tok.header.Alg)
+	}
+	candidates, err := p.keys.lookup(ctx, tok.header.Kid)
+	if err != nil {
+		log.Warnf("jwt access provider %q: %v", p.name, err)
+		return gjson.Result{}, err
+	}
+	verified := false
+	for _, key := range candidates {
+		if key.alg != "" && key.alg != tok.header.Alg {
+			continue
+		}
+		if verifySignature(tok, key.key) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return gjson.Result{}, errors.New("signature verification failed")
+	}
+
+	claims := gjson.ParseBytes(tok.payload)
+	if err = p.checkClaims(claims); err != nil {
+		return gjson.Result{}, err
+	}
+	return claims, nil
+}
+
+func (p *provider) checkClaims(claims gjson.Result) error {
+	now := p.now()
+	leeway := defaultLeeway
+	if p.opts.LeewaySeconds > 0 {
+		leeway = time.Duration(p.opts.LeewaySeconds) * time.Second
+	} else if p.opts.LeewaySeconds < 0 {
+		leeway = 0
+	}
+
+	exp := claims.Get("exp")
+	if !exp.Exists() {
+		return errors.New("token has no exp claim")
+	}
+	if !now.Before(time.Unix(exp.Int(), 0).Add(leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf := claims.Get("nbf"); nbf.Exists() && now.Add(leeway).Before(time.Unix(nbf.Int(), 0)) {
+		return errors.New("token not yet valid")
+	}
+	if iat := claims.Get("iat"); iat.Exists() && now.Add(leeway).Before(time.Unix(iat.Int(), 0)) {
+		return errors.New("token issued in the future")
+	}
+	if p.opts.Issuer != "" && claims.Get("iss").String() != p.opts.Issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Get("iss").String())
+	}
+	if len(p.opts.Audience) > 0 {
+		aud := claims.Get("aud")
+		var audiences []string
+		if aud.IsArray() {
+			for _, v := range aud.Array() {
+				audiences = append(audiences, v.String())
+			}
+		} else if aud.Exists() {
+			audiences = []string{aud.String()}
+		}
+		matched := false
+		for _, want := range p.opts.Audience {
+			if containsString(audiences, want) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.New("audience mismatch")
+		}
+	}
+	return nil
+}
+
+// allowedModels combines the provider-wide allow-list with the models claim. The
+// second result is false when neither restricts the client.
+func (p *provider) allowedModels(claims gjson.Result) ([]string, bool) {
+	var fromClaim []string
+	claimRestricts := false
+	if p.opts.ModelsClaim != "" {
+		if v := claims.Get(p.opts.ModelsClaim); v.Exists() {
+			claimRestricts = true
+			fromClaim = claimValues(v)
+		}
+	}
+	switch {
+	case claimRestricts && len(p.opts.AllowedModels) > 0:
+		// Keep only claimed models the provider-wide list also permits.
+		allowed := make([]string, 0, len(fromClaim))
+		providerWide := map[string]string{sdkaccess.MetadataAllowedModels: strings.Join(p.opts.AllowedModels, ",")}
+		for _, model := range fromClaim {
+			if sdkaccess.ModelAllowed(providerWide, model) {
+				allowed = append(allowed, model)
+			}
+		}
+		return allowed, true
+	case claimRestricts:
+		return fromClaim, true
+	case len(p.opts.AllowedModels) > 0:
+		return p.opts.AllowedModels, true
+	default:
+		return nil, false
+	}
+}
+
+// claimString renders a claim as a string; arrays are joined with commas.
+func claimString(claims gjson.Result, path string) string {
+	v := claims.Get(path)
+	if !v.Exists() {
+		return ""
+	}
+	if v.IsArray() {
+		return strings.Join(claimValues(v), ",")
+	}
+	return strings.TrimSpace(v.String())
+}
+
+// claimValues returns the values of an array claim, or the space/comma separated
+// parts of a string claim (as used by "scope").
+func claimValues(v gjson.Result) []string {
+	if v.IsArray() {
+		var out []string
+		for _, item := range v.Array() {
+			if s := strings.TrimSpace(item.String()); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	if v.Type == gjson.String {
+		return splitList(v.String())
+	}
+	if s := strings.TrimSpace(v.String()); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	sum := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return input + "." + b64(sig)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	sum := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + b64(sig)
+}
+
+func authenticate(p *provider, token string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return p.Authenticate(context.Background(), req)
+}
+
+func TestJWTProviderValidatesClaims(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}})
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksPath, jwks, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	opts, err := parseSettings(map[string]any{
+		"issuer":          "https://idp.example.com",
+		"audience":        "cliproxy",
+		"jwks-file":       jwksPath,
+		"principal-claim": "email",
+		"metadata-claims": []any{"groups"},
+		"models-claim":    "models",
+		"allowed-models":  "gemini-*, claude-*",
+	})
+	if err != nil {
+		t.Fatalf("parse settings: %v", err)
+	}
+	p := newProvider("corp", opts)
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":    "https://idp.example.com",
+			"aud":    []string{"other", "cliproxy"},
+			"sub":    "user-1",
+			"email":  "alice@example.com",
+			"groups": []string{"eng", "ml"},
+			"models": "gemini-2.5-pro gpt-4o",
+			"exp":    now.Add(time.Hour).Unix(),
+			"iat":    now.Add(-time.Minute).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	result, authErr := authenticate(p, signRS256(t, rsaKey, "rsa-1", claims(nil)))
+	if authErr != nil {
+		t.Fatalf("valid token rejected: %v", authErr)
+	}
+	if result.Provider != "corp" || result.Principal != "alice@example.com" || result.Metadata["groups"] != "eng,ml" ||
+		result.Metadata[sdkaccess.MetadataIdentity] != "alice@example.com" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	// gpt-4o is claimed but outside the provider-wide allow-list.
+	if got := result.Metadata[sdkaccess.MetadataAllowedModels]; got != "gemini-2.5-pro" {
+		t.Fatalf("allowed models = %q", got)
+	}
+	if !sdkaccess.ModelAllowed(result.Metadata, "gemini-2.5-pro(8192)") || sdkaccess.ModelAllowed(result.Metadata, "gpt-4o") {
+		t.Fatalf("model allow-list not applied")
+	}
+
+	if _, authErr = authenticate(p, signES256(t, ecKey, "ec-1", claims(nil))); authErr != nil {
+		t.Fatalf("valid ES256 token rejected: %v", authErr)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rejected := map[string]string{
+		"expired":       signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})),
+		"no exp":        signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"exp": nil})),
+		"not yet valid": signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"nbf": now.Add(5 * time.Minute).Unix()})),
+		"wrong issuer":  signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"iss": "https://evil.example.com"})),
+		"wrong aud":     signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"aud": "someone-else"})),
+		"no principal":  signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"email": nil})),
+		"bad signature": signRS256(t, otherKey, "rsa-1", claims(nil)),
+	}
+	for name, token := range rejected {
+		if _, authErr = authenticate(p, token); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Fatalf("%s: got %v, want invalid credential", name, authErr)
+		}
+	}
+
+	// API keys are left to other providers.
+	if _, authErr = authenticate(p, "sk-plain-api-key"); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("api key: got %v, want not handled", authErr)
+	}
+}
+
+func TestJWTProviderFetchesJWKSFromURL(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "k1", "alg": "RS256", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())},
+		}})
+	}))
+	defer srv.Close()
+
+	opts, err := parseSettings(map[string]any{"issuer": "https://idp.example.com", "jwks-url": srv.URL, "algorithms": []any{"RS256"}})
+	if err != nil {
+		t.Fatalf("parse settings: %v", err)
+	}
+	p := newProvider("oidc", opts)
+	token := signRS256(t, key, "k1", map[string]any{"iss": "https://idp.example.com", "sub": "svc", "exp": time.Now().Add(time.Hour).Unix()})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, authErr := authenticate(p, token); authErr != nil || result.Principal != "svc" {
+				t.Errorf("authenticate: %+v %v", result, authErr)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 3; i++ {
+		if result, authErr := authenticate(p, token); authErr != nil || result.Principal != "svc" {
+			t.Fatalf("authenticate: %+v %v", result, authErr)
+		}
+	}
+	// Unknown key IDs do not trigger a refetch within the minimum refresh interval.
+	if _, authErr := authenticate(p, signRS256(t, key, "k2", map[string]any{"iss": "https://idp.example.com", "sub": "svc", "exp": time.Now().Add(time.Hour).Unix()})); authErr == nil {
+		t.Fatalf("token with unknown kid accepted")
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", n)
+	}
+
+	if _, err = parseSettings(map[string]any{"issuer": "x"}); err == nil {
+		t.Fatalf("settings without a key source accepted")
+	}
+	if _, err = parseSettings(map[string]any{"jwks-url": srv.URL}); err == nil {
+		t.Fatalf("settings without issuer or audience accepted")
+	}
+}
@@ -0,0 +1,519 @@
+package management
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secretref"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// accountTokenPrefix marks management account tokens: cpam_<account id>_<secret>.
+const accountTokenPrefix = "cpam_"
+
+// principalContextKey stores the authenticated *Principal on the gin context.
+const principalContextKey = "managementPrincipal"
+
+// Permission is a management route group an account may access.
+type Permission string
+
+const (
+	// PermissionRead covers read-only routes that expose no secrets.
+	PermissionRead Permission = "read"
+	// PermissionKeys covers client API key routes.
+	PermissionKeys Permission = "keys"
+	// PermissionCredentials covers auth files, provider keys and OAuth logins.
+	PermissionCredentials Permission = "credentials"
+	// PermissionAdmin covers configuration, backups, accounts and everything else.
+	PermissionAdmin Permission = "admin"
+)
+
+var rolePermissions = map[string][]Permission{
+	config.ManagementRoleViewer:            {PermissionRead},
+	config.ManagementRoleKeyManager:        {PermissionRead, PermissionKeys},
+	config.ManagementRoleCredentialManager: {PermissionRead, PermissionCredentials},
+	config.ManagementRoleAdmin:             {PermissionRead, PermissionKeys, PermissionCredentials, PermissionAdmin},
+}
+
+// Principal identifies who authenticated a management request.
+type Principal struct {
+	// AccountID is empty for the secret key, MANAGEMENT_PASSWORD and the local password.
+	AccountID string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	// Tenant limits the principal to one tenant's resources; empty for global principals.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Allows reports whether the principal's role grants perm.
+func (p *Principal) Allows(perm Permission) bool {
+	if p == nil {
+		return false
+	}
+	for _, granted := range rolePermissions[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists the permissions granted to the principal's role.
+func (p *Principal) Permissions() []Permission {
+	if p == nil {
+		return nil
+	}
+	return append([]Permission(nil), rolePermissions[p.Role]...)
+}
+
+var adminPrincipal = &Principal{Name: "admin", Role: config.ManagementRoleAdmin}
+
+// PrincipalFromContext returns the principal set by Middleware, if any.
+func PrincipalFromContext(c *gin.Context) *Principal {
+	if v, ok := c.Get(principalContextKey); ok {
+		if p, ok := v.(*Principal); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// routes requiring admin regardless of method, matched by prefix.
+var adminRoutePrefixes = []string{
+	"/accounts",
+	"/audit-log",
+	"/config.yaml",
+	"/config/versions",
+	"/backups",
+	"/webui/update",
+	"/api-call",
+	"/usage/import",
+}
+
+// client API key routes, matched exactly.
+var keyRoutes = map[string]struct{}{
+	"/api-keys":                  {},
+	"/api-keys/usage":            {},
+	"/api-keys/rotate":           {},
+	"/ampcode":                   {},
+	"/ampcode/upstream-api-key":  {},
+	"/ampcode/upstream-api-keys": {},
+}
+
+// credential routes, matched exactly; reads are included because they reveal secrets.
+var credentialRoutes = map[string]struct{}{
+	"/gemini-api-key":        {},
+	"/claude-api-key":        {},
+	"/codex-api-key":         {},
+	"/vertex-api-key":        {},
+	"/bedrock-api-key":       {},
+	"/azure-openai":          {},
+	"/local-providers":       {},
+	"/oauth-providers":       {},
+	"/openai-compatibility":  {},
+	"/oauth-excluded-models": {},
+	"/oauth-model-alias":     {},
+	"/auth-files/download":   {},
+	"/vertex/import":         {},
+	"/oauth-callback":        {},
+	"/get-auth-status":       {},
+}
+
+// requiredPermission maps a management route (path relative to /v0/management) and method
+// to the permission needed to call it. Unlisted reads need PermissionRead and unlisted writes
+// need PermissionAdmin, so new routes default to the safe side.
+func requiredPermission(method, route string) Permission {
+	for _, prefix := range adminRoutePrefixes {
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return PermissionAdmin
+		}
+	}
+	if _, ok := keyRoutes[route]; ok {
+		return PermissionKeys
+	}
+	if _, ok := credentialRoutes[route]; ok {
+		return PermissionCredentials
+	}
+	if strings.HasSuffix(route, "-auth-url") {
+		return PermissionCredentials
+	}
+	if route == "/auth-files" || strings.HasPrefix(route, "/auth-files/") {
+		if method == http.MethodGet {
+			return PermissionRead
+		}
+		return PermissionCredentials
+	}
+	if route == "/whoami" {
+		return PermissionRead
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return PermissionRead
+	}
+	return PermissionAdmin
+}
+
+// managementRoute returns the matched route pattern relative to the management group.
+func managementRoute(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return strings.TrimPrefix(route, "/v0/management")
+}
+
+// disallowedSecretReference returns the first secret reference in a JSON write body
+// that is neither already in the config nor matched by
+// remote-management.allowed-secret-references. References make the server load a
+// secret of its choosing on reload, so accounts below admin may only write those.
+// Auth files are exempt because their values are never resolved.
+func (h *Handler) disallowedSecretReference(c *gin.Context) string {
+	switch c.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return ""
+	}
+	if c.Request.Body == nil || strings.HasPrefix(managementRoute(c), "/auth-files") {
+		return ""
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	_ = c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil || !gjson.ValidBytes(body) {
+		return ""
+	}
+	var allowed []string
+	existing := make(map[string]struct{})
+	if h.cfg != nil {
+		allowed = h.cfg.RemoteManagement.AllowedSecretReferences
+		for _, ref := range h.cfg.SecretReferences() {
+			existing[ref.Reference] = struct{}{}
+		}
+	}
+	var found string
+	var walk func(value gjson.Result)
+	walk = func(value gjson.Result) {
+		if found != "" {
+			return
+		}
+		if value.IsObject() || value.IsArray() {
+			value.ForEach(func(_, child gjson.Result) bool {
+				walk(child)
+				return found == ""
+			})
+			return
+		}
+		if value.Type != gjson.String || !secretref.IsReference(value.String()) {
+			return
+		}
+		ref := strings.TrimSpace(value.String())
+		if _, ok := existing[ref]; ok || secretref.Allowed(ref, allowed) {
+			return
+		}
+		found = ref
+	}
+	walk(gjson.ParseBytes(body))
+	return found
+}
+
+// authenticateAccount resolves an account token. The boolean reports whether the token
+// had the account format at all, so callers can fall back to the shared secrets.
+func authenticateAccount(cfg *config.Config, token string) (*Principal, bool) {
+	rest, ok := strings.CutPrefix(token, accountTokenPrefix)
+	if !ok || cfg == nil {
+		return nil, ok
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return nil, true
+	}
+	for _, account := range cfg.RemoteManagement.Accounts {
+		if account.ID != id {
+			continue
+		}
+		if account.Disabled || bcrypt.CompareHashAndPassword([]byte(account.TokenHash), []byte(token)) != nil {
+			return nil, true
+		}
+		return &Principal{AccountID: account.ID, Name: account.Name, Role: account.Role, Tenant: account.Tenant}, true
+	}
+	return nil, true
+}
+
+// WhoAmI returns the authenticated principal and its permissions.
+func (h *Handler) WhoAmI(c *gin.Context) {
+	p := PrincipalFromContext(c)
+	if p == nil {
+		p = adminPrincipal
+	}
+	c.JSON(http.StatusOK, gin.H{"principal": p, "permissions": p.Permissions()})
+}
+
+// ListAccounts returns the management accounts without their token hashes.
+func (h *Handler) ListAccounts(c *gin.Context) {
+	accounts := h.cfg.RemoteManagement.Accounts
+	if accounts == nil {
+		accounts = []config.ManagementAccount{}
+	}
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// CreateAccount adds a management account and returns its token. The token is only
+// shown in this response; the config stores its bcrypt hash.
+func (h *Handler) CreateAccount(c *gin.Context) {
+	var body struct {
+		Name   string `json:"name"`
+		Role   string `json:"role"`
+		Tenant string `json:"tenant"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	role, ok := config.NormalizeManagementRole(body.Role)
+	if name == "" || !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and a role of viewer, key-manager, credential-manager or admin are required"})
+		return
+	}
+	for _, account := range h.cfg.RemoteManagement.Accounts {
+		if strings.EqualFold(account.Name, name) {
+			c.JSON(http.StatusConflict, gin.H{"error": "account name already exists"})
+			return
+		}
+	}
+
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(idBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	account := config.ManagementAccount{
+		ID:        hex.EncodeToString(idBytes),
+		Name:      name,
+		Role:      role,
+		Tenant:    strings.TrimSpace(body.Tenant),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	token := accountTokenPrefix + account.ID + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	hashed, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash token"})
+		return
+	}
+	account.TokenHash = string(hashed)
+
+	h.mu.Lock()
+	h.cfg.RemoteManagement.Accounts = append(h.cfg.RemoteManagement.Accounts, account)
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.RemoteManagement.Accounts = h.cfg.RemoteManagement.Accounts[:len(h.cfg.RemoteManagement.Accounts)-1]
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save config: " + errSave.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"account": account, "token": token})
+}
+
+// DeleteAccount revokes a management account by ID.
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	h.mu.Lock()
+	accounts := h.cfg.RemoteManagement.Accounts
+	kept := make([]config.ManagementAccount, 0, len(accounts))
+	for _, account := range accounts {
+		if account.ID != id {
+			kept = append(kept, account)
+		}
+	}
+	if len(kept) == len(accounts) {
+		h.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	h.cfg.RemoteManagement.Accounts = kept
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.RemoteManagement.Accounts = accounts
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save config: " + errSave.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// secretJSONPaths lists config JSON paths that may hold secrets or secret references.
+var secretJSONPaths = []string{
+	"api-keys.#.api-key",
+	"gemini-api-key.#.api-key",
+	"claude-api-key.#.api-key",
+	"codex-api-key.#.api-key",
+	"vertex-api-key.#.api-key",
+	"bedrock-api-key.#.access-key-id",
+	"bedrock-api-key.#.secret-access-key",
+	"bedrock-api-key.#.session-token",
+	"azure-openai.#.api-key",
+	"azure-openai.#.entra.client-secret",
+	"local-providers.#.api-key",
+	"oauth-providers.#.client-secret",
+	"openai-compatibility.#.api-key-entries.#.api-key",
+	"ampcode.upstream-api-key",
+	"ampcode.upstream-api-keys.#.upstream-api-key",
+	"credential-bundles.signing-key",
+	"credential-bundles.passphrase",
+	"backup.passphrase",
+	"notifications.targets.#.bot-token",
+}
+
+// showSecretReferences replaces values loaded from secret references with the
+// reference itself so resolved secrets never leave the process. Values are matched at
+// the path they were loaded from, and by value at the known secret paths in case the
+// entry moved since loading.
+func showSecretReferences(data []byte, refs map[string]config.SecretReference) []byte {
+	if len(refs) == 0 {
+		return data
+	}
+	for path, ref := range refs {
+		if value := gjson.GetBytes(data, path); value.Type == gjson.String && value.String() == ref.Value {
+			if out, err := sjson.SetBytes(data, path, ref.Reference); err == nil {
+				data = out
+			}
+		}
+	}
+	byValue := config.SecretReferencesByValue(refs)
+	for _, path := range secretJSONPaths {
+		data = rewriteJSONStrings(data, path, func(value string) string {
+			if ref, ok := byValue[value]; ok {
+				return ref
+			}
+			return value
+		})
+	}
+	return data
+}
+
+// redactConfigJSON masks secrets in the GetConfig payload that the principal may not manage.
+func redactConfigJSON(data []byte, p *Principal) []byte {
+	if !p.Allows(PermissionKeys) {
+		data = maskJSONStrings(data, "api-keys.#.api-key")
+		data = maskJSONStrings(data, "ampcode.upstream-api-key")
+		data = maskJSONStrings(data, "ampcode.upstream-api-keys.#.upstream-api-key")
+		data = maskJSONStrings(data, "ampcode.upstream-api-keys.#.api-keys.#")
+	}
+	if !p.Allows(PermissionCredentials) {
+		for _, path := range []string{
+			"gemini-api-key.#.api-key",
+			"claude-api-key.#.api-key",
+			"codex-api-key.#.api-key",
+			"vertex-api-key.#.api-key",
+			"bedrock-api-key.#.secret-access-key",
+			"bedrock-api-key.#.session-token",
+			"azure-openai.#.api-key",
+			"azure-openai.#.entra.client-secret",
+			"local-providers.#.api-key",
+			"oauth-providers.#.client-secret",
+			"openai-compatibility.#.api-key-entries.#.api-key",
+		} {
+			data = maskJSONStrings(data, path)
+		}
+		// Proxy URLs may embed user:pass; keep the rest of the URL readable.
+		data = rewriteJSONStrings(data, "proxy-pools.#.proxies.#", proxyutil.Redact)
+	}
+	// Executor plugin environments usually carry backend API keys.
+	if !p.Allows(PermissionAdmin) {
+		plugins := int(gjson.GetBytes(data, "plugins.#").Int())
+		for i := 0; i < plugins; i++ {
+			data = maskJSONObjectValues(data, "plugins."+strconv.Itoa(i)+".env")
+		}
+	}
+	// Keys that sign or decrypt credential bundles and backups are admin-only.
+	if !p.Allows(PermissionAdmin) {
+		data = maskJSONStrings(data, "credential-bundles.signing-key")
+		data = maskJSONStrings(data, "credential-bundles.passphrase")
+		data = maskJSONStrings(data, "backup.passphrase")
+	}
+	// Webhook URLs, bot tokens and headers carry the receiver's credentials.
+	data = maskJSONStrings(data, "notifications.targets.#.url")
+	data = maskJSONStrings(data, "notifications.targets.#.bot-token")
+	count := int(gjson.GetBytes(data, "notifications.targets.#").Int())
+	for i := 0; i < count; i++ {
+		data = maskJSONObjectValues(data, "notifications.targets."+strconv.Itoa(i)+".headers")
+	}
+	return data
+}
+
+// maskJSONObjectValues masks every string value of the object at path.
+func maskJSONObjectValues(data []byte, path string) []byte {
+	var keys []string
+	gjson.GetBytes(data, path).ForEach(func(key, _ gjson.Result) bool {
+		keys = append(keys, key.String())
+		return true
+	})
+	for _, key := range keys {
+		data = maskJSONStrings(data, path+"."+jsonPathEscaper.Replace(key))
+	}
+	return data
+}
+
+// jsonPathEscaper escapes object keys for use in gjson and sjson paths.
+var jsonPathEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`)
+
+// maskJSONStrings masks every string value matched by path, where ".#" segments
+// expand over array elements.
+func maskJSONStrings(data []byte, path string) []byte {
+	return rewriteJSONStrings(data, path, maskSecret)
+}
+
+// rewriteJSONStrings replaces every non-empty string value matched by path with fn(value).
+func rewriteJSONStrings(data []byte, path string, fn func(string) string) []byte {
+	head, tail, nested := strings.Cut(path, ".#")
+	if !nested {
+		value := gjson.GetBytes(data, path)
+		if value.Type != gjson.String || value.String() == "" {
+			return data
+		}
+		replacement := fn(value.String())
+		if replacement == value.String() {
+			return data
+		}
+		if out, err := sjson.SetBytes(data, path, replacement); err == nil {
+			return out
+		}
+		return data
+	}
+	count := int(gjson.GetBytes(data, head+".#").Int())
+	for i := 0; i < count; i++ {
+		data = rewriteJSONStrings(data, head+"."+strconv.Itoa(i)+tail, fn)
+	}
+	return data
+}
+
+// maskSecret keeps a short prefix and suffix so entries stay distinguishable.
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + "****" + secret[len(secret)-4:]
+}
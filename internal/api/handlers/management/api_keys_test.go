@@ -0,0 +1,76 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeneratedAPIKeyRotation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := NewHandler(&config.Config{}, configPath, nil)
+	router := gin.New()
+	router.POST("/api-keys", h.PostAPIKey)
+	router.POST("/api-keys/rotate", h.RotateAPIKey)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := post("/api-keys", `{"name":"ci","expires-in":3600}`)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", resp.Code, resp.Body.String())
+	}
+	plaintext := gjson.Get(resp.Body.String(), "key").String()
+	id := gjson.Get(resp.Body.String(), "api-key.id").String()
+	if !strings.HasPrefix(plaintext, config.GeneratedAPIKeyPrefix) || id == "" {
+		t.Fatalf("unexpected create response: %s", resp.Body.String())
+	}
+	entry := h.cfg.APIKeys[0]
+	if entry.Key != config.HashAPIKey(plaintext) || !strings.HasPrefix(plaintext, entry.Prefix+"_") || !entry.MatchesAPIKey(plaintext) {
+		t.Fatalf("stored entry %+v does not hash the issued key", entry)
+	}
+	if expires := entry.ExpiresAtTime(); expires.Before(time.Now().Add(59*time.Minute)) || expires.After(time.Now().Add(61*time.Minute)) {
+		t.Fatalf("expires-at = %s", entry.ExpiresAt)
+	}
+	saved, _ := os.ReadFile(configPath)
+	if strings.Contains(string(saved), plaintext) || !strings.Contains(string(saved), entry.Key) {
+		t.Fatalf("config should hold only the digest:\n%s", saved)
+	}
+
+	resp = post("/api-keys/rotate", `{"id":"`+id+`","overlap":60}`)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("rotate: %d %s", resp.Code, resp.Body.String())
+	}
+	successor := gjson.Get(resp.Body.String(), "key").String()
+	successorID := gjson.Get(resp.Body.String(), "api-key.id").String()
+	if successor == "" || successor == plaintext || len(h.cfg.APIKeys) != 2 {
+		t.Fatalf("unexpected rotate response: %s", resp.Body.String())
+	}
+	old := h.cfg.APIKeys[0]
+	if old.RotatedTo != successorID || old.ExpiresAtTime().After(time.Now().Add(61*time.Second)) || old.Expired(time.Now()) {
+		t.Fatalf("old key not scheduled for overlap expiry: %+v", old)
+	}
+	if h.cfg.APIKeys[1].Name != "ci" || !h.cfg.APIKeys[1].MatchesAPIKey(successor) {
+		t.Fatalf("successor entry %+v", h.cfg.APIKeys[1])
+	}
+	if resp = post("/api-keys/rotate", `{"id":"`+id+`"}`); resp.Code != http.StatusConflict {
+		t.Fatalf("second rotation: %d %s", resp.Code, resp.Body.String())
+	}
+}
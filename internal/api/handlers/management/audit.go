@@ -0,0 +1,390 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagerecord"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxAuditChanges caps the number of changed leaves stored per entry.
+	maxAuditChanges = 200
+	// maxAuditErrorBody bounds how much of a response is buffered to extract its error message.
+	maxAuditErrorBody = 4096
+	auditRedacted     = "[redacted]"
+)
+
+// sensitiveKeyParts marks snapshot keys whose values never leave the process.
+var sensitiveKeyParts = []string{"key", "secret", "token", "password", "cookie", "authorization", "credential", "private", "bearer"}
+
+// AuditMiddleware records every mutating management request with its actor, outcome
+// and a redacted diff of the config file and/or auth files it touched.
+func (h *Handler) AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAuditedMethod(c.Request.Method) || usagerecord.DefaultStore() == nil {
+			c.Next()
+			return
+		}
+		route := managementRoute(c)
+		withConfig, withAuth := auditSections(route)
+		var authFiles []string
+		allAuth := false
+		if withAuth {
+			authFiles, allAuth = auditAuthTargets(c, route)
+		}
+		before := h.auditSnapshot(withConfig, authFiles, allAuth)
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		after := h.auditSnapshot(withConfig, authFiles, allAuth)
+		changes, truncated := diffSnapshots(before, after)
+		status := c.Writer.Status()
+		entry := h.newAuditEntry(c, route, status)
+		entry.Changes = changes
+		entry.Truncated = truncated
+		if !entry.Success {
+			entry.Error = gjson.GetBytes(writer.body.Bytes(), "error").String()
+		}
+		h.recordAudit(entry)
+	}
+}
+
+// auditDenied records a mutation rejected for lack of permission.
+func (h *Handler) auditDenied(c *gin.Context, reason string) {
+	if !isAuditedMethod(c.Request.Method) || usagerecord.DefaultStore() == nil {
+		return
+	}
+	entry := h.newAuditEntry(c, managementRoute(c), http.StatusForbidden)
+	entry.Error = reason
+	h.recordAudit(entry)
+}
+
+func (h *Handler) newAuditEntry(c *gin.Context, route string, status int) *usagerecord.AuditEntry {
+	principal := PrincipalFromContext(c)
+	if principal == nil {
+		principal = adminPrincipal
+	}
+	return &usagerecord.AuditEntry{
+		Timestamp:  time.Now(),
+		Actor:      principal.Name,
+		ActorRole:  principal.Role,
+		AccountID:  principal.AccountID,
+		IP:         c.ClientIP(),
+		Method:     c.Request.Method,
+		Route:      route,
+		Path:       c.Request.URL.RequestURI(),
+		StatusCode: status,
+		Success:    status < http.StatusBadRequest,
+	}
+}
+
+func (h *Handler) recordAudit(entry *usagerecord.AuditEntry) {
+	store := usagerecord.DefaultStore()
+	if store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.InsertAudit(ctx, entry); err != nil {
+		log.WithError(err).Errorf("failed to record audit entry for %s %s", entry.Method, entry.Route)
+	}
+}
+
+func isAuditedMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// auditSections reports which state a route can change: the config file, the auth files, or both.
+func auditSections(route string) (withConfig, withAuth bool) {
+	switch {
+	case route == "/auth-files" || strings.HasPrefix(route, "/auth-files/") || route == "/vertex/import":
+		return false, true
+	case strings.HasPrefix(route, "/backups"):
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+// auditAuthTargets names the auth files a route can change, so only those are read
+// around the call. all reports a bulk operation that may touch any file in the auth dir.
+func auditAuthTargets(c *gin.Context, route string) (names []string, all bool) {
+	switch route {
+	case "/auth-files/export":
+		return nil, false
+	case "/auth-files/import", "/backups/restore", "/backups/upload":
+		return nil, true
+	case "/vertex/import":
+		if data := auditFormFile(c); len(data) > 0 {
+			if projectID := strings.TrimSpace(gjson.GetBytes(data, "project_id").String()); projectID != "" {
+				return []string{fmt.Sprintf("vertex-%s.json", sanitizeVertexFilePart(projectID))}, false
+			}
+		}
+		return nil, false
+	case "/auth-files":
+		if c.Request.Method == http.MethodDelete {
+			if v := c.Query("all"); v == "true" || v == "1" || v == "*" {
+				return nil, true
+			}
+		}
+		if name := c.Query("name"); name != "" {
+			return []string{name}, false
+		}
+		if file, err := c.FormFile("file"); err == nil && file != nil {
+			return []string{file.Filename}, false
+		}
+		return nil, false
+	}
+	if strings.HasPrefix(route, "/backups") || c.Request.Body == nil {
+		return nil, false
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	_ = c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, false
+	}
+	if name := strings.TrimSpace(gjson.GetBytes(body, "name").String()); name != "" {
+		return []string{name}, false
+	}
+	return nil, false
+}
+
+// auditFormFile returns the uploaded "file" part; gin keeps the parsed form for the handler.
+func auditFormFile(c *gin.Context) []byte {
+	header, err := c.FormFile("file")
+	if err != nil || header == nil {
+		return nil
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+	data, _ := io.ReadAll(file)
+	return data
+}
+
+// auditSnapshot flattens the selected state into redaction-aware leaves keyed by path.
+// Auth files are limited to authFiles unless allAuth is set.
+func (h *Handler) auditSnapshot(withConfig bool, authFiles []string, allAuth bool) map[string]any {
+	out := make(map[string]any)
+	if withConfig && h.configFilePath != "" {
+		if data, err := os.ReadFile(h.configFilePath); err == nil {
+			var doc any
+			if yaml.Unmarshal(data, &doc) == nil {
+				flattenSnapshot(out, "config", doc, false)
+			}
+		}
+	}
+	if h.cfg == nil || h.cfg.AuthDir == "" {
+		return out
+	}
+	if allAuth {
+		authFiles = authFiles[:0]
+		entries, err := os.ReadDir(h.cfg.AuthDir)
+		if err != nil {
+			return out
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				authFiles = append(authFiles, e.Name())
+			}
+		}
+	}
+	for _, name := range authFiles {
+		name = filepath.Base(name)
+		if !strings.HasSuffix(strings.ToLower(name), ".json") {
+			continue
+		}
+		data, err := encryption.ReadFile(filepath.Join(h.cfg.AuthDir, name))
+		if err != nil {
+			continue
+		}
+		var doc any
+		if json.Unmarshal(data, &doc) != nil {
+			doc = auditRedacted
+		}
+		flattenSnapshot(out, "auth-files["+name+"]", doc, false)
+	}
+	return out
+}
+
+// flattenSnapshot records scalar leaves of v under prefix. Values below a sensitive key
+// are wrapped so a diff shows that they changed without exposing them.
+func flattenSnapshot(out map[string]any, prefix string, v any, sensitive bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			out[prefix] = "{}"
+		}
+		for k, child := range val {
+			flattenSnapshot(out, prefix+"."+k, child, sensitive || isSensitiveKey(k))
+		}
+	case []any:
+		if len(val) == 0 {
+			out[prefix] = "[]"
+		}
+		for i, child := range val {
+			flattenSnapshot(out, prefix+"["+strconv.Itoa(i)+"]", child, sensitive)
+		}
+	default:
+		if sensitive && val != nil {
+			out[prefix] = redactedValue{fingerprint: fmt.Sprint(val)}
+			return
+		}
+		out[prefix] = normalizeScalar(val)
+	}
+}
+
+// redactedValue hides a secret while keeping it comparable across snapshots.
+type redactedValue struct{ fingerprint string }
+
+func normalizeScalar(v any) any {
+	switch val := v.(type) {
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case nil, string, bool, int, int64, float64, uint64:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffSnapshots lists changed leaves in path order, redacting secret values.
+func diffSnapshots(before, after map[string]any) ([]usagerecord.AuditChange, bool) {
+	paths := make([]string, 0, len(before)+len(after))
+	for path := range before {
+		paths = append(paths, path)
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := make([]usagerecord.AuditChange, 0)
+	for _, path := range paths {
+		oldValue, hadOld := before[path]
+		newValue, hasNew := after[path]
+		if hadOld && hasNew && oldValue == newValue {
+			continue
+		}
+		if len(changes) == maxAuditChanges {
+			return changes, true
+		}
+		change := usagerecord.AuditChange{Path: path}
+		if hadOld {
+			change.Before = displayValue(oldValue)
+		}
+		if hasNew {
+			change.After = displayValue(newValue)
+		}
+		changes = append(changes, change)
+	}
+	return changes, false
+}
+
+func displayValue(v any) any {
+	if _, ok := v.(redactedValue); ok {
+		return auditRedacted
+	}
+	return v
+}
+
+// auditResponseWriter keeps the start of the response so failures can record their error.
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if remaining := maxAuditErrorBody - w.body.Len(); remaining > 0 {
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func parseAuditQuery(c *gin.Context) usagerecord.AuditQuery {
+	query := usagerecord.AuditQuery{
+		Actor:     c.Query("actor"),
+		Route:     c.Query("route"),
+		Method:    c.Query("method"),
+		StartTime: c.Query("start_time"),
+		EndTime:   c.Query("end_time"),
+	}
+	query.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	query.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if successStr := c.Query("success"); successStr != "" {
+		success := successStr == "true" || successStr == "1"
+		query.Success = &success
+	}
+	return query
+}
+
+// GetAuditLog returns a page of audit log entries, newest first.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	store := usagerecord.DefaultStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "audit log not available"})
+		return
+	}
+	result, err := store.ListAudit(c.Request.Context(), parseAuditQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportAuditLog streams matching audit log entries as JSON lines, oldest first.
+func (h *Handler) ExportAuditLog(c *gin.Context) {
+	store := usagerecord.DefaultStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "audit log not available"})
+		return
+	}
+	filename := fmt.Sprintf("audit-log-%s.jsonl", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if _, err := store.ExportAudit(c.Request.Context(), parseAuditQuery(c), c.Writer); err != nil {
+		log.WithError(err).Warn("audit log export failed")
+	}
+}
@@ -0,0 +1,141 @@
+package management
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagerecord"
+	"github.com/tidwall/gjson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuditMiddlewareRecordsRedactedConfigDiff(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+	if err := usagerecord.InitDefaultStore(t.TempDir()); err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = usagerecord.CloseDefaultStore() })
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("root-secret"), bcrypt.MinCost)
+	cfg := &config.Config{}
+	cfg.Port = 8317
+	cfg.AuthDir = dir
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.SecretKey = string(hashed)
+	cfg.APIKeys = []config.ApiKeyEntry{{Key: "sk-old-client-key"}}
+	if err := config.SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("seed config: %v", err)
+	}
+
+	h := NewHandler(cfg, configPath, nil)
+	router := gin.New()
+	mgmt := router.Group("/v0/management", h.Middleware(), h.AuditMiddleware())
+	mgmt.POST("/accounts", h.CreateAccount)
+	mgmt.PUT("/debug", h.PutDebug)
+	mgmt.PUT("/api-keys", h.PutAPIKeys)
+	mgmt.GET("/audit-log", h.GetAuditLog)
+	mgmt.GET("/audit-log/export", h.ExportAuditLog)
+
+	_, viewerToken := createTestAccount(t, router, config.ManagementRoleViewer)
+
+	if resp := doManagementRequest(router, http.MethodPut, "/v0/management/debug", "root-secret", `{"value":true}`); resp.Code != http.StatusOK {
+		t.Fatalf("put debug status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := doManagementRequest(router, http.MethodPut, "/v0/management/api-keys", "root-secret", `["sk-new-client-key"]`); resp.Code != http.StatusOK {
+		t.Fatalf("put api-keys status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := doManagementRequest(router, http.MethodPut, "/v0/management/debug", viewerToken, `{"value":false}`); resp.Code != http.StatusForbidden {
+		t.Fatalf("viewer put debug status = %d", resp.Code)
+	}
+
+	result, err := usagerecord.DefaultStore().ListAudit(context.Background(), usagerecord.AuditQuery{})
+	if err != nil {
+		t.Fatalf("list audit: %v", err)
+	}
+	if result.Total != 4 {
+		t.Fatalf("audit entries = %d, want 4", result.Total)
+	}
+	denied, keys, debug := result.Entries[0], result.Entries[1], result.Entries[2]
+
+	if denied.Success || denied.StatusCode != http.StatusForbidden || denied.ActorRole != config.ManagementRoleViewer {
+		t.Fatalf("unexpected denied entry: %+v", denied)
+	}
+	if !debug.Success || debug.Actor != "admin" || debug.Route != "/debug" || debug.IP != "192.0.2.10" {
+		t.Fatalf("unexpected debug entry: %+v", debug)
+	}
+	if len(debug.Changes) != 1 || debug.Changes[0].Path != "config.debug" || debug.Changes[0].After != true {
+		t.Fatalf("unexpected debug changes: %+v", debug.Changes)
+	}
+	if len(keys.Changes) == 0 {
+		t.Fatalf("api-keys change not recorded")
+	}
+	for _, change := range keys.Changes {
+		if (change.Before != nil && change.Before != auditRedacted) || (change.After != nil && change.After != auditRedacted) {
+			t.Fatalf("api key change not redacted: %+v", change)
+		}
+	}
+
+	resp := doManagementRequest(router, http.MethodGet, "/v0/management/audit-log/export", "root-secret", "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("export status = %d", resp.Code)
+	}
+	if strings.Contains(resp.Body.String(), "sk-new-client-key") || strings.Contains(resp.Body.String(), "sk-old-client-key") {
+		t.Fatalf("export leaks keys: %s", resp.Body.String())
+	}
+	var lines []gjson.Result
+	scanner := bufio.NewScanner(strings.NewReader(resp.Body.String()))
+	for scanner.Scan() {
+		lines = append(lines, gjson.Parse(scanner.Text()))
+	}
+	if len(lines) != 4 || lines[0].Get("route").String() != "/accounts" {
+		t.Fatalf("unexpected export: %s", resp.Body.String())
+	}
+
+	if resp = doManagementRequest(router, http.MethodGet, "/v0/management/audit-log", viewerToken, ""); resp.Code != http.StatusForbidden {
+		t.Fatalf("viewer audit-log status = %d", resp.Code)
+	}
+}
+
+func TestAuditAuthTargets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		method, target, body string
+		want                 string
+		all                  bool
+	}{
+		{http.MethodDelete, "/auth-files?name=a.json", "", "a.json", false},
+		{http.MethodDelete, "/auth-files?all=true", "", "", true},
+		{http.MethodPatch, "/auth-files/status", `{"name":"b.json","disabled":true}`, "b.json", false},
+		{http.MethodPost, "/auth-files/export", `{"names":["c.json"]}`, "", false},
+		{http.MethodPost, "/auth-files/import", `{}`, "", true},
+		{http.MethodPost, "/backups/restore", `{}`, "", true},
+		{http.MethodPost, "/backups", `{}`, "", false},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(tc.method, "/v0/management"+tc.target, strings.NewReader(tc.body))
+		route := strings.SplitN(tc.target, "?", 2)[0]
+		names, all := auditAuthTargets(c, route)
+		if got := strings.Join(names, ","); got != tc.want || all != tc.all {
+			t.Fatalf("%s %s: names = %q all = %v, want %q %v", tc.method, tc.target, got, all, tc.want, tc.all)
+		}
+		if body, _ := io.ReadAll(c.Request.Body); string(body) != tc.body {
+			t.Fatalf("%s %s: body not restored: %q", tc.method, tc.target, body)
+		}
+	}
+}
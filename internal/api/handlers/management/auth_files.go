@@ -28,6 +28,7 @@ import (
 	iflowauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kimi"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
@@ -36,6 +37,7 @@ import (
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
@@ -246,6 +248,9 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 	auths := h.authManager.List()
 	files := make([]gin.H, 0, len(auths))
 	for _, auth := range auths {
+		if !tenantVisible(c, auth.Tenant()) {
+			continue
+		}
 		if entry := h.buildAuthFileEntry(auth); entry != nil {
 			files = append(files, entry)
 		}
@@ -272,11 +277,19 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 		auths := h.authManager.List()
 		for _, auth := range auths {
 			if auth.FileName == name || auth.ID == name {
+				if !tenantVisible(c, auth.Tenant()) {
+					c.JSON(404, gin.H{"error": "auth file not found"})
+					return
+				}
 				authID = auth.ID
 				break
 			}
 		}
 	}
+	if authID == "" && principalTenant(c) != "" {
+		c.JSON(404, gin.H{"error": "auth file not found"})
+		return
+	}
 
 	if authID == "" {
 		authID = name // fallback to filename as ID
@@ -327,7 +340,13 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 
 			// Read file to get type field
 			full := filepath.Join(h.cfg.AuthDir, name)
-			if data, errRead := os.ReadFile(full); errRead == nil {
+			tenant := ""
+			if raw, errRead := os.ReadFile(full); errRead == nil {
+				fileData["encrypted"] = encryption.IsSealed(raw)
+				data, errOpen := encryption.Open(raw)
+				if errOpen != nil {
+					data = nil
+				}
 				typeValue := gjson.GetBytes(data, "type").String()
 				emailValue := gjson.GetBytes(data, "email").String()
 				fileData["type"] = typeValue
@@ -340,6 +359,13 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 				if prefixValue != "" {
 					fileData["prefix"] = prefixValue
 				}
+				tenant = strings.TrimSpace(gjson.GetBytes(data, "tenant").String())
+				if tenant != "" {
+					fileData["tenant"] = tenant
+				}
+			}
+			if !tenantVisible(c, tenant) {
+				continue
 			}
 
 			files = append(files, fileData)
@@ -407,6 +433,9 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if v := strings.TrimSpace(auth.Prefix); v != "" {
 		entry["prefix"] = v
 	}
+	if v := auth.Tenant(); v != "" {
+		entry["tenant"] = v
+	}
 	if !auth.NextRetryAfter.IsZero() {
 		entry["next_retry_after"] = auth.NextRetryAfter
 	}
@@ -518,7 +547,7 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		return
 	}
 	full := filepath.Join(h.cfg.AuthDir, name)
-	data, err := os.ReadFile(full)
+	data, err := encryption.ReadFile(full)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
@@ -527,6 +556,10 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		}
 		return
 	}
+	if !tenantVisible(c, gjson.GetBytes(data, "tenant").String()) {
+		c.JSON(404, gin.H{"error": "file not found"})
+		return
+	}
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
 	c.Data(200, "application/json", data)
 }
@@ -544,6 +577,9 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			c.JSON(400, gin.H{"error": "file must be .json"})
 			return
 		}
+		if !h.authFileWritable(c, name) {
+			return
+		}
 		dst := filepath.Join(h.cfg.AuthDir, name)
 		if !filepath.IsAbs(dst) {
 			if abs, errAbs := filepath.Abs(dst); errAbs == nil {
@@ -554,11 +590,24 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errSave)})
 			return
 		}
-		data, errRead := os.ReadFile(dst)
+		data, errRead := encryption.ReadFile(dst)
 		if errRead != nil {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read saved file: %v", errRead)})
 			return
 		}
+		if tenant := principalTenant(c); tenant != "" {
+			if data, errRead = sjson.SetBytes(data, "tenant", tenant); errRead != nil {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("invalid auth file: %v", errRead)})
+				return
+			}
+			if errWrite := encryption.WriteFile(dst, data, 0o600); errWrite != nil {
+				c.JSON(500, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
+				return
+			}
+		} else if errSeal := encryption.SealFile(dst); errSeal != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to encrypt file: %v", errSeal)})
+			return
+		}
 		if errReg := h.registerAuthFromFile(ctx, dst, data); errReg != nil {
 			c.JSON(500, gin.H{"error": errReg.Error()})
 			return
@@ -575,6 +624,9 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "name must end with .json"})
 		return
 	}
+	if !h.authFileWritable(c, name) {
+		return
+	}
 	data, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		c.JSON(400, gin.H{"error": "failed to read body"})
@@ -586,7 +638,17 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			dst = abs
 		}
 	}
-	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+	if data, err = encryption.Open(data); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if tenant := principalTenant(c); tenant != "" {
+		if data, err = sjson.SetBytes(data, "tenant", tenant); err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid auth file: %v", err)})
+			return
+		}
+	}
+	if errWrite := encryption.WriteFile(dst, data, 0o600); errWrite != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
 		return
 	}
@@ -605,6 +667,9 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 	}
 	ctx := c.Request.Context()
 	if all := c.Query("all"); all == "true" || all == "1" || all == "*" {
+		if denyTenant(c, "tenant accounts must delete auth files by name") {
+			return
+		}
 		entries, err := os.ReadDir(h.cfg.AuthDir)
 		if err != nil {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read auth dir: %v", err)})
@@ -643,6 +708,11 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 		return
 	}
 
+	if tenant, _ := h.authFileTenant(name); !tenantVisible(c, tenant) {
+		c.JSON(404, gin.H{"error": "file not found"})
+		return
+	}
+
 	targetPath := filepath.Join(h.cfg.AuthDir, filepath.Base(name))
 	targetID := ""
 	if targetAuth := h.findAuthForDelete(name); targetAuth != nil {
@@ -676,6 +746,19 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// authFileWritable rejects uploads by tenant accounts that would overwrite another
+// tenant's or a shared auth file.
+func (h *Handler) authFileWritable(c *gin.Context, name string) bool {
+	if principalTenant(c) == "" {
+		return true
+	}
+	if tenant, exists := h.authFileTenant(name); exists && !tenantVisible(c, tenant) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "auth file belongs to another tenant"})
+		return false
+	}
+	return true
+}
+
 func (h *Handler) findAuthForDelete(name string) *coreauth.Auth {
 	if h == nil || h.authManager == nil {
 		return nil
@@ -732,7 +815,7 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	}
 	if data == nil {
 		var err error
-		data, err = os.ReadFile(path)
+		data, err = encryption.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read auth file: %w", err)
 		}
@@ -892,7 +975,7 @@ func (h *Handler) PatchAuthFileMetadata(c *gin.Context) {
 		}
 	}
 
-	data, errRead := os.ReadFile(path)
+	data, errRead := encryption.ReadFile(path)
 	if errRead != nil {
 		if os.IsNotExist(errRead) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
@@ -941,7 +1024,7 @@ func (h *Handler) PatchAuthFileMetadata(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to serialize auth file"})
 		return
 	}
-	if errWrite := os.WriteFile(path, out, 0o600); errWrite != nil {
+	if errWrite := encryption.WriteFile(path, out, 0o600); errWrite != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
 		return
 	}
@@ -995,7 +1078,7 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 		}
 	}
 
-	if targetAuth == nil {
+	if targetAuth == nil || !tenantVisible(c, targetAuth.Tenant()) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
 		return
 	}
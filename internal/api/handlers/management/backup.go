@@ -1,8 +1,10 @@
 // Package management provides the management API handlers and middleware.
-// This file implements backup and restore functionality for .env, config.yaml, and auths folder.
+// This file implements backup and restore functionality for .env, config.yaml, the auths folder
+// and the usage records database.
 package management
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,35 +23,60 @@ type BackupCreateRequest struct {
 	Name       string               `json:"name"`       // Custom backup name (optional)
 	Content    backup.BackupContent `json:"content"`    // What to backup
 	BackupPath string               `json:"backupPath"` // Custom backup directory (optional)
+	Passphrase string               `json:"passphrase"` // Encrypts the archive (optional, defaults to backup.passphrase)
 }
 
 // BackupRestoreRequest represents a request to restore a backup
 type BackupRestoreRequest struct {
-	Name      string `json:"name"`      // Backup name to restore
-	AuthsMode string `json:"authsMode"` // "overwrite" or "incremental"
+	Name       string `json:"name"`       // Backup name to restore
+	AuthsMode  string `json:"authsMode"`  // "overwrite" or "incremental"
+	Passphrase string `json:"passphrase"` // Decrypts encrypted archives (optional, defaults to backup.passphrase)
 }
 
 // BackupListResponse represents the response for listing backups
 type BackupListResponse struct {
 	Backups    []BackupMetadataResponse `json:"backups"`
 	BackupPath string                   `json:"backupPath"`
+	Schedule   *backup.SchedulerStatus  `json:"schedule,omitempty"`
 }
 
 // BackupMetadataResponse is the API response format for backup metadata
 type BackupMetadataResponse struct {
-	Name    string               `json:"name"`
-	Date    time.Time            `json:"date"`
-	Content backup.BackupContent `json:"content"`
-	Size    int64                `json:"size"`
+	Name      string               `json:"name"`
+	Date      time.Time            `json:"date"`
+	Content   backup.BackupContent `json:"content"`
+	Size      int64                `json:"size"`
+	Encrypted bool                 `json:"encrypted"`
 }
 
-// getBackupDir returns the backup directory path
+// SetBackupScheduler registers the running backup scheduler so its status is listed.
+func (h *Handler) SetBackupScheduler(scheduler *backup.Scheduler) {
+	h.backupScheduler = scheduler
+}
+
+// getBackupDir returns the backup directory path (backup.path, default "backup")
 func (h *Handler) getBackupDir() string {
 	wd, err := os.Getwd()
 	if err != nil {
 		wd = filepath.Dir(h.configFilePath)
 	}
-	return filepath.Join(wd, "backup")
+	return backup.ValidateBackupPathCLI(h.cfg.Backup.Path, wd)
+}
+
+// backupPassphrase returns the request passphrase or the configured backup.passphrase.
+func (h *Handler) backupPassphrase(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return h.cfg.Backup.Passphrase
+}
+
+// restoreErrorStatus maps restore errors caused by the passphrase to 400.
+func restoreErrorStatus(err error) int {
+	if errors.Is(err, backup.ErrPassphraseRequired) || errors.Is(err, backup.ErrWrongPassphrase) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
 }
 
 // getAuthDir returns the auth directory path using the centralized helper
@@ -86,10 +113,11 @@ func (h *Handler) ListBackups(c *gin.Context) {
 			dateTime = time.Time{}
 		}
 		response = append(response, BackupMetadataResponse{
-			Name:    b.Name,
-			Date:    dateTime,
-			Content: b.Content,
-			Size:    b.Size,
+			Name:      b.Name,
+			Date:      dateTime,
+			Content:   b.Content,
+			Size:      b.Size,
+			Encrypted: b.Encrypted,
 		})
 	}
 
@@ -98,10 +126,15 @@ func (h *Handler) ListBackups(c *gin.Context) {
 		return response[i].Date.After(response[j].Date)
 	})
 
-	c.JSON(http.StatusOK, BackupListResponse{
+	resp := BackupListResponse{
 		Backups:    response,
 		BackupPath: backupDir,
-	})
+	}
+	if h.backupScheduler != nil {
+		status := h.backupScheduler.Status()
+		resp.Schedule = &status
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // CreateBackup creates a new backup
@@ -113,7 +146,7 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 	}
 
 	// Default content selection: config.yaml and auths
-	if !req.Content.Env && !req.Content.Config && !req.Content.Auths {
+	if !req.Content.Env && !req.Content.Config && !req.Content.Auths && !req.Content.UsageDB {
 		req.Content.Config = true
 		req.Content.Auths = true
 	}
@@ -125,10 +158,12 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 	}
 
 	// Validate backup path (no absolute paths allowed for API)
-	backupDir, err := backup.ValidateBackupPath(req.BackupPath, wd)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	backupDir := h.getBackupDir()
+	if req.BackupPath != "" {
+		if backupDir, err = backup.ValidateBackupPath(req.BackupPath, wd); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	// Create backup using shared package
@@ -138,6 +173,10 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 		Content:    req.Content,
 		WorkDir:    wd,
 		AuthDir:    h.getAuthDir(),
+		Passphrase: h.backupPassphrase(req.Passphrase),
+	}
+	if req.Content.UsageDB {
+		opts.SnapshotUsageDB = backup.SnapshotUsageRecords
 	}
 
 	backupPath, err := backup.CreateBackup(opts)
@@ -156,10 +195,11 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ok",
 		"backup": BackupMetadataResponse{
-			Name:    filepath.Base(backupPath),
-			Date:    time.Now(),
-			Content: req.Content,
-			Size:    info.Size(),
+			Name:      filepath.Base(backupPath),
+			Date:      time.Now(),
+			Content:   req.Content,
+			Size:      info.Size(),
+			Encrypted: opts.Passphrase != "",
 		},
 		"filepath": backupPath,
 	})
@@ -176,9 +216,7 @@ func (h *Handler) DeleteBackup(c *gin.Context) {
 	backupDir := h.getBackupDir()
 
 	// Ensure .zip extension
-	if !strings.HasSuffix(name, ".zip") {
-		name = name + ".zip"
-	}
+	name = backup.NormalizeBackupName(name)
 
 	if err := backup.DeleteBackup(backupDir, name); err != nil {
 		if os.IsNotExist(err) {
@@ -206,10 +244,7 @@ func (h *Handler) DownloadBackup(c *gin.Context) {
 	backupDir := h.getBackupDir()
 
 	// Sanitize name to prevent path traversal
-	name = filepath.Base(name)
-	if !strings.HasSuffix(name, ".zip") {
-		name = name + ".zip"
-	}
+	name = backup.NormalizeBackupName(filepath.Base(name))
 
 	zipPath := filepath.Join(backupDir, name)
 
@@ -221,7 +256,11 @@ func (h *Handler) DownloadBackup(c *gin.Context) {
 
 	c.Header("Content-Description", "File Transfer")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", name))
-	c.Header("Content-Type", "application/zip")
+	if strings.HasSuffix(name, backup.EncryptedSuffix) {
+		c.Header("Content-Type", "application/octet-stream")
+	} else {
+		c.Header("Content-Type", "application/zip")
+	}
 	c.File(zipPath)
 }
 
@@ -256,10 +295,7 @@ func (h *Handler) RestoreBackup(c *gin.Context) {
 	}
 
 	// Sanitize name
-	name := filepath.Base(req.Name)
-	if !strings.HasSuffix(name, ".zip") {
-		name = name + ".zip"
-	}
+	name := backup.NormalizeBackupName(filepath.Base(req.Name))
 
 	// Check if backup exists
 	zipPath := filepath.Join(backupDir, name)
@@ -268,17 +304,18 @@ func (h *Handler) RestoreBackup(c *gin.Context) {
 		return
 	}
 
-	// Restore using shared package
+	// Restore using shared package; the usage records database is in use and is not restored
 	opts := backup.RestoreOptions{
 		BackupPath: backupDir,
 		BackupName: name,
 		AuthsMode:  req.AuthsMode,
 		WorkDir:    wd,
 		AuthDir:    h.getAuthDir(),
+		Passphrase: h.backupPassphrase(req.Passphrase),
 	}
 
 	if err := backup.RestoreBackup(opts); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to restore backup: %v", err)})
+		c.JSON(restoreErrorStatus(err), gin.H{"error": fmt.Sprintf("failed to restore backup: %v", err)})
 		return
 	}
 
@@ -301,8 +338,8 @@ func (h *Handler) UploadAndRestoreBackup(c *gin.Context) {
 	defer file.Close()
 
 	// Validate file extension
-	if !strings.HasSuffix(strings.ToLower(header.Filename), ".zip") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "only zip files are allowed"})
+	if !backup.IsBackupName(strings.ToLower(header.Filename)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only .zip or .zip.enc backup files are allowed"})
 		return
 	}
 
@@ -349,10 +386,11 @@ func (h *Handler) UploadAndRestoreBackup(c *gin.Context) {
 		AuthsMode:  authsMode,
 		WorkDir:    wd,
 		AuthDir:    h.getAuthDir(),
+		Passphrase: h.backupPassphrase(c.PostForm("passphrase")),
 	}
 
 	if err := backup.RestoreBackup(opts); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to restore backup: %v", err)})
+		c.JSON(restoreErrorStatus(err), gin.H{"error": fmt.Sprintf("failed to restore backup: %v", err)})
 		return
 	}
 
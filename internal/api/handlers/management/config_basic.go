@@ -28,6 +28,21 @@ func (h *Handler) GetConfig(c *gin.Context) {
 		c.JSON(200, gin.H{})
 		return
 	}
+	p := PrincipalFromContext(c)
+	restricted := p != nil && !p.Allows(PermissionAdmin)
+	if restricted || len(h.cfg.SecretReferences()) > 0 {
+		data, err := json.Marshal(h.cfg)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode config"})
+			return
+		}
+		data = showSecretReferences(data, h.cfg.SecretReferences())
+		if restricted {
+			data = redactConfigJSON(data, p)
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+		return
+	}
 	c.JSON(200, new(*h.cfg))
 }
 
@@ -91,21 +106,9 @@ func (h *Handler) GetLatestVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"latest-version": version})
 }
 
+// WriteConfig atomically replaces the config file at path with data.
 func WriteConfig(path string, data []byte) error {
-	data = config.NormalizeCommentIndentation(data)
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
-	if _, errWrite := f.Write(data); errWrite != nil {
-		_ = f.Close()
-		return errWrite
-	}
-	if errSync := f.Sync(); errSync != nil {
-		_ = f.Close()
-		return errSync
-	}
-	return f.Close()
+	return config.WriteConfigFile(path, data)
 }
 
 func (h *Handler) PutConfigYAML(c *gin.Context) {
@@ -114,29 +117,45 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": "cannot read request body"})
 		return
 	}
+	if !h.applyConfigYAML(c, body) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}})
+}
+
+// applyConfigYAML validates body as a complete config, writes it and reloads the
+// handler's in-memory copy. On failure it writes the error response and returns false.
+func (h *Handler) applyConfigYAML(c *gin.Context, body []byte) bool {
 	var cfg config.Config
-	if err = yaml.Unmarshal(body, &cfg); err != nil {
+	if err := yaml.Unmarshal(body, &cfg); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": err.Error()})
-		return
+		return false
+	}
+	// Seal secrets first so plaintext values never reach the disk, not even in the
+	// validation file.
+	body, err := config.SealConfigData(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "encrypt_failed", "message": err.Error()})
+		return false
 	}
 	// Validate config using LoadConfigOptional with optional=false to enforce parsing
 	tmpDir := filepath.Dir(h.configFilePath)
 	tmpFile, err := os.CreateTemp(tmpDir, "config-validate-*.yaml")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": err.Error()})
-		return
+		return false
 	}
 	tempFile := tmpFile.Name()
 	if _, errWrite := tmpFile.Write(body); errWrite != nil {
 		_ = tmpFile.Close()
 		_ = os.Remove(tempFile)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": errWrite.Error()})
-		return
+		return false
 	}
 	if errClose := tmpFile.Close(); errClose != nil {
 		_ = os.Remove(tempFile)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": errClose.Error()})
-		return
+		return false
 	}
 	defer func() {
 		_ = os.Remove(tempFile)
@@ -144,22 +163,22 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 	_, err = config.LoadConfigOptional(tempFile, false)
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
-		return
+		return false
 	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if WriteConfig(h.configFilePath, body) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": "failed to write config"})
-		return
+		return false
 	}
 	// Reload into handler to keep memory in sync
 	newCfg, err := config.LoadConfig(h.configFilePath)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload_failed", "message": err.Error()})
-		return
+		return false
 	}
 	h.cfg = newCfg
-	c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}})
+	return true
 }
 
 // GetConfigYAML returns the raw config.yaml file bytes without re-encoding.
@@ -0,0 +1,81 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/confighistory"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+	"gopkg.in/yaml.v3"
+)
+
+// ListConfigVersions returns the recorded config versions, newest first.
+// Each version carries the changes it introduced over its predecessor.
+func (h *Handler) ListConfigVersions(c *gin.Context) {
+	versions, err := confighistory.ForConfig(h.configFilePath).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if versions == nil {
+		versions = []confighistory.Version{}
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// GetConfigVersion returns one version's YAML together with the changes that
+// rolling back to it would apply to the current config.
+func (h *Handler) GetConfigVersion(c *gin.Context) {
+	version, data, ok := h.loadConfigVersion(c)
+	if !ok {
+		return
+	}
+	var versionCfg config.Config
+	if err := yaml.Unmarshal(data, &versionCfg); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_yaml", "message": err.Error()})
+		return
+	}
+	h.mu.Lock()
+	changes := diff.BuildConfigChangeDetails(h.cfg, &versionCfg)
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{
+		"version":          version,
+		"content":          string(data),
+		"rollback-changes": changes,
+	})
+}
+
+// RollbackConfigVersion restores a recorded version. The file is written the same
+// way as PutConfigYAML, so the watcher hot-reloads it and records a rollback version.
+func (h *Handler) RollbackConfigVersion(c *gin.Context) {
+	version, data, ok := h.loadConfigVersion(c)
+	if !ok {
+		return
+	}
+	confighistory.ForConfig(h.configFilePath).NoteRollback(config.NormalizeCommentIndentation(data), version.ID)
+	if !h.applyConfigYAML(c, data) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}, "restored": version.ID})
+}
+
+func (h *Handler) loadConfigVersion(c *gin.Context) (confighistory.Version, []byte, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version id"})
+		return confighistory.Version{}, nil, false
+	}
+	version, data, err := confighistory.ForConfig(h.configFilePath).Get(id)
+	if err != nil {
+		if errors.Is(err, confighistory.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return confighistory.Version{}, nil, false
+	}
+	return version, data, true
+}
@@ -4,26 +4,40 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagerecord"
 )
 
 type apiKeyUsageResponse struct {
-	ID           string `json:"id,omitempty"`
-	Key          string `json:"api-key"`
-	Name         string `json:"name,omitempty"`
-	IsActive     bool   `json:"is-active"`
-	UsageCount   int64  `json:"usage-count,omitempty"`
-	InputTokens  int64  `json:"input-tokens,omitempty"`
-	OutputTokens int64  `json:"output-tokens,omitempty"`
-	LastUsedAt   string `json:"last-used-at,omitempty"`
-	CreatedAt    string `json:"created-at,omitempty"`
-}
+	ID           string   `json:"id,omitempty"`
+	Key          string   `json:"api-key"`
+	Name         string   `json:"name,omitempty"`
+	IsActive     bool     `json:"is-active"`
+	UsageCount   int64    `json:"usage-count,omitempty"`
+	InputTokens  int64    `json:"input-tokens,omitempty"`
+	OutputTokens int64    `json:"output-tokens,omitempty"`
+	LastUsedAt   string   `json:"last-used-at,omitempty"`
+	CreatedAt    string   `json:"created-at,omitempty"`
+	Prefix       string   `json:"prefix,omitempty"`
+	ExpiresAt    string   `json:"expires-at,omitempty"`
+	Expired      bool     `json:"expired,omitempty"`
+	RotatedTo    string   `json:"rotated-to,omitempty"`
+	IPAllow      []string `json:"ip-allow,omitempty"`
+	IPDeny       []string `json:"ip-deny,omitempty"`
+	IPDenied     int64    `json:"ip-denied,omitempty"`
+	Tenant       string   `json:"tenant,omitempty"`
+}
+
+// defaultAPIKeyRotationOverlap is how long a rotated key keeps working when the
+// request does not specify an overlap window.
+const defaultAPIKeyRotationOverlap = 24 * time.Hour
 
 // Generic helpers for list[string]
 func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()) {
@@ -138,10 +152,24 @@ func apiKeyEntriesToStrings(entries []config.ApiKeyEntry) []string {
 	return out
 }
 
+// visibleAPIKeys returns the client keys the request's principal may see.
+func visibleAPIKeys(c *gin.Context, entries []config.ApiKeyEntry) []config.ApiKeyEntry {
+	if principalTenant(c) == "" {
+		return entries
+	}
+	out := make([]config.ApiKeyEntry, 0, len(entries))
+	for _, entry := range entries {
+		if tenantVisible(c, entry.Tenant) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
 // api-keys
 func (h *Handler) GetAPIKeys(c *gin.Context) {
 	// Align to upstream: return a simple list of api key strings.
-	c.JSON(200, gin.H{"api-keys": apiKeyEntriesToStrings(h.cfg.APIKeys)})
+	c.JSON(200, gin.H{"api-keys": apiKeyEntriesToStrings(visibleAPIKeys(c, h.cfg.APIKeys))})
 }
 
 // GetAPIKeysUsage returns object entries with usage fields loaded from DB table.
@@ -155,18 +183,29 @@ func (h *Handler) GetAPIKeysUsage(c *gin.Context) {
 			stats = dbStats
 		}
 	}
-	out := make([]apiKeyUsageResponse, 0, len(h.cfg.APIKeys))
-	for _, entry := range h.cfg.APIKeys {
+	now := time.Now()
+	entries := visibleAPIKeys(c, h.cfg.APIKeys)
+	out := make([]apiKeyUsageResponse, 0, len(entries))
+	for _, entry := range entries {
 		key := strings.TrimSpace(entry.Key)
 		if key == "" {
 			continue
 		}
+		expired := entry.Expired(now)
 		row := apiKeyUsageResponse{
 			ID:        entry.ID,
 			Key:       key,
 			Name:      entry.Name,
-			IsActive:  true,
+			IsActive:  !expired && (entry.IsActive || !entry.HasPolicy()),
 			CreatedAt: entry.CreatedAt,
+			Prefix:    entry.Prefix,
+			ExpiresAt: entry.ExpiresAt,
+			Expired:   expired,
+			RotatedTo: entry.RotatedTo,
+			IPAllow:   entry.IPAllow,
+			IPDeny:    entry.IPDeny,
+			IPDenied:  usage.GetRequestStatistics().DeniedCount(key),
+			Tenant:    entry.Tenant,
 		}
 		if s, ok := stats[key]; ok && s != nil {
 			row.UsageCount = s.UsageCount
@@ -180,6 +219,9 @@ func (h *Handler) GetAPIKeysUsage(c *gin.Context) {
 }
 
 func (h *Handler) PutAPIKeys(c *gin.Context) {
+	if denyTenant(c, "tenant accounts cannot replace the key list") {
+		return
+	}
 	data, err := c.GetRawData()
 	if err != nil {
 		c.JSON(400, gin.H{"error": "failed to read body"})
@@ -266,14 +308,22 @@ func (h *Handler) PatchAPIKeys(c *gin.Context) {
 		IsActive *bool   `json:"is-active"`
 		Name     *string `json:"name"`
 		APIKey   *string `json:"api-key"` // For updating the key value itself
+		// ExpiresAt sets an RFC 3339 expiry; an empty string removes it.
+		ExpiresAt *string `json:"expires-at"`
+		// IPAllow and IPDeny replace the key's CIDR lists; an empty list removes them.
+		IPAllow *[]string `json:"ip-allow"`
+		IPDeny  *[]string `json:"ip-deny"`
+		// Tenant reassigns the key; an empty string makes it a global key.
+		Tenant *string `json:"tenant"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
+	tenant := principalTenant(c)
 
 	// Update by index with value (string or object) - legacy compatibility.
-	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.APIKeys) && len(body.Value) > 0 {
+	if tenant == "" && body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.APIKeys) && len(body.Value) > 0 {
 		// Prefer parsing as string (upstream-compatible TUI expects this).
 		var asString string
 		if err := json.Unmarshal(body.Value, &asString); err == nil {
@@ -340,10 +390,28 @@ func (h *Handler) PatchAPIKeys(c *gin.Context) {
 		}
 	}
 
+	// Tenant accounts may only update their own keys, found by id or key.
+	if tenant != "" {
+		if targetIndex < 0 || !tenantVisible(c, h.cfg.APIKeys[targetIndex].Tenant) {
+			c.JSON(404, gin.H{"error": "key not found"})
+			return
+		}
+		if body.Tenant != nil {
+			c.JSON(403, gin.H{"error": "tenant accounts cannot reassign keys"})
+			return
+		}
+	}
+
 	// Handle partial updates if target found
 	if targetIndex >= 0 {
 		modified := false
 
+		// Update Tenant if provided
+		if body.Tenant != nil {
+			h.cfg.APIKeys[targetIndex].Tenant = strings.TrimSpace(*body.Tenant)
+			modified = true
+		}
+
 		// Update IsActive if provided
 		if body.IsActive != nil {
 			h.cfg.APIKeys[targetIndex].IsActive = *body.IsActive
@@ -356,6 +424,37 @@ func (h *Handler) PatchAPIKeys(c *gin.Context) {
 			modified = true
 		}
 
+		// Update ExpiresAt if provided
+		if body.ExpiresAt != nil {
+			expiresAt, err := parseAPIKeyExpiry(*body.ExpiresAt, 0, time.Now())
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+			h.cfg.APIKeys[targetIndex].ExpiresAt = expiresAt
+			modified = true
+		}
+
+		// Update IP lists if provided
+		for _, update := range []struct {
+			values *[]string
+			target *[]string
+		}{
+			{body.IPAllow, &h.cfg.APIKeys[targetIndex].IPAllow},
+			{body.IPDeny, &h.cfg.APIKeys[targetIndex].IPDeny},
+		} {
+			if update.values == nil {
+				continue
+			}
+			rules, err := normalizeIPRules(*update.values)
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+			*update.target = rules
+			modified = true
+		}
+
 		// Update Key value if provided (with uniqueness check)
 		if body.APIKey != nil {
 			newKey := strings.TrimSpace(*body.APIKey)
@@ -379,6 +478,10 @@ func (h *Handler) PatchAPIKeys(c *gin.Context) {
 			return
 		}
 	}
+	if tenant != "" {
+		c.JSON(400, gin.H{"error": "missing fields"})
+		return
+	}
 
 	// Append-only add (compat): allow {"new": "..."} or {"old": null, "new": "..."}.
 	if body.New != nil && strings.TrimSpace(*body.New) != "" && body.Old == nil {
@@ -444,6 +547,19 @@ func (h *Handler) PatchAPIKeys(c *gin.Context) {
 }
 
 func (h *Handler) DeleteAPIKeys(c *gin.Context) {
+	if principalTenant(c) != "" {
+		id := strings.TrimSpace(c.Query("id"))
+		if id == "" {
+			c.JSON(400, gin.H{"error": "tenant accounts must delete keys by id"})
+			return
+		}
+		for _, entry := range h.cfg.APIKeys {
+			if entry.ID == id && !tenantVisible(c, entry.Tenant) {
+				c.JSON(404, gin.H{"error": "key not found"})
+				return
+			}
+		}
+	}
 	// Delete by ID (preferred)
 	if id := strings.TrimSpace(c.Query("id")); id != "" {
 		out := make([]config.ApiKeyEntry, 0, len(h.cfg.APIKeys))
@@ -501,30 +617,35 @@ func (h *Handler) DeleteAPIKeys(c *gin.Context) {
 	c.JSON(400, gin.H{"error": "missing id, index, value, or api-key"})
 }
 
-// PostAPIKey adds a new API key entry
+// PostAPIKey adds a new API key entry. When api-key is omitted the server generates
+// one, stores only its hash and returns the plaintext once in the "key" field.
 func (h *Handler) PostAPIKey(c *gin.Context) {
 	var body struct {
-		APIKey string `json:"api-key"`
-		Name   string `json:"name"`
-		Label  string `json:"label"` // Alias for name
+		APIKey    string `json:"api-key"`
+		Name      string `json:"name"`
+		Label     string `json:"label"` // Alias for name
+		ExpiresAt string `json:"expires-at"`
+		ExpiresIn int64  `json:"expires-in"` // Seconds from now; ignored when expires-at is set
+		Tenant    string `json:"tenant"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
-
-	key := strings.TrimSpace(body.APIKey)
-	if key == "" {
-		c.JSON(400, gin.H{"error": "api-key is required"})
-		return
-	}
-
-	// Check for duplicate
-	for _, entry := range h.cfg.APIKeys {
-		if entry.Key == key {
-			c.JSON(409, gin.H{"error": "key already exists"})
+	tenant := strings.TrimSpace(body.Tenant)
+	if scope := principalTenant(c); scope != "" {
+		if tenant != "" && tenant != scope {
+			c.JSON(403, gin.H{"error": "tenant accounts cannot create keys for other tenants"})
 			return
 		}
+		tenant = scope
+	}
+
+	now := time.Now().UTC()
+	expiresAt, err := parseAPIKeyExpiry(body.ExpiresAt, body.ExpiresIn, now)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 
 	name := strings.TrimSpace(body.Name)
@@ -532,18 +653,182 @@ func (h *Handler) PostAPIKey(c *gin.Context) {
 		name = strings.TrimSpace(body.Label)
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
 	newEntry := config.ApiKeyEntry{
 		ID:        uuid.New().String(),
-		Key:       key,
 		Name:      name,
 		IsActive:  true,
-		CreatedAt: now,
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: expiresAt,
+		Tenant:    tenant,
+	}
+	plaintext := ""
+	if key := strings.TrimSpace(body.APIKey); key != "" {
+		newEntry.Key = key
+	} else {
+		plaintext, newEntry.Prefix, err = config.GenerateAPIKey()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to generate api key"})
+			return
+		}
+		newEntry.Key = config.HashAPIKey(plaintext)
+	}
+
+	h.mu.Lock()
+	for _, entry := range h.cfg.APIKeys {
+		if entry.Key == newEntry.Key {
+			h.mu.Unlock()
+			c.JSON(409, gin.H{"error": "key already exists"})
+			return
+		}
 	}
 	h.cfg.APIKeys = append(h.cfg.APIKeys, newEntry)
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.APIKeys = h.cfg.APIKeys[:len(h.cfg.APIKeys)-1]
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
 
 	// Return the created entry with its ID
-	c.JSON(201, gin.H{"api-key": newEntry})
+	resp := gin.H{"api-key": newEntry}
+	if plaintext != "" {
+		resp["key"] = plaintext
+	}
+	c.JSON(201, resp)
+}
+
+// RotateAPIKey issues a generated successor for an existing key. The old key keeps
+// working for the overlap window (seconds, default 24h) and then expires.
+func (h *Handler) RotateAPIKey(c *gin.Context) {
+	var body struct {
+		ID        string `json:"id"`
+		APIKey    string `json:"api-key"`
+		Overlap   *int64 `json:"overlap"`
+		ExpiresAt string `json:"expires-at"`
+		ExpiresIn int64  `json:"expires-in"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	id := strings.TrimSpace(body.ID)
+	key := strings.TrimSpace(body.APIKey)
+	if id == "" && key == "" {
+		c.JSON(400, gin.H{"error": "missing id or api-key"})
+		return
+	}
+	overlap := defaultAPIKeyRotationOverlap
+	if body.Overlap != nil {
+		if *body.Overlap < 0 {
+			c.JSON(400, gin.H{"error": "overlap must not be negative"})
+			return
+		}
+		overlap = time.Duration(*body.Overlap) * time.Second
+	}
+
+	now := time.Now().UTC()
+	expiresAt, err := parseAPIKeyExpiry(body.ExpiresAt, body.ExpiresIn, now)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	plaintext, prefix, err := config.GenerateAPIKey()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate api key"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	targetIndex := -1
+	for i := range h.cfg.APIKeys {
+		entry := &h.cfg.APIKeys[i]
+		if (id != "" && entry.ID == id) || (id == "" && strings.TrimSpace(entry.Key) == key) {
+			targetIndex = i
+			break
+		}
+	}
+	if targetIndex < 0 || !tenantVisible(c, h.cfg.APIKeys[targetIndex].Tenant) {
+		c.JSON(404, gin.H{"error": "key not found"})
+		return
+	}
+	old := h.cfg.APIKeys[targetIndex]
+	if old.RotatedTo != "" {
+		c.JSON(409, gin.H{"error": "key already rotated"})
+		return
+	}
+	if !old.Usable(now) {
+		c.JSON(409, gin.H{"error": "key is inactive or expired"})
+		return
+	}
+
+	successor := config.ApiKeyEntry{
+		ID:        uuid.New().String(),
+		Key:       config.HashAPIKey(plaintext),
+		Prefix:    prefix,
+		Name:      old.Name,
+		IsActive:  true,
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: expiresAt,
+		Tenant:    old.Tenant,
+	}
+	updated := old
+	if updated.ID == "" {
+		updated.ID = uuid.New().String()
+	}
+	updated.RotatedTo = successor.ID
+	// Never extend an expiry that falls inside the overlap window.
+	if cutoff := now.Add(overlap); updated.ExpiresAtTime().IsZero() || cutoff.Before(updated.ExpiresAtTime()) {
+		updated.ExpiresAt = cutoff.Format(time.RFC3339)
+	}
+
+	h.cfg.APIKeys[targetIndex] = updated
+	h.cfg.APIKeys = append(h.cfg.APIKeys, successor)
+	if errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); errSave != nil {
+		h.cfg.APIKeys = h.cfg.APIKeys[:len(h.cfg.APIKeys)-1]
+		h.cfg.APIKeys[targetIndex] = old
+		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	c.JSON(201, gin.H{"api-key": successor, "key": plaintext, "previous": updated})
+}
+
+// normalizeIPRules trims CIDR/address entries and rejects invalid ones.
+func normalizeIPRules(values []string) ([]string, error) {
+	var out []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(value); err != nil && net.ParseIP(value) == nil {
+			return nil, fmt.Errorf("invalid CIDR or address: %s", value)
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+// parseAPIKeyExpiry resolves an explicit RFC 3339 expiry or a relative lifetime in
+// seconds into the stored expires-at value. Both empty means no expiry.
+func parseAPIKeyExpiry(expiresAt string, expiresIn int64, now time.Time) (string, error) {
+	if expiresAt = strings.TrimSpace(expiresAt); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return "", fmt.Errorf("invalid expires-at: %w", err)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if expiresIn < 0 {
+		return "", fmt.Errorf("expires-in must not be negative")
+	}
+	if expiresIn == 0 {
+		return "", nil
+	}
+	return now.Add(time.Duration(expiresIn) * time.Second).UTC().Format(time.RFC3339), nil
 }
 
 // IncrementAPIKeyUsage atomically increments the usage count for an API key.
@@ -876,12 +1161,13 @@ func (h *Handler) PutOpenAICompat(c *gin.Context) {
 }
 func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 	type openAICompatPatch struct {
-		Name          *string                             `json:"name"`
-		Prefix        *string                             `json:"prefix"`
-		BaseURL       *string                             `json:"base-url"`
-		APIKeyEntries *[]config.OpenAICompatibilityAPIKey `json:"api-key-entries"`
-		Models        *[]config.OpenAICompatibilityModel  `json:"models"`
-		Headers       *map[string]string                  `json:"headers"`
+		Name           *string                             `json:"name"`
+		Prefix         *string                             `json:"prefix"`
+		BaseURL        *string                             `json:"base-url"`
+		APIKeyEntries  *[]config.OpenAICompatibilityAPIKey `json:"api-key-entries"`
+		Models         *[]config.OpenAICompatibilityModel  `json:"models"`
+		DiscoverModels *config.ModelDiscovery              `json:"discover-models"`
+		Headers        *map[string]string                  `json:"headers"`
 	}
 	var body struct {
 		Name  *string            `json:"name"`
@@ -933,6 +1219,9 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 	if body.Value.Models != nil {
 		entry.Models = append([]config.OpenAICompatibilityModel(nil), (*body.Value.Models)...)
 	}
+	if body.Value.DiscoverModels != nil {
+		entry.DiscoverModels = body.Value.DiscoverModels
+	}
 	if body.Value.Headers != nil {
 		entry.Headers = config.NormalizeHeaders(*body.Value.Headers)
 	}
@@ -1107,6 +1396,626 @@ func (h *Handler) DeleteVertexCompatKey(c *gin.Context) {
 	c.JSON(400, gin.H{"error": "missing api-key or index"})
 }
 
+// bedrock-api-key: []BedrockKey
+func (h *Handler) GetBedrockKeys(c *gin.Context) {
+	c.JSON(200, gin.H{"bedrock-api-key": h.cfg.BedrockKey})
+}
+func (h *Handler) PutBedrockKeys(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.BedrockKey
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.BedrockKey `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.BedrockKey = arr
+	h.cfg.SanitizeBedrockKeys()
+	h.persist(c)
+}
+
+// PatchBedrockKey updates one entry selected by index or by match, which is compared
+// with the access key ID or "profile:<name>".
+func (h *Handler) PatchBedrockKey(c *gin.Context) {
+	type bedrockPatch struct {
+		AccessKeyID     *string                `json:"access-key-id"`
+		SecretAccessKey *string                `json:"secret-access-key"`
+		SessionToken    *string                `json:"session-token"`
+		Profile         *string                `json:"profile"`
+		Region          *string                `json:"region"`
+		Disabled        *bool                  `json:"disabled"`
+		Priority        *int                   `json:"priority"`
+		Prefix          *string                `json:"prefix"`
+		Tenant          *string                `json:"tenant"`
+		BaseURL         *string                `json:"base-url"`
+		ModelIDPrefix   *string                `json:"model-id-prefix"`
+		ProxyURL        *string                `json:"proxy-url"`
+		Headers         *map[string]string     `json:"headers"`
+		Models          *[]config.BedrockModel `json:"models"`
+		ExcludedModels  *[]string              `json:"excluded-models"`
+	}
+	var body struct {
+		Index *int          `json:"index"`
+		Match *string       `json:"match"`
+		Value *bedrockPatch `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.BedrockKey) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Match != nil {
+		match := strings.TrimSpace(*body.Match)
+		if match != "" {
+			for i := range h.cfg.BedrockKey {
+				if h.cfg.BedrockKey[i].GetAPIKey() == match {
+					targetIndex = i
+					break
+				}
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.BedrockKey[targetIndex]
+	if body.Value.AccessKeyID != nil {
+		entry.AccessKeyID = *body.Value.AccessKeyID
+	}
+	if body.Value.SecretAccessKey != nil {
+		entry.SecretAccessKey = *body.Value.SecretAccessKey
+	}
+	if body.Value.SessionToken != nil {
+		entry.SessionToken = *body.Value.SessionToken
+	}
+	if body.Value.Profile != nil {
+		entry.Profile = *body.Value.Profile
+	}
+	if body.Value.Region != nil {
+		entry.Region = *body.Value.Region
+	}
+	if body.Value.Disabled != nil {
+		entry.Disabled = *body.Value.Disabled
+	}
+	if body.Value.Priority != nil {
+		entry.Priority = *body.Value.Priority
+	}
+	if body.Value.Prefix != nil {
+		entry.Prefix = *body.Value.Prefix
+	}
+	if body.Value.Tenant != nil {
+		entry.Tenant = *body.Value.Tenant
+	}
+	if body.Value.BaseURL != nil {
+		entry.BaseURL = *body.Value.BaseURL
+	}
+	if body.Value.ModelIDPrefix != nil {
+		entry.ModelIDPrefix = *body.Value.ModelIDPrefix
+	}
+	if body.Value.ProxyURL != nil {
+		entry.ProxyURL = *body.Value.ProxyURL
+	}
+	if body.Value.Headers != nil {
+		entry.Headers = *body.Value.Headers
+	}
+	if body.Value.Models != nil {
+		entry.Models = append([]config.BedrockModel(nil), (*body.Value.Models)...)
+	}
+	if body.Value.ExcludedModels != nil {
+		entry.ExcludedModels = *body.Value.ExcludedModels
+	}
+	// Entries left without region or credentials are dropped by the sanitizer.
+	h.cfg.BedrockKey[targetIndex] = entry
+	h.cfg.SanitizeBedrockKeys()
+	h.persist(c)
+}
+
+func (h *Handler) DeleteBedrockKey(c *gin.Context) {
+	if val := strings.TrimSpace(c.Query("match")); val != "" {
+		out := make([]config.BedrockKey, 0, len(h.cfg.BedrockKey))
+		for _, v := range h.cfg.BedrockKey {
+			if v.GetAPIKey() != val {
+				out = append(out, v)
+			}
+		}
+		h.cfg.BedrockKey = out
+		h.cfg.SanitizeBedrockKeys()
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, errScan := fmt.Sscanf(idxStr, "%d", &idx)
+		if errScan == nil && idx >= 0 && idx < len(h.cfg.BedrockKey) {
+			h.cfg.BedrockKey = append(h.cfg.BedrockKey[:idx], h.cfg.BedrockKey[idx+1:]...)
+			h.cfg.SanitizeBedrockKeys()
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing match or index"})
+}
+
+// azure-openai: []AzureOpenAIKey
+func (h *Handler) GetAzureOpenAIKeys(c *gin.Context) {
+	c.JSON(200, gin.H{"azure-openai": h.cfg.AzureOpenAIKey})
+}
+func (h *Handler) PutAzureOpenAIKeys(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.AzureOpenAIKey
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.AzureOpenAIKey `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.AzureOpenAIKey = arr
+	h.cfg.SanitizeAzureOpenAIKeys()
+	h.persist(c)
+}
+
+// PatchAzureOpenAIKey updates one entry selected by index or by match, which is compared
+// with the api-key or "entra:<client-id>".
+func (h *Handler) PatchAzureOpenAIKey(c *gin.Context) {
+	type azurePatch struct {
+		Name                *string                    `json:"name"`
+		BaseURL             *string                    `json:"base-url"`
+		APIKey              *string                    `json:"api-key"`
+		Entra               *config.AzureEntraAuth     `json:"entra"`
+		APIVersion          *string                    `json:"api-version"`
+		ResponsesAPIVersion *string                    `json:"responses-api-version"`
+		Disabled            *bool                      `json:"disabled"`
+		Priority            *int                       `json:"priority"`
+		Prefix              *string                    `json:"prefix"`
+		Tenant              *string                    `json:"tenant"`
+		ProxyURL            *string                    `json:"proxy-url"`
+		Headers             *map[string]string         `json:"headers"`
+		Models              *[]config.AzureOpenAIModel `json:"models"`
+		ExcludedModels      *[]string                  `json:"excluded-models"`
+	}
+	var body struct {
+		Index *int        `json:"index"`
+		Match *string     `json:"match"`
+		Value *azurePatch `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.AzureOpenAIKey) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Match != nil {
+		match := strings.TrimSpace(*body.Match)
+		if match != "" {
+			for i := range h.cfg.AzureOpenAIKey {
+				if h.cfg.AzureOpenAIKey[i].GetAPIKey() == match {
+					targetIndex = i
+					break
+				}
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.AzureOpenAIKey[targetIndex]
+	if body.Value.Name != nil {
+		entry.Name = *body.Value.Name
+	}
+	if body.Value.BaseURL != nil {
+		entry.BaseURL = *body.Value.BaseURL
+	}
+	if body.Value.APIKey != nil {
+		entry.APIKey = *body.Value.APIKey
+	}
+	if body.Value.Entra != nil {
+		entra := *body.Value.Entra
+		entry.Entra = &entra
+	}
+	if body.Value.APIVersion != nil {
+		entry.APIVersion = *body.Value.APIVersion
+	}
+	if body.Value.ResponsesAPIVersion != nil {
+		entry.ResponsesAPIVersion = *body.Value.ResponsesAPIVersion
+	}
+	if body.Value.Disabled != nil {
+		entry.Disabled = *body.Value.Disabled
+	}
+	if body.Value.Priority != nil {
+		entry.Priority = *body.Value.Priority
+	}
+	if body.Value.Prefix != nil {
+		entry.Prefix = *body.Value.Prefix
+	}
+	if body.Value.Tenant != nil {
+		entry.Tenant = *body.Value.Tenant
+	}
+	if body.Value.ProxyURL != nil {
+		entry.ProxyURL = *body.Value.ProxyURL
+	}
+	if body.Value.Headers != nil {
+		entry.Headers = *body.Value.Headers
+	}
+	if body.Value.Models != nil {
+		entry.Models = append([]config.AzureOpenAIModel(nil), (*body.Value.Models)...)
+	}
+	if body.Value.ExcludedModels != nil {
+		entry.ExcludedModels = *body.Value.ExcludedModels
+	}
+	// Entries left without base-url, credentials or deployments are dropped by the sanitizer.
+	h.cfg.AzureOpenAIKey[targetIndex] = entry
+	h.cfg.SanitizeAzureOpenAIKeys()
+	h.persist(c)
+}
+
+func (h *Handler) DeleteAzureOpenAIKey(c *gin.Context) {
+	if val := strings.TrimSpace(c.Query("match")); val != "" {
+		out := make([]config.AzureOpenAIKey, 0, len(h.cfg.AzureOpenAIKey))
+		for _, v := range h.cfg.AzureOpenAIKey {
+			if v.GetAPIKey() != val {
+				out = append(out, v)
+			}
+		}
+		h.cfg.AzureOpenAIKey = out
+		h.cfg.SanitizeAzureOpenAIKeys()
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, errScan := fmt.Sscanf(idxStr, "%d", &idx)
+		if errScan == nil && idx >= 0 && idx < len(h.cfg.AzureOpenAIKey) {
+			h.cfg.AzureOpenAIKey = append(h.cfg.AzureOpenAIKey[:idx], h.cfg.AzureOpenAIKey[idx+1:]...)
+			h.cfg.SanitizeAzureOpenAIKeys()
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing match or index"})
+}
+
+// local-providers: []LocalProvider
+func (h *Handler) GetLocalProviders(c *gin.Context) {
+	c.JSON(200, gin.H{"local-providers": h.cfg.LocalProviders})
+}
+func (h *Handler) PutLocalProviders(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.LocalProvider
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.LocalProvider `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.LocalProviders = arr
+	h.cfg.SanitizeLocalProviders()
+	h.persist(c)
+}
+
+// PatchLocalProvider updates one entry selected by index or by name.
+func (h *Handler) PatchLocalProvider(c *gin.Context) {
+	type localPatch struct {
+		Name              *string              `json:"name"`
+		Type              *string              `json:"type"`
+		BaseURL           *string              `json:"base-url"`
+		APIKey            *string              `json:"api-key"`
+		DiscoveryInterval *string              `json:"discovery-interval"`
+		Disabled          *bool                `json:"disabled"`
+		Priority          *int                 `json:"priority"`
+		Prefix            *string              `json:"prefix"`
+		Tenant            *string              `json:"tenant"`
+		ProxyURL          *string              `json:"proxy-url"`
+		Headers           *map[string]string   `json:"headers"`
+		Models            *[]config.LocalModel `json:"models"`
+		ExcludedModels    *[]string            `json:"excluded-models"`
+	}
+	var body struct {
+		Index *int        `json:"index"`
+		Name  *string     `json:"name"`
+		Value *localPatch `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.LocalProviders) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		for i := range h.cfg.LocalProviders {
+			if name != "" && strings.EqualFold(h.cfg.LocalProviders[i].Name, name) {
+				targetIndex = i
+				break
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.LocalProviders[targetIndex]
+	if body.Value.Name != nil {
+		entry.Name = *body.Value.Name
+	}
+	if body.Value.Type != nil {
+		entry.Type = *body.Value.Type
+	}
+	if body.Value.BaseURL != nil {
+		entry.BaseURL = *body.Value.BaseURL
+	}
+	if body.Value.APIKey != nil {
+		entry.APIKey = *body.Value.APIKey
+	}
+	if body.Value.DiscoveryInterval != nil {
+		entry.DiscoveryInterval = *body.Value.DiscoveryInterval
+	}
+	if body.Value.Disabled != nil {
+		entry.Disabled = *body.Value.Disabled
+	}
+	if body.Value.Priority != nil {
+		entry.Priority = *body.Value.Priority
+	}
+	if body.Value.Prefix != nil {
+		entry.Prefix = *body.Value.Prefix
+	}
+	if body.Value.Tenant != nil {
+		entry.Tenant = *body.Value.Tenant
+	}
+	if body.Value.ProxyURL != nil {
+		entry.ProxyURL = *body.Value.ProxyURL
+	}
+	if body.Value.Headers != nil {
+		entry.Headers = *body.Value.Headers
+	}
+	if body.Value.Models != nil {
+		entry.Models = append([]config.LocalModel(nil), (*body.Value.Models)...)
+	}
+	if body.Value.ExcludedModels != nil {
+		entry.ExcludedModels = *body.Value.ExcludedModels
+	}
+	h.cfg.LocalProviders[targetIndex] = entry
+	h.cfg.SanitizeLocalProviders()
+	h.persist(c)
+}
+
+func (h *Handler) DeleteLocalProvider(c *gin.Context) {
+	if name := strings.TrimSpace(c.Query("name")); name != "" {
+		out := make([]config.LocalProvider, 0, len(h.cfg.LocalProviders))
+		for _, v := range h.cfg.LocalProviders {
+			if !strings.EqualFold(v.Name, name) {
+				out = append(out, v)
+			}
+		}
+		h.cfg.LocalProviders = out
+		h.cfg.SanitizeLocalProviders()
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, errScan := fmt.Sscanf(idxStr, "%d", &idx)
+		if errScan == nil && idx >= 0 && idx < len(h.cfg.LocalProviders) {
+			h.cfg.LocalProviders = append(h.cfg.LocalProviders[:idx], h.cfg.LocalProviders[idx+1:]...)
+			h.cfg.SanitizeLocalProviders()
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing name or index"})
+}
+
+// oauth-providers: []OAuthProvider
+func (h *Handler) GetOAuthProviders(c *gin.Context) {
+	c.JSON(200, gin.H{"oauth-providers": h.cfg.OAuthProviders})
+}
+func (h *Handler) PutOAuthProviders(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.OAuthProvider
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.OAuthProvider `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.OAuthProviders = arr
+	h.cfg.SanitizeOAuthProviders()
+	SetDeclaredOAuthProviders(h.cfg)
+	h.persist(c)
+}
+
+// PatchOAuthProvider updates one entry selected by index or by name.
+func (h *Handler) PatchOAuthProvider(c *gin.Context) {
+	type oauthProviderPatch struct {
+		Name                   *string                      `json:"name"`
+		Flow                   *string                      `json:"flow"`
+		ClientID               *string                      `json:"client-id"`
+		ClientSecret           *string                      `json:"client-secret"`
+		AuthorizeURL           *string                      `json:"authorize-url"`
+		TokenURL               *string                      `json:"token-url"`
+		DeviceAuthorizationURL *string                      `json:"device-authorization-url"`
+		RedirectURL            *string                      `json:"redirect-url"`
+		CallbackPort           *int                         `json:"callback-port"`
+		Scopes                 *[]string                    `json:"scopes"`
+		PKCE                   *bool                        `json:"pkce"`
+		AuthParams             *map[string]string           `json:"auth-params"`
+		RefreshLead            *string                      `json:"refresh-lead"`
+		BaseURL                *string                      `json:"base-url"`
+		Format                 *string                      `json:"format"`
+		Headers                *map[string]string           `json:"headers"`
+		Priority               *int                         `json:"priority"`
+		Prefix                 *string                      `json:"prefix"`
+		ProxyURL               *string                      `json:"proxy-url"`
+		Models                 *[]config.OAuthProviderModel `json:"models"`
+		ExcludedModels         *[]string                    `json:"excluded-models"`
+	}
+	var body struct {
+		Index *int                `json:"index"`
+		Name  *string             `json:"name"`
+		Value *oauthProviderPatch `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.OAuthProviders) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		for i := range h.cfg.OAuthProviders {
+			if name != "" && strings.EqualFold(h.cfg.OAuthProviders[i].Name, name) {
+				targetIndex = i
+				break
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.OAuthProviders[targetIndex]
+	v := body.Value
+	if v.Name != nil {
+		entry.Name = *v.Name
+	}
+	if v.Flow != nil {
+		entry.Flow = *v.Flow
+	}
+	if v.ClientID != nil {
+		entry.ClientID = *v.ClientID
+	}
+	if v.ClientSecret != nil {
+		entry.ClientSecret = *v.ClientSecret
+	}
+	if v.AuthorizeURL != nil {
+		entry.AuthorizeURL = *v.AuthorizeURL
+	}
+	if v.TokenURL != nil {
+		entry.TokenURL = *v.TokenURL
+	}
+	if v.DeviceAuthorizationURL != nil {
+		entry.DeviceAuthorizationURL = *v.DeviceAuthorizationURL
+	}
+	if v.RedirectURL != nil {
+		entry.RedirectURL = *v.RedirectURL
+	}
+	if v.CallbackPort != nil {
+		entry.CallbackPort = *v.CallbackPort
+	}
+	if v.Scopes != nil {
+		entry.Scopes = *v.Scopes
+	}
+	if v.PKCE != nil {
+		entry.PKCE = *v.PKCE
+	}
+	if v.AuthParams != nil {
+		entry.AuthParams = *v.AuthParams
+	}
+	if v.RefreshLead != nil {
+		entry.RefreshLead = *v.RefreshLead
+	}
+	if v.BaseURL != nil {
+		entry.BaseURL = *v.BaseURL
+	}
+	if v.Format != nil {
+		entry.Format = *v.Format
+	}
+	if v.Headers != nil {
+		entry.Headers = *v.Headers
+	}
+	if v.Priority != nil {
+		entry.Priority = *v.Priority
+	}
+	if v.Prefix != nil {
+		entry.Prefix = *v.Prefix
+	}
+	if v.ProxyURL != nil {
+		entry.ProxyURL = *v.ProxyURL
+	}
+	if v.Models != nil {
+		entry.Models = append([]config.OAuthProviderModel(nil), (*v.Models)...)
+	}
+	if v.ExcludedModels != nil {
+		entry.ExcludedModels = *v.ExcludedModels
+	}
+	h.cfg.OAuthProviders[targetIndex] = entry
+	h.cfg.SanitizeOAuthProviders()
+	SetDeclaredOAuthProviders(h.cfg)
+	h.persist(c)
+}
+
+func (h *Handler) DeleteOAuthProvider(c *gin.Context) {
+	if name := strings.TrimSpace(c.Query("name")); name != "" {
+		out := make([]config.OAuthProvider, 0, len(h.cfg.OAuthProviders))
+		for _, v := range h.cfg.OAuthProviders {
+			if !strings.EqualFold(v.Name, name) {
+				out = append(out, v)
+			}
+		}
+		h.cfg.OAuthProviders = out
+		SetDeclaredOAuthProviders(h.cfg)
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, errScan := fmt.Sscanf(idxStr, "%d", &idx)
+		if errScan == nil && idx >= 0 && idx < len(h.cfg.OAuthProviders) {
+			h.cfg.OAuthProviders = append(h.cfg.OAuthProviders[:idx], h.cfg.OAuthProviders[idx+1:]...)
+			SetDeclaredOAuthProviders(h.cfg)
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing name or index"})
+}
+
 // oauth-excluded-models: map[string][]string
 func (h *Handler) GetOAuthExcludedModels(c *gin.Context) {
 	c.JSON(200, gin.H{"oauth-excluded-models": config.NormalizeOAuthExcludedModels(h.cfg.OAuthExcludedModels)})
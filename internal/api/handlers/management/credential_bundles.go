@@ -0,0 +1,235 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/credbundle"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// maxBundleSize bounds uploaded credential bundles.
+const maxBundleSize = 32 << 20
+
+type exportBundleRequest struct {
+	// Names limits the export to these auth files; empty exports every visible credential.
+	Names []string `json:"names"`
+	// Passphrase encrypts the bundle; defaults to credential-bundles.passphrase.
+	Passphrase *string `json:"passphrase"`
+}
+
+// ExportAuthFiles returns the selected auth files and their settings as one signed,
+// optionally encrypted credential bundle.
+func (h *Handler) ExportAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req exportBundleRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	signingKey, _, err := credbundle.KeysFromConfig(h.cfg.CredentialBundles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	passphrase := h.cfg.CredentialBundles.Passphrase
+	if req.Passphrase != nil {
+		passphrase = *req.Passphrase
+	}
+
+	wanted := make(map[string]bool, len(req.Names))
+	for _, name := range req.Names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			wanted[name] = false
+		}
+	}
+	entries := make([]credbundle.Entry, 0)
+	for _, auth := range h.authManager.List() {
+		if !credbundle.Exportable(auth) || !tenantVisible(c, auth.Tenant()) {
+			continue
+		}
+		name := strings.ToLower(credbundle.AuthName(auth))
+		if len(wanted) > 0 {
+			if _, ok := wanted[name]; !ok {
+				continue
+			}
+			wanted[name] = true
+		}
+		entry, errEntry := credbundle.EntryFromAuth(auth)
+		if errEntry != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": errEntry.Error()})
+			return
+		}
+		entries = append(entries, entry)
+	}
+	for name, found := range wanted {
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("auth file not found: %s", name)})
+			return
+		}
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no credentials to export"})
+		return
+	}
+
+	data, err := credbundle.Build(entries, credbundle.BuildOptions{Passphrase: passphrase, SigningKey: signingKey})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to build bundle: %v", err)})
+		return
+	}
+	filename := fmt.Sprintf("credentials-%s.bundle.json", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+type importBundleRequest struct {
+	Bundle     json.RawMessage `json:"bundle"`
+	Passphrase *string         `json:"passphrase"`
+	Replace    bool            `json:"replace"`
+	DropProxy  bool            `json:"dropProxy"`
+	DryRun     bool            `json:"dryRun"`
+	Names      []string        `json:"names"`
+}
+
+// ImportAuthFiles imports a credential bundle uploaded as multipart "file" or as JSON
+// {"bundle": {...}}. Accounts that already exist are skipped unless replace is set, and
+// imported credentials are registered immediately.
+func (h *Handler) ImportAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	req, err := readImportBundleRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Bundle) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bundle is required"})
+		return
+	}
+	tenant := principalTenant(c)
+	if req.Replace && denyTenant(c, "tenant accounts cannot replace existing credentials") {
+		return
+	}
+	_, trustedKeys, err := credbundle.KeysFromConfig(h.cfg.CredentialBundles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	passphrase := h.cfg.CredentialBundles.Passphrase
+	if req.Passphrase != nil {
+		passphrase = *req.Passphrase
+	}
+	contents, err := credbundle.Open(req.Bundle, credbundle.OpenOptions{
+		Passphrase:       passphrase,
+		TrustedKeys:      trustedKeys,
+		RequireSignature: h.cfg.CredentialBundles.RequireSignature,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, credbundle.ErrBadSignature) || errors.Is(err, credbundle.ErrUntrustedSigner) || errors.Is(err, credbundle.ErrSignatureRequired) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	authDir := h.cfg.AuthDir
+	if abs, errAbs := filepath.Abs(authDir); errAbs == nil {
+		authDir = abs
+	}
+	existing := h.authManager.List()
+	results := credbundle.Import(c.Request.Context(), contents.Entries, credbundle.Target{
+		Store:    h.tokenStoreWithBaseDir(),
+		Manager:  h.authManager,
+		Config:   h.cfg,
+		AuthDir:  authDir,
+		Existing: existing,
+	}, credbundle.ImportOptions{
+		Replace:   req.Replace,
+		DropProxy: req.DropProxy,
+		Tenant:    tenant,
+		DryRun:    req.DryRun,
+		Names:     req.Names,
+	})
+	if tenant != "" {
+		hideForeignConflicts(c, results, existing)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"created_at": contents.CreatedAt,
+		"encrypted":  contents.Encrypted,
+		"signer":     contents.Signer,
+		"dry_run":    req.DryRun,
+		"results":    results,
+	})
+}
+
+func readImportBundleRequest(c *gin.Context) (importBundleRequest, error) {
+	var req importBundleRequest
+	if file, err := c.FormFile("file"); err == nil && file != nil {
+		if file.Size > maxBundleSize {
+			return req, fmt.Errorf("bundle exceeds %d bytes", maxBundleSize)
+		}
+		f, errOpen := file.Open()
+		if errOpen != nil {
+			return req, fmt.Errorf("failed to read bundle: %v", errOpen)
+		}
+		defer func() { _ = f.Close() }()
+		if req.Bundle, err = io.ReadAll(f); err != nil {
+			return req, fmt.Errorf("failed to read bundle: %v", err)
+		}
+		if passphrase, ok := c.GetPostForm("passphrase"); ok {
+			req.Passphrase = &passphrase
+		}
+		req.Replace, _ = strconv.ParseBool(c.PostForm("replace"))
+		req.DropProxy, _ = strconv.ParseBool(c.PostForm("dropProxy"))
+		req.DryRun, _ = strconv.ParseBool(c.PostForm("dryRun"))
+		for _, name := range strings.Split(c.PostForm("names"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				req.Names = append(req.Names, name)
+			}
+		}
+		return req, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBundleSize+1))
+	if err != nil {
+		return req, fmt.Errorf("failed to read body")
+	}
+	if len(body) > maxBundleSize {
+		return req, fmt.Errorf("bundle exceeds %d bytes", maxBundleSize)
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		return req, fmt.Errorf("invalid body")
+	}
+	return req, nil
+}
+
+// hideForeignConflicts clears conflict names that refer to credentials of other tenants.
+func hideForeignConflicts(c *gin.Context, results []credbundle.Result, existing []*coreauth.Auth) {
+	foreign := make(map[string]struct{})
+	for _, auth := range existing {
+		if !tenantVisible(c, auth.Tenant()) {
+			foreign[strings.ToLower(credbundle.AuthName(auth))] = struct{}{}
+		}
+	}
+	for i := range results {
+		if _, ok := foreign[strings.ToLower(results[i].Conflict)]; ok {
+			results[i].Conflict = ""
+		}
+	}
+}
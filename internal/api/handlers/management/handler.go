@@ -14,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
@@ -49,6 +50,7 @@ type Handler struct {
 	envSecret           string
 	logDir              string
 	postAuthHook        coreauth.PostAuthHook
+	backupScheduler     *backup.Scheduler
 }
 
 // NewHandler creates a new management handler instance.
@@ -67,6 +69,7 @@ func NewHandler(cfg *config.Config, configFilePath string, manager *coreauth.Man
 		envSecret:           envSecret,
 	}
 	h.startAttemptCleanup()
+	SetDeclaredOAuthProviders(cfg)
 	return h
 }
 
@@ -106,7 +109,10 @@ func NewHandlerWithoutConfigFilePath(cfg *config.Config, manager *coreauth.Manag
 }
 
 // SetConfig updates the in-memory config reference when the server hot-reloads.
-func (h *Handler) SetConfig(cfg *config.Config) { h.cfg = cfg }
+func (h *Handler) SetConfig(cfg *config.Config) {
+	h.cfg = cfg
+	SetDeclaredOAuthProviders(cfg)
+}
 
 // SetAuthManager updates the auth manager reference used by management endpoints.
 func (h *Handler) SetAuthManager(manager *coreauth.Manager) { h.authManager = manager }
@@ -191,10 +197,12 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		var (
 			allowRemote bool
 			secretHash  string
+			hasAccounts bool
 		)
 		if cfg != nil {
 			allowRemote = cfg.RemoteManagement.AllowRemote
 			secretHash = cfg.RemoteManagement.SecretKey
+			hasAccounts = len(cfg.RemoteManagement.Accounts) > 0
 		}
 		if h.allowRemoteOverride {
 			allowRemote = true
@@ -241,7 +249,18 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				h.attemptsMu.Unlock()
 			}
 		}
-		if secretHash == "" && envSecret == "" {
+		resetAttempts := func() {
+			if localClient {
+				return
+			}
+			h.attemptsMu.Lock()
+			if ai := h.failedAttempts[clientIP]; ai != nil {
+				ai.count = 0
+				ai.blockedUntil = time.Time{}
+			}
+			h.attemptsMu.Unlock()
+		}
+		if !hasAccounts && secretHash == "" && envSecret == "" {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -271,22 +290,28 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
-					c.Next()
+					h.authorize(c, adminPrincipal)
 					return
 				}
 			}
 		}
 
 		if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
-			if !localClient {
-				h.attemptsMu.Lock()
-				if ai := h.failedAttempts[clientIP]; ai != nil {
-					ai.count = 0
-					ai.blockedUntil = time.Time{}
+			resetAttempts()
+			h.authorize(c, adminPrincipal)
+			return
+		}
+
+		if principal, isAccountToken := authenticateAccount(cfg, provided); isAccountToken {
+			if principal == nil {
+				if !localClient {
+					fail()
 				}
-				h.attemptsMu.Unlock()
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+				return
 			}
-			c.Next()
+			resetAttempts()
+			h.authorize(c, principal)
 			return
 		}
 
@@ -298,17 +323,35 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			return
 		}
 
-		if !localClient {
-			h.attemptsMu.Lock()
-			if ai := h.failedAttempts[clientIP]; ai != nil {
-				ai.count = 0
-				ai.blockedUntil = time.Time{}
-			}
-			h.attemptsMu.Unlock()
-		}
+		resetAttempts()
+		h.authorize(c, adminPrincipal)
+	}
+}
 
-		c.Next()
+// authorize records the principal and rejects routes outside its role.
+func (h *Handler) authorize(c *gin.Context, principal *Principal) {
+	c.Set(principalContextKey, principal)
+	if required := requiredPermission(c.Request.Method, managementRoute(c)); !principal.Allows(required) {
+		reason := fmt.Sprintf("role %s lacks %s permission", principal.Role, required)
+		h.auditDenied(c, reason)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": reason})
+		return
+	}
+	if principal.Tenant != "" && !tenantRouteAllowed(managementRoute(c)) {
+		reason := "route not available to tenant accounts"
+		h.auditDenied(c, reason)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": reason})
+		return
+	}
+	if !principal.Allows(PermissionAdmin) {
+		if ref := h.disallowedSecretReference(c); ref != "" {
+			reason := fmt.Sprintf("secret reference %s is not allowed for role %s", ref, principal.Role)
+			h.auditDenied(c, reason)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": reason})
+			return
+		}
 	}
+	c.Next()
 }
 
 // persist saves the current in-memory config to disk.
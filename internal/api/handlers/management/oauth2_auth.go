@@ -0,0 +1,189 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/oauth2"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestOAuth2Token starts the login flow of the declarative OAuth provider named by the
+// provider query parameter. Client-credentials providers complete synchronously; the
+// device-code and authorization-code flows return a URL and finish in the background.
+func (h *Handler) RequestOAuth2Token(c *gin.Context) {
+	ctx := context.Background()
+	ctx = PopulateAuthContext(ctx, c)
+
+	entry := h.cfg.FindOAuthProvider(c.Query("provider"))
+	if entry == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown oauth provider"})
+		return
+	}
+	provider := *entry
+	client := oauth2.NewClient(h.cfg, &provider)
+
+	log.Infof("Initializing %s authentication...", provider.Name)
+
+	switch provider.Flow {
+	case config.OAuthFlowClientCredentials:
+		token, err := client.ClientCredentials(ctx)
+		if err != nil {
+			log.Errorf("%s: client credentials grant failed: %v", provider.Name, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "client credentials grant failed"})
+			return
+		}
+		savedPath, errSave := h.saveTokenRecord(ctx, sdkAuth.NewOAuth2Record(&provider, token))
+		if errSave != nil {
+			log.Errorf("Failed to save authentication tokens: %v", errSave)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save authentication tokens"})
+			return
+		}
+		log.Infof("Authentication successful! Token saved to %s", savedPath)
+		c.JSON(200, gin.H{"status": "ok"})
+
+	case config.OAuthFlowDeviceCode:
+		deviceCode, err := client.StartDeviceFlow(ctx)
+		if err != nil {
+			log.Errorf("%s: failed to start device flow: %v", provider.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate authorization url"})
+			return
+		}
+		state, errState := misc.GenerateRandomState()
+		if errState != nil {
+			log.Errorf("Failed to generate state parameter: %v", errState)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate state parameter"})
+			return
+		}
+		RegisterOAuthSession(state, provider.Name)
+
+		go func() {
+			log.Info("Waiting for authentication...")
+			token, errPoll := client.PollDeviceToken(ctx, deviceCode)
+			if errPoll != nil {
+				SetOAuthSessionError(state, "Authentication failed")
+				log.Errorf("%s: authentication failed: %v", provider.Name, errPoll)
+				return
+			}
+			h.completeOAuth2Login(ctx, &provider, state, token)
+		}()
+
+		c.JSON(200, gin.H{"status": "ok", "url": deviceCode.VerificationURL(), "user_code": deviceCode.UserCode, "state": state})
+
+	default:
+		var pkce *oauth2.PKCECodes
+		if provider.PKCE {
+			codes, errPKCE := oauth2.GeneratePKCECodes()
+			if errPKCE != nil {
+				log.Errorf("Failed to generate PKCE codes: %v", errPKCE)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PKCE codes"})
+				return
+			}
+			pkce = codes
+		}
+		state, err := misc.GenerateRandomState()
+		if err != nil {
+			log.Errorf("Failed to generate state parameter: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate state parameter"})
+			return
+		}
+		port, redirectURI := provider.Callback()
+		authURL, err := client.AuthorizationURL(state, redirectURI, pkce)
+		if err != nil {
+			log.Errorf("Failed to generate authorization URL: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate authorization url"})
+			return
+		}
+
+		RegisterOAuthSession(state, provider.Name)
+
+		isWebUI := isWebUIRequest(c)
+		var forwarder *callbackForwarder
+		if isWebUI {
+			targetURL, errTarget := h.managementCallbackURL("/oauth2/" + provider.Name + "/callback")
+			if errTarget != nil {
+				log.WithError(errTarget).Errorf("failed to compute %s callback target", provider.Name)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "callback server unavailable"})
+				return
+			}
+			var errStart error
+			if forwarder, errStart = startCallbackForwarder(port, provider.Name, targetURL); errStart != nil {
+				log.WithError(errStart).Errorf("failed to start %s callback forwarder", provider.Name)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start callback server"})
+				return
+			}
+		}
+
+		go func() {
+			if isWebUI {
+				defer stopCallbackForwarderInstance(port, forwarder)
+			}
+
+			log.Info("Waiting for authentication callback...")
+			waitFile := filepath.Join(h.cfg.AuthDir, fmt.Sprintf(".oauth-%s-%s.oauth", provider.Name, state))
+			deadline := time.Now().Add(5 * time.Minute)
+			var resultMap map[string]string
+			for {
+				if !IsOAuthSessionPending(state, provider.Name) {
+					return
+				}
+				if time.Now().After(deadline) {
+					SetOAuthSessionError(state, "Timeout waiting for OAuth callback")
+					log.Errorf("%s: timeout waiting for OAuth callback", provider.Name)
+					return
+				}
+				data, errRead := os.ReadFile(waitFile)
+				if errRead == nil {
+					_ = json.Unmarshal(data, &resultMap)
+					_ = os.Remove(waitFile)
+					if resultMap == nil {
+						resultMap = map[string]string{}
+					}
+					break
+				}
+				time.Sleep(500 * time.Millisecond)
+			}
+			if errStr := resultMap["error"]; errStr != "" {
+				log.Errorf("%s: authorization failed: %s", provider.Name, errStr)
+				SetOAuthSessionError(state, "Bad request")
+				return
+			}
+			if resultMap["state"] != state {
+				log.Errorf("%s: state mismatch", provider.Name)
+				SetOAuthSessionError(state, "State code error")
+				return
+			}
+			token, errExchange := client.ExchangeCode(ctx, resultMap["code"], redirectURI, pkce)
+			if errExchange != nil {
+				log.Errorf("Failed to exchange authorization code for tokens: %v", errExchange)
+				SetOAuthSessionError(state, "Failed to exchange authorization code for tokens")
+				return
+			}
+			h.completeOAuth2Login(ctx, &provider, state, token)
+		}()
+
+		c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
+	}
+}
+
+// completeOAuth2Login saves the token obtained by a background login and closes its session.
+func (h *Handler) completeOAuth2Login(ctx context.Context, provider *config.OAuthProvider, state string, token *oauth2.Token) {
+	savedPath, errSave := h.saveTokenRecord(ctx, sdkAuth.NewOAuth2Record(provider, token))
+	if errSave != nil {
+		log.Errorf("Failed to save authentication tokens: %v", errSave)
+		SetOAuthSessionError(state, "Failed to save authentication tokens")
+		return
+	}
+	log.Infof("Authentication successful! Token saved to %s", savedPath)
+	CompleteOAuthSession(state)
+	CompleteOAuthSessionsByProvider(provider.Name)
+}
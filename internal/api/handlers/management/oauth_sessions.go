@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 )
 
 const (
@@ -217,8 +219,36 @@ func ValidateOAuthState(state string) error {
 	return nil
 }
 
+// declaredOAuthProviders holds the names of declarative OAuth providers from config so
+// their login sessions and callbacks are accepted alongside the built-in providers.
+var declaredOAuthProviders struct {
+	mu    sync.RWMutex
+	names map[string]struct{}
+}
+
+// SetDeclaredOAuthProviders replaces the set of declarative OAuth provider names.
+func SetDeclaredOAuthProviders(cfg *config.Config) {
+	names := make(map[string]struct{})
+	if cfg != nil {
+		for i := range cfg.OAuthProviders {
+			names[cfg.OAuthProviders[i].Name] = struct{}{}
+		}
+	}
+	declaredOAuthProviders.mu.Lock()
+	declaredOAuthProviders.names = names
+	declaredOAuthProviders.mu.Unlock()
+}
+
+func isDeclaredOAuthProvider(name string) bool {
+	declaredOAuthProviders.mu.RLock()
+	defer declaredOAuthProviders.mu.RUnlock()
+	_, ok := declaredOAuthProviders.names[name]
+	return ok
+}
+
 func NormalizeOAuthProvider(provider string) (string, error) {
-	switch strings.ToLower(strings.TrimSpace(provider)) {
+	normalized := strings.ToLower(strings.TrimSpace(provider))
+	switch normalized {
 	case "anthropic", "claude":
 		return "anthropic", nil
 	case "codex", "openai":
@@ -232,6 +262,9 @@ func NormalizeOAuthProvider(provider string) (string, error) {
 	case "qwen":
 		return "qwen", nil
 	default:
+		if isDeclaredOAuthProvider(normalized) {
+			return normalized, nil
+		}
 		return "", errUnsupportedOAuthFlow
 	}
 }
@@ -0,0 +1,33 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/proxypool"
+)
+
+// proxyPoolCheckTimeout bounds an on-demand health check of all pools.
+const proxyPoolCheckTimeout = time.Minute
+
+// GetProxyPools returns health, credential assignments and usage statistics of every
+// proxy in the configured proxy pools.
+func (h *Handler) GetProxyPools(c *gin.Context) {
+	if denyTenant(c, "proxy pools are shared by all tenants") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pools": proxypool.Default().Stats()})
+}
+
+// CheckProxyPools probes every pool proxy now and returns the updated statistics.
+func (h *Handler) CheckProxyPools(c *gin.Context) {
+	if denyTenant(c, "proxy pools are shared by all tenants") {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), proxyPoolCheckTimeout)
+	defer cancel()
+	proxypool.Default().Check(ctx)
+	c.JSON(http.StatusOK, gin.H{"pools": proxypool.Default().Stats()})
+}
@@ -0,0 +1,154 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scripting"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type scriptTestRequest struct {
+	// Name selects a configured hook; Source tests inline Lua instead.
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	// Hook is "request" (default), "response" or "chunk".
+	Hook   string `json:"hook"`
+	Format string `json:"format"`
+	Model  string `json:"model"`
+	Route  string `json:"route"`
+	Stream bool   `json:"stream"`
+	// Payload is the sample body: a JSON value, or a string used verbatim.
+	Payload json.RawMessage `json:"payload"`
+}
+
+// TestScript runs a hook script against a sample payload without sending anything
+// upstream.
+//
+// Endpoint:
+//
+//	POST /v0/management/scripts/test
+//
+// Request JSON:
+//   - name or source (one required): configured hook name, or inline Lua source.
+//   - hook (optional): "request" (default), "response" or "chunk".
+//   - format, model, route, stream (optional): values exposed to the script.
+//   - payload: sample body or chunk, as a JSON value or a string.
+//
+// Response JSON: payload (string), model (request hooks), chunks (chunk hooks),
+// duration_ms, and error/status when the script failed or rejected the payload.
+func (h *Handler) TestScript(c *gin.Context) {
+	var body scriptTestRequest
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	source := body.Source
+	var hooks []config.ScriptHook
+	timeout := time.Duration(0)
+	if h.cfg != nil {
+		hooks = h.cfg.Scripting.Hooks
+		timeout = time.Duration(h.cfg.Scripting.TimeoutMS) * time.Millisecond
+	}
+	if strings.TrimSpace(source) == "" {
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing name or source"})
+			return
+		}
+		var found bool
+		for _, hook := range hooks {
+			if hook.Name != name {
+				continue
+			}
+			found = true
+			source = hook.Source
+			if hook.File != "" {
+				data, errRead := os.ReadFile(hook.File)
+				if errRead != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read script file"})
+					return
+				}
+				source = string(data)
+			}
+			break
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "script hook not found"})
+			return
+		}
+	}
+	if name == "" {
+		name = "test"
+	}
+
+	script, errCompile := scripting.Compile(name, source)
+	if errCompile != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCompile.Error()})
+		return
+	}
+
+	payload := string(body.Payload)
+	var asString string
+	if json.Unmarshal(body.Payload, &asString) == nil {
+		payload = asString
+	}
+	format := strings.TrimSpace(body.Format)
+	if format == "" {
+		format = "openai"
+	}
+	ctx := scripting.WithPath(context.Background(), strings.TrimSpace(body.Route))
+
+	started := time.Now()
+	result := gin.H{}
+	var errRun error
+	switch strings.ToLower(strings.TrimSpace(body.Hook)) {
+	case "", "request":
+		var out sdktranslator.RequestEnvelope
+		out, errRun = script.Request(ctx, timeout, sdktranslator.RequestEnvelope{
+			Format: sdktranslator.FromString(format),
+			Model:  body.Model,
+			Stream: body.Stream,
+			Body:   []byte(payload),
+		})
+		result["payload"] = string(out.Body)
+		result["model"] = out.Model
+	case "response":
+		var out sdktranslator.ResponseEnvelope
+		out, errRun = script.Response(ctx, timeout, sdktranslator.ResponseEnvelope{
+			Format: sdktranslator.FromString(format),
+			Model:  body.Model,
+			Body:   []byte(payload),
+		})
+		result["payload"] = string(out.Body)
+	case "chunk":
+		var out sdktranslator.ResponseEnvelope
+		out, errRun = script.Response(ctx, timeout, sdktranslator.ResponseEnvelope{
+			Format: sdktranslator.FromString(format),
+			Model:  body.Model,
+			Stream: true,
+			Chunks: []string{payload},
+		})
+		result["chunks"] = out.Chunks
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hook must be request, response or chunk"})
+		return
+	}
+	result["duration_ms"] = time.Since(started).Milliseconds()
+	if errRun != nil {
+		result["error"] = errRun.Error()
+		var scriptErr *scripting.Error
+		if errors.As(errRun, &scriptErr) {
+			result["status"] = scriptErr.Status
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,127 @@
+package management
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
+	"github.com/tidwall/gjson"
+)
+
+// tenantRoutes lists the management routes open to tenant accounts, matched exactly.
+// Their handlers filter and check resources by tenant; every other route is global.
+var tenantRoutes = map[string]struct{}{
+	"/whoami":                       {},
+	"/tenants":                      {},
+	"/api-keys":                     {},
+	"/api-keys/usage":               {},
+	"/api-keys/rotate":              {},
+	"/auth-files":                   {},
+	"/auth-files/models":            {},
+	"/auth-files/download":          {},
+	"/auth-files/status":            {},
+	"/usage-records":                {},
+	"/usage-records/:id":            {},
+	"/usage-records/:id/candidates": {},
+}
+
+func tenantRouteAllowed(route string) bool {
+	_, ok := tenantRoutes[route]
+	return ok
+}
+
+// principalTenant returns the tenant the request is scoped to; empty for global principals.
+func principalTenant(c *gin.Context) string {
+	if p := PrincipalFromContext(c); p != nil {
+		return p.Tenant
+	}
+	return ""
+}
+
+// tenantVisible reports whether a resource owned by tenant may be seen and changed by the
+// request. Global principals see everything; tenant accounts only their own resources.
+func tenantVisible(c *gin.Context, tenant string) bool {
+	scope := principalTenant(c)
+	return scope == "" || scope == strings.TrimSpace(tenant)
+}
+
+// denyTenant rejects operations that span tenants when the request comes from a tenant account.
+func denyTenant(c *gin.Context, reason string) bool {
+	if principalTenant(c) == "" {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": reason})
+	return true
+}
+
+// authFileTenant returns the tenant recorded for an auth file and whether the file is known.
+func (h *Handler) authFileTenant(name string) (string, bool) {
+	if auth := h.findAuthForDelete(name); auth != nil {
+		return auth.Tenant(), true
+	}
+	data, err := encryption.ReadFile(filepath.Join(h.cfg.AuthDir, filepath.Base(name)))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(gjson.GetBytes(data, "tenant").String()), true
+}
+
+type tenantSummary struct {
+	Name        string `json:"name"`
+	APIKeys     int    `json:"api-keys"`
+	Credentials int    `json:"credentials"`
+	Accounts    int    `json:"accounts"`
+}
+
+// ListTenants returns every tenant referenced by client keys, credentials or management
+// accounts with resource counts. Tenant accounts only see their own tenant.
+func (h *Handler) ListTenants(c *gin.Context) {
+	summaries := make(map[string]*tenantSummary)
+	get := func(name string) *tenantSummary {
+		name = strings.TrimSpace(name)
+		if name == "" || !tenantVisible(c, name) {
+			return nil
+		}
+		if s, ok := summaries[name]; ok {
+			return s
+		}
+		s := &tenantSummary{Name: name}
+		summaries[name] = s
+		return s
+	}
+	if scope := principalTenant(c); scope != "" {
+		get(scope)
+	}
+
+	for _, entry := range h.cfg.APIKeys {
+		if s := get(entry.Tenant); s != nil {
+			s.APIKeys++
+		}
+	}
+	for _, account := range h.cfg.RemoteManagement.Accounts {
+		if s := get(account.Tenant); s != nil {
+			s.Accounts++
+		}
+	}
+	if h.authManager != nil {
+		for _, auth := range h.authManager.List() {
+			// Virtual Gemini project auths are counted through their parent file.
+			if authAttribute(auth, "gemini_virtual_parent") != "" {
+				continue
+			}
+			if s := get(auth.Tenant()); s != nil {
+				s.Credentials++
+			}
+		}
+	}
+
+	out := make([]tenantSummary, 0, len(summaries))
+	for _, s := range summaries {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	c.JSON(http.StatusOK, gin.H{"tenants": out})
+}
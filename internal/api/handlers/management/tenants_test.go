@@ -0,0 +1,93 @@
+package management
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestTenantAccountIsScopedToOwnResources(t *testing.T) {
+	router, h := newAccountsTestRouter(t)
+	mgmt := router.Group("/v0/management", h.Middleware())
+	mgmt.GET("/tenants", h.ListTenants)
+	mgmt.GET("/api-keys/usage", h.GetAPIKeysUsage)
+	mgmt.POST("/api-keys", h.PostAPIKey)
+	mgmt.PUT("/api-keys", h.PutAPIKeys)
+	mgmt.DELETE("/api-keys", h.DeleteAPIKeys)
+	mgmt.GET("/auth-files", h.ListAuthFiles)
+	mgmt.GET("/auth-files/download", h.DownloadAuthFile)
+
+	h.cfg.AuthDir = t.TempDir()
+	for name, body := range map[string]string{
+		"a.json":      `{"type":"codex","tenant":"a"}`,
+		"b.json":      `{"type":"codex","tenant":"b"}`,
+		"shared.json": `{"type":"codex"}`,
+	} {
+		if err := os.WriteFile(filepath.Join(h.cfg.AuthDir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+	}
+	h.cfg.APIKeys = append(h.cfg.APIKeys,
+		config.ApiKeyEntry{ID: "key-a", Key: "sk-tenant-a", IsActive: true, Tenant: "a"},
+		config.ApiKeyEntry{ID: "key-b", Key: "sk-tenant-b", IsActive: true, Tenant: "b"},
+	)
+
+	resp := doManagementRequest(router, http.MethodPost, "/v0/management/accounts", "root-secret", `{"name":"team-a","role":"admin","tenant":"a"}`)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("create account: %d %s", resp.Code, resp.Body.String())
+	}
+	token := gjson.Get(resp.Body.String(), "token").String()
+
+	resp = doManagementRequest(router, http.MethodGet, "/v0/management/api-keys", token, "")
+	if got := gjson.Get(resp.Body.String(), "api-keys").String(); got != `["sk-tenant-a"]` {
+		t.Fatalf("tenant api-keys = %s", resp.Body.String())
+	}
+	resp = doManagementRequest(router, http.MethodPost, "/v0/management/api-keys", token, `{"name":"ci"}`)
+	if resp.Code != http.StatusCreated || gjson.Get(resp.Body.String(), "api-key.tenant").String() != "a" {
+		t.Fatalf("tenant create key: %d %s", resp.Code, resp.Body.String())
+	}
+	resp = doManagementRequest(router, http.MethodGet, "/v0/management/api-keys/usage", token, "")
+	if n := gjson.Get(resp.Body.String(), "api-keys.#").Int(); n != 2 || gjson.Get(resp.Body.String(), `api-keys.#(tenant!="a")`).Exists() {
+		t.Fatalf("tenant usage listing = %s", resp.Body.String())
+	}
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodDelete, "/v0/management/api-keys?id=key-b", http.StatusNotFound},
+		{http.MethodPut, "/v0/management/api-keys", http.StatusForbidden},
+		{http.MethodGet, "/v0/management/config", http.StatusForbidden},
+		{http.MethodGet, "/v0/management/accounts", http.StatusForbidden},
+		{http.MethodGet, "/v0/management/auth-files/download?name=b.json", http.StatusNotFound},
+		{http.MethodGet, "/v0/management/auth-files/download?name=shared.json", http.StatusNotFound},
+		{http.MethodGet, "/v0/management/auth-files/download?name=a.json", http.StatusOK},
+		{http.MethodDelete, "/v0/management/api-keys?id=key-a", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if resp = doManagementRequest(router, tc.method, tc.path, token, `[]`); resp.Code != tc.want {
+			t.Fatalf("%s %s: status = %d, want %d, body = %s", tc.method, tc.path, resp.Code, tc.want, resp.Body.String())
+		}
+	}
+
+	resp = doManagementRequest(router, http.MethodGet, "/v0/management/auth-files", token, "")
+	if got := gjson.Get(resp.Body.String(), "files.#.name").String(); got != `["a.json"]` {
+		t.Fatalf("tenant auth files = %s", resp.Body.String())
+	}
+	resp = doManagementRequest(router, http.MethodGet, "/v0/management/tenants", token, "")
+	if got := gjson.Get(resp.Body.String(), "tenants.#.name").String(); got != `["a"]` {
+		t.Fatalf("tenant listing = %s", resp.Body.String())
+	}
+
+	resp = doManagementRequest(router, http.MethodGet, "/v0/management/tenants", "root-secret", "")
+	if got := gjson.Get(resp.Body.String(), "tenants.#.name").String(); got != `["a","b"]` {
+		t.Fatalf("admin tenant listing = %s", resp.Body.String())
+	}
+	if got := gjson.Get(resp.Body.String(), `tenants.#(name=="a")`); got.Get("api-keys").Int() != 1 || got.Get("accounts").Int() != 1 {
+		t.Fatalf("tenant a summary = %s", got.Raw)
+	}
+}
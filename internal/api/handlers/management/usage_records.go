@@ -22,6 +22,10 @@ func (h *Handler) GetUsageRecords(c *gin.Context) {
 	query.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
 	query.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	query.APIKey = c.Query("api_key")
+	query.Tenant = c.Query("tenant")
+	if scope := principalTenant(c); scope != "" {
+		query.Tenant = scope
+	}
 	query.Model = c.Query("model")
 	query.Provider = c.Query("provider")
 	query.StartTime = c.Query("start_time")
@@ -89,7 +93,7 @@ func (h *Handler) GetUsageRecordByID(c *gin.Context) {
 		return
 	}
 
-	if record == nil {
+	if record == nil || !tenantVisible(c, record.Tenant) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
 		return
 	}
@@ -290,7 +294,7 @@ func (h *Handler) GetRequestCandidates(c *gin.Context) {
 		return
 	}
 
-	if record == nil {
+	if record == nil || !tenantVisible(c, record.Tenant) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
 		return
 	}
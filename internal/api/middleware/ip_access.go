@@ -0,0 +1,232 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the IP access filter that applies CIDR allow/deny lists globally
+// and per client API key before requests reach the access providers.
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// IPAccessFilter enforces ip-access and per-key ip-allow/ip-deny rules. Rules are
+// swapped atomically on config reload.
+type IPAccessFilter struct {
+	rules atomic.Pointer[ipRules]
+}
+
+type ipRules struct {
+	allow          []*net.IPNet
+	deny           []*net.IPNet
+	trustedProxies []*net.IPNet
+	keys           []keyIPRule
+}
+
+// keyIPRule holds the IP lists of a client API key that has any.
+type keyIPRule struct {
+	entry config.ApiKeyEntry
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewIPAccessFilter builds a filter for cfg.
+func NewIPAccessFilter(cfg *config.Config) *IPAccessFilter {
+	f := &IPAccessFilter{}
+	f.Update(cfg)
+	return f
+}
+
+// Update replaces the rules with those from cfg. Invalid entries are logged and skipped.
+func (f *IPAccessFilter) Update(cfg *config.Config) {
+	if f == nil {
+		return
+	}
+	rules := &ipRules{}
+	if cfg != nil {
+		rules.allow = parseIPNets("ip-access.allow", cfg.IPAccess.Allow)
+		rules.deny = parseIPNets("ip-access.deny", cfg.IPAccess.Deny)
+		rules.trustedProxies = parseIPNets("ip-access.trusted-proxies", cfg.IPAccess.TrustedProxies)
+		for _, entry := range cfg.APIKeys {
+			if len(entry.IPAllow) == 0 && len(entry.IPDeny) == 0 {
+				continue
+			}
+			label := "api-keys[" + entry.Name + "]"
+			rules.keys = append(rules.keys, keyIPRule{
+				entry: entry,
+				allow: parseIPNets(label+".ip-allow", entry.IPAllow),
+				deny:  parseIPNets(label+".ip-deny", entry.IPDeny),
+			})
+		}
+	}
+	f.rules.Store(rules)
+}
+
+// Middleware rejects requests from addresses outside the configured lists with 403.
+func (f *IPAccessFilter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if f.Allow(c) {
+			c.Next()
+		}
+	}
+}
+
+// Allow checks the request and aborts it with 403 when the client address is denied.
+// Denials are counted in the usage statistics under the presented key, if any.
+func (f *IPAccessFilter) Allow(c *gin.Context) bool {
+	if f == nil {
+		return true
+	}
+	rules := f.rules.Load()
+	if rules == nil || (rules.allow == nil && rules.deny == nil && len(rules.keys) == 0) {
+		return true
+	}
+	ip := rules.clientIP(c.Request)
+
+	key := rules.matchKey(c.Request)
+	allowed := ip != nil && permitted(ip, rules.allow, rules.deny)
+	if allowed && key != nil {
+		allowed = permitted(ip, key.allow, key.deny)
+	}
+	if allowed {
+		return true
+	}
+
+	principal := ""
+	if key != nil {
+		principal = strings.TrimSpace(key.entry.Key)
+	}
+	usage.GetRequestStatistics().RecordDenied(principal)
+	log.Debugf("ip access: denied %s %s from %v", c.Request.Method, c.Request.URL.Path, ip)
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client address not allowed"})
+	return false
+}
+
+// clientIP returns the connection address, or the forwarded client address when the
+// connection comes from a trusted proxy.
+func (r *ipRules) clientIP(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(req.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(req.RemoteAddr)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(r.trustedProxies, ip) {
+		return ip
+	}
+	// Walk X-Forwarded-For from the nearest hop and stop at the first untrusted address.
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				break
+			}
+			ip = hop
+			if !containsIP(r.trustedProxies, hop) {
+				return hop
+			}
+		}
+		return ip
+	}
+	if realIP := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); realIP != nil {
+		return realIP
+	}
+	return ip
+}
+
+// matchKey finds the IP-restricted key presented by the request, if any.
+func (r *ipRules) matchKey(req *http.Request) *keyIPRule {
+	if len(r.keys) == 0 {
+		return nil
+	}
+	for _, candidate := range presentedKeys(req) {
+		for i := range r.keys {
+			if r.keys[i].entry.MatchesAPIKey(candidate) {
+				return &r.keys[i]
+			}
+		}
+	}
+	return nil
+}
+
+// presentedKeys mirrors the credential sources read by the config-api-key provider.
+func presentedKeys(req *http.Request) []string {
+	var keys []string
+	if auth := strings.TrimSpace(req.Header.Get("Authorization")); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "bearer") {
+			auth = strings.TrimSpace(token)
+		}
+		keys = append(keys, auth)
+	}
+	for _, value := range []string{req.Header.Get("X-Goog-Api-Key"), req.Header.Get("X-Api-Key")} {
+		if value != "" {
+			keys = append(keys, value)
+		}
+	}
+	if req.URL != nil {
+		query := req.URL.Query()
+		for _, name := range []string{"key", "auth_token"} {
+			if value := query.Get(name); value != "" {
+				keys = append(keys, value)
+			}
+		}
+	}
+	return keys
+}
+
+// permitted applies deny before allow. A nil allow list allows everything; an allow
+// list whose entries were all invalid is empty but non-nil and allows nothing.
+func permitted(ip net.IP, allow, deny []*net.IPNet) bool {
+	if containsIP(deny, ip) {
+		return false
+	}
+	return allow == nil || containsIP(allow, ip)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPNets parses CIDRs and bare addresses; bare addresses match a single host.
+func parseIPNets(field string, values []string) []*net.IPNet {
+	if len(values) == 0 {
+		return nil
+	}
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				log.Warnf("%s: ignoring invalid address %q", field, value)
+				continue
+			}
+			bits := 128
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(value)
+		if err != nil {
+			log.Warnf("%s: ignoring invalid CIDR %q", field, value)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
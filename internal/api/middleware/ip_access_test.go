@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+func TestIPAccessFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.IPAccess = config.IPAccessConfig{
+		Allow:          []string{"10.0.0.0/8", "192.0.2.7"},
+		Deny:           []string{"10.9.0.0/16"},
+		TrustedProxies: []string{"127.0.0.1"},
+	}
+	cfg.APIKeys = []config.ApiKeyEntry{
+		{Key: "office-key", IsActive: true, IPAllow: []string{"10.1.0.0/16"}},
+		{Key: config.HashAPIKey("cpk_blocked"), IsActive: true, IPDeny: []string{"10.2.3.4"}},
+		{Key: "open-key", IsActive: true},
+	}
+	filter := NewIPAccessFilter(cfg)
+	router := gin.New()
+	router.Use(filter.Middleware())
+	router.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	stats := usage.GetRequestStatistics()
+	stats.Reset()
+
+	cases := []struct {
+		name       string
+		remote     string
+		forwarded  string
+		key        string
+		wantStatus int
+	}{
+		{"allowed range", "10.5.5.5:1000", "", "open-key", http.StatusOK},
+		{"single address", "192.0.2.7:1000", "", "", http.StatusOK},
+		{"outside allow", "203.0.113.9:1000", "", "open-key", http.StatusForbidden},
+		{"deny wins", "10.9.1.1:1000", "", "open-key", http.StatusForbidden},
+		{"key allow", "10.1.2.3:1000", "", "office-key", http.StatusOK},
+		{"key outside its allow", "10.5.5.5:1000", "", "office-key", http.StatusForbidden},
+		{"hashed key deny", "10.2.3.4:1000", "", "cpk_blocked", http.StatusForbidden},
+		{"spoofed forward ignored", "203.0.113.9:1000", "10.5.5.5", "", http.StatusForbidden},
+		{"trusted proxy forward", "127.0.0.1:1000", "203.0.113.1, 10.5.5.5", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		if tc.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.key)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != tc.wantStatus {
+			t.Fatalf("%s: status %d, want %d", tc.name, resp.Code, tc.wantStatus)
+		}
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.DeniedRequests != 5 || stats.DeniedCount("office-key") != 1 || stats.DeniedCount(config.HashAPIKey("cpk_blocked")) != 1 {
+		t.Fatalf("denials = %d %v", snapshot.DeniedRequests, snapshot.DeniedByAPI)
+	}
+
+	// An allow list with only invalid entries must not open access to everyone.
+	cfg.IPAccess = config.IPAccessConfig{Allow: []string{"not-an-ip"}}
+	filter.Update(cfg)
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.RemoteAddr = "10.5.5.5:1000"
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("invalid allow list: status %d", resp.Code)
+	}
+}
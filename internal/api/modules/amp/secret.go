@@ -174,7 +174,7 @@ func (s *StaticSecretSource) Get(ctx context.Context) (string, error) {
 type MappedSecretSource struct {
 	defaultSource SecretSource
 	mu            sync.RWMutex
-	lookup        map[string]string // clientKey -> upstreamKey
+	lookup        map[string]string // client key digest -> upstreamKey
 }
 
 // NewMappedSecretSource creates a MappedSecretSource wrapping the given default source.
@@ -193,7 +193,7 @@ func (s *MappedSecretSource) Get(ctx context.Context) (string, error) {
 	clientKey := getClientAPIKeyFromContext(ctx)
 	if clientKey != "" {
 		s.mu.RLock()
-		if upstreamKey, ok := s.lookup[clientKey]; ok && upstreamKey != "" {
+		if upstreamKey, ok := s.lookup[clientKeyDigest(clientKey)]; ok && upstreamKey != "" {
 			s.mu.RUnlock()
 			return upstreamKey, nil
 		}
@@ -219,6 +219,7 @@ func (s *MappedSecretSource) UpdateMappings(entries []config.AmpUpstreamAPIKeyEn
 			if trimmedKey == "" {
 				continue
 			}
+			trimmedKey = clientKeyDigest(trimmedKey)
 			if _, exists := newLookup[trimmedKey]; exists {
 				// Log warning for duplicate client key, first one wins
 				log.Warnf("amp upstream-api-keys: client API key appears in multiple entries; using first mapping.")
@@ -233,6 +234,16 @@ func (s *MappedSecretSource) UpdateMappings(entries []config.AmpUpstreamAPIKeyEn
 	s.mu.Unlock()
 }
 
+// clientKeyDigest returns the stored "sha256:" form of a client key. Mappings and
+// presented keys are compared in this form, so a mapping written with the literal key
+// matches clients whose api-keys entry is stored hashed, and vice versa.
+func clientKeyDigest(key string) string {
+	if config.IsHashedAPIKey(key) {
+		return key
+	}
+	return config.HashAPIKey(key)
+}
+
 // UpdateDefaultExplicitKey updates the explicit key on the underlying MultiSourceSecret (if applicable).
 func (s *MappedSecretSource) UpdateDefaultExplicitKey(key string) {
 	if ms, ok := s.defaultSource.(*MultiSourceSecret); ok {
@@ -312,6 +312,30 @@ func TestMappedSecretSource_UsesMappingFromContext(t *testing.T) {
 	}
 }
 
+func TestMappedSecretSource_MatchesHashedClientKeys(t *testing.T) {
+	s := NewMappedSecretSource(NewStaticSecretSource("default"))
+	s.UpdateMappings([]config.AmpUpstreamAPIKeyEntry{
+		{UpstreamAPIKey: "u1", APIKeys: []string{"k1"}},
+		{UpstreamAPIKey: "u2", APIKeys: []string{config.HashAPIKey("k2")}},
+	})
+
+	// Clients authenticated by a hashed api-keys entry carry the stored digest.
+	for principal, want := range map[string]string{
+		config.HashAPIKey("k1"): "u1",
+		"k2":                    "u2",
+		config.HashAPIKey("k2"): "u2",
+		config.HashAPIKey("k3"): "default",
+	} {
+		got, err := s.Get(context.WithValue(context.Background(), clientAPIKeyContextKey{}, principal))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Fatalf("principal %q: want %s, got %q", principal, want, got)
+		}
+	}
+}
+
 func TestMappedSecretSource_DuplicateClientKey_FirstWins(t *testing.T) {
 	defaultSource := NewStaticSecretSource("default")
 	s := NewMappedSecretSource(defaultSource)
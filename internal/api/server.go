@@ -20,10 +20,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/access"
+	certaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/cert_access"
 	managementHandlers "github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/management"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/claudebatch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
@@ -96,7 +99,7 @@ func detectLegacyObjectAPIKeys(configFilePath string) (bool, error) {
 			return false, nil
 		}
 		for _, item := range valueNode.Content {
-			if item != nil && item.Kind == yaml.MappingNode {
+			if item != nil && item.Kind == yaml.MappingNode && !hasAPIKeyPolicyNode(item) {
 				return true, nil
 			}
 		}
@@ -105,6 +108,22 @@ func detectLegacyObjectAPIKeys(configFilePath string) (bool, error) {
 	return false, nil
 }
 
+// hasAPIKeyPolicyNode reports whether an api-keys mapping item carries lifecycle or
+// access policy fields; such entries stay in object form and are not treated as legacy.
+func hasAPIKeyPolicyNode(item *yaml.Node) bool {
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		switch strings.TrimSpace(item.Content[i].Value) {
+		case "prefix", "expires-at", "rotated-to", "ip-allow", "ip-deny", "tenant":
+			return true
+		case "api-key":
+			if config.IsHashedAPIKey(strings.TrimSpace(item.Content[i+1].Value)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func migrateObjectAPIKeysToScalarAndPersistUsage(cfg *config.Config, configFilePath string) error {
 	if cfg == nil {
 		return nil
@@ -126,14 +145,18 @@ func migrateObjectAPIKeysToScalarAndPersistUsage(cfg *config.Config, configFileP
 			})
 			cancel()
 		}
-		// Strip object-only metadata so YAML becomes scalar list via MarshalYAML.
-		cfg.APIKeys[i].ID = ""
-		cfg.APIKeys[i].Name = ""
-		cfg.APIKeys[i].IsActive = false
 		cfg.APIKeys[i].UsageCount = 0
 		cfg.APIKeys[i].InputTokens = 0
 		cfg.APIKeys[i].OutputTokens = 0
 		cfg.APIKeys[i].LastUsedAt = ""
+		if cfg.APIKeys[i].HasPolicy() {
+			// Keep identity, status and expiry; only usage moves to the database.
+			continue
+		}
+		// Strip object-only metadata so YAML becomes scalar list via MarshalYAML.
+		cfg.APIKeys[i].ID = ""
+		cfg.APIKeys[i].Name = ""
+		cfg.APIKeys[i].IsActive = false
 		cfg.APIKeys[i].CreatedAt = ""
 	}
 	if strings.TrimSpace(configFilePath) == "" {
@@ -218,6 +241,9 @@ type Server struct {
 	// accessManager handles request authentication providers.
 	accessManager *sdkaccess.Manager
 
+	// ipAccess enforces client IP allow/deny lists ahead of accessManager.
+	ipAccess *middleware.IPAccessFilter
+
 	// requestLogger is the request logger instance for dynamic configuration updates.
 	requestLogger logging.RequestLogger
 	loggerToggle  func(bool)
@@ -257,6 +283,8 @@ type Server struct {
 	keepAliveStop      chan struct{}
 
 	usageRecordCleaner *usagerecord.RetentionCleaner
+	auditLogCleaner    *usagerecord.RetentionCleaner
+	backupScheduler    *backup.Scheduler
 }
 
 // NewServer creates and initializes a new API server instance.
@@ -327,6 +355,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		handlers:            handlers.NewBaseAPIHandlers(&cfg.SDKConfig, authManager),
 		cfg:                 cfg,
 		accessManager:       accessManager,
+		ipAccess:            &middleware.IPAccessFilter{},
 		requestLogger:       requestLogger,
 		loggerToggle:        toggle,
 		configFilePath:      configFilePath,
@@ -395,17 +424,19 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		}
 	}
 	s.configureUsageRecordRetention(cfg)
+	s.configureAuditLogRetention(cfg)
+	s.configureBackupSchedule(cfg)
 
 	// Setup routes
 	s.setupRoutes()
 
 	// Register Amp module using V2 interface with Context
-	s.ampModule = ampmodule.NewLegacy(accessManager, AuthMiddleware(accessManager))
+	s.ampModule = ampmodule.NewLegacy(accessManager, s.clientAuthMiddleware())
 	ctx := modules.Context{
 		Engine:         engine,
 		BaseHandler:    s.handlers,
 		Config:         cfg,
-		AuthMiddleware: AuthMiddleware(accessManager),
+		AuthMiddleware: s.clientAuthMiddleware(),
 	}
 	if err := modules.RegisterModule(ctx, s.ampModule); err != nil {
 		log.Errorf("Failed to register Amp module: %v", err)
@@ -418,7 +449,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 
 	// Register management routes when configuration or environment secrets are available,
 	// or when a local management password is provided (e.g. TUI mode).
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret || s.localPassword != ""
+	hasManagementSecret := cfg.RemoteManagement.HasCredentials() || envManagementSecret || s.localPassword != ""
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
@@ -437,6 +468,17 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	return s
 }
 
+// claudeBatchRoutesPath keeps batch and file pins next to the credentials they refer to.
+// The name carries no .json suffix so the auth watcher never loads it as a credential.
+func claudeBatchRoutesPath(cfg *config.Config) string {
+	authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+	if err != nil || authDir == "" {
+		log.Warn("claude batch routes are kept in memory: auth directory unavailable")
+		return ""
+	}
+	return filepath.Join(authDir, ".claude-batch-routes")
+}
+
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
@@ -445,25 +487,49 @@ func (s *Server) setupRoutes() {
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
+	claudeBatchRoutes := claudebatch.NewRouteTable(claudeBatchRoutesPath(s.cfg))
+	claudeCodeHandlers.SetFileRoutes(claudeBatchRoutes)
+	claudeBatchHandlers := claude.NewClaudeBatchAPIHandler(s.handlers, claudeBatchRoutes)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiImagesHandlers := openai.NewOpenAIImagesAPIHandler(s.handlers)
+	openaiAudioHandlers := openai.NewOpenAIAudioAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(s.clientAuthMiddleware())
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", claudeBatchHandlers.CreateBatch)
+		v1.GET("/messages/batches", claudeBatchHandlers.ListBatches)
+		v1.GET("/messages/batches/:id", claudeBatchHandlers.GetBatch)
+		v1.DELETE("/messages/batches/:id", claudeBatchHandlers.DeleteBatch)
+		v1.POST("/messages/batches/:id/cancel", claudeBatchHandlers.CancelBatch)
+		v1.GET("/messages/batches/:id/results", claudeBatchHandlers.BatchResults)
+		v1.POST("/files", claudeBatchHandlers.UploadFile)
+		v1.GET("/files", claudeBatchHandlers.ListFiles)
+		v1.GET("/files/:id", claudeBatchHandlers.GetFile)
+		v1.DELETE("/files/:id", claudeBatchHandlers.DeleteFile)
+		v1.GET("/files/:id/content", claudeBatchHandlers.FileContent)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.POST("/images/generations", openaiImagesHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiImagesHandlers.ImageEdits)
+		v1.POST("/audio/transcriptions", openaiAudioHandlers.Transcriptions)
+		v1.POST("/audio/translations", openaiAudioHandlers.Translations)
+		v1.POST("/audio/speech", openaiAudioHandlers.Speech)
 	}
 
+	// Generated image downloads use unguessable names so clients can fetch them without credentials.
+	s.engine.GET("/v1/images/files/:name", openaiImagesHandlers.ServeImageFile)
+
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(s.clientAuthMiddleware())
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
@@ -556,6 +622,22 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
+	// Declarative OAuth providers share one route keyed by provider name; unknown names are
+	// rejected by the pending-session check.
+	s.engine.GET("/oauth2/:provider/callback", func(c *gin.Context) {
+		code := c.Query("code")
+		state := c.Query("state")
+		errStr := c.Query("error")
+		if errStr == "" {
+			errStr = c.Query("error_description")
+		}
+		if state != "" {
+			_, _ = managementHandlers.WriteOAuthCallbackFileForPendingSession(s.cfg.AuthDir, c.Param("provider"), state, code, errStr)
+		}
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.String(http.StatusOK, oauthCallbackSuccessHTML)
+	})
+
 	// Management routes are registered lazily by registerManagementRoutes when a secret is configured.
 }
 
@@ -580,7 +662,7 @@ func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
 	s.wsRoutes[trimmed] = struct{}{}
 	s.wsRouteMu.Unlock()
 
-	authMiddleware := AuthMiddleware(s.accessManager)
+	authMiddleware := s.clientAuthMiddleware()
 	conditionalAuth := func(c *gin.Context) {
 		if !s.wsAuthEnabled.Load() {
 			c.Next()
@@ -607,14 +689,25 @@ func (s *Server) registerManagementRoutes() {
 	log.Info("management routes registered after secret key configuration")
 
 	mgmt := s.engine.Group("/v0/management")
-	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware(), s.mgmt.AuditMiddleware())
 	{
+		mgmt.GET("/whoami", s.mgmt.WhoAmI)
+		mgmt.GET("/accounts", s.mgmt.ListAccounts)
+		mgmt.POST("/accounts", s.mgmt.CreateAccount)
+		mgmt.DELETE("/accounts/:id", s.mgmt.DeleteAccount)
+		mgmt.GET("/tenants", s.mgmt.ListTenants)
+		mgmt.GET("/audit-log", s.mgmt.GetAuditLog)
+		mgmt.GET("/audit-log/export", s.mgmt.ExportAuditLog)
+
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
+		mgmt.GET("/config/versions", s.mgmt.ListConfigVersions)
+		mgmt.GET("/config/versions/:id", s.mgmt.GetConfigVersion)
+		mgmt.POST("/config/versions/:id/rollback", s.mgmt.RollbackConfigVersion)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
 		// WebUI update endpoint
@@ -644,8 +737,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/proxy-url", s.mgmt.PutProxyURL)
 		mgmt.PATCH("/proxy-url", s.mgmt.PutProxyURL)
 		mgmt.DELETE("/proxy-url", s.mgmt.DeleteProxyURL)
+		mgmt.GET("/proxy-pools", s.mgmt.GetProxyPools)
+		mgmt.POST("/proxy-pools/check", s.mgmt.CheckProxyPools)
 
 		mgmt.POST("/api-call", s.mgmt.APICall)
+		mgmt.POST("/scripts/test", s.mgmt.TestScript)
 
 		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
 		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
@@ -661,6 +757,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.POST("/api-keys/rotate", s.mgmt.RotateAPIKey)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
@@ -738,6 +835,26 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/vertex-api-key", s.mgmt.PatchVertexCompatKey)
 		mgmt.DELETE("/vertex-api-key", s.mgmt.DeleteVertexCompatKey)
 
+		mgmt.GET("/bedrock-api-key", s.mgmt.GetBedrockKeys)
+		mgmt.PUT("/bedrock-api-key", s.mgmt.PutBedrockKeys)
+		mgmt.PATCH("/bedrock-api-key", s.mgmt.PatchBedrockKey)
+		mgmt.DELETE("/bedrock-api-key", s.mgmt.DeleteBedrockKey)
+
+		mgmt.GET("/azure-openai", s.mgmt.GetAzureOpenAIKeys)
+		mgmt.PUT("/azure-openai", s.mgmt.PutAzureOpenAIKeys)
+		mgmt.PATCH("/azure-openai", s.mgmt.PatchAzureOpenAIKey)
+		mgmt.DELETE("/azure-openai", s.mgmt.DeleteAzureOpenAIKey)
+
+		mgmt.GET("/local-providers", s.mgmt.GetLocalProviders)
+		mgmt.PUT("/local-providers", s.mgmt.PutLocalProviders)
+		mgmt.PATCH("/local-providers", s.mgmt.PatchLocalProvider)
+		mgmt.DELETE("/local-providers", s.mgmt.DeleteLocalProvider)
+
+		mgmt.GET("/oauth-providers", s.mgmt.GetOAuthProviders)
+		mgmt.PUT("/oauth-providers", s.mgmt.PutOAuthProviders)
+		mgmt.PATCH("/oauth-providers", s.mgmt.PatchOAuthProvider)
+		mgmt.DELETE("/oauth-providers", s.mgmt.DeleteOAuthProvider)
+
 		mgmt.GET("/oauth-excluded-models", s.mgmt.GetOAuthExcludedModels)
 		mgmt.PUT("/oauth-excluded-models", s.mgmt.PutOAuthExcludedModels)
 		mgmt.PATCH("/oauth-excluded-models", s.mgmt.PatchOAuthExcludedModels)
@@ -753,6 +870,8 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
+		mgmt.POST("/auth-files/export", s.mgmt.ExportAuthFiles)
+		mgmt.POST("/auth-files/import", s.mgmt.ImportAuthFiles)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/metadata", s.mgmt.PatchAuthFileMetadata)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
@@ -765,6 +884,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/antigravity-auth-url", s.mgmt.RequestAntigravityToken)
 		mgmt.GET("/qwen-auth-url", s.mgmt.RequestQwenToken)
 		mgmt.GET("/kimi-auth-url", s.mgmt.RequestKimiToken)
+		mgmt.GET("/oauth2-auth-url", s.mgmt.RequestOAuth2Token)
 		mgmt.GET("/iflow-auth-url", s.mgmt.RequestIFlowToken)
 		mgmt.POST("/iflow-auth-url", s.mgmt.RequestIFlowCookieToken)
 		mgmt.POST("/oauth-callback", s.mgmt.PostOAuthCallback)
@@ -953,8 +1073,15 @@ func (s *Server) Start() error {
 		if cert == "" || key == "" {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
+		// Client certificate verification (tls.client-ca) is resolved per handshake so
+		// CA bundle changes apply on config reload.
+		tlsConfig, errTLS := certaccess.ServerTLSConfig(cert, key)
+		if errTLS != nil {
+			return fmt.Errorf("failed to start HTTPS server: %v", errTLS)
+		}
+		s.server.TLSConfig = tlsConfig
 		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
-		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
+		if errServeTLS := s.server.ListenAndServeTLS("", ""); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
 			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
 		}
 		return nil
@@ -990,6 +1117,11 @@ func (s *Server) Stop(ctx context.Context) error {
 		s.usageRecordCleaner.Stop()
 		s.usageRecordCleaner = nil
 	}
+	if s.auditLogCleaner != nil {
+		s.auditLogCleaner.Stop()
+		s.auditLogCleaner = nil
+	}
+	s.stopBackupScheduler()
 
 	// Shutdown the HTTP server.
 	if err := s.server.Shutdown(ctx); err != nil {
@@ -1021,7 +1153,11 @@ func corsMiddleware() gin.HandlerFunc {
 }
 
 func (s *Server) applyAccessConfig(oldCfg, newCfg *config.Config) {
-	if s == nil || s.accessManager == nil || newCfg == nil {
+	if s == nil || newCfg == nil {
+		return
+	}
+	s.ipAccess.Update(newCfg)
+	if s.accessManager == nil {
 		return
 	}
 	if _, err := access.ApplyAccessProviders(s.accessManager, oldCfg, newCfg); err != nil {
@@ -1086,9 +1222,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = !oldCfg.RemoteManagement.HasCredentials()
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := !cfg.RemoteManagement.HasCredentials()
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -1134,6 +1270,8 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.configureUsageRecordRetention(cfg)
+	s.configureAuditLogRetention(cfg)
+	s.configureBackupSchedule(cfg)
 
 	// Notify Amp module only when Amp config has changed.
 	ampConfigChanged := oldCfg == nil || !reflect.DeepEqual(oldCfg.AmpCode, cfg.AmpCode)
@@ -1158,20 +1296,28 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	claudeAPIKeyCount := len(cfg.ClaudeKey)
 	codexAPIKeyCount := len(cfg.CodexKey)
 	vertexAICompatCount := len(cfg.VertexCompatAPIKey)
+	bedrockCount := len(cfg.BedrockKey)
+	azureOpenAICount := len(cfg.AzureOpenAIKey)
+	localCount := len(cfg.LocalProviders)
+	pluginCount := len(cfg.Plugins)
 	openAICompatCount := 0
 	for i := range cfg.OpenAICompatibility {
 		entry := cfg.OpenAICompatibility[i]
 		openAICompatCount += len(entry.APIKeyEntries)
 	}
 
-	total := authEntries + geminiAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + vertexAICompatCount + openAICompatCount
-	fmt.Printf("server clients and configuration updated: %d clients (%d auth entries + %d Gemini API keys + %d Claude API keys + %d Codex keys + %d Vertex-compat + %d OpenAI-compat)\n",
+	total := authEntries + geminiAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + vertexAICompatCount + bedrockCount + azureOpenAICount + localCount + pluginCount + openAICompatCount
+	fmt.Printf("server clients and configuration updated: %d clients (%d auth entries + %d Gemini API keys + %d Claude API keys + %d Codex keys + %d Vertex-compat + %d Bedrock + %d Azure OpenAI + %d local + %d plugins + %d OpenAI-compat)\n",
 		total,
 		authEntries,
 		geminiAPIKeyCount,
 		claudeAPIKeyCount,
 		codexAPIKeyCount,
 		vertexAICompatCount,
+		bedrockCount,
+		azureOpenAICount,
+		localCount,
+		pluginCount,
 		openAICompatCount,
 	)
 }
@@ -1210,6 +1356,113 @@ func (s *Server) configureUsageRecordRetention(cfg *config.Config) {
 	}
 }
 
+func (s *Server) configureAuditLogRetention(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+
+	days := 0
+	if cfg != nil {
+		days = cfg.AuditLogRetentionDays
+	}
+
+	switch {
+	case days <= 0:
+		if s.auditLogCleaner != nil {
+			s.auditLogCleaner.Stop()
+			s.auditLogCleaner = nil
+			log.Info("audit log retention cleanup disabled")
+		}
+		return
+	case usagerecord.DefaultStore() == nil:
+		return
+	case s.auditLogCleaner == nil:
+		s.auditLogCleaner = usagerecord.NewAuditRetentionCleaner(usagerecord.DefaultStore(), days)
+		s.auditLogCleaner.Start()
+		log.Infof("audit log retention cleanup enabled: %d days", days)
+		return
+	default:
+		previous := s.auditLogCleaner.UpdateRetentionDays(days)
+		if previous != days {
+			log.Infof("audit log retention updated: %d -> %d days", previous, days)
+		}
+	}
+}
+
+func (s *Server) configureBackupSchedule(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	if cfg == nil || cfg.Backup.Schedule == "" {
+		if s.backupScheduler != nil {
+			s.stopBackupScheduler()
+			log.Info("scheduled backups disabled")
+		}
+		return
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		log.WithError(err).Warn("scheduled backups disabled: failed to get working directory")
+		s.stopBackupScheduler()
+		return
+	}
+	schedulerCfg := backup.SchedulerConfig{
+		Schedule: cfg.Backup.Schedule,
+		Options: backup.BackupOptions{
+			BackupPath: backup.ValidateBackupPathCLI(cfg.Backup.Path, wd),
+			Content: backup.BackupContent{
+				Env:     cfg.Backup.IncludeEnv,
+				Config:  true,
+				Auths:   true,
+				UsageDB: cfg.Backup.IncludeUsageDB,
+			},
+			WorkDir:         wd,
+			AuthDir:         backup.ResolveAuthDir(cfg.AuthDir, wd),
+			Passphrase:      cfg.Backup.Passphrase,
+			SnapshotUsageDB: backup.SnapshotUsageRecords,
+		},
+		KeepLast:  cfg.Backup.KeepLast,
+		KeepDaily: cfg.Backup.KeepDaily,
+	}
+	if cfg.Backup.Upload {
+		if uploader, ok := sdkAuth.GetTokenStore().(backup.Uploader); ok {
+			schedulerCfg.Uploader = uploader
+		} else {
+			log.Warn("backup upload enabled but no object store is configured")
+		}
+	}
+
+	if s.backupScheduler != nil {
+		if err = s.backupScheduler.Update(schedulerCfg); err != nil {
+			log.WithError(err).Warn("invalid backup schedule; keeping previous schedule")
+		}
+		return
+	}
+	scheduler, err := backup.NewScheduler(schedulerCfg)
+	if err != nil {
+		log.WithError(err).Warn("invalid backup schedule; scheduled backups disabled")
+		return
+	}
+	s.backupScheduler = scheduler
+	s.backupScheduler.Start()
+	if s.mgmt != nil {
+		s.mgmt.SetBackupScheduler(scheduler)
+	}
+	log.Infof("scheduled backups enabled: %s", cfg.Backup.Schedule)
+}
+
+func (s *Server) stopBackupScheduler() {
+	if s.backupScheduler == nil {
+		return
+	}
+	s.backupScheduler.Stop()
+	s.backupScheduler = nil
+	if s.mgmt != nil {
+		s.mgmt.SetBackupScheduler(nil)
+	}
+}
+
 func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 	if s == nil {
 		return
@@ -1219,6 +1472,18 @@ func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 
 // (management handlers moved to internal/api/handlers/management)
 
+// clientAuthMiddleware applies the IP access rules and then authenticates the request
+// with the access providers.
+func (s *Server) clientAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware(s.accessManager)
+	return func(c *gin.Context) {
+		if !s.ipAccess.Allow(c) {
+			return
+		}
+		auth(c)
+	}
+}
+
 // AuthMiddleware returns a Gin middleware handler that authenticates requests
 // using the configured authentication providers. When no providers are available,
 // it allows all requests (legacy behaviour).
@@ -0,0 +1,148 @@
+// Package azure obtains Microsoft Entra ID access tokens for Azure OpenAI using the
+// OAuth2 client-credentials grant.
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// DefaultAuthorityHost is the public cloud Entra ID login endpoint.
+	DefaultAuthorityHost = "https://login.microsoftonline.com"
+	// DefaultScope is the scope for Azure AI services, including Azure OpenAI.
+	DefaultScope = "https://cognitiveservices.azure.com/.default"
+
+	// refreshSkew renews tokens this long before they expire.
+	refreshSkew = 2 * time.Minute
+)
+
+// ClientCredentials identifies an app registration used for the client-credentials grant.
+type ClientCredentials struct {
+	TenantID      string
+	ClientID      string
+	ClientSecret  string
+	AuthorityHost string
+	Scope         string
+}
+
+func (c ClientCredentials) normalized() ClientCredentials {
+	c.TenantID = strings.TrimSpace(c.TenantID)
+	c.ClientID = strings.TrimSpace(c.ClientID)
+	c.ClientSecret = strings.TrimSpace(c.ClientSecret)
+	c.AuthorityHost = strings.TrimRight(strings.TrimSpace(c.AuthorityHost), "/")
+	if c.AuthorityHost == "" {
+		c.AuthorityHost = DefaultAuthorityHost
+	}
+	c.Scope = strings.TrimSpace(c.Scope)
+	if c.Scope == "" {
+		c.Scope = DefaultScope
+	}
+	return c
+}
+
+func (c ClientCredentials) cacheKey() string {
+	return c.AuthorityHost + "|" + c.TenantID + "|" + c.ClientID + "|" + c.Scope
+}
+
+type cachedToken struct {
+	accessToken string
+	expiresAt   time.Time
+	secret      string
+}
+
+// TokenCache caches access tokens per app registration and scope.
+type TokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+	now    func() time.Time
+}
+
+// NewTokenCache returns an empty token cache.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{tokens: make(map[string]cachedToken), now: time.Now}
+}
+
+// DefaultTokenCache is shared by executors so tokens survive executor re-registration.
+var DefaultTokenCache = NewTokenCache()
+
+// Token returns a cached access token or requests a new one with client.
+func (c *TokenCache) Token(ctx context.Context, client *http.Client, creds ClientCredentials) (string, error) {
+	creds = creds.normalized()
+	if creds.TenantID == "" || creds.ClientID == "" || creds.ClientSecret == "" {
+		return "", fmt.Errorf("azure: incomplete entra client credentials")
+	}
+	key := creds.cacheKey()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.tokens[key]; ok && cached.secret == creds.ClientSecret && c.now().Add(refreshSkew).Before(cached.expiresAt) {
+		return cached.accessToken, nil
+	}
+	token, expiresIn, err := requestToken(ctx, client, creds)
+	if err != nil {
+		return "", err
+	}
+	c.tokens[key] = cachedToken{accessToken: token, expiresAt: c.now().Add(expiresIn), secret: creds.ClientSecret}
+	return token, nil
+}
+
+// Invalidate drops the cached token for creds, e.g. after the upstream rejected it.
+func (c *TokenCache) Invalidate(creds ClientCredentials) {
+	creds = creds.normalized()
+	c.mu.Lock()
+	delete(c.tokens, creds.cacheKey())
+	c.mu.Unlock()
+}
+
+func requestToken(ctx context.Context, client *http.Client, creds ClientCredentials) (string, time.Duration, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {creds.ClientID},
+		"client_secret": {creds.ClientSecret},
+		"scope":         {creds.Scope},
+	}
+	endpoint := creds.AuthorityHost + "/" + url.PathEscape(creds.TenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("azure: token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("azure: read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := gjson.GetBytes(body, "error_description").String()
+		if msg == "" {
+			msg = strings.TrimSpace(string(body))
+		}
+		return "", 0, fmt.Errorf("azure: token request returned %d: %s", resp.StatusCode, msg)
+	}
+	token := gjson.GetBytes(body, "access_token").String()
+	if token == "" {
+		return "", 0, fmt.Errorf("azure: token response without access_token")
+	}
+	expiresIn := time.Duration(gjson.GetBytes(body, "expires_in").Int()) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	return token, expiresIn, nil
+}
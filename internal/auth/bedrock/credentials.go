@@ -0,0 +1,110 @@
+// Package bedrock provides AWS credential loading and SigV4 request signing for the
+// Bedrock runtime without depending on the AWS SDK.
+package bedrock
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials holds an AWS access key pair and optional session token.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Valid reports whether both halves of the access key pair are present.
+func (c Credentials) Valid() bool {
+	return strings.TrimSpace(c.AccessKeyID) != "" && strings.TrimSpace(c.SecretAccessKey) != ""
+}
+
+// LoadProfile reads credentials for profile from the shared credentials file
+// (AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials), falling back to the config file
+// (AWS_CONFIG_FILE or ~/.aws/config) where profiles are named "profile <name>".
+func LoadProfile(profile string) (Credentials, error) {
+	profile = strings.TrimSpace(profile)
+	if profile == "" {
+		profile = "default"
+	}
+	home, _ := os.UserHomeDir()
+
+	credentialsFile := strings.TrimSpace(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"))
+	if credentialsFile == "" && home != "" {
+		credentialsFile = filepath.Join(home, ".aws", "credentials")
+	}
+	if creds, ok, err := readProfile(credentialsFile, profile); err != nil {
+		return Credentials{}, err
+	} else if ok {
+		return creds, nil
+	}
+
+	configFile := strings.TrimSpace(os.Getenv("AWS_CONFIG_FILE"))
+	if configFile == "" && home != "" {
+		configFile = filepath.Join(home, ".aws", "config")
+	}
+	section := "profile " + profile
+	if profile == "default" {
+		section = "default"
+	}
+	if creds, ok, err := readProfile(configFile, section); err != nil {
+		return Credentials{}, err
+	} else if ok {
+		return creds, nil
+	}
+	return Credentials{}, fmt.Errorf("bedrock: aws profile %q not found or incomplete", profile)
+}
+
+// readProfile parses an INI style AWS file and returns the credentials of section.
+// A missing file is not an error.
+func readProfile(path, section string) (Credentials, bool, error) {
+	if path == "" {
+		return Credentials{}, false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Credentials{}, false, nil
+		}
+		return Credentials{}, false, fmt.Errorf("bedrock: open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var creds Credentials
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			inSection = name == section
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = value
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = value
+		case "aws_session_token":
+			creds.SessionToken = value
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return Credentials{}, false, fmt.Errorf("bedrock: read %s: %w", path, err)
+	}
+	return creds, creds.Valid(), nil
+}
@@ -0,0 +1,83 @@
+package bedrock
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCreds = Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestSignRequestMatchesAWSTestSuite(t *testing.T) {
+	// "get-vanilla" from the AWS Signature Version 4 test suite.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	if err := SignRequest(req, nil, testCreds, "us-east-1", "service", now); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestSignRequestDoubleEncodesModelPath(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/", nil)
+	req.URL.Path = "/model/anthropic.claude-sonnet-4-20250514-v1:0/invoke"
+	req.URL.RawPath = EscapePath(req.URL.Path)
+	if got := req.URL.String(); !strings.Contains(got, "v1%3A0/invoke") {
+		t.Fatalf("request URL %q does not escape the model ID", got)
+	}
+	if got := canonicalURI(req.URL); got != "/model/anthropic.claude-sonnet-4-20250514-v1%253A0/invoke" {
+		t.Fatalf("canonical URI = %q", got)
+	}
+
+	creds := testCreds
+	creds.SessionToken = "session"
+	req.Header.Set("Content-Type", "application/json")
+	if err := SignRequest(req, []byte(`{}`), creds, "us-east-1", ServiceName, time.Now()); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token,") {
+		t.Fatalf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "session" {
+		t.Fatal("session token header not set")
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	if err := os.WriteFile(credentialsFile, []byte("[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = secret-default\n\n[ team ]\naws_access_key_id=AKIDTEAM\naws_secret_access_key=secret-team\naws_session_token=token-team\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("[profile sso]\nregion = eu-west-1\naws_access_key_id = AKIDSSO\naws_secret_access_key = secret-sso\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	for profile, wantKey := range map[string]string{"": "AKIDDEFAULT", "team": "AKIDTEAM", "sso": "AKIDSSO"} {
+		creds, err := LoadProfile(profile)
+		if err != nil {
+			t.Fatalf("LoadProfile(%q): %v", profile, err)
+		}
+		if creds.AccessKeyID != wantKey {
+			t.Fatalf("LoadProfile(%q) key = %q, want %q", profile, creds.AccessKeyID, wantKey)
+		}
+	}
+	if creds, _ := LoadProfile("team"); creds.SessionToken != "token-team" {
+		t.Fatalf("session token = %q", creds.SessionToken)
+	}
+	if _, err := LoadProfile("missing"); err == nil {
+		t.Fatal("expected error for missing profile")
+	}
+}
@@ -0,0 +1,75 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// callbackSuccessHTML is shown in the browser once the redirect has been received.
+const callbackSuccessHTML = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Authentication successful</title></head>` +
+	`<body><h1>Authentication successful</h1><p>You can close this window and return to the terminal.</p></body></html>`
+
+// CallbackResult carries the query parameters of an authorization redirect.
+type CallbackResult struct {
+	Code  string
+	State string
+	Error string
+}
+
+// CallbackServer receives a single authorization-code redirect on a local port.
+type CallbackServer struct {
+	server  *http.Server
+	results chan *CallbackResult
+}
+
+// ListenForCallback starts a server on 127.0.0.1:port that accepts redirects on path.
+func ListenForCallback(port int, path string) (*CallbackServer, error) {
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: listen on %s: %w", addr, err)
+	}
+	s := &CallbackServer{results: make(chan *CallbackResult, 1)}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		result := &CallbackResult{Code: q.Get("code"), State: q.Get("state"), Error: q.Get("error")}
+		if result.Error == "" {
+			result.Error = q.Get("error_description")
+		}
+		select {
+		case s.results <- result:
+		default:
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(callbackSuccessHTML))
+	})
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if errServe := s.server.Serve(ln); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			log.Warnf("oauth2: callback server stopped: %v", errServe)
+		}
+	}()
+	return s, nil
+}
+
+// Results delivers the first redirect received.
+func (s *CallbackServer) Results() <-chan *CallbackResult {
+	return s.results
+}
+
+// Close shuts the server down.
+func (s *CallbackServer) Close() {
+	if s == nil || s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.server.Shutdown(ctx)
+}
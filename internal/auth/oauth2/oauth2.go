@@ -0,0 +1,385 @@
+// Package oauth2 implements the OAuth2 grants used by declaratively configured providers:
+// authorization code with optional PKCE, the device authorization grant (RFC 8628) and
+// client credentials, plus refresh and auth-file metadata handling.
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultPollInterval is used when the device authorization response omits an interval.
+	defaultPollInterval = 5 * time.Second
+	// defaultDeviceExpiry bounds device flow polling when the server omits expires_in.
+	defaultDeviceExpiry = 15 * time.Minute
+)
+
+// ErrAuthorizationPending is returned while the user has not yet approved a device code.
+var ErrAuthorizationPending = errors.New("oauth2: authorization pending")
+
+// Client performs token requests for one declarative provider.
+type Client struct {
+	provider   config.OAuthProvider
+	httpClient *http.Client
+}
+
+// NewClient builds a client for provider using its proxy-url, or the global proxy.
+func NewClient(cfg *config.Config, provider *config.OAuthProvider) *Client {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	proxyURL := ""
+	if cfg != nil {
+		proxyURL = cfg.ProxyURL
+	}
+	if provider != nil && provider.ProxyURL != "" {
+		proxyURL = provider.ProxyURL
+	}
+	transport, _, errBuild := proxyutil.BuildHTTPTransport(proxyURL)
+	if errBuild != nil {
+		log.Errorf("oauth2: %v", errBuild)
+	}
+	if transport != nil {
+		httpClient.Transport = transport
+	}
+	c := &Client{httpClient: httpClient}
+	if provider != nil {
+		c.provider = *provider
+	}
+	return c
+}
+
+// PKCECodes holds a PKCE verifier and its S256 challenge.
+type PKCECodes struct {
+	CodeVerifier  string
+	CodeChallenge string
+}
+
+// GeneratePKCECodes creates a random verifier and its S256 challenge.
+func GeneratePKCECodes() (*PKCECodes, error) {
+	buf := make([]byte, 64)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("oauth2: generate pkce verifier: %w", err)
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	return &PKCECodes{CodeVerifier: verifier, CodeChallenge: base64.RawURLEncoding.EncodeToString(sum[:])}, nil
+}
+
+// Token is a token endpoint response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+
+	// ExpiresAt is derived from ExpiresIn when the token is received.
+	ExpiresAt time.Time `json:"-"`
+}
+
+// DeviceCode is a device authorization response.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// VerificationURL returns the URL the user should open, preferring the pre-filled form.
+func (d *DeviceCode) VerificationURL() string {
+	if d == nil {
+		return ""
+	}
+	if d.VerificationURIComplete != "" {
+		return d.VerificationURIComplete
+	}
+	return d.VerificationURI
+}
+
+// tokenError is an OAuth2 error response body.
+type tokenError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// AuthorizationURL builds the authorization endpoint URL for the authorization-code flow.
+func (c *Client) AuthorizationURL(state, redirectURI string, pkce *PKCECodes) (string, error) {
+	u, err := url.Parse(c.provider.AuthorizeURL)
+	if err != nil {
+		return "", fmt.Errorf("oauth2: invalid authorize-url: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", c.provider.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	if len(c.provider.Scopes) > 0 {
+		q.Set("scope", strings.Join(c.provider.Scopes, " "))
+	}
+	if pkce != nil {
+		q.Set("code_challenge", pkce.CodeChallenge)
+		q.Set("code_challenge_method", "S256")
+	}
+	for k, v := range c.provider.AuthParams {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ExchangeCode redeems an authorization code.
+func (c *Client) ExchangeCode(ctx context.Context, code, redirectURI string, pkce *PKCECodes) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	if pkce != nil {
+		form.Set("code_verifier", pkce.CodeVerifier)
+	}
+	return c.requestToken(ctx, form)
+}
+
+// ClientCredentials requests a token with the client credentials grant.
+func (c *Client) ClientCredentials(ctx context.Context) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(c.provider.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.provider.Scopes, " "))
+	}
+	return c.requestToken(ctx, form)
+}
+
+// Refresh exchanges a refresh token for a new access token.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	return c.requestToken(ctx, form)
+}
+
+// StartDeviceFlow requests a device and user code.
+func (c *Client) StartDeviceFlow(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{}
+	form.Set("client_id", c.provider.ClientID)
+	if len(c.provider.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.provider.Scopes, " "))
+	}
+	status, body, err := c.postForm(ctx, c.provider.DeviceAuthorizationURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: device authorization request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oauth2: device authorization failed with status %d: %s", status, strings.TrimSpace(string(body)))
+	}
+	var code DeviceCode
+	if err = json.Unmarshal(body, &code); err != nil {
+		return nil, fmt.Errorf("oauth2: parse device authorization response: %w", err)
+	}
+	if code.DeviceCode == "" {
+		return nil, fmt.Errorf("oauth2: device authorization response has no device_code")
+	}
+	return &code, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user approves or denies the device
+// code, the code expires or ctx is cancelled.
+func (c *Client) PollDeviceToken(ctx context.Context, code *DeviceCode) (*Token, error) {
+	if code == nil {
+		return nil, fmt.Errorf("oauth2: device code is nil")
+	}
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	expiry := time.Duration(code.ExpiresIn) * time.Second
+	if expiry <= 0 {
+		expiry = defaultDeviceExpiry
+	}
+	deadline := time.Now().Add(expiry)
+	for {
+		token, err := c.pollDeviceTokenOnce(ctx, code.DeviceCode)
+		switch {
+		case err == nil:
+			return token, nil
+		case errors.Is(err, errSlowDown):
+			interval += 5 * time.Second
+		case !errors.Is(err, ErrAuthorizationPending):
+			return nil, err
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("oauth2: device code expired before authorization")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+var errSlowDown = errors.New("oauth2: slow down")
+
+func (c *Client) pollDeviceTokenOnce(ctx context.Context, deviceCode string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+	form.Set("device_code", deviceCode)
+	token, err := c.requestToken(ctx, form)
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		switch tokenErr.Code {
+		case "authorization_pending":
+			return nil, ErrAuthorizationPending
+		case "slow_down":
+			return nil, errSlowDown
+		}
+	}
+	return token, err
+}
+
+// TokenError is an error response from the token endpoint.
+type TokenError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	msg := fmt.Sprintf("oauth2: token request failed with status %d", e.Status)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += " (" + e.Description + ")"
+	}
+	return msg
+}
+
+// requestToken posts form to the token endpoint with the client credentials attached.
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.provider.ClientID)
+	if c.provider.ClientSecret != "" {
+		form.Set("client_secret", c.provider.ClientSecret)
+	}
+	status, body, err := c.postForm(ctx, c.provider.TokenURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: token request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		var errBody tokenError
+		_ = json.Unmarshal(body, &errBody)
+		if errBody.Error == "" && errBody.Description == "" {
+			errBody.Description = strings.TrimSpace(string(body))
+		}
+		return nil, &TokenError{Status: status, Code: errBody.Error, Description: errBody.Description}
+	}
+	var token Token
+	if err = json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oauth2: parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		var errBody tokenError
+		if json.Unmarshal(body, &errBody) == nil && errBody.Error != "" {
+			return nil, &TokenError{Status: status, Code: errBody.Error, Description: errBody.Description}
+		}
+		return nil, fmt.Errorf("oauth2: token response has no access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
+
+func (c *Client) postForm(ctx context.Context, endpoint string, form url.Values) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("oauth2: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// IdentityFromIDToken returns the email, or subject, claimed by an unverified ID token.
+// It only labels the credential; the token is never trusted for authorization.
+func IdentityFromIDToken(idToken string) string {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Email   string `json:"email"`
+		Subject string `json:"sub"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	if claims.Email != "" {
+		return claims.Email
+	}
+	return claims.Subject
+}
+
+// ApplyToken writes token into auth-file metadata for provider, keeping the previous
+// refresh token when the server does not rotate it.
+func ApplyToken(metadata map[string]any, provider string, token *Token) map[string]any {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	now := time.Now()
+	metadata["type"] = provider
+	metadata["access_token"] = token.AccessToken
+	if token.RefreshToken != "" {
+		metadata["refresh_token"] = token.RefreshToken
+	}
+	if token.TokenType != "" {
+		metadata["token_type"] = token.TokenType
+	}
+	if token.Scope != "" {
+		metadata["scope"] = token.Scope
+	}
+	if token.IDToken != "" {
+		metadata["id_token"] = token.IDToken
+	}
+	if !token.ExpiresAt.IsZero() {
+		metadata["expired"] = token.ExpiresAt.UTC().Format(time.RFC3339)
+	} else {
+		delete(metadata, "expired")
+	}
+	metadata["last_refresh"] = now.Format(time.RFC3339)
+	metadata["timestamp"] = now.UnixMilli()
+	return metadata
+}
@@ -0,0 +1,143 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestPollDeviceToken_PendingThenSuccess(t *testing.T) {
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("client_id") != "cid" || r.Form.Get("scope") != "openid offline_access" {
+			t.Errorf("device request form = %v", r.Form)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"device_code":      "dev-1",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": "https://auth.example/device",
+			"interval":         1,
+			"expires_in":       60,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("device_code") != "dev-1" {
+			t.Errorf("device_code = %q", r.Form.Get("device_code"))
+		}
+		if polls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"at-1","refresh_token":"rt-1","token_type":"Bearer","expires_in":3600}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(nil, &config.OAuthProvider{
+		Name:                   "acme",
+		ClientID:               "cid",
+		TokenURL:               srv.URL + "/token",
+		DeviceAuthorizationURL: srv.URL + "/device",
+		Scopes:                 []string{"openid", "offline_access"},
+	})
+	code, err := client.StartDeviceFlow(context.Background())
+	if err != nil {
+		t.Fatalf("StartDeviceFlow: %v", err)
+	}
+	if code.VerificationURL() != "https://auth.example/device" || code.UserCode != "ABCD-EFGH" {
+		t.Fatalf("unexpected device code %+v", code)
+	}
+	token, err := client.PollDeviceToken(context.Background(), code)
+	if err != nil {
+		t.Fatalf("PollDeviceToken: %v", err)
+	}
+	if token.AccessToken != "at-1" || token.RefreshToken != "rt-1" || token.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected token %+v", token)
+	}
+	if polls.Load() != 2 {
+		t.Fatalf("polls = %d, want 2", polls.Load())
+	}
+}
+
+func TestClientCredentialsAndRefresh(t *testing.T) {
+	var forms []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		forms = append(forms, r.PostForm)
+		switch r.PostForm.Get("grant_type") {
+		case "client_credentials":
+			_, _ = w.Write([]byte(`{"access_token":"cc-token","expires_in":60}`))
+		case "refresh_token":
+			_, _ = w.Write([]byte(`{"access_token":"refreshed"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(nil, &config.OAuthProvider{Name: "acme", ClientID: "cid", ClientSecret: "secret", TokenURL: srv.URL})
+	token, err := client.ClientCredentials(context.Background())
+	if err != nil {
+		t.Fatalf("ClientCredentials: %v", err)
+	}
+	if token.AccessToken != "cc-token" {
+		t.Fatalf("access token = %q", token.AccessToken)
+	}
+	if forms[0].Get("client_secret") != "secret" || forms[0].Get("client_id") != "cid" {
+		t.Fatalf("client credentials form = %v", forms[0])
+	}
+
+	metadata := map[string]any{"refresh_token": "rt-old", "expired": "2020-01-01T00:00:00Z"}
+	token, err = client.Refresh(context.Background(), "rt-old")
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	metadata = ApplyToken(metadata, "acme", token)
+	if metadata["access_token"] != "refreshed" || metadata["refresh_token"] != "rt-old" || metadata["type"] != "acme" {
+		t.Fatalf("unexpected metadata %v", metadata)
+	}
+	if _, ok := metadata["expired"]; ok {
+		t.Fatalf("stale expiry kept: %v", metadata["expired"])
+	}
+
+	_, err = NewClient(nil, &config.OAuthProvider{ClientID: "cid", TokenURL: srv.URL}).pollDeviceTokenOnce(context.Background(), "x")
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || tokenErr.Code != "unsupported_grant_type" {
+		t.Fatalf("expected unsupported_grant_type token error, got %v", err)
+	}
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	client := NewClient(nil, &config.OAuthProvider{
+		ClientID:     "cid",
+		AuthorizeURL: "https://auth.example/authorize?prompt=consent",
+		Scopes:       []string{"openid"},
+		AuthParams:   map[string]string{"audience": "api"},
+	})
+	raw, err := client.AuthorizationURL("st", "http://localhost:8765/cb", &PKCECodes{CodeChallenge: "chal"})
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+	for key, want := range map[string]string{
+		"prompt": "consent", "response_type": "code", "client_id": "cid", "state": "st",
+		"redirect_uri": "http://localhost:8765/cb", "scope": "openid", "audience": "api",
+		"code_challenge": "chal", "code_challenge_method": "S256",
+	} {
+		if got := q.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 // Package backup provides secure backup and restore functionality for CLI Proxy API.
 // It handles zip archive creation/extraction with protection against Zip Slip attacks
-// and other security vulnerabilities.
+// and other security vulnerabilities. Archives can optionally include a snapshot of
+// the usage records database, be encrypted with a passphrase, and be created on a
+// cron schedule with retention (see Scheduler).
 package backup
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,21 +16,25 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagerecord"
 )
 
 // BackupContent specifies what to include in a backup
 type BackupContent struct {
-	Env    bool `json:"env"`
-	Config bool `json:"config"`
-	Auths  bool `json:"auths"`
+	Env     bool `json:"env"`
+	Config  bool `json:"config"`
+	Auths   bool `json:"auths"`
+	UsageDB bool `json:"usageDb"`
 }
 
 // BackupMetadata contains information about a backup archive
 type BackupMetadata struct {
-	Name    string        `json:"name"`
-	Date    string        `json:"date"`
-	Size    int64         `json:"size"`
-	Content BackupContent `json:"content"`
+	Name      string        `json:"name"`
+	Date      string        `json:"date"`
+	Size      int64         `json:"size"`
+	Content   BackupContent `json:"content"`
+	Encrypted bool          `json:"encrypted"`
 }
 
 // BackupOptions configures backup creation
@@ -37,6 +44,11 @@ type BackupOptions struct {
 	Content    BackupContent
 	WorkDir    string
 	AuthDir    string
+	// Passphrase encrypts the archive when set; the file name gets EncryptedSuffix.
+	Passphrase string
+	// SnapshotUsageDB writes a consistent copy of the usage records database to dest.
+	// It is required when Content.UsageDB is set.
+	SnapshotUsageDB func(dest string) error
 }
 
 // RestoreOptions configures backup restoration
@@ -46,8 +58,21 @@ type RestoreOptions struct {
 	AuthsMode  string // "overwrite" or "incremental"
 	WorkDir    string
 	AuthDir    string
+	// Passphrase decrypts encrypted archives.
+	Passphrase string
+	// UsageDBPath is where a usage records database in the archive is restored to.
+	// Leave empty to skip it, e.g. while the database is open by a running server.
+	UsageDBPath string
 }
 
+const (
+	metadataEntry = "backup_metadata.json"
+	usageDBEntry  = "usage_records.db"
+	// maxUsageDBSize bounds the extracted usage database (the per-file limit for
+	// other entries is 100MB).
+	maxUsageDBSize = 8 << 30
+)
+
 // SafeJoinPath safely joins a base directory with a relative path from a zip entry.
 // It normalizes the path and validates that the result stays within the base directory.
 // Returns an error if the path would escape the base directory (Zip Slip attack).
@@ -152,7 +177,7 @@ func WriteMetadata(zipWriter *zip.Writer, metadata BackupMetadata) error {
 		return fmt.Errorf("failed to marshal backup metadata: %w", err)
 	}
 
-	metaWriter, err := zipWriter.Create("backup_metadata.json")
+	metaWriter, err := zipWriter.Create(metadataEntry)
 	if err != nil {
 		return fmt.Errorf("failed to create metadata file in zip: %w", err)
 	}
@@ -167,6 +192,10 @@ func WriteMetadata(zipWriter *zip.Writer, metadata BackupMetadata) error {
 // ExtractFile safely extracts a single file from a zip entry to the target path.
 // It applies safe permissions and validates the target path.
 func ExtractFile(f *zip.File, targetPath string, perm os.FileMode) error {
+	return extractFile(f, targetPath, perm, 100*1024*1024)
+}
+
+func extractFile(f *zip.File, targetPath string, perm os.FileMode, maxFileSize int64) error {
 	// Ensure parent directory exists
 	parentDir := filepath.Dir(targetPath)
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
@@ -194,8 +223,7 @@ func ExtractFile(f *zip.File, targetPath string, perm os.FileMode) error {
 	}
 	defer outFile.Close()
 
-	// Limit read size to prevent zip bombs (100MB per file)
-	const maxFileSize = 100 * 1024 * 1024
+	// Limit read size to prevent zip bombs
 	limitedReader := io.LimitReader(rc, maxFileSize+1)
 
 	n, err := io.Copy(outFile, limitedReader)
@@ -204,7 +232,7 @@ func ExtractFile(f *zip.File, targetPath string, perm os.FileMode) error {
 	}
 	if n > maxFileSize {
 		os.Remove(targetPath)
-		return fmt.Errorf("file exceeds maximum allowed size of 100MB")
+		return fmt.Errorf("file exceeds maximum allowed size of %dMB", maxFileSize>>20)
 	}
 
 	return nil
@@ -236,7 +264,7 @@ func CreateBackup(opts BackupOptions) (string, error) {
 	}
 
 	// Generate backup filename
-	backupName := opts.Name
+	backupName := strings.TrimSuffix(opts.Name, EncryptedSuffix)
 	if backupName == "" {
 		backupName = fmt.Sprintf("CLIProxyAPI-backup-%s.zip", time.Now().Format("20060102-150405"))
 	}
@@ -245,23 +273,43 @@ func CreateBackup(opts BackupOptions) (string, error) {
 	}
 
 	backupPath := filepath.Join(backupDir, backupName)
+	if opts.Passphrase == "" {
+		if err := writeArchive(backupPath, backupName, opts); err != nil {
+			return "", err
+		}
+		return backupPath, nil
+	}
 
+	// Build the plain archive next to the target, then encrypt it.
+	plainPath := backupPath + ".tmp"
+	defer os.Remove(plainPath)
+	if err := writeArchive(plainPath, backupName, opts); err != nil {
+		return "", err
+	}
+	encryptedPath := backupPath + EncryptedSuffix
+	if err := EncryptFile(plainPath, encryptedPath, opts.Passphrase); err != nil {
+		return "", fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+	return encryptedPath, nil
+}
+
+// writeArchive writes the zip archive for opts to archivePath.
+func writeArchive(archivePath, backupName string, opts BackupOptions) error {
 	// Create zip file with restrictive permissions
-	zipFile, err := os.OpenFile(backupPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	zipFile, err := os.OpenFile(archivePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
-		return "", fmt.Errorf("failed to create backup file: %w", err)
+		return fmt.Errorf("failed to create backup file: %w", err)
 	}
 	defer zipFile.Close()
 
 	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
 
 	// Backup .env if requested
 	if opts.Content.Env {
 		envPath := filepath.Join(opts.WorkDir, ".env")
 		if _, err := os.Stat(envPath); err == nil {
 			if err := AddFileToZip(zipWriter, envPath, ".env"); err != nil {
-				return "", fmt.Errorf("failed to backup .env: %w", err)
+				return fmt.Errorf("failed to backup .env: %w", err)
 			}
 		}
 	}
@@ -271,7 +319,7 @@ func CreateBackup(opts BackupOptions) (string, error) {
 		configPath := filepath.Join(opts.WorkDir, "config.yaml")
 		if _, err := os.Stat(configPath); err == nil {
 			if err := AddFileToZip(zipWriter, configPath, "config.yaml"); err != nil {
-				return "", fmt.Errorf("failed to backup config.yaml: %w", err)
+				return fmt.Errorf("failed to backup config.yaml: %w", err)
 			}
 		}
 	}
@@ -280,28 +328,88 @@ func CreateBackup(opts BackupOptions) (string, error) {
 	if opts.Content.Auths {
 		if _, err := os.Stat(opts.AuthDir); err == nil {
 			if err := AddDirToZip(zipWriter, opts.AuthDir, "auths"); err != nil {
-				return "", fmt.Errorf("failed to backup auths directory: %w", err)
+				return fmt.Errorf("failed to backup auths directory: %w", err)
 			}
 		}
 	}
 
+	// Backup a consistent snapshot of the usage records database if requested
+	if opts.Content.UsageDB {
+		if opts.SnapshotUsageDB == nil {
+			return fmt.Errorf("usage records database is not available")
+		}
+		if err := addUsageDBSnapshot(zipWriter, archivePath, opts.SnapshotUsageDB); err != nil {
+			return err
+		}
+	}
+
 	// Write metadata
 	metadata := BackupMetadata{
-		Name:    backupName,
-		Date:    time.Now().Format(time.RFC3339),
-		Content: opts.Content,
+		Name:      backupName,
+		Date:      time.Now().Format(time.RFC3339),
+		Content:   opts.Content,
+		Encrypted: opts.Passphrase != "",
+	}
+	if opts.Passphrase != "" {
+		metadata.Name += EncryptedSuffix
 	}
 	if err := WriteMetadata(zipWriter, metadata); err != nil {
-		return "", err
+		return err
 	}
 
-	return backupPath, nil
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finalize backup archive: %w", err)
+	}
+	return zipFile.Close()
+}
+
+// SnapshotUsageRecords writes a consistent copy of the usage records database to dest.
+// It is the BackupOptions.SnapshotUsageDB used by manual and scheduled backups.
+func SnapshotUsageRecords(dest string) error {
+	store := usagerecord.DefaultStore()
+	if store == nil {
+		return fmt.Errorf("usage record store is not available")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	return store.Snapshot(ctx, dest)
+}
+
+func addUsageDBSnapshot(zipWriter *zip.Writer, archivePath string, snapshot func(dest string) error) error {
+	snapshotPath := archivePath + ".usage-db"
+	_ = os.Remove(snapshotPath)
+	defer os.Remove(snapshotPath)
+	if err := snapshot(snapshotPath); err != nil {
+		return fmt.Errorf("failed to snapshot usage records database: %w", err)
+	}
+	if err := AddFileToZip(zipWriter, snapshotPath, usageDBEntry); err != nil {
+		return fmt.Errorf("failed to backup usage records database: %w", err)
+	}
+	return nil
 }
 
 // RestoreBackup restores a backup archive with the specified options
 func RestoreBackup(opts RestoreOptions) error {
 	backupPath := filepath.Join(opts.BackupPath, opts.BackupName)
 
+	// Decrypt encrypted archives to a temporary file first
+	if IsEncryptedFile(backupPath) {
+		if opts.Passphrase == "" {
+			return ErrPassphraseRequired
+		}
+		plain, err := os.CreateTemp("", "backup_restore_*.zip")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		plainPath := plain.Name()
+		plain.Close()
+		defer os.Remove(plainPath)
+		if err = DecryptFile(backupPath, plainPath, opts.Passphrase); err != nil {
+			return err
+		}
+		backupPath = plainPath
+	}
+
 	// Open the zip file
 	zipReader, err := zip.OpenReader(backupPath)
 	if err != nil {
@@ -354,7 +462,18 @@ func RestoreBackup(opts RestoreOptions) error {
 				return fmt.Errorf("invalid auth file path in backup: %w", err)
 			}
 			perm = 0600 // Auth files are sensitive
-		case f.Name == "backup_metadata.json":
+		case f.Name == usageDBEntry:
+			if opts.UsageDBPath == "" {
+				continue
+			}
+			if err := extractFile(f, opts.UsageDBPath, 0600, maxUsageDBSize); err != nil {
+				return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+			}
+			// Drop WAL files of the previous database so SQLite does not replay them.
+			_ = os.Remove(opts.UsageDBPath + "-wal")
+			_ = os.Remove(opts.UsageDBPath + "-shm")
+			continue
+		case f.Name == metadataEntry:
 			continue // Skip metadata file
 		default:
 			continue // Skip unknown files
@@ -381,7 +500,22 @@ func ListBackups(backupDir string) ([]BackupMetadata, error) {
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zip") {
+		if entry.IsDir() || !IsBackupName(entry.Name()) {
+			continue
+		}
+
+		// Encrypted archives cannot be inspected without the passphrase
+		if strings.HasSuffix(entry.Name(), EncryptedSuffix) {
+			info, errInfo := entry.Info()
+			if errInfo != nil {
+				continue
+			}
+			backups = append(backups, BackupMetadata{
+				Name:      entry.Name(),
+				Date:      info.ModTime().Format(time.RFC3339),
+				Size:      info.Size(),
+				Encrypted: true,
+			})
 			continue
 		}
 
@@ -423,7 +557,7 @@ func ReadBackupMetadata(zipPath string) (BackupMetadata, error) {
 	defer zipReader.Close()
 
 	for _, f := range zipReader.File {
-		if f.Name == "backup_metadata.json" {
+		if f.Name == metadataEntry {
 			rc, err := f.Open()
 			if err != nil {
 				return metadata, err
@@ -440,6 +574,19 @@ func ReadBackupMetadata(zipPath string) (BackupMetadata, error) {
 	return metadata, fmt.Errorf("metadata not found in backup")
 }
 
+// IsBackupName reports whether name is a plain (.zip) or encrypted (.zip.enc) backup archive name.
+func IsBackupName(name string) bool {
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".zip"+EncryptedSuffix)
+}
+
+// NormalizeBackupName appends ".zip" to name unless it already names a backup archive.
+func NormalizeBackupName(name string) string {
+	if IsBackupName(name) {
+		return name
+	}
+	return name + ".zip"
+}
+
 // DeleteBackup removes a backup file
 func DeleteBackup(backupDir, backupName string) error {
 	// Validate backup name to prevent path traversal
@@ -0,0 +1,180 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 30, 0, 0, time.UTC) // Saturday
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 3 * * *", time.Date(2026, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		s, err := ParseSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tc.expr, err)
+		}
+		if got := s.Next(base); !got.Equal(tc.want) {
+			t.Fatalf("%q: next = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := ParseSchedule(bad); err == nil {
+			t.Fatalf("ParseSchedule(%q) accepted invalid expression", bad)
+		}
+	}
+}
+
+func TestEncryptedBackupRoundTrip(t *testing.T) {
+	workDir := t.TempDir()
+	authDir := filepath.Join(workDir, "auths")
+	if err := os.MkdirAll(authDir, 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "config.yaml"), []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(authDir, "codex.json"), []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write auth: %v", err)
+	}
+
+	backupDir := filepath.Join(workDir, "backup")
+	path, err := CreateBackup(BackupOptions{
+		Name:       "nightly",
+		BackupPath: backupDir,
+		Content:    BackupContent{Config: true, Auths: true, UsageDB: true},
+		WorkDir:    workDir,
+		AuthDir:    authDir,
+		Passphrase: "correct horse",
+		SnapshotUsageDB: func(dest string) error {
+			return os.WriteFile(dest, []byte("sqlite snapshot"), 0o600)
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if filepath.Base(path) != "nightly.zip"+EncryptedSuffix || !IsEncryptedFile(path) {
+		t.Fatalf("unexpected archive %s", path)
+	}
+	if _, err = os.Stat(filepath.Join(backupDir, "nightly.zip")); !os.IsNotExist(err) {
+		t.Fatalf("plaintext archive left behind: %v", err)
+	}
+	list, err := ListBackups(backupDir)
+	if err != nil || len(list) != 1 || !list[0].Encrypted {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	restoreDir := t.TempDir()
+	opts := RestoreOptions{
+		BackupPath:  backupDir,
+		BackupName:  filepath.Base(path),
+		AuthsMode:   "incremental",
+		WorkDir:     restoreDir,
+		AuthDir:     filepath.Join(restoreDir, "auths"),
+		UsageDBPath: filepath.Join(restoreDir, "logs", "usage_records.db"),
+	}
+	if err = RestoreBackup(opts); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("restore without passphrase: %v", err)
+	}
+	opts.Passphrase = "wrong"
+	if err = RestoreBackup(opts); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("restore with wrong passphrase: %v", err)
+	}
+	opts.Passphrase = "correct horse"
+	if err = RestoreBackup(opts); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	for file, want := range map[string]string{
+		"config.yaml":           "port: 8317\n",
+		"auths/codex.json":      `{"type":"codex"}`,
+		"logs/usage_records.db": "sqlite snapshot",
+	} {
+		data, errRead := os.ReadFile(filepath.Join(restoreDir, file))
+		if errRead != nil || string(data) != want {
+			t.Fatalf("%s = %q, %v", file, data, errRead)
+		}
+	}
+}
+
+func TestEncryptFileDetectsTruncation(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain")
+	payload := make([]byte, 3*encryptedChunkSize+123)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	if err := os.WriteFile(src, payload, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	enc := filepath.Join(dir, "enc")
+	if err := EncryptFile(src, enc, "pw"); err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	out := filepath.Join(dir, "out")
+	if err := DecryptFile(enc, out, "pw"); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != string(payload) {
+		t.Fatalf("round trip mismatch")
+	}
+
+	data, _ := os.ReadFile(enc)
+	truncated := filepath.Join(dir, "truncated")
+	if err := os.WriteFile(truncated, data[:len(data)-200], 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := DecryptFile(truncated, out, "pw"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("truncated archive decrypted: %v", err)
+	}
+}
+
+func TestPruneScheduledRetention(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 5, 1, 3, 0, 0, 0, time.Local)
+	// Two backups per day for five days, plus a manual backup that must survive.
+	for day := 0; day < 5; day++ {
+		for _, hour := range []int{3, 15} {
+			at := start.AddDate(0, 0, day).Add(time.Duration(hour-3) * time.Hour)
+			if err := os.WriteFile(filepath.Join(dir, ScheduledBackupName(at)), nil, 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manual.zip"), nil, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	removed, err := PruneScheduled(dir, 2, 3)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	// Kept: both backups of the last day plus the newest backup of each of the two days before.
+	if len(removed) != 6 {
+		t.Fatalf("removed %d backups: %v", len(removed), removed)
+	}
+	for _, name := range []string{
+		ScheduledBackupName(start.AddDate(0, 0, 4).Add(12 * time.Hour)),
+		ScheduledBackupName(start.AddDate(0, 0, 4)),
+		ScheduledBackupName(start.AddDate(0, 0, 3).Add(12 * time.Hour)),
+		ScheduledBackupName(start.AddDate(0, 0, 2).Add(12 * time.Hour)),
+		"manual.zip",
+	} {
+		if _, errStat := os.Stat(filepath.Join(dir, name)); errStat != nil {
+			t.Fatalf("%s was pruned: %v", name, errStat)
+		}
+	}
+}
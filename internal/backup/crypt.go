@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptedSuffix is appended to the archive name of passphrase-encrypted backups.
+const EncryptedSuffix = ".enc"
+
+// Encrypted archives start with encryptedMagic, followed by the scrypt salt and a
+// nonce prefix. The zip is then written as AES-256-GCM frames of at most
+// encryptedChunkSize plaintext bytes; each frame is "final flag | length | ciphertext"
+// and the frame counter and final flag are bound into the nonce, so reordered,
+// dropped or truncated frames fail to decrypt.
+var encryptedMagic = []byte("CLIPROXY-BACKUP-ENC1\n")
+
+const (
+	encryptedSaltSize   = 16
+	encryptedPrefixSize = 7
+	encryptedChunkSize  = 64 * 1024
+	scryptN             = 1 << 15
+	scryptR             = 8
+	scryptP             = 1
+)
+
+// ErrPassphraseRequired is returned when restoring an encrypted backup without a passphrase.
+var ErrPassphraseRequired = errors.New("backup is encrypted: passphrase required")
+
+// ErrWrongPassphrase is returned when an encrypted backup cannot be authenticated.
+var ErrWrongPassphrase = errors.New("backup decryption failed: wrong passphrase or corrupted archive")
+
+// IsEncryptedFile reports whether path holds a passphrase-encrypted backup.
+func IsEncryptedFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(encryptedMagic))
+	if _, err = io.ReadFull(f, head); err != nil {
+		return false
+	}
+	return bytes.Equal(head, encryptedMagic)
+}
+
+func backupAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive backup key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func frameNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// EncryptFile writes an encrypted copy of src to dst using passphrase.
+func EncryptFile(src, dst, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("backup passphrase is empty")
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err = encryptStream(in, out, passphrase); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func encryptStream(r io.Reader, w io.Writer, passphrase string) error {
+	header := make([]byte, encryptedSaltSize+encryptedPrefixSize)
+	if _, err := rand.Read(header); err != nil {
+		return fmt.Errorf("generate backup salt: %w", err)
+	}
+	salt, prefix := header[:encryptedSaltSize], header[encryptedSaltSize:]
+	aead, err := backupAEAD(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if _, err = bw.Write(encryptedMagic); err != nil {
+		return err
+	}
+	if _, err = bw.Write(header); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(r, encryptedChunkSize)
+	buf := make([]byte, encryptedChunkSize)
+	var counter uint32
+	for {
+		n, errRead := io.ReadFull(br, buf)
+		if errRead != nil && errRead != io.EOF && errRead != io.ErrUnexpectedEOF {
+			return errRead
+		}
+		// The frame is final when the source is exhausted.
+		final := errRead != nil
+		if !final {
+			if _, errPeek := br.Peek(1); errPeek == io.EOF {
+				final = true
+			}
+		}
+		sealed := aead.Seal(nil, frameNonce(prefix, counter, final), buf[:n], nil)
+		frame := make([]byte, 5)
+		if final {
+			frame[0] = 1
+		}
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(sealed)))
+		if _, err = bw.Write(frame); err != nil {
+			return err
+		}
+		if _, err = bw.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return bw.Flush()
+		}
+		counter++
+	}
+}
+
+// DecryptFile writes the decrypted contents of the encrypted backup src to dst.
+func DecryptFile(src, dst, passphrase string) error {
+	if passphrase == "" {
+		return ErrPassphraseRequired
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err = decryptStream(in, out, passphrase); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func decryptStream(r io.Reader, w io.Writer, passphrase string) error {
+	br := bufio.NewReader(r)
+	head := make([]byte, len(encryptedMagic)+encryptedSaltSize+encryptedPrefixSize)
+	if _, err := io.ReadFull(br, head); err != nil || !bytes.Equal(head[:len(encryptedMagic)], encryptedMagic) {
+		return fmt.Errorf("not an encrypted backup")
+	}
+	salt := head[len(encryptedMagic) : len(encryptedMagic)+encryptedSaltSize]
+	prefix := head[len(encryptedMagic)+encryptedSaltSize:]
+	aead, err := backupAEAD(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, 5)
+	var counter uint32
+	for {
+		if _, err = io.ReadFull(br, frame); err != nil {
+			return ErrWrongPassphrase
+		}
+		final := frame[0] == 1
+		size := binary.BigEndian.Uint32(frame[1:])
+		if size > encryptedChunkSize+uint32(aead.Overhead()) {
+			return ErrWrongPassphrase
+		}
+		sealed := make([]byte, size)
+		if _, err = io.ReadFull(br, sealed); err != nil {
+			return ErrWrongPassphrase
+		}
+		plain, errOpen := aead.Open(nil, frameNonce(prefix, counter, final), sealed, nil)
+		if errOpen != nil {
+			return ErrWrongPassphrase
+		}
+		if _, err = w.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		counter++
+	}
+}
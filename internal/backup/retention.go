@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ScheduledPrefix names archives created by the Scheduler; only these are pruned.
+	ScheduledPrefix = "CLIProxyAPI-auto-"
+	scheduledLayout = "20060102-150405"
+)
+
+// ScheduledBackupName returns the archive name for a scheduled backup taken at t.
+func ScheduledBackupName(t time.Time) string {
+	return ScheduledPrefix + t.Format(scheduledLayout) + ".zip"
+}
+
+type scheduledBackup struct {
+	name string
+	at   time.Time
+}
+
+// listScheduled returns the scheduled backups in dir, newest first.
+func listScheduled(dir string) ([]scheduledBackup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	var out []scheduledBackup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, ScheduledPrefix) || !IsBackupName(name) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, EncryptedSuffix), ".zip")
+		at, errParse := time.ParseInLocation(scheduledLayout, strings.TrimPrefix(stamp, ScheduledPrefix), time.Local)
+		if errParse != nil {
+			continue
+		}
+		out = append(out, scheduledBackup{name: name, at: at})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].at.After(out[j].at) })
+	return out, nil
+}
+
+// selectPrunable returns the names not retained by the keep-last-N and keep-daily
+// policies. backups must be sorted newest first. keepLast retains the newest
+// keepLast archives; keepDaily retains the newest archive of each of the keepDaily
+// most recent days that have one. When both are zero everything is retained.
+func selectPrunable(backups []scheduledBackup, keepLast, keepDaily int) []string {
+	if keepLast <= 0 && keepDaily <= 0 {
+		return nil
+	}
+	days := make(map[string]struct{})
+	var prune []string
+	for i, b := range backups {
+		keep := i < keepLast
+		day := b.at.Format("2006-01-02")
+		if _, seen := days[day]; !seen && len(days) < keepDaily {
+			days[day] = struct{}{}
+			keep = true
+		}
+		if !keep {
+			prune = append(prune, b.name)
+		}
+	}
+	return prune
+}
+
+// PruneScheduled deletes scheduled backups in dir that fall outside the retention
+// policy and returns the removed archive names. Manually created backups are never
+// touched.
+func PruneScheduled(dir string, keepLast, keepDaily int) ([]string, error) {
+	backups, err := listScheduled(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, name := range selectPrunable(backups, keepLast, keepDaily) {
+		if errRemove := os.Remove(filepath.Join(dir, name)); errRemove != nil && !os.IsNotExist(errRemove) {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", name, errRemove)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,220 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// uploadTimeout bounds uploading one archive and pruning its remote copies.
+const uploadTimeout = 10 * time.Minute
+
+// Uploader copies finished archives to remote storage and removes pruned ones.
+type Uploader interface {
+	UploadBackup(ctx context.Context, name, path string) error
+	DeleteBackup(ctx context.Context, name string) error
+}
+
+// SchedulerConfig describes a scheduled backup job.
+type SchedulerConfig struct {
+	// Schedule is a five-field cron expression evaluated in local time.
+	Schedule string
+	// Options controls archive content, location and encryption; Name is ignored.
+	Options BackupOptions
+	// KeepLast and KeepDaily configure retention (see PruneScheduled).
+	KeepLast  int
+	KeepDaily int
+	// Uploader receives each new archive when set.
+	Uploader Uploader
+}
+
+// SchedulerStatus reports the state of a Scheduler.
+type SchedulerStatus struct {
+	Schedule   string    `json:"schedule"`
+	NextRun    time.Time `json:"next-run,omitempty"`
+	LastRun    time.Time `json:"last-run,omitempty"`
+	LastBackup string    `json:"last-backup,omitempty"`
+	LastError  string    `json:"last-error,omitempty"`
+}
+
+// Scheduler creates backups on a cron schedule and applies retention after each run.
+type Scheduler struct {
+	mu       sync.Mutex
+	cfg      SchedulerConfig
+	schedule *Schedule
+	status   SchedulerStatus
+
+	running sync.Mutex
+	started atomic.Bool
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	update    chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewScheduler validates cfg and returns a scheduler that is not yet started.
+func NewScheduler(cfg SchedulerConfig) (*Scheduler, error) {
+	s := &Scheduler{
+		update: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := s.Update(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update replaces the job configuration and reschedules the next run.
+func (s *Scheduler) Update(cfg SchedulerConfig) error {
+	schedule, err := ParseSchedule(cfg.Schedule)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cfg = cfg
+	s.schedule = schedule
+	s.status.Schedule = cfg.Schedule
+	s.mu.Unlock()
+	select {
+	case s.update <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status returns a snapshot of the scheduler state.
+func (s *Scheduler) Status() SchedulerStatus {
+	if s == nil {
+		return SchedulerStatus{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Scheduler) Start() {
+	if s == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.started.Store(true)
+		go s.loop()
+	})
+}
+
+func (s *Scheduler) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	if !s.started.Load() {
+		return
+	}
+	<-s.done
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+
+	for {
+		s.mu.Lock()
+		next := s.schedule.Next(time.Now())
+		s.status.NextRun = next
+		s.mu.Unlock()
+		if next.IsZero() {
+			log.Warnf("backup schedule %q never fires", s.Status().Schedule)
+			select {
+			case <-s.stop:
+				return
+			case <-s.update:
+				continue
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-s.update:
+			timer.Stop()
+			continue
+		case <-timer.C:
+			if _, err := s.RunOnce(context.Background()); err != nil {
+				log.WithError(err).Warn("scheduled backup failed")
+			}
+		}
+	}
+}
+
+// RunOnce creates a scheduled backup now, uploads it when an uploader is configured
+// and prunes old scheduled backups. It returns the path of the new archive.
+func (s *Scheduler) RunOnce(ctx context.Context) (string, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	s.mu.Lock()
+	cfg := s.cfg
+	s.mu.Unlock()
+
+	start := time.Now()
+	path, err := s.run(ctx, cfg, start)
+
+	s.mu.Lock()
+	s.status.LastRun = start
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	if path != "" {
+		s.status.LastBackup = filepath.Base(path)
+	}
+	s.mu.Unlock()
+	return path, err
+}
+
+func (s *Scheduler) run(ctx context.Context, cfg SchedulerConfig, now time.Time) (string, error) {
+	opts := cfg.Options
+	opts.Name = ScheduledBackupName(now)
+	path, err := CreateBackup(opts)
+	if err != nil {
+		return "", err
+	}
+	log.Infof("scheduled backup created: %s", path)
+
+	var uploadErr error
+	if cfg.Uploader != nil {
+		uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout)
+		defer cancel()
+		if uploadErr = cfg.Uploader.UploadBackup(uploadCtx, filepath.Base(path), path); uploadErr != nil {
+			uploadErr = fmt.Errorf("upload backup: %w", uploadErr)
+		}
+	}
+
+	removed, errPrune := PruneScheduled(filepath.Dir(path), cfg.KeepLast, cfg.KeepDaily)
+	if errPrune != nil {
+		log.WithError(errPrune).Warn("backup retention cleanup failed")
+	}
+	if len(removed) > 0 {
+		log.Infof("backup retention cleanup: removed %d old backups", len(removed))
+	}
+	// Only mirror deletions once the new archive reached remote storage.
+	if cfg.Uploader != nil && uploadErr == nil {
+		for _, name := range removed {
+			if errDelete := cfg.Uploader.DeleteBackup(ctx, name); errDelete != nil {
+				log.WithError(errDelete).Warnf("failed to delete remote backup %s", name)
+			}
+		}
+	}
+	return path, uploadErr
+}
@@ -0,0 +1,221 @@
+// Package claudebatch supports the Anthropic Message Batches and Files APIs.
+//
+// Batches and files created on an Anthropic API key only exist for that key, so
+// the RouteTable remembers which credential created each object and later calls
+// are pinned to it. Every pin also records the client that created the object, and
+// lookups only succeed for that same client. For requests that cannot be passed through, Runner emulates
+// the batch lifecycle locally by executing every request through the conductor.
+package claudebatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// routeRetention bounds how long pins are remembered. Anthropic keeps batch
+// results for 29 days; files live until deleted but stale pins are harmless to drop.
+const routeRetention = 60 * 24 * time.Hour
+
+// Route records the credential that holds an upstream batch or file and the client
+// that created it.
+type Route struct {
+	AuthID    string    `json:"auth_id"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type routeTableFile struct {
+	Batches map[string]Route `json:"batches"`
+	Files   map[string]Route `json:"files"`
+}
+
+// RouteTable maps upstream batch and file IDs to the auth that created them.
+// When a path is configured the table is persisted as JSON after each change.
+type RouteTable struct {
+	mu      sync.RWMutex
+	path    string
+	batches map[string]Route
+	files   map[string]Route
+}
+
+// NewRouteTable loads the table from path, or starts empty when the file does not exist.
+// An empty path keeps the table in memory only.
+func NewRouteTable(path string) *RouteTable {
+	t := &RouteTable{
+		path:    path,
+		batches: make(map[string]Route),
+		files:   make(map[string]Route),
+	}
+	if path == "" {
+		return t
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("claude batch routes: failed to read %s: %v", path, err)
+		}
+		return t
+	}
+	var stored routeTableFile
+	if err = json.Unmarshal(data, &stored); err != nil {
+		log.Warnf("claude batch routes: failed to parse %s: %v", path, err)
+		return t
+	}
+	cutoff := time.Now().Add(-routeRetention)
+	for id, route := range stored.Batches {
+		if route.AuthID != "" && route.CreatedAt.After(cutoff) {
+			t.batches[id] = route
+		}
+	}
+	for id, route := range stored.Files {
+		if route.AuthID != "" && route.CreatedAt.After(cutoff) {
+			t.files[id] = route
+		}
+	}
+	return t
+}
+
+// PinBatch records that owner created batchID with authID.
+func (t *RouteTable) PinBatch(batchID, authID, owner string) {
+	t.pin(t.batches, batchID, authID, owner)
+}
+
+// BatchAuth returns the auth that holds batchID when owner created it.
+func (t *RouteTable) BatchAuth(batchID, owner string) (string, bool) {
+	return t.lookup(t.batches, batchID, owner)
+}
+
+// BatchIDs returns the batch IDs owner created with authID.
+func (t *RouteTable) BatchIDs(authID, owner string) map[string]struct{} {
+	return t.idsFor(t.batches, authID, owner)
+}
+
+// RemoveBatch forgets a batch pin.
+func (t *RouteTable) RemoveBatch(batchID string) {
+	t.remove(t.batches, batchID)
+}
+
+// PinFile records that owner uploaded fileID with authID.
+func (t *RouteTable) PinFile(fileID, authID, owner string) {
+	t.pin(t.files, fileID, authID, owner)
+}
+
+// FileAuth returns the auth that holds fileID when owner uploaded it.
+func (t *RouteTable) FileAuth(fileID, owner string) (string, bool) {
+	return t.lookup(t.files, fileID, owner)
+}
+
+// FileIDs returns the file IDs owner uploaded with authID.
+func (t *RouteTable) FileIDs(authID, owner string) map[string]struct{} {
+	return t.idsFor(t.files, authID, owner)
+}
+
+// RemoveFile forgets a file pin.
+func (t *RouteTable) RemoveFile(fileID string) {
+	t.remove(t.files, fileID)
+}
+
+// AuthIDs returns every auth that holds at least one batch or file created by owner.
+func (t *RouteTable) AuthIDs(owner string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	seen := make(map[string]struct{})
+	out := make([]string, 0)
+	for _, table := range []map[string]Route{t.batches, t.files} {
+		for _, route := range table {
+			if route.Owner != owner {
+				continue
+			}
+			if _, ok := seen[route.AuthID]; ok {
+				continue
+			}
+			seen[route.AuthID] = struct{}{}
+			out = append(out, route.AuthID)
+		}
+	}
+	return out
+}
+
+func (t *RouteTable) pin(table map[string]Route, id, authID, owner string) {
+	if t == nil || id == "" || authID == "" {
+		return
+	}
+	t.mu.Lock()
+	table[id] = Route{AuthID: authID, Owner: owner, CreatedAt: time.Now().UTC()}
+	t.saveLocked()
+	t.mu.Unlock()
+}
+
+func (t *RouteTable) lookup(table map[string]Route, id, owner string) (string, bool) {
+	if t == nil || id == "" {
+		return "", false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	route, ok := table[id]
+	if !ok || route.Owner != owner {
+		return "", false
+	}
+	return route.AuthID, true
+}
+
+func (t *RouteTable) idsFor(table map[string]Route, authID, owner string) map[string]struct{} {
+	out := make(map[string]struct{})
+	if t == nil {
+		return out
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for id, route := range table {
+		if route.AuthID == authID && route.Owner == owner {
+			out[id] = struct{}{}
+		}
+	}
+	return out
+}
+
+func (t *RouteTable) remove(table map[string]Route, id string) {
+	if t == nil || id == "" {
+		return
+	}
+	t.mu.Lock()
+	if _, ok := table[id]; ok {
+		delete(table, id)
+		t.saveLocked()
+	}
+	t.mu.Unlock()
+}
+
+func (t *RouteTable) saveLocked() {
+	if t.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(routeTableFile{Batches: t.batches, Files: t.files}, "", "  ")
+	if err != nil {
+		log.Warnf("claude batch routes: marshal failed: %v", err)
+		return
+	}
+	if err = writeFileAtomic(t.path, data); err != nil {
+		log.Warnf("claude batch routes: %v", err)
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create directory for %s: %w", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp, err)
+	}
+	return nil
+}
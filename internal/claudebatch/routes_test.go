@@ -0,0 +1,51 @@
+package claudebatch
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRouteTablePersistsPins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	table := NewRouteTable(path)
+	table.PinBatch("msgbatch_1", "auth-a", "client-a")
+	table.PinFile("file_1", "auth-b", "client-a")
+	table.PinFile("file_2", "auth-b", "client-a")
+	table.RemoveFile("file_2")
+
+	reloaded := NewRouteTable(path)
+	if authID, ok := reloaded.BatchAuth("msgbatch_1", "client-a"); !ok || authID != "auth-a" {
+		t.Fatalf("batch pin = %q, %v", authID, ok)
+	}
+	if authID, ok := reloaded.FileAuth("file_1", "client-a"); !ok || authID != "auth-b" {
+		t.Fatalf("file pin = %q, %v", authID, ok)
+	}
+	if _, ok := reloaded.FileAuth("file_2", "client-a"); ok {
+		t.Fatal("removed file pin was persisted")
+	}
+	if ids := reloaded.FileIDs("auth-b", "client-a"); len(ids) != 1 {
+		t.Fatalf("file ids = %v", ids)
+	}
+}
+
+func TestRouteTableScopesPinsToOwner(t *testing.T) {
+	table := NewRouteTable("")
+	table.PinBatch("msgbatch_1", "auth-a", "client-a")
+	table.PinFile("file_1", "auth-a", "client-a")
+
+	if _, ok := table.BatchAuth("msgbatch_1", "client-b"); ok {
+		t.Fatal("batch visible to another client")
+	}
+	if _, ok := table.FileAuth("file_1", ""); ok {
+		t.Fatal("file visible to an anonymous client")
+	}
+	if ids := table.BatchIDs("auth-a", "client-b"); len(ids) != 0 {
+		t.Fatalf("batch ids for another client = %v", ids)
+	}
+	if ids := table.AuthIDs("client-b"); len(ids) != 0 {
+		t.Fatalf("auth ids for another client = %v", ids)
+	}
+	if ids := table.AuthIDs("client-a"); len(ids) != 1 || ids[0] != "auth-a" {
+		t.Fatalf("auth ids = %v", ids)
+	}
+}
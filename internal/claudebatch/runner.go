@@ -0,0 +1,362 @@
+package claudebatch
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// MaxRequests mirrors the Anthropic per-batch request limit.
+	MaxRequests = 100000
+
+	defaultConcurrency = 4
+	batchExpiry        = 24 * time.Hour
+	resultRetention    = 29 * 24 * time.Hour
+)
+
+// Processing states reported in the batch object.
+const (
+	StatusInProgress = "in_progress"
+	StatusCanceling  = "canceling"
+	StatusEnded      = "ended"
+)
+
+var (
+	// ErrNotFound is returned for unknown batch IDs.
+	ErrNotFound = errors.New("batch not found")
+	// ErrNotEnded is returned when results or deletion are requested before a batch ends.
+	ErrNotEnded = errors.New("batch has not finished processing")
+)
+
+// Request is one entry of a batch: the caller-supplied ID and Messages API params.
+type Request struct {
+	CustomID string
+	Params   []byte
+}
+
+// ExecuteFunc runs a single non-streaming Messages request on behalf of the client that
+// submitted the batch. On failure it returns the HTTP status and error message reported
+// for the request.
+type ExecuteFunc func(ctx context.Context, owner string, params []byte) ([]byte, int, error)
+
+// RequestCounts tallies request outcomes within a batch.
+type RequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// Batch is the Anthropic message_batch object.
+type Batch struct {
+	ID                string        `json:"id"`
+	Type              string        `json:"type"`
+	ProcessingStatus  string        `json:"processing_status"`
+	RequestCounts     RequestCounts `json:"request_counts"`
+	EndedAt           *time.Time    `json:"ended_at"`
+	CreatedAt         time.Time     `json:"created_at"`
+	ExpiresAt         time.Time     `json:"expires_at"`
+	ArchivedAt        *time.Time    `json:"archived_at"`
+	CancelInitiatedAt *time.Time    `json:"cancel_initiated_at"`
+	ResultsURL        *string       `json:"results_url"`
+}
+
+type batchState struct {
+	batch    Batch
+	owner    string
+	requests []Request
+	results  [][]byte
+	cancel   context.CancelFunc
+}
+
+// Runner emulates Message Batches by executing each request locally.
+// Batches and results are held in memory.
+type Runner struct {
+	mu          sync.Mutex
+	exec        ExecuteFunc
+	concurrency int
+	batches     map[string]*batchState
+}
+
+// NewRunner creates a runner that executes requests with exec, running up to
+// concurrency requests of a batch in parallel.
+func NewRunner(exec ExecuteFunc, concurrency int) *Runner {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	return &Runner{exec: exec, concurrency: concurrency, batches: make(map[string]*batchState)}
+}
+
+// Owns reports whether id is an emulated batch submitted by owner.
+func (r *Runner) Owns(id, owner string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.batches[id]
+	return ok && state.owner == owner
+}
+
+// Submit validates the requests and starts processing them in the background on behalf
+// of owner. Every request runs with the values of ctx; the batch is only stopped by Cancel.
+func (r *Runner) Submit(ctx context.Context, owner string, requests []Request) (Batch, error) {
+	if len(requests) == 0 {
+		return Batch{}, errors.New("requests: at least one request is required")
+	}
+	if len(requests) > MaxRequests {
+		return Batch{}, fmt.Errorf("requests: at most %d requests are allowed", MaxRequests)
+	}
+	seen := make(map[string]struct{}, len(requests))
+	for i, req := range requests {
+		if req.CustomID == "" {
+			return Batch{}, fmt.Errorf("requests.%d.custom_id: field required", i)
+		}
+		if _, dup := seen[req.CustomID]; dup {
+			return Batch{}, fmt.Errorf("requests.%d.custom_id: duplicate custom_id %q", i, req.CustomID)
+		}
+		seen[req.CustomID] = struct{}{}
+		if !gjson.ValidBytes(req.Params) || !gjson.GetBytes(req.Params, "model").Exists() {
+			return Batch{}, fmt.Errorf("requests.%d.params: model is required", i)
+		}
+	}
+
+	now := time.Now().UTC()
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	state := &batchState{
+		batch: Batch{
+			ID:               "msgbatch_" + randomID(),
+			Type:             "message_batch",
+			ProcessingStatus: StatusInProgress,
+			RequestCounts:    RequestCounts{Processing: len(requests)},
+			CreatedAt:        now,
+			ExpiresAt:        now.Add(batchExpiry),
+		},
+		owner:    owner,
+		requests: requests,
+		results:  make([][]byte, len(requests)),
+		cancel:   cancel,
+	}
+
+	r.mu.Lock()
+	r.pruneLocked(now)
+	r.batches[state.batch.ID] = state
+	snapshot := state.batch
+	r.mu.Unlock()
+
+	go r.run(ctx, state)
+	return snapshot, nil
+}
+
+// Get returns the current state of a batch.
+func (r *Runner) Get(id string) (Batch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.batches[id]
+	if !ok {
+		return Batch{}, ErrNotFound
+	}
+	return state.batch, nil
+}
+
+// List returns the batches submitted by owner, most recently created first.
+func (r *Runner) List(owner string) []Batch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Batch, 0)
+	for _, state := range r.batches {
+		if state.owner == owner {
+			out = append(out, state.batch)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Cancel stops a batch. Requests that have not finished are reported as canceled.
+func (r *Runner) Cancel(id string) (Batch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.batches[id]
+	if !ok {
+		return Batch{}, ErrNotFound
+	}
+	if state.batch.ProcessingStatus == StatusInProgress {
+		now := time.Now().UTC()
+		state.batch.ProcessingStatus = StatusCanceling
+		state.batch.CancelInitiatedAt = &now
+		state.cancel()
+	}
+	return state.batch, nil
+}
+
+// Delete removes an ended batch and its results.
+func (r *Runner) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.batches[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if state.batch.ProcessingStatus != StatusEnded {
+		return ErrNotEnded
+	}
+	delete(r.batches, id)
+	return nil
+}
+
+// Results returns the JSONL results of an ended batch in request order.
+func (r *Runner) Results(id string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.batches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if state.batch.ProcessingStatus != StatusEnded {
+		return nil, ErrNotEnded
+	}
+	var buf bytes.Buffer
+	for _, line := range state.results {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Runner) run(ctx context.Context, state *batchState) {
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i := range state.requests {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.runOne(ctx, state, idx)
+		}(i)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, line := range state.results {
+		if line == nil {
+			state.results[i] = resultLine(state.requests[i].CustomID, []byte(`{"type":"canceled"}`))
+			state.batch.RequestCounts.Canceled++
+			state.batch.RequestCounts.Processing--
+		}
+	}
+	now := time.Now().UTC()
+	state.batch.ProcessingStatus = StatusEnded
+	state.batch.EndedAt = &now
+	state.cancel()
+}
+
+func (r *Runner) runOne(ctx context.Context, state *batchState, idx int) {
+	if ctx.Err() != nil {
+		return
+	}
+	req := state.requests[idx]
+	params, _ := sjson.DeleteBytes(req.Params, "stream")
+	resp, status, err := r.exec(ctx, state.owner, params)
+	if err != nil && ctx.Err() != nil {
+		// Canceled mid-flight; reported as canceled when the batch ends.
+		return
+	}
+
+	var result []byte
+	if err != nil {
+		result = erroredResult(status, err.Error())
+	} else {
+		result = []byte(`{"type":"succeeded"}`)
+		if gjson.ValidBytes(resp) {
+			result, _ = sjson.SetRawBytes(result, "message", resp)
+		} else {
+			result = erroredResult(502, "upstream returned an invalid message")
+			err = errors.New("invalid message")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.results[idx] = resultLine(req.CustomID, result)
+	state.batch.RequestCounts.Processing--
+	if err != nil {
+		state.batch.RequestCounts.Errored++
+	} else {
+		state.batch.RequestCounts.Succeeded++
+	}
+}
+
+// pruneLocked drops ended batches whose results are past retention.
+func (r *Runner) pruneLocked(now time.Time) {
+	for id, state := range r.batches {
+		if state.batch.EndedAt != nil && now.Sub(*state.batch.EndedAt) > resultRetention {
+			delete(r.batches, id)
+		}
+	}
+}
+
+func resultLine(customID string, result []byte) []byte {
+	line := []byte(`{"custom_id":""}`)
+	line, _ = sjson.SetBytes(line, "custom_id", customID)
+	line, _ = sjson.SetRawBytes(line, "result", result)
+	return line
+}
+
+func erroredResult(status int, message string) []byte {
+	result := []byte(`{"type":"errored","error":{"type":"error","error":{"type":"","message":""}}}`)
+	result, _ = sjson.SetBytes(result, "error.error.type", ErrorType(status))
+	result, _ = sjson.SetBytes(result, "error.error.message", message)
+	return result
+}
+
+// ErrorType maps an HTTP status to the Anthropic error type name.
+func ErrorType(status int) string {
+	switch status {
+	case 400, 413, 422:
+		return "invalid_request_error"
+	case 401:
+		return "authentication_error"
+	case 403:
+		return "permission_error"
+	case 404:
+		return "not_found_error"
+	case 429:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+func randomID() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%024x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// MarshalBatch renders b with results_url pointing at resultsURL once the batch has ended.
+func MarshalBatch(b Batch, resultsURL string) []byte {
+	if b.ProcessingStatus == StatusEnded && resultsURL != "" {
+		b.ResultsURL = &resultsURL
+	}
+	data, _ := json.Marshal(b)
+	return data
+}
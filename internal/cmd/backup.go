@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagerecord"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,12 +22,26 @@ type BackupOptions struct {
 	IncludeEnv    bool
 	IncludeConfig bool
 	IncludeAuths  bool
+	// IncludeUsageDB adds a snapshot of the usage records database.
+	IncludeUsageDB bool
+	// Passphrase encrypts the archive; empty falls back to backup.passphrase in the config.
+	Passphrase string
 }
 
 // RestoreOptions contains options for restore operations
 type RestoreOptions struct {
 	BackupPath string
 	AuthsMode  string // "overwrite" or "incremental"
+	// Passphrase decrypts encrypted archives; empty falls back to backup.passphrase in the config.
+	Passphrase string
+}
+
+// backupPassphrase returns passphrase, falling back to the configured backup passphrase.
+func backupPassphrase(cfg *config.Config, passphrase string) string {
+	if passphrase != "" || cfg == nil {
+		return passphrase
+	}
+	return cfg.Backup.Passphrase
 }
 
 // DoBackup performs a backup operation
@@ -44,12 +61,15 @@ func DoBackup(cfg *config.Config, configPath string, opts *BackupOptions) {
 	}
 
 	// Default content selection
-	if !opts.IncludeEnv && !opts.IncludeConfig && !opts.IncludeAuths {
+	if !opts.IncludeEnv && !opts.IncludeConfig && !opts.IncludeAuths && !opts.IncludeUsageDB {
 		opts.IncludeConfig = true
 		opts.IncludeAuths = true
 	}
 
 	// Determine backup directory (CLI allows absolute paths)
+	if opts.BackupPath == "" {
+		opts.BackupPath = cfg.Backup.Path
+	}
 	backupDir := backup.ValidateBackupPathCLI(opts.BackupPath, wd)
 
 	// Determine auth directory using centralized helper
@@ -60,12 +80,21 @@ func DoBackup(cfg *config.Config, configPath string, opts *BackupOptions) {
 		Name:       opts.Name,
 		BackupPath: backupDir,
 		Content: backup.BackupContent{
-			Env:    opts.IncludeEnv,
-			Config: opts.IncludeConfig,
-			Auths:  opts.IncludeAuths,
+			Env:     opts.IncludeEnv,
+			Config:  opts.IncludeConfig,
+			Auths:   opts.IncludeAuths,
+			UsageDB: opts.IncludeUsageDB,
 		},
-		WorkDir: wd,
-		AuthDir: authDir,
+		WorkDir:    wd,
+		AuthDir:    authDir,
+		Passphrase: backupPassphrase(cfg, opts.Passphrase),
+	}
+	if opts.IncludeUsageDB {
+		// The server is not running here, so snapshot the database file directly.
+		logDir := logging.ResolveLogDirectory(cfg)
+		backupOpts.SnapshotUsageDB = func(dest string) error {
+			return usagerecord.SnapshotDatabase(context.Background(), logDir, dest)
+		}
 	}
 
 	backupPath, err := backup.CreateBackup(backupOpts)
@@ -97,20 +126,24 @@ func DoRestore(cfg *config.Config, configPath string, backupFile string, opts *R
 
 	// Determine backup file path
 	zipPath := backupFile
-	backupDir := filepath.Join(wd, "backup")
-	if opts.BackupPath != "" {
-		backupDir = backup.ValidateBackupPathCLI(opts.BackupPath, wd)
+	backupPath := opts.BackupPath
+	if backupPath == "" {
+		backupPath = cfg.Backup.Path
 	}
+	backupDir := backup.ValidateBackupPathCLI(backupPath, wd)
 
 	if !filepath.IsAbs(zipPath) {
 		// Check if it's in the backup directory
 		possiblePath := filepath.Join(backupDir, zipPath)
 		if _, err := os.Stat(possiblePath); err == nil {
 			zipPath = possiblePath
-		} else if !strings.HasSuffix(zipPath, ".zip") {
-			possiblePath = filepath.Join(backupDir, zipPath+".zip")
-			if _, err := os.Stat(possiblePath); err == nil {
-				zipPath = possiblePath
+		} else if !backup.IsBackupName(zipPath) {
+			for _, suffix := range []string{".zip", ".zip" + backup.EncryptedSuffix} {
+				possiblePath = filepath.Join(backupDir, zipPath+suffix)
+				if _, err := os.Stat(possiblePath); err == nil {
+					zipPath = possiblePath
+					break
+				}
 			}
 		}
 	}
@@ -124,12 +157,15 @@ func DoRestore(cfg *config.Config, configPath string, backupFile string, opts *R
 	authDir := backup.ResolveAuthDir(cfg.AuthDir, wd)
 
 	// Restore using shared package
+	// The server is not running here, so the usage records database can be replaced.
 	restoreOpts := backup.RestoreOptions{
-		BackupPath: filepath.Dir(zipPath),
-		BackupName: filepath.Base(zipPath),
-		AuthsMode:  opts.AuthsMode,
-		WorkDir:    wd,
-		AuthDir:    authDir,
+		BackupPath:  filepath.Dir(zipPath),
+		BackupName:  filepath.Base(zipPath),
+		AuthsMode:   opts.AuthsMode,
+		WorkDir:     wd,
+		AuthDir:     authDir,
+		Passphrase:  backupPassphrase(cfg, opts.Passphrase),
+		UsageDBPath: usagerecord.DatabasePath(logging.ResolveLogDirectory(cfg)),
 	}
 
 	if err := backup.RestoreBackup(restoreOpts); err != nil {
@@ -174,6 +210,12 @@ func ListBackups(backupPath string) {
 		if b.Content.Auths {
 			contentParts = append(contentParts, "auths")
 		}
+		if b.Content.UsageDB {
+			contentParts = append(contentParts, "usage-db")
+		}
+		if b.Encrypted {
+			contentParts = append(contentParts, "encrypted")
+		}
 		contentStr := strings.Join(contentParts, ", ")
 		if contentStr == "" {
 			contentStr = "unknown"
@@ -0,0 +1,204 @@
+// Package cmd contains CLI helpers. This file implements exporting auth files into a
+// portable credential bundle and importing bundles into the auth store.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/credbundle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// CredentialBundleOptions holds options for credential bundle export and import.
+type CredentialBundleOptions struct {
+	// Passphrase encrypts or decrypts the bundle; defaults to credential-bundles.passphrase.
+	Passphrase string
+	// Names limits the export or import to these auth file names.
+	Names []string
+	// Replace overwrites credentials for accounts that already exist.
+	Replace bool
+	// DropProxy removes per-credential proxy settings carried in the bundle.
+	DropProxy bool
+	// DryRun reports what an import would do without writing anything.
+	DryRun bool
+}
+
+func (o *CredentialBundleOptions) passphrase(cfg *config.Config) string {
+	if o != nil && o.Passphrase != "" {
+		return o.Passphrase
+	}
+	return cfg.CredentialBundles.Passphrase
+}
+
+// bundleStore returns the registered token store rooted at the auth directory and the
+// credentials it currently holds, rebuilt the way the file watcher sees them.
+func bundleStore(ctx context.Context, cfg *config.Config) (coreauth.Store, []*coreauth.Auth, error) {
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	store := sdkAuth.GetTokenStore()
+	if store == nil {
+		return nil, nil, fmt.Errorf("token store unavailable")
+	}
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	listed, err := store.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list auth files: %w", err)
+	}
+	return store, credbundle.Synthesize(cfg, cfg.AuthDir, listed), nil
+}
+
+// DoExportCredentials writes the selected auth files and their settings to a signed,
+// optionally encrypted credential bundle at outPath.
+func DoExportCredentials(cfg *config.Config, outPath string, opts *CredentialBundleOptions) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	ctx := context.Background()
+	_, auths, err := bundleStore(ctx, cfg)
+	if err != nil {
+		log.Errorf("export-credentials: %v", err)
+		return
+	}
+	signingKey, _, err := credbundle.KeysFromConfig(cfg.CredentialBundles)
+	if err != nil {
+		log.Errorf("export-credentials: %v", err)
+		return
+	}
+
+	wanted := make(map[string]bool)
+	if opts != nil {
+		for _, name := range opts.Names {
+			wanted[strings.ToLower(name)] = false
+		}
+	}
+	entries := make([]credbundle.Entry, 0, len(auths))
+	for _, auth := range auths {
+		if !credbundle.Exportable(auth) {
+			continue
+		}
+		name := strings.ToLower(credbundle.AuthName(auth))
+		if len(wanted) > 0 {
+			if _, ok := wanted[name]; !ok {
+				continue
+			}
+			wanted[name] = true
+		}
+		entry, errEntry := credbundle.EntryFromAuth(auth)
+		if errEntry != nil {
+			log.Errorf("export-credentials: %v", errEntry)
+			return
+		}
+		entries = append(entries, entry)
+	}
+	for name, found := range wanted {
+		if !found {
+			log.Errorf("export-credentials: auth file not found: %s", name)
+			return
+		}
+	}
+	if len(entries) == 0 {
+		log.Errorf("export-credentials: no credentials to export")
+		return
+	}
+
+	passphrase := opts.passphrase(cfg)
+	data, err := credbundle.Build(entries, credbundle.BuildOptions{Passphrase: passphrase, SigningKey: signingKey})
+	if err != nil {
+		log.Errorf("export-credentials: %v", err)
+		return
+	}
+	if err = os.WriteFile(outPath, data, 0o600); err != nil {
+		log.Errorf("export-credentials: write %s: %v", outPath, err)
+		return
+	}
+	fmt.Printf("Exported %d credential(s) to %s (encrypted: %t, signed: %t)\n", len(entries), outPath, passphrase != "", signingKey != nil)
+}
+
+// DoImportCredentials imports a credential bundle into the auth store. A running server
+// picks the new files up through its file watcher.
+func DoImportCredentials(cfg *config.Config, inPath string, opts *CredentialBundleOptions) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if opts == nil {
+		opts = &CredentialBundleOptions{}
+	}
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		log.Errorf("import-credentials: read %s: %v", inPath, err)
+		return
+	}
+	_, trustedKeys, err := credbundle.KeysFromConfig(cfg.CredentialBundles)
+	if err != nil {
+		log.Errorf("import-credentials: %v", err)
+		return
+	}
+	contents, err := credbundle.Open(data, credbundle.OpenOptions{
+		Passphrase:       opts.passphrase(cfg),
+		TrustedKeys:      trustedKeys,
+		RequireSignature: cfg.CredentialBundles.RequireSignature,
+	})
+	if err != nil {
+		log.Errorf("import-credentials: %v", err)
+		return
+	}
+	ctx := context.Background()
+	store, existing, err := bundleStore(ctx, cfg)
+	if err != nil {
+		log.Errorf("import-credentials: %v", err)
+		return
+	}
+
+	signer := "unsigned"
+	if contents.Signer != "" {
+		signer = "signed by " + contents.Signer
+	}
+	fmt.Printf("Bundle created %s, %d credential(s), %s\n", contents.CreatedAt.Format("2006-01-02 15:04:05"), len(contents.Entries), signer)
+	results := credbundle.Import(ctx, contents.Entries, credbundle.Target{
+		Store:    store,
+		Config:   cfg,
+		AuthDir:  cfg.AuthDir,
+		Existing: existing,
+	}, credbundle.ImportOptions{
+		Replace:   opts.Replace,
+		DropProxy: opts.DropProxy,
+		DryRun:    opts.DryRun,
+		Names:     opts.Names,
+	})
+	for _, result := range results {
+		line := fmt.Sprintf("  %-8s %s", result.Action, result.Name)
+		if result.File != "" && result.File != result.Name {
+			line += " -> " + result.File
+		}
+		if result.Conflict != "" {
+			line += fmt.Sprintf(" (conflicts with %s)", result.Conflict)
+		}
+		if result.Error != "" {
+			line += ": " + result.Error
+		}
+		fmt.Println(line)
+	}
+	if opts.DryRun {
+		fmt.Println("Dry run: nothing was written")
+	}
+}
+
+// DoGenerateBundleKey prints a new credential bundle signing key and its public key.
+func DoGenerateBundleKey() {
+	privateKey, publicKey, err := credbundle.GenerateSigningKey()
+	if err != nil {
+		log.Errorf("failed to generate bundle signing key: %v", err)
+		return
+	}
+	fmt.Printf("signing-key: %s\npublic-key:  %s\n", privateKey, publicKey)
+}
@@ -0,0 +1,161 @@
+// Package cmd provides command-line interface functionality. This file implements
+// encrypting, decrypting and re-keying secrets stored at rest.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// SecretsMode selects the operation performed by DoSecrets.
+type SecretsMode string
+
+const (
+	// SecretsEncrypt encrypts plaintext auth files and config secrets.
+	SecretsEncrypt SecretsMode = "encrypt"
+	// SecretsDecrypt rewrites encrypted auth files and config secrets as plaintext.
+	SecretsDecrypt SecretsMode = "decrypt"
+	// SecretsRotate re-wraps encrypted data under the current primary key.
+	SecretsRotate SecretsMode = "rotate"
+)
+
+// secretsPersister is implemented by remote-backed token stores that mirror local files.
+type secretsPersister interface {
+	PersistConfig(ctx context.Context) error
+	PersistAuthFiles(ctx context.Context, message string, paths ...string) error
+}
+
+// DoGenerateEncryptionKey prints a new random key suitable for ENCRYPTION_KEY.
+func DoGenerateEncryptionKey() {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		log.Errorf("failed to generate encryption key: %v", err)
+		return
+	}
+	fmt.Println(key)
+}
+
+// DoSecrets encrypts, decrypts or re-keys the auth files in cfg.AuthDir and the
+// secret values in the config file, then pushes the changes to a remote-backed
+// token store when one is in use.
+func DoSecrets(cfg *config.Config, configFilePath string, mode SecretsMode) {
+	if mode != SecretsDecrypt && !encryption.Enabled() {
+		log.Errorf("%s: set %s or %s first", mode, encryption.EnvKey, encryption.EnvKeyFile)
+		return
+	}
+
+	changedAuths, failed := transformAuthFiles(cfg.AuthDir, mode)
+
+	configChanged := false
+	if strings.TrimSpace(configFilePath) != "" {
+		var err error
+		switch mode {
+		case SecretsEncrypt:
+			configChanged, err = config.EncryptConfigSecrets(configFilePath)
+		case SecretsDecrypt:
+			configChanged, err = config.DecryptConfigSecrets(configFilePath)
+		case SecretsRotate:
+			configChanged, err = config.RotateConfigSecrets(configFilePath)
+		}
+		if err != nil {
+			log.Errorf("%s: config %s: %v", mode, configFilePath, err)
+			failed++
+		}
+	}
+
+	if persister, ok := sdkAuth.GetTokenStore().(secretsPersister); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		if len(changedAuths) > 0 {
+			if err := persister.PersistAuthFiles(ctx, fmt.Sprintf("%s auth files", mode), changedAuths...); err != nil {
+				log.Errorf("%s: persist auth files: %v", mode, err)
+				failed++
+			}
+		}
+		if configChanged {
+			if err := persister.PersistConfig(ctx); err != nil {
+				log.Errorf("%s: persist config: %v", mode, err)
+				failed++
+			}
+		}
+	}
+
+	log.Infof("%s: %d auth file(s) updated, config updated: %t, failures: %d", mode, len(changedAuths), configChanged, failed)
+	if mode == SecretsRotate && failed == 0 {
+		log.Infof("rotate: all data now uses key %s; retired keys can be removed", encryption.Default().KeyID())
+	}
+}
+
+// transformAuthFiles applies mode to every JSON file under authDir and returns the
+// paths it rewrote together with the number of files it could not process.
+func transformAuthFiles(authDir string, mode SecretsMode) ([]string, int) {
+	authDir = strings.TrimSpace(authDir)
+	if authDir == "" {
+		return nil, 0
+	}
+	var changed []string
+	failed := 0
+	_ = filepath.WalkDir(authDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		data, errRead := os.ReadFile(path)
+		if errRead != nil || len(bytes.TrimSpace(data)) == 0 {
+			return nil
+		}
+		out, errTransform := transformAuthData(data, mode)
+		if errTransform != nil {
+			log.Errorf("%s: %s: %v", mode, filepath.Base(path), errTransform)
+			failed++
+			return nil
+		}
+		if bytes.Equal(out, data) {
+			return nil
+		}
+		tmp := path + ".tmp"
+		if errWrite := os.WriteFile(tmp, out, 0o600); errWrite != nil {
+			log.Errorf("%s: %s: %v", mode, filepath.Base(path), errWrite)
+			failed++
+			return nil
+		}
+		if errRename := os.Rename(tmp, path); errRename != nil {
+			log.Errorf("%s: %s: %v", mode, filepath.Base(path), errRename)
+			failed++
+			return nil
+		}
+		changed = append(changed, path)
+		return nil
+	})
+	return changed, failed
+}
+
+func transformAuthData(data []byte, mode SecretsMode) ([]byte, error) {
+	switch mode {
+	case SecretsEncrypt:
+		return encryption.Seal(data)
+	case SecretsDecrypt:
+		return encryption.Open(data)
+	case SecretsRotate:
+		if !encryption.IsSealed(data) {
+			return encryption.Seal(data)
+		}
+		out, _, err := encryption.Default().Rewrap(data)
+		return out, err
+	default:
+		return nil, fmt.Errorf("unknown mode %q", mode)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
 )
 
 // DoIFlowCookieAuth performs the iFlow cookie-based authentication.
@@ -74,6 +75,10 @@ func DoIFlowCookieAuth(cfg *config.Config, options *LoginOptions) {
 		fmt.Printf("Failed to save authentication: %v\n", err)
 		return
 	}
+	if err := encryption.SealFile(authFilePath); err != nil {
+		fmt.Printf("Failed to encrypt authentication: %v\n", err)
+		return
+	}
 
 	fmt.Printf("Authentication successful! API key: %s\n", tokenData.APIKey)
 	fmt.Printf("Expires at: %s\n", tokenData.Expire)
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoOAuth2Login runs the configured flow of a provider declared under oauth-providers and
+// saves the resulting token as an auth file of that provider's type.
+//
+// Parameters:
+//   - cfg: The application configuration containing the oauth-providers list
+//   - provider: The name of the declarative OAuth provider
+//   - options: Login options including browser behavior and callback port settings
+func DoOAuth2Login(cfg *config.Config, provider string, options *LoginOptions) {
+	if options == nil {
+		options = &LoginOptions{}
+	}
+
+	entry := cfg.FindOAuthProvider(provider)
+	if entry == nil {
+		log.Errorf("OAuth provider %q is not configured under oauth-providers", provider)
+		return
+	}
+
+	manager := newAuthManager()
+	manager.Register(sdkAuth.NewOAuth2Authenticator(*entry))
+	authOpts := &sdkAuth.LoginOptions{
+		NoBrowser:    options.NoBrowser,
+		CallbackPort: options.CallbackPort,
+		Metadata:     map[string]string{},
+		Prompt:       options.Prompt,
+	}
+
+	record, savedPath, err := manager.Login(context.Background(), entry.Name, cfg, authOpts)
+	if err != nil {
+		log.Errorf("%s authentication failed: %v", entry.Name, err)
+		return
+	}
+
+	if savedPath != "" {
+		fmt.Printf("Authentication saved to %s\n", savedPath)
+	}
+	if record != nil && record.Label != "" {
+		fmt.Printf("Authenticated as %s\n", record.Label)
+	}
+}
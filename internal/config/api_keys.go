@@ -0,0 +1,136 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// GeneratedAPIKeyPrefix starts every server-generated client API key.
+	GeneratedAPIKeyPrefix = "cpk_"
+	// HashedAPIKeyPrefix marks api-key values stored as a SHA-256 digest.
+	HashedAPIKeyPrefix = "sha256:"
+
+	generatedKeyIDLength     = 8
+	generatedKeySecretLength = 40
+	apiKeyAlphabet           = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// HashAPIKey returns the stored form of a client API key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return HashedAPIKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// IsHashedAPIKey reports whether value is a stored digest rather than a literal key.
+func IsHashedAPIKey(value string) bool {
+	digest, ok := strings.CutPrefix(value, HashedAPIKeyPrefix)
+	if !ok || len(digest) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+// APIKeyDigest returns the SHA-256 digest a presented key must match for this entry.
+// Literal keys from older configs are digested on the fly so all comparisons work on
+// fixed-size values.
+func (e *ApiKeyEntry) APIKeyDigest() []byte {
+	key := strings.TrimSpace(e.Key)
+	if IsHashedAPIKey(key) {
+		digest, _ := hex.DecodeString(strings.TrimPrefix(key, HashedAPIKeyPrefix))
+		return digest
+	}
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// MatchesAPIKey compares presented against the entry in constant time.
+func (e *ApiKeyEntry) MatchesAPIKey(presented string) bool {
+	sum := sha256.Sum256([]byte(presented))
+	return subtle.ConstantTimeCompare(sum[:], e.APIKeyDigest()) == 1
+}
+
+// ExpiresAtTime parses ExpiresAt; the zero time means the key never expires.
+func (e *ApiKeyEntry) ExpiresAtTime() time.Time {
+	if strings.TrimSpace(e.ExpiresAt) == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(e.ExpiresAt))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Expired reports whether the key has an expiry at or before now.
+func (e *ApiKeyEntry) Expired(now time.Time) bool {
+	expires := e.ExpiresAtTime()
+	return !expires.IsZero() && !now.Before(expires)
+}
+
+// Usable reports whether the key is active and not expired at now.
+func (e *ApiKeyEntry) Usable(now time.Time) bool {
+	return e.IsActive && !e.Expired(now) && strings.TrimSpace(e.Key) != ""
+}
+
+// HasPolicy reports whether the entry carries lifecycle or access policy metadata (a
+// stored digest, display prefix, expiry, rotation link, IP lists or tenant) and therefore must
+// keep its object form.
+func (e *ApiKeyEntry) HasPolicy() bool {
+	return e.Prefix != "" || e.ExpiresAt != "" || e.RotatedTo != "" || len(e.IPAllow) > 0 || len(e.IPDeny) > 0 || e.Tenant != "" ||
+		IsHashedAPIKey(strings.TrimSpace(e.Key))
+}
+
+// GenerateAPIKey creates a new client API key of the form "cpk_<id>_<secret>". It
+// returns the plaintext key, which is shown to the caller once, and the display
+// prefix ("cpk_<id>") kept alongside the hash.
+func GenerateAPIKey() (key, prefix string, err error) {
+	id, err := randomAPIKeyString(generatedKeyIDLength)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := randomAPIKeyString(generatedKeySecretLength)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = GeneratedAPIKeyPrefix + id
+	return prefix + "_" + secret, prefix, nil
+}
+
+func randomAPIKeyString(n int) (string, error) {
+	var b strings.Builder
+	b.Grow(n)
+	limit := big.NewInt(int64(len(apiKeyAlphabet)))
+	for i := 0; i < n; i++ {
+		idx, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", fmt.Errorf("generate api key: %w", err)
+		}
+		b.WriteByte(apiKeyAlphabet[idx.Int64()])
+	}
+	return b.String(), nil
+}
+
+// trimStringList trims entries and drops empty ones.
+func trimStringList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
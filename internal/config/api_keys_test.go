@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAPIKeyEntryLifecycle(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if !strings.HasPrefix(key, prefix+"_") || len(prefix) != len(GeneratedAPIKeyPrefix)+generatedKeyIDLength {
+		t.Fatalf("key %q, prefix %q", key, prefix)
+	}
+
+	entry := ApiKeyEntry{Key: HashAPIKey(key), Prefix: prefix, IsActive: true, ExpiresAt: "2026-01-02T03:04:05Z"}
+	if !IsHashedAPIKey(entry.Key) || !entry.HasPolicy() {
+		t.Fatalf("entry %+v not recognized as hashed", entry)
+	}
+	if !entry.MatchesAPIKey(key) || entry.MatchesAPIKey(entry.Key) || entry.MatchesAPIKey(prefix) {
+		t.Fatalf("hashed entry matching is wrong")
+	}
+	if literal := (ApiKeyEntry{Key: "plain"}); !literal.MatchesAPIKey("plain") || literal.HasPolicy() {
+		t.Fatalf("literal entry matching is wrong")
+	}
+
+	expiry := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !entry.Usable(expiry.Add(-time.Second)) || entry.Usable(expiry) {
+		t.Fatalf("expiry boundary is wrong")
+	}
+
+	data, err := yaml.Marshal(struct {
+		APIKeys []ApiKeyEntry `yaml:"api-keys"`
+	}{[]ApiKeyEntry{entry, {Key: "plain"}}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), "expires-at: \"2026-01-02T03:04:05Z\"") || !strings.Contains(string(data), "- plain") {
+		t.Fatalf("unexpected yaml:\n%s", data)
+	}
+}
@@ -0,0 +1,178 @@
+package config
+
+import "strings"
+
+const (
+	// DefaultAzureOpenAIAPIVersion is the api-version used for chat completions.
+	DefaultAzureOpenAIAPIVersion = "2024-10-21"
+	// DefaultAzureOpenAIResponsesAPIVersion is the api-version used for the Responses API.
+	DefaultAzureOpenAIResponsesAPIVersion = "2025-04-01-preview"
+)
+
+// AzureOpenAIKey represents an Azure OpenAI resource. Client model names are routed to
+// deployments through Models; requests authenticate with an api-key header or with
+// Microsoft Entra ID client credentials.
+type AzureOpenAIKey struct {
+	// Name is an optional display label for the resource.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// BaseURL is the resource endpoint, e.g. "https://my-resource.openai.azure.com".
+	BaseURL string `yaml:"base-url" json:"base-url"`
+
+	// APIKey is the resource key sent in the api-key header. Leave empty to use Entra.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Entra configures Microsoft Entra ID client-credentials authentication.
+	Entra *AzureEntraAuth `yaml:"entra,omitempty" json:"entra,omitempty"`
+
+	// APIVersion is the api-version query parameter for chat completions.
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// ResponsesAPIVersion is the api-version query parameter for the Responses API.
+	ResponsesAPIVersion string `yaml:"responses-api-version,omitempty" json:"responses-api-version,omitempty"`
+
+	// Disabled indicates whether this credential is intentionally disabled.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "azure/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Tenant assigns the credential to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this resource if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this resource.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models maps client-facing model names (Alias) to deployment names (Name).
+	Models []AzureOpenAIModel `yaml:"models" json:"models"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// AzureEntraAuth holds Microsoft Entra ID (Azure AD) client-credentials settings.
+type AzureEntraAuth struct {
+	// TenantID is the directory (tenant) ID of the app registration.
+	TenantID string `yaml:"tenant-id" json:"tenant-id"`
+
+	// ClientID is the application (client) ID.
+	ClientID string `yaml:"client-id" json:"client-id"`
+
+	// ClientSecret is the client secret of the app registration.
+	ClientSecret string `yaml:"client-secret" json:"client-secret"`
+
+	// AuthorityHost overrides the login endpoint; defaults to "https://login.microsoftonline.com".
+	AuthorityHost string `yaml:"authority-host,omitempty" json:"authority-host,omitempty"`
+
+	// Scope overrides the requested scope; defaults to "https://cognitiveservices.azure.com/.default".
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+}
+
+// GetAPIKey returns the identifier used to match auths to this entry: the api-key, or
+// "entra:<client-id>" for Entra credentials.
+func (k AzureOpenAIKey) GetAPIKey() string {
+	if key := strings.TrimSpace(k.APIKey); key != "" {
+		return key
+	}
+	if k.Entra != nil && strings.TrimSpace(k.Entra.ClientID) != "" {
+		return "entra:" + strings.TrimSpace(k.Entra.ClientID)
+	}
+	return ""
+}
+
+func (k AzureOpenAIKey) GetBaseURL() string { return k.BaseURL }
+
+// AzureOpenAIModel maps a client-facing model name to a deployment.
+type AzureOpenAIModel struct {
+	// Name is the deployment name.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name; defaults to the deployment name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m AzureOpenAIModel) GetName() string  { return m.Name }
+func (m AzureOpenAIModel) GetAlias() string { return m.Alias }
+
+// SanitizeAzureOpenAIKeys normalizes Azure OpenAI resources and drops entries without a
+// base URL, credentials or deployments.
+func (cfg *Config) SanitizeAzureOpenAIKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.AzureOpenAIKey))
+	out := cfg.AzureOpenAIKey[:0]
+	for i := range cfg.AzureOpenAIKey {
+		entry := cfg.AzureOpenAIKey[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.BaseURL = strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.BaseURL == "" {
+			continue
+		}
+		if entry.Entra != nil {
+			entra := *entry.Entra
+			entra.TenantID = strings.TrimSpace(entra.TenantID)
+			entra.ClientID = strings.TrimSpace(entra.ClientID)
+			entra.ClientSecret = strings.TrimSpace(entra.ClientSecret)
+			entra.AuthorityHost = strings.TrimRight(strings.TrimSpace(entra.AuthorityHost), "/")
+			entra.Scope = strings.TrimSpace(entra.Scope)
+			entry.Entra = &entra
+			if entra.TenantID == "" || entra.ClientID == "" || entra.ClientSecret == "" {
+				entry.Entra = nil
+			}
+		}
+		if entry.APIKey != "" {
+			// The api-key takes precedence; Entra settings would be ignored.
+			entry.Entra = nil
+		} else if entry.Entra == nil {
+			continue
+		}
+		entry.APIVersion = strings.TrimSpace(entry.APIVersion)
+		if entry.APIVersion == "" {
+			entry.APIVersion = DefaultAzureOpenAIAPIVersion
+		}
+		entry.ResponsesAPIVersion = strings.TrimSpace(entry.ResponsesAPIVersion)
+		if entry.ResponsesAPIVersion == "" {
+			entry.ResponsesAPIVersion = DefaultAzureOpenAIResponsesAPIVersion
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Tenant = strings.TrimSpace(entry.Tenant)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]AzureOpenAIModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name == "" {
+				continue
+			}
+			if model.Alias == "" {
+				model.Alias = model.Name
+			}
+			models = append(models, model)
+		}
+		if len(models) == 0 {
+			continue
+		}
+		entry.Models = models
+
+		uniqueKey := entry.GetAPIKey() + "|" + entry.BaseURL
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.AzureOpenAIKey = out
+}
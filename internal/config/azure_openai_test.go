@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestSanitizeAzureOpenAIKeys(t *testing.T) {
+	cfg := &Config{AzureOpenAIKey: []AzureOpenAIKey{
+		{BaseURL: "https://a.openai.azure.com/", APIKey: " key ", Entra: &AzureEntraAuth{TenantID: "t", ClientID: "c", ClientSecret: "s"}, Models: []AzureOpenAIModel{{Name: "gpt-4o-prod", Alias: "gpt-4o"}}},
+		{BaseURL: "https://a.openai.azure.com", APIKey: "key", Models: []AzureOpenAIModel{{Name: "dup"}}},
+		{BaseURL: "https://b.openai.azure.com", Entra: &AzureEntraAuth{TenantID: "t", ClientID: "c", ClientSecret: "s"}, Models: []AzureOpenAIModel{{Name: "gpt-4o-mini"}}},
+		{BaseURL: "https://c.openai.azure.com", Entra: &AzureEntraAuth{ClientID: "c"}, Models: []AzureOpenAIModel{{Name: "m"}}},
+		{BaseURL: "https://d.openai.azure.com", APIKey: "key"},
+		{APIKey: "key", Models: []AzureOpenAIModel{{Name: "m"}}},
+	}}
+	cfg.SanitizeAzureOpenAIKeys()
+
+	if len(cfg.AzureOpenAIKey) != 2 {
+		t.Fatalf("kept %d entries, want 2: %+v", len(cfg.AzureOpenAIKey), cfg.AzureOpenAIKey)
+	}
+	keyed := cfg.AzureOpenAIKey[0]
+	if keyed.BaseURL != "https://a.openai.azure.com" || keyed.GetAPIKey() != "key" || keyed.Entra != nil {
+		t.Fatalf("api-key entry = %+v", keyed)
+	}
+	if keyed.APIVersion != DefaultAzureOpenAIAPIVersion || keyed.ResponsesAPIVersion != DefaultAzureOpenAIResponsesAPIVersion {
+		t.Fatalf("default api versions not applied: %+v", keyed)
+	}
+	entra := cfg.AzureOpenAIKey[1]
+	if entra.GetAPIKey() != "entra:c" || entra.Models[0].Alias != "gpt-4o-mini" {
+		t.Fatalf("entra entry = %+v", entra)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import "strings"
+
+// BedrockKey represents an AWS Bedrock credential serving Claude models through the
+// Bedrock runtime invoke endpoints. Requests are signed with SigV4 using either the
+// static access key fields or a named profile from the shared AWS credentials file.
+type BedrockKey struct {
+	// AccessKeyID is the AWS access key ID. Leave empty to use Profile instead.
+	AccessKeyID string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+
+	// SecretAccessKey is the AWS secret access key paired with AccessKeyID.
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional session token for temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// Profile names a profile in the shared AWS credentials file
+	// (AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials). The file is re-read on every
+	// request so externally refreshed credentials are picked up.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+
+	// Region is the AWS region hosting the Bedrock runtime, e.g. "us-east-1".
+	Region string `yaml:"region" json:"region"`
+
+	// Disabled indicates whether this credential is intentionally disabled.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "aws/claude-sonnet-4").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Tenant assigns the credential to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// BaseURL overrides the runtime endpoint; defaults to
+	// "https://bedrock-runtime.{region}.amazonaws.com".
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ModelIDPrefix is prepended to built-in Bedrock model IDs, e.g. "us." or "eu." to
+	// route through a cross-region inference profile. Ignored for configured models.
+	ModelIDPrefix string `yaml:"model-id-prefix,omitempty" json:"model-id-prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models maps client-facing Claude model names (Alias) to Bedrock model IDs (Name).
+	// When empty, the built-in Claude model list is served with derived Bedrock IDs.
+	Models []BedrockModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// GetAPIKey returns the identifier used to match auths to this entry: the access key
+// ID, or "profile:<name>" for profile credentials. It never contains the secret.
+func (k BedrockKey) GetAPIKey() string {
+	if id := strings.TrimSpace(k.AccessKeyID); id != "" {
+		return id
+	}
+	if profile := strings.TrimSpace(k.Profile); profile != "" {
+		return "profile:" + profile
+	}
+	return ""
+}
+
+func (k BedrockKey) GetBaseURL() string { return k.BaseURL }
+
+// BedrockModel maps a client-facing alias to a Bedrock model ID.
+type BedrockModel struct {
+	// Name is the Bedrock model or inference profile ID, e.g.
+	// "anthropic.claude-sonnet-4-20250514-v1:0".
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m BedrockModel) GetName() string  { return m.Name }
+func (m BedrockModel) GetAlias() string { return m.Alias }
+
+// SanitizeBedrockKeys normalizes Bedrock credentials, drops entries without a region or
+// credentials and fills in the default runtime endpoint.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.BedrockKey))
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		entry.Profile = strings.TrimSpace(entry.Profile)
+		entry.Region = strings.ToLower(strings.TrimSpace(entry.Region))
+		if entry.Region == "" {
+			continue
+		}
+		if entry.AccessKeyID != "" {
+			if entry.SecretAccessKey == "" {
+				continue
+			}
+			// Static credentials take precedence; a profile would be ignored.
+			entry.Profile = ""
+		} else if entry.Profile == "" {
+			continue
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Tenant = strings.TrimSpace(entry.Tenant)
+		entry.BaseURL = strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			entry.BaseURL = "https://bedrock-runtime." + entry.Region + ".amazonaws.com"
+		}
+		entry.ModelIDPrefix = strings.TrimSpace(entry.ModelIDPrefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		sanitizedModels := make([]BedrockModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Alias = strings.TrimSpace(model.Alias)
+			model.Name = strings.TrimSpace(model.Name)
+			if model.Name == "" {
+				continue
+			}
+			if model.Alias == "" {
+				model.Alias = model.Name
+			}
+			sanitizedModels = append(sanitizedModels, model)
+		}
+		entry.Models = sanitizedModels
+
+		uniqueKey := entry.GetAPIKey() + "|" + entry.BaseURL
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
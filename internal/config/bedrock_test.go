@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestSanitizeBedrockKeys(t *testing.T) {
+	cfg := &Config{BedrockKey: []BedrockKey{
+		{AccessKeyID: " AKID1 ", SecretAccessKey: "secret", Region: "US-EAST-1", Profile: "ignored"},
+		{AccessKeyID: "AKID1", SecretAccessKey: "secret", Region: "us-east-1"},
+		{AccessKeyID: "AKID2", Region: "us-east-1"},
+		{Profile: "team", Region: "eu-west-1", BaseURL: "https://bedrock.example.com/", Models: []BedrockModel{{Name: "anthropic.claude-v2"}}},
+		{Profile: "team"},
+	}}
+	cfg.SanitizeBedrockKeys()
+
+	if len(cfg.BedrockKey) != 2 {
+		t.Fatalf("kept %d entries, want 2: %+v", len(cfg.BedrockKey), cfg.BedrockKey)
+	}
+	static := cfg.BedrockKey[0]
+	if static.AccessKeyID != "AKID1" || static.Region != "us-east-1" || static.Profile != "" {
+		t.Fatalf("static entry = %+v", static)
+	}
+	if static.BaseURL != "https://bedrock-runtime.us-east-1.amazonaws.com" {
+		t.Fatalf("default base url = %q", static.BaseURL)
+	}
+	profile := cfg.BedrockKey[1]
+	if profile.GetAPIKey() != "profile:team" || profile.BaseURL != "https://bedrock.example.com" {
+		t.Fatalf("profile entry = %+v", profile)
+	}
+	if profile.Models[0].Alias != "anthropic.claude-v2" {
+		t.Fatalf("model alias should default to name: %+v", profile.Models)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -35,6 +36,9 @@ type Config struct {
 	// TLS config controls HTTPS server settings.
 	TLS TLSConfig `yaml:"tls" json:"tls"`
 
+	// IPAccess restricts which client addresses may call the proxy API.
+	IPAccess IPAccessConfig `yaml:"ip-access" json:"ip-access"`
+
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
@@ -68,6 +72,27 @@ type Config struct {
 	// Default: 30 days. Set to 0 to keep forever (disable cleanup).
 	UsageRecordsRetentionDays int `yaml:"usage-records-retention-days" json:"usage-records-retention-days"`
 
+	// AuditLogRetentionDays controls how long management audit log entries are kept.
+	// Default: 365 days. Set to 0 to keep forever (disable cleanup).
+	AuditLogRetentionDays int `yaml:"audit-log-retention-days" json:"audit-log-retention-days"`
+
+	// ConfigHistoryLimit is how many accepted config versions are kept for rollback.
+	// Default: 50. Set to 0 to disable config history.
+	ConfigHistoryLimit int `yaml:"config-history-limit" json:"config-history-limit"`
+
+	// Backup configures scheduled backups of config, auth files and usage records.
+	Backup BackupConfig `yaml:"backup" json:"backup"`
+
+	// Notifications delivers operational events to webhook targets.
+	Notifications NotificationsConfig `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+
+	// CredentialBundles configures signing and encryption of credential export bundles.
+	CredentialBundles CredentialBundleConfig `yaml:"credential-bundles,omitempty" json:"credential-bundles,omitempty"`
+
+	// ProxyPools are named sets of outbound proxies referenced by credentials ("pool:<name>")
+	// or assigned to providers.
+	ProxyPools []ProxyPool `yaml:"proxy-pools,omitempty" json:"proxy-pools,omitempty"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -112,6 +137,21 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// BedrockKey defines AWS Bedrock credentials that serve Claude models.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key" json:"bedrock-api-key"`
+
+	// AzureOpenAIKey defines Azure OpenAI resources with deployment-based routing.
+	AzureOpenAIKey []AzureOpenAIKey `yaml:"azure-openai" json:"azure-openai"`
+
+	// LocalProviders defines self-hosted model servers (Ollama, llama.cpp) with model discovery.
+	LocalProviders []LocalProvider `yaml:"local-providers" json:"local-providers"`
+
+	// OAuthProviders declares OAuth2-protected upstreams configured without provider-specific code.
+	OAuthProviders []OAuthProvider `yaml:"oauth-providers" json:"oauth-providers"`
+
+	// Plugins declares out-of-process executors reached over a Unix socket.
+	Plugins []ExecutorPlugin `yaml:"plugins" json:"plugins"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -130,6 +170,22 @@ type Config struct {
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
 	legacyMigrationPending bool `yaml:"-" json:"-"`
+
+	// secretRefs holds the values resolved from secret references, keyed by config path.
+	secretRefs map[string]SecretReference
+}
+
+// managementSecretKeyPath is the config path of remote-management.secret-key.
+const managementSecretKeyPath = "remote-management.secret-key"
+
+// SecretReferences returns the secret references resolved while loading, keyed by
+// config path such as "claude-api-key.0.api-key". Callers must not modify the
+// returned map.
+func (cfg *Config) SecretReferences() map[string]SecretReference {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.secretRefs
 }
 
 // ClaudeHeaderDefaults configures default header values injected into Claude API requests
@@ -157,6 +213,57 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ClientCA is a PEM bundle of CAs trusted to sign client certificates.
+	ClientCA string `yaml:"client-ca,omitempty" json:"client-ca,omitempty"`
+	// ClientAuth selects client certificate verification: "none" (default), "optional"
+	// (verify when presented) or "require". Verified certificate subjects become the
+	// access principal.
+	ClientAuth string `yaml:"client-auth,omitempty" json:"client-auth,omitempty"`
+}
+
+// TLS client authentication modes.
+const (
+	TLSClientAuthNone     = "none"
+	TLSClientAuthOptional = "optional"
+	TLSClientAuthRequire  = "require"
+)
+
+// ClientCertsEnabled reports whether client certificates are verified.
+func (t TLSConfig) ClientCertsEnabled() bool {
+	return t.Enable && t.ClientCA != "" && t.ClientAuth != TLSClientAuthNone
+}
+
+// IPAccessConfig holds CIDR allow and deny lists for the proxy API.
+type IPAccessConfig struct {
+	// Allow lists the CIDRs or addresses permitted to connect. Empty allows all.
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+	// Deny lists CIDRs or addresses that are always rejected; deny wins over allow.
+	Deny []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+	// TrustedProxies lists reverse proxies whose X-Forwarded-For/X-Real-IP headers are
+	// honored when determining the client address. Empty uses the connection address.
+	TrustedProxies []string `yaml:"trusted-proxies,omitempty" json:"trusted-proxies,omitempty"`
+}
+
+// BackupConfig holds scheduled backup settings.
+type BackupConfig struct {
+	// Schedule is a five-field cron expression in local time, e.g. "0 3 * * *".
+	// Empty disables scheduled backups.
+	Schedule string `yaml:"schedule" json:"schedule"`
+	// Path is the backup directory; relative paths resolve against the working directory.
+	// Default: "backup".
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// KeepLast keeps the newest N scheduled backups. Default: 7.
+	KeepLast int `yaml:"keep-last" json:"keep-last"`
+	// KeepDaily additionally keeps the newest scheduled backup of each of the last N days.
+	KeepDaily int `yaml:"keep-daily" json:"keep-daily"`
+	// IncludeEnv adds the .env file to scheduled backups.
+	IncludeEnv bool `yaml:"include-env" json:"include-env"`
+	// IncludeUsageDB adds a consistent snapshot of the usage records database.
+	IncludeUsageDB bool `yaml:"include-usage-db" json:"include-usage-db"`
+	// Passphrase encrypts every backup archive created by the service when set.
+	Passphrase string `yaml:"passphrase,omitempty" json:"-"`
+	// Upload copies scheduled backups to the object store when one is configured.
+	Upload bool `yaml:"upload" json:"upload"`
 }
 
 // PprofConfig holds pprof HTTP server settings.
@@ -178,6 +285,12 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Accounts lists named management users with role-scoped, bcrypt-hashed tokens.
+	Accounts []ManagementAccount `yaml:"accounts,omitempty"`
+	// AllowedSecretReferences lists the secret references (env:, file:, vault:) that
+	// non-admin accounts may write through the management API, e.g. "env:OPENAI_*" or
+	// "file:/run/secrets/". References already in the config stay writable.
+	AllowedSecretReferences []string `yaml:"allowed-secret-references,omitempty"`
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -342,6 +455,9 @@ type ClaudeKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tenant assigns the credential to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
 	// BaseURL is the base URL for the Claude API endpoint.
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -393,6 +509,9 @@ type CodexKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-5-codex").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tenant assigns the credential to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
 	// BaseURL is the base URL for the Codex API endpoint.
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -444,6 +563,9 @@ type GeminiKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gemini-3-pro-preview").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tenant assigns the credential to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
@@ -488,6 +610,9 @@ type OpenAICompatibility struct {
 	// Prefix optionally namespaces model aliases for this provider (e.g., "teamA/kimi-k2").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tenant assigns the credential to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
 	// BaseURL is the base URL for the external OpenAI-compatible API endpoint.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
@@ -497,6 +622,10 @@ type OpenAICompatibility struct {
 	// Models defines the model configurations including aliases for routing.
 	Models []OpenAICompatibilityModel `yaml:"models" json:"models"`
 
+	// DiscoverModels optionally lists the provider's /models with each key and registers
+	// the matching models alongside Models.
+	DiscoverModels *ModelDiscovery `yaml:"discover-models,omitempty" json:"discover-models,omitempty"`
+
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 }
@@ -558,6 +687,16 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		return &Config{}, nil
 	}
 
+	// Decrypt secret values encrypted at rest before parsing.
+	if data, err = decryptConfigData(data); err != nil {
+		return nil, err
+	}
+	// Resolve secret references (env:, file:, vault:) in place of literal keys.
+	var secretRefs map[string]SecretReference
+	if data, secretRefs, err = resolveConfigReferences(data); err != nil {
+		return nil, err
+	}
+
 	// Unmarshal the YAML data into the Config struct.
 	var cfg Config
 	// Set defaults before unmarshal so that absent keys keep defaults.
@@ -567,6 +706,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	cfg.ErrorLogsMaxFiles = 10
 	cfg.UsageStatisticsEnabled = false
 	cfg.UsageRecordsRetentionDays = 30
+	cfg.AuditLogRetentionDays = 365
+	cfg.ConfigHistoryLimit = 50
+	cfg.Backup.KeepLast = 7
 	cfg.DisableCooling = false
 	cfg.Pprof.Enable = false
 	cfg.Pprof.Addr = DefaultPprofAddr
@@ -599,16 +741,26 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Hash remote management key if plaintext is detected (nested)
 	// We consider a value to be already hashed if it looks like a bcrypt hash ($2a$, $2b$, or $2y$ prefix).
 	if cfg.RemoteManagement.SecretKey != "" && !looksLikeBcrypt(cfg.RemoteManagement.SecretKey) {
-		hashed, errHash := hashSecret(cfg.RemoteManagement.SecretKey)
+		plaintext := cfg.RemoteManagement.SecretKey
+		hashed, errHash := hashSecret(plaintext)
 		if errHash != nil {
 			return nil, fmt.Errorf("failed to hash remote management key: %w", errHash)
 		}
 		cfg.RemoteManagement.SecretKey = hashed
 
-		// Persist the hashed value back to the config file to avoid re-hashing on next startup.
-		// Preserve YAML comments and ordering; update only the nested key.
-		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+		if ref, isRef := secretRefs[managementSecretKeyPath]; isRef && ref.Value == plaintext {
+			// A referenced key is hashed in memory only; the file keeps the reference.
+			ref.Value = hashed
+			secretRefs[managementSecretKeyPath] = ref
+		} else {
+			// Persist the hashed value back to the config file to avoid re-hashing on next startup.
+			// Preserve YAML comments and ordering; update only the nested key.
+			_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+		}
 	}
+	cfg.secretRefs = secretRefs
+
+	cfg.RemoteManagement.Accounts = NormalizeManagementAccounts(cfg.RemoteManagement.Accounts)
 
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
@@ -626,6 +778,38 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	if cfg.UsageRecordsRetentionDays < 0 {
 		cfg.UsageRecordsRetentionDays = 0
 	}
+	if cfg.AuditLogRetentionDays < 0 {
+		cfg.AuditLogRetentionDays = 0
+	}
+	if cfg.ConfigHistoryLimit < 0 {
+		cfg.ConfigHistoryLimit = 0
+	}
+	cfg.TLS.ClientCA = strings.TrimSpace(cfg.TLS.ClientCA)
+	switch cfg.TLS.ClientAuth = strings.ToLower(strings.TrimSpace(cfg.TLS.ClientAuth)); cfg.TLS.ClientAuth {
+	case "", TLSClientAuthNone, TLSClientAuthOptional, TLSClientAuthRequire:
+	default:
+		log.Warnf("unknown tls.client-auth %q; client certificates are required", cfg.TLS.ClientAuth)
+		cfg.TLS.ClientAuth = TLSClientAuthRequire
+	}
+	if cfg.TLS.ClientCA != "" && cfg.TLS.ClientAuth == "" {
+		cfg.TLS.ClientAuth = TLSClientAuthRequire
+	}
+	cfg.IPAccess.Allow = trimStringList(cfg.IPAccess.Allow)
+	cfg.IPAccess.Deny = trimStringList(cfg.IPAccess.Deny)
+	cfg.IPAccess.TrustedProxies = trimStringList(cfg.IPAccess.TrustedProxies)
+	for i := range cfg.APIKeys {
+		cfg.APIKeys[i].IPAllow = trimStringList(cfg.APIKeys[i].IPAllow)
+		cfg.APIKeys[i].IPDeny = trimStringList(cfg.APIKeys[i].IPDeny)
+		cfg.APIKeys[i].Tenant = strings.TrimSpace(cfg.APIKeys[i].Tenant)
+	}
+	cfg.Backup.Schedule = strings.TrimSpace(cfg.Backup.Schedule)
+	cfg.Backup.Path = strings.TrimSpace(cfg.Backup.Path)
+	if cfg.Backup.KeepLast < 0 {
+		cfg.Backup.KeepLast = 0
+	}
+	if cfg.Backup.KeepDaily < 0 {
+		cfg.Backup.KeepDaily = 0
+	}
 
 	if cfg.ErrorLogsMaxFiles < 0 {
 		cfg.ErrorLogsMaxFiles = 10
@@ -641,6 +825,21 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Vertex-compatible API keys: drop entries without base-url
 	cfg.SanitizeVertexCompatKeys()
 
+	// Sanitize Bedrock keys: drop entries without region or credentials
+	cfg.SanitizeBedrockKeys()
+
+	// Sanitize Azure OpenAI resources: drop entries without base-url, credentials or deployments
+	cfg.SanitizeAzureOpenAIKeys()
+
+	// Sanitize local backends: fill default base URLs and drop unnamed entries
+	cfg.SanitizeLocalProviders()
+
+	// Normalize declarative OAuth providers.
+	cfg.SanitizeOAuthProviders()
+
+	// Sanitize executor plugins after OAuth providers so names cannot collide.
+	cfg.SanitizePlugins()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
@@ -662,6 +861,15 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize notification targets and budgets.
+	cfg.SanitizeNotifications()
+
+	// Trim credential bundle keys.
+	cfg.SanitizeCredentialBundles()
+
+	// Normalize proxy pools and drop invalid members.
+	cfg.SanitizeProxyPools()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -796,8 +1004,10 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 		e := cfg.OpenAICompatibility[i]
 		e.Name = strings.TrimSpace(e.Name)
 		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.Tenant = strings.TrimSpace(e.Tenant)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
+		e.DiscoverModels.sanitize()
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
 			continue
@@ -817,6 +1027,7 @@ func (cfg *Config) SanitizeCodexKeys() {
 	for i := range cfg.CodexKey {
 		e := cfg.CodexKey[i]
 		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.Tenant = strings.TrimSpace(e.Tenant)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
 		e.ExcludedModels = NormalizeExcludedModels(e.ExcludedModels)
@@ -836,6 +1047,7 @@ func (cfg *Config) SanitizeClaudeKeys() {
 	for i := range cfg.ClaudeKey {
 		entry := &cfg.ClaudeKey[i]
 		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Tenant = strings.TrimSpace(entry.Tenant)
 		entry.Headers = NormalizeHeaders(entry.Headers)
 		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
 	}
@@ -856,6 +1068,7 @@ func (cfg *Config) SanitizeGeminiKeys() {
 			continue
 		}
 		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Tenant = strings.TrimSpace(entry.Tenant)
 		entry.BaseURL = strings.TrimSpace(entry.BaseURL)
 		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
 		entry.Headers = NormalizeHeaders(entry.Headers)
@@ -985,6 +1198,11 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	if original.Content[0] == nil || original.Content[0].Kind != yaml.MappingNode {
 		return fmt.Errorf("expected root mapping node")
 	}
+	// Merge against plaintext; secrets are re-encrypted below.
+	ciphertexts, err := openConfigSecrets(&original)
+	if err != nil {
+		return err
+	}
 
 	// Marshal the current cfg to YAML, then unmarshal to a yaml.Node we can merge from.
 	rendered, err := yaml.Marshal(persistCfg)
@@ -1014,13 +1232,12 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	// Merge generated into original in-place, preserving comments/order of existing nodes.
 	mergeMappingPreserve(original.Content[0], generated.Content[0])
 	normalizeCollectionNodeStyles(original.Content[0])
-
-	// Write back.
-	f, err := os.Create(configFile)
-	if err != nil {
+	restoreConfigReferences(original.Content[0], cfg.secretRefs)
+	if _, err = sealConfigSecrets(original.Content[0], ciphertexts); err != nil {
 		return err
 	}
-	defer func() { _ = f.Close() }()
+
+	// Write back.
 	var buf bytes.Buffer
 	enc := yaml.NewEncoder(&buf)
 	enc.SetIndent(2)
@@ -1031,9 +1248,7 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	if err = enc.Close(); err != nil {
 		return err
 	}
-	data = NormalizeCommentIndentation(buf.Bytes())
-	_, err = f.Write(data)
-	return err
+	return WriteConfigFile(configFile, buf.Bytes())
 }
 
 // SaveConfigPreserveCommentsUpdateNestedScalar updates a nested scalar key path like ["a","b"]
@@ -1068,11 +1283,6 @@ func SaveConfigPreserveCommentsUpdateNestedScalar(configFile string, path []stri
 			node = next
 		}
 	}
-	f, err := os.Create(configFile)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
 	var buf bytes.Buffer
 	enc := yaml.NewEncoder(&buf)
 	enc.SetIndent(2)
@@ -1083,9 +1293,44 @@ func SaveConfigPreserveCommentsUpdateNestedScalar(configFile string, path []stri
 	if err = enc.Close(); err != nil {
 		return err
 	}
-	data = NormalizeCommentIndentation(buf.Bytes())
-	_, err = f.Write(data)
-	return err
+	return WriteConfigFile(configFile, buf.Bytes())
+}
+
+// WriteConfigFile replaces configFile with data in one atomic step: data is written
+// to a temporary file in the same directory, synced and renamed over the target, so
+// readers and the file watcher never see a partial or intermediate file. Callers
+// seal secrets in data before writing. The target keeps its file mode.
+func WriteConfigFile(configFile string, data []byte) error {
+	data = NormalizeCommentIndentation(data)
+	tmp, err := os.CreateTemp(filepath.Dir(configFile), "."+filepath.Base(configFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		cleanup()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	if info, errStat := os.Stat(configFile); errStat == nil {
+		_ = os.Chmod(tmpPath, info.Mode().Perm())
+	}
+	if err = os.Rename(tmpPath, configFile); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
 }
 
 // NormalizeCommentIndentation removes indentation from standalone YAML comment lines to keep them left aligned.
@@ -0,0 +1,398 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secretref"
+	"gopkg.in/yaml.v3"
+)
+
+// secretRefTimeout bounds resolving all secret references of one config load.
+const secretRefTimeout = 30 * time.Second
+
+// secretScalarKeys are mapping keys whose scalar values are encrypted at rest.
+var secretScalarKeys = map[string]struct{}{
+	"api-key":              {},
+	"upstream-api-key":     {},
+	"amp-upstream-api-key": {},
+	"secret-access-key":    {},
+	"session-token":        {},
+	"client-secret":        {},
+	"passphrase":           {},
+	"bot-token":            {},
+	"signing-key":          {},
+}
+
+// secretListKeys are mapping keys whose plain string list items are encrypted at rest.
+var secretListKeys = map[string]struct{}{
+	"api-keys":                    {},
+	"generative-language-api-key": {},
+}
+
+// walkConfigScalars calls fn for every scalar value with the mapping key it belongs
+// to and whether it is a direct item of a sequence.
+func walkConfigScalars(node *yaml.Node, key string, inList bool, fn func(n *yaml.Node, key string, inList bool) error) error {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := walkConfigScalars(child, key, false, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := walkConfigScalars(node.Content[i+1], node.Content[i].Value, false, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := walkConfigScalars(child, key, true, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(node, key, inList)
+	}
+	return nil
+}
+
+// secretRefKeys are mapping keys whose values may be secret references such as
+// "env:NAME" or "file:/path" instead of literal keys.
+var secretRefKeys = map[string]struct{}{
+	"api-key":              {},
+	"upstream-api-key":     {},
+	"amp-upstream-api-key": {},
+	"secret-key":           {},
+	"access-key-id":        {},
+	"secret-access-key":    {},
+	"session-token":        {},
+	"client-secret":        {},
+	"passphrase":           {},
+	"bot-token":            {},
+	"signing-key":          {},
+}
+
+func isSecretConfigValue(key string, inList bool) bool {
+	if inList {
+		_, ok := secretListKeys[key]
+		return ok
+	}
+	_, ok := secretScalarKeys[key]
+	return ok
+}
+
+// openConfigSecrets decrypts every encrypted scalar in place and returns the original
+// ciphertext keyed by plaintext so unchanged values can be written back verbatim.
+func openConfigSecrets(root *yaml.Node) (map[string]string, error) {
+	ciphertexts := make(map[string]string)
+	err := walkConfigScalars(root, "", false, func(n *yaml.Node, key string, _ bool) error {
+		if !encryption.IsSealedString(n.Value) {
+			return nil
+		}
+		plaintext, err := encryption.OpenString(n.Value)
+		if err != nil {
+			return fmt.Errorf("config key %s: %w", key, err)
+		}
+		ciphertexts[plaintext] = n.Value
+		n.Value = plaintext
+		n.Tag = "!!str"
+		n.Style = 0
+		return nil
+	})
+	return ciphertexts, err
+}
+
+// sealConfigSecrets encrypts secret values with the default keyring, reusing the
+// previous ciphertext of unchanged values to keep the file stable across saves.
+// It returns the number of values it changed.
+func sealConfigSecrets(root *yaml.Node, previous map[string]string) (int, error) {
+	if !encryption.Enabled() {
+		return 0, nil
+	}
+	changed := 0
+	err := walkConfigScalars(root, "", false, func(n *yaml.Node, key string, inList bool) error {
+		if n.Value == "" || encryption.IsSealedString(n.Value) || secretref.IsReference(n.Value) || !isSecretConfigValue(key, inList) {
+			return nil
+		}
+		changed++
+		if sealed, ok := previous[n.Value]; ok {
+			n.Value = sealed
+			return nil
+		}
+		sealed, err := encryption.SealString(n.Value)
+		if err != nil {
+			return err
+		}
+		n.Value = sealed
+		n.Style = 0
+		return nil
+	})
+	return changed, err
+}
+
+// decryptConfigData returns data with every encrypted scalar replaced by its plaintext.
+func decryptConfigData(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(encryption.StringPrefix)) {
+		return data, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if _, err := openConfigSecrets(&root); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&root)
+}
+
+// SecretReference is a config value loaded from a secret reference.
+type SecretReference struct {
+	// Reference is the value written in the file, e.g. "env:CLAUDE_API_KEY".
+	Reference string
+	// Value is the secret it resolved to.
+	Value string
+}
+
+// walkConfigScalarPaths calls fn for every scalar value with its dotted path, such as
+// "claude-api-key.0.api-key". Dots and wildcards in mapping keys are escaped so the
+// path doubles as a gjson/sjson path into the config JSON.
+func walkConfigScalarPaths(node *yaml.Node, path string, fn func(n *yaml.Node, path, key string, inList bool) error) error {
+	return walkConfigScalarPathsIn(node, path, "", false, fn)
+}
+
+func walkConfigScalarPathsIn(node *yaml.Node, path, key string, inList bool, fn func(n *yaml.Node, path, key string, inList bool) error) error {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := walkConfigScalarPathsIn(child, path, key, false, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childKey := node.Content[i].Value
+			if err := walkConfigScalarPathsIn(node.Content[i+1], joinConfigPath(path, configPathEscaper.Replace(childKey)), childKey, false, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := walkConfigScalarPathsIn(child, joinConfigPath(path, strconv.Itoa(i)), key, true, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(node, path, key, inList)
+	}
+	return nil
+}
+
+var configPathEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`)
+
+func joinConfigPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+// resolveConfigReferences replaces secret references with the secrets they point to.
+// It returns the rewritten document and the references keyed by config path, so a
+// later save can write the references back instead of the secrets.
+func resolveConfigReferences(data []byte) ([]byte, map[string]SecretReference, error) {
+	found := false
+	for _, scheme := range secretref.Schemes() {
+		if bytes.Contains(data, []byte(scheme+":")) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return data, nil, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), secretRefTimeout)
+	defer cancel()
+	refs := make(map[string]SecretReference)
+	err := walkConfigScalarPaths(&root, "", func(n *yaml.Node, path, key string, inList bool) error {
+		if _, ok := secretRefKeys[key]; !ok || inList {
+			return nil
+		}
+		secret, resolved, errResolve := secretref.Resolve(ctx, n.Value)
+		if errResolve != nil {
+			return fmt.Errorf("config key %s: %w", key, errResolve)
+		}
+		if resolved {
+			refs[path] = SecretReference{Reference: n.Value, Value: secret}
+			n.Value = secret
+			n.Tag = "!!str"
+			n.Style = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(refs) == 0 {
+		return data, nil, nil
+	}
+	out, err := yaml.Marshal(&root)
+	return out, refs, err
+}
+
+// restoreConfigReferences writes secret references back in place of the values
+// they resolved to, so secrets loaded from references never reach the file. A value
+// that moved to another path, e.g. after an entry was removed, is matched by value.
+func restoreConfigReferences(root *yaml.Node, refs map[string]SecretReference) {
+	if len(refs) == 0 {
+		return
+	}
+	byValue := SecretReferencesByValue(refs)
+	_ = walkConfigScalarPaths(root, "", func(n *yaml.Node, path, key string, inList bool) error {
+		if _, ok := secretRefKeys[key]; !ok || inList {
+			return nil
+		}
+		if ref, ok := refs[path]; ok && ref.Value == n.Value {
+			n.Value = ref.Reference
+		} else if reference, ok := byValue[n.Value]; ok {
+			n.Value = reference
+		}
+		return nil
+	})
+}
+
+// SecretReferencesByValue maps values loaded from secret references to a reference
+// that produced them, for callers that only know the value. When several references
+// resolve to the same secret, the one with the lowest path is used.
+func SecretReferencesByValue(refs map[string]SecretReference) map[string]string {
+	paths := make([]string, 0, len(refs))
+	for path := range refs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	byValue := make(map[string]string, len(refs))
+	for _, path := range paths {
+		if _, taken := byValue[refs[path].Value]; !taken {
+			byValue[refs[path].Value] = refs[path].Reference
+		}
+	}
+	return byValue
+}
+
+// EncryptConfigSecrets encrypts plaintext secret values in the config file with the
+// default keyring, preserving comments. It reports whether the file changed.
+func EncryptConfigSecrets(configFile string) (bool, error) {
+	if !encryption.Enabled() {
+		return false, encryption.ErrNoKey
+	}
+	return rewriteConfigSecrets(configFile, func(root *yaml.Node) (int, error) {
+		return sealConfigSecrets(root, nil)
+	})
+}
+
+// DecryptConfigSecrets rewrites every encrypted value in the config file as plaintext.
+func DecryptConfigSecrets(configFile string) (bool, error) {
+	return rewriteConfigSecrets(configFile, func(root *yaml.Node) (int, error) {
+		ciphertexts, err := openConfigSecrets(root)
+		return len(ciphertexts), err
+	})
+}
+
+// RotateConfigSecrets re-wraps encrypted values under the primary key and encrypts
+// any remaining plaintext secrets.
+func RotateConfigSecrets(configFile string) (bool, error) {
+	if !encryption.Enabled() {
+		return false, encryption.ErrNoKey
+	}
+	return rewriteConfigSecrets(configFile, func(root *yaml.Node) (int, error) {
+		rewrapped := 0
+		err := walkConfigScalars(root, "", false, func(n *yaml.Node, _ string, _ bool) error {
+			value, changed, errRewrap := encryption.RewrapString(n.Value)
+			if errRewrap != nil {
+				return errRewrap
+			}
+			if changed {
+				n.Value = value
+				rewrapped++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		sealed, err := sealConfigSecrets(root, nil)
+		return rewrapped + sealed, err
+	})
+}
+
+// SealConfigData encrypts plaintext secret values in a config document with the
+// default keyring. Without a keyring, or when nothing needs sealing, data is returned as is.
+func SealConfigData(data []byte) ([]byte, error) {
+	if !encryption.Enabled() {
+		return data, nil
+	}
+	out, _, err := transformConfigData(data, func(root *yaml.Node) (int, error) {
+		return sealConfigSecrets(root, nil)
+	})
+	return out, err
+}
+
+// rewriteConfigSecrets applies transform to the config file and writes it back,
+// preserving comments, when transform reports changed values.
+func rewriteConfigSecrets(configFile string, transform func(root *yaml.Node) (int, error)) (bool, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return false, err
+	}
+	out, changed, err := transformConfigData(data, transform)
+	if err != nil || !changed {
+		return false, err
+	}
+	if err = WriteConfigFile(configFile, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func transformConfigData(data []byte, transform func(root *yaml.Node) (int, error)) ([]byte, bool, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return data, false, nil
+	}
+	changed, err := transform(&root)
+	if err != nil || changed == 0 {
+		return data, false, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&root); err != nil {
+		_ = enc.Close()
+		return nil, false, err
+	}
+	if err = enc.Close(); err != nil {
+		return nil, false, err
+	}
+	return NormalizeCommentIndentation(buf.Bytes()), true, nil
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
+)
+
+func TestConfigSecretsEncryptedAtRest(t *testing.T) {
+	encoded, _ := encryption.GenerateKey()
+	raw, _ := encryption.ParseKey(encoded)
+	keyring, err := encryption.NewKeyring(raw)
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	encryption.SetDefault(keyring)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	seed := "# server port\nport: 8317\napi-keys:\n  - api-key: sk-client\ncodex-api-key:\n  - api-key: sk-upstream\n    base-url: https://example.com\n"
+	if err = os.WriteFile(path, []byte(seed), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	changed, err := EncryptConfigSecrets(path)
+	if err != nil || !changed {
+		t.Fatalf("encrypt: changed=%v err=%v", changed, err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-client") || strings.Contains(string(data), "sk-upstream") {
+		t.Fatalf("plaintext secret left in config:\n%s", data)
+	}
+	if !strings.Contains(string(data), "# server port") || !strings.Contains(string(data), "https://example.com") {
+		t.Fatalf("non-secret content lost:\n%s", data)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(cfg.APIKeys) != 1 || cfg.APIKeys[0].Key != "sk-client" || cfg.CodexKey[0].APIKey != "sk-upstream" {
+		t.Fatalf("secrets not decrypted: %+v %+v", cfg.APIKeys, cfg.CodexKey)
+	}
+
+	// Saving unchanged secrets keeps their ciphertext stable.
+	cfg.Debug = true
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	for _, field := range strings.Fields(string(data)) {
+		if strings.HasPrefix(field, encryption.StringPrefix) && !strings.Contains(string(saved), field) {
+			t.Fatalf("ciphertext changed on save: %s\n%s", field, saved)
+		}
+	}
+
+	if changed, err = DecryptConfigSecrets(path); err != nil || !changed {
+		t.Fatalf("decrypt: changed=%v err=%v", changed, err)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "sk-client") || strings.Contains(string(data), encryption.StringPrefix) {
+		t.Fatalf("config not decrypted:\n%s", data)
+	}
+}
+
+func TestConfigSecretReferencesResolvedAndPreserved(t *testing.T) {
+	t.Setenv("CONFIG_TEST_CLAUDE_KEY", "sk-ant-from-env")
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "mgmt-secret")
+	if err := os.WriteFile(secretPath, []byte("management-password\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	seed := "port: 8317\nremote-management:\n  secret-key: file:" + secretPath + "\nclaude-api-key:\n  - api-key: env:CONFIG_TEST_CLAUDE_KEY\n"
+	if err := os.WriteFile(path, []byte(seed), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.ClaudeKey[0].APIKey != "sk-ant-from-env" {
+		t.Fatalf("claude key = %q", cfg.ClaudeKey[0].APIKey)
+	}
+	if !looksLikeBcrypt(cfg.RemoteManagement.SecretKey) {
+		t.Fatalf("management secret not hashed: %q", cfg.RemoteManagement.SecretKey)
+	}
+	if data, _ := os.ReadFile(path); string(data) != seed {
+		t.Fatalf("loading rewrote a referenced secret:\n%s", data)
+	}
+
+	cfg.Debug = true
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if strings.Contains(string(saved), "sk-ant-from-env") || strings.Contains(string(saved), "$2a$") {
+		t.Fatalf("resolved secret written back:\n%s", saved)
+	}
+	if !strings.Contains(string(saved), "env:CONFIG_TEST_CLAUDE_KEY") || !strings.Contains(string(saved), "file:"+secretPath) {
+		t.Fatalf("references lost on save:\n%s", saved)
+	}
+
+	t.Setenv("CONFIG_TEST_CLAUDE_KEY", "")
+	if err = os.Unsetenv("CONFIG_TEST_CLAUDE_KEY"); err != nil {
+		t.Fatalf("unsetenv: %v", err)
+	}
+	if _, err = LoadConfig(path); err == nil {
+		t.Fatalf("expected load error for unresolvable reference")
+	}
+}
+
+func TestConfigSecretReferencesKeyedByPath(t *testing.T) {
+	t.Setenv("CONFIG_TEST_SHARED_A", "sk-shared")
+	t.Setenv("CONFIG_TEST_SHARED_B", "sk-shared")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	seed := "claude-api-key:\n  - api-key: env:CONFIG_TEST_SHARED_A\n  - api-key: env:CONFIG_TEST_SHARED_B\n"
+	if err := os.WriteFile(path, []byte(seed), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	refs := cfg.SecretReferences()
+	if refs["claude-api-key.0.api-key"].Reference != "env:CONFIG_TEST_SHARED_A" || refs["claude-api-key.1.api-key"].Reference != "env:CONFIG_TEST_SHARED_B" {
+		t.Fatalf("refs = %+v", refs)
+	}
+
+	cfg.Debug = true
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if strings.Contains(string(saved), "sk-shared") || !strings.Contains(string(saved), "env:CONFIG_TEST_SHARED_A") || !strings.Contains(string(saved), "env:CONFIG_TEST_SHARED_B") {
+		t.Fatalf("references not preserved per entry:\n%s", saved)
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import "strings"
+
+// CredentialBundleConfig configures exporting and importing credential bundles.
+type CredentialBundleConfig struct {
+	// SigningKey is a base64 Ed25519 private key seed used to sign exported bundles.
+	// Its public key is always trusted on import.
+	//
+	// Once a signing key or any trusted key is configured, imports only accept bundles
+	// signed by one of those keys. Unsigned or foreign-signed bundles are accepted only
+	// when no key is configured at all.
+	SigningKey string `yaml:"signing-key,omitempty" json:"signing-key,omitempty"`
+
+	// TrustedKeys are base64 Ed25519 public keys, besides the signing key's own, whose
+	// signed bundles may be imported.
+	TrustedKeys []string `yaml:"trusted-keys,omitempty" json:"trusted-keys,omitempty"`
+
+	// RequireSignature rejects unsigned bundles on import even when no key is configured.
+	RequireSignature bool `yaml:"require-signature,omitempty" json:"require-signature,omitempty"`
+
+	// Passphrase is the default passphrase for encrypting exports and decrypting imports.
+	Passphrase string `yaml:"passphrase,omitempty" json:"passphrase,omitempty"`
+}
+
+// SanitizeCredentialBundles trims credential bundle settings and drops empty trusted keys.
+func (cfg *Config) SanitizeCredentialBundles() {
+	if cfg == nil {
+		return
+	}
+	b := &cfg.CredentialBundles
+	b.SigningKey = strings.TrimSpace(b.SigningKey)
+	keys := make([]string, 0, len(b.TrustedKeys))
+	for _, key := range b.TrustedKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	b.TrustedKeys = keys
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Local backend types.
+const (
+	// LocalBackendOllama talks to Ollama's native /api/chat endpoint and discovers models via /api/tags.
+	LocalBackendOllama = "ollama"
+	// LocalBackendOpenAI talks to an OpenAI-compatible server (llama.cpp, vLLM, LM Studio)
+	// and discovers models via /models.
+	LocalBackendOpenAI = "openai"
+
+	// DefaultOllamaBaseURL is the address Ollama listens on by default.
+	DefaultOllamaBaseURL = "http://127.0.0.1:11434"
+	// DefaultLocalDiscoveryInterval is how often local backends are polled for models.
+	DefaultLocalDiscoveryInterval = time.Minute
+)
+
+// LocalProvider describes a self-hosted model server. Its models are discovered
+// periodically and registered alongside any statically configured models.
+type LocalProvider struct {
+	// Name identifies the backend in logs and model listings.
+	Name string `yaml:"name" json:"name"`
+
+	// Type selects the wire protocol: "ollama" (default) or "openai".
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// BaseURL is the server address. Ollama uses the bare host (e.g. "http://127.0.0.1:11434");
+	// OpenAI-compatible servers include the API root (e.g. "http://127.0.0.1:8080/v1").
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// APIKey is sent as a bearer token when the server requires one.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// DiscoveryInterval controls model polling, e.g. "30s". "0" disables discovery so only
+	// Models are served. Defaults to one minute.
+	DiscoveryInterval string `yaml:"discovery-interval,omitempty" json:"discovery-interval,omitempty"`
+
+	// Disabled indicates whether this backend is intentionally disabled.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this backend (e.g., "local/llama3.1:8b").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Tenant assigns the backend to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this backend if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this backend.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models are always registered, in addition to discovered models, and may alias them.
+	Models []LocalModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs (wildcards allowed) that should not be registered.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// DiscoveryEvery returns the parsed discovery interval; zero means discovery is disabled.
+func (p LocalProvider) DiscoveryEvery() time.Duration {
+	raw := strings.TrimSpace(p.DiscoveryInterval)
+	if raw == "" {
+		return DefaultLocalDiscoveryInterval
+	}
+	if raw == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return DefaultLocalDiscoveryInterval
+	}
+	return d
+}
+
+// LocalModel maps a client-facing model name to a model served by the backend.
+type LocalModel struct {
+	// Name is the model name on the backend (e.g. "llama3.1:8b").
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name; defaults to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+}
+
+func (m LocalModel) GetName() string  { return m.Name }
+func (m LocalModel) GetAlias() string { return m.Alias }
+
+// SanitizeLocalProviders normalizes local backends, fills defaults and drops unnamed or
+// duplicate entries.
+func (cfg *Config) SanitizeLocalProviders() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.LocalProviders))
+	out := cfg.LocalProviders[:0]
+	for i := range cfg.LocalProviders {
+		entry := cfg.LocalProviders[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" {
+			continue
+		}
+		key := strings.ToLower(entry.Name)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		entry.Type = strings.ToLower(strings.TrimSpace(entry.Type))
+		if entry.Type != LocalBackendOpenAI {
+			entry.Type = LocalBackendOllama
+		}
+		entry.BaseURL = strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			if entry.Type != LocalBackendOllama {
+				continue
+			}
+			entry.BaseURL = DefaultOllamaBaseURL
+		}
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.DiscoveryInterval = strings.TrimSpace(entry.DiscoveryInterval)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Tenant = strings.TrimSpace(entry.Tenant)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]LocalModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name == "" {
+				continue
+			}
+			if model.Alias == "" {
+				model.Alias = model.Name
+			}
+			models = append(models, model)
+		}
+		entry.Models = models
+		if len(models) == 0 && entry.DiscoveryEvery() == 0 {
+			// Nothing would ever be served.
+			continue
+		}
+
+		seen[key] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.LocalProviders = out
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Management account roles, from least to most privileged.
+const (
+	// ManagementRoleViewer may read usage, logs and non-secret settings.
+	ManagementRoleViewer = "viewer"
+	// ManagementRoleKeyManager may additionally manage client API keys.
+	ManagementRoleKeyManager = "key-manager"
+	// ManagementRoleCredentialManager may additionally manage auth files, provider keys and OAuth logins.
+	ManagementRoleCredentialManager = "credential-manager"
+	// ManagementRoleAdmin has unrestricted access, equivalent to the remote management secret key.
+	ManagementRoleAdmin = "admin"
+)
+
+// ManagementAccount is a named management user authenticated by its own token.
+type ManagementAccount struct {
+	// ID is the stable identifier embedded in the account token.
+	ID string `yaml:"id" json:"id"`
+	// Name is a human-readable label for the account.
+	Name string `yaml:"name" json:"name"`
+	// Role selects the route groups the account may access.
+	Role string `yaml:"role" json:"role"`
+	// TokenHash is the bcrypt hash of the account token; the token itself is never stored.
+	TokenHash string `yaml:"token-hash" json:"-"`
+	// CreatedAt records when the account was created.
+	CreatedAt time.Time `yaml:"created-at,omitempty" json:"created-at,omitempty"`
+	// Disabled revokes the account without deleting it.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// Tenant scopes the account to one tenant's client keys, auth files and usage records.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+}
+
+// NormalizeManagementRole lower-cases role and reports whether it is a known role.
+func NormalizeManagementRole(role string) (string, bool) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch role {
+	case ManagementRoleViewer, ManagementRoleKeyManager, ManagementRoleCredentialManager, ManagementRoleAdmin:
+		return role, true
+	default:
+		return role, false
+	}
+}
+
+// NormalizeManagementAccounts trims account fields and drops entries that cannot authenticate:
+// missing or duplicate IDs, unknown roles and token hashes that are not bcrypt.
+func NormalizeManagementAccounts(accounts []ManagementAccount) []ManagementAccount {
+	if len(accounts) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(accounts))
+	out := make([]ManagementAccount, 0, len(accounts))
+	for _, account := range accounts {
+		account.ID = strings.TrimSpace(account.ID)
+		account.Name = strings.TrimSpace(account.Name)
+		account.TokenHash = strings.TrimSpace(account.TokenHash)
+		role, ok := NormalizeManagementRole(account.Role)
+		if !ok || account.ID == "" || !looksLikeBcrypt(account.TokenHash) {
+			continue
+		}
+		if _, dup := seen[account.ID]; dup {
+			continue
+		}
+		seen[account.ID] = struct{}{}
+		account.Role = role
+		out = append(out, account)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// HasCredentials reports whether any management credential is configured:
+// the secret key or at least one enabled account.
+func (r RemoteManagement) HasCredentials() bool {
+	if r.SecretKey != "" {
+		return true
+	}
+	for _, account := range r.Accounts {
+		if !account.Disabled {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
+)
+
+// DefaultModelDiscoveryInterval is how often OpenAI-compatible providers are polled for
+// models when discovery is enabled without an explicit interval.
+const DefaultModelDiscoveryInterval = 10 * time.Minute
+
+// ModelDiscovery opts a provider into listing its models from the upstream /models
+// endpoint with each configured key. Discovered models are filtered by Include and
+// Exclude and registered next to the explicitly configured models.
+type ModelDiscovery struct {
+	// Enabled turns discovery on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Interval is how often the model list is refreshed (Go duration, default "10m").
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// Include keeps only models matching one of these patterns ('*' wildcard).
+	// Empty keeps every model.
+	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
+
+	// Exclude drops models matching any of these patterns ('*' wildcard).
+	Exclude []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+}
+
+// DiscoveryEvery returns the refresh interval; zero means discovery is disabled.
+func (d *ModelDiscovery) DiscoveryEvery() time.Duration {
+	if d == nil || !d.Enabled {
+		return 0
+	}
+	raw := strings.TrimSpace(d.Interval)
+	if raw == "" {
+		return DefaultModelDiscoveryInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		return DefaultModelDiscoveryInterval
+	}
+	return interval
+}
+
+// Allows reports whether a discovered model passes the include and exclude filters.
+// Matching is case-insensitive.
+func (d *ModelDiscovery) Allows(model string) bool {
+	if d == nil {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return false
+	}
+	for _, pattern := range d.Exclude {
+		if wildcard.Match(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return false
+		}
+	}
+	if len(d.Include) == 0 {
+		return true
+	}
+	for _, pattern := range d.Include {
+		if wildcard.Match(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitize trims the filters and drops empty patterns.
+func (d *ModelDiscovery) sanitize() {
+	if d == nil {
+		return
+	}
+	d.Interval = strings.TrimSpace(d.Interval)
+	d.Include = NormalizeExcludedModels(d.Include)
+	d.Exclude = NormalizeExcludedModels(d.Exclude)
+}
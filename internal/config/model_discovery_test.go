@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModelDiscoveryAllows(t *testing.T) {
+	discovery := &ModelDiscovery{
+		Enabled: true,
+		Include: []string{"gpt-*", "*-coder*"},
+		Exclude: []string{"*-preview", "*audio*"},
+	}
+	cases := map[string]bool{
+		"gpt-4.1":                true,
+		"GPT-4o":                 true,
+		"qwen3-coder-plus":       true,
+		"gpt-4o-audio":           false,
+		"gpt-5-preview":          false,
+		"claude-sonnet-4":        false,
+		"":                       false,
+		"text-embedding-3-large": false,
+	}
+	for model, want := range cases {
+		if got := discovery.Allows(model); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", model, got, want)
+		}
+	}
+	if !(&ModelDiscovery{Enabled: true}).Allows("anything") {
+		t.Fatal("empty include list should allow every model")
+	}
+}
+
+func TestModelDiscoveryInterval(t *testing.T) {
+	var disabled *ModelDiscovery
+	if disabled.DiscoveryEvery() != 0 || (&ModelDiscovery{Interval: "1m"}).DiscoveryEvery() != 0 {
+		t.Fatal("disabled discovery should have no interval")
+	}
+	if got := (&ModelDiscovery{Enabled: true}).DiscoveryEvery(); got != DefaultModelDiscoveryInterval {
+		t.Fatalf("default interval = %v", got)
+	}
+	if got := (&ModelDiscovery{Enabled: true, Interval: "90s"}).DiscoveryEvery(); got != 90*time.Second {
+		t.Fatalf("interval = %v", got)
+	}
+}
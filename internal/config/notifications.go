@@ -0,0 +1,192 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultNotificationDedupWindow suppresses repeats of the same event for a target.
+	DefaultNotificationDedupWindow = "10m"
+	// DefaultNotificationMaxRetries bounds delivery attempts after the first failure.
+	DefaultNotificationMaxRetries = 3
+)
+
+// NotificationsConfig routes operational events (expired tokens, exhausted quotas,
+// disabled credentials, model changes, budget thresholds) to webhook targets.
+type NotificationsConfig struct {
+	// Targets receive events; an empty list disables notifications.
+	Targets []NotificationTarget `yaml:"targets,omitempty" json:"targets,omitempty"`
+
+	// Budgets raise budget.threshold events when usage crosses a percentage of a limit.
+	Budgets []NotificationBudget `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+}
+
+// NotificationTarget is one webhook receiving events.
+type NotificationTarget struct {
+	// Name identifies the target in logs.
+	Name string `yaml:"name" json:"name"`
+
+	// Format is the body shape: "json" (default), "slack" or "telegram".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// URL is the webhook endpoint. Telegram targets may leave it empty and set BotToken.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// BotToken and ChatID address the Telegram sendMessage API.
+	BotToken string `yaml:"bot-token,omitempty" json:"bot-token,omitempty"`
+	ChatID   string `yaml:"chat-id,omitempty" json:"chat-id,omitempty"`
+
+	// Headers are added to every delivery, e.g. an Authorization header.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Events lists the event types delivered to this target; wildcards such as "auth.*"
+	// are allowed. Empty delivers every event.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Template is a Go text/template rendering one event's message text. Empty uses
+	// the built-in summary.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// BatchWindow collects events for this long and delivers them together, e.g. "30s".
+	// Empty delivers each event as soon as it happens.
+	BatchWindow string `yaml:"batch-window,omitempty" json:"batch-window,omitempty"`
+
+	// DedupWindow drops repeats of the same event within this window (default "10m").
+	// Set to "0" to deliver every repeat.
+	DedupWindow string `yaml:"dedup-window,omitempty" json:"dedup-window,omitempty"`
+
+	// MaxRetries bounds retries with exponential backoff after a failed delivery (default 3).
+	MaxRetries *int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+
+	// Disabled keeps the target configured without delivering to it.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// NotificationBudget declares a usage limit per period. Usage is counted in memory from
+// the records of matching requests and resets when the period rolls over.
+type NotificationBudget struct {
+	// Name identifies the budget in events.
+	Name string `yaml:"name" json:"name"`
+
+	// Provider, Model and AuthID narrow which requests count; wildcards are allowed in
+	// Model. Empty matches everything.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty" json:"model,omitempty"`
+	AuthID   string `yaml:"auth-id,omitempty" json:"auth-id,omitempty"`
+
+	// Period is "hour", "day" (default) or "month", in local time.
+	Period string `yaml:"period,omitempty" json:"period,omitempty"`
+
+	// MaxTokens and MaxRequests are the limits; at least one must be set.
+	MaxTokens   int64 `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+	MaxRequests int64 `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+
+	// Thresholds are the percentages of a limit that raise an event (default 80 and 100).
+	Thresholds []int `yaml:"thresholds,omitempty" json:"thresholds,omitempty"`
+}
+
+// Retries returns the configured retry count, falling back to the default.
+func (t NotificationTarget) Retries() int {
+	if t.MaxRetries == nil {
+		return DefaultNotificationMaxRetries
+	}
+	return *t.MaxRetries
+}
+
+// SanitizeNotifications normalizes notification targets and budgets and drops entries
+// that cannot be delivered or evaluated.
+func (cfg *Config) SanitizeNotifications() {
+	if cfg == nil {
+		return
+	}
+	n := &cfg.Notifications
+	targets := make([]NotificationTarget, 0, len(n.Targets))
+	for i := range n.Targets {
+		t := n.Targets[i]
+		t.Name = strings.TrimSpace(t.Name)
+		t.Format = strings.ToLower(strings.TrimSpace(t.Format))
+		if t.Format == "" {
+			t.Format = "json"
+		}
+		t.URL = strings.TrimSpace(t.URL)
+		t.BotToken = strings.TrimSpace(t.BotToken)
+		t.ChatID = strings.TrimSpace(t.ChatID)
+		t.BatchWindow = strings.TrimSpace(t.BatchWindow)
+		t.DedupWindow = strings.TrimSpace(t.DedupWindow)
+		if t.DedupWindow == "" {
+			t.DedupWindow = DefaultNotificationDedupWindow
+		}
+		if t.MaxRetries != nil && *t.MaxRetries < 0 {
+			zero := 0
+			t.MaxRetries = &zero
+		}
+		events := make([]string, 0, len(t.Events))
+		for _, event := range t.Events {
+			if event = strings.ToLower(strings.TrimSpace(event)); event != "" {
+				events = append(events, event)
+			}
+		}
+		t.Events = events
+		if t.Name == "" {
+			t.Name = t.Format
+		}
+		switch t.Format {
+		case "json", "slack":
+			if t.URL == "" {
+				log.Warnf("notifications.targets[%d] %s: url is required, skipping", i, t.Name)
+				continue
+			}
+		case "telegram":
+			if t.ChatID == "" || (t.URL == "" && t.BotToken == "") {
+				log.Warnf("notifications.targets[%d] %s: chat-id and bot-token or url are required, skipping", i, t.Name)
+				continue
+			}
+		default:
+			log.Warnf("notifications.targets[%d] %s: unsupported format %q, skipping", i, t.Name, t.Format)
+			continue
+		}
+		targets = append(targets, t)
+	}
+	n.Targets = targets
+
+	budgets := make([]NotificationBudget, 0, len(n.Budgets))
+	for i := range n.Budgets {
+		b := n.Budgets[i]
+		b.Name = strings.TrimSpace(b.Name)
+		b.Provider = strings.ToLower(strings.TrimSpace(b.Provider))
+		b.Model = strings.TrimSpace(b.Model)
+		b.AuthID = strings.TrimSpace(b.AuthID)
+		b.Period = strings.ToLower(strings.TrimSpace(b.Period))
+		if b.Period == "" {
+			b.Period = "day"
+		}
+		if b.Name == "" {
+			log.Warnf("notifications.budgets[%d]: name is required, skipping", i)
+			continue
+		}
+		switch b.Period {
+		case "hour", "day", "month":
+		default:
+			log.Warnf("notifications.budgets[%d] %s: unsupported period %q, skipping", i, b.Name, b.Period)
+			continue
+		}
+		if b.MaxTokens <= 0 && b.MaxRequests <= 0 {
+			log.Warnf("notifications.budgets[%d] %s: max-tokens or max-requests is required, skipping", i, b.Name)
+			continue
+		}
+		thresholds := make([]int, 0, len(b.Thresholds))
+		for _, pct := range b.Thresholds {
+			if pct > 0 {
+				thresholds = append(thresholds, pct)
+			}
+		}
+		if len(thresholds) == 0 {
+			thresholds = []int{80, 100}
+		}
+		b.Thresholds = thresholds
+		budgets = append(budgets, b)
+	}
+	n.Budgets = budgets
+}
@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestSanitizeNotifications(t *testing.T) {
+	negative := -2
+	cfg := &Config{Notifications: NotificationsConfig{
+		Targets: []NotificationTarget{
+			{Name: " ops ", Format: "Slack", URL: " https://hooks.example.com/x ", Events: []string{" Auth.* ", ""}},
+			{Format: "json"},
+			{Format: "telegram", BotToken: "123:abc"},
+			{Format: "telegram", BotToken: "123:abc", ChatID: "42", MaxRetries: &negative},
+			{Format: "teams", URL: "https://example.com"},
+		},
+		Budgets: []NotificationBudget{
+			{Name: "daily", MaxTokens: 1000},
+			{Name: "weekly", Period: "week", MaxTokens: 1000},
+			{Name: "nolimit"},
+			{MaxRequests: 5},
+			{Name: "hourly", Period: "Hour", MaxRequests: 5, Thresholds: []int{0, 90}},
+		},
+	}}
+	cfg.SanitizeNotifications()
+
+	targets := cfg.Notifications.Targets
+	if len(targets) != 2 {
+		t.Fatalf("targets = %+v", targets)
+	}
+	ops := targets[0]
+	if ops.Name != "ops" || ops.Format != "slack" || ops.URL != "https://hooks.example.com/x" || len(ops.Events) != 1 || ops.Events[0] != "auth.*" {
+		t.Fatalf("ops = %+v", ops)
+	}
+	if ops.DedupWindow != DefaultNotificationDedupWindow || ops.Retries() != DefaultNotificationMaxRetries {
+		t.Fatalf("ops defaults = %q %d", ops.DedupWindow, ops.Retries())
+	}
+	if tg := targets[1]; tg.Name != "telegram" || tg.Retries() != 0 {
+		t.Fatalf("telegram = %+v", tg)
+	}
+
+	budgets := cfg.Notifications.Budgets
+	if len(budgets) != 2 {
+		t.Fatalf("budgets = %+v", budgets)
+	}
+	if b := budgets[0]; b.Period != "day" || len(b.Thresholds) != 2 || b.Thresholds[0] != 80 || b.Thresholds[1] != 100 {
+		t.Fatalf("daily = %+v", b)
+	}
+	if b := budgets[1]; b.Period != "hour" || len(b.Thresholds) != 1 || b.Thresholds[0] != 90 {
+		t.Fatalf("hourly = %+v", b)
+	}
+}
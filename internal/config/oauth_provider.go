@@ -0,0 +1,247 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// OAuthFlowAuthorizationCode is the browser redirect flow (RFC 6749 section 4.1).
+	OAuthFlowAuthorizationCode = "authorization-code"
+	// OAuthFlowDeviceCode is the device authorization grant (RFC 8628).
+	OAuthFlowDeviceCode = "device-code"
+	// OAuthFlowClientCredentials is the non-interactive client credentials grant.
+	OAuthFlowClientCredentials = "client-credentials"
+
+	// DefaultOAuthRefreshLead is how long before expiry declarative OAuth tokens are refreshed.
+	DefaultOAuthRefreshLead = 5 * time.Minute
+	// DefaultOAuthCallbackPort is the local port that receives authorization-code redirects.
+	DefaultOAuthCallbackPort = 8765
+)
+
+// reservedProviderNames lists provider keys served by built-in executors; declarative
+// providers and plugins may not shadow them.
+var reservedProviderNames = map[string]struct{}{
+	"gemini": {}, "gemini-cli": {}, "vertex": {}, "aistudio": {}, "antigravity": {},
+	"claude": {}, "anthropic": {}, "codex": {}, "openai": {}, "qwen": {}, "iflow": {},
+	"kimi": {}, "bedrock": {}, "azure-openai": {}, "local": {}, "openai-compatibility": {},
+}
+
+// OAuthProvider declares an OAuth2-protected upstream that needs no provider-specific code.
+// Tokens are obtained through the configured flow, stored as auth files of type Name and
+// refreshed RefreshLead before expiry; requests are translated to Format and sent to BaseURL.
+type OAuthProvider struct {
+	// Name is the provider key used for auth files, routing and the login flow.
+	Name string `yaml:"name" json:"name"`
+
+	// Flow selects the grant: "authorization-code" (default), "device-code" or "client-credentials".
+	Flow string `yaml:"flow,omitempty" json:"flow,omitempty"`
+
+	// ClientID and ClientSecret identify the OAuth client. The secret is optional for public clients.
+	ClientID     string `yaml:"client-id" json:"client-id"`
+	ClientSecret string `yaml:"client-secret,omitempty" json:"client-secret,omitempty"`
+
+	// AuthorizeURL is the authorization endpoint for the authorization-code flow.
+	AuthorizeURL string `yaml:"authorize-url,omitempty" json:"authorize-url,omitempty"`
+
+	// TokenURL is the token endpoint used by every flow and by refresh.
+	TokenURL string `yaml:"token-url" json:"token-url"`
+
+	// DeviceAuthorizationURL is the device authorization endpoint for the device-code flow.
+	DeviceAuthorizationURL string `yaml:"device-authorization-url,omitempty" json:"device-authorization-url,omitempty"`
+
+	// RedirectURL overrides the redirect URI sent in the authorization-code flow.
+	// Defaults to http://localhost:{callback-port}/oauth2/{name}/callback.
+	RedirectURL string `yaml:"redirect-url,omitempty" json:"redirect-url,omitempty"`
+
+	// CallbackPort is the local port listening for authorization-code redirects (default 8765).
+	CallbackPort int `yaml:"callback-port,omitempty" json:"callback-port,omitempty"`
+
+	// Scopes are requested during authorization.
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+
+	// PKCE enables S256 PKCE for the authorization-code flow.
+	PKCE bool `yaml:"pkce,omitempty" json:"pkce,omitempty"`
+
+	// AuthParams are extra query parameters added to the authorization URL (e.g. audience).
+	AuthParams map[string]string `yaml:"auth-params,omitempty" json:"auth-params,omitempty"`
+
+	// RefreshLead is how long before expiry tokens are refreshed (Go duration, default "5m").
+	RefreshLead string `yaml:"refresh-lead,omitempty" json:"refresh-lead,omitempty"`
+
+	// BaseURL is the API root requests are sent to (e.g. "https://api.example.com/v1").
+	BaseURL string `yaml:"base-url" json:"base-url"`
+
+	// Format is the upstream wire format: "openai" (default), "claude" or "gemini".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// Headers are added to upstream requests. Values may reference {access_token},
+	// {token_type} and {client_id}; the default is "Authorization: Bearer {access_token}".
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match. It is written
+	// into auth files created by login.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces model aliases; like Priority it is written into new auth files.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy for token and API requests.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models lists the models served by the provider, with optional aliases.
+	Models []OAuthProviderModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists models that should not be exposed.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// OAuthProviderModel maps a client-facing model name to an upstream model.
+type OAuthProviderModel struct {
+	// Name is the upstream model name.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name; defaults to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+}
+
+func (m OAuthProviderModel) GetName() string  { return m.Name }
+func (m OAuthProviderModel) GetAlias() string { return m.Alias }
+
+// RefreshLeadDuration returns the parsed refresh lead, falling back to the default.
+func (p OAuthProvider) RefreshLeadDuration() time.Duration {
+	if raw := strings.TrimSpace(p.RefreshLead); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultOAuthRefreshLead
+}
+
+// Callback returns the local callback port and redirect URI for the authorization-code flow.
+func (p OAuthProvider) Callback() (int, string) {
+	port := p.CallbackPort
+	if port <= 0 {
+		port = DefaultOAuthCallbackPort
+	}
+	if p.RedirectURL != "" {
+		return port, p.RedirectURL
+	}
+	return port, "http://localhost:" + strconv.Itoa(port) + "/oauth2/" + p.Name + "/callback"
+}
+
+// FindOAuthProvider returns the declarative OAuth provider named name, or nil.
+func (cfg *Config) FindOAuthProvider(name string) *OAuthProvider {
+	if cfg == nil {
+		return nil
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	for i := range cfg.OAuthProviders {
+		if cfg.OAuthProviders[i].Name == name {
+			return &cfg.OAuthProviders[i]
+		}
+	}
+	return nil
+}
+
+// SanitizeOAuthProviders normalizes declarative OAuth providers and drops entries that are
+// incomplete, duplicated or shadow a built-in provider.
+func (cfg *Config) SanitizeOAuthProviders() {
+	if cfg == nil || len(cfg.OAuthProviders) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.OAuthProviders))
+	out := make([]OAuthProvider, 0, len(cfg.OAuthProviders))
+	for i := range cfg.OAuthProviders {
+		p := cfg.OAuthProviders[i]
+		p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+		p.Flow = strings.ToLower(strings.TrimSpace(p.Flow))
+		if p.Flow == "" {
+			p.Flow = OAuthFlowAuthorizationCode
+		}
+		p.ClientID = strings.TrimSpace(p.ClientID)
+		p.ClientSecret = strings.TrimSpace(p.ClientSecret)
+		p.AuthorizeURL = strings.TrimSpace(p.AuthorizeURL)
+		p.TokenURL = strings.TrimSpace(p.TokenURL)
+		p.DeviceAuthorizationURL = strings.TrimSpace(p.DeviceAuthorizationURL)
+		p.RedirectURL = strings.TrimSpace(p.RedirectURL)
+		p.RefreshLead = strings.TrimSpace(p.RefreshLead)
+		p.BaseURL = strings.TrimRight(strings.TrimSpace(p.BaseURL), "/")
+		p.Format = strings.ToLower(strings.TrimSpace(p.Format))
+		if p.Format == "" {
+			p.Format = "openai"
+		}
+		p.Prefix = normalizeModelPrefix(p.Prefix)
+		p.ProxyURL = strings.TrimSpace(p.ProxyURL)
+		p.ExcludedModels = NormalizeExcludedModels(p.ExcludedModels)
+
+		if p.Name == "" || strings.ContainsAny(p.Name, "/\\ ") {
+			log.Warnf("oauth-providers[%d]: invalid name %q, skipping", i, p.Name)
+			continue
+		}
+		if _, reserved := reservedProviderNames[p.Name]; reserved {
+			log.Warnf("oauth-providers[%d]: name %q is reserved for a built-in provider, skipping", i, p.Name)
+			continue
+		}
+		if _, dup := seen[p.Name]; dup {
+			log.Warnf("oauth-providers[%d]: duplicate name %q, skipping", i, p.Name)
+			continue
+		}
+		if p.ClientID == "" || p.TokenURL == "" || p.BaseURL == "" {
+			log.Warnf("oauth-providers[%d] %s: client-id, token-url and base-url are required, skipping", i, p.Name)
+			continue
+		}
+		switch p.Flow {
+		case OAuthFlowAuthorizationCode:
+			if p.AuthorizeURL == "" {
+				log.Warnf("oauth-providers[%d] %s: authorize-url is required for the authorization-code flow, skipping", i, p.Name)
+				continue
+			}
+		case OAuthFlowDeviceCode:
+			if p.DeviceAuthorizationURL == "" {
+				log.Warnf("oauth-providers[%d] %s: device-authorization-url is required for the device-code flow, skipping", i, p.Name)
+				continue
+			}
+		case OAuthFlowClientCredentials:
+			if p.ClientSecret == "" {
+				log.Warnf("oauth-providers[%d] %s: client-secret is required for the client-credentials flow, skipping", i, p.Name)
+				continue
+			}
+		default:
+			log.Warnf("oauth-providers[%d] %s: unsupported flow %q, skipping", i, p.Name, p.Flow)
+			continue
+		}
+		switch p.Format {
+		case "openai", "claude", "gemini":
+		default:
+			log.Warnf("oauth-providers[%d] %s: unsupported format %q, skipping", i, p.Name, p.Format)
+			continue
+		}
+		p.Headers = NormalizeHeaders(p.Headers)
+		scopes := make([]string, 0, len(p.Scopes))
+		for _, scope := range p.Scopes {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		p.Scopes = scopes
+		models := make([]OAuthProviderModel, 0, len(p.Models))
+		for _, model := range p.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		p.Models = models
+		seen[p.Name] = struct{}{}
+		out = append(out, p)
+	}
+	cfg.OAuthProviders = out
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestSanitizeOAuthProviders(t *testing.T) {
+	cfg := &Config{OAuthProviders: []OAuthProvider{
+		{Name: " Acme ", Flow: "Device-Code", ClientID: "cid", TokenURL: "https://a/token", DeviceAuthorizationURL: "https://a/device", BaseURL: "https://a/v1/", Scopes: []string{" openid ", ""}},
+		{Name: "acme", ClientID: "cid", AuthorizeURL: "https://a/auth", TokenURL: "https://a/token", BaseURL: "https://a"},
+		{Name: "claude", ClientID: "cid", AuthorizeURL: "https://a/auth", TokenURL: "https://a/token", BaseURL: "https://a"},
+		{Name: "nosecret", Flow: OAuthFlowClientCredentials, ClientID: "cid", TokenURL: "https://a/token", BaseURL: "https://a"},
+		{Name: "noauthorize", ClientID: "cid", TokenURL: "https://a/token", BaseURL: "https://a"},
+		{Name: "badformat", Flow: OAuthFlowClientCredentials, ClientID: "cid", ClientSecret: "s", TokenURL: "https://a/token", BaseURL: "https://a", Format: "xml"},
+		{Name: "corp", ClientID: "cid", AuthorizeURL: "https://c/auth", TokenURL: "https://c/token", BaseURL: "https://c", Format: "Claude", RefreshLead: "2m", CallbackPort: 9000},
+	}}
+	cfg.SanitizeOAuthProviders()
+
+	if len(cfg.OAuthProviders) != 2 {
+		t.Fatalf("providers = %+v", cfg.OAuthProviders)
+	}
+	acme := cfg.FindOAuthProvider("ACME")
+	if acme == nil || acme.Flow != OAuthFlowDeviceCode || acme.BaseURL != "https://a/v1" || acme.Format != "openai" {
+		t.Fatalf("acme = %+v", acme)
+	}
+	if len(acme.Scopes) != 1 || acme.Scopes[0] != "openid" {
+		t.Fatalf("scopes = %v", acme.Scopes)
+	}
+	if acme.RefreshLeadDuration() != DefaultOAuthRefreshLead {
+		t.Fatalf("acme refresh lead = %s", acme.RefreshLeadDuration())
+	}
+
+	corp := cfg.FindOAuthProvider("corp")
+	if corp == nil || corp.Flow != OAuthFlowAuthorizationCode || corp.Format != "claude" || corp.RefreshLeadDuration().Minutes() != 2 {
+		t.Fatalf("corp = %+v", corp)
+	}
+	if port, redirect := corp.Callback(); port != 9000 || redirect != "http://localhost:9000/oauth2/corp/callback" {
+		t.Fatalf("callback = %d %s", port, redirect)
+	}
+}
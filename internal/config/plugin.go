@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPluginHealthCheckInterval is how often plugin processes are health-checked.
+	DefaultPluginHealthCheckInterval = 30 * time.Second
+	// DefaultPluginStartupTimeout bounds how long a launched plugin may take to answer Health.
+	DefaultPluginStartupTimeout = 10 * time.Second
+)
+
+// ExecutorPlugin declares an out-of-process executor. The service launches Command with
+// the socket path in CLIPROXY_PLUGIN_SOCKET, health-checks it and registers it as provider
+// Name. When Command is empty the plugin is expected to be running already on Socket.
+type ExecutorPlugin struct {
+	// Name is the provider key requests are routed to.
+	Name string `yaml:"name" json:"name"`
+
+	// Command and Args start the plugin process.
+	Command string   `yaml:"command,omitempty" json:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// Env adds environment variables to the plugin process.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// Socket is the Unix socket the plugin serves JSON-RPC on. Defaults to
+	// cliproxy-plugin-{name}.sock in the system temp directory.
+	Socket string `yaml:"socket,omitempty" json:"socket,omitempty"`
+
+	// Format is the payload format the plugin speaks: "openai" (default), "claude" or "gemini".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// HealthCheckInterval controls how often Health is called (default "30s").
+	HealthCheckInterval string `yaml:"health-check-interval,omitempty" json:"health-check-interval,omitempty"`
+
+	// StartupTimeout bounds how long a launched plugin may take to become healthy (default "10s").
+	StartupTimeout string `yaml:"startup-timeout,omitempty" json:"startup-timeout,omitempty"`
+
+	// DiscoveryInterval polls the plugin's ListModels method, e.g. "5m". Empty disables discovery.
+	DiscoveryInterval string `yaml:"discovery-interval,omitempty" json:"discovery-interval,omitempty"`
+
+	// Disabled keeps the plugin stopped and unregistered.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this plugin.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Tenant assigns the plugin to a tenant; empty shares it with all tenants.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Attributes are passed to the plugin with every call as auth attributes.
+	Attributes map[string]string `yaml:"attributes,omitempty" json:"attributes,omitempty"`
+
+	// Models lists the models served by the plugin, with optional aliases.
+	Models []PluginModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists models that should not be exposed.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// PluginModel maps a client-facing model name to a model served by a plugin.
+type PluginModel struct {
+	// Name is the model name passed to the plugin.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name; defaults to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+}
+
+func (m PluginModel) GetName() string  { return m.Name }
+func (m PluginModel) GetAlias() string { return m.Alias }
+
+// SocketPath returns the configured socket or the default one for the plugin name.
+func (p ExecutorPlugin) SocketPath() string {
+	if p.Socket != "" {
+		return p.Socket
+	}
+	return filepath.Join(os.TempDir(), "cliproxy-plugin-"+p.Name+".sock")
+}
+
+// HealthEvery returns the parsed health check interval, falling back to the default.
+func (p ExecutorPlugin) HealthEvery() time.Duration {
+	return parsePositiveDuration(p.HealthCheckInterval, DefaultPluginHealthCheckInterval)
+}
+
+// StartupWithin returns the parsed startup timeout, falling back to the default.
+func (p ExecutorPlugin) StartupWithin() time.Duration {
+	return parsePositiveDuration(p.StartupTimeout, DefaultPluginStartupTimeout)
+}
+
+// DiscoveryEvery returns the parsed discovery interval; zero means discovery is disabled.
+func (p ExecutorPlugin) DiscoveryEvery() time.Duration {
+	return parsePositiveDuration(p.DiscoveryInterval, 0)
+}
+
+func parsePositiveDuration(raw string, fallback time.Duration) time.Duration {
+	if raw = strings.TrimSpace(raw); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// FindPlugin returns the executor plugin named name, or nil.
+func (cfg *Config) FindPlugin(name string) *ExecutorPlugin {
+	if cfg == nil {
+		return nil
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	for i := range cfg.Plugins {
+		if cfg.Plugins[i].Name == name {
+			return &cfg.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// SanitizePlugins normalizes executor plugins and drops entries without a name or a way to
+// reach them, duplicates and names already used by other providers.
+func (cfg *Config) SanitizePlugins() {
+	if cfg == nil || len(cfg.Plugins) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.Plugins))
+	out := make([]ExecutorPlugin, 0, len(cfg.Plugins))
+	for i := range cfg.Plugins {
+		p := cfg.Plugins[i]
+		p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+		p.Command = strings.TrimSpace(p.Command)
+		p.Socket = strings.TrimSpace(p.Socket)
+		p.Format = strings.ToLower(strings.TrimSpace(p.Format))
+		if p.Format == "" {
+			p.Format = "openai"
+		}
+		p.HealthCheckInterval = strings.TrimSpace(p.HealthCheckInterval)
+		p.StartupTimeout = strings.TrimSpace(p.StartupTimeout)
+		p.DiscoveryInterval = strings.TrimSpace(p.DiscoveryInterval)
+		p.Prefix = normalizeModelPrefix(p.Prefix)
+		p.Tenant = strings.TrimSpace(p.Tenant)
+		p.ExcludedModels = NormalizeExcludedModels(p.ExcludedModels)
+
+		if p.Name == "" || strings.ContainsAny(p.Name, "/\\ ") {
+			log.Warnf("plugins[%d]: invalid name %q, skipping", i, p.Name)
+			continue
+		}
+		if _, reserved := reservedProviderNames[p.Name]; reserved || cfg.FindOAuthProvider(p.Name) != nil {
+			log.Warnf("plugins[%d]: name %q is already used by another provider, skipping", i, p.Name)
+			continue
+		}
+		if _, dup := seen[p.Name]; dup {
+			log.Warnf("plugins[%d]: duplicate name %q, skipping", i, p.Name)
+			continue
+		}
+		if p.Command == "" && p.Socket == "" {
+			log.Warnf("plugins[%d] %s: command or socket is required, skipping", i, p.Name)
+			continue
+		}
+		switch p.Format {
+		case "openai", "claude", "gemini":
+		default:
+			log.Warnf("plugins[%d] %s: unsupported format %q, skipping", i, p.Name, p.Format)
+			continue
+		}
+		models := make([]PluginModel, 0, len(p.Models))
+		for _, model := range p.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		p.Models = models
+		seen[p.Name] = struct{}{}
+		out = append(out, p)
+	}
+	cfg.Plugins = out
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizePlugins(t *testing.T) {
+	cfg := &Config{
+		OAuthProviders: []OAuthProvider{{Name: "acme"}},
+		Plugins: []ExecutorPlugin{
+			{Name: " Echo ", Command: "/bin/echo-plugin", Models: []PluginModel{{Name: " m1 "}, {Name: " "}}},
+			{Name: "echo", Command: "/bin/other"},
+			{Name: "claude", Command: "/bin/x"},
+			{Name: "acme", Command: "/bin/x"},
+			{Name: "nowhere"},
+			{Name: "weird", Socket: "/tmp/w.sock", Format: "cohere"},
+			{Name: "remote", Socket: "/run/r.sock", Format: "Claude", HealthCheckInterval: "bogus", DiscoveryInterval: "2m"},
+		},
+	}
+	cfg.SanitizePlugins()
+
+	if len(cfg.Plugins) != 2 {
+		t.Fatalf("plugins = %+v", cfg.Plugins)
+	}
+	echo := cfg.FindPlugin("ECHO")
+	if echo == nil || echo.Format != "openai" || len(echo.Models) != 1 || echo.Models[0].Name != "m1" {
+		t.Fatalf("echo = %+v", echo)
+	}
+	if !strings.HasSuffix(echo.SocketPath(), "cliproxy-plugin-echo.sock") {
+		t.Fatalf("default socket = %s", echo.SocketPath())
+	}
+	remote := cfg.FindPlugin("remote")
+	if remote == nil || remote.Format != "claude" || remote.SocketPath() != "/run/r.sock" {
+		t.Fatalf("remote = %+v", remote)
+	}
+	if remote.HealthEvery() != DefaultPluginHealthCheckInterval || remote.DiscoveryEvery() != 2*time.Minute || echo.DiscoveryEvery() != 0 {
+		t.Fatalf("durations = %s %s %s", remote.HealthEvery(), remote.DiscoveryEvery(), echo.DiscoveryEvery())
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultProxyHealthCheckInterval is how often pool proxies are probed.
+	DefaultProxyHealthCheckInterval = "1m"
+	// DefaultProxyHealthCheckTimeout bounds one probe.
+	DefaultProxyHealthCheckTimeout = "10s"
+	// DefaultProxyFailureCooldown is how long a proxy that failed to connect is avoided
+	// when no health check brings it back earlier.
+	DefaultProxyFailureCooldown = "1m"
+)
+
+// ProxyPool is a named set of outbound proxies. Credentials reference a pool with
+// proxy-url "pool:<name>" and providers through Providers; each credential sticks to
+// one healthy proxy of the pool and fails over to another on connect errors.
+type ProxyPool struct {
+	// Name identifies the pool in "pool:<name>" references.
+	Name string `yaml:"name" json:"name"`
+
+	// Proxies are the pool members (http, https or socks5 URLs).
+	Proxies []string `yaml:"proxies" json:"proxies"`
+
+	// Providers routes credentials of these providers without their own proxy-url
+	// through the pool, e.g. ["codex", "claude"].
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// HealthCheck configures periodic probing of the pool proxies.
+	HealthCheck ProxyHealthCheck `yaml:"health-check,omitempty" json:"health-check,omitempty"`
+
+	// FailureCooldown is how long a proxy that failed to connect is skipped (default "1m").
+	FailureCooldown string `yaml:"failure-cooldown,omitempty" json:"failure-cooldown,omitempty"`
+}
+
+// ProxyHealthCheck configures how pool proxies are probed.
+type ProxyHealthCheck struct {
+	// URL is requested through each proxy; any HTTP response counts as healthy. Empty
+	// only checks that the proxy accepts TCP connections.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Interval between probes (default "1m"); "0" disables health checks.
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// Timeout bounds one probe (default "10s").
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// SanitizeProxyPools normalizes proxy pools, drops invalid proxies and pools without
+// members, and keeps the first pool of a duplicated name.
+func (cfg *Config) SanitizeProxyPools() {
+	if cfg == nil {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.ProxyPools))
+	pools := make([]ProxyPool, 0, len(cfg.ProxyPools))
+	for i := range cfg.ProxyPools {
+		p := cfg.ProxyPools[i]
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" {
+			log.Warnf("proxy-pools[%d]: name is required, skipping", i)
+			continue
+		}
+		if _, dup := seen[strings.ToLower(p.Name)]; dup {
+			log.Warnf("proxy-pools[%d] %s: duplicate name, skipping", i, p.Name)
+			continue
+		}
+		proxies := make([]string, 0, len(p.Proxies))
+		for _, raw := range p.Proxies {
+			setting, err := proxyutil.Parse(raw)
+			if err != nil || setting.Mode != proxyutil.ModeProxy {
+				log.Warnf("proxy-pools[%d] %s: ignoring invalid proxy %q", i, p.Name, proxyutil.Redact(raw))
+				continue
+			}
+			proxies = append(proxies, setting.Raw)
+		}
+		if len(proxies) == 0 {
+			log.Warnf("proxy-pools[%d] %s: no valid proxies, skipping", i, p.Name)
+			continue
+		}
+		p.Proxies = proxies
+		providers := make([]string, 0, len(p.Providers))
+		for _, provider := range p.Providers {
+			if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+				providers = append(providers, provider)
+			}
+		}
+		p.Providers = providers
+		p.HealthCheck.URL = strings.TrimSpace(p.HealthCheck.URL)
+		p.HealthCheck.Interval = strings.TrimSpace(p.HealthCheck.Interval)
+		if p.HealthCheck.Interval == "" {
+			p.HealthCheck.Interval = DefaultProxyHealthCheckInterval
+		}
+		p.HealthCheck.Timeout = strings.TrimSpace(p.HealthCheck.Timeout)
+		if p.HealthCheck.Timeout == "" {
+			p.HealthCheck.Timeout = DefaultProxyHealthCheckTimeout
+		}
+		p.FailureCooldown = strings.TrimSpace(p.FailureCooldown)
+		if p.FailureCooldown == "" {
+			p.FailureCooldown = DefaultProxyFailureCooldown
+		}
+		seen[strings.ToLower(p.Name)] = struct{}{}
+		pools = append(pools, p)
+	}
+	cfg.ProxyPools = pools
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestSanitizeProxyPools(t *testing.T) {
+	cfg := &Config{ProxyPools: []ProxyPool{
+		{Name: " residential ", Proxies: []string{" http://10.0.0.1:8080 ", "bad-value", "pool:other", "socks5://u:p@10.0.0.2:1080"}, Providers: []string{" Codex ", ""}},
+		{Name: "Residential", Proxies: []string{"http://10.0.0.3:8080"}},
+		{Name: "empty", Proxies: []string{"direct"}},
+		{Proxies: []string{"http://10.0.0.4:8080"}},
+	}}
+	cfg.SanitizeProxyPools()
+
+	if len(cfg.ProxyPools) != 1 {
+		t.Fatalf("pools = %+v", cfg.ProxyPools)
+	}
+	p := cfg.ProxyPools[0]
+	if p.Name != "residential" || len(p.Proxies) != 2 || p.Proxies[0] != "http://10.0.0.1:8080" {
+		t.Fatalf("pool = %+v", p)
+	}
+	if len(p.Providers) != 1 || p.Providers[0] != "codex" {
+		t.Fatalf("providers = %v", p.Providers)
+	}
+	if p.HealthCheck.Interval != DefaultProxyHealthCheckInterval || p.HealthCheck.Timeout != DefaultProxyHealthCheckTimeout || p.FailureCooldown != DefaultProxyFailureCooldown {
+		t.Fatalf("defaults = %+v %q", p.HealthCheck, p.FailureCooldown)
+	}
+}
@@ -16,9 +16,13 @@ type ApiKeyEntry struct {
 	// ID is a stable unique identifier for this key entry (UUID format).
 	ID string `yaml:"id,omitempty" json:"id,omitempty"`
 
-	// Key is the actual API key value.
+	// Key is the API key value, or its "sha256:<hex>" digest for server-generated keys.
 	Key string `yaml:"api-key" json:"api-key"`
 
+	// Prefix is the non-secret start of a generated key (e.g. "cpk_ab12cd34") used to
+	// identify it once only the digest is stored.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
 	// Name is an optional human-readable name for the key.
 	Name string `yaml:"name,omitempty" json:"name,omitempty"`
 
@@ -40,6 +44,22 @@ type ApiKeyEntry struct {
 
 	// CreatedAt is the ISO 8601 timestamp when this key was created.
 	CreatedAt string `yaml:"created-at,omitempty" json:"created-at,omitempty"`
+
+	// ExpiresAt is the optional RFC 3339 timestamp after which the key is rejected.
+	ExpiresAt string `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+
+	// RotatedTo is the ID of the successor key issued when this key was rotated.
+	RotatedTo string `yaml:"rotated-to,omitempty" json:"rotated-to,omitempty"`
+
+	// IPAllow restricts this key to the listed CIDRs or addresses. Empty allows all.
+	IPAllow []string `yaml:"ip-allow,omitempty" json:"ip-allow,omitempty"`
+
+	// IPDeny rejects this key from the listed CIDRs or addresses.
+	IPDeny []string `yaml:"ip-deny,omitempty" json:"ip-deny,omitempty"`
+
+	// Tenant assigns the key to a tenant. Requests made with it only use credentials of
+	// the same tenant or shared ones.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
 }
 
 // IncrementUsage atomically increments the usage count.
@@ -111,7 +131,8 @@ func (e ApiKeyEntry) MarshalYAML() (interface{}, error) {
 	if key == "" {
 		return "", nil
 	}
-	if e.ID == "" && e.Name == "" && !e.IsActive && e.UsageCount == 0 && e.InputTokens == 0 && e.OutputTokens == 0 && e.LastUsedAt == "" && e.CreatedAt == "" {
+	if e.ID == "" && e.Name == "" && !e.IsActive && e.UsageCount == 0 && e.InputTokens == 0 && e.OutputTokens == 0 && e.LastUsedAt == "" && e.CreatedAt == "" &&
+		e.Prefix == "" && e.ExpiresAt == "" && e.RotatedTo == "" && len(e.IPAllow) == 0 && len(e.IPDeny) == 0 && e.Tenant == "" {
 		return key, nil
 	}
 	type alias ApiKeyEntry
@@ -149,6 +170,87 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// PublicBaseURL is the externally reachable base URL of this proxy (e.g. "https://ai.example.com"),
+	// used to build links back to it such as generated image URLs. When empty, links use the
+	// request Host; forwarded headers are not trusted for this.
+	PublicBaseURL string `yaml:"public-base-url,omitempty" json:"public-base-url,omitempty"`
+
+	// RemoteMedia configures downloading of http(s) image and file URLs found in requests
+	// so they can be inlined for backends that only accept base64 data.
+	RemoteMedia RemoteMediaConfig `yaml:"remote-media,omitempty" json:"remote-media,omitempty"`
+
+	// Scripting attaches sandboxed Lua hooks to client requests, responses and stream chunks.
+	Scripting ScriptingConfig `yaml:"scripting,omitempty" json:"scripting,omitempty"`
+}
+
+// ScriptingConfig controls the Lua hooks run around request execution.
+type ScriptingConfig struct {
+	// TimeoutMS bounds a single hook invocation. <= 0 uses the default of 100 ms.
+	TimeoutMS int `yaml:"timeout-ms,omitempty" json:"timeout-ms,omitempty"`
+
+	// Hooks are evaluated in order; every matching hook runs.
+	Hooks []ScriptHook `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+}
+
+// ScriptHook declares one Lua script and where it applies. The script may define the
+// global functions on_request, on_response and on_chunk.
+type ScriptHook struct {
+	// Name identifies the hook in logs.
+	Name string `yaml:"name" json:"name"`
+
+	// File is the Lua source file; it is reloaded when it changes on disk.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// Source is inline Lua used when File is empty.
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+
+	// Models restricts the hook to matching client model names; "*" wildcards are allowed.
+	// Empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Routes restricts the hook to client formats (openai, openai-response, claude, gemini)
+	// or request paths such as "/v1/messages"; "*" wildcards are allowed. Empty matches all.
+	Routes []string `yaml:"routes,omitempty" json:"routes,omitempty"`
+
+	// FailClosed rejects the request when the script errors or times out instead of
+	// passing the payload through unchanged.
+	FailClosed bool `yaml:"fail-closed,omitempty" json:"fail-closed,omitempty"`
+
+	// Disabled keeps the hook configured but inactive.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// RemoteMediaConfig controls the remote media fetcher used before request translation.
+type RemoteMediaConfig struct {
+	// Enable turns on fetching of remote image/file URLs. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// MaxSizeMB caps the size of a single downloaded file. <= 0 uses the default of 20 MB.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// TimeoutSeconds bounds each download. <= 0 uses the default of 15 seconds.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// AllowedContentTypes lists accepted MIME types or prefixes (e.g. "image/", "application/pdf").
+	// Empty means images and PDFs.
+	AllowedContentTypes []string `yaml:"allowed-content-types,omitempty" json:"allowed-content-types,omitempty"`
+
+	// AllowHosts restricts downloads to these hosts when non-empty. Entries may use a leading
+	// "*." wildcard to match subdomains.
+	AllowHosts []string `yaml:"allow-hosts,omitempty" json:"allow-hosts,omitempty"`
+
+	// DenyHosts blocks downloads from these hosts. Deny entries take precedence over allow entries.
+	DenyHosts []string `yaml:"deny-hosts,omitempty" json:"deny-hosts,omitempty"`
+
+	// AllowPrivateNetworks permits downloads from loopback, link-local, private, CGNAT and
+	// NAT64 addresses. Default is false to avoid server-side request forgery. Redirect
+	// targets are checked too. Through proxy-url or HTTP(S)_PROXY, host names are resolved
+	// locally for the check, so names only the proxy can resolve are not covered.
+	AllowPrivateNetworks bool `yaml:"allow-private-networks,omitempty" json:"allow-private-networks,omitempty"`
+
+	// CacheEntries is the number of downloads kept in the in-memory LRU cache. <= 0 uses 64.
+	CacheEntries int `yaml:"cache-entries,omitempty" json:"cache-entries,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
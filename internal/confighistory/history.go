@@ -0,0 +1,246 @@
+// Package confighistory keeps versioned snapshots of the configuration file so
+// accepted changes can be listed, compared and rolled back.
+//
+// Snapshots are stored as plain files in a directory next to the config file.
+// Remote-backed token stores (Git, Postgres, object storage) mirror the config
+// into a local working file, so the history follows that working copy.
+package confighistory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLimit is the number of versions kept when the config does not set one.
+const DefaultLimit = 50
+
+// ErrNotFound is returned for unknown version IDs.
+var ErrNotFound = errors.New("config version not found")
+
+// Version describes one accepted config snapshot.
+type Version struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Hash      string    `json:"hash"`
+	Size      int       `json:"size"`
+	// Source records what produced the version: "startup", "reload" or "rollback".
+	Source string `json:"source"`
+	// Changes is the human-readable diff against the previous accepted config.
+	Changes []string `json:"changes"`
+}
+
+// Store persists config versions under a directory.
+type Store struct {
+	mu       sync.Mutex
+	dir      string
+	limit    int
+	versions []Version
+	loaded   bool
+	// rollbackHash and rollbackFrom label the next matching Record as a rollback.
+	rollbackHash string
+	rollbackFrom int64
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*Store)
+)
+
+// ForConfig returns the shared history store for configPath, stored in a
+// "config-history" directory beside it. It returns nil for an empty path.
+func ForConfig(configPath string) *Store {
+	if configPath == "" {
+		return nil
+	}
+	dir := filepath.Join(filepath.Dir(configPath), "config-history")
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if s, ok := stores[dir]; ok {
+		return s
+	}
+	s := NewStore(dir, DefaultLimit)
+	stores[dir] = s
+	return s
+}
+
+// NewStore creates a store in dir keeping at most limit versions. A limit of 0 disables recording.
+func NewStore(dir string, limit int) *Store {
+	return &Store{dir: dir, limit: limit}
+}
+
+// SetLimit changes how many versions are kept; older versions are pruned on the next Record.
+func (s *Store) SetLimit(limit int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.limit = limit
+	s.mu.Unlock()
+}
+
+// Record stores data as a new version unless it matches the latest version.
+// It reports whether a version was added.
+func (s *Store) Record(data []byte, source string, changes []string) (Version, bool, error) {
+	if s == nil || len(data) == 0 {
+		return Version{}, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit <= 0 {
+		return Version{}, false, nil
+	}
+	if err := s.loadLocked(); err != nil {
+		return Version{}, false, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	var nextID int64 = 1
+	if n := len(s.versions); n > 0 {
+		latest := s.versions[n-1]
+		if latest.Hash == hash {
+			return latest, false, nil
+		}
+		nextID = latest.ID + 1
+	}
+	if changes == nil {
+		changes = []string{}
+	}
+	if s.rollbackHash == hash {
+		source = "rollback"
+		changes = append([]string{fmt.Sprintf("rolled back to version %d", s.rollbackFrom)}, changes...)
+	}
+	s.rollbackHash, s.rollbackFrom = "", 0
+	version := Version{
+		ID:        nextID,
+		CreatedAt: time.Now().UTC(),
+		Hash:      hash,
+		Size:      len(data),
+		Source:    source,
+		Changes:   changes,
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return Version{}, false, fmt.Errorf("config history: create directory: %w", err)
+	}
+	if err := os.WriteFile(s.contentPath(version.ID), data, 0o600); err != nil {
+		return Version{}, false, fmt.Errorf("config history: write version: %w", err)
+	}
+	s.versions = append(s.versions, version)
+	for len(s.versions) > s.limit {
+		_ = os.Remove(s.contentPath(s.versions[0].ID))
+		s.versions = s.versions[1:]
+	}
+	if err := s.saveIndexLocked(); err != nil {
+		return Version{}, false, err
+	}
+	return version, true, nil
+}
+
+// NoteRollback marks content restored from version id so the reload that
+// records it is labelled as a rollback.
+func (s *Store) NoteRollback(data []byte, id int64) {
+	if s == nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+	s.mu.Lock()
+	s.rollbackHash, s.rollbackFrom = hex.EncodeToString(sum[:]), id
+	s.mu.Unlock()
+}
+
+// List returns all kept versions, newest first.
+func (s *Store) List() ([]Version, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	out := make([]Version, len(s.versions))
+	for i, v := range s.versions {
+		out[len(s.versions)-1-i] = v
+	}
+	return out, nil
+}
+
+// Get returns a version and its raw YAML content.
+func (s *Store) Get(id int64) (Version, []byte, error) {
+	if s == nil {
+		return Version{}, nil, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return Version{}, nil, err
+	}
+	idx := sort.Search(len(s.versions), func(i int) bool { return s.versions[i].ID >= id })
+	if idx == len(s.versions) || s.versions[idx].ID != id {
+		return Version{}, nil, ErrNotFound
+	}
+	data, err := os.ReadFile(s.contentPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Version{}, nil, ErrNotFound
+		}
+		return Version{}, nil, fmt.Errorf("config history: read version: %w", err)
+	}
+	return s.versions[idx], data, nil
+}
+
+func (s *Store) contentPath(id int64) string {
+	return filepath.Join(s.dir, strconv.FormatInt(id, 10)+".yaml")
+}
+
+func (s *Store) indexPath() string {
+	return filepath.Join(s.dir, "index.json")
+}
+
+func (s *Store) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.indexPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.versions = nil
+	case err != nil:
+		return fmt.Errorf("config history: read index: %w", err)
+	default:
+		if err = json.Unmarshal(data, &s.versions); err != nil {
+			return fmt.Errorf("config history: parse index: %w", err)
+		}
+	}
+	sort.Slice(s.versions, func(i, j int) bool { return s.versions[i].ID < s.versions[j].ID })
+	s.loaded = true
+	return nil
+}
+
+func (s *Store) saveIndexLocked() error {
+	data, err := json.MarshalIndent(s.versions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("config history: encode index: %w", err)
+	}
+	tmp := s.indexPath() + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("config history: write index: %w", err)
+	}
+	if err = os.Rename(tmp, s.indexPath()); err != nil {
+		return fmt.Errorf("config history: replace index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package confighistory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStoreRecordDedupesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir, 2)
+
+	if _, added, err := s.Record([]byte("port: 1\n"), "startup", nil); err != nil || !added {
+		t.Fatalf("record v1: added=%v err=%v", added, err)
+	}
+	if _, added, _ := s.Record([]byte("port: 1\n"), "reload", nil); added {
+		t.Fatalf("identical content recorded twice")
+	}
+	s.Record([]byte("port: 2\n"), "reload", []string{"port: 1 -> 2"})
+	v3, _, _ := s.Record([]byte("port: 3\n"), "reload", []string{"port: 2 -> 3"})
+
+	versions, err := s.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(versions) != 2 || versions[0].ID != v3.ID || versions[1].ID != 2 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if _, _, err = s.Get(1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("pruned version still readable: %v", err)
+	}
+
+	reopened := NewStore(dir, 2)
+	_, data, err := reopened.Get(2)
+	if err != nil || string(data) != "port: 2\n" {
+		t.Fatalf("reopened get: %q, %v", data, err)
+	}
+}
+
+func TestStoreLabelsRollback(t *testing.T) {
+	s := NewStore(t.TempDir(), DefaultLimit)
+	s.Record([]byte("port: 1\n"), "startup", nil)
+	s.Record([]byte("port: 2\n"), "reload", nil)
+
+	s.NoteRollback([]byte("port: 1\n"), 1)
+	v, added, err := s.Record([]byte("port: 1\n"), "reload", []string{"port: 2 -> 1"})
+	if err != nil || !added {
+		t.Fatalf("record rollback: added=%v err=%v", added, err)
+	}
+	if v.Source != "rollback" || len(v.Changes) != 2 || v.Changes[0] != "rolled back to version 1" {
+		t.Fatalf("unexpected rollback version: %+v", v)
+	}
+
+	v, _, _ = s.Record([]byte("port: 4\n"), "reload", nil)
+	if v.Source != "reload" {
+		t.Fatalf("rollback label leaked into later version: %+v", v)
+	}
+}
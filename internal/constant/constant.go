@@ -22,6 +22,9 @@ const (
 	// OpenaiResponse represents the OpenAI response format identifier.
 	OpenaiResponse = "openai-response"
 
+	// OpenAICompletions represents the legacy OpenAI completions format identifier.
+	OpenAICompletions = "openai-completions"
+
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
 )
@@ -0,0 +1,341 @@
+// Package credbundle exports credentials into a single portable bundle and imports
+// them into another deployment. A bundle is a JSON document carrying auth files plus
+// their routing settings (prefix, priority, excluded models, proxy). The entries are
+// optionally encrypted with a passphrase (scrypt + AES-256-GCM) and the bundle is
+// optionally signed with an Ed25519 key, so an importer can check who produced it.
+package credbundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Format identifies credential bundles.
+const Format = "cliproxy-credential-bundle"
+
+// Version is the bundle layout version written by Build.
+const Version = 1
+
+const (
+	saltSize = 16
+	scryptN  = 1 << 15
+	scryptR  = 8
+	scryptP  = 1
+)
+
+var (
+	// ErrInvalidBundle is returned for documents that are not credential bundles.
+	ErrInvalidBundle = errors.New("not a credential bundle")
+	// ErrCorrupted is returned when the payload does not match the bundle digest.
+	ErrCorrupted = errors.New("credential bundle is corrupted")
+	// ErrPassphraseRequired is returned when opening an encrypted bundle without a passphrase.
+	ErrPassphraseRequired = errors.New("credential bundle is encrypted: passphrase required")
+	// ErrWrongPassphrase is returned when an encrypted bundle cannot be decrypted.
+	ErrWrongPassphrase = errors.New("credential bundle decryption failed: wrong passphrase")
+	// ErrBadSignature is returned when the bundle signature does not verify.
+	ErrBadSignature = errors.New("credential bundle signature is invalid")
+	// ErrUntrustedSigner is returned when the bundle is signed by a key that is not trusted.
+	ErrUntrustedSigner = errors.New("credential bundle is signed by an untrusted key")
+	// ErrSignatureRequired is returned when an unsigned bundle is opened with RequireSignature.
+	ErrSignatureRequired = errors.New("credential bundle is not signed")
+)
+
+// Entry is one credential in a bundle.
+type Entry struct {
+	// Name is the auth file name.
+	Name string `json:"name"`
+	// Provider is the auth type, e.g. "claude" or "codex".
+	Provider string `json:"provider"`
+	// Identity is the account identity used to detect duplicates on import.
+	Identity string `json:"identity,omitempty"`
+	// Label is a human readable account name.
+	Label string `json:"label,omitempty"`
+	// Settings are the routing settings in effect on the exporting deployment.
+	Settings Settings `json:"settings"`
+	// Auth is the auth file content.
+	Auth json.RawMessage `json:"auth"`
+}
+
+// Settings are per-credential routing settings carried with an entry.
+type Settings struct {
+	Prefix         string   `json:"prefix,omitempty"`
+	Priority       *int     `json:"priority,omitempty"`
+	ExcludedModels []string `json:"excluded_models,omitempty"`
+	ProxyURL       string   `json:"proxy_url,omitempty"`
+	Disabled       bool     `json:"disabled,omitempty"`
+}
+
+// document is the serialized bundle. Payload is the base64 of the JSON entry list, or
+// of its ciphertext when Encryption is set; Digest is the hex SHA-256 of the decoded
+// payload and is what the signature covers.
+type document struct {
+	Format     string      `json:"format"`
+	Version    int         `json:"version"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Count      int         `json:"count"`
+	Encryption *encryption `json:"encryption,omitempty"`
+	Payload    string      `json:"payload"`
+	Digest     string      `json:"digest"`
+	Signature  *signature  `json:"signature,omitempty"`
+}
+
+type encryption struct {
+	KDF   string `json:"kdf"`
+	Salt  string `json:"salt"`
+	Nonce string `json:"nonce"`
+}
+
+type signature struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// BuildOptions control how a bundle is written.
+type BuildOptions struct {
+	// Passphrase encrypts the entries when set.
+	Passphrase string
+	// SigningKey signs the bundle when set.
+	SigningKey ed25519.PrivateKey
+}
+
+// Build serializes entries into a bundle.
+func Build(entries []Entry, opts BuildOptions) ([]byte, error) {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("encode credential bundle entries: %w", err)
+	}
+	doc := document{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Count:     len(entries),
+	}
+	payload := plain
+	if opts.Passphrase != "" {
+		salt := make([]byte, saltSize)
+		if _, err = rand.Read(salt); err != nil {
+			return nil, err
+		}
+		aead, errAEAD := newAEAD(opts.Passphrase, salt)
+		if errAEAD != nil {
+			return nil, errAEAD
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err = rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		payload = aead.Seal(nil, nonce, plain, []byte(Format))
+		doc.Encryption = &encryption{
+			KDF:   "scrypt",
+			Salt:  base64.StdEncoding.EncodeToString(salt),
+			Nonce: base64.StdEncoding.EncodeToString(nonce),
+		}
+	}
+	digest := sha256.Sum256(payload)
+	doc.Payload = base64.StdEncoding.EncodeToString(payload)
+	doc.Digest = hex.EncodeToString(digest[:])
+	if opts.SigningKey != nil {
+		doc.Signature = &signature{
+			Key:   base64.StdEncoding.EncodeToString(opts.SigningKey.Public().(ed25519.PublicKey)),
+			Value: base64.StdEncoding.EncodeToString(ed25519.Sign(opts.SigningKey, signedMessage(doc))),
+		}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// OpenOptions control how a bundle is verified and decrypted.
+type OpenOptions struct {
+	// Passphrase decrypts encrypted bundles.
+	Passphrase string
+	// TrustedKeys are the public keys accepted as signers. When set, unsigned bundles and
+	// bundles signed by other keys are rejected; with no trusted keys any bundle with a
+	// valid or missing signature is accepted.
+	TrustedKeys []ed25519.PublicKey
+	// RequireSignature rejects unsigned bundles even when no keys are trusted.
+	RequireSignature bool
+}
+
+// Contents is an opened bundle.
+type Contents struct {
+	CreatedAt time.Time `json:"created_at"`
+	Encrypted bool      `json:"encrypted"`
+	// Signer is the base64 public key that signed the bundle; empty when unsigned.
+	Signer  string  `json:"signer,omitempty"`
+	Entries []Entry `json:"-"`
+}
+
+// Open verifies and decrypts a bundle.
+func Open(data []byte, opts OpenOptions) (*Contents, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Format != Format {
+		return nil, ErrInvalidBundle
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported credential bundle version %d", doc.Version)
+	}
+	payload, err := base64.StdEncoding.DecodeString(doc.Payload)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	digest := sha256.Sum256(payload)
+	if hex.EncodeToString(digest[:]) != doc.Digest {
+		return nil, ErrCorrupted
+	}
+
+	contents := &Contents{CreatedAt: doc.CreatedAt, Encrypted: doc.Encryption != nil}
+	if doc.Signature != nil {
+		key, errKey := base64.StdEncoding.DecodeString(doc.Signature.Key)
+		sig, errSig := base64.StdEncoding.DecodeString(doc.Signature.Value)
+		if errKey != nil || errSig != nil || len(key) != ed25519.PublicKeySize {
+			return nil, ErrBadSignature
+		}
+		if !ed25519.Verify(key, signedMessage(doc), sig) {
+			return nil, ErrBadSignature
+		}
+		if len(opts.TrustedKeys) > 0 && !trusted(opts.TrustedKeys, key) {
+			return nil, ErrUntrustedSigner
+		}
+		contents.Signer = doc.Signature.Key
+	} else if opts.RequireSignature || len(opts.TrustedKeys) > 0 {
+		return nil, ErrSignatureRequired
+	}
+
+	plain := payload
+	if doc.Encryption != nil {
+		if opts.Passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		salt, errSalt := base64.StdEncoding.DecodeString(doc.Encryption.Salt)
+		nonce, errNonce := base64.StdEncoding.DecodeString(doc.Encryption.Nonce)
+		if errSalt != nil || errNonce != nil || doc.Encryption.KDF != "scrypt" {
+			return nil, ErrCorrupted
+		}
+		aead, errAEAD := newAEAD(opts.Passphrase, salt)
+		if errAEAD != nil {
+			return nil, errAEAD
+		}
+		if len(nonce) != aead.NonceSize() {
+			return nil, ErrCorrupted
+		}
+		if plain, err = aead.Open(nil, nonce, payload, []byte(Format)); err != nil {
+			return nil, ErrWrongPassphrase
+		}
+	}
+	if err = json.Unmarshal(plain, &contents.Entries); err != nil {
+		return nil, fmt.Errorf("decode credential bundle entries: %w", err)
+	}
+	return contents, nil
+}
+
+// signedMessage binds the signature to the bundle header and payload digest.
+func signedMessage(doc document) []byte {
+	encrypted := "plain"
+	if doc.Encryption != nil {
+		encrypted = "scrypt:" + doc.Encryption.Salt + ":" + doc.Encryption.Nonce
+	}
+	return []byte(strings.Join([]string{
+		Format,
+		fmt.Sprint(doc.Version),
+		doc.CreatedAt.UTC().Format(time.RFC3339),
+		fmt.Sprint(doc.Count),
+		encrypted,
+		doc.Digest,
+	}, "\n"))
+}
+
+func trusted(keys []ed25519.PublicKey, key []byte) bool {
+	for _, k := range keys {
+		if k.Equal(ed25519.PublicKey(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive credential bundle key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// GenerateSigningKey returns a new base64 private key seed and its base64 public key.
+func GenerateSigningKey() (privateKey, publicKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.Seed()), base64.StdEncoding.EncodeToString(pub), nil
+}
+
+// ParseSigningKey decodes a base64 Ed25519 seed or full private key.
+func ParseSigningKey(raw string) (ed25519.PrivateKey, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	switch len(data) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(data), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(data), nil
+	default:
+		return nil, fmt.Errorf("invalid signing key: expected %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(data))
+	}
+}
+
+// ParsePublicKey decodes a base64 Ed25519 public key.
+func ParsePublicKey(raw string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key %q", raw)
+	}
+	return ed25519.PublicKey(data), nil
+}
+
+// KeysFromConfig returns the configured signing key and the public keys trusted on
+// import: the configured trusted keys plus the signing key's own public key. The list
+// is empty only when no key is configured at all.
+func KeysFromConfig(cfg config.CredentialBundleConfig) (ed25519.PrivateKey, []ed25519.PublicKey, error) {
+	signingKey, err := ParseSigningKey(cfg.SigningKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	trustedKeys := make([]ed25519.PublicKey, 0, len(cfg.TrustedKeys)+1)
+	for _, raw := range cfg.TrustedKeys {
+		key, errKey := ParsePublicKey(raw)
+		if errKey != nil {
+			return nil, nil, errKey
+		}
+		trustedKeys = append(trustedKeys, key)
+	}
+	if signingKey != nil {
+		trustedKeys = append(trustedKeys, signingKey.Public().(ed25519.PublicKey))
+	}
+	return signingKey, trustedKeys, nil
+}
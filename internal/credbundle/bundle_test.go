@@ -0,0 +1,214 @@
+package credbundle
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func testEntries(t *testing.T) []Entry {
+	t.Helper()
+	priority := 5
+	auth := &coreauth.Auth{
+		ID:       "claude-team.json",
+		FileName: "claude-team.json",
+		Provider: "claude",
+		Prefix:   "team",
+		ProxyURL: "socks5://127.0.0.1:1080",
+		Metadata: map[string]any{"type": "claude", "email": "Team@Example.com", "access_token": "secret"},
+		Attributes: map[string]string{
+			"priority":        "5",
+			"excluded_models": "claude-3-*,claude-2",
+		},
+	}
+	entry, err := EntryFromAuth(auth)
+	if err != nil {
+		t.Fatalf("EntryFromAuth: %v", err)
+	}
+	if entry.Identity != "claude:team@example.com" || *entry.Settings.Priority != priority || len(entry.Settings.ExcludedModels) != 2 {
+		t.Fatalf("entry = %+v", entry)
+	}
+	return []Entry{entry}
+}
+
+func TestBuildOpenEncrypted(t *testing.T) {
+	data, err := Build(testEntries(t), BuildOptions{Passphrase: "hunter2"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Fatal("encrypted bundle leaks token")
+	}
+	if _, err = Open(data, OpenOptions{}); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err = Open(data, OpenOptions{Passphrase: "wrong"}); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("err = %v", err)
+	}
+	contents, err := Open(data, OpenOptions{Passphrase: "hunter2"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !contents.Encrypted || contents.Signer != "" || len(contents.Entries) != 1 || contents.Entries[0].Settings.Prefix != "team" {
+		t.Fatalf("contents = %+v", contents)
+	}
+	if _, err = Open([]byte(`{"format":"other"}`), OpenOptions{}); !errors.Is(err, ErrInvalidBundle) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	privateKey, publicKey, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey: %v", err)
+	}
+	otherPrivate, otherPublic, _ := GenerateSigningKey()
+	signing, trustedKeys, err := KeysFromConfig(config.CredentialBundleConfig{SigningKey: privateKey, TrustedKeys: []string{otherPublic}})
+	if err != nil || len(trustedKeys) != 2 {
+		t.Fatalf("KeysFromConfig: %v %d", err, len(trustedKeys))
+	}
+	data, err := Build(testEntries(t), BuildOptions{SigningKey: signing})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	contents, err := Open(data, OpenOptions{TrustedKeys: trustedKeys, RequireSignature: true})
+	if err != nil || contents.Signer != publicKey {
+		t.Fatalf("Open: %v %+v", err, contents)
+	}
+
+	otherKey, _ := ParseSigningKey(otherPrivate)
+	foreign, _ := Build(testEntries(t), BuildOptions{SigningKey: otherKey})
+	onlyOther, _ := ParsePublicKey(otherPublic)
+	if _, err = Open(data, OpenOptions{TrustedKeys: []ed25519.PublicKey{onlyOther}}); !errors.Is(err, ErrUntrustedSigner) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err = Open(foreign, OpenOptions{TrustedKeys: trustedKeys}); err != nil {
+		t.Fatalf("trusted foreign signer: %v", err)
+	}
+
+	unsigned, _ := Build(testEntries(t), BuildOptions{})
+	if _, err = Open(unsigned, OpenOptions{RequireSignature: true}); !errors.Is(err, ErrSignatureRequired) {
+		t.Fatalf("err = %v", err)
+	}
+
+	// A configured signing key alone trusts only itself.
+	_, ownOnly, err := KeysFromConfig(config.CredentialBundleConfig{SigningKey: privateKey})
+	if err != nil || len(ownOnly) != 1 {
+		t.Fatalf("KeysFromConfig: %v %d", err, len(ownOnly))
+	}
+	if _, err = Open(data, OpenOptions{TrustedKeys: ownOnly}); err != nil {
+		t.Fatalf("own signature: %v", err)
+	}
+	if _, err = Open(foreign, OpenOptions{TrustedKeys: ownOnly}); !errors.Is(err, ErrUntrustedSigner) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err = Open(unsigned, OpenOptions{TrustedKeys: ownOnly}); !errors.Is(err, ErrSignatureRequired) {
+		t.Fatalf("err = %v", err)
+	}
+	// Nothing configured accepts unsigned and foreign-signed bundles.
+	if _, none, _ := KeysFromConfig(config.CredentialBundleConfig{}); len(none) != 0 {
+		t.Fatalf("trusted keys without configuration = %d", len(none))
+	}
+	if _, err = Open(foreign, OpenOptions{}); err != nil {
+		t.Fatalf("unconfigured foreign signer: %v", err)
+	}
+
+	// Tampering with the signed header or the payload is detected.
+	var doc map[string]any
+	_ = json.Unmarshal(data, &doc)
+	doc["count"] = 2
+	tampered, _ := json.Marshal(doc)
+	if _, err = Open(tampered, OpenOptions{}); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("err = %v", err)
+	}
+	_ = json.Unmarshal(data, &doc)
+	doc["payload"] = doc["payload"].(string)[:8] + "AAAA" + doc["payload"].(string)[12:]
+	tampered, _ = json.Marshal(doc)
+	if _, err = Open(tampered, OpenOptions{}); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	cases := []struct {
+		metadata map[string]any
+		want     string
+	}{
+		{map[string]any{"type": "codex", "email": "a@b.c", "account_id": "acc-1"}, "codex:account:acc-1"},
+		{map[string]any{"type": "codex", "email": "A@b.c"}, "codex:a@b.c"},
+		{map[string]any{"type": "gemini-cli", "email": "a@b.c", "project_id": "p1"}, "gemini:a@b.c:p1"},
+		{map[string]any{"type": "claude", "email": "a@b.c"}, "claude:a@b.c"},
+		{map[string]any{"type": "qwen"}, ""},
+	}
+	for _, tc := range cases {
+		if got := Identity(tc.metadata); got != tc.want {
+			t.Errorf("Identity(%v) = %q, want %q", tc.metadata, got, tc.want)
+		}
+	}
+}
+
+func TestImportDedupeReplaceAndRename(t *testing.T) {
+	dir := t.TempDir()
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(dir)
+	manager := coreauth.NewManager(nil, nil, nil)
+	ctx := context.Background()
+
+	existing := []*coreauth.Auth{
+		{ID: "claude-team.json", FileName: "claude-team.json", Metadata: map[string]any{"type": "claude", "email": "team@example.com"}},
+		{ID: "codex.json", FileName: "codex.json", Metadata: map[string]any{"type": "codex", "email": "other@example.com"}},
+	}
+	codex := Entry{Name: "codex.json", Auth: json.RawMessage(`{"type":"codex","email":"new@example.com","account_id":"acc-9"}`), Settings: Settings{Disabled: true}}
+	entries := append(testEntries(t), codex, Entry{Name: "dup.json", Auth: codex.Auth})
+	target := Target{Store: store, Manager: manager, Config: &config.Config{}, AuthDir: dir, Existing: existing}
+
+	results := Import(ctx, entries, target, ImportOptions{DropProxy: true})
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	if r := results[0]; r.Action != ActionSkipped || r.Conflict != "claude-team.json" {
+		t.Fatalf("existing account = %+v", r)
+	}
+	if r := results[1]; r.Action != ActionImported || r.File != "codex-2.json" || r.Conflict != "codex.json" {
+		t.Fatalf("renamed = %+v", r)
+	}
+	if r := results[2]; r.Action != ActionSkipped || r.Conflict != "codex.json" {
+		t.Fatalf("duplicate = %+v", r)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "codex-2.json"))
+	if err != nil {
+		t.Fatalf("read imported file: %v", err)
+	}
+	var saved map[string]any
+	_ = json.Unmarshal(raw, &saved)
+	if saved["disabled"] != true || saved["account_id"] != "acc-9" {
+		t.Fatalf("saved = %v", saved)
+	}
+	auth, ok := manager.GetByID("codex-2.json")
+	if !ok || !auth.Disabled || auth.Provider != "codex" {
+		t.Fatalf("registered = %+v %v", auth, ok)
+	}
+
+	results = Import(ctx, entries[:1], target, ImportOptions{Replace: true})
+	if r := results[0]; r.Action != ActionReplaced || r.File != "claude-team.json" {
+		t.Fatalf("replace = %+v", r)
+	}
+	raw, _ = os.ReadFile(filepath.Join(dir, "claude-team.json"))
+	_ = json.Unmarshal(raw, &saved)
+	if saved["prefix"] != "team" || saved["proxy_url"] != "socks5://127.0.0.1:1080" || saved["priority"] != float64(5) {
+		t.Fatalf("replaced = %v", saved)
+	}
+	if auth, ok = manager.GetByID("claude-team.json"); !ok || auth.Prefix != "team" {
+		t.Fatalf("registered = %+v %v", auth, ok)
+	}
+}
@@ -0,0 +1,169 @@
+package credbundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// Exportable reports whether auth is backed by an auth file that can be bundled.
+// Config-declared API keys and runtime-only (virtual) credentials are not.
+func Exportable(auth *coreauth.Auth) bool {
+	if auth == nil || auth.Metadata == nil {
+		return false
+	}
+	if auth.Attributes != nil {
+		if strings.EqualFold(strings.TrimSpace(auth.Attributes["runtime_only"]), "true") {
+			return false
+		}
+	}
+	if virtual, _ := auth.Metadata["virtual"].(bool); virtual {
+		return false
+	}
+	t, _ := auth.Metadata["type"].(string)
+	return strings.TrimSpace(t) != ""
+}
+
+// EntryFromAuth builds a bundle entry from a file-backed auth.
+func EntryFromAuth(auth *coreauth.Auth) (Entry, error) {
+	if !Exportable(auth) {
+		return Entry{}, fmt.Errorf("auth %s is not backed by an auth file", AuthName(auth))
+	}
+	metadata := make(map[string]any, len(auth.Metadata))
+	for k, v := range auth.Metadata {
+		metadata[k] = v
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return Entry{}, fmt.Errorf("encode auth %s: %w", AuthName(auth), err)
+	}
+	provider, _ := metadata["type"].(string)
+	entry := Entry{
+		Name:     AuthName(auth),
+		Provider: strings.ToLower(strings.TrimSpace(provider)),
+		Identity: Identity(metadata),
+		Label:    auth.Label,
+		Auth:     raw,
+		Settings: Settings{
+			Prefix:   auth.Prefix,
+			ProxyURL: strings.TrimSpace(auth.ProxyURL),
+			Disabled: auth.Disabled || auth.Status == coreauth.StatusDisabled,
+		},
+	}
+	if auth.Attributes != nil {
+		// Multi-project Gemini primaries are disabled in favour of their per-project
+		// virtual auths; the file's own flag is what must travel with the bundle.
+		if strings.EqualFold(auth.Attributes["gemini_virtual_primary"], "true") {
+			entry.Settings.Disabled, _ = metadata["disabled"].(bool)
+		}
+		if priority, errAtoi := strconv.Atoi(strings.TrimSpace(auth.Attributes["priority"])); errAtoi == nil {
+			entry.Settings.Priority = &priority
+		}
+		// excluded_models holds the per-account list merged with oauth-excluded-models.
+		for _, model := range strings.Split(auth.Attributes["excluded_models"], ",") {
+			if model = strings.TrimSpace(model); model != "" {
+				entry.Settings.ExcludedModels = append(entry.Settings.ExcludedModels, model)
+			}
+		}
+	}
+	return entry, nil
+}
+
+// AuthName returns the auth file name of auth.
+func AuthName(auth *coreauth.Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if name := strings.TrimSpace(auth.FileName); name != "" {
+		return filepath.Base(name)
+	}
+	if auth.Attributes != nil {
+		if path := strings.TrimSpace(auth.Attributes["path"]); path != "" {
+			return filepath.Base(path)
+		}
+	}
+	return filepath.Base(auth.ID)
+}
+
+// Identity returns the account identity of an auth file, used to detect the same account
+// stored under different file names. Codex accounts are identified by their ChatGPT
+// account ID, Gemini accounts by email and project, and other providers by email.
+func Identity(metadata map[string]any) string {
+	provider := strings.ToLower(strings.TrimSpace(stringValue(metadata, "type")))
+	if provider == "" {
+		return ""
+	}
+	if provider == "gemini-cli" {
+		provider = "gemini"
+	}
+	email := strings.ToLower(stringValue(metadata, "email"))
+	switch provider {
+	case "codex":
+		accountID := stringValue(metadata, "account_id")
+		if accountID == "" {
+			if idToken := stringValue(metadata, "id_token"); idToken != "" {
+				if claims, err := codex.ParseJWTToken(idToken); err == nil && claims != nil {
+					accountID = claims.GetAccountID()
+				}
+			}
+		}
+		if accountID != "" {
+			return provider + ":account:" + accountID
+		}
+	case "gemini", "vertex":
+		if project := stringValue(metadata, "project_id"); project != "" && email != "" {
+			return provider + ":" + email + ":" + project
+		}
+	}
+	if email != "" {
+		return provider + ":" + email
+	}
+	return ""
+}
+
+func stringValue(metadata map[string]any, key string) string {
+	v, _ := metadata[key].(string)
+	return strings.TrimSpace(v)
+}
+
+// Metadata returns the auth file content of an entry with its settings applied, so they
+// take effect on the importing deployment.
+func (e Entry) Metadata(dropProxy bool) (map[string]any, error) {
+	var metadata map[string]any
+	if err := json.Unmarshal(e.Auth, &metadata); err != nil || metadata == nil {
+		return nil, fmt.Errorf("entry %s: invalid auth content", e.Name)
+	}
+	if stringValue(metadata, "type") == "" {
+		return nil, fmt.Errorf("entry %s: auth type is missing", e.Name)
+	}
+	s := e.Settings
+	setOrDelete(metadata, "prefix", s.Prefix)
+	setOrDelete(metadata, "proxy_url", s.ProxyURL)
+	if dropProxy {
+		delete(metadata, "proxy_url")
+	}
+	if s.Priority != nil {
+		metadata["priority"] = *s.Priority
+	}
+	delete(metadata, "excluded-models")
+	if len(s.ExcludedModels) > 0 {
+		metadata["excluded_models"] = s.ExcludedModels
+	} else {
+		delete(metadata, "excluded_models")
+	}
+	metadata["disabled"] = s.Disabled
+	return metadata, nil
+}
+
+func setOrDelete(metadata map[string]any, key, value string) {
+	if value == "" {
+		delete(metadata, key)
+		return
+	}
+	metadata[key] = value
+}
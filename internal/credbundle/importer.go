@@ -0,0 +1,264 @@
+package credbundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/synthesizer"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// Import actions reported per entry.
+const (
+	ActionImported = "imported"
+	ActionReplaced = "replaced"
+	ActionSkipped  = "skipped"
+	ActionFailed   = "failed"
+)
+
+// ImportOptions control how bundle entries are imported.
+type ImportOptions struct {
+	// Replace overwrites credentials for an account that already exists instead of
+	// skipping them.
+	Replace bool
+	// DropProxy removes per-credential proxy settings carried in the bundle.
+	DropProxy bool
+	// Tenant assigns every imported credential to this tenant when set.
+	Tenant string
+	// DryRun reports what would happen without writing anything.
+	DryRun bool
+	// Names limits the import to these entry names; empty imports every entry.
+	Names []string
+}
+
+// Result reports what happened to one entry.
+type Result struct {
+	Name     string `json:"name"`
+	File     string `json:"file,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	Action   string `json:"action"`
+	// Conflict names the existing credential (or earlier bundle entry) for the same
+	// account, or the existing file whose name forced a rename.
+	Conflict string `json:"conflict,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Target is where imported credentials go.
+type Target struct {
+	// Store persists the auth files.
+	Store coreauth.Store
+	// Manager registers the imported credentials so they are used without a restart.
+	// When nil, a running server picks the files up through its file watcher.
+	Manager *coreauth.Manager
+	// Config and AuthDir are used to build auths exactly like the file watcher does.
+	Config  *config.Config
+	AuthDir string
+	// Existing are the credentials already present, used to detect duplicates.
+	Existing []*coreauth.Auth
+}
+
+// Import writes bundle entries to target, skipping or replacing accounts that already
+// exist and renaming entries whose file name is taken by a different account.
+func Import(ctx context.Context, entries []Entry, target Target, opts ImportOptions) []Result {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	byIdentity := make(map[string]string)
+	byName := make(map[string]string)
+	for _, auth := range target.Existing {
+		if !Exportable(auth) {
+			continue
+		}
+		name := AuthName(auth)
+		byName[strings.ToLower(name)] = Identity(auth.Metadata)
+		if identity := Identity(auth.Metadata); identity != "" {
+			if _, seen := byIdentity[identity]; !seen {
+				byIdentity[identity] = name
+			}
+		}
+	}
+	wanted := make(map[string]struct{}, len(opts.Names))
+	for _, name := range opts.Names {
+		wanted[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+
+	inBundle := make(map[string]string)
+	results := make([]Result, 0, len(entries))
+	for _, entry := range entries {
+		if len(wanted) > 0 {
+			if _, ok := wanted[strings.ToLower(entry.Name)]; !ok {
+				continue
+			}
+		}
+		result := Result{Name: entry.Name, Provider: entry.Provider}
+		metadata, err := entry.Metadata(opts.DropProxy)
+		if err != nil {
+			result.Action, result.Error = ActionFailed, err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Provider = strings.ToLower(stringValue(metadata, "type"))
+		result.Identity = Identity(metadata)
+		if opts.Tenant != "" {
+			metadata["tenant"] = opts.Tenant
+		}
+
+		file := fileName(entry.Name, result.Provider, result.Identity)
+		result.Action = ActionImported
+		if result.Identity != "" {
+			if first, dup := inBundle[result.Identity]; dup {
+				result.Action, result.Conflict = ActionSkipped, first
+				result.Error = "duplicate account in bundle"
+				results = append(results, result)
+				continue
+			}
+			inBundle[result.Identity] = entry.Name
+			if existing, exists := byIdentity[result.Identity]; exists {
+				result.Conflict = existing
+				if !opts.Replace {
+					result.Action, result.Error = ActionSkipped, "account already exists"
+					results = append(results, result)
+					continue
+				}
+				result.Action, file = ActionReplaced, existing
+			}
+		}
+		if result.Action == ActionImported {
+			if _, taken := byName[strings.ToLower(file)]; taken {
+				result.Conflict = file
+				file = freeName(file, byName)
+			}
+		}
+		result.File = file
+		byName[strings.ToLower(file)] = result.Identity
+		if result.Identity != "" {
+			byIdentity[result.Identity] = file
+		}
+
+		if !opts.DryRun {
+			if errWrite := write(ctx, target, file, metadata, entry.Settings.Disabled); errWrite != nil {
+				result.Action, result.Error = ActionFailed, errWrite.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// write persists one credential through the store and registers it with the manager.
+func write(ctx context.Context, target Target, file string, metadata map[string]any, disabled bool) error {
+	if target.Store == nil {
+		return fmt.Errorf("no token store configured")
+	}
+	path := filepath.Join(target.AuthDir, file)
+	record := &coreauth.Auth{
+		ID:         file,
+		Provider:   stringValue(metadata, "type"),
+		FileName:   file,
+		Metadata:   metadata,
+		Attributes: map[string]string{"path": path},
+	}
+	// Stores skip writing new files for disabled auths, so create the file enabled
+	// first and then record the disabled state.
+	if _, err := target.Store.Save(ctx, record); err != nil {
+		return fmt.Errorf("save %s: %w", file, err)
+	}
+	if disabled {
+		record.Disabled = true
+		if _, err := target.Store.Save(ctx, record); err != nil {
+			return fmt.Errorf("save %s: %w", file, err)
+		}
+	}
+	if target.Manager == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	synthCtx := &synthesizer.SynthesisContext{Config: target.Config, AuthDir: target.AuthDir, Now: time.Now()}
+	if synthCtx.Config == nil {
+		synthCtx.Config = &config.Config{}
+	}
+	// The file is already persisted; register without writing it again.
+	registerCtx := coreauth.WithSkipPersist(ctx)
+	for _, auth := range synthesizer.SynthesizeAuthFile(synthCtx, path, raw) {
+		if existing, ok := target.Manager.GetByID(auth.ID); ok {
+			auth.CreatedAt = existing.CreatedAt
+			auth.Runtime = existing.Runtime
+			_, err = target.Manager.Update(registerCtx, auth)
+		} else {
+			_, err = target.Manager.Register(registerCtx, auth)
+		}
+		if err != nil {
+			return fmt.Errorf("register %s: %w", auth.ID, err)
+		}
+	}
+	return nil
+}
+
+// fileName returns a safe auth file name for an entry.
+func fileName(name, provider, identity string) string {
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "." || name == string(filepath.Separator) || name == "" {
+		name = provider
+		if identity != "" {
+			name = identity
+		}
+	}
+	name = strings.NewReplacer("/", "_", "\\", "_", ":", "_", " ", "-").Replace(name)
+	if !strings.HasSuffix(strings.ToLower(name), ".json") {
+		name += ".json"
+	}
+	return name
+}
+
+func freeName(file string, taken map[string]string) string {
+	base := strings.TrimSuffix(file, filepath.Ext(file))
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d.json", base, i)
+		if _, exists := taken[strings.ToLower(candidate)]; !exists {
+			return candidate
+		}
+	}
+}
+
+// Synthesize rebuilds auths listed from a token store the way the file watcher does, so
+// per-file settings such as priority and excluded models are populated. It is used when
+// no running auth manager is available, e.g. from the command line.
+func Synthesize(cfg *config.Config, authDir string, listed []*coreauth.Auth) []*coreauth.Auth {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	synthCtx := &synthesizer.SynthesisContext{Config: cfg, AuthDir: authDir, Now: time.Now()}
+	out := make([]*coreauth.Auth, 0, len(listed))
+	for _, auth := range listed {
+		if auth == nil || auth.Metadata == nil {
+			continue
+		}
+		path := ""
+		if auth.Attributes != nil {
+			path = auth.Attributes["path"]
+		}
+		if path == "" {
+			path = filepath.Join(authDir, AuthName(auth))
+		}
+		raw, err := json.Marshal(auth.Metadata)
+		if err != nil {
+			continue
+		}
+		if synthesized := synthesizer.SynthesizeAuthFile(synthCtx, path, raw); len(synthesized) > 0 {
+			primary := synthesized[0]
+			primary.FileName = AuthName(auth)
+			out = append(out, primary)
+		}
+	}
+	return out
+}
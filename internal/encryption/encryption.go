@@ -0,0 +1,483 @@
+// Package encryption provides envelope encryption for secrets stored at rest:
+// OAuth token files in the auth directory and secret values in config.yaml.
+//
+// Every payload is encrypted with a fresh data key (AES-256-GCM). The data key is
+// wrapped with a key-encryption key identified by a short key ID, so rotating the
+// key-encryption key only re-wraps data keys. Keys come from ENCRYPTION_KEY or
+// ENCRYPTION_KEY_FILE; retired keys stay usable for decryption via
+// ENCRYPTION_PREVIOUS_KEYS or additional lines in the key file.
+//
+// When no key is configured, Seal is a no-op and Open passes plaintext through,
+// so plaintext files keep working during migration.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvKey holds the primary key-encryption key (32 bytes, base64 or hex encoded).
+	EnvKey = "ENCRYPTION_KEY"
+	// EnvKeyFile names a file whose first non-comment line is the primary key and
+	// whose remaining lines are retired keys still accepted for decryption.
+	EnvKeyFile = "ENCRYPTION_KEY_FILE"
+	// EnvPreviousKeys lists retired keys, comma separated.
+	EnvPreviousKeys = "ENCRYPTION_PREVIOUS_KEYS"
+
+	// StringPrefix marks an encrypted scalar value inside config.yaml.
+	StringPrefix = "enc:v1:"
+
+	envelopeMarker  = "cliproxy-encrypted"
+	envelopeVersion = 1
+	envelopeAlg     = "A256GCM"
+	keySize         = 32
+)
+
+var (
+	// ErrNoKey is returned when sealed data is read without a configured key.
+	ErrNoKey = errors.New("encryption: data is encrypted but no encryption key is configured")
+	// ErrUnknownKey is returned when data was sealed with a key that is not in the keyring.
+	ErrUnknownKey = errors.New("encryption: data was encrypted with an unknown key")
+)
+
+// envelope is the JSON document written in place of an encrypted payload. It stays
+// valid JSON so auth-dir scanners and JSONB columns accept it unchanged.
+type envelope struct {
+	Marker     int    `json:"cliproxy-encrypted"`
+	Alg        string `json:"alg"`
+	KeyID      string `json:"kid"`
+	WrappedKey string `json:"wrapped_key"`
+	Nonce      string `json:"nonce"`
+	Data       string `json:"data"`
+}
+
+type kek struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring holds the primary key used for sealing and every key accepted for opening.
+type Keyring struct {
+	primary *kek
+	keys    map[string]*kek
+}
+
+// NewKeyring builds a keyring from a 32-byte primary key and optional retired keys.
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	p, err := newKEK(primary)
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{primary: p, keys: map[string]*kek{p.id: p}}
+	for _, raw := range previous {
+		prev, errPrev := newKEK(raw)
+		if errPrev != nil {
+			return nil, errPrev
+		}
+		if _, exists := k.keys[prev.id]; !exists {
+			k.keys[prev.id] = prev
+		}
+	}
+	return k, nil
+}
+
+func newKEK(raw []byte) (*kek, error) {
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("encryption: key must be %d bytes, got %d", keySize, len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: init key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: init key: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return &kek{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+// ParseKey decodes a base64 (standard or URL) or hex encoded 32-byte key.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("encryption: empty key")
+	}
+	decoders := []func(string) ([]byte, error){
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+		hex.DecodeString,
+	}
+	for _, decode := range decoders {
+		if raw, err := decode(s); err == nil && len(raw) == keySize {
+			return raw, nil
+		}
+	}
+	return nil, fmt.Errorf("encryption: key must be %d bytes encoded as base64 or hex", keySize)
+}
+
+// GenerateKey returns a new random key encoded as standard base64.
+func GenerateKey() (string, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("encryption: generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// LoadKeyringFromEnv builds a keyring from the environment. It returns nil without
+// error when no key is configured.
+func LoadKeyringFromEnv() (*Keyring, error) {
+	var encoded []string
+	if path := strings.TrimSpace(os.Getenv(EnvKeyFile)); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: read key file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				encoded = append(encoded, line)
+			}
+		}
+		if len(encoded) == 0 {
+			return nil, fmt.Errorf("encryption: key file %s contains no key", path)
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv(EnvKey)); value != "" {
+		// An explicit key takes precedence; key-file entries become retired keys.
+		encoded = append([]string{value}, encoded...)
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	for _, value := range strings.Split(os.Getenv(EnvPreviousKeys), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			encoded = append(encoded, value)
+		}
+	}
+	keys := make([][]byte, 0, len(encoded))
+	for _, value := range encoded {
+		raw, err := ParseKey(value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, raw)
+	}
+	return NewKeyring(keys[0], keys[1:]...)
+}
+
+// KeyID returns the identifier of the primary key.
+func (k *Keyring) KeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.primary.id
+}
+
+// Seal encrypts plaintext into a JSON envelope under the primary key.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("encryption: generate data key: %w", err)
+	}
+	dataAEAD, err := newKEK(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, dataAEAD.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("encryption: generate nonce: %w", err)
+	}
+	wrapped, err := k.wrap(k.primary, dek)
+	if err != nil {
+		return nil, err
+	}
+	env := envelope{
+		Marker:     envelopeVersion,
+		Alg:        envelopeAlg,
+		KeyID:      k.primary.id,
+		WrappedKey: wrapped,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Data:       base64.StdEncoding.EncodeToString(dataAEAD.aead.Seal(nil, nonce, plaintext, []byte(envelopeMarker))),
+	}
+	return json.Marshal(env)
+}
+
+// Open decrypts an envelope produced by Seal. Data that is not an envelope is returned as is.
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return data, nil
+	}
+	dek, err := k.unwrap(env)
+	if err != nil {
+		return nil, err
+	}
+	dataAEAD, err := newKEK(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: decode nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: decode data: %w", err)
+	}
+	if len(nonce) != dataAEAD.aead.NonceSize() {
+		return nil, fmt.Errorf("encryption: invalid nonce")
+	}
+	plaintext, err := dataAEAD.aead.Open(nil, nonce, ciphertext, []byte(envelopeMarker))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap re-wraps the data key of an envelope under the primary key without touching
+// the payload. It reports whether data changed; plaintext and envelopes already under
+// the primary key are returned unchanged.
+func (k *Keyring) Rewrap(data []byte) ([]byte, bool, error) {
+	env, ok := parseEnvelope(data)
+	if !ok || env.KeyID == k.primary.id {
+		return data, false, nil
+	}
+	dek, err := k.unwrap(env)
+	if err != nil {
+		return nil, false, err
+	}
+	wrapped, err := k.wrap(k.primary, dek)
+	if err != nil {
+		return nil, false, err
+	}
+	env.KeyID = k.primary.id
+	env.WrappedKey = wrapped
+	out, err := json.Marshal(env)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+func (k *Keyring) wrap(key *kek, dek []byte) (string, error) {
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("encryption: generate nonce: %w", err)
+	}
+	sealed := key.aead.Seal(nonce, nonce, dek, []byte(key.id))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Keyring) unwrap(env envelope) ([]byte, error) {
+	if env.Alg != envelopeAlg {
+		return nil, fmt.Errorf("encryption: unsupported algorithm %q", env.Alg)
+	}
+	key, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w (kid %s)", ErrUnknownKey, env.KeyID)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: decode wrapped key: %w", err)
+	}
+	nonceSize := key.aead.NonceSize()
+	if len(wrapped) < nonceSize {
+		return nil, fmt.Errorf("encryption: wrapped key too short")
+	}
+	dek, err := key.aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], []byte(key.id))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: unwrap data key: %w", err)
+	}
+	return dek, nil
+}
+
+func parseEnvelope(data []byte) (envelope, bool) {
+	var env envelope
+	if !bytes.Contains(data, []byte(envelopeMarker)) {
+		return env, false
+	}
+	if err := json.Unmarshal(data, &env); err != nil || env.Marker != envelopeVersion {
+		return env, false
+	}
+	return env, true
+}
+
+// IsSealed reports whether data is an encryption envelope.
+func IsSealed(data []byte) bool {
+	_, ok := parseEnvelope(data)
+	return ok
+}
+
+// SealedKeyID returns the key ID of an envelope, or "" for plaintext.
+func SealedKeyID(data []byte) string {
+	env, _ := parseEnvelope(data)
+	return env.KeyID
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultKeyring *Keyring
+)
+
+// SetDefault installs the process-wide keyring used by the package-level helpers.
+// A nil keyring disables encryption of new data.
+func SetDefault(k *Keyring) {
+	defaultMu.Lock()
+	defaultKeyring = k
+	defaultMu.Unlock()
+}
+
+// Default returns the process-wide keyring, or nil when encryption is disabled.
+func Default() *Keyring {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultKeyring
+}
+
+// Enabled reports whether new data is encrypted.
+func Enabled() bool {
+	return Default() != nil
+}
+
+// Seal encrypts data with the default keyring. Without a keyring, or when data is
+// already sealed, it returns data unchanged.
+func Seal(data []byte) ([]byte, error) {
+	k := Default()
+	if k == nil || IsSealed(data) {
+		return data, nil
+	}
+	return k.Seal(data)
+}
+
+// Open decrypts data with the default keyring, passing plaintext through unchanged.
+func Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	k := Default()
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	return k.Open(data)
+}
+
+// ReadFile reads path and decrypts its content if it is sealed.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+// WriteFile seals data with the default keyring (when enabled) and writes it to path.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := Seal(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, perm)
+}
+
+// SealFile encrypts an existing plaintext file in place. It is used after token
+// storages that write their own JSON files. Without a keyring it does nothing.
+func SealFile(path string) error {
+	if !Enabled() {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 || IsSealed(data) {
+		return nil
+	}
+	sealed, err := Seal(data)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, sealed, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// IsSealedString reports whether s is an encrypted config value.
+func IsSealedString(s string) bool {
+	return strings.HasPrefix(s, StringPrefix)
+}
+
+// SealString encrypts a config value as "enc:v1:<base64 envelope>". Without a
+// keyring, or when s is empty or already sealed, it returns s unchanged.
+func SealString(s string) (string, error) {
+	k := Default()
+	if k == nil || s == "" || IsSealedString(s) {
+		return s, nil
+	}
+	sealed, err := k.Seal([]byte(s))
+	if err != nil {
+		return "", err
+	}
+	return StringPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// OpenString decrypts a value produced by SealString, passing other values through.
+func OpenString(s string) (string, error) {
+	if !IsSealedString(s) {
+		return s, nil
+	}
+	data, err := decodeSealedString(s)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := Open(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// RewrapString re-wraps an encrypted config value under the primary key.
+func RewrapString(s string) (string, bool, error) {
+	k := Default()
+	if k == nil || !IsSealedString(s) {
+		return s, false, nil
+	}
+	data, err := decodeSealedString(s)
+	if err != nil {
+		return "", false, err
+	}
+	out, changed, err := k.Rewrap(data)
+	if err != nil || !changed {
+		return s, false, err
+	}
+	return StringPrefix + base64.RawURLEncoding.EncodeToString(out), true, nil
+}
+
+func decodeSealedString(s string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, StringPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: decode value: %w", err)
+	}
+	if !IsSealed(data) {
+		return nil, fmt.Errorf("encryption: malformed encrypted value")
+	}
+	return data, nil
+}
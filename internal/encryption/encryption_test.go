@@ -0,0 +1,115 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKeyring(t *testing.T, previous ...[]byte) (*Keyring, []byte) {
+	t.Helper()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	raw, err := ParseKey(encoded)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	k, err := NewKeyring(raw, previous...)
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+	return k, raw
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	k, _ := testKeyring(t)
+	plaintext := []byte(`{"type":"claude","access_token":"secret"}`)
+
+	sealed, err := k.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("unexpected envelope: %s", sealed)
+	}
+	opened, err := k.Open(sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("open = %s, %v", opened, err)
+	}
+
+	// Plaintext passes through unchanged for backward compatibility.
+	if out, errOpen := k.Open(plaintext); errOpen != nil || !bytes.Equal(out, plaintext) {
+		t.Fatalf("plaintext open = %s, %v", out, errOpen)
+	}
+}
+
+func TestRewrapUnderNewPrimary(t *testing.T) {
+	oldKeyring, oldRaw := testKeyring(t)
+	sealed, err := oldKeyring.Seal([]byte("payload"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	newKeyring, _ := testKeyring(t, oldRaw)
+	rewrapped, changed, err := newKeyring.Rewrap(sealed)
+	if err != nil || !changed {
+		t.Fatalf("rewrap: changed=%v err=%v", changed, err)
+	}
+	if SealedKeyID(rewrapped) != newKeyring.KeyID() {
+		t.Fatalf("rewrapped kid = %s, want %s", SealedKeyID(rewrapped), newKeyring.KeyID())
+	}
+	if _, err = oldKeyring.Open(rewrapped); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("old keyring opened rewrapped data: %v", err)
+	}
+	opened, err := newKeyring.Open(rewrapped)
+	if err != nil || string(opened) != "payload" {
+		t.Fatalf("open rewrapped = %q, %v", opened, err)
+	}
+}
+
+func TestDefaultKeyringHelpers(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	path := filepath.Join(t.TempDir(), "auth.json")
+	if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	SetDefault(nil)
+	if err := SealFile(path); err != nil {
+		t.Fatalf("seal file without key: %v", err)
+	}
+	if value, _ := SealString("sk-123"); value != "sk-123" {
+		t.Fatalf("SealString without key = %q", value)
+	}
+
+	k, _ := testKeyring(t)
+	SetDefault(k)
+	if err := SealFile(path); err != nil {
+		t.Fatalf("seal file: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !IsSealed(raw) {
+		t.Fatalf("file not sealed: %s", raw)
+	}
+	data, err := ReadFile(path)
+	if err != nil || string(data) != `{"type":"codex"}` {
+		t.Fatalf("read file = %s, %v", data, err)
+	}
+
+	value, err := SealString("sk-123")
+	if err != nil || !IsSealedString(value) {
+		t.Fatalf("seal string = %q, %v", value, err)
+	}
+	if plain, errOpen := OpenString(value); errOpen != nil || plain != "sk-123" {
+		t.Fatalf("open string = %q, %v", plain, errOpen)
+	}
+
+	SetDefault(nil)
+	if _, err = ReadFile(path); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("read sealed file without key: %v", err)
+	}
+}
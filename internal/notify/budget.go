@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+type budgetUsage struct {
+	period   string
+	tokens   int64
+	requests int64
+}
+
+// HandleUsage implements coreusage.Plugin. It counts the record against every matching
+// budget and reports each threshold the usage crosses.
+func (n *Notifier) HandleUsage(_ context.Context, record coreusage.Record) {
+	if n == nil {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+
+	var events []Event
+	n.mu.Lock()
+	for _, budget := range n.budgets {
+		if !budgetMatches(budget, record) {
+			continue
+		}
+		period := periodKey(budget.Period, at)
+		usage := n.usage[budget.Name]
+		if usage == nil || usage.period != period {
+			usage = &budgetUsage{period: period}
+			n.usage[budget.Name] = usage
+		}
+		beforeTokens, beforeRequests := usage.tokens, usage.requests
+		usage.tokens += tokens
+		usage.requests++
+		for _, pct := range budget.Thresholds {
+			if crossed(beforeTokens, usage.tokens, budget.MaxTokens, pct) {
+				events = append(events, budgetEvent(budget, period, "tokens", usage.tokens, budget.MaxTokens, pct))
+			}
+			if crossed(beforeRequests, usage.requests, budget.MaxRequests, pct) {
+				events = append(events, budgetEvent(budget, period, "requests", usage.requests, budget.MaxRequests, pct))
+			}
+		}
+	}
+	n.mu.Unlock()
+
+	for _, event := range events {
+		n.Emit(event)
+	}
+}
+
+func budgetMatches(budget config.NotificationBudget, record coreusage.Record) bool {
+	if budget.Provider != "" && !strings.EqualFold(budget.Provider, record.Provider) {
+		return false
+	}
+	if budget.Model != "" && !wildcard.Match(strings.ToLower(budget.Model), strings.ToLower(record.Model)) {
+		return false
+	}
+	if budget.AuthID != "" && budget.AuthID != record.AuthID {
+		return false
+	}
+	return true
+}
+
+// crossed reports whether usage moved from below pct percent of limit to at or above it.
+func crossed(before, after, limit int64, pct int) bool {
+	if limit <= 0 {
+		return false
+	}
+	mark := limit * int64(pct)
+	return before*100 < mark && after*100 >= mark
+}
+
+func periodKey(period string, at time.Time) string {
+	at = at.Local()
+	switch period {
+	case "hour":
+		return at.Format("2006-01-02T15")
+	case "month":
+		return at.Format("2006-01")
+	default:
+		return at.Format("2006-01-02")
+	}
+}
+
+func budgetEvent(budget config.NotificationBudget, period, metric string, used, limit int64, pct int) Event {
+	severity := SeverityWarning
+	if pct >= 100 {
+		severity = SeverityCritical
+	}
+	return Event{
+		Type:     EventBudgetThreshold,
+		Severity: severity,
+		Title:    fmt.Sprintf("Budget %s reached %d%% of its %s limit", budget.Name, pct, metric),
+		Message:  fmt.Sprintf("%d of %d %s used in %s %s", used, limit, metric, budget.Period, period),
+		Provider: budget.Provider,
+		Model:    budget.Model,
+		AuthID:   budget.AuthID,
+		Fields: map[string]any{
+			"budget":    budget.Name,
+			"period":    period,
+			"metric":    metric,
+			"used":      used,
+			"limit":     limit,
+			"threshold": pct,
+		},
+		Key: fmt.Sprintf("%s|%s|%s|%s|%d", EventBudgetThreshold, budget.Name, period, metric, pct),
+	}
+}
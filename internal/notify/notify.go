@@ -0,0 +1,204 @@
+// Package notify delivers operational events to webhook targets. Events come from
+// the auth manager (refresh failures, unauthorized and quota-exhausted credentials,
+// disabled credentials), the model registry (models added or removed for a provider)
+// and usage budgets. Each target filters events by type, renders them with an optional
+// template, drops repeats inside a deduplication window, batches them and retries
+// failed deliveries with exponential backoff.
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event types.
+const (
+	EventAuthRefreshFailed = "auth.refresh_failed"
+	EventAuthUnauthorized  = "auth.unauthorized"
+	EventAuthDisabled      = "auth.disabled"
+	EventQuotaExceeded     = "quota.exceeded"
+	EventModelsChanged     = "models.changed"
+	EventBudgetThreshold   = "budget.threshold"
+)
+
+// Event severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Event is one operational occurrence delivered to targets.
+type Event struct {
+	Type     string         `json:"type"`
+	Severity string         `json:"severity"`
+	Time     time.Time      `json:"time"`
+	Title    string         `json:"title"`
+	Message  string         `json:"message,omitempty"`
+	Provider string         `json:"provider,omitempty"`
+	AuthID   string         `json:"auth_id,omitempty"`
+	Auth     string         `json:"auth,omitempty"`
+	Model    string         `json:"model,omitempty"`
+	Fields   map[string]any `json:"fields,omitempty"`
+
+	// Key identifies repeats of the same occurrence for deduplication. Empty derives
+	// it from Type, Provider, AuthID and Model.
+	Key string `json:"-"`
+}
+
+func (e Event) dedupKey() string {
+	if e.Key != "" {
+		return e.Key
+	}
+	return strings.Join([]string{e.Type, e.Provider, e.AuthID, e.Model}, "|")
+}
+
+// Notifier fans events out to the configured targets. It also implements the auth,
+// model registry and usage hooks that produce events; see AuthHook, RegistryHook and
+// HandleUsage.
+type Notifier struct {
+	mu      sync.Mutex
+	targets []*target
+	budgets []config.NotificationBudget
+	usage   map[string]*budgetUsage
+
+	auths  map[string]authState
+	models map[string]map[string]int
+	owners map[string]clientModels
+}
+
+// NewNotifier returns a notifier delivering to the targets in cfg.
+func NewNotifier(cfg *config.Config) *Notifier {
+	n := &Notifier{
+		usage:  make(map[string]*budgetUsage),
+		auths:  make(map[string]authState),
+		models: make(map[string]map[string]int),
+		owners: make(map[string]clientModels),
+	}
+	n.Update(cfg)
+	return n
+}
+
+// Update applies a new configuration. Targets whose settings are unchanged keep their
+// queue and deduplication state; removed or changed targets are flushed and stopped.
+func (n *Notifier) Update(cfg *config.Config) {
+	if n == nil {
+		return
+	}
+	var (
+		targetCfgs []config.NotificationTarget
+		budgets    []config.NotificationBudget
+		proxyURL   string
+	)
+	if cfg != nil {
+		targetCfgs = cfg.Notifications.Targets
+		budgets = cfg.Notifications.Budgets
+		proxyURL = cfg.ProxyURL
+	}
+
+	n.mu.Lock()
+	existing := make(map[string]*target, len(n.targets))
+	for _, t := range n.targets {
+		existing[t.fingerprint] = t
+	}
+	next := make([]*target, 0, len(targetCfgs))
+	for _, tc := range targetCfgs {
+		if tc.Disabled {
+			continue
+		}
+		fp := targetFingerprint(tc, proxyURL)
+		if t, ok := existing[fp]; ok {
+			delete(existing, fp)
+			next = append(next, t)
+			continue
+		}
+		t, err := newTarget(tc, proxyURL, fp)
+		if err != nil {
+			log.Warnf("notifications: target %s: %v", tc.Name, err)
+			continue
+		}
+		t.start()
+		next = append(next, t)
+	}
+	n.targets = next
+	n.budgets = budgets
+	n.mu.Unlock()
+
+	for _, t := range existing {
+		t.stop()
+	}
+}
+
+// Enabled reports whether any target receives events.
+func (n *Notifier) Enabled() bool {
+	if n == nil {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.targets) > 0
+}
+
+// Emit queues event for every target subscribed to its type.
+func (n *Notifier) Emit(event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Severity == "" {
+		event.Severity = SeverityInfo
+	}
+	n.mu.Lock()
+	targets := n.targets
+	n.mu.Unlock()
+	for _, t := range targets {
+		t.enqueue(event)
+	}
+}
+
+// Stop flushes pending batches and stops all targets.
+func (n *Notifier) Stop() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	targets := n.targets
+	n.targets = nil
+	n.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			t.stop()
+		}(t)
+	}
+	wg.Wait()
+}
+
+func targetFingerprint(tc config.NotificationTarget, proxyURL string) string {
+	raw, _ := json.Marshal(struct {
+		Target config.NotificationTarget
+		Proxy  string
+	}{tc, proxyURL})
+	return string(raw)
+}
+
+func authDisplay(provider, label, id string) string {
+	name := label
+	if name == "" {
+		name = id
+	}
+	if provider == "" {
+		return name
+	}
+	return fmt.Sprintf("%s %s", provider, name)
+}
@@ -0,0 +1,226 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// webhook records request bodies; the first failures requests answer 503.
+func webhook(t *testing.T, failures int32) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+	bodies := make(chan map[string]any, 16)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("invalid body %s: %v", raw, err)
+		}
+		bodies <- body
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+func newTestNotifier(t *testing.T, targets []config.NotificationTarget, budgets []config.NotificationBudget) *Notifier {
+	t.Helper()
+	cfg := &config.Config{Notifications: config.NotificationsConfig{Targets: targets, Budgets: budgets}}
+	cfg.SanitizeNotifications()
+	n := NewNotifier(cfg)
+	t.Cleanup(n.Stop)
+	return n
+}
+
+func receive(t *testing.T, bodies <-chan map[string]any) map[string]any {
+	t.Helper()
+	select {
+	case body := <-bodies:
+		return body
+	case <-time.After(3 * time.Second):
+		t.Fatal("no delivery")
+		return nil
+	}
+}
+
+func expectNothing(t *testing.T, bodies <-chan map[string]any) {
+	t.Helper()
+	select {
+	case body := <-bodies:
+		t.Fatalf("unexpected delivery %v", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAuthEventsFilterAndDedup(t *testing.T) {
+	srv, bodies := webhook(t, 0)
+	n := newTestNotifier(t, []config.NotificationTarget{{
+		Name:   "ops",
+		Format: "slack",
+		URL:    srv.URL,
+		Events: []string{"auth.*"},
+	}}, nil)
+	hook := n.AuthHook(nil)
+	ctx := context.Background()
+
+	auth := &coreauth.Auth{ID: "a1", Provider: "claude", Label: "team@example.com", Status: coreauth.StatusActive}
+	hook.OnAuthRegistered(ctx, auth)
+	disabled := auth.Clone()
+	disabled.Disabled = true
+	hook.OnAuthUpdated(ctx, disabled)
+	if text := receive(t, bodies)["text"].(string); !strings.Contains(text, "Credential disabled: claude team@example.com") {
+		t.Fatalf("text = %q", text)
+	}
+
+	// Quota events are filtered out; a repeated refresh failure is delivered once.
+	hook.OnResult(ctx, coreauth.Result{AuthID: "a1", Provider: "claude", Error: &coreauth.Error{HTTPStatus: 429, Message: "rate"}})
+	refresher := hook.(coreauth.RefreshFailureHook)
+	refresher.OnRefreshFailed(ctx, auth, errors.New("invalid_grant"))
+	refresher.OnRefreshFailed(ctx, auth, errors.New("invalid_grant"))
+	if text := receive(t, bodies)["text"].(string); !strings.HasPrefix(text, "[CRITICAL] Token refresh failed") || !strings.Contains(text, "invalid_grant") {
+		t.Fatalf("text = %q", text)
+	}
+	expectNothing(t, bodies)
+}
+
+func TestFailedDeliveryIsNotDeduplicated(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	n := newTestNotifier(t, []config.NotificationTarget{{Name: "ops", URL: srv.URL}}, nil)
+
+	n.Emit(Event{Type: EventAuthUnauthorized, AuthID: "a1"})
+	deadline := time.Now().Add(3 * time.Second)
+	for calls.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The rejected delivery is forgotten, so the repeat is sent.
+	time.Sleep(50 * time.Millisecond)
+	n.Emit(Event{Type: EventAuthUnauthorized, AuthID: "a1"})
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("deliveries = %d, want 2", got)
+	}
+}
+
+func TestBatchingRetryAndTemplate(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = time.Second })
+
+	srv, bodies := webhook(t, 2)
+	n := newTestNotifier(t, []config.NotificationTarget{{
+		Format:      "json",
+		URL:         srv.URL,
+		Template:    "{{.Type}} {{upper .Provider}}",
+		BatchWindow: "50ms",
+	}}, nil)
+	n.Emit(Event{Type: EventQuotaExceeded, Provider: "gemini", AuthID: "a"})
+	n.Emit(Event{Type: EventQuotaExceeded, Provider: "codex", AuthID: "b"})
+
+	body := receive(t, bodies)
+	if body["text"] != "quota.exceeded GEMINI\nquota.exceeded CODEX" {
+		t.Fatalf("text = %q", body["text"])
+	}
+	if events := body["events"].([]any); len(events) != 2 {
+		t.Fatalf("events = %v", events)
+	}
+}
+
+func TestTelegramBody(t *testing.T) {
+	srv, bodies := webhook(t, 0)
+	n := newTestNotifier(t, []config.NotificationTarget{{Format: "telegram", URL: srv.URL, ChatID: "-100"}}, nil)
+	n.Emit(Event{Type: EventAuthUnauthorized, Severity: SeverityCritical, Title: "Credential rejected"})
+	body := receive(t, bodies)
+	if body["chat_id"] != "-100" || body["text"] != "[CRITICAL] Credential rejected" {
+		t.Fatalf("body = %v", body)
+	}
+}
+
+func TestPostErrorRedactsBotToken(t *testing.T) {
+	target, err := newTarget(config.NotificationTarget{Name: "tg", Format: "telegram", BotToken: "123:secret-token"}, "", "")
+	if err != nil {
+		t.Fatalf("newTarget: %v", err)
+	}
+	target.url = strings.Replace(target.url, telegramAPIBase, "http://127.0.0.1:1", 1)
+	_, _, errPost := target.post([]byte("{}"))
+	if errPost == nil || strings.Contains(errPost.Error(), "secret-token") {
+		t.Fatalf("post error = %v", errPost)
+	}
+}
+
+func TestBudgetThresholds(t *testing.T) {
+	srv, bodies := webhook(t, 0)
+	n := newTestNotifier(t, []config.NotificationTarget{{Format: "json", URL: srv.URL}}, []config.NotificationBudget{{
+		Name:       "gemini-daily",
+		Provider:   "gemini",
+		MaxTokens:  1000,
+		Thresholds: []int{50, 100},
+	}})
+	record := func(provider string, tokens int64) {
+		n.HandleUsage(context.Background(), coreusage.Record{Provider: provider, RequestedAt: time.Now(), Detail: coreusage.Detail{TotalTokens: tokens}})
+	}
+
+	record("gemini", 400)
+	record("claude", 5000)
+	expectNothing(t, bodies)
+	record("gemini", 200)
+	if text := receive(t, bodies)["text"].(string); !strings.Contains(text, "reached 50% of its tokens limit") {
+		t.Fatalf("text = %q", text)
+	}
+	record("gemini", 600)
+	if text := receive(t, bodies)["text"].(string); !strings.HasPrefix(text, "[CRITICAL] Budget gemini-daily reached 100%") {
+		t.Fatalf("text = %q", text)
+	}
+	record("gemini", 600)
+	expectNothing(t, bodies)
+}
+
+func TestModelsChanged(t *testing.T) {
+	srv, bodies := webhook(t, 0)
+	n := newTestNotifier(t, []config.NotificationTarget{{Format: "json", URL: srv.URL}}, nil)
+	hook := n.RegistryHook(nil)
+	ctx := context.Background()
+	models := func(ids ...string) []*registry.ModelInfo {
+		out := make([]*registry.ModelInfo, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, &registry.ModelInfo{ID: id})
+		}
+		return out
+	}
+
+	hook.OnModelsRegistered(ctx, "claude", "c1", models("opus", "sonnet"))
+	hook.OnModelsRegistered(ctx, "claude", "c2", models("sonnet"))
+	expectNothing(t, bodies)
+
+	// c1 losing sonnet is masked by c2; losing opus is reported.
+	hook.OnModelsRegistered(ctx, "claude", "c1", models("haiku"))
+	if text := receive(t, bodies)["text"].(string); text != "[WARNING] Models changed for claude: added haiku; removed opus" {
+		t.Fatalf("text = %q", text)
+	}
+	hook.OnModelsUnregistered(ctx, "claude", "c2")
+	if text := receive(t, bodies)["text"].(string); !strings.HasSuffix(text, "removed sonnet") {
+		t.Fatalf("text = %q", text)
+	}
+}
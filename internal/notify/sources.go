@@ -0,0 +1,293 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type authState struct {
+	provider string
+	label    string
+	disabled bool
+}
+
+type clientModels struct {
+	provider string
+	models   []string
+}
+
+// AuthHook returns an auth manager hook that reports credential events and then
+// forwards every callback to next.
+func (n *Notifier) AuthHook(next coreauth.Hook) coreauth.Hook {
+	if next == nil {
+		next = coreauth.NoopHook{}
+	}
+	return &authHook{n: n, next: next}
+}
+
+type authHook struct {
+	n    *Notifier
+	next coreauth.Hook
+}
+
+func (h *authHook) OnAuthRegistered(ctx context.Context, auth *coreauth.Auth) {
+	h.n.observeAuth(auth, false)
+	h.next.OnAuthRegistered(ctx, auth)
+}
+
+func (h *authHook) OnAuthUpdated(ctx context.Context, auth *coreauth.Auth) {
+	h.n.observeAuth(auth, true)
+	h.next.OnAuthUpdated(ctx, auth)
+}
+
+func (h *authHook) OnResult(ctx context.Context, result coreauth.Result) {
+	h.n.observeResult(result)
+	h.next.OnResult(ctx, result)
+}
+
+func (h *authHook) OnCandidate(ctx context.Context, candidate coreauth.Candidate) {
+	h.next.OnCandidate(ctx, candidate)
+}
+
+func (h *authHook) OnRefreshFailed(ctx context.Context, auth *coreauth.Auth, err error) {
+	h.n.observeRefreshFailure(auth, err)
+	if next, ok := h.next.(coreauth.RefreshFailureHook); ok {
+		next.OnRefreshFailed(ctx, auth, err)
+	}
+}
+
+// TrackAuths records credentials that are already registered so later updates to them
+// are reported.
+func (n *Notifier) TrackAuths(auths []*coreauth.Auth) {
+	for _, auth := range auths {
+		n.observeAuth(auth, false)
+	}
+}
+
+func authDisabled(auth *coreauth.Auth) bool {
+	return auth.Disabled || auth.Status == coreauth.StatusDisabled
+}
+
+// observeAuth records the credential label and reports transitions into the disabled
+// state. Credentials first seen in an update are only recorded.
+func (n *Notifier) observeAuth(auth *coreauth.Auth, update bool) {
+	if n == nil || auth == nil {
+		return
+	}
+	state := authState{provider: auth.Provider, label: auth.Label, disabled: authDisabled(auth)}
+	n.mu.Lock()
+	prev, known := n.auths[auth.ID]
+	n.auths[auth.ID] = state
+	n.mu.Unlock()
+	if !update || !known || prev.disabled || !state.disabled {
+		return
+	}
+	message := auth.StatusMessage
+	if message == "" {
+		message = "credential is no longer used for requests"
+	}
+	n.Emit(Event{
+		Type:     EventAuthDisabled,
+		Severity: SeverityWarning,
+		Title:    "Credential disabled: " + authDisplay(auth.Provider, auth.Label, auth.ID),
+		Message:  message,
+		Provider: auth.Provider,
+		AuthID:   auth.ID,
+		Auth:     auth.Label,
+	})
+}
+
+func (n *Notifier) observeResult(result coreauth.Result) {
+	if n == nil || result.Success || result.Error == nil {
+		return
+	}
+	var eventType, severity, title string
+	switch result.Error.HTTPStatus {
+	case 401, 402, 403:
+		eventType, severity, title = EventAuthUnauthorized, SeverityCritical, "Credential rejected"
+	case 429:
+		eventType, severity, title = EventQuotaExceeded, SeverityWarning, "Quota exhausted"
+	default:
+		return
+	}
+	label := n.authLabel(result.AuthID)
+	event := Event{
+		Type:     eventType,
+		Severity: severity,
+		Title:    title + ": " + authDisplay(result.Provider, label, result.AuthID),
+		Message:  result.Error.Message,
+		Provider: result.Provider,
+		AuthID:   result.AuthID,
+		Auth:     label,
+		Model:    result.Model,
+		Fields:   map[string]any{"status": result.Error.HTTPStatus},
+	}
+	if result.RetryAfter != nil {
+		event.Fields["retry_after_seconds"] = int64(result.RetryAfter.Seconds())
+	}
+	n.Emit(event)
+}
+
+func (n *Notifier) observeRefreshFailure(auth *coreauth.Auth, err error) {
+	if n == nil || auth == nil || err == nil {
+		return
+	}
+	n.Emit(Event{
+		Type:     EventAuthRefreshFailed,
+		Severity: SeverityCritical,
+		Title:    "Token refresh failed: " + authDisplay(auth.Provider, auth.Label, auth.ID),
+		Message:  err.Error(),
+		Provider: auth.Provider,
+		AuthID:   auth.ID,
+		Auth:     auth.Label,
+	})
+}
+
+func (n *Notifier) authLabel(id string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.auths[id].label
+}
+
+// RegistryHook returns a model registry hook that reports models added to or removed
+// from a provider and then forwards every callback to next, which may be nil. The first
+// models registered for a provider are not reported, so startup stays quiet.
+func (n *Notifier) RegistryHook(next registry.ModelRegistryHook) registry.ModelRegistryHook {
+	return &registryHook{n: n, next: next}
+}
+
+type registryHook struct {
+	n    *Notifier
+	next registry.ModelRegistryHook
+}
+
+func (h *registryHook) OnModelsRegistered(ctx context.Context, provider, clientID string, models []*registry.ModelInfo) {
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		if model != nil && model.ID != "" {
+			ids = append(ids, model.ID)
+		}
+	}
+	h.n.trackModels(provider, clientID, ids)
+	if h.next != nil {
+		h.next.OnModelsRegistered(ctx, provider, clientID, models)
+	}
+}
+
+func (h *registryHook) OnModelsUnregistered(ctx context.Context, provider, clientID string) {
+	h.n.trackModels(provider, clientID, nil)
+	if h.next != nil {
+		h.next.OnModelsUnregistered(ctx, provider, clientID)
+	}
+}
+
+// trackModels replaces the models served by clientID and reports which models the
+// provider gained or lost as a result.
+func (n *Notifier) trackModels(provider, clientID string, models []string) {
+	if n == nil {
+		return
+	}
+	models = dedupe(models)
+	n.mu.Lock()
+	prev := n.owners[clientID]
+	if provider == "" {
+		provider = prev.provider
+	}
+	if prev.provider != "" && prev.provider != provider {
+		// The client moved to another provider; drop its old models silently.
+		old := n.models[prev.provider]
+		for _, id := range prev.models {
+			if old[id]--; old[id] <= 0 {
+				delete(old, id)
+			}
+		}
+		prev = clientModels{}
+	}
+	counts := n.models[provider]
+	if counts == nil {
+		counts = make(map[string]int)
+		n.models[provider] = counts
+	}
+	hadModels := len(counts) > 0
+	var added, removed []string
+	for _, id := range prev.models {
+		if counts[id]--; counts[id] <= 0 {
+			delete(counts, id)
+			removed = append(removed, id)
+		}
+	}
+	for _, id := range models {
+		if counts[id]++; counts[id] == 1 {
+			added = append(added, id)
+		}
+	}
+	if len(models) == 0 {
+		delete(n.owners, clientID)
+	} else {
+		n.owners[clientID] = clientModels{provider: provider, models: models}
+	}
+	n.mu.Unlock()
+
+	added, removed = subtract(added, removed), subtract(removed, added)
+	if !hadModels || (len(added) == 0 && len(removed) == 0) {
+		return
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	severity := SeverityInfo
+	if len(removed) > 0 {
+		severity = SeverityWarning
+	}
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(removed, ", "))
+	}
+	n.Emit(Event{
+		Type:     EventModelsChanged,
+		Severity: severity,
+		Title:    fmt.Sprintf("Models changed for %s", provider),
+		Message:  strings.Join(parts, "; "),
+		Provider: provider,
+		Fields:   map[string]any{"added": added, "removed": removed},
+		Key:      EventModelsChanged + "|" + provider + "|" + strings.Join(added, ",") + "|" + strings.Join(removed, ","),
+	})
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
+
+// subtract returns the values of a that are not in b.
+func subtract(a, b []string) []string {
+	if len(a) == 0 || len(b) == 0 {
+		return a
+	}
+	skip := make(map[string]struct{}, len(b))
+	for _, v := range b {
+		skip[v] = struct{}{}
+	}
+	out := a[:0:0]
+	for _, v := range a {
+		if _, ok := skip[v]; !ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,366 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	queueSize       = 256
+	deliveryTimeout = 15 * time.Second
+	// stopGrace bounds flushing pending events when a target is stopped.
+	stopGrace = 5 * time.Second
+	// maxBatchSize delivers a batch early once it holds this many events.
+	maxBatchSize = 50
+)
+
+// Retry backoff bounds; variables so tests can shorten them.
+var (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+const telegramAPIBase = "https://api.telegram.org"
+
+type target struct {
+	cfg         config.NotificationTarget
+	fingerprint string
+	url         string
+	tmpl        *template.Template
+	batch       time.Duration
+	dedup       time.Duration
+	client      *http.Client
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+
+	queue    chan Event
+	quit     chan struct{}
+	done     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+func newTarget(tc config.NotificationTarget, proxyURL, fingerprint string) (*target, error) {
+	t := &target{
+		cfg:         tc,
+		fingerprint: fingerprint,
+		url:         tc.URL,
+		sent:        make(map[string]time.Time),
+		queue:       make(chan Event, queueSize),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if tc.Format == "telegram" && t.url == "" {
+		t.url = telegramAPIBase + "/bot" + tc.BotToken + "/sendMessage"
+	}
+	if tc.Template != "" {
+		tmpl, err := template.New(tc.Name).Funcs(template.FuncMap{
+			"upper": strings.ToUpper,
+			"lower": strings.ToLower,
+		}).Parse(tc.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		t.tmpl = tmpl
+	}
+	var err error
+	if t.batch, err = parseWindow(tc.BatchWindow); err != nil {
+		return nil, fmt.Errorf("invalid batch-window: %w", err)
+	}
+	if t.dedup, err = parseWindow(tc.DedupWindow); err != nil {
+		return nil, fmt.Errorf("invalid dedup-window: %w", err)
+	}
+	t.client = util.SetProxy(&config.SDKConfig{ProxyURL: proxyURL}, &http.Client{Timeout: deliveryTimeout})
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t, nil
+}
+
+func parseWindow(raw string) (time.Duration, error) {
+	if raw == "" || raw == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
+
+func (t *target) subscribed(eventType string) bool {
+	if len(t.cfg.Events) == 0 {
+		return true
+	}
+	for _, pattern := range t.cfg.Events {
+		if pattern == "*" || wildcard.Match(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicate reports whether event repeats one queued or delivered within the dedup
+// window and records it otherwise. The record is rolled back by forget when the event
+// is dropped or its delivery fails.
+func (t *target) duplicate(event Event) bool {
+	if t.dedup <= 0 {
+		return false
+	}
+	key := event.dedupKey()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.sent[key]; ok && event.Time.Sub(last) < t.dedup {
+		return true
+	}
+	t.sent[key] = event.Time
+	if len(t.sent) > 4*queueSize {
+		for k, at := range t.sent {
+			if event.Time.Sub(at) >= t.dedup {
+				delete(t.sent, k)
+			}
+		}
+	}
+	return false
+}
+
+// forget removes the dedup records of events that were not delivered, so a repeat
+// is sent instead of suppressed.
+func (t *target) forget(events []Event) {
+	if t.dedup <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, event := range events {
+		key := event.dedupKey()
+		if last, ok := t.sent[key]; ok && last.Equal(event.Time) {
+			delete(t.sent, key)
+		}
+	}
+}
+
+func (t *target) enqueue(event Event) {
+	if !t.subscribed(event.Type) || t.duplicate(event) {
+		return
+	}
+	select {
+	case <-t.quit:
+		t.forget([]Event{event})
+	case t.queue <- event:
+	default:
+		t.forget([]Event{event})
+		log.Warnf("notifications: target %s queue is full, dropping %s event", t.cfg.Name, event.Type)
+	}
+}
+
+func (t *target) start() {
+	go t.run()
+}
+
+func (t *target) stop() {
+	t.stopOnce.Do(func() {
+		close(t.quit)
+		select {
+		case <-t.done:
+		case <-time.After(stopGrace):
+			t.cancel()
+			<-t.done
+		}
+		t.cancel()
+	})
+}
+
+func (t *target) run() {
+	defer close(t.done)
+	var (
+		pending []Event
+		timer   *time.Timer
+		timerC  <-chan time.Time
+	)
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+		if len(pending) == 0 {
+			return
+		}
+		t.deliver(pending)
+		pending = nil
+	}
+	for {
+		select {
+		case event := <-t.queue:
+			pending = append(pending, event)
+			if t.batch <= 0 || len(pending) >= maxBatchSize {
+				flush()
+			} else if timer == nil {
+				timer = time.NewTimer(t.batch)
+				timerC = timer.C
+			}
+		case <-timerC:
+			timer, timerC = nil, nil
+			flush()
+		case <-t.quit:
+			for drained := false; !drained; {
+				select {
+				case event := <-t.queue:
+					pending = append(pending, event)
+				default:
+					drained = true
+				}
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// deliver posts events, retrying transport errors, 429 and 5xx responses with
+// exponential backoff.
+func (t *target) deliver(events []Event) {
+	body, err := t.body(events)
+	if err != nil {
+		t.forget(events)
+		log.Errorf("notifications: target %s: %v", t.cfg.Name, err)
+		return
+	}
+	retries := t.cfg.Retries()
+	for attempt := 0; ; attempt++ {
+		wait, retryable, errPost := t.post(body)
+		if errPost == nil {
+			return
+		}
+		if !retryable || attempt >= retries {
+			t.forget(events)
+			log.Warnf("notifications: target %s: delivering %d event(s) failed: %v", t.cfg.Name, len(events), errPost)
+			return
+		}
+		if wait <= 0 {
+			wait = retryBaseDelay << attempt
+		}
+		if wait > retryMaxDelay {
+			wait = retryMaxDelay
+		}
+		log.Debugf("notifications: target %s: retrying in %s after: %v", t.cfg.Name, wait, errPost)
+		select {
+		case <-t.ctx.Done():
+			t.forget(events)
+			log.Warnf("notifications: target %s: dropping %d event(s) on shutdown: %v", t.cfg.Name, len(events), errPost)
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (t *target) post(body []byte) (retryAfter time.Duration, retryable bool, err error) {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, redactURLError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cli-proxy-api")
+	for key, value := range t.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, t.ctx.Err() == nil, redactURLError(err)
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("notifications: close response body: %v", errClose)
+		}
+	}()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, false, nil
+	}
+	err = fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		if seconds, errAtoi := strconv.Atoi(resp.Header.Get("Retry-After")); errAtoi == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return retryAfter, true, err
+	}
+	return 0, false, err
+}
+
+// redactURLError strips the path and query from the URL carried by a *url.Error, since
+// webhook URLs and the Telegram API path embed the receiver's secret.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := "<redacted>"
+	if u, errParse := url.Parse(urlErr.URL); errParse == nil && u.Host != "" {
+		redacted = u.Scheme + "://" + u.Host + "/<redacted>"
+	}
+	return &url.Error{Op: urlErr.Op, URL: redacted, Err: urlErr.Err}
+}
+
+// text renders one event with the target template or the built-in summary.
+func (t *target) text(event Event) string {
+	if t.tmpl != nil {
+		var buf bytes.Buffer
+		errExec := t.tmpl.Execute(&buf, event)
+		if errExec == nil {
+			return buf.String()
+		}
+		log.Warnf("notifications: target %s: template: %v", t.cfg.Name, errExec)
+	}
+	text := fmt.Sprintf("[%s] %s", strings.ToUpper(event.Severity), event.Title)
+	if event.Message != "" {
+		text += ": " + event.Message
+	}
+	return text
+}
+
+func (t *target) body(events []Event) ([]byte, error) {
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, t.text(event))
+	}
+	text := strings.Join(lines, "\n")
+	switch t.cfg.Format {
+	case "slack":
+		return json.Marshal(map[string]any{"text": text})
+	case "telegram":
+		return json.Marshal(map[string]any{
+			"chat_id":                  t.cfg.ChatID,
+			"text":                     text,
+			"disable_web_page_preview": true,
+		})
+	default:
+		type rendered struct {
+			Event
+			Text string `json:"text"`
+		}
+		out := make([]rendered, 0, len(events))
+		for i, event := range events {
+			out = append(out, rendered{Event: event, Text: lines[i]})
+		}
+		return json.Marshal(map[string]any{"text": text, "events": out})
+	}
+}
@@ -0,0 +1,388 @@
+// Package proxypool implements named pools of outbound proxies. Each credential sticks
+// to one healthy member of its pool; connect errors move it to another member, health
+// checks take failed members out of rotation, and per-proxy usage and latency are
+// recorded for the management API.
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// Pool is a named set of proxies with sticky per-credential assignment.
+type Pool struct {
+	name      string
+	providers []string
+	members   []*member
+	cooldown  time.Duration
+
+	checkURL      string
+	checkInterval time.Duration
+	checkTimeout  time.Duration
+
+	mu     sync.Mutex
+	sticky map[string]*member
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// member is one proxy of a pool. Members are shared across config reloads so their
+// health and statistics survive.
+type member struct {
+	raw       string
+	transport *http.Transport
+
+	mu          sync.Mutex
+	healthy     bool
+	failedUntil time.Time
+	lastCheck   time.Time
+	lastError   string
+	assigned    int
+	requests    int64
+	failures    int64
+	failovers   int64
+	latencySum  time.Duration
+	latencyN    int64
+	lastLatency time.Duration
+}
+
+func newMember(raw string) (*member, error) {
+	transport, _, err := proxyutil.BuildHTTPTransport(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &member{raw: raw, transport: transport, healthy: true}, nil
+}
+
+func newPool(cfg config.ProxyPool, previous *Pool) *Pool {
+	p := &Pool{
+		name:          cfg.Name,
+		providers:     append([]string(nil), cfg.Providers...),
+		cooldown:      parseDuration(cfg.FailureCooldown, time.Minute),
+		checkURL:      cfg.HealthCheck.URL,
+		checkInterval: parseDuration(cfg.HealthCheck.Interval, time.Minute),
+		checkTimeout:  parseDuration(cfg.HealthCheck.Timeout, 10*time.Second),
+		sticky:        make(map[string]*member),
+	}
+	reuse := make(map[string]*member)
+	if previous != nil {
+		for _, m := range previous.members {
+			reuse[m.raw] = m
+		}
+	}
+	for _, raw := range cfg.Proxies {
+		if m, ok := reuse[raw]; ok {
+			p.members = append(p.members, m)
+			continue
+		}
+		m, err := newMember(raw)
+		if err != nil {
+			log.Warnf("proxy pool %s: %v", cfg.Name, err)
+			continue
+		}
+		p.members = append(p.members, m)
+	}
+	if previous != nil {
+		kept := make(map[*member]struct{}, len(p.members))
+		for _, m := range p.members {
+			kept[m] = struct{}{}
+		}
+		previous.mu.Lock()
+		for key, m := range previous.sticky {
+			if _, ok := kept[m]; ok {
+				p.sticky[key] = m
+			}
+		}
+		previous.mu.Unlock()
+		for _, m := range previous.members {
+			if _, ok := kept[m]; !ok {
+				m.mu.Lock()
+				m.assigned = 0
+				m.mu.Unlock()
+			}
+		}
+	}
+	return p
+}
+
+func parseDuration(raw string, fallback time.Duration) time.Duration {
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Warnf("proxy pool: invalid duration %q, using %s", raw, fallback)
+		return fallback
+	}
+	return d
+}
+
+// Name returns the pool name.
+func (p *Pool) Name() string { return p.name }
+
+func (m *member) available(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthy && !now.Before(m.failedUntil)
+}
+
+func (m *member) load() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.assigned
+}
+
+// assign returns the member serving key. A key keeps its member while the member is
+// available; otherwise it moves to the available member with the fewest credentials.
+// When every member is down, the member that failed longest ago is used.
+func (p *Pool) assign(key string, skip map[*member]struct{}) *member {
+	if len(p.members) == 0 {
+		return nil
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	current := p.sticky[key]
+	if current != nil {
+		if _, skipped := skip[current]; !skipped && current.available(now) {
+			return current
+		}
+	}
+
+	var best *member
+	bestLoad := 0
+	for _, m := range p.members {
+		if _, skipped := skip[m]; skipped || !m.available(now) {
+			continue
+		}
+		if load := m.load(); best == nil || load < bestLoad {
+			best, bestLoad = m, load
+		}
+	}
+	if best == nil {
+		var bestUntil time.Time
+		for _, m := range p.members {
+			if _, skipped := skip[m]; skipped {
+				continue
+			}
+			m.mu.Lock()
+			failedUntil := m.failedUntil
+			m.mu.Unlock()
+			if best == nil || failedUntil.Before(bestUntil) {
+				best, bestUntil = m, failedUntil
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	if current != best {
+		if current != nil {
+			current.mu.Lock()
+			current.assigned--
+			current.mu.Unlock()
+		}
+		best.mu.Lock()
+		best.assigned++
+		best.mu.Unlock()
+		p.sticky[key] = best
+	}
+	return best
+}
+
+// ProxyURL returns the proxy URL currently assigned to key.
+func (p *Pool) ProxyURL(key string) string {
+	if m := p.assign(key, nil); m != nil {
+		return m.raw
+	}
+	return ""
+}
+
+// RoundTripper returns a transport that sends requests for key through its assigned
+// proxy and fails over to other members on connect errors.
+func (p *Pool) RoundTripper(key string) http.RoundTripper {
+	return &failoverTransport{pool: p, key: key}
+}
+
+type failoverTransport struct {
+	pool *Pool
+	key  string
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tried := make(map[*member]struct{})
+	var lastErr error
+	for len(tried) < len(t.pool.members) {
+		m := t.pool.assign(t.key, tried)
+		if m == nil {
+			break
+		}
+		start := time.Now()
+		resp, err := m.transport.RoundTrip(req)
+		if err == nil {
+			m.recordSuccess(time.Since(start))
+			return resp, nil
+		}
+		lastErr = err
+		if !isConnectError(err) || req.Context().Err() != nil {
+			m.recordFailure(err)
+			return nil, err
+		}
+		m.markDown(err, t.pool.cooldown)
+		tried[m] = struct{}{}
+		if len(tried) >= len(t.pool.members) {
+			break
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, err
+			}
+			body, errBody := req.GetBody()
+			if errBody != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		log.Warnf("proxy pool %s: %s failed to connect (%v), failing over", t.pool.name, proxyutil.Redact(m.raw), err)
+	}
+	if lastErr == nil {
+		lastErr = errors.New("proxy pool " + t.pool.name + " has no proxies")
+	}
+	return nil, lastErr
+}
+
+// isConnectError reports whether err happened while connecting to or through the proxy,
+// before the request reached the upstream, so it is safe to retry on another proxy.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	switch opErr.Op {
+	case "dial", "proxyconnect", "socks connect":
+		return true
+	}
+	return false
+}
+
+func (m *member) recordSuccess(latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.latencySum += latency
+	m.latencyN++
+	m.lastLatency = latency
+}
+
+func (m *member) recordFailure(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.failures++
+	m.lastError = err.Error()
+}
+
+func (m *member) markDown(err error, cooldown time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.failures++
+	m.failovers++
+	m.lastError = err.Error()
+	m.failedUntil = time.Now().Add(cooldown)
+}
+
+// start runs periodic health checks until stopped.
+func (p *Pool) start() {
+	if p.checkInterval <= 0 || len(p.members) == 0 {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.checkInterval)
+		defer ticker.Stop()
+		for {
+			p.Check(context.Background())
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (p *Pool) halt() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop = nil
+}
+
+// Check probes every member of the pool once.
+func (p *Pool) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range p.members {
+		wg.Add(1)
+		go func(m *member) {
+			defer wg.Done()
+			err := p.probe(ctx, m)
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.lastCheck = time.Now()
+			if err != nil {
+				if m.healthy {
+					log.Warnf("proxy pool %s: %s is unhealthy: %v", p.name, proxyutil.Redact(m.raw), err)
+				}
+				m.healthy = false
+				m.lastError = err.Error()
+				return
+			}
+			if !m.healthy {
+				log.Infof("proxy pool %s: %s is healthy again", p.name, proxyutil.Redact(m.raw))
+			}
+			m.healthy = true
+			m.failedUntil = time.Time{}
+		}(m)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) probe(ctx context.Context, m *member) error {
+	ctx, cancel := context.WithTimeout(ctx, p.checkTimeout)
+	defer cancel()
+	if p.checkURL == "" {
+		setting, err := proxyutil.Parse(m.raw)
+		if err != nil {
+			return err
+		}
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", setting.URL.Host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.checkURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,175 @@
+package proxypool
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// deadProxy returns a proxy URL whose port refuses connections.
+func deadProxy(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return "http://" + addr
+}
+
+// liveProxy returns an HTTP proxy that answers every request itself.
+func liveProxy(t *testing.T, name string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, name+" "+r.URL.String())
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func newTestRegistry(t *testing.T, pools ...config.ProxyPool) *Registry {
+	t.Helper()
+	cfg := &config.Config{ProxyPools: pools}
+	cfg.SanitizeProxyPools()
+	r := NewRegistry()
+	r.Update(cfg)
+	t.Cleanup(r.Stop)
+	return r
+}
+
+func TestStickyAssignmentBalancesCredentials(t *testing.T) {
+	r := newTestRegistry(t, config.ProxyPool{
+		Name:        "residential",
+		Proxies:     []string{"http://10.0.0.1:8080", "socks5://10.0.0.2:1080"},
+		HealthCheck: config.ProxyHealthCheck{Interval: "0"},
+	})
+	pool := r.Pool("Residential")
+	a, b := pool.ProxyURL("a"), pool.ProxyURL("b")
+	if a == b {
+		t.Fatalf("both credentials assigned to %s", a)
+	}
+	if again := pool.ProxyURL("a"); again != a {
+		t.Fatalf("assignment moved from %s to %s", a, again)
+	}
+	stats := r.Stats()[0].Proxies
+	if stats[0].Assigned != 1 || stats[1].Assigned != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestFailoverOnConnectError(t *testing.T) {
+	dead, live := deadProxy(t), liveProxy(t, "live")
+	r := newTestRegistry(t, config.ProxyPool{
+		Name:        "pool",
+		Proxies:     []string{dead, live},
+		HealthCheck: config.ProxyHealthCheck{Interval: "0"},
+	})
+	rt, ok := r.RoundTripperFor(&coreauth.Auth{ID: "cred", ProxyURL: "pool:pool"})
+	if !ok {
+		t.Fatal("auth not served by pool")
+	}
+	client := &http.Client{Transport: rt}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "http://upstream.example/v1", strings.NewReader("body"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if !strings.HasPrefix(string(body), "live ") {
+			t.Fatalf("body = %q", body)
+		}
+	}
+
+	stats := r.Stats()[0].Proxies
+	if stats[0].Failovers != 1 || stats[0].CooldownUntil == nil || stats[0].Assigned != 0 {
+		t.Fatalf("dead = %+v", stats[0])
+	}
+	if stats[1].Requests != 2 || stats[1].Failures != 0 || stats[1].Assigned != 1 {
+		t.Fatalf("live = %+v", stats[1])
+	}
+}
+
+func TestHealthCheckMarksProxies(t *testing.T) {
+	dead, live := deadProxy(t), liveProxy(t, "live")
+	r := newTestRegistry(t,
+		config.ProxyPool{Name: "tcp", Proxies: []string{dead, live}, HealthCheck: config.ProxyHealthCheck{Interval: "0"}},
+		config.ProxyPool{Name: "http", Proxies: []string{live}, HealthCheck: config.ProxyHealthCheck{Interval: "0", URL: "http://probe.example/"}},
+	)
+	r.Check(context.Background())
+
+	stats := r.Stats()
+	if tcp := stats[0].Proxies; tcp[0].Healthy || !tcp[1].Healthy || tcp[0].LastCheckedAt == nil {
+		t.Fatalf("tcp = %+v", tcp)
+	}
+	if !stats[1].Proxies[0].Healthy {
+		t.Fatalf("http = %+v", stats[1].Proxies)
+	}
+	// Unhealthy proxies are skipped by new assignments.
+	if got := r.Pool("tcp").ProxyURL("cred"); got != live {
+		t.Fatalf("assigned %s, want %s", got, live)
+	}
+}
+
+func TestResolveAndUpdate(t *testing.T) {
+	pool := config.ProxyPool{
+		Name:        "codex-pool",
+		Proxies:     []string{"http://10.0.0.1:8080"},
+		Providers:   []string{"Codex"},
+		HealthCheck: config.ProxyHealthCheck{Interval: "0"},
+	}
+	r := newTestRegistry(t, pool)
+
+	if p := r.Resolve(&coreauth.Auth{ID: "c", Provider: "codex"}); p == nil || p.Name() != "codex-pool" {
+		t.Fatalf("provider pool = %v", p)
+	}
+	if p := r.Resolve(&coreauth.Auth{ID: "c", Provider: "codex", ProxyURL: "http://own:8080"}); p != nil {
+		t.Fatalf("explicit proxy resolved to pool %s", p.Name())
+	}
+	if p := r.Resolve(&coreauth.Auth{ID: "c", Provider: "codex", ProxyURL: "direct"}); p != nil {
+		t.Fatalf("direct resolved to pool %s", p.Name())
+	}
+	if p := r.Resolve(&coreauth.Auth{ID: "c", Provider: "claude", ProxyURL: "pool:missing"}); p != nil {
+		t.Fatalf("missing pool resolved to %s", p.Name())
+	}
+	if _, ok, err := r.ProxyURLFor(&coreauth.Auth{ID: "c", Provider: "codex"}); !ok || err != nil {
+		t.Fatalf("no proxy for codex credential: %v", err)
+	}
+
+	// A missing pool fails instead of falling back to another route.
+	missing := &coreauth.Auth{ID: "m", Provider: "claude", ProxyURL: "pool:missing"}
+	if _, ok, err := r.ProxyURLFor(missing); !ok || err == nil {
+		t.Fatalf("missing pool proxy: ok=%v err=%v", ok, err)
+	}
+	rt, ok := r.RoundTripperFor(missing)
+	if !ok {
+		t.Fatal("missing pool fell back to another transport")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://upstream.example/", nil)
+	if _, err := rt.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Fatalf("missing pool round trip: %v", err)
+	}
+
+	// Reloading keeps the member's assignment and adds the new proxy.
+	pool.Proxies = append(pool.Proxies, "http://10.0.0.2:8080")
+	cfg := &config.Config{ProxyPools: []config.ProxyPool{pool}}
+	cfg.SanitizeProxyPools()
+	r.Update(cfg)
+	stats := r.Stats()[0].Proxies
+	if len(stats) != 2 || stats[0].Assigned != 1 || stats[1].Assigned != 0 {
+		t.Fatalf("stats after reload = %+v", stats)
+	}
+	if got := r.Pool("codex-pool").ProxyURL("c"); got != "http://10.0.0.1:8080" {
+		t.Fatalf("assignment after reload = %s", got)
+	}
+}
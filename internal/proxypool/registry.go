@@ -0,0 +1,248 @@
+package proxypool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// Registry holds the configured proxy pools.
+type Registry struct {
+	mu         sync.RWMutex
+	pools      map[string]*Pool
+	order      []*Pool
+	byProvider map[string]*Pool
+
+	// missing remembers unknown pool references already logged.
+	missing sync.Map
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the process-wide registry used by executors and the management API.
+func Default() *Registry { return defaultRegistry }
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{pools: make(map[string]*Pool), byProvider: make(map[string]*Pool)}
+}
+
+// Update replaces the pools with those in cfg. Proxies that stay configured keep their
+// health, statistics and credential assignments.
+func (r *Registry) Update(cfg *config.Config) {
+	var pools []config.ProxyPool
+	if cfg != nil {
+		pools = cfg.ProxyPools
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := r.pools
+	r.pools = make(map[string]*Pool, len(pools))
+	r.byProvider = make(map[string]*Pool)
+	r.order = make([]*Pool, 0, len(pools))
+	for _, pc := range pools {
+		key := strings.ToLower(pc.Name)
+		old := previous[key]
+		if old != nil {
+			old.halt()
+			delete(previous, key)
+		}
+		pool := newPool(pc, old)
+		r.pools[key] = pool
+		r.order = append(r.order, pool)
+		for _, provider := range pool.providers {
+			if _, taken := r.byProvider[provider]; taken {
+				log.Warnf("proxy pool %s: provider %s is already served by another pool", pool.name, provider)
+				continue
+			}
+			r.byProvider[provider] = pool
+		}
+		pool.start()
+	}
+	for _, old := range previous {
+		old.halt()
+	}
+}
+
+// Stop halts all health checks.
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, pool := range r.order {
+		pool.halt()
+	}
+}
+
+// Pool returns the pool with the given name.
+func (r *Registry) Pool(name string) *Pool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pools[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// Resolve returns the pool serving auth: the pool its proxy-url references, or the pool
+// assigned to its provider when it has no proxy of its own.
+func (r *Registry) Resolve(auth *coreauth.Auth) *Pool {
+	pool, _ := r.resolve(auth)
+	return pool
+}
+
+// resolve is Resolve that also reports a proxy-url referencing a pool that is not
+// configured. Such credentials must fail rather than fall back to another route.
+func (r *Registry) resolve(auth *coreauth.Auth) (*Pool, error) {
+	if r == nil || auth == nil {
+		return nil, nil
+	}
+	setting, _ := proxyutil.Parse(auth.ProxyURL)
+	switch setting.Mode {
+	case proxyutil.ModePool:
+		pool := r.Pool(setting.Pool)
+		if pool == nil {
+			if _, logged := r.missing.LoadOrStore(strings.ToLower(setting.Pool), struct{}{}); !logged {
+				log.Warnf("proxy pool %s referenced by %s is not configured", setting.Pool, auth.ID)
+			}
+			return nil, fmt.Errorf("proxy pool %s is not configured", setting.Pool)
+		}
+		return pool, nil
+	case proxyutil.ModeInherit:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.byProvider[strings.ToLower(strings.TrimSpace(auth.Provider))], nil
+	default:
+		return nil, nil
+	}
+}
+
+// RoundTripperFor returns the failover transport for auth when it is served by a pool.
+// When auth references a pool that is not configured, the returned transport fails
+// every request.
+func (r *Registry) RoundTripperFor(auth *coreauth.Auth) (http.RoundTripper, bool) {
+	pool, err := r.resolve(auth)
+	if err != nil {
+		return failingTransport{err: err}, true
+	}
+	if pool == nil {
+		return nil, false
+	}
+	return pool.RoundTripper(auth.ID), true
+}
+
+// ProxyURLFor returns the proxy assigned to auth when it is served by a pool, for
+// connections that cannot use a transport, such as websockets. The error is set when
+// auth references a pool that is not configured or has no usable proxy.
+func (r *Registry) ProxyURLFor(auth *coreauth.Auth) (string, bool, error) {
+	pool, err := r.resolve(auth)
+	if err != nil {
+		return "", true, err
+	}
+	if pool == nil {
+		return "", false, nil
+	}
+	proxyURL := pool.ProxyURL(auth.ID)
+	if proxyURL == "" {
+		return "", true, fmt.Errorf("proxy pool %s has no proxies", pool.Name())
+	}
+	return proxyURL, true, nil
+}
+
+// failingTransport rejects every request with err.
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, t.err
+}
+
+// Check probes every proxy of every pool once.
+func (r *Registry) Check(ctx context.Context) {
+	r.mu.RLock()
+	pools := append([]*Pool(nil), r.order...)
+	r.mu.RUnlock()
+	for _, pool := range pools {
+		pool.Check(ctx)
+	}
+}
+
+// PoolStats describes one pool for the management API.
+type PoolStats struct {
+	Name        string       `json:"name"`
+	Providers   []string     `json:"providers,omitempty"`
+	HealthCheck bool         `json:"health_check"`
+	Proxies     []ProxyStats `json:"proxies"`
+}
+
+// ProxyStats describes one proxy of a pool. URLs have their password redacted.
+type ProxyStats struct {
+	URL           string     `json:"url"`
+	Healthy       bool       `json:"healthy"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+	Assigned      int        `json:"assigned"`
+	Requests      int64      `json:"requests"`
+	Failures      int64      `json:"failures"`
+	Failovers     int64      `json:"failovers"`
+	AvgLatencyMs  int64      `json:"avg_latency_ms"`
+	LastLatencyMs int64      `json:"last_latency_ms"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+}
+
+// Stats returns a snapshot of every pool.
+func (r *Registry) Stats() []PoolStats {
+	r.mu.RLock()
+	pools := append([]*Pool(nil), r.order...)
+	r.mu.RUnlock()
+	now := time.Now()
+	out := make([]PoolStats, 0, len(pools))
+	for _, pool := range pools {
+		stats := PoolStats{
+			Name:        pool.name,
+			Providers:   pool.providers,
+			HealthCheck: pool.checkInterval > 0,
+			Proxies:     make([]ProxyStats, 0, len(pool.members)),
+		}
+		for _, m := range pool.members {
+			stats.Proxies = append(stats.Proxies, m.stats(now))
+		}
+		out = append(out, stats)
+	}
+	return out
+}
+
+func (m *member) stats(now time.Time) ProxyStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := ProxyStats{
+		URL:           proxyutil.Redact(m.raw),
+		Healthy:       m.healthy,
+		Assigned:      m.assigned,
+		Requests:      m.requests,
+		Failures:      m.failures,
+		Failovers:     m.failovers,
+		LastLatencyMs: m.lastLatency.Milliseconds(),
+		LastError:     m.lastError,
+	}
+	if m.latencyN > 0 {
+		s.AvgLatencyMs = (m.latencySum / time.Duration(m.latencyN)).Milliseconds()
+	}
+	if now.Before(m.failedUntil) {
+		until := m.failedUntil
+		s.CooldownUntil = &until
+	}
+	if !m.lastCheck.IsZero() {
+		checked := m.lastCheck
+		s.LastCheckedAt = &checked
+	}
+	return s
+}
@@ -0,0 +1,426 @@
+// Package remotemedia downloads http(s) image and document URLs referenced by client
+// requests so they can be inlined as base64 data for backends that cannot fetch
+// remote content themselves. Downloads are bounded by size, content type and host
+// policy, and recent results are kept in a small LRU cache.
+package remotemedia
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultMaxSizeMB      = 20
+	defaultTimeoutSeconds = 15
+	defaultCacheEntries   = 64
+)
+
+var defaultAllowedContentTypes = []string{"image/", "application/pdf"}
+
+// Media is a downloaded remote resource.
+type Media struct {
+	MimeType string
+	Data     []byte
+}
+
+// FetchError reports a download that was refused or failed. It maps to HTTP 400
+// because the offending URL came from the client request.
+type FetchError struct {
+	URL    string
+	Reason string
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("failed to fetch %s: %s", e.URL, e.Reason)
+}
+
+// StatusCode implements the status-code interface consumed by the API handlers.
+func (e *FetchError) StatusCode() int { return http.StatusBadRequest }
+
+// Fetcher downloads remote media according to a RemoteMediaConfig.
+// It is safe for concurrent use and may be reconfigured at runtime.
+type Fetcher struct {
+	mu       sync.RWMutex
+	cfg      config.RemoteMediaConfig
+	proxyURL string
+	client   *http.Client
+	// proxied is set when downloads go through a proxy, where the dialer cannot see
+	// the resolved upstream address.
+	proxied bool
+
+	cacheMu sync.Mutex
+	cache   map[string]*list.Element
+	order   *list.List
+}
+
+type cacheEntry struct {
+	url   string
+	media Media
+}
+
+// NewFetcher creates a fetcher for the given configuration and outbound proxy.
+func NewFetcher(cfg config.RemoteMediaConfig, proxyURL string) *Fetcher {
+	f := &Fetcher{
+		cache: make(map[string]*list.Element),
+		order: list.New(),
+	}
+	f.Update(cfg, proxyURL)
+	return f
+}
+
+// Update swaps the active configuration while keeping cached downloads.
+func (f *Fetcher) Update(cfg config.RemoteMediaConfig, proxyURL string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.client != nil && f.proxyURL == proxyURL && f.cfg.AllowPrivateNetworks == cfg.AllowPrivateNetworks {
+		f.cfg = cfg
+		f.trimCache()
+		return
+	}
+	f.cfg = cfg
+	f.proxyURL = proxyURL
+	f.client, f.proxied = f.buildClient(proxyURL, cfg.AllowPrivateNetworks)
+	f.trimCache()
+}
+
+// Enabled reports whether remote fetching is turned on.
+func (f *Fetcher) Enabled() bool {
+	if f == nil {
+		return false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.cfg.Enable
+}
+
+// Fetch downloads rawURL, enforcing host policy, size limit and allowed content types.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Media, error) {
+	f.mu.RLock()
+	cfg := f.cfg
+	client := f.client
+	f.mu.RUnlock()
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return Media{}, &FetchError{URL: rawURL, Reason: "only absolute http(s) URLs are supported"}
+	}
+	if err = f.checkTarget(ctx, parsed); err != nil {
+		return Media{}, &FetchError{URL: rawURL, Reason: err.Error()}
+	}
+
+	allowed := cfg.AllowedContentTypes
+	if len(allowed) == 0 {
+		allowed = defaultAllowedContentTypes
+	}
+	maxBytes := int64(cfg.MaxSizeMB) << 20
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxSizeMB << 20
+	}
+	if media, ok := f.cached(rawURL); ok && int64(len(media.Data)) <= maxBytes && contentTypeAllowed(media.MimeType, allowed) {
+		return media, nil
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeoutSeconds * time.Second
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return Media{}, &FetchError{URL: rawURL, Reason: err.Error()}
+	}
+	req.Header.Set("Accept", "image/*, application/pdf;q=0.9, */*;q=0.5")
+	resp, err := client.Do(req)
+	if err != nil {
+		return Media{}, &FetchError{URL: rawURL, Reason: describeTransportError(err)}
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Debugf("remote media: close body error: %v", errClose)
+		}
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Media{}, &FetchError{URL: rawURL, Reason: fmt.Sprintf("upstream returned status %d", resp.StatusCode)}
+	}
+
+	if resp.ContentLength > maxBytes {
+		return Media{}, &FetchError{URL: rawURL, Reason: fmt.Sprintf("content exceeds %d bytes", maxBytes)}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return Media{}, &FetchError{URL: rawURL, Reason: describeTransportError(err)}
+	}
+	if int64(len(data)) > maxBytes {
+		return Media{}, &FetchError{URL: rawURL, Reason: fmt.Sprintf("content exceeds %d bytes", maxBytes)}
+	}
+
+	mimeType := ""
+	if declared := resp.Header.Get("Content-Type"); declared != "" {
+		if parsedType, _, errParse := mime.ParseMediaType(declared); errParse == nil {
+			mimeType = strings.ToLower(parsedType)
+		}
+	}
+	if mimeType == "" || mimeType == "application/octet-stream" || mimeType == "binary/octet-stream" {
+		mimeType = strings.ToLower(strings.Split(http.DetectContentType(data), ";")[0])
+	}
+	if !contentTypeAllowed(mimeType, allowed) {
+		return Media{}, &FetchError{URL: rawURL, Reason: fmt.Sprintf("content type %s is not allowed", mimeType)}
+	}
+
+	media := Media{MimeType: mimeType, Data: data}
+	f.store(rawURL, media)
+	return media, nil
+}
+
+func (f *Fetcher) cacheLimit() int {
+	if f.cfg.CacheEntries > 0 {
+		return f.cfg.CacheEntries
+	}
+	return defaultCacheEntries
+}
+
+func (f *Fetcher) cached(rawURL string) (Media, bool) {
+	f.cacheMu.Lock()
+	defer f.cacheMu.Unlock()
+	elem, ok := f.cache[rawURL]
+	if !ok {
+		return Media{}, false
+	}
+	f.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).media, true
+}
+
+func (f *Fetcher) store(rawURL string, media Media) {
+	f.mu.RLock()
+	limit := f.cacheLimit()
+	f.mu.RUnlock()
+
+	f.cacheMu.Lock()
+	defer f.cacheMu.Unlock()
+	if elem, ok := f.cache[rawURL]; ok {
+		elem.Value.(*cacheEntry).media = media
+		f.order.MoveToFront(elem)
+		return
+	}
+	f.cache[rawURL] = f.order.PushFront(&cacheEntry{url: rawURL, media: media})
+	f.evictLocked(limit)
+}
+
+// trimCache must be called with f.mu held.
+func (f *Fetcher) trimCache() {
+	limit := f.cacheLimit()
+	f.cacheMu.Lock()
+	defer f.cacheMu.Unlock()
+	f.evictLocked(limit)
+}
+
+func (f *Fetcher) evictLocked(limit int) {
+	for f.order.Len() > limit {
+		oldest := f.order.Back()
+		if oldest == nil {
+			return
+		}
+		f.order.Remove(oldest)
+		delete(f.cache, oldest.Value.(*cacheEntry).url)
+	}
+}
+
+// checkTarget applies the host policy to the initial URL and every redirect target.
+// Direct connections are also checked after DNS resolution by denyPrivateControl;
+// through a proxy the name is resolved locally instead, and names that do not resolve
+// here are left to the proxy.
+func (f *Fetcher) checkTarget(ctx context.Context, target *url.URL) error {
+	f.mu.RLock()
+	cfg := f.cfg
+	proxied := f.proxied
+	f.mu.RUnlock()
+
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return errors.New("only absolute http(s) URLs are supported")
+	}
+	host := strings.ToLower(target.Hostname())
+	if !hostAllowed(host, cfg.AllowHosts, cfg.DenyHosts) {
+		return errors.New("host is not allowed")
+	}
+	if cfg.AllowPrivateNetworks {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if isPrivateIP(ip) {
+			return errPrivateAddress
+		}
+		return nil
+	}
+	if proxied {
+		addrs, errLookup := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if errLookup != nil {
+			return nil
+		}
+		for _, addr := range addrs {
+			if isPrivateIP(addr.IP) {
+				return errPrivateAddress
+			}
+		}
+	}
+	return nil
+}
+
+// buildClient returns the download client and whether it connects through a proxy.
+func (f *Fetcher) buildClient(proxyURL string, allowPrivate bool) (*http.Client, bool) {
+	transport, mode, errBuild := proxyutil.BuildHTTPTransport(proxyURL)
+	if errBuild != nil {
+		log.Errorf("remote media: %v", errBuild)
+	}
+	proxied := mode == proxyutil.ModeProxy && transport != nil
+	if !proxied {
+		if mode == proxyutil.ModeDirect {
+			transport = proxyutil.NewDirectTransport()
+		} else if base, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport = base.Clone()
+		} else {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+		if transport.Proxy != nil && environmentProxyConfigured() {
+			// The dialer reaches the environment proxy rather than the target, so
+			// targets are vetted by resolving them in checkTarget instead.
+			proxied = true
+		} else if !allowPrivate {
+			dialer := &net.Dialer{Timeout: 10 * time.Second, Control: denyPrivateControl}
+			transport.DialContext = dialer.DialContext
+		}
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return f.checkTarget(req.Context(), req.URL)
+		},
+	}, proxied
+}
+
+// denyPrivateControl rejects connections to private addresses after DNS resolution,
+// which also covers redirects and DNS rebinding.
+func denyPrivateControl(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// environmentProxyConfigured reports whether HTTP(S)_PROXY routes downloads through a proxy.
+func environmentProxyConfigured() bool {
+	env := httpproxy.FromEnvironment()
+	return env.HTTPProxy != "" || env.HTTPSProxy != ""
+}
+
+var errPrivateAddress = errors.New("private network addresses are not allowed")
+
+// internalRanges are not reported by net.IP as private but still reach internal hosts:
+// carrier-grade NAT space and the NAT64 well-known prefix.
+var internalRanges = []*net.IPNet{
+	{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(10, 32)},
+	{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)},
+}
+
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range internalRanges {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTransportError(err error) string {
+	if errors.Is(err, errPrivateAddress) {
+		return errPrivateAddress.Error()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "download timed out"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "download timed out"
+	}
+	return err.Error()
+}
+
+// hostAllowed applies deny then allow lists. Entries match the host exactly or,
+// with a "*." prefix, any subdomain.
+func hostAllowed(host string, allow, deny []string) bool {
+	for _, pattern := range deny {
+		if hostMatches(host, pattern) {
+			return false
+		}
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	for _, pattern := range allow {
+		if hostMatches(host, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostMatches(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == suffix || strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+func contentTypeAllowed(mimeType string, allowed []string) bool {
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.HasSuffix(entry, "/") || strings.HasSuffix(entry, "/*") {
+			if strings.HasPrefix(mimeType, strings.TrimSuffix(entry, "*")) {
+				return true
+			}
+			continue
+		}
+		if mimeType == entry {
+			return true
+		}
+	}
+	return false
+}
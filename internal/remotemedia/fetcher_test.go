@@ -0,0 +1,189 @@
+package remotemedia
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+var pngBytes = []byte("\x89PNG\r\n\x1a\n0000")
+
+func newMediaServer(t *testing.T, hits *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(pngBytes)
+		case "/doc.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/redirect":
+			_, port, _ := strings.Cut(r.Host, ":")
+			http.Redirect(w, r, "http://localhost:"+port+"/cat.png", http.StatusFound)
+		case "/big.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(make([]byte, 2<<20))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInlineOpenAIChatImageURL(t *testing.T) {
+	var hits int32
+	srv := newMediaServer(t, &hits)
+	f := NewFetcher(config.RemoteMediaConfig{Enable: true, AllowPrivateNetworks: true}, "")
+
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"` + srv.URL + `/cat.png"}}]}]}`)
+	out, err := f.InlineRemoteURLs(context.Background(), "openai", payload)
+	if err != nil {
+		t.Fatalf("InlineRemoteURLs error: %v", err)
+	}
+	got := gjson.GetBytes(out, "messages.0.content.1.image_url.url").String()
+	if !strings.HasPrefix(got, "data:image/png;base64,") {
+		t.Fatalf("image url = %q, want data URL", got)
+	}
+
+	// A second request for the same URL is served from the cache.
+	if _, err = f.InlineRemoteURLs(context.Background(), "openai", payload); err != nil {
+		t.Fatalf("second InlineRemoteURLs error: %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("server hits = %d, want 1", hits)
+	}
+}
+
+func TestInlineClaudeDocumentURL(t *testing.T) {
+	var hits int32
+	srv := newMediaServer(t, &hits)
+	f := NewFetcher(config.RemoteMediaConfig{Enable: true, AllowPrivateNetworks: true}, "")
+
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"url","url":"` + srv.URL + `/doc.pdf"}}]}]}`)
+	out, err := f.InlineRemoteURLs(context.Background(), "claude", payload)
+	if err != nil {
+		t.Fatalf("InlineRemoteURLs error: %v", err)
+	}
+	source := gjson.GetBytes(out, "messages.0.content.0.source")
+	if source.Get("type").String() != "base64" || source.Get("media_type").String() != "application/pdf" {
+		t.Fatalf("unexpected source: %s", source.Raw)
+	}
+	if source.Get("url").Exists() {
+		t.Fatalf("url should be removed: %s", source.Raw)
+	}
+}
+
+func TestFetchRejections(t *testing.T) {
+	var hits int32
+	srv := newMediaServer(t, &hits)
+
+	cases := []struct {
+		name   string
+		cfg    config.RemoteMediaConfig
+		path   string
+		reason string
+	}{
+		{name: "content type", cfg: config.RemoteMediaConfig{AllowPrivateNetworks: true}, path: "/page.html", reason: "content type text/html"},
+		{name: "size", cfg: config.RemoteMediaConfig{AllowPrivateNetworks: true, MaxSizeMB: 1}, path: "/big.png", reason: "exceeds"},
+		{name: "status", cfg: config.RemoteMediaConfig{AllowPrivateNetworks: true}, path: "/missing.png", reason: "status 404"},
+		{name: "deny host", cfg: config.RemoteMediaConfig{AllowPrivateNetworks: true, DenyHosts: []string{"127.0.0.1"}}, path: "/cat.png", reason: "host is not allowed"},
+		{name: "allow list", cfg: config.RemoteMediaConfig{AllowPrivateNetworks: true, AllowHosts: []string{"*.example.com"}}, path: "/cat.png", reason: "host is not allowed"},
+		{name: "private", cfg: config.RemoteMediaConfig{}, path: "/cat.png", reason: "private network"},
+		{name: "redirect to denied host", cfg: config.RemoteMediaConfig{AllowPrivateNetworks: true, DenyHosts: []string{"localhost"}}, path: "/redirect", reason: "host is not allowed"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Enable = true
+			f := NewFetcher(tc.cfg, "")
+			_, err := f.Fetch(context.Background(), srv.URL+tc.path)
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) {
+				t.Fatalf("expected FetchError, got %v", err)
+			}
+			if fetchErr.StatusCode() != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", fetchErr.StatusCode())
+			}
+			if !strings.Contains(fetchErr.Error(), tc.reason) {
+				t.Fatalf("error %q does not mention %q", fetchErr.Error(), tc.reason)
+			}
+		})
+	}
+}
+
+func TestFetchThroughProxyChecksResolvedAddress(t *testing.T) {
+	var hits int32
+	proxy := newMediaServer(t, &hits)
+	f := NewFetcher(config.RemoteMediaConfig{Enable: true}, proxy.URL)
+	_, err := f.Fetch(context.Background(), "http://localhost/cat.png")
+	if err == nil || !strings.Contains(err.Error(), "private network") {
+		t.Fatalf("err = %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Fatal("request reached the proxy")
+	}
+}
+
+func TestInlineDisabledLeavesPayload(t *testing.T) {
+	f := NewFetcher(config.RemoteMediaConfig{}, "")
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`)
+	out, err := f.InlineRemoteURLs(context.Background(), "openai", payload)
+	if err != nil || string(out) != string(payload) {
+		t.Fatalf("disabled fetcher modified payload: %s, %v", out, err)
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	if !hostAllowed("img.example.com", []string{"*.example.com"}, nil) {
+		t.Fatal("wildcard allow should match subdomain")
+	}
+	if hostAllowed("img.example.com", []string{"*.example.com"}, []string{"img.example.com"}) {
+		t.Fatal("deny should win over allow")
+	}
+	if hostAllowed("example.org", []string{"example.com"}, nil) {
+		t.Fatal("host outside allow list should be rejected")
+	}
+}
+
+func TestFetchThroughEnvironmentProxyChecksResolvedAddress(t *testing.T) {
+	var hits int32
+	proxy := newMediaServer(t, &hits)
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	f := NewFetcher(config.RemoteMediaConfig{Enable: true}, "")
+	if !f.proxied {
+		t.Fatal("environment proxy not treated as a proxy")
+	}
+	_, err := f.Fetch(context.Background(), "http://localhost/cat.png")
+	if err == nil || !strings.Contains(err.Error(), "private network") {
+		t.Fatalf("err = %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Fatal("request reached the proxy")
+	}
+}
+
+func TestIsPrivateIPCoversSharedRanges(t *testing.T) {
+	for _, addr := range []string{"100.64.0.1", "100.127.255.254", "64:ff9b::a00:1", "10.0.0.1", "::1"} {
+		if !isPrivateIP(net.ParseIP(addr)) {
+			t.Fatalf("%s should be private", addr)
+		}
+	}
+	for _, addr := range []string{"100.128.0.1", "8.8.8.8", "2001:4860:4860::8888"} {
+		if isPrivateIP(net.ParseIP(addr)) {
+			t.Fatalf("%s should be public", addr)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package remotemedia
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// inlineProviders lists backends that reject remote URLs and need base64 inline data.
+var inlineProviders = map[string]struct{}{
+	"gemini":      {},
+	"gemini-cli":  {},
+	"vertex":      {},
+	"aistudio":    {},
+	"antigravity": {},
+}
+
+// NeedsInline reports whether any of the candidate providers requires inline media.
+func NeedsInline(providers []string) bool {
+	for _, provider := range providers {
+		if _, ok := inlineProviders[strings.ToLower(strings.TrimSpace(provider))]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// InlineRemoteURLs replaces http(s) image and file references in a request payload of the
+// given source format with base64 data. Payloads without remote references are returned
+// unchanged. Any download failure aborts the rewrite with a *FetchError.
+func (f *Fetcher) InlineRemoteURLs(ctx context.Context, format string, payload []byte) ([]byte, error) {
+	if f == nil || !f.Enabled() || len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return payload, nil
+	}
+	switch format {
+	case "openai":
+		return f.inlineOpenAIChat(ctx, payload)
+	case "openai-response":
+		return f.inlineOpenAIResponses(ctx, payload)
+	case "claude":
+		return f.inlineClaude(ctx, payload)
+	case "gemini":
+		return f.inlineGemini(ctx, payload, "contents")
+	case "gemini-cli":
+		return f.inlineGemini(ctx, payload, "request.contents")
+	default:
+		return payload, nil
+	}
+}
+
+func (f *Fetcher) inlineOpenAIChat(ctx context.Context, payload []byte) ([]byte, error) {
+	var err error
+	gjson.GetBytes(payload, "messages").ForEach(func(msgIdx, msg gjson.Result) bool {
+		msg.Get("content").ForEach(func(partIdx, part gjson.Result) bool {
+			if part.Get("type").String() != "image_url" {
+				return true
+			}
+			base := fmt.Sprintf("messages.%d.content.%d.image_url", msgIdx.Int(), partIdx.Int())
+			rawURL := part.Get("image_url.url").String()
+			path := base + ".url"
+			if part.Get("image_url").Type == gjson.String {
+				rawURL = part.Get("image_url").String()
+				path = base
+			}
+			payload, err = f.replaceWithDataURL(ctx, payload, path, rawURL)
+			return err == nil
+		})
+		return err == nil
+	})
+	return payload, err
+}
+
+func (f *Fetcher) inlineOpenAIResponses(ctx context.Context, payload []byte) ([]byte, error) {
+	var err error
+	gjson.GetBytes(payload, "input").ForEach(func(itemIdx, item gjson.Result) bool {
+		item.Get("content").ForEach(func(partIdx, part gjson.Result) bool {
+			base := fmt.Sprintf("input.%d.content.%d", itemIdx.Int(), partIdx.Int())
+			switch part.Get("type").String() {
+			case "input_image":
+				path := base + ".image_url"
+				rawURL := part.Get("image_url").String()
+				if part.Get("image_url.url").Exists() {
+					path += ".url"
+					rawURL = part.Get("image_url.url").String()
+				}
+				payload, err = f.replaceWithDataURL(ctx, payload, path, rawURL)
+			case "input_file":
+				rawURL := part.Get("file_url").String()
+				if !isRemoteURL(rawURL) {
+					return true
+				}
+				var media Media
+				if media, err = f.Fetch(ctx, rawURL); err != nil {
+					return false
+				}
+				payload, _ = sjson.SetBytes(payload, base+".file_data", dataURL(media))
+				payload, _ = sjson.DeleteBytes(payload, base+".file_url")
+				if !part.Get("filename").Exists() {
+					payload, _ = sjson.SetBytes(payload, base+".filename", fileNameFromURL(rawURL))
+				}
+			}
+			return err == nil
+		})
+		return err == nil
+	})
+	return payload, err
+}
+
+func (f *Fetcher) inlineClaude(ctx context.Context, payload []byte) ([]byte, error) {
+	var err error
+	inlineBlock := func(path string, block gjson.Result) {
+		switch block.Get("type").String() {
+		case "image", "document":
+		default:
+			return
+		}
+		if block.Get("source.type").String() != "url" {
+			return
+		}
+		rawURL := block.Get("source.url").String()
+		if !isRemoteURL(rawURL) {
+			return
+		}
+		var media Media
+		if media, err = f.Fetch(ctx, rawURL); err != nil {
+			return
+		}
+		source := []byte(`{"type":"base64","media_type":"","data":""}`)
+		source, _ = sjson.SetBytes(source, "media_type", media.MimeType)
+		source, _ = sjson.SetBytes(source, "data", base64.StdEncoding.EncodeToString(media.Data))
+		payload, _ = sjson.SetRawBytes(payload, path+".source", source)
+	}
+
+	gjson.GetBytes(payload, "messages").ForEach(func(msgIdx, msg gjson.Result) bool {
+		msg.Get("content").ForEach(func(blockIdx, block gjson.Result) bool {
+			path := fmt.Sprintf("messages.%d.content.%d", msgIdx.Int(), blockIdx.Int())
+			inlineBlock(path, block)
+			if err == nil && block.Get("type").String() == "tool_result" {
+				block.Get("content").ForEach(func(innerIdx, inner gjson.Result) bool {
+					inlineBlock(fmt.Sprintf("%s.content.%d", path, innerIdx.Int()), inner)
+					return err == nil
+				})
+			}
+			return err == nil
+		})
+		return err == nil
+	})
+	return payload, err
+}
+
+func (f *Fetcher) inlineGemini(ctx context.Context, payload []byte, contentsPath string) ([]byte, error) {
+	var err error
+	gjson.GetBytes(payload, contentsPath).ForEach(func(contentIdx, content gjson.Result) bool {
+		content.Get("parts").ForEach(func(partIdx, part gjson.Result) bool {
+			fileData := part.Get("fileData")
+			if !fileData.Exists() {
+				fileData = part.Get("file_data")
+			}
+			rawURL := fileData.Get("fileUri").String()
+			if rawURL == "" {
+				rawURL = fileData.Get("file_uri").String()
+			}
+			if !isRemoteURL(rawURL) || isGeminiNativeURI(rawURL) {
+				return true
+			}
+			var media Media
+			if media, err = f.Fetch(ctx, rawURL); err != nil {
+				return false
+			}
+			path := fmt.Sprintf("%s.%d.parts.%d", contentsPath, contentIdx.Int(), partIdx.Int())
+			inline := []byte(`{"mimeType":"","data":""}`)
+			inline, _ = sjson.SetBytes(inline, "mimeType", media.MimeType)
+			inline, _ = sjson.SetBytes(inline, "data", base64.StdEncoding.EncodeToString(media.Data))
+			payload, _ = sjson.DeleteBytes(payload, path+".fileData")
+			payload, _ = sjson.DeleteBytes(payload, path+".file_data")
+			payload, _ = sjson.SetRawBytes(payload, path+".inlineData", inline)
+			return true
+		})
+		return err == nil
+	})
+	return payload, err
+}
+
+func (f *Fetcher) replaceWithDataURL(ctx context.Context, payload []byte, path, rawURL string) ([]byte, error) {
+	if !isRemoteURL(rawURL) {
+		return payload, nil
+	}
+	media, err := f.Fetch(ctx, rawURL)
+	if err != nil {
+		return payload, err
+	}
+	updated, errSet := sjson.SetBytes(payload, path, dataURL(media))
+	if errSet != nil {
+		return payload, nil
+	}
+	return updated, nil
+}
+
+func dataURL(media Media) string {
+	return fmt.Sprintf("data:%s;base64,%s", media.MimeType, base64.StdEncoding.EncodeToString(media.Data))
+}
+
+func isRemoteURL(raw string) bool {
+	lower := strings.ToLower(strings.TrimSpace(raw))
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// isGeminiNativeURI reports URIs Gemini resolves on its own (uploaded files and YouTube).
+func isGeminiNativeURI(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case host == "generativelanguage.googleapis.com":
+		return true
+	case host == "youtube.com" || host == "youtu.be" || strings.HasSuffix(host, ".youtube.com"):
+		return true
+	}
+	return false
+}
+
+func fileNameFromURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "file"
+	}
+	segments := strings.Split(strings.TrimRight(parsed.Path, "/"), "/")
+	if name := segments[len(segments)-1]; name != "" {
+		return name
+	}
+	return "file"
+}
@@ -0,0 +1,421 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	azureauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/azure"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AzureOpenAIExecutor executes requests against Azure OpenAI deployments. Chat-style
+// clients use /openai/deployments/{deployment}/chat/completions; Responses API clients
+// use /openai/responses with the deployment as model.
+type AzureOpenAIExecutor struct {
+	cfg    *config.Config
+	tokens *azureauth.TokenCache
+}
+
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	return &AzureOpenAIExecutor{cfg: cfg, tokens: azureauth.DefaultTokenCache}
+}
+
+func (e *AzureOpenAIExecutor) Identifier() string { return "azure-openai" }
+
+// azureTarget holds the resolved endpoint and credentials for one auth.
+type azureTarget struct {
+	baseURL             string
+	apiVersion          string
+	responsesAPIVersion string
+	apiKey              string
+	entra               *azureauth.ClientCredentials
+}
+
+func azureTargetFromAuth(auth *cliproxyauth.Auth) (azureTarget, error) {
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	target := azureTarget{
+		baseURL:             strings.TrimRight(strings.TrimSpace(attrs["base_url"]), "/"),
+		apiVersion:          strings.TrimSpace(attrs["api_version"]),
+		responsesAPIVersion: strings.TrimSpace(attrs["responses_api_version"]),
+	}
+	if target.baseURL == "" {
+		return target, statusErr{code: http.StatusUnauthorized, msg: "azure openai executor: missing base url"}
+	}
+	if target.apiVersion == "" {
+		target.apiVersion = config.DefaultAzureOpenAIAPIVersion
+	}
+	if target.responsesAPIVersion == "" {
+		target.responsesAPIVersion = config.DefaultAzureOpenAIResponsesAPIVersion
+	}
+	if clientID := strings.TrimSpace(attrs["client_id"]); clientID != "" {
+		target.entra = &azureauth.ClientCredentials{
+			TenantID:      attrs["entra_tenant_id"],
+			ClientID:      clientID,
+			ClientSecret:  attrs["client_secret"],
+			AuthorityHost: attrs["authority_host"],
+			Scope:         attrs["scope"],
+		}
+		return target, nil
+	}
+	target.apiKey = strings.TrimSpace(attrs["api_key"])
+	if target.apiKey == "" {
+		return target, statusErr{code: http.StatusUnauthorized, msg: "azure openai executor: missing api key"}
+	}
+	return target, nil
+}
+
+// authorize sets the api-key header or an Entra bearer token on req.
+func (e *AzureOpenAIExecutor) authorize(ctx context.Context, auth *cliproxyauth.Auth, target azureTarget, req *http.Request) error {
+	if target.entra == nil {
+		req.Header.Del("Authorization")
+		req.Header.Set("api-key", target.apiKey)
+		return nil
+	}
+	token, err := e.tokens.Token(ctx, newProxyAwareHTTPClient(ctx, e.cfg, auth, 0), *target.entra)
+	if err != nil {
+		return statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+	}
+	req.Header.Del("api-key")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// PrepareRequest injects Azure OpenAI credentials into the outgoing HTTP request.
+func (e *AzureOpenAIExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	target, err := azureTargetFromAuth(auth)
+	if err != nil {
+		return err
+	}
+	if err = e.authorize(req.Context(), auth, target, req); err != nil {
+		return err
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects Azure OpenAI credentials into the request and executes it.
+func (e *AzureOpenAIExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("azure openai executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// azureRequest describes the upstream call derived from the client request.
+type azureRequest struct {
+	to   sdktranslator.Format
+	url  string
+	body []byte
+}
+
+// buildRequest translates the payload and resolves the deployment URL. Responses API
+// clients are served by the Responses API; every other dialect goes through chat completions.
+func (e *AzureOpenAIExecutor) buildRequest(target azureTarget, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (azureRequest, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	out := azureRequest{to: sdktranslator.FromString("openai")}
+	if from.String() == "openai-response" {
+		out.to = sdktranslator.FromString("openai-response")
+		out.url = target.baseURL + "/openai/responses?api-version=" + url.QueryEscape(target.responsesAPIVersion)
+	} else {
+		out.url = target.baseURL + "/openai/deployments/" + url.PathEscape(baseModel) + "/chat/completions?api-version=" + url.QueryEscape(target.apiVersion)
+	}
+
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, out.to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, out.to, baseModel, req.Payload, stream)
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, out.to.String(), "", body, originalTranslated, requestedModel)
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), out.to.String(), e.Identifier())
+	if err != nil {
+		return out, err
+	}
+	// Azure routes by deployment; the model field must name it for the Responses API.
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+	if stream {
+		body, _ = sjson.SetBytes(body, "stream", true)
+	} else {
+		body, _ = sjson.DeleteBytes(body, "stream")
+	}
+	out.body = body
+	return out, nil
+}
+
+// send executes a prepared request and converts non-2xx responses into status errors.
+func (e *AzureOpenAIExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, target azureTarget, upstream azureRequest, from sdktranslator.Format, stream bool) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstream.url, bytes.NewReader(upstream.body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	}
+	if err = e.authorize(ctx, auth, target, httpReq); err != nil {
+		return nil, err
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       upstream.url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      upstream.body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+		return httpResp, nil
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	b, _ := io.ReadAll(httpResp.Body)
+	appendAPIResponseChunk(ctx, e.cfg, b)
+	logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+	if httpResp.StatusCode == http.StatusUnauthorized && target.entra != nil {
+		e.tokens.Invalidate(*target.entra)
+	}
+	if message, ok := azureContentFilterMessage(b); ok {
+		return nil, statusErr{code: http.StatusBadRequest, msg: string(contentFilterErrorBody(from, message))}
+	}
+	return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+}
+
+func (e *AzureOpenAIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	target, err := azureTargetFromAuth(auth)
+	if err != nil {
+		return resp, err
+	}
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	upstream, err := e.buildRequest(target, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.send(ctx, auth, target, upstream, from, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, body)
+	reporter.publish(ctx, parseOpenAIUsage(body))
+	reporter.ensurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, upstream.to, from, req.Model, opts.OriginalRequest, upstream.body, body, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out), Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *AzureOpenAIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	target, err := azureTargetFromAuth(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	upstream, err := e.buildRequest(target, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.send(ctx, auth, target, upstream, from, true)
+	if err != nil {
+		return nil, err
+	}
+
+	responsesAPI := upstream.to.String() == "openai-response"
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("azure openai executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if len(line) == 0 {
+				continue
+			}
+			if responsesAPI {
+				if data := jsonPayload(line); gjson.GetBytes(data, "type").String() == "response.completed" {
+					if detail, ok := parseCodexUsage(data); ok {
+						reporter.publish(ctx, detail)
+					}
+				}
+			} else {
+				if detail, ok := parseOpenAIStreamUsage(line); ok {
+					reporter.publish(ctx, detail)
+				}
+				if !bytes.HasPrefix(line, []byte("data:")) {
+					continue
+				}
+			}
+			chunks := sdktranslator.TranslateStream(ctx, upstream.to, from, req.Model, opts.OriginalRequest, upstream.body, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens counts tokens locally; Azure OpenAI has no token counting endpoint.
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh is a no-op; Entra tokens are obtained and cached per request.
+func (e *AzureOpenAIExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("azure openai executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// azureContentFilterMessage reports whether an Azure error body is a content filter
+// rejection and returns its message.
+func azureContentFilterMessage(body []byte) (string, bool) {
+	errNode := gjson.GetBytes(body, "error")
+	if !errNode.Exists() {
+		return "", false
+	}
+	code := errNode.Get("code").String()
+	innerCode := errNode.Get("innererror.code").String()
+	if code != "content_filter" && innerCode != "ResponsibleAIPolicyViolation" {
+		return "", false
+	}
+	message := strings.TrimSpace(errNode.Get("message").String())
+	if message == "" {
+		message = "The request was rejected by the Azure OpenAI content filter."
+	}
+	return message, true
+}
+
+// contentFilterErrorBody renders a content filter rejection as a 400 error body in the
+// client's dialect so it is passed through verbatim by the API handlers.
+func contentFilterErrorBody(from sdktranslator.Format, message string) []byte {
+	var body []byte
+	switch from.String() {
+	case "claude":
+		body = []byte(`{"type":"error","error":{"type":"invalid_request_error","message":""}}`)
+		body, _ = sjson.SetBytes(body, "error.message", message)
+	case "gemini", "gemini-cli":
+		body = []byte(`{"error":{"code":400,"message":"","status":"INVALID_ARGUMENT"}}`)
+		body, _ = sjson.SetBytes(body, "error.message", message)
+	default:
+		body = []byte(`{"error":{"message":"","type":"invalid_request_error","param":null,"code":"content_filter"}}`)
+		body, _ = sjson.SetBytes(body, "error.message", message)
+	}
+	return body
+}
@@ -0,0 +1,183 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	azureauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/azure"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const azureChatCompletion = `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello from Azure"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`
+
+func newAzureTestExecutor() *AzureOpenAIExecutor {
+	executor := NewAzureOpenAIExecutor(&config.Config{})
+	executor.tokens = azureauth.NewTokenCache()
+	return executor
+}
+
+func newAzureTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{ID: "azure-test", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":     "azure-key",
+		"base_url":    baseURL,
+		"api_version": "2024-10-21",
+	}}
+}
+
+func TestAzureOpenAIExecutorChatWithAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" || r.URL.Query().Get("api-version") != "2024-10-21" {
+			t.Errorf("unexpected url %s", r.URL.String())
+		}
+		if r.Header.Get("api-key") != "azure-key" || r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected auth headers: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if gjson.GetBytes(body, "model").String() != "gpt-4o-prod" {
+			t.Errorf("model should be the deployment: %s", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(azureChatCompletion))
+	}))
+	defer server.Close()
+
+	resp, err := newAzureTestExecutor().Execute(context.Background(), newAzureTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "gpt-4o-prod",
+		Payload: []byte(`{"model":"gpt-4o","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "content.0.text").String() != "Hello from Azure" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestAzureOpenAIExecutorEntraToken(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tenant-1/oauth2/v2.0/token" {
+			tokenRequests++
+			_ = r.ParseForm()
+			if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_secret") != "shh" || r.PostForm.Get("scope") != azureauth.DefaultScope {
+				t.Errorf("unexpected token form: %v", r.PostForm)
+			}
+			_, _ = w.Write([]byte(`{"access_token":"entra-token","expires_in":3600,"token_type":"Bearer"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer entra-token" || r.Header.Get("api-key") != "" {
+			t.Errorf("unexpected auth headers: %v", r.Header)
+		}
+		_, _ = w.Write([]byte(azureChatCompletion))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "azure-entra", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":         "entra:client-1",
+		"base_url":        server.URL,
+		"entra_tenant_id": "tenant-1",
+		"client_id":       "client-1",
+		"client_secret":   "shh",
+		"authority_host":  server.URL,
+	}}
+	executor := newAzureTestExecutor()
+	for i := 0; i < 2; i++ {
+		payload := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+		resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4o", Payload: payload},
+			cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+		if err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "Hello from Azure" {
+			t.Fatalf("payload = %s", resp.Payload)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("token requests = %d, want 1 (cached)", tokenRequests)
+	}
+}
+
+func TestAzureOpenAIExecutorResponsesStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/responses" || r.URL.Query().Get("api-version") != config.DefaultAzureOpenAIResponsesAPIVersion {
+			t.Errorf("unexpected url %s", r.URL.String())
+		}
+		body, _ := io.ReadAll(r.Body)
+		if gjson.GetBytes(body, "model").String() != "gpt-4o-prod" || !gjson.GetBytes(body, "stream").Bool() {
+			t.Errorf("unexpected body: %s", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"Hi\"}\n\n" +
+			"event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"usage\":{\"input_tokens\":3,\"output_tokens\":1,\"total_tokens\":4}}}\n\n"))
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"model":"gpt-4o","stream":true,"input":"hi"}`)
+	result, err := newAzureTestExecutor().ExecuteStream(context.Background(), newAzureTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "gpt-4o-prod",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response"), OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var lines []string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		lines = append(lines, string(chunk.Payload))
+	}
+	if len(lines) != 4 || lines[0] != "event: response.output_text.delta" || !strings.Contains(lines[3], "response.completed") {
+		t.Fatalf("unexpected stream lines: %q", lines)
+	}
+}
+
+func TestAzureOpenAIExecutorContentFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"The response was filtered due to the prompt triggering Azure OpenAI's content management policy.","type":null,"param":"prompt","code":"content_filter","status":400,"innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":{"hate":{"filtered":true,"severity":"high"}}}}}`))
+	}))
+	defer server.Close()
+
+	cases := map[string]string{
+		"claude": "error.type",
+		"openai": "error.code",
+		"gemini": "error.status",
+	}
+	want := map[string]string{
+		"claude": "invalid_request_error",
+		"openai": "content_filter",
+		"gemini": "INVALID_ARGUMENT",
+	}
+	for from, path := range cases {
+		payload := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+		if from == "gemini" {
+			payload = []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+		}
+		_, err := newAzureTestExecutor().Execute(context.Background(), newAzureTestAuth(server.URL), cliproxyexecutor.Request{
+			Model:   "gpt-4o-prod",
+			Payload: payload,
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString(from)})
+		var se statusErr
+		if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+			t.Fatalf("%s: err = %v, want 400 status error", from, err)
+		}
+		if got := gjson.Get(se.msg, path).String(); got != want[from] {
+			t.Fatalf("%s: %s = %q in %s", from, path, got, se.msg)
+		}
+		if !strings.Contains(gjson.Get(se.msg, "error.message").String(), "content management policy") {
+			t.Fatalf("%s: message lost: %s", from, se.msg)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package executor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AWS event-stream framing used by Bedrock invoke-with-response-stream:
+//
+//	total length (4) | headers length (4) | prelude CRC (4) | headers | payload | message CRC (4)
+//
+// Both CRCs are CRC-32 (IEEE); all integers are big endian.
+const (
+	eventStreamPreludeLen  = 12
+	eventStreamMinFrameLen = eventStreamPreludeLen + 4
+	eventStreamMaxFrameLen = 16 << 20
+)
+
+// eventStreamMessage is one decoded event-stream frame. Only string headers are kept.
+type eventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+type eventStreamDecoder struct {
+	r io.Reader
+}
+
+func newEventStreamDecoder(r io.Reader) *eventStreamDecoder {
+	return &eventStreamDecoder{r: r}
+}
+
+// Next reads the next frame. It returns io.EOF at a clean end of stream.
+func (d *eventStreamDecoder) Next() (eventStreamMessage, error) {
+	var prelude [eventStreamPreludeLen]byte
+	if _, err := io.ReadFull(d.r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return eventStreamMessage{}, fmt.Errorf("event stream: truncated prelude")
+		}
+		return eventStreamMessage{}, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return eventStreamMessage{}, fmt.Errorf("event stream: prelude checksum mismatch")
+	}
+	if totalLen < eventStreamMinFrameLen || totalLen > eventStreamMaxFrameLen || headersLen > totalLen-eventStreamMinFrameLen {
+		return eventStreamMessage{}, fmt.Errorf("event stream: invalid frame length %d", totalLen)
+	}
+
+	frame := make([]byte, totalLen)
+	copy(frame, prelude[:])
+	if _, err := io.ReadFull(d.r, frame[eventStreamPreludeLen:]); err != nil {
+		return eventStreamMessage{}, fmt.Errorf("event stream: truncated frame: %w", err)
+	}
+	crcOffset := totalLen - 4
+	if crc32.ChecksumIEEE(frame[:crcOffset]) != binary.BigEndian.Uint32(frame[crcOffset:]) {
+		return eventStreamMessage{}, fmt.Errorf("event stream: message checksum mismatch")
+	}
+
+	headersEnd := eventStreamPreludeLen + headersLen
+	headers, err := parseEventStreamHeaders(frame[eventStreamPreludeLen:headersEnd])
+	if err != nil {
+		return eventStreamMessage{}, err
+	}
+	return eventStreamMessage{Headers: headers, Payload: frame[headersEnd:crcOffset]}, nil
+}
+
+func parseEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("event stream: truncated header")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true / false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(b) < 2 {
+				return nil, fmt.Errorf("event stream: truncated header %s", name)
+			}
+			size = int(binary.BigEndian.Uint16(b[:2]))
+			b = b[2:]
+		default:
+			return nil, fmt.Errorf("event stream: unknown header type %d", valueType)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("event stream: truncated header %s", name)
+		}
+		if valueType == 7 {
+			headers[name] = string(b[:size])
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
+
+// bedrockEventToSSE converts a Bedrock stream frame into Claude SSE lines
+// ("event: ...", "data: ...", ""). Exception frames become status errors.
+func bedrockEventToSSE(msg eventStreamMessage) ([][]byte, error) {
+	switch msg.Headers[":message-type"] {
+	case "event":
+		if msg.Headers[":event-type"] != "chunk" {
+			return nil, nil
+		}
+		encoded := gjson.GetBytes(msg.Payload, "bytes").String()
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("bedrock stream: decode chunk: %w", err)
+		}
+		if gjson.GetBytes(data, "amazon-bedrock-invocationMetrics").Exists() {
+			data, _ = sjson.DeleteBytes(data, "amazon-bedrock-invocationMetrics")
+		}
+		eventType := gjson.GetBytes(data, "type").String()
+		return [][]byte{
+			[]byte("event: " + eventType),
+			append([]byte("data: "), data...),
+			{},
+		}, nil
+	case "exception":
+		exceptionType := msg.Headers[":exception-type"]
+		message := gjson.GetBytes(msg.Payload, "message").String()
+		if message == "" {
+			message = string(msg.Payload)
+		}
+		return nil, statusErr{code: bedrockExceptionStatus(exceptionType), msg: exceptionType + ": " + message}
+	case "error":
+		return nil, statusErr{code: http.StatusBadGateway, msg: msg.Headers[":error-code"] + ": " + msg.Headers[":error-message"]}
+	default:
+		return nil, nil
+	}
+}
+
+// bedrockExceptionStatus maps Bedrock exception names to HTTP status codes so the
+// conductor's cooldown and retry handling treats them like HTTP errors.
+func bedrockExceptionStatus(exceptionType string) int {
+	switch strings.ToLower(strings.TrimSuffix(exceptionType, "Exception")) {
+	case "throttling", "servicequotaexceeded":
+		return http.StatusTooManyRequests
+	case "validation":
+		return http.StatusBadRequest
+	case "accessdenied":
+		return http.StatusForbidden
+	case "resourcenotfound":
+		return http.StatusNotFound
+	case "modeltimeout":
+		return http.StatusRequestTimeout
+	case "serviceunavailable", "modelnotready":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
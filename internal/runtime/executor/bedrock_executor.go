@@ -0,0 +1,463 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	bedrockauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/bedrock"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// bedrockAnthropicVersion is the anthropic_version Bedrock requires in Claude request bodies.
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// bedrockModelIDs maps Anthropic model names to Bedrock model IDs where they differ from
+// the default "anthropic.<name>-v1:0" pattern.
+var bedrockModelIDs = map[string]string{
+	"claude-3-5-sonnet-20241022": "anthropic.claude-3-5-sonnet-20241022-v2:0",
+	"claude-opus-4-6":            "anthropic.claude-opus-4-6-v1",
+	"claude-sonnet-4-6":          "anthropic.claude-sonnet-4-6",
+}
+
+// BedrockExecutor serves Claude messages requests through the AWS Bedrock runtime
+// invoke endpoints, signing every request with SigV4.
+type BedrockExecutor struct {
+	cfg *config.Config
+	now func() time.Time
+}
+
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor {
+	return &BedrockExecutor{cfg: cfg, now: time.Now}
+}
+
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// bedrockTarget holds the resolved endpoint and credentials for one auth.
+type bedrockTarget struct {
+	creds   bedrockauth.Credentials
+	region  string
+	baseURL string
+}
+
+func bedrockTargetFromAuth(auth *cliproxyauth.Auth) (bedrockTarget, error) {
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	target := bedrockTarget{
+		region:  strings.TrimSpace(attrs["region"]),
+		baseURL: strings.TrimRight(strings.TrimSpace(attrs["base_url"]), "/"),
+	}
+	if target.region == "" {
+		return target, statusErr{code: http.StatusUnauthorized, msg: "bedrock executor: missing region"}
+	}
+	if target.baseURL == "" {
+		target.baseURL = "https://bedrock-runtime." + target.region + ".amazonaws.com"
+	}
+	if profile := strings.TrimSpace(attrs["profile"]); profile != "" {
+		creds, err := bedrockauth.LoadProfile(profile)
+		if err != nil {
+			return target, statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+		}
+		target.creds = creds
+		return target, nil
+	}
+	target.creds = bedrockauth.Credentials{
+		AccessKeyID:     strings.TrimSpace(attrs["api_key"]),
+		SecretAccessKey: strings.TrimSpace(attrs["secret_access_key"]),
+		SessionToken:    strings.TrimSpace(attrs["session_token"]),
+	}
+	if !target.creds.Valid() {
+		return target, statusErr{code: http.StatusUnauthorized, msg: "bedrock executor: missing aws credentials"}
+	}
+	return target, nil
+}
+
+// bedrockModelID returns the Bedrock model ID for model. Values that already look like
+// Bedrock IDs or ARNs (configured through model aliases) are used unchanged.
+func bedrockModelID(auth *cliproxyauth.Auth, model string) string {
+	model = strings.TrimSpace(model)
+	if strings.HasPrefix(model, "arn:") || strings.Contains(model, "anthropic.") {
+		return model
+	}
+	id, ok := bedrockModelIDs[model]
+	if !ok {
+		id = "anthropic." + model + "-v1:0"
+	}
+	if auth != nil && auth.Attributes != nil {
+		if prefix := strings.TrimSpace(auth.Attributes["model_id_prefix"]); prefix != "" {
+			id = strings.TrimSuffix(prefix, ".") + "." + id
+		}
+	}
+	return id
+}
+
+// bedrockRequestBody turns a Claude messages body into a Bedrock invoke body: the model
+// moves into the URL, streaming is chosen by endpoint and betas move into the body.
+func bedrockRequestBody(body []byte, betas []string) []byte {
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	return body
+}
+
+// newSignedRequest builds a signed POST to path below the runtime endpoint.
+func (e *BedrockExecutor) newSignedRequest(ctx context.Context, auth *cliproxyauth.Auth, target bedrockTarget, path string, body []byte, accept string) (*http.Request, error) {
+	endpoint, err := url.Parse(target.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock executor: invalid base url: %w", err)
+	}
+	endpoint.Path = strings.TrimRight(endpoint.Path, "/") + path
+	endpoint.RawPath = bedrockauth.EscapePath(endpoint.Path)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", accept)
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	if err = bedrockauth.SignRequest(httpReq, body, target.creds, target.region, bedrockauth.ServiceName, e.now()); err != nil {
+		return nil, err
+	}
+	return httpReq, nil
+}
+
+// PrepareRequest signs the outgoing HTTP request with the auth's AWS credentials.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	target, err := bedrockTargetFromAuth(auth)
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return bedrockauth.SignRequest(req, body, target.creds, target.region, bedrockauth.ServiceName, e.now())
+}
+
+// HttpRequest signs the request with the auth's AWS credentials and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// prepareBody translates the request into a Claude messages body and applies the same
+// thinking, payload and cache-control normalisation as the Claude executor.
+func (e *BedrockExecutor) prepareBody(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (body, bodyForTranslation []byte, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body = sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, nil, err
+	}
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = disableThinkingIfToolChoiceForced(body)
+	body = enforceCacheControlLimit(body, 4)
+	body = normalizeCacheControlTTL(body)
+
+	var betas []string
+	betas, body = extractAndRemoveBetas(body)
+	return bedrockRequestBody(body, betas), body, nil
+}
+
+// send executes a signed request and converts non-2xx responses into status errors.
+func (e *BedrockExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, httpReq *http.Request, body []byte) (*http.Response, error) {
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       httpReq.URL.String(),
+		Method:    httpReq.Method,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+		return httpResp, nil
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+	b, readErr := io.ReadAll(httpResp.Body)
+	if readErr != nil {
+		recordAPIResponseError(ctx, e.cfg, readErr)
+		b = []byte(fmt.Sprintf("failed to read error response body: %v", readErr))
+	}
+	appendAPIResponseChunk(ctx, e.cfg, b)
+	logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+	return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+}
+
+// readEventStream decodes a Bedrock response stream and calls fn with each Claude SSE line.
+func readEventStream(body io.Reader, fn func(line []byte)) error {
+	decoder := newEventStreamDecoder(body)
+	for {
+		msg, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		lines, err := bedrockEventToSSE(msg)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			fn(line)
+		}
+	}
+}
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	target, err := bedrockTargetFromAuth(auth)
+	if err != nil {
+		return resp, err
+	}
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	// Use streaming translation to preserve function calling, except for claude.
+	stream := from != to
+	body, bodyForTranslation, err := e.prepareBody(req, opts, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	action, accept := "invoke", "application/json"
+	if stream {
+		action, accept = "invoke-with-response-stream", "application/vnd.amazon.eventstream"
+	}
+	httpReq, err := e.newSignedRequest(ctx, auth, target, "/model/"+bedrockModelID(auth, baseModel)+"/"+action, body, accept)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.send(ctx, auth, httpReq, body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+
+	var data []byte
+	if stream {
+		var buf bytes.Buffer
+		err = readEventStream(httpResp.Body, func(line []byte) {
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		})
+		data = buf.Bytes()
+	} else {
+		data, err = io.ReadAll(httpResp.Body)
+		if err == nil {
+			reporter.publish(ctx, parseClaudeUsage(data))
+		}
+	}
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, bodyForTranslation, data, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out), Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	target, err := bedrockTargetFromAuth(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body, bodyForTranslation, err := e.prepareBody(req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := e.newSignedRequest(ctx, auth, target, "/model/"+bedrockModelID(auth, baseModel)+"/invoke-with-response-stream", body, "application/vnd.amazon.eventstream")
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.send(ctx, auth, httpReq, body)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("response body close error: %v", errClose)
+			}
+		}()
+
+		var param any
+		errStream := readEventStream(httpResp.Body, func(line []byte) {
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if from == to {
+				// Claude clients receive the SSE lines as-is.
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				return
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, bodyForTranslation, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		})
+		if errStream != nil {
+			recordAPIResponseError(ctx, e.cfg, errStream)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errStream}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens uses the Bedrock CountTokens API with the translated invoke body.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	target, err := bedrockTargetFromAuth(auth)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	invokeBody, _, err := e.prepareBody(req, opts, false)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	body, _ := sjson.SetBytes([]byte(`{}`), "input.invokeModel.body", base64.StdEncoding.EncodeToString(invokeBody))
+
+	httpReq, err := e.newSignedRequest(ctx, auth, target, "/model/"+bedrockModelID(auth, baseModel)+"/count-tokens", body, "application/json")
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	httpResp, err := e.send(ctx, auth, httpReq, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return cliproxyexecutor.Response{}, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	count := gjson.GetBytes(data, "inputTokens").Int()
+	usageJSON, _ := sjson.SetBytes([]byte(`{}`), "input_tokens", count)
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(out), Headers: httpResp.Header.Clone()}, nil
+}
+
+// Refresh is a no-op; profile credentials are re-read from disk on every request.
+func (e *BedrockExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("bedrock executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
@@ -0,0 +1,248 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bedrockauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/bedrock"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// encodeEventStreamFrame builds an AWS event-stream frame with string headers.
+func encodeEventStreamFrame(headers map[string]string, payload []byte) []byte {
+	var hb bytes.Buffer
+	for _, name := range []string{":message-type", ":event-type", ":exception-type", ":content-type"} {
+		value, ok := headers[name]
+		if !ok {
+			continue
+		}
+		hb.WriteByte(byte(len(name)))
+		hb.WriteString(name)
+		hb.WriteByte(7)
+		_ = binary.Write(&hb, binary.BigEndian, uint16(len(value)))
+		hb.WriteString(value)
+	}
+	total := uint32(12 + hb.Len() + len(payload) + 4)
+	var frame bytes.Buffer
+	_ = binary.Write(&frame, binary.BigEndian, total)
+	_ = binary.Write(&frame, binary.BigEndian, uint32(hb.Len()))
+	_ = binary.Write(&frame, binary.BigEndian, crc32.ChecksumIEEE(frame.Bytes()))
+	frame.Write(hb.Bytes())
+	frame.Write(payload)
+	_ = binary.Write(&frame, binary.BigEndian, crc32.ChecksumIEEE(frame.Bytes()))
+	return frame.Bytes()
+}
+
+func bedrockChunkFrame(event string) []byte {
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+	return encodeEventStreamFrame(map[string]string{":message-type": "event", ":event-type": "chunk", ":content-type": "application/json"}, []byte(payload))
+}
+
+var bedrockTestEvents = []string{
+	`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[],"stop_reason":null,"usage":{"input_tokens":7,"output_tokens":1}}}`,
+	`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello from Bedrock"}}`,
+	`{"type":"content_block_stop","index":0}`,
+	`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`,
+	`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":7,"outputTokenCount":4}}`,
+}
+
+// newFakeBedrock serves invoke endpoints and verifies each request's SigV4 signature by
+// re-signing it with the shared test credentials.
+func newFakeBedrock(t *testing.T, frames func() [][]byte) (*httptest.Server, *[]string) {
+	t.Helper()
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		body, _ := io.ReadAll(r.Body)
+
+		signedAt, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			t.Errorf("missing X-Amz-Date: %v", err)
+		}
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+		check.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		creds := bedrockauth.Credentials{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret", SessionToken: "session"}
+		_ = bedrockauth.SignRequest(check, body, creds, "us-east-1", bedrockauth.ServiceName, signedAt)
+		if got, want := r.Header.Get("Authorization"), check.Header.Get("Authorization"); got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		if gjson.GetBytes(body, "model").Exists() || gjson.GetBytes(body, "stream").Exists() {
+			t.Errorf("model and stream must not be sent to Bedrock: %s", body)
+		}
+		if gjson.GetBytes(body, "anthropic_version").String() != bedrockAnthropicVersion {
+			t.Errorf("anthropic_version missing: %s", body)
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/invoke") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"Hello from Bedrock"}],"stop_reason":"end_turn","usage":{"input_tokens":7,"output_tokens":4}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		for _, frame := range frames() {
+			_, _ = w.Write(frame)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+func newBedrockTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{ID: "bedrock-test", Provider: "bedrock", Attributes: map[string]string{
+		"api_key":           "AKIDTEST",
+		"secret_access_key": "secret",
+		"session_token":     "session",
+		"region":            "us-east-1",
+		"base_url":          baseURL,
+	}}
+}
+
+func defaultBedrockFrames() [][]byte {
+	frames := make([][]byte, 0, len(bedrockTestEvents))
+	for _, event := range bedrockTestEvents {
+		frames = append(frames, bedrockChunkFrame(event))
+	}
+	return frames
+}
+
+func TestBedrockExecutorExecuteClaude(t *testing.T) {
+	server, paths := newFakeBedrock(t, defaultBedrockFrames)
+	executor := NewBedrockExecutor(&config.Config{})
+	resp, err := executor.Execute(context.Background(), newBedrockTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-20250514",
+		Payload: []byte(`{"model":"claude-sonnet-4-20250514","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := (*paths)[0]; got != "/model/anthropic.claude-sonnet-4-20250514-v1%3A0/invoke" {
+		t.Fatalf("path = %q", got)
+	}
+	if gjson.GetBytes(resp.Payload, "content.0.text").String() != "Hello from Bedrock" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestBedrockExecutorStreamClaudePassthrough(t *testing.T) {
+	server, paths := newFakeBedrock(t, defaultBedrockFrames)
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := newBedrockTestAuth(server.URL)
+	auth.Attributes["model_id_prefix"] = "us."
+	result, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-20250514",
+		Payload: []byte(`{"model":"claude-sonnet-4-20250514","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var out strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+	if got := (*paths)[0]; got != "/model/us.anthropic.claude-sonnet-4-20250514-v1%3A0/invoke-with-response-stream" {
+		t.Fatalf("path = %q", got)
+	}
+	sse := out.String()
+	for _, want := range []string{"event: message_start\n", `"text":"Hello from Bedrock"`, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"} {
+		if !strings.Contains(sse, want) {
+			t.Fatalf("stream missing %q:\n%s", want, sse)
+		}
+	}
+	if strings.Contains(sse, "invocationMetrics") {
+		t.Fatalf("bedrock metrics leaked into stream:\n%s", sse)
+	}
+}
+
+func TestBedrockExecutorStreamTranslatesToOpenAI(t *testing.T) {
+	server, _ := newFakeBedrock(t, defaultBedrockFrames)
+	executor := NewBedrockExecutor(&config.Config{})
+	payload := []byte(`{"model":"claude-sonnet-4-20250514","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	result, err := executor.ExecuteStream(context.Background(), newBedrockTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-20250514",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var text strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		data := strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data: ")
+		text.WriteString(gjson.Get(data, "choices.0.delta.content").String())
+	}
+	if text.String() != "Hello from Bedrock" {
+		t.Fatalf("translated text = %q", text.String())
+	}
+}
+
+func TestBedrockExecutorStreamException(t *testing.T) {
+	server, _ := newFakeBedrock(t, func() [][]byte {
+		return [][]byte{
+			bedrockChunkFrame(bedrockTestEvents[0]),
+			encodeEventStreamFrame(map[string]string{":message-type": "exception", ":exception-type": "throttlingException"}, []byte(`{"message":"Too many requests"}`)),
+		}
+	})
+	executor := NewBedrockExecutor(&config.Config{})
+	result, err := executor.ExecuteStream(context.Background(), newBedrockTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-20250514",
+		Payload: []byte(`{"model":"claude-sonnet-4-20250514","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var streamErr error
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+		}
+	}
+	var se statusErr
+	if !errors.As(streamErr, &se) || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("stream error = %v, want 429 status error", streamErr)
+	}
+}
+
+func TestEventStreamDecoderRejectsCorruptFrames(t *testing.T) {
+	frame := bedrockChunkFrame(bedrockTestEvents[0])
+	frame[len(frame)-6] ^= 0xff
+	if _, err := newEventStreamDecoder(bytes.NewReader(frame)).Next(); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	if _, err := newEventStreamDecoder(bytes.NewReader(frame[:5])).Next(); err == nil {
+		t.Fatal("expected error for truncated prelude")
+	}
+}
+
+func TestBedrockModelID(t *testing.T) {
+	for model, want := range map[string]string{
+		"claude-3-5-sonnet-20241022":               "anthropic.claude-3-5-sonnet-20241022-v2:0",
+		"claude-opus-4-1-20250805":                 "anthropic.claude-opus-4-1-20250805-v1:0",
+		"anthropic.claude-3-haiku-20240307-v1:0":   "anthropic.claude-3-haiku-20240307-v1:0",
+		"arn:aws:bedrock:us-east-1:1:profile/test": "arn:aws:bedrock:us-east-1:1:profile/test",
+	} {
+		if got := bedrockModelID(nil, model); got != want {
+			t.Fatalf("bedrockModelID(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
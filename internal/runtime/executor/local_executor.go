@@ -0,0 +1,288 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// LocalExecutor executes requests against self-hosted model servers. Ollama backends use
+// the native /api/chat NDJSON protocol; OpenAI-compatible backends (llama.cpp, vLLM) are
+// served by the OpenAI-compatible executor.
+type LocalExecutor struct {
+	cfg    *config.Config
+	compat *OpenAICompatExecutor
+}
+
+func NewLocalExecutor(cfg *config.Config) *LocalExecutor {
+	return &LocalExecutor{cfg: cfg, compat: NewOpenAICompatExecutor("local", cfg)}
+}
+
+func (e *LocalExecutor) Identifier() string { return "local" }
+
+func localBackend(auth *cliproxyauth.Auth) (backend, baseURL, apiKey string) {
+	if auth == nil || auth.Attributes == nil {
+		return config.LocalBackendOllama, "", ""
+	}
+	backend = strings.TrimSpace(auth.Attributes["backend_type"])
+	if backend == "" {
+		backend = config.LocalBackendOllama
+	}
+	return backend, strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/"), strings.TrimSpace(auth.Attributes["api_key"])
+}
+
+// PrepareRequest injects the optional bearer token and custom headers into the request.
+func (e *LocalExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	_, _, apiKey := localBackend(auth)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects credentials into the request and executes it.
+func (e *LocalExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("local executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// ListModels returns the models currently served by the backend, from /api/tags for
+// Ollama or /models for OpenAI-compatible servers.
+func (e *LocalExecutor) ListModels(ctx context.Context, auth *cliproxyauth.Auth) ([]string, error) {
+	backend, baseURL, _ := localBackend(auth)
+	if baseURL == "" {
+		return nil, fmt.Errorf("local executor: missing base url")
+	}
+	if backend == config.LocalBackendOpenAI {
+		return fetchModelIDs(ctx, e.cfg, auth, e.PrepareRequest, baseURL+"/models", "data", "id")
+	}
+	return fetchModelIDs(ctx, e.cfg, auth, e.PrepareRequest, baseURL+"/api/tags", "models", "name", "model")
+}
+
+// buildOllamaRequest translates the client payload into an Ollama /api/chat body.
+func (e *LocalExecutor) buildOllamaRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (openAIBody, ollamaBody []byte, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	openAIBody = sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	openAIBody, err = thinking.ApplyThinking(openAIBody, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, nil, err
+	}
+	// Payload rules target the native body so keep_alive and options.num_ctx can be set
+	// with protocol "ollama".
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	ollamaBody = openAIToOllamaChat(openAIBody, baseModel, stream)
+	originalOllama := openAIToOllamaChat(originalTranslated, baseModel, stream)
+	ollamaBody = applyPayloadConfigWithRoot(e.cfg, baseModel, config.LocalBackendOllama, "", ollamaBody, originalOllama, requestedModel)
+	return openAIBody, ollamaBody, nil
+}
+
+// postOllama sends body to /api/chat and converts non-2xx responses into status errors.
+func (e *LocalExecutor) postOllama(ctx context.Context, auth *cliproxyauth.Auth, baseURL string, body []byte) (*http.Response, error) {
+	url := baseURL + "/api/chat"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+		return httpResp, nil
+	}
+	b, _ := io.ReadAll(httpResp.Body)
+	appendAPIResponseChunk(ctx, e.cfg, b)
+	logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+	if errClose := httpResp.Body.Close(); errClose != nil {
+		log.Errorf("local executor: close response body error: %v", errClose)
+	}
+	return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+}
+
+func (e *LocalExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	backend, baseURL, _ := localBackend(auth)
+	if backend == config.LocalBackendOpenAI {
+		return e.compat.Execute(ctx, auth, req, opts)
+	}
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	if baseURL == "" {
+		return resp, statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	openAIBody, ollamaBody, err := e.buildOllamaRequest(req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.postOllama(ctx, auth, baseURL, ollamaBody)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("local executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, body)
+	if msg := gjson.GetBytes(body, "error").String(); msg != "" {
+		return resp, statusErr{code: http.StatusBadGateway, msg: string(body)}
+	}
+	converted := ollamaChatToOpenAI(body, baseModel)
+	reporter.publish(ctx, parseOpenAIUsage(converted))
+	reporter.ensurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, sdktranslator.FromString("openai"), opts.SourceFormat, req.Model, opts.OriginalRequest, openAIBody, converted, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out), Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *LocalExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	backend, baseURL, _ := localBackend(auth)
+	if backend == config.LocalBackendOpenAI {
+		return e.compat.ExecuteStream(ctx, auth, req, opts)
+	}
+	if baseURL == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	openAIBody, ollamaBody, err := e.buildOllamaRequest(req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.postOllama(ctx, auth, baseURL, ollamaBody)
+	if err != nil {
+		return nil, err
+	}
+
+	to := sdktranslator.FromString("openai")
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("local executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		state := newOllamaStreamState(baseModel)
+		var param any
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if len(line) == 0 {
+				continue
+			}
+			sseLines, errConvert := state.convert(line)
+			if errConvert != nil {
+				reporter.publishFailure(ctx)
+				out <- cliproxyexecutor.StreamChunk{Err: statusErr{code: http.StatusBadGateway, msg: errConvert.Error()}}
+				return
+			}
+			for _, sse := range sseLines {
+				if detail, ok := parseOpenAIStreamUsage(sse); ok {
+					reporter.publish(ctx, detail)
+				}
+				chunks := sdktranslator.TranslateStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, openAIBody, sse, &param)
+				for i := range chunks {
+					out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+				}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens counts tokens locally with the OpenAI tokenizer as an approximation.
+func (e *LocalExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.compat.CountTokens(ctx, auth, req, opts)
+}
+
+// Refresh is a no-op; local backends have no credentials to renew.
+func (e *LocalExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("local executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
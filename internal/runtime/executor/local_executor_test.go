@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newOllamaTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{ID: "local-test", Provider: "local", Attributes: map[string]string{
+		"local_name":   "ollama",
+		"backend_type": config.LocalBackendOllama,
+		"base_url":     baseURL,
+	}}
+}
+
+func TestLocalExecutorOllamaChatAppliesPayloadRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if gjson.GetBytes(body, "model").String() != "llama3.1:8b" || gjson.GetBytes(body, "stream").Bool() {
+			t.Errorf("unexpected body: %s", body)
+		}
+		if gjson.GetBytes(body, "options.num_ctx").Int() != 8192 || gjson.GetBytes(body, "keep_alive").String() != "30m" {
+			t.Errorf("payload rules not applied: %s", body)
+		}
+		if gjson.GetBytes(body, "options.num_predict").Int() != 64 || gjson.GetBytes(body, "messages.0.role").String() != "system" {
+			t.Errorf("request not converted: %s", body)
+		}
+		_, _ = w.Write([]byte(`{"model":"llama3.1:8b","message":{"role":"assistant","content":"Hello from Ollama"},"done":true,"done_reason":"stop","prompt_eval_count":9,"eval_count":4}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Payload: config.PayloadConfig{Default: []config.PayloadRule{{
+		Models: []config.PayloadModelRule{{Name: "llama3*", Protocol: "ollama"}},
+		Params: map[string]any{"keep_alive": "30m", "options.num_ctx": 8192},
+	}}}}
+	resp, err := NewLocalExecutor(cfg).Execute(context.Background(), newOllamaTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "llama3.1:8b",
+		Payload: []byte(`{"model":"llama3.1:8b","max_tokens":64,"system":"be brief","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "content.0.text").String() != "Hello from Ollama" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+	if gjson.GetBytes(resp.Payload, "usage.output_tokens").Int() != 4 {
+		t.Fatalf("usage not mapped: %s", resp.Payload)
+	}
+}
+
+func TestLocalExecutorOllamaStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(`{"model":"llama3.1:8b","message":{"role":"assistant","content":"Hel"},"done":false}
+{"model":"llama3.1:8b","message":{"role":"assistant","content":"lo"},"done":false}
+{"model":"llama3.1:8b","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":9,"eval_count":2}
+`))
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"model":"llama3.1:8b","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	result, err := NewLocalExecutor(&config.Config{}).ExecuteStream(context.Background(), newOllamaTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "llama3.1:8b",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var text strings.Builder
+	var finish string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		data := strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data: ")
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+		text.WriteString(gjson.Get(data, "choices.0.delta.content").String())
+		if reason := gjson.Get(data, "choices.0.finish_reason").String(); reason != "" {
+			finish = reason
+		}
+	}
+	if text.String() != "Hello" || finish != "stop" {
+		t.Fatalf("text = %q, finish = %q", text.String(), finish)
+	}
+}
+
+func TestLocalExecutorListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"llama3.1:8b","model":"llama3.1:8b"},{"model":"qwen2.5:7b"}]}`))
+		case "/v1/models":
+			if r.Header.Get("Authorization") != "Bearer local-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gguf-model","object":"model"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	executor := NewLocalExecutor(&config.Config{})
+	models, err := executor.ListModels(context.Background(), newOllamaTestAuth(server.URL))
+	if err != nil || !slices.Equal(models, []string{"llama3.1:8b", "qwen2.5:7b"}) {
+		t.Fatalf("ollama models = %v, err = %v", models, err)
+	}
+
+	openAIAuth := &cliproxyauth.Auth{ID: "local-openai", Provider: "local", Attributes: map[string]string{
+		"backend_type": config.LocalBackendOpenAI,
+		"base_url":     server.URL + "/v1",
+		"api_key":      "local-key",
+	}}
+	models, err = executor.ListModels(context.Background(), openAIAuth)
+	if err != nil || !slices.Equal(models, []string{"gguf-model"}) {
+		t.Fatalf("openai models = %v, err = %v", models, err)
+	}
+}
+
+func TestOpenAIToOllamaChatToolCalls(t *testing.T) {
+	body := []byte(`{"messages":[
+		{"role":"user","content":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]},
+		{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\":\"x\"}"}}]},
+		{"role":"tool","tool_call_id":"call_1","content":"result"}
+	],"stop":"END","response_format":{"type":"json_object"}}`)
+	out := openAIToOllamaChat(body, "llama3.1:8b", true)
+	checks := map[string]string{
+		"messages.0.content":                           "look",
+		"messages.0.images.0":                          "AAAA",
+		"messages.1.tool_calls.0.function.name":        "lookup",
+		"messages.1.tool_calls.0.function.arguments.q": "x",
+		"messages.2.tool_name":                         "lookup",
+		"options.stop.0":                               "END",
+		"format":                                       "json",
+	}
+	for path, want := range checks {
+		if got := gjson.GetBytes(out, path).String(); got != want {
+			t.Fatalf("%s = %q, want %q in %s", path, got, want, out)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Ollama's native API is bridged through the OpenAI chat format: requests are translated to
+// OpenAI chat completions first and then rewritten for /api/chat, and Ollama's NDJSON
+// responses are rewritten into OpenAI chat completion (chunk) payloads for the translators.
+
+// ollamaOptionFields maps OpenAI sampling parameters to Ollama options.
+var ollamaOptionFields = [][2]string{
+	{"temperature", "temperature"},
+	{"top_p", "top_p"},
+	{"top_k", "top_k"},
+	{"seed", "seed"},
+	{"frequency_penalty", "frequency_penalty"},
+	{"presence_penalty", "presence_penalty"},
+	{"max_tokens", "num_predict"},
+	{"max_completion_tokens", "num_predict"},
+}
+
+// openAIToOllamaChat converts an OpenAI chat completions request into an Ollama /api/chat request.
+func openAIToOllamaChat(body []byte, model string, stream bool) []byte {
+	out := []byte(`{"model":"","messages":[]}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "stream", stream)
+
+	toolNames := make(map[string]string)
+	gjson.GetBytes(body, "messages").ForEach(func(_, msg gjson.Result) bool {
+		role := msg.Get("role").String()
+		if role == "developer" {
+			role = "system"
+		}
+		entry := []byte(`{"role":"","content":""}`)
+		entry, _ = sjson.SetBytes(entry, "role", role)
+
+		content := msg.Get("content")
+		if content.IsArray() {
+			var text strings.Builder
+			content.ForEach(func(_, part gjson.Result) bool {
+				switch part.Get("type").String() {
+				case "text":
+					text.WriteString(part.Get("text").String())
+				case "image_url":
+					url := part.Get("image_url.url").String()
+					if _, data, ok := strings.Cut(url, ";base64,"); ok && strings.HasPrefix(url, "data:") {
+						entry, _ = sjson.SetBytes(entry, "images.-1", data)
+					}
+				}
+				return true
+			})
+			entry, _ = sjson.SetBytes(entry, "content", text.String())
+		} else {
+			entry, _ = sjson.SetBytes(entry, "content", content.String())
+		}
+
+		msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			name := call.Get("function.name").String()
+			toolNames[call.Get("id").String()] = name
+			tc := []byte(`{"function":{"name":"","arguments":{}}}`)
+			tc, _ = sjson.SetBytes(tc, "function.name", name)
+			if args := call.Get("function.arguments").String(); gjson.Valid(args) {
+				tc, _ = sjson.SetRawBytes(tc, "function.arguments", []byte(args))
+			}
+			entry, _ = sjson.SetRawBytes(entry, "tool_calls.-1", tc)
+			return true
+		})
+		if role == "tool" {
+			if name := toolNames[msg.Get("tool_call_id").String()]; name != "" {
+				entry, _ = sjson.SetBytes(entry, "tool_name", name)
+			}
+		}
+		out, _ = sjson.SetRawBytes(out, "messages.-1", entry)
+		return true
+	})
+
+	if tools := gjson.GetBytes(body, "tools"); tools.IsArray() && len(tools.Array()) > 0 {
+		out, _ = sjson.SetRawBytes(out, "tools", []byte(tools.Raw))
+	}
+	for _, field := range ollamaOptionFields {
+		if v := gjson.GetBytes(body, field[0]); v.Exists() && v.Type != gjson.Null {
+			out, _ = sjson.SetRawBytes(out, "options."+field[1], []byte(v.Raw))
+		}
+	}
+	if stop := gjson.GetBytes(body, "stop"); stop.Exists() {
+		if stop.IsArray() {
+			out, _ = sjson.SetRawBytes(out, "options.stop", []byte(stop.Raw))
+		} else if stop.String() != "" {
+			out, _ = sjson.SetBytes(out, "options.stop", []string{stop.String()})
+		}
+	}
+	switch gjson.GetBytes(body, "response_format.type").String() {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, "format", "json")
+	case "json_schema":
+		if schema := gjson.GetBytes(body, "response_format.json_schema.schema"); schema.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "format", []byte(schema.Raw))
+		}
+	}
+	if effort := gjson.GetBytes(body, "reasoning_effort"); effort.Exists() {
+		out, _ = sjson.SetBytes(out, "think", effort.String() != "none")
+	}
+	return out
+}
+
+// ollamaFinishReason maps Ollama's done_reason to an OpenAI finish_reason.
+func ollamaFinishReason(doneReason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	if doneReason == "length" {
+		return "length"
+	}
+	return "stop"
+}
+
+// ollamaMessageContent returns the generated text of a /api/chat or /api/generate payload.
+func ollamaMessageContent(payload gjson.Result) string {
+	if content := payload.Get("message.content"); content.Exists() {
+		return content.String()
+	}
+	return payload.Get("response").String()
+}
+
+// ollamaToolCalls converts Ollama tool calls to OpenAI tool calls, numbering from offset.
+func ollamaToolCalls(calls gjson.Result, offset int, withIndex bool) []byte {
+	out := []byte(`[]`)
+	i := offset
+	calls.ForEach(func(_, call gjson.Result) bool {
+		tc := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+		tc, _ = sjson.SetBytes(tc, "id", fmt.Sprintf("call_%d", i))
+		tc, _ = sjson.SetBytes(tc, "function.name", call.Get("function.name").String())
+		args := call.Get("function.arguments").Raw
+		if args == "" {
+			args = "{}"
+		}
+		tc, _ = sjson.SetBytes(tc, "function.arguments", args)
+		if withIndex {
+			tc, _ = sjson.SetBytes(tc, "index", i)
+		}
+		out, _ = sjson.SetRawBytes(out, "-1", tc)
+		i++
+		return true
+	})
+	return out
+}
+
+func ollamaUsage(out []byte, root string, payload gjson.Result) []byte {
+	prompt := payload.Get("prompt_eval_count").Int()
+	completion := payload.Get("eval_count").Int()
+	out, _ = sjson.SetBytes(out, root+"prompt_tokens", prompt)
+	out, _ = sjson.SetBytes(out, root+"completion_tokens", completion)
+	out, _ = sjson.SetBytes(out, root+"total_tokens", prompt+completion)
+	return out
+}
+
+// ollamaChatToOpenAI converts a non-streaming Ollama response into an OpenAI chat completion.
+func ollamaChatToOpenAI(body []byte, model string) []byte {
+	payload := gjson.ParseBytes(body)
+	out := []byte(`{"id":"","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}],"usage":{}}`)
+	out, _ = sjson.SetBytes(out, "id", "chatcmpl-"+uuid.NewString())
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "choices.0.message.content", ollamaMessageContent(payload))
+	if thinking := payload.Get("message.thinking").String(); thinking != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.message.reasoning_content", thinking)
+	}
+	calls := payload.Get("message.tool_calls")
+	hasToolCalls := calls.IsArray() && len(calls.Array()) > 0
+	if hasToolCalls {
+		out, _ = sjson.SetRawBytes(out, "choices.0.message.tool_calls", ollamaToolCalls(calls, 0, false))
+	}
+	out, _ = sjson.SetBytes(out, "choices.0.finish_reason", ollamaFinishReason(payload.Get("done_reason").String(), hasToolCalls))
+	return ollamaUsage(out, "usage.", payload)
+}
+
+// ollamaStreamState tracks one streamed Ollama response while it is rewritten as OpenAI chunks.
+type ollamaStreamState struct {
+	id        string
+	created   int64
+	model     string
+	roleSent  bool
+	toolCalls int
+}
+
+func newOllamaStreamState(model string) *ollamaStreamState {
+	return &ollamaStreamState{id: "chatcmpl-" + uuid.NewString(), created: time.Now().Unix(), model: model}
+}
+
+func (s *ollamaStreamState) chunk() []byte {
+	out := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`)
+	out, _ = sjson.SetBytes(out, "id", s.id)
+	out, _ = sjson.SetBytes(out, "created", s.created)
+	out, _ = sjson.SetBytes(out, "model", s.model)
+	return out
+}
+
+// convert rewrites one NDJSON line into zero or more "data: ..." SSE lines. An error is
+// returned when Ollama reports a failure mid-stream.
+func (s *ollamaStreamState) convert(line []byte) ([][]byte, error) {
+	payload := gjson.ParseBytes(line)
+	if msg := payload.Get("error").String(); msg != "" {
+		return nil, fmt.Errorf("ollama: %s", msg)
+	}
+	var lines [][]byte
+	emit := func(chunk []byte) {
+		lines = append(lines, append([]byte("data: "), chunk...))
+	}
+
+	delta := s.chunk()
+	hasDelta := false
+	if !s.roleSent {
+		delta, _ = sjson.SetBytes(delta, "choices.0.delta.role", "assistant")
+		s.roleSent = true
+		hasDelta = true
+	}
+	if content := ollamaMessageContent(payload); content != "" {
+		delta, _ = sjson.SetBytes(delta, "choices.0.delta.content", content)
+		hasDelta = true
+	}
+	if thinking := payload.Get("message.thinking").String(); thinking != "" {
+		delta, _ = sjson.SetBytes(delta, "choices.0.delta.reasoning_content", thinking)
+		hasDelta = true
+	}
+	if calls := payload.Get("message.tool_calls"); calls.IsArray() && len(calls.Array()) > 0 {
+		delta, _ = sjson.SetRawBytes(delta, "choices.0.delta.tool_calls", ollamaToolCalls(calls, s.toolCalls, true))
+		s.toolCalls += len(calls.Array())
+		hasDelta = true
+	}
+	if hasDelta {
+		emit(delta)
+	}
+
+	if payload.Get("done").Bool() {
+		final := s.chunk()
+		final, _ = sjson.SetBytes(final, "choices.0.finish_reason", ollamaFinishReason(payload.Get("done_reason").String(), s.toolCalls > 0))
+		final = ollamaUsage(final, "usage.", payload)
+		emit(final)
+		lines = append(lines, []byte("data: [DONE]"))
+	}
+	return lines, nil
+}
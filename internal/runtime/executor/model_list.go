@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// modelListTimeout bounds model discovery requests against upstream providers.
+const modelListTimeout = 10 * time.Second
+
+// fetchModelIDs GETs endpoint with credentials applied by prepare and returns the ids
+// found under listPath. The first non-empty field of idFields is used for each item.
+func fetchModelIDs(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, prepare func(*http.Request, *cliproxyauth.Auth) error, endpoint, listPath string, idFields ...string) ([]string, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, modelListTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if err = prepare(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpResp, err := newProxyAwareHTTPClient(reqCtx, cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("model list: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 8<<20))
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, statusErr{code: httpResp.StatusCode, msg: summarizeErrorBody(httpResp.Header.Get("Content-Type"), body)}
+	}
+	var models []string
+	gjson.GetBytes(body, listPath).ForEach(func(_, item gjson.Result) bool {
+		for _, field := range idFields {
+			if id := strings.TrimSpace(item.Get(field).String()); id != "" {
+				models = append(models, id)
+				break
+			}
+		}
+		return true
+	})
+	return models, nil
+}
@@ -0,0 +1,391 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/oauth2"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+// oauth2AnthropicVersion is sent to claude-format providers unless a header overrides it.
+const oauth2AnthropicVersion = "2023-06-01"
+
+// OAuth2Executor serves providers declared under oauth-providers. Requests are translated
+// to the provider's configured format and sent to its base URL with headers rendered from
+// the stored OAuth token.
+type OAuth2Executor struct {
+	provider string
+	cfg      *config.Config
+}
+
+// NewOAuth2Executor creates an executor bound to a declarative OAuth provider name.
+func NewOAuth2Executor(provider string, cfg *config.Config) *OAuth2Executor {
+	return &OAuth2Executor{provider: provider, cfg: cfg}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *OAuth2Executor) Identifier() string { return e.provider }
+
+// PrepareRequest renders the provider's header templates onto the outgoing HTTP request.
+func (e *OAuth2Executor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	p := e.cfg.FindOAuthProvider(e.provider)
+	if p == nil {
+		return fmt.Errorf("oauth2 executor: provider %q is not configured", e.provider)
+	}
+	e.applyHeaders(req, p, auth)
+	return nil
+}
+
+// HttpRequest injects the provider credentials into the request and executes it.
+func (e *OAuth2Executor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("oauth2 executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	return e.httpClient(ctx, auth, e.cfg.FindOAuthProvider(e.provider)).Do(httpReq)
+}
+
+func (e *OAuth2Executor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	p, to, translated, err := e.translateRequest(auth, req, opts, baseModel, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.send(ctx, auth, p, baseModel, translated, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("oauth2 executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, body)
+	switch p.Format {
+	case "claude":
+		reporter.publish(ctx, parseClaudeUsage(body))
+	case "gemini":
+		reporter.publish(ctx, parseGeminiUsage(body))
+	default:
+		reporter.publish(ctx, parseOpenAIUsage(body))
+	}
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out), Headers: httpResp.Header.Clone()}, nil
+}
+
+func (e *OAuth2Executor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	p, to, translated, err := e.translateRequest(auth, req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.send(ctx, auth, p, baseModel, translated, true)
+	if err != nil {
+		return nil, err
+	}
+	from := opts.SourceFormat
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("oauth2 executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		emit := func(line []byte) {
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			switch p.Format {
+			case "claude":
+				if detail, ok := parseClaudeStreamUsage(line); ok {
+					reporter.publish(ctx, detail)
+				}
+				emit(line)
+			case "gemini":
+				payload := jsonPayload(FilterSSEUsageMetadata(line))
+				if len(payload) == 0 {
+					continue
+				}
+				if detail, ok := parseGeminiStreamUsage(payload); ok {
+					reporter.publish(ctx, detail)
+				}
+				emit(payload)
+			default:
+				if detail, ok := parseOpenAIStreamUsage(line); ok {
+					reporter.publish(ctx, detail)
+				}
+				if !bytes.HasPrefix(line, []byte("data:")) {
+					continue
+				}
+				emit(line)
+			}
+		}
+		if p.Format == "gemini" {
+			emit([]byte("[DONE]"))
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens estimates prompt tokens locally; declarative providers expose no common
+// counting endpoint.
+func (e *OAuth2Executor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("oauth2 executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("oauth2 executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh renews the access token with the stored refresh token. Client-credentials
+// providers without a refresh token request a fresh token instead.
+func (e *OAuth2Executor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("oauth2 executor: refresh called for %s", e.provider)
+	if auth == nil {
+		return nil, fmt.Errorf("oauth2 executor: auth is nil")
+	}
+	p := e.cfg.FindOAuthProvider(e.provider)
+	if p == nil {
+		return nil, fmt.Errorf("oauth2 executor: provider %q is not configured", e.provider)
+	}
+	var refreshToken string
+	if auth.Metadata != nil {
+		if v, ok := auth.Metadata["refresh_token"].(string); ok {
+			refreshToken = strings.TrimSpace(v)
+		}
+	}
+	client := oauth2.NewClient(e.cfg, p)
+	var (
+		token *oauth2.Token
+		err   error
+	)
+	switch {
+	case refreshToken != "":
+		token, err = client.Refresh(ctx, refreshToken)
+	case p.Flow == config.OAuthFlowClientCredentials:
+		token, err = client.ClientCredentials(ctx)
+	default:
+		// Nothing to refresh
+		return auth, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	auth.Metadata = oauth2.ApplyToken(auth.Metadata, p.Name, token)
+	return auth, nil
+}
+
+// translateRequest converts the client payload into the provider's wire format.
+func (e *OAuth2Executor) translateRequest(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) (*config.OAuthProvider, sdktranslator.Format, []byte, error) {
+	p := e.cfg.FindOAuthProvider(e.provider)
+	if p == nil {
+		return nil, "", nil, statusErr{code: http.StatusServiceUnavailable, msg: fmt.Sprintf("oauth provider %q is not configured", e.provider)}
+	}
+	if oauth2AccessToken(auth) == "" {
+		return nil, "", nil, statusErr{code: http.StatusUnauthorized, msg: "missing access token"}
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString(p.Format)
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if p.Format == "gemini" {
+		translated, _ = sjson.DeleteBytes(translated, "session_id")
+	}
+	return p, to, translated, nil
+}
+
+// send posts body to the provider endpoint for the configured format and returns the
+// response once a 2xx status has been received.
+func (e *OAuth2Executor) send(ctx context.Context, auth *cliproxyauth.Auth, p *config.OAuthProvider, model string, body []byte, stream bool) (*http.Response, error) {
+	endpoint := oauth2Endpoint(p, model, stream)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "cli-proxy-oauth2")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	e.applyHeaders(httpReq, p, auth)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       endpoint,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpResp, err := e.httpClient(ctx, auth, p).Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("oauth2 executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+// applyHeaders renders the provider's header templates from the auth token. An
+// Authorization header is added unless the templates define one.
+func (e *OAuth2Executor) applyHeaders(req *http.Request, p *config.OAuthProvider, auth *cliproxyauth.Auth) {
+	tokenType := "Bearer"
+	if auth != nil && auth.Metadata != nil {
+		if v, ok := auth.Metadata["token_type"].(string); ok && strings.TrimSpace(v) != "" {
+			tokenType = strings.TrimSpace(v)
+		}
+	}
+	replacer := strings.NewReplacer(
+		"{access_token}", oauth2AccessToken(auth),
+		"{token_type}", tokenType,
+		"{client_id}", p.ClientID,
+	)
+	hasAuthorization := false
+	for name, value := range p.Headers {
+		if strings.EqualFold(name, "Authorization") {
+			hasAuthorization = true
+		}
+		req.Header.Set(name, replacer.Replace(value))
+	}
+	if !hasAuthorization {
+		req.Header.Set("Authorization", "Bearer "+oauth2AccessToken(auth))
+	}
+	if p.Format == "claude" && req.Header.Get("Anthropic-Version") == "" {
+		req.Header.Set("Anthropic-Version", oauth2AnthropicVersion)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+}
+
+// httpClient prefers the auth proxy, then the provider proxy, then the global proxy.
+func (e *OAuth2Executor) httpClient(ctx context.Context, auth *cliproxyauth.Auth, p *config.OAuthProvider) *http.Client {
+	if p != nil && p.ProxyURL != "" && (auth == nil || strings.TrimSpace(auth.ProxyURL) == "") {
+		return newProxyAwareHTTPClient(ctx, e.cfg, &cliproxyauth.Auth{ProxyURL: p.ProxyURL}, 0)
+	}
+	return newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+}
+
+// oauth2Endpoint returns the request URL for model in the provider's wire format.
+func oauth2Endpoint(p *config.OAuthProvider, model string, stream bool) string {
+	switch p.Format {
+	case "claude":
+		return p.BaseURL + "/messages"
+	case "gemini":
+		if stream {
+			return p.BaseURL + "/models/" + url.PathEscape(model) + ":streamGenerateContent?alt=sse"
+		}
+		return p.BaseURL + "/models/" + url.PathEscape(model) + ":generateContent"
+	default:
+		return p.BaseURL + "/chat/completions"
+	}
+}
+
+func oauth2AccessToken(auth *cliproxyauth.Auth) string {
+	if auth == nil || auth.Metadata == nil {
+		return ""
+	}
+	v, _ := auth.Metadata["access_token"].(string)
+	return strings.TrimSpace(v)
+}
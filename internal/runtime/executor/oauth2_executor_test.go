@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOAuth2ExecutorRendersHeaderTemplates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Token at-1" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get("X-Client"); got != "cid" {
+			t.Errorf("X-Client = %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if gjson.GetBytes(body, "model").String() != "acme-large" {
+			t.Errorf("unexpected body: %s", body)
+		}
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"acme-large","choices":[{"index":0,"message":{"role":"assistant","content":"hi there"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OAuthProviders: []config.OAuthProvider{{
+		Name:     "acme",
+		ClientID: "cid",
+		BaseURL:  server.URL + "/v1",
+		Format:   "openai",
+		Headers:  map[string]string{"Authorization": "{token_type} {access_token}", "X-Client": "{client_id}"},
+	}}}
+	auth := &cliproxyauth.Auth{ID: "acme-1.json", Provider: "acme", Metadata: map[string]any{"access_token": "at-1", "token_type": "Token"}}
+	resp, err := NewOAuth2Executor("acme", cfg).Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "acme-large",
+		Payload: []byte(`{"model":"acme-large","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "hi there" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestOAuth2ExecutorRefreshFallsBackToClientCredentials(t *testing.T) {
+	var grants []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		grants = append(grants, r.PostForm.Get("grant_type"))
+		_, _ = w.Write([]byte(`{"access_token":"fresh","expires_in":600}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OAuthProviders: []config.OAuthProvider{{
+		Name:         "acme",
+		Flow:         config.OAuthFlowClientCredentials,
+		ClientID:     "cid",
+		ClientSecret: "secret",
+		TokenURL:     server.URL,
+		BaseURL:      "https://api.acme.test",
+	}}}
+	exec := NewOAuth2Executor("acme", cfg)
+
+	auth := &cliproxyauth.Auth{ID: "acme-1.json", Provider: "acme", Metadata: map[string]any{"access_token": "old"}}
+	updated, err := exec.Refresh(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("Refresh error: %v", err)
+	}
+	if updated.Metadata["access_token"] != "fresh" || updated.Metadata["expired"] == nil {
+		t.Fatalf("metadata = %v", updated.Metadata)
+	}
+
+	updated.Metadata["refresh_token"] = "rt-1"
+	if _, err = exec.Refresh(context.Background(), updated); err != nil {
+		t.Fatalf("Refresh error: %v", err)
+	}
+	if len(grants) != 2 || grants[0] != "client_credentials" || grants[1] != "refresh_token" {
+		t.Fatalf("grants = %v", grants)
+	}
+}
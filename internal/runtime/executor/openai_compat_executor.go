@@ -329,6 +329,15 @@ func (e *OpenAICompatExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
 }
 
+// ListModels returns the model ids served by the provider's /models endpoint.
+func (e *OpenAICompatExecutor) ListModels(ctx context.Context, auth *cliproxyauth.Auth) ([]string, error) {
+	baseURL, _ := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai compat executor: missing provider baseURL")
+	}
+	return fetchModelIDs(ctx, e.cfg, auth, e.PrepareRequest, strings.TrimSuffix(baseURL, "/")+"/models", "data", "id")
+}
+
 // Refresh is a no-op for API-key based compatibility providers.
 func (e *OpenAICompatExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
 	log.Debugf("openai compat executor: refresh called")
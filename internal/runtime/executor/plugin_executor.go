@@ -0,0 +1,293 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/plugin"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+// PluginExecutor forwards requests to an out-of-process executor plugin. Payloads are
+// translated to the plugin's configured format and exchanged as JSON-RPC over the
+// plugin's Unix socket.
+type PluginExecutor struct {
+	provider string
+	cfg      *config.Config
+}
+
+// NewPluginExecutor creates an executor bound to a configured plugin name.
+func NewPluginExecutor(provider string, cfg *config.Config) *PluginExecutor {
+	return &PluginExecutor{provider: provider, cfg: cfg}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *PluginExecutor) Identifier() string { return e.provider }
+
+// HttpRequest is not supported; plugins are reached over RPC only.
+func (e *PluginExecutor) HttpRequest(context.Context, *cliproxyauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, statusErr{code: http.StatusNotImplemented, msg: fmt.Sprintf("plugin executor %q does not support raw HTTP requests", e.provider)}
+}
+
+func (e *PluginExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	p, to, translated, err := e.translateRequest(req, opts, baseModel, false)
+	if err != nil {
+		return resp, err
+	}
+	params := e.executeParams(ctx, auth, p, baseModel, translated, opts, false)
+	var result plugin.ExecuteResult
+	if err = plugin.NewClient(p.SocketPath()).Call(ctx, plugin.MethodExecute, params, &result); err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	body := []byte(result.Payload)
+	recordAPIResponseMetadata(ctx, e.cfg, http.StatusOK, result.Headers)
+	appendAPIResponseChunk(ctx, e.cfg, body)
+	switch p.Format {
+	case "claude":
+		reporter.publish(ctx, parseClaudeUsage(body))
+	case "gemini":
+		reporter.publish(ctx, parseGeminiUsage(body))
+	default:
+		reporter.publish(ctx, parseOpenAIUsage(body))
+	}
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out), Headers: result.Headers}, nil
+}
+
+func (e *PluginExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	p, to, translated, err := e.translateRequest(req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+	params := e.executeParams(ctx, auth, p, baseModel, translated, opts, true)
+	var result plugin.ExecuteResult
+	chunks, err := plugin.NewClient(p.SocketPath()).Stream(ctx, plugin.MethodExecuteStream, params, &result)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, http.StatusOK, result.Headers)
+	from := opts.SourceFormat
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		var param any
+		emit := func(line []byte) {
+			translatedChunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			for i := range translatedChunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(translatedChunks[i])}
+			}
+		}
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				recordAPIResponseError(ctx, e.cfg, chunk.Err)
+				reporter.publishFailure(ctx)
+				out <- cliproxyexecutor.StreamChunk{Err: chunk.Err}
+				return
+			}
+			line := bytes.TrimRight(chunk.Payload, "\r\n")
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			switch p.Format {
+			case "claude":
+				if detail, ok := parseClaudeStreamUsage(line); ok {
+					reporter.publish(ctx, detail)
+				}
+				emit(line)
+			case "gemini":
+				payload := jsonPayload(FilterSSEUsageMetadata(line))
+				if len(payload) == 0 {
+					continue
+				}
+				if detail, ok := parseGeminiStreamUsage(payload); ok {
+					reporter.publish(ctx, detail)
+				}
+				emit(payload)
+			default:
+				if detail, ok := parseOpenAIStreamUsage(line); ok {
+					reporter.publish(ctx, detail)
+				}
+				if !bytes.HasPrefix(line, []byte("data:")) {
+					continue
+				}
+				emit(line)
+			}
+		}
+		if p.Format == "gemini" {
+			emit([]byte("[DONE]"))
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}, nil
+}
+
+// CountTokens asks the plugin for a token count and falls back to the local tokenizer
+// when the plugin does not implement CountTokens.
+func (e *PluginExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+
+	p, pluginFormat, translated, err := e.translateRequest(req, opts, baseModel, false)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	var result plugin.CountTokensResult
+	errCall := plugin.NewClient(p.SocketPath()).Call(ctx, plugin.MethodCountTokens, e.executeParams(ctx, auth, p, baseModel, translated, opts, false), &result)
+	if errCall != nil && !plugin.IsMethodNotFound(errCall) {
+		return cliproxyexecutor.Response{}, errCall
+	}
+
+	to := sdktranslator.FromString("openai")
+	count := result.Tokens
+	if errCall != nil {
+		openAIPayload := translated
+		if pluginFormat != to {
+			openAIPayload = sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+		}
+		enc, errEnc := tokenizerForModel(baseModel)
+		if errEnc != nil {
+			return cliproxyexecutor.Response{}, fmt.Errorf("plugin executor: tokenizer init failed: %w", errEnc)
+		}
+		if count, errEnc = countOpenAIChatTokens(enc, openAIPayload); errEnc != nil {
+			return cliproxyexecutor.Response{}, fmt.Errorf("plugin executor: token counting failed: %w", errEnc)
+		}
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh lets the plugin renew credentials. Returned attributes and metadata replace the
+// stored keys; plugins without Refresh leave the auth unchanged.
+func (e *PluginExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("plugin executor: refresh called for %s", e.provider)
+	if auth == nil {
+		return nil, fmt.Errorf("plugin executor: auth is nil")
+	}
+	p := e.cfg.FindPlugin(e.provider)
+	if p == nil {
+		return nil, fmt.Errorf("plugin executor: plugin %q is not configured", e.provider)
+	}
+	var result plugin.RefreshResult
+	err := plugin.NewClient(p.SocketPath()).Call(ctx, plugin.MethodRefresh, plugin.AuthParams{Auth: pluginAuth(auth)}, &result)
+	if plugin.IsMethodNotFound(err) {
+		return auth, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Attributes) > 0 && auth.Attributes == nil {
+		auth.Attributes = make(map[string]string, len(result.Attributes))
+	}
+	for key, value := range result.Attributes {
+		auth.Attributes[key] = value
+	}
+	if len(result.Metadata) > 0 && auth.Metadata == nil {
+		auth.Metadata = make(map[string]any, len(result.Metadata))
+	}
+	for key, value := range result.Metadata {
+		auth.Metadata[key] = value
+	}
+	return auth, nil
+}
+
+// ListModels implements cliproxyauth.ModelLister for plugins with discovery enabled.
+func (e *PluginExecutor) ListModels(ctx context.Context, auth *cliproxyauth.Auth) ([]string, error) {
+	p := e.cfg.FindPlugin(e.provider)
+	if p == nil {
+		return nil, fmt.Errorf("plugin executor: plugin %q is not configured", e.provider)
+	}
+	var result plugin.ListModelsResult
+	if err := plugin.NewClient(p.SocketPath()).Call(ctx, plugin.MethodListModels, plugin.AuthParams{Auth: pluginAuth(auth)}, &result); err != nil {
+		return nil, err
+	}
+	return result.Models, nil
+}
+
+// translateRequest converts the client payload into the plugin's wire format.
+func (e *PluginExecutor) translateRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) (*config.ExecutorPlugin, sdktranslator.Format, []byte, error) {
+	p := e.cfg.FindPlugin(e.provider)
+	if p == nil {
+		return nil, "", nil, statusErr{code: http.StatusServiceUnavailable, msg: fmt.Sprintf("plugin %q is not configured", e.provider)}
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString(p.Format)
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if p.Format == "gemini" {
+		translated, _ = sjson.DeleteBytes(translated, "session_id")
+	}
+	return p, to, translated, nil
+}
+
+// executeParams builds the RPC params and records the outgoing request.
+func (e *PluginExecutor) executeParams(ctx context.Context, auth *cliproxyauth.Auth, p *config.ExecutorPlugin, model string, body []byte, opts cliproxyexecutor.Options, stream bool) plugin.ExecuteParams {
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       "unix://" + p.SocketPath(),
+		Method:    http.MethodPost,
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+	return plugin.ExecuteParams{
+		Auth:    pluginAuth(auth),
+		Model:   model,
+		Payload: string(body),
+		Stream:  stream,
+		Alt:     opts.Alt,
+	}
+}
+
+func pluginAuth(auth *cliproxyauth.Auth) plugin.Auth {
+	if auth == nil {
+		return plugin.Auth{}
+	}
+	return plugin.Auth{
+		ID:         auth.ID,
+		Provider:   auth.Provider,
+		Label:      auth.Label,
+		Attributes: auth.Attributes,
+		Metadata:   auth.Metadata,
+	}
+}
@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/plugin"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+type echoPlugin struct{ t *testing.T }
+
+func (p echoPlugin) Execute(_ context.Context, params *plugin.ExecuteParams) (*plugin.ExecuteResult, error) {
+	if gjson.Get(params.Payload, "model").String() != "echo-1" || params.Auth.Attributes["region"] != "eu" {
+		p.t.Errorf("unexpected params: %+v", params)
+	}
+	return &plugin.ExecuteResult{Payload: `{"id":"1","object":"chat.completion","model":"echo-1","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`}, nil
+}
+
+func (p echoPlugin) ExecuteStream(_ context.Context, params *plugin.ExecuteParams) (*plugin.ExecuteResult, <-chan cliproxyexecutor.StreamChunk, error) {
+	if !params.Stream {
+		p.t.Errorf("stream flag not set")
+	}
+	out := make(chan cliproxyexecutor.StreamChunk, 3)
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: {"id":"1","object":"chat.completion.chunk","model":"echo-1","choices":[{"index":0,"delta":{"content":"po"}}]}`)}
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: {"id":"1","object":"chat.completion.chunk","model":"echo-1","choices":[{"index":0,"delta":{"content":"ng"},"finish_reason":"stop"}]}`)}
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("data: [DONE]")}
+	close(out)
+	return nil, out, nil
+}
+
+func startEchoPlugin(t *testing.T) *config.Config {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "plg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "echo.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = plugin.Serve(ctx, socket, echoPlugin{t: t})
+	}()
+	t.Cleanup(func() { cancel(); <-done; _ = os.RemoveAll(dir) })
+	deadline := time.Now().Add(2 * time.Second)
+	for plugin.NewClient(socket).Call(context.Background(), plugin.MethodHealth, nil, nil) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("plugin did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return &config.Config{Plugins: []config.ExecutorPlugin{{Name: "echo", Socket: socket, Format: "openai"}}}
+}
+
+func TestPluginExecutorExecuteAndStream(t *testing.T) {
+	cfg := startEchoPlugin(t)
+	exec := NewPluginExecutor("echo", cfg)
+	auth := &cliproxyauth.Auth{ID: "echo-1", Provider: "echo", Attributes: map[string]string{"region": "eu"}}
+	req := cliproxyexecutor.Request{Model: "echo-1", Payload: []byte(`{"model":"echo-1","messages":[{"role":"user","content":"ping"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	resp, err := exec.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "pong" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+
+	stream, err := exec.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var text strings.Builder
+	for chunk := range stream.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		text.WriteString(gjson.GetBytes(chunk.Payload, "choices.0.delta.content").String())
+	}
+	if text.String() != "pong" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+}
+
+func TestPluginExecutorFallsBackForOptionalMethods(t *testing.T) {
+	cfg := startEchoPlugin(t)
+	exec := NewPluginExecutor("echo", cfg)
+	auth := &cliproxyauth.Auth{ID: "echo-1", Provider: "echo", Attributes: map[string]string{"region": "eu"}}
+
+	resp, err := exec.CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "echo-1",
+		Payload: []byte(`{"model":"echo-1","messages":[{"role":"user","content":"count these tokens"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("CountTokens: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int() <= 0 {
+		t.Fatalf("token count payload = %s", resp.Payload)
+	}
+
+	updated, err := exec.Refresh(context.Background(), auth)
+	if err != nil || updated != auth {
+		t.Fatalf("Refresh = %v, %v", updated, err)
+	}
+	if _, err = exec.ListModels(context.Background(), auth); !plugin.IsMethodNotFound(err) {
+		t.Fatalf("ListModels error = %v", err)
+	}
+}
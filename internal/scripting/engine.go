@@ -0,0 +1,487 @@
+// Package scripting runs sandboxed Lua hooks around request execution. A hook script may
+// define any of these global functions:
+//
+//	function on_request(req)     -- req.format, req.model, req.stream, req.route, req.body
+//	function on_response(resp)   -- resp.format, resp.model, resp.route, resp.body
+//	function on_chunk(chunk)     -- chunk.format, chunk.model, chunk.route, chunk.data
+//
+// Hooks mutate the table they receive: body, model and data are read back afterwards.
+// on_chunk may also return a string to replace the chunk or false to drop it. Calling
+// reject(status, message) aborts the request with that HTTP status.
+//
+// Scripts only see the base, string, table and math libraries plus json.decode,
+// json.encode, json.get, json.set, json.delete and log. Each call is bounded by the
+// configured timeout, and script files are reloaded when they change on disk.
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	defaultTimeoutMS    = 100
+	reloadCheckInterval = time.Second
+)
+
+// Hook function names looked up in scripts.
+const (
+	FuncRequest  = "on_request"
+	FuncResponse = "on_response"
+	FuncChunk    = "on_chunk"
+)
+
+// Error is returned when a script rejects a request or a fail-closed script fails.
+type Error struct {
+	Hook    string
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("script %s: %s", e.Hook, e.Message)
+}
+
+// StatusCode implements the status-code interface consumed by the API handlers.
+func (e *Error) StatusCode() int { return e.Status }
+
+type pathKey struct{}
+
+// WithPath records the client request path used to match hook routes.
+func WithPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathKey{}, path)
+}
+
+func pathFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	path, _ := ctx.Value(pathKey{}).(string)
+	return path
+}
+
+// Engine holds the configured hooks. It is safe for concurrent use and may be
+// reconfigured at runtime.
+type Engine struct {
+	mu      sync.RWMutex
+	timeout time.Duration
+	hooks   []*hook
+}
+
+// NewEngine creates an engine for the given configuration.
+func NewEngine(cfg config.ScriptingConfig) *Engine {
+	e := &Engine{}
+	e.Update(cfg)
+	return e
+}
+
+// Update replaces the hook set. Hooks whose definition did not change keep their compiled
+// scripts.
+func (e *Engine) Update(cfg config.ScriptingConfig) {
+	timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+	if cfg.TimeoutMS <= 0 {
+		timeout = defaultTimeoutMS * time.Millisecond
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	existing := make(map[string]*hook, len(e.hooks))
+	for _, h := range e.hooks {
+		existing[h.key()] = h
+	}
+	hooks := make([]*hook, 0, len(cfg.Hooks))
+	for i, def := range cfg.Hooks {
+		if def.Disabled {
+			continue
+		}
+		if strings.TrimSpace(def.Name) == "" {
+			def.Name = fmt.Sprintf("hook-%d", i)
+		}
+		candidate := &hook{def: def}
+		if h, ok := existing[candidate.key()]; ok {
+			hooks = append(hooks, h)
+			continue
+		}
+		if def.File == "" && strings.TrimSpace(def.Source) == "" {
+			log.Warnf("scripting: hook %s has neither file nor source, skipping", def.Name)
+			continue
+		}
+		if err := candidate.load(); err != nil {
+			log.Warnf("scripting: hook %s: %v", def.Name, err)
+		}
+		hooks = append(hooks, candidate)
+	}
+	e.timeout = timeout
+	e.hooks = hooks
+}
+
+// Enabled reports whether any hook is configured.
+func (e *Engine) Enabled() bool {
+	if e == nil {
+		return false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.hooks) > 0
+}
+
+func (e *Engine) matching(format, model, path string) ([]*hook, time.Duration) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var out []*hook
+	for _, h := range e.hooks {
+		if h.matches(format, model, path) {
+			out = append(out, h)
+		}
+	}
+	return out, e.timeout
+}
+
+// Request runs on_request of every matching hook in order.
+func (e *Engine) Request(ctx context.Context, req sdktranslator.RequestEnvelope) (sdktranslator.RequestEnvelope, error) {
+	if !e.Enabled() {
+		return req, nil
+	}
+	path := pathFromContext(ctx)
+	hooks, timeout := e.matching(req.Format.String(), req.Model, path)
+	for _, h := range hooks {
+		out, err := h.current().request(ctx, timeout, path, req)
+		if err != nil {
+			if errFail := h.failure(err); errFail != nil {
+				return req, errFail
+			}
+			continue
+		}
+		req = out
+	}
+	return req, nil
+}
+
+// Response runs on_response, or on_chunk for every chunk of a stream envelope, of every
+// matching hook in order.
+func (e *Engine) Response(ctx context.Context, resp sdktranslator.ResponseEnvelope) (sdktranslator.ResponseEnvelope, error) {
+	if !e.Enabled() {
+		return resp, nil
+	}
+	path := pathFromContext(ctx)
+	hooks, timeout := e.matching(resp.Format.String(), resp.Model, path)
+	for _, h := range hooks {
+		out, err := h.current().response(ctx, timeout, path, resp)
+		if err != nil {
+			if errFail := h.failure(err); errFail != nil {
+				return resp, errFail
+			}
+			continue
+		}
+		resp = out
+	}
+	return resp, nil
+}
+
+// RequestMiddleware adapts the engine to sdktranslator.Pipeline; hooks see the request
+// before translation.
+func (e *Engine) RequestMiddleware() sdktranslator.RequestMiddleware {
+	return func(ctx context.Context, req sdktranslator.RequestEnvelope, next sdktranslator.RequestHandler) (sdktranslator.RequestEnvelope, error) {
+		req, err := e.Request(ctx, req)
+		if err != nil {
+			return req, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// ResponseMiddleware adapts the engine to sdktranslator.Pipeline; hooks see the response
+// after translation back to the client format.
+func (e *Engine) ResponseMiddleware() sdktranslator.ResponseMiddleware {
+	return func(ctx context.Context, resp sdktranslator.ResponseEnvelope, next sdktranslator.ResponseHandler) (sdktranslator.ResponseEnvelope, error) {
+		resp, err := next(ctx, resp)
+		if err != nil {
+			return resp, err
+		}
+		return e.Response(ctx, resp)
+	}
+}
+
+// hook is a configured script with its matching rules.
+type hook struct {
+	def config.ScriptHook
+
+	mu       sync.Mutex
+	script   *Script
+	modTime  time.Time
+	size     int64
+	checked  time.Time
+	loadErrs int
+}
+
+func (h *hook) key() string {
+	return strings.Join([]string{h.def.Name, h.def.File, h.def.Source, strings.Join(h.def.Models, ","), strings.Join(h.def.Routes, ","), fmt.Sprint(h.def.FailClosed)}, "\x00")
+}
+
+func (h *hook) matches(format, model, path string) bool {
+	if len(h.def.Models) > 0 {
+		model = strings.ToLower(strings.TrimSpace(model))
+		matched := false
+		for _, pattern := range h.def.Models {
+			if wildcard.Match(strings.ToLower(strings.TrimSpace(pattern)), model) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(h.def.Routes) > 0 {
+		for _, pattern := range h.def.Routes {
+			pattern = strings.TrimSpace(pattern)
+			if strings.EqualFold(pattern, format) || (path != "" && wildcard.Match(pattern, path)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// load compiles the hook source, reading the file when one is configured.
+func (h *hook) load() error {
+	source := h.def.Source
+	if h.def.File != "" {
+		info, err := os.Stat(h.def.File)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(h.def.File)
+		if err != nil {
+			return err
+		}
+		source = string(data)
+		h.modTime, h.size = info.ModTime(), info.Size()
+	}
+	h.checked = time.Now()
+	script, err := Compile(h.def.Name, source)
+	if err != nil {
+		return err
+	}
+	h.script = script
+	return nil
+}
+
+// current returns the compiled script, reloading the file when it changed. A script that
+// fails to reload keeps serving the previous version.
+func (h *hook) current() *Script {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.def.File != "" && time.Since(h.checked) >= reloadCheckInterval {
+		h.checked = time.Now()
+		if info, err := os.Stat(h.def.File); err == nil && (!info.ModTime().Equal(h.modTime) || info.Size() != h.size) {
+			if errLoad := h.load(); errLoad != nil {
+				log.Warnf("scripting: reload of hook %s failed, keeping previous version: %v", h.def.Name, errLoad)
+			} else {
+				log.Infof("scripting: reloaded hook %s from %s", h.def.Name, h.def.File)
+			}
+		}
+	}
+	return h.script
+}
+
+// failure decides whether a script error aborts the request.
+func (h *hook) failure(err error) error {
+	var scriptErr *Error
+	if errors.As(err, &scriptErr) {
+		return scriptErr
+	}
+	if h.def.FailClosed {
+		return &Error{Hook: h.def.Name, Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+	log.Warnf("scripting: hook %s failed, passing payload through: %v", h.def.Name, err)
+	return nil
+}
+
+// Script is a compiled hook script. States are pooled; globals set by the script persist
+// within a pooled state but are not shared between states.
+type Script struct {
+	name  string
+	proto *lua.FunctionProto
+	pool  sync.Pool
+}
+
+// Compile parses and compiles a Lua hook script.
+func Compile(name, source string) (*Script, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w", err)
+	}
+	return &Script{name: name, proto: proto}, nil
+}
+
+// Request runs the script's on_request against req.
+func (s *Script) Request(ctx context.Context, timeout time.Duration, req sdktranslator.RequestEnvelope) (sdktranslator.RequestEnvelope, error) {
+	return s.request(ctx, timeout, pathFromContext(ctx), req)
+}
+
+// Response runs the script's on_response, or on_chunk for stream envelopes, against resp.
+func (s *Script) Response(ctx context.Context, timeout time.Duration, resp sdktranslator.ResponseEnvelope) (sdktranslator.ResponseEnvelope, error) {
+	return s.response(ctx, timeout, pathFromContext(ctx), resp)
+}
+
+func (s *Script) request(ctx context.Context, timeout time.Duration, path string, req sdktranslator.RequestEnvelope) (sdktranslator.RequestEnvelope, error) {
+	if s == nil {
+		return req, nil
+	}
+	err := s.with(ctx, timeout, func(L *lua.LState) error {
+		fn, ok := L.GetGlobal(FuncRequest).(*lua.LFunction)
+		if !ok {
+			return nil
+		}
+		t := L.NewTable()
+		t.RawSetString("format", lua.LString(req.Format.String()))
+		t.RawSetString("model", lua.LString(req.Model))
+		t.RawSetString("stream", lua.LBool(req.Stream))
+		t.RawSetString("route", lua.LString(path))
+		t.RawSetString("body", lua.LString(req.Body))
+		if err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, t); err != nil {
+			return err
+		}
+		req.Body = []byte(lua.LVAsString(t.RawGetString("body")))
+		if model := lua.LVAsString(t.RawGetString("model")); model != "" {
+			req.Model = model
+		}
+		return nil
+	})
+	return req, err
+}
+
+func (s *Script) response(ctx context.Context, timeout time.Duration, path string, resp sdktranslator.ResponseEnvelope) (sdktranslator.ResponseEnvelope, error) {
+	if s == nil {
+		return resp, nil
+	}
+	err := s.with(ctx, timeout, func(L *lua.LState) error {
+		if resp.Stream {
+			fn, ok := L.GetGlobal(FuncChunk).(*lua.LFunction)
+			if !ok {
+				return nil
+			}
+			chunks := make([]string, 0, len(resp.Chunks))
+			for _, chunk := range resp.Chunks {
+				t := L.NewTable()
+				t.RawSetString("format", lua.LString(resp.Format.String()))
+				t.RawSetString("model", lua.LString(resp.Model))
+				t.RawSetString("route", lua.LString(path))
+				t.RawSetString("data", lua.LString(chunk))
+				if err := L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, t); err != nil {
+					return err
+				}
+				ret := L.Get(-1)
+				L.Pop(1)
+				switch v := ret.(type) {
+				case lua.LBool:
+					if !bool(v) {
+						continue
+					}
+					chunks = append(chunks, lua.LVAsString(t.RawGetString("data")))
+				case lua.LString:
+					chunks = append(chunks, string(v))
+				default:
+					chunks = append(chunks, lua.LVAsString(t.RawGetString("data")))
+				}
+			}
+			resp.Chunks = chunks
+			return nil
+		}
+		fn, ok := L.GetGlobal(FuncResponse).(*lua.LFunction)
+		if !ok {
+			return nil
+		}
+		t := L.NewTable()
+		t.RawSetString("format", lua.LString(resp.Format.String()))
+		t.RawSetString("model", lua.LString(resp.Model))
+		t.RawSetString("route", lua.LString(path))
+		t.RawSetString("body", lua.LString(resp.Body))
+		if err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, t); err != nil {
+			return err
+		}
+		resp.Body = []byte(lua.LVAsString(t.RawGetString("body")))
+		return nil
+	})
+	return resp, err
+}
+
+// with runs fn on a pooled state under the call timeout. States that fail are discarded
+// rather than returned to the pool.
+func (s *Script) with(ctx context.Context, timeout time.Duration, fn func(L *lua.LState) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeoutMS * time.Millisecond
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	L, err := s.state(callCtx)
+	if err != nil {
+		return s.wrap(callCtx, err)
+	}
+	L.SetContext(callCtx)
+	err = fn(L)
+	L.RemoveContext()
+	if err != nil {
+		L.Close()
+		return s.wrap(callCtx, err)
+	}
+	L.SetTop(0)
+	s.pool.Put(L)
+	return nil
+}
+
+// state returns a pooled state or creates one and runs the script's top-level chunk.
+func (s *Script) state(ctx context.Context) (*lua.LState, error) {
+	if L, ok := s.pool.Get().(*lua.LState); ok {
+		return L, nil
+	}
+	L := newSandbox(s.name)
+	L.SetContext(ctx)
+	L.Push(L.NewFunctionFromProto(s.proto))
+	err := L.PCall(0, lua.MultRet, nil)
+	L.RemoveContext()
+	if err != nil {
+		L.Close()
+		return nil, err
+	}
+	L.SetTop(0)
+	return L, nil
+}
+
+// wrap converts Lua errors, turning reject() calls into *Error and reporting timeouts.
+func (s *Script) wrap(ctx context.Context, err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		if ud, ok := apiErr.Object.(*lua.LUserData); ok {
+			if r, ok := ud.Value.(*rejection); ok {
+				return &Error{Hook: s.name, Status: r.status, Message: r.message}
+			}
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("script %s: execution time limit exceeded", s.name)
+	}
+	return fmt.Errorf("script %s: %w", s.name, err)
+}
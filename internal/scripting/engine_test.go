@@ -0,0 +1,168 @@
+package scripting
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func openAIRequest(model, body string) sdktranslator.RequestEnvelope {
+	return sdktranslator.RequestEnvelope{Format: sdktranslator.FromString("openai"), Model: model, Body: []byte(body)}
+}
+
+func TestRequestHookMutatesBodyAndModel(t *testing.T) {
+	engine := NewEngine(config.ScriptingConfig{Hooks: []config.ScriptHook{{
+		Name:   "rewrite",
+		Models: []string{"gpt-*"},
+		Routes: []string{"openai"},
+		Source: `
+function on_request(req)
+  local body = json.decode(req.body)
+  body.temperature = 0.5
+  body.messages[#body.messages + 1] = {role = "system", content = "be brief"}
+  req.body = json.encode(body)
+  req.model = "gpt-mini"
+end`,
+	}}})
+
+	out, err := engine.Request(context.Background(), openAIRequest("gpt-large", `{"model":"gpt-large","messages":[{"role":"user","content":"hi"}],"tools":[]}`))
+	if err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if out.Model != "gpt-mini" || gjson.GetBytes(out.Body, "temperature").Float() != 0.5 {
+		t.Fatalf("out = %s %s", out.Model, out.Body)
+	}
+	if gjson.GetBytes(out.Body, "messages.#").Int() != 2 || !gjson.GetBytes(out.Body, "tools").IsArray() {
+		t.Fatalf("arrays not preserved: %s", out.Body)
+	}
+
+	untouched, err := engine.Request(context.Background(), openAIRequest("claude-sonnet", `{"x":1}`))
+	if err != nil || string(untouched.Body) != `{"x":1}` {
+		t.Fatalf("non-matching model was modified: %s %v", untouched.Body, err)
+	}
+}
+
+func TestRejectAndFailureModes(t *testing.T) {
+	engine := NewEngine(config.ScriptingConfig{TimeoutMS: 20, Hooks: []config.ScriptHook{
+		{Name: "guard", Source: `function on_request(req) if json.get(req.body, "blocked") then reject(403, "blocked by policy") end end`},
+		{Name: "buggy", Source: `function on_request(req) error("boom") end`},
+		{Name: "spin", Routes: []string{"/v1/spin"}, FailClosed: true, Source: `function on_request(req) while true do end end`},
+	}})
+
+	_, err := engine.Request(context.Background(), openAIRequest("m", `{"blocked":true}`))
+	var scriptErr *Error
+	if !errors.As(err, &scriptErr) || scriptErr.StatusCode() != 403 || scriptErr.Message != "blocked by policy" {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+
+	// A failing fail-open hook passes the payload through.
+	out, err := engine.Request(context.Background(), openAIRequest("m", `{"ok":true}`))
+	if err != nil || string(out.Body) != `{"ok":true}` {
+		t.Fatalf("fail-open hook: %s %v", out.Body, err)
+	}
+
+	started := time.Now()
+	_, err = engine.Request(WithPath(context.Background(), "/v1/spin"), openAIRequest("m", `{}`))
+	if !errors.As(err, &scriptErr) || scriptErr.StatusCode() != 500 || !strings.Contains(err.Error(), "time limit") {
+		t.Fatalf("expected time limit error, got %v", err)
+	}
+	if time.Since(started) > 2*time.Second {
+		t.Fatalf("timeout not enforced")
+	}
+}
+
+func TestSandboxHidesUnsafeFunctions(t *testing.T) {
+	script, err := Compile("probe", `
+function on_request(req)
+  req.body = tostring(io) .. "," .. tostring(os) .. "," .. tostring(require) .. "," .. tostring(dofile) .. "," .. tostring(load)
+end`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	out, err := script.Request(context.Background(), time.Second, openAIRequest("m", ""))
+	if err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if string(out.Body) != "nil,nil,nil,nil,nil" {
+		t.Fatalf("sandbox exposes %s", out.Body)
+	}
+}
+
+func TestChunkAndResponseHooks(t *testing.T) {
+	engine := NewEngine(config.ScriptingConfig{Hooks: []config.ScriptHook{{
+		Name: "filter",
+		Source: `
+function on_chunk(chunk)
+  if string.find(chunk.data, "secret", 1, true) then return false end
+  return (string.gsub(chunk.data, "foo", "bar"))
+end
+function on_response(resp)
+  resp.body = json.set(resp.body, "choices.0.message.content", "[filtered]")
+end`,
+	}}})
+
+	out, err := engine.Response(context.Background(), sdktranslator.ResponseEnvelope{
+		Format: sdktranslator.FromString("openai"),
+		Stream: true,
+		Chunks: []string{"data: foo", "data: secret", "data: baz"},
+	})
+	if err != nil {
+		t.Fatalf("Response: %v", err)
+	}
+	if len(out.Chunks) != 2 || out.Chunks[0] != "data: bar" || out.Chunks[1] != "data: baz" {
+		t.Fatalf("chunks = %q", out.Chunks)
+	}
+
+	out, err = engine.Response(context.Background(), sdktranslator.ResponseEnvelope{
+		Format: sdktranslator.FromString("openai"),
+		Body:   []byte(`{"choices":[{"message":{"content":"hello"}}]}`),
+	})
+	if err != nil || gjson.GetBytes(out.Body, "choices.0.message.content").String() != "[filtered]" {
+		t.Fatalf("response = %s %v", out.Body, err)
+	}
+}
+
+func TestHookReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.lua")
+	write := func(tag string, mod time.Time) {
+		src := `function on_request(req) req.body = "` + tag + `" end`
+		if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v1", time.Now().Add(-time.Hour))
+	engine := NewEngine(config.ScriptingConfig{Hooks: []config.ScriptHook{{Name: "file", File: path}}})
+
+	out, _ := engine.Request(context.Background(), openAIRequest("m", ""))
+	if string(out.Body) != "v1" {
+		t.Fatalf("body = %s", out.Body)
+	}
+
+	write("v2", time.Now())
+	engine.hooks[0].checked = time.Time{}
+	out, _ = engine.Request(context.Background(), openAIRequest("m", ""))
+	if string(out.Body) != "v2" {
+		t.Fatalf("reloaded body = %s", out.Body)
+	}
+
+	// A broken edit keeps the previous version.
+	if err := os.WriteFile(path, []byte("function on_request("), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	engine.hooks[0].checked = time.Time{}
+	out, _ = engine.Request(context.Background(), openAIRequest("m", ""))
+	if string(out.Body) != "v2" {
+		t.Fatalf("body after broken edit = %s", out.Body)
+	}
+}
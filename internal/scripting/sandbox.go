@@ -0,0 +1,228 @@
+package scripting
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// unsafeBaseFunctions are removed from the base library: they load code or reach the
+// file system.
+var unsafeBaseFunctions = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage", "newproxy"}
+
+// arrayMarker is the metatable registry key that marks tables decoded from JSON arrays,
+// so empty arrays encode back as [] rather than {}.
+const arrayMarker = "cliproxy.json.array"
+
+// newSandbox returns a Lua state with only the base, string, table and math libraries,
+// plus the json, log and reject helpers.
+func newSandbox(name string) *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true, CallStackSize: 256, RegistryMaxSize: 1 << 16})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, fn := range unsafeBaseFunctions {
+		L.SetGlobal(fn, lua.LNil)
+	}
+
+	L.NewTypeMetatable(arrayMarker)
+	jsonMod := L.NewTable()
+	L.SetFuncs(jsonMod, map[string]lua.LGFunction{
+		"decode": luaJSONDecode,
+		"encode": luaJSONEncode,
+		"get":    luaJSONGet,
+		"set":    luaJSONSet,
+		"delete": luaJSONDelete,
+	})
+	L.SetGlobal("json", jsonMod)
+
+	logFn := L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, 0, L.GetTop())
+		for i := 1; i <= L.GetTop(); i++ {
+			parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+		}
+		log.Debugf("script %s: %s", name, strings.Join(parts, " "))
+		return 0
+	})
+	L.SetGlobal("log", logFn)
+	L.SetGlobal("print", logFn)
+	L.SetGlobal("reject", L.NewFunction(luaReject))
+	return L
+}
+
+// rejection is raised by reject(status, message) and surfaces as an *Error.
+type rejection struct {
+	status  int
+	message string
+}
+
+func luaReject(L *lua.LState) int {
+	status := L.CheckInt(1)
+	if status < 400 || status > 599 {
+		L.ArgError(1, "status must be between 400 and 599")
+	}
+	ud := L.NewUserData()
+	ud.Value = &rejection{status: status, message: L.OptString(2, "rejected by script")}
+	L.Error(ud, 0)
+	return 0
+}
+
+func luaJSONDecode(L *lua.LState) int {
+	var v any
+	if err := json.Unmarshal([]byte(L.CheckString(1)), &v); err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(toLua(L, v))
+	return 1
+}
+
+func luaJSONEncode(L *lua.LState) int {
+	v, err := fromLua(L, L.CheckAny(1), 0)
+	if err != nil {
+		L.RaiseError("json.encode: %v", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		L.RaiseError("json.encode: %v", err)
+	}
+	L.Push(lua.LString(data))
+	return 1
+}
+
+// luaJSONGet reads a gjson path from a JSON string without decoding the whole document.
+func luaJSONGet(L *lua.LState) int {
+	result := gjson.Get(L.CheckString(1), L.CheckString(2))
+	if !result.Exists() {
+		L.Push(lua.LNil)
+		return 1
+	}
+	L.Push(toLua(L, result.Value()))
+	return 1
+}
+
+// luaJSONSet writes a value at an sjson path and returns the updated JSON string.
+func luaJSONSet(L *lua.LState) int {
+	v, err := fromLua(L, L.CheckAny(3), 0)
+	if err != nil {
+		L.RaiseError("json.set: %v", err)
+	}
+	out, err := sjson.Set(L.CheckString(1), L.CheckString(2), v)
+	if err != nil {
+		L.RaiseError("json.set: %v", err)
+	}
+	L.Push(lua.LString(out))
+	return 1
+}
+
+func luaJSONDelete(L *lua.LState) int {
+	out, err := sjson.Delete(L.CheckString(1), L.CheckString(2))
+	if err != nil {
+		L.RaiseError("json.delete: %v", err)
+	}
+	L.Push(lua.LString(out))
+	return 1
+}
+
+// toLua converts a decoded JSON value into a Lua value. JSON null becomes nil.
+func toLua(L *lua.LState, v any) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(val)
+	case float64:
+		return lua.LNumber(val)
+	case string:
+		return lua.LString(val)
+	case []any:
+		t := L.CreateTable(len(val), 0)
+		for _, item := range val {
+			t.Append(toLua(L, item))
+		}
+		L.SetMetatable(t, L.GetTypeMetatable(arrayMarker))
+		return t
+	case map[string]any:
+		t := L.CreateTable(0, len(val))
+		for key, item := range val {
+			t.RawSetString(key, toLua(L, item))
+		}
+		return t
+	default:
+		return lua.LString(fmt.Sprint(val))
+	}
+}
+
+// fromLua converts a Lua value into a JSON-encodable value. Tables with keys 1..n, or
+// tables decoded from JSON arrays, become arrays; other tables become objects.
+func fromLua(L *lua.LState, v lua.LValue, depth int) (any, error) {
+	if depth > 64 {
+		return nil, fmt.Errorf("nesting too deep")
+	}
+	switch val := v.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(val), nil
+	case lua.LNumber:
+		f := float64(val)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("cannot encode %v", f)
+		}
+		return f, nil
+	case lua.LString:
+		return string(val), nil
+	case *lua.LTable:
+		n := val.Len()
+		isArray := L.GetMetatable(val) == L.GetTypeMetatable(arrayMarker)
+		if !isArray && n > 0 {
+			count := 0
+			val.ForEach(func(lua.LValue, lua.LValue) { count++ })
+			isArray = count == n
+		}
+		if isArray {
+			out := make([]any, 0, n)
+			for i := 1; i <= n; i++ {
+				item, err := fromLua(L, val.RawGetInt(i), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, item)
+			}
+			return out, nil
+		}
+		out := make(map[string]any)
+		var errField error
+		val.ForEach(func(key, item lua.LValue) {
+			if errField != nil {
+				return
+			}
+			converted, err := fromLua(L, item, depth+1)
+			if err != nil {
+				errField = err
+				return
+			}
+			out[key.String()] = converted
+		})
+		return out, errField
+	default:
+		return nil, fmt.Errorf("cannot encode %s", v.Type().String())
+	}
+}
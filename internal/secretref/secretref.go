@@ -0,0 +1,156 @@
+// Package secretref resolves secret references used in place of literal keys in
+// config.yaml, such as "env:ANTHROPIC_API_KEY", "file:/run/secrets/claude" or
+// "vault:secret/data/cliproxy#claude".
+//
+// A reference is "<scheme>:<ref>" where scheme names a registered Resolver.
+// The env, file and vault schemes are registered by default; embedders can add
+// their own with Register.
+package secretref
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
+)
+
+// Resolver turns the scheme-specific part of a reference into the secret value.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Resolver)
+)
+
+func init() {
+	Register("env", ResolverFunc(resolveEnv))
+	Register("file", ResolverFunc(resolveFile))
+	Register("vault", NewVaultResolverFromEnv())
+}
+
+// Register installs r for scheme, replacing any previous resolver. A nil r removes it.
+func Register(scheme string, r Resolver) {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" {
+		return
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if r == nil {
+		delete(registry, scheme)
+		return
+	}
+	registry[scheme] = r
+}
+
+// Schemes returns the registered schemes in sorted order.
+func Schemes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]string, 0, len(registry))
+	for scheme := range registry {
+		out = append(out, scheme)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func lookup(value string) (Resolver, string, bool) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok || ref == "" {
+		return nil, "", false
+	}
+	registryMu.RLock()
+	r, found := registry[strings.ToLower(scheme)]
+	registryMu.RUnlock()
+	return r, ref, found
+}
+
+// IsReference reports whether value uses a registered reference scheme.
+func IsReference(value string) bool {
+	_, _, ok := lookup(strings.TrimSpace(value))
+	return ok
+}
+
+// Allowed reports whether the reference value matches one of patterns, which are
+// references themselves: "env:NAME" matches an environment variable name and may use
+// '*' wildcards, "file:/dir/" matches files below a directory after cleaning the path,
+// and other schemes match by prefix of the scheme-specific part.
+func Allowed(value string, patterns []string) bool {
+	scheme, ref, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok || ref == "" {
+		return false
+	}
+	scheme = strings.ToLower(scheme)
+	for _, pattern := range patterns {
+		patternScheme, patternRef, okPattern := strings.Cut(strings.TrimSpace(pattern), ":")
+		if !okPattern || patternRef == "" || !strings.EqualFold(patternScheme, scheme) {
+			continue
+		}
+		switch scheme {
+		case "env":
+			if wildcard.Match(patternRef, strings.TrimSpace(ref)) {
+				return true
+			}
+		case "file":
+			cleaned := path.Clean(strings.TrimSpace(ref))
+			dir := strings.TrimSuffix(patternRef, "/")
+			if cleaned == patternRef || (dir != "" && strings.HasPrefix(cleaned, dir+"/")) {
+				return true
+			}
+		default:
+			if strings.HasPrefix(ref, patternRef) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resolve returns the secret for a reference. Values that are not references are
+// returned unchanged with resolved=false.
+func Resolve(ctx context.Context, value string) (secret string, resolved bool, err error) {
+	r, ref, ok := lookup(strings.TrimSpace(value))
+	if !ok {
+		return value, false, nil
+	}
+	secret, err = r.Resolve(ctx, ref)
+	if err != nil {
+		return "", false, fmt.Errorf("resolve secret reference %q: %w", value, err)
+	}
+	if secret == "" {
+		return "", false, fmt.Errorf("resolve secret reference %q: empty secret", value)
+	}
+	return secret, true, nil
+}
+
+func resolveEnv(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(strings.TrimSpace(name))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func resolveFile(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
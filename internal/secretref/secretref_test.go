@@ -0,0 +1,88 @@
+package secretref
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveBuiltinSchemes(t *testing.T) {
+	t.Setenv("SECRETREF_TEST_KEY", "sk-from-env")
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cases := map[string]string{
+		"env:SECRETREF_TEST_KEY": "sk-from-env",
+		"file:" + path:           "sk-from-file",
+		"sk-literal":             "sk-literal",
+		"https://example.com":    "https://example.com",
+	}
+	for value, want := range cases {
+		got, _, err := Resolve(context.Background(), value)
+		if err != nil || got != want {
+			t.Fatalf("Resolve(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, _, err := Resolve(context.Background(), "env:SECRETREF_TEST_MISSING"); err == nil {
+		t.Fatalf("expected error for unset variable")
+	}
+}
+
+func TestVaultResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/cliproxy":
+			_, _ = w.Write([]byte(`{"data":{"data":{"claude":"sk-ant-vault"},"metadata":{"version":3}}}`))
+		case "/v1/kv/cliproxy":
+			_, _ = w.Write([]byte(`{"data":{"value":"sk-kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	Register("vault", &VaultResolver{Addr: srv.URL, Token: "test-token"})
+	t.Cleanup(func() { Register("vault", NewVaultResolverFromEnv()) })
+
+	got, resolved, err := Resolve(context.Background(), "vault:secret/data/cliproxy#claude")
+	if err != nil || !resolved || got != "sk-ant-vault" {
+		t.Fatalf("kv2 = %q, %v, %v", got, resolved, err)
+	}
+	if got, _, err = Resolve(context.Background(), "vault:kv/cliproxy"); err != nil || got != "sk-kv1" {
+		t.Fatalf("kv1 = %q, %v", got, err)
+	}
+	if _, _, err = Resolve(context.Background(), "vault:secret/data/missing#claude"); err == nil {
+		t.Fatalf("expected error for missing secret")
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	patterns := []string{"env:OPENAI_*", "file:/run/secrets/", "vault:secret/data/cliproxy/"}
+	cases := map[string]bool{
+		"env:OPENAI_API_KEY":                     true,
+		"env:MANAGEMENT_PASSWORD":                false,
+		"file:/run/secrets/claude":               true,
+		"file:/run/secrets/../../etc/passwd":     false,
+		"file:/run/secretsx/claude":              false,
+		"vault:secret/data/cliproxy/keys#openai": true,
+		"vault:secret/data/other#key":            false,
+		"sk-literal":                             false,
+	}
+	for value, want := range cases {
+		if got := Allowed(value, patterns); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if Allowed("env:OPENAI_API_KEY", nil) {
+		t.Error("reference allowed without patterns")
+	}
+}
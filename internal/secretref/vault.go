@@ -0,0 +1,117 @@
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultVaultField is read when a vault reference names no field.
+	defaultVaultField = "value"
+	vaultTimeout      = 10 * time.Second
+)
+
+// VaultResolver reads secrets from a HashiCorp Vault compatible HTTP API.
+//
+// References have the form "<mount>/<path>#<field>", for example
+// "secret/data/cliproxy#claude". Both KV v2 ("data.data") and KV v1 ("data")
+// responses are understood. The field defaults to "value".
+type VaultResolver struct {
+	// Addr is the server base URL, e.g. https://vault.example.com:8200.
+	Addr string
+	// Token is sent as X-Vault-Token.
+	Token string
+	// Namespace is sent as X-Vault-Namespace when set.
+	Namespace string
+	// Client performs requests; nil uses a client with a 10s timeout.
+	Client *http.Client
+	// fromEnv re-reads Addr, Token and Namespace from the environment on every call.
+	fromEnv bool
+}
+
+// NewVaultResolverFromEnv returns a resolver configured from VAULT_ADDR, VAULT_TOKEN
+// and VAULT_NAMESPACE at resolve time.
+func NewVaultResolverFromEnv() *VaultResolver {
+	return &VaultResolver{fromEnv: true}
+}
+
+// Resolve fetches ref from Vault.
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	addr, token, namespace := v.Addr, v.Token, v.Namespace
+	if v.fromEnv {
+		addr = os.Getenv("VAULT_ADDR")
+		token = os.Getenv("VAULT_TOKEN")
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	addr = strings.TrimRight(strings.TrimSpace(addr), "/")
+	if addr == "" {
+		return "", fmt.Errorf("vault address is not configured (VAULT_ADDR)")
+	}
+	path, field, _ := strings.Cut(strings.TrimSpace(ref), "#")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("vault path is empty")
+	}
+	if field == "" {
+		field = defaultVaultField
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	if token = strings.TrimSpace(token); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace = strings.TrimSpace(namespace); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: vaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("vault read failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for %s", resp.StatusCode, path)
+	}
+
+	var payload struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("vault response is not valid JSON: %w", err)
+	}
+	data := payload.Data
+	// KV v2 nests the secret under data.data next to data.metadata.
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no field %q", path, field)
+	}
+	secret, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s field %q is not a string", path, field)
+	}
+	return secret, nil
+}
@@ -17,14 +17,16 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	objectStoreConfigKey  = "config/config.yaml"
-	objectStoreAuthPrefix = "auths"
+	objectStoreConfigKey    = "config/config.yaml"
+	objectStoreAuthPrefix   = "auths"
+	objectStoreBackupPrefix = "backups"
 )
 
 // ObjectStoreConfig captures configuration for the object storage-backed token store.
@@ -187,12 +189,15 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 		if err = auth.Storage.SaveTokenToFile(path); err != nil {
 			return "", err
 		}
+		if err = encryption.SealFile(path); err != nil {
+			return "", fmt.Errorf("object store: encrypt auth file: %w", err)
+		}
 	case auth.Metadata != nil:
 		raw, errMarshal := json.Marshal(auth.Metadata)
 		if errMarshal != nil {
 			return "", fmt.Errorf("object store: marshal metadata: %w", errMarshal)
 		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
+		if existing, errRead := encryption.ReadFile(path); errRead == nil {
 			if jsonEqual(existing, raw) {
 				return path, nil
 			}
@@ -200,7 +205,7 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 			return "", fmt.Errorf("object store: read existing metadata: %w", errRead)
 		}
 		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		if errWrite := encryption.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("object store: write temp auth file: %w", errWrite)
 		}
 		if errRename := os.Rename(tmp, path); errRename != nil {
@@ -324,6 +329,31 @@ func (s *ObjectTokenStore) PersistConfig(ctx context.Context) error {
 	return s.putObject(ctx, objectStoreConfigKey, data, "application/x-yaml")
 }
 
+// UploadBackup copies a local backup archive to "backups/<name>" in the bucket.
+func (s *ObjectTokenStore) UploadBackup(ctx context.Context, name, path string) error {
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "" || name == "." {
+		return fmt.Errorf("object store: backup name is empty")
+	}
+	fullKey := s.prefixedKey(objectStoreBackupPrefix + "/" + name)
+	_, err := s.client.FPutObject(ctx, s.cfg.Bucket, fullKey, path, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		return fmt.Errorf("object store: put object %s: %w", fullKey, err)
+	}
+	return nil
+}
+
+// DeleteBackup removes a backup archive previously stored with UploadBackup.
+func (s *ObjectTokenStore) DeleteBackup(ctx context.Context, name string) error {
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "" || name == "." {
+		return nil
+	}
+	return s.deleteObject(ctx, objectStoreBackupPrefix+"/"+name)
+}
+
 func (s *ObjectTokenStore) ensureBucket(ctx context.Context) error {
 	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
 	if err != nil {
@@ -554,7 +584,7 @@ func (s *ObjectTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := encryption.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -0,0 +1,16 @@
+package completions
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	openaicompletions "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/completions"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		OpenAICompletions,
+		Antigravity,
+		openaicompletions.ChainRequest(Antigravity),
+		openaicompletions.ChainResponse(Antigravity),
+	)
+}
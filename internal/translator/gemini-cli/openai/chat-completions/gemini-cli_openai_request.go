@@ -67,6 +67,23 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		out, _ = sjson.SetBytes(out, "request.generationConfig.topK", tkr.Num)
 	}
 
+	// Stop sequences (OpenAI 'stop' parameter, string or array)
+	if stop := gjson.GetBytes(rawJSON, "stop"); stop.Exists() {
+		var stopSequences []string
+		if stop.IsArray() {
+			for _, value := range stop.Array() {
+				if value.String() != "" {
+					stopSequences = append(stopSequences, value.String())
+				}
+			}
+		} else if stop.String() != "" {
+			stopSequences = append(stopSequences, stop.String())
+		}
+		if len(stopSequences) > 0 {
+			out, _ = sjson.SetBytes(out, "request.generationConfig.stopSequences", stopSequences)
+		}
+	}
+
 	// Candidate count (OpenAI 'n' parameter)
 	if n := gjson.GetBytes(rawJSON, "n"); n.Exists() && n.Type == gjson.Number {
 		if val := n.Int(); val > 1 {
@@ -0,0 +1,56 @@
+package completions
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+// chainState holds the state of both translation steps of a streamed response.
+type chainState struct {
+	chatRequest []byte
+	chat        any
+	completion  any
+}
+
+// ChainRequest returns a request translator from the completions format to target. The
+// request is converted to chat completions first and then handed to the chat translator
+// registered for target, so every backend that speaks chat also serves completions.
+func ChainRequest(target string) interfaces.TranslateRequestFunc {
+	return func(modelName string, rawJSON []byte, stream bool) []byte {
+		chat := ConvertOpenAICompletionsRequestToOpenAI(modelName, rawJSON, stream)
+		return translator.Request(OpenAI, target, modelName, chat, stream)
+	}
+}
+
+// ChainResponse returns the response translators matching ChainRequest: target responses
+// are translated to chat completions and then to text_completion.
+func ChainResponse(target string) interfaces.TranslateResponse {
+	return interfaces.TranslateResponse{
+		Stream: func(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+			if *param == nil {
+				*param = &chainState{chatRequest: ConvertOpenAICompletionsRequestToOpenAI(modelName, originalRequestRawJSON, true)}
+			}
+			st := (*param).(*chainState)
+			var out []string
+			for _, chunk := range translator.Response(target, OpenAI, ctx, modelName, st.chatRequest, requestRawJSON, rawJSON, &st.chat) {
+				out = append(out, ConvertOpenAIResponseToOpenAICompletions(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(chunk), &st.completion)...)
+			}
+			// Chat translators swallow the end marker, but the completions step needs it
+			// to release text held back for stop sequence matching.
+			if bytes.Equal(bytes.TrimSpace(bytes.TrimPrefix(rawJSON, []byte("data:"))), []byte("[DONE]")) {
+				out = append(out, ConvertOpenAIResponseToOpenAICompletions(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte("[DONE]"), &st.completion)...)
+			}
+			return out
+		},
+		NonStream: func(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
+			chatRequest := ConvertOpenAICompletionsRequestToOpenAI(modelName, originalRequestRawJSON, false)
+			var chatParam any
+			chat := translator.ResponseNonStream(target, OpenAI, ctx, modelName, chatRequest, requestRawJSON, rawJSON, &chatParam)
+			return ConvertOpenAIResponseToOpenAICompletionsNonStream(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(chat), nil)
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/chat-completions"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/chat-completions"
+	"github.com/tidwall/gjson"
+)
+
+func TestChainToClaudeKeepsFIMPromptAndStopSequences(t *testing.T) {
+	request := []byte(`{"model":"claude-x","prompt":"def add(a, b):\n    ","suffix":"\n\nprint(add(1, 2))","stop":["\n\n"],"max_tokens":16}`)
+	body := ChainRequest(Claude)("claude-x", request, false)
+
+	if got := gjson.GetBytes(body, "messages.1.content.0.text").String(); got != "def add(a, b):\n    "+FIMMarker+"\n\nprint(add(1, 2))" {
+		t.Fatalf("fim prompt = %q", got)
+	}
+	if got := gjson.GetBytes(body, "stop_sequences.0").String(); got != "\n\n" {
+		t.Fatalf("stop_sequences = %q", got)
+	}
+
+	// The Claude executor always reads the upstream as an event stream.
+	upstream := []byte(strings.Join([]string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-x","usage":{"input_tokens":12,"output_tokens":0}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"return a + b\n\nextra"}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"input_tokens":12,"output_tokens":7}}`,
+		`data: {"type":"message_stop"}`,
+	}, "\n"))
+	out := gjson.Parse(ChainResponse(Claude).NonStream(context.Background(), "claude-x", request, body, upstream, nil))
+	if out.Get("object").String() != "text_completion" {
+		t.Fatalf("unexpected response: %s", out.Raw)
+	}
+	if got := out.Get("choices.0.text").String(); got != "return a + b" {
+		t.Fatalf("text = %q", got)
+	}
+	if got := out.Get("choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("finish_reason = %q", got)
+	}
+	if got := out.Get("usage.total_tokens").Int(); got != 19 {
+		t.Fatalf("usage = %s", out.Get("usage").Raw)
+	}
+}
+
+func TestChainStreamEchoesPromptAndReleasesHeldTextAtDone(t *testing.T) {
+	request := []byte(`{"model":"gpt-x","prompt":"count: ","echo":true,"stop":"STOP","stream":true}`)
+	body := ChainRequest(OpenAI)("gpt-x", request, true)
+	stream := ChainResponse(OpenAI).Stream
+
+	var param any
+	var text strings.Builder
+	finish := ""
+	for _, line := range []string{
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"one two ST"}}]}`,
+		"data: [DONE]",
+	} {
+		for _, chunk := range stream(context.Background(), "gpt-x", request, body, []byte(line), &param) {
+			choice := gjson.Get(chunk, "choices.0")
+			text.WriteString(choice.Get("text").String())
+			if reason := choice.Get("finish_reason").String(); reason != "" {
+				finish = reason
+			}
+		}
+	}
+	if text.String() != "count: one two ST" || finish != "stop" {
+		t.Fatalf("streamed text = %q, finish = %q", text.String(), finish)
+	}
+}
@@ -0,0 +1,15 @@
+package completions
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		OpenAICompletions,
+		OpenAI,
+		ChainRequest(OpenAI),
+		ChainResponse(OpenAI),
+	)
+}
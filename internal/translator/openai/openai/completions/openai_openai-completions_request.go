@@ -0,0 +1,111 @@
+// Package completions translates legacy OpenAI completions requests and responses. Backends
+// only speak chat, so each prompt becomes a chat request whose system instruction asks the
+// model to behave like a raw completion engine; stop sequences, echo and logprobs are applied
+// to the chat response so the semantics do not depend on what the backend honours.
+package completions
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// FIMMarker marks the position to fill in fill-in-the-middle prompts (prompt + suffix).
+const FIMMarker = "<FILL_ME>"
+
+const (
+	continueInstruction = "You are a raw text completion engine. Continue the user's text exactly where it stops. " +
+		"Reply with the continuation only: do not repeat the given text, add commentary, or wrap the output in code fences."
+	fimInstruction = "You are a code completion engine. The user's text contains a " + FIMMarker + " marker. " +
+		"Reply with exactly the text that replaces the marker so that the text before it, your reply and the text after it form a coherent whole. " +
+		"Do not repeat the surrounding text, add commentary, or wrap the output in code fences."
+)
+
+// passthroughKeys are sampling parameters shared by both APIs.
+var passthroughKeys = []string{"max_tokens", "temperature", "top_p", "frequency_penalty", "presence_penalty", "seed", "user", "logit_bias"}
+
+// ConvertOpenAICompletionsRequestToOpenAI converts a single-prompt completions request into a
+// chat completions request. The request is expected to be validated already and to carry one
+// prompt and one choice; callers fan out multiple prompts and n themselves.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - inputRawJSON: The raw JSON request data in OpenAI completions format
+//   - stream: A boolean indicating if the request is for a streaming response
+//
+// Returns:
+//   - []byte: The transformed request data in OpenAI chat completions format
+func ConvertOpenAICompletionsRequestToOpenAI(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+	prompt := requestPrompt(root)
+	suffix := root.Get("suffix").String()
+
+	out := []byte(`{"model":"","messages":[{"role":"system","content":""},{"role":"user","content":""}]}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	if suffix != "" {
+		out, _ = sjson.SetBytes(out, "messages.0.content", fimInstruction)
+		out, _ = sjson.SetBytes(out, "messages.1.content", prompt+FIMMarker+suffix)
+	} else {
+		if prompt == "" {
+			prompt = "Complete this:"
+		}
+		out, _ = sjson.SetBytes(out, "messages.0.content", continueInstruction)
+		out, _ = sjson.SetBytes(out, "messages.1.content", prompt)
+	}
+
+	for _, key := range passthroughKeys {
+		if value := root.Get(key); value.Exists() && value.Type != gjson.Null {
+			out, _ = sjson.SetRawBytes(out, key, []byte(value.Raw))
+		}
+	}
+	if stops := stopSequences(root); len(stops) > 0 {
+		out, _ = sjson.SetBytes(out, "stop", stops)
+	}
+	if logprobs := requestLogprobs(root); logprobs >= 0 {
+		out, _ = sjson.SetBytes(out, "logprobs", true)
+		out, _ = sjson.SetBytes(out, "top_logprobs", logprobs)
+	}
+	if stream {
+		out, _ = sjson.SetBytes(out, "stream", true)
+		if root.Get("stream_options.include_usage").Bool() {
+			out, _ = sjson.SetRawBytes(out, "stream_options", []byte(`{"include_usage":true}`))
+		}
+	}
+	return out
+}
+
+// requestPrompt returns the prompt text. Array prompts are fanned out by the caller, so only
+// the first entry is used here.
+func requestPrompt(root gjson.Result) string {
+	prompt := root.Get("prompt")
+	if prompt.IsArray() {
+		return prompt.Get("0").String()
+	}
+	return prompt.String()
+}
+
+// stopSequences returns the non-empty stop sequences of a string or array stop parameter.
+func stopSequences(root gjson.Result) []string {
+	stop := root.Get("stop")
+	if stop.Type == gjson.String {
+		if stop.String() == "" {
+			return nil
+		}
+		return []string{stop.String()}
+	}
+	var out []string
+	for _, item := range stop.Array() {
+		if item.Type == gjson.String && item.String() != "" {
+			out = append(out, item.String())
+		}
+	}
+	return out
+}
+
+// requestLogprobs returns the requested number of alternatives, or -1 when logprobs are off.
+func requestLogprobs(root gjson.Result) int {
+	logprobs := root.Get("logprobs")
+	if logprobs.Type != gjson.Number || logprobs.Int() < 0 {
+		return -1
+	}
+	return int(logprobs.Int())
+}
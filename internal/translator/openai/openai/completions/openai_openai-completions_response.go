@@ -0,0 +1,315 @@
+package completions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// streamState carries a streamed choice across chunks.
+type streamState struct {
+	filter   *stopFilter
+	echo     string
+	logprobs bool
+	offset   int
+	started  bool
+	finished bool
+}
+
+// ConvertOpenAIResponseToOpenAICompletions converts a chat completions stream chunk into
+// text_completion chunks. The prompt is echoed before the first chunk when requested, stop
+// sequences are applied across chunk boundaries, and usage is sent as a separate chunk
+// without choices.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - originalRequestRawJSON: The original completions request
+//   - requestRawJSON: The translated request sent upstream
+//   - rawJSON: The chat completions chunk
+//   - param: A pointer to the per-stream state
+//
+// Returns:
+//   - []string: The text_completion chunks
+func ConvertOpenAIResponseToOpenAICompletions(_ context.Context, modelName string, originalRequestRawJSON, _, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		root := gjson.ParseBytes(originalRequestRawJSON)
+		st := &streamState{filter: newStopFilter(stopSequences(root)), logprobs: requestLogprobs(root) >= 0}
+		if root.Get("echo").Bool() {
+			st.echo = requestPrompt(root)
+		}
+		*param = st
+	}
+	st := (*param).(*streamState)
+
+	if bytes.HasPrefix(rawJSON, []byte("data:")) {
+		rawJSON = bytes.TrimSpace(rawJSON[5:])
+	}
+	if bytes.Equal(rawJSON, []byte("[DONE]")) {
+		// Streams that end without a finish reason still release held-back text.
+		if !st.started || st.finished {
+			return nil
+		}
+		return st.finish(gjson.Result{}, modelName, "stop")
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		return nil
+	}
+	root := gjson.ParseBytes(rawJSON)
+
+	var out []string
+	if !st.started {
+		st.started = true
+		if st.echo != "" {
+			out = append(out, string(completionChunk(root, modelName, st.echo, nil, "")))
+		}
+	}
+	if !st.finished {
+		choice := root.Get("choices.0")
+		text, stopped := st.filter.push(choice.Get("delta.content").String())
+		var logprobs []byte
+		if st.logprobs {
+			logprobs, st.offset = buildLegacyLogprobs(choice.Get("logprobs.content").Array(), st.offset, -1)
+		}
+		if text != "" || logprobs != nil {
+			out = append(out, string(completionChunk(root, modelName, text, logprobs, "")))
+		}
+		if stopped {
+			st.finished = true
+			out = append(out, string(completionChunk(root, modelName, "", nil, "stop")))
+		} else if reason := choice.Get("finish_reason").String(); reason != "" {
+			out = append(out, st.finish(root, modelName, reason)...)
+		}
+	}
+	// Keep converting usage after a local stop so it is still reported.
+	if usage := root.Get("usage"); usage.IsObject() {
+		chunk := []byte(`{"id":"","object":"text_completion","created":0,"model":"","choices":[]}`)
+		chunk = setEnvelope(chunk, root, modelName)
+		chunk, _ = sjson.SetRawBytes(chunk, "usage", completionUsage(usage))
+		out = append(out, string(chunk))
+	}
+	return out
+}
+
+// finish flushes held-back text and emits the final chunk of the choice.
+func (st *streamState) finish(root gjson.Result, modelName, reason string) []string {
+	st.finished = true
+	var out []string
+	if rest := st.filter.flush(); rest != "" {
+		out = append(out, string(completionChunk(root, modelName, rest, nil, "")))
+	}
+	return append(out, string(completionChunk(root, modelName, "", nil, normalizeFinishReason(reason))))
+}
+
+// ConvertOpenAIResponseToOpenAICompletionsNonStream converts a chat completions response into
+// a text_completion response with a single choice.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - originalRequestRawJSON: The original completions request
+//   - requestRawJSON: The translated request sent upstream
+//   - rawJSON: The chat completions response
+//   - param: Unused
+//
+// Returns:
+//   - string: The text_completion response
+func ConvertOpenAIResponseToOpenAICompletionsNonStream(_ context.Context, modelName string, originalRequestRawJSON, _, rawJSON []byte, _ *any) string {
+	request := gjson.ParseBytes(originalRequestRawJSON)
+	root := gjson.ParseBytes(rawJSON)
+	message := root.Get("choices.0")
+
+	text, stopped := applyStopSequences(chatMessageText(message.Get("message.content")), stopSequences(request))
+	finish := normalizeFinishReason(message.Get("finish_reason").String())
+	if stopped {
+		finish = "stop"
+	}
+
+	choice := []byte(`{"text":"","index":0,"logprobs":null,"finish_reason":""}`)
+	if requestLogprobs(request) >= 0 {
+		if logprobs, _ := buildLegacyLogprobs(message.Get("logprobs.content").Array(), 0, len(text)); logprobs != nil {
+			choice, _ = sjson.SetRawBytes(choice, "logprobs", logprobs)
+		}
+	}
+	if request.Get("echo").Bool() {
+		text = requestPrompt(request) + text
+	}
+	choice, _ = sjson.SetBytes(choice, "text", text)
+	choice, _ = sjson.SetBytes(choice, "finish_reason", finish)
+
+	out := []byte(`{"id":"","object":"text_completion","created":0,"model":"","choices":[]}`)
+	out = setEnvelope(out, root, modelName)
+	out, _ = sjson.SetRawBytes(out, "choices.-1", choice)
+	if usage := root.Get("usage"); usage.IsObject() {
+		out, _ = sjson.SetRawBytes(out, "usage", completionUsage(usage))
+	}
+	return string(out)
+}
+
+func completionChunk(root gjson.Result, modelName, text string, logprobs []byte, finish string) []byte {
+	out := []byte(`{"id":"","object":"text_completion","created":0,"model":"","choices":[{"text":"","index":0,"logprobs":null,"finish_reason":null}]}`)
+	out = setEnvelope(out, root, modelName)
+	out, _ = sjson.SetBytes(out, "choices.0.text", text)
+	if logprobs != nil {
+		out, _ = sjson.SetRawBytes(out, "choices.0.logprobs", logprobs)
+	}
+	if finish != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", finish)
+	}
+	return out
+}
+
+// setEnvelope copies id and created from the chat payload and sets the model.
+func setEnvelope(out []byte, root gjson.Result, modelName string) []byte {
+	out, _ = sjson.SetBytes(out, "id", root.Get("id").String())
+	out, _ = sjson.SetBytes(out, "created", root.Get("created").Int())
+	model := root.Get("model").String()
+	if model == "" {
+		model = modelName
+	}
+	out, _ = sjson.SetBytes(out, "model", model)
+	return out
+}
+
+func completionUsage(usage gjson.Result) []byte {
+	prompt := usage.Get("prompt_tokens").Int()
+	completion := usage.Get("completion_tokens").Int()
+	out := []byte(`{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}`)
+	out, _ = sjson.SetBytes(out, "prompt_tokens", prompt)
+	out, _ = sjson.SetBytes(out, "completion_tokens", completion)
+	out, _ = sjson.SetBytes(out, "total_tokens", prompt+completion)
+	return out
+}
+
+// chatMessageText returns the text of a chat message content field, which may be a plain
+// string or an array of content parts.
+func chatMessageText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var sb strings.Builder
+	content.ForEach(func(_, part gjson.Result) bool {
+		if part.Get("type").String() == "text" {
+			sb.WriteString(part.Get("text").String())
+		}
+		return true
+	})
+	return sb.String()
+}
+
+func normalizeFinishReason(reason string) string {
+	switch reason {
+	case "length", "content_filter":
+		return reason
+	default:
+		return "stop"
+	}
+}
+
+// applyStopSequences truncates text at the earliest stop sequence.
+func applyStopSequences(text string, stops []string) (string, bool) {
+	cut := -1
+	for _, stop := range stops {
+		if idx := strings.Index(text, stop); idx >= 0 && (cut < 0 || idx < cut) {
+			cut = idx
+		}
+	}
+	if cut < 0 {
+		return text, false
+	}
+	return text[:cut], true
+}
+
+// stopFilter applies stop sequences to streamed text. It holds back just enough trailing
+// text to detect a stop sequence that spans chunk boundaries.
+type stopFilter struct {
+	stops   []string
+	holdLen int
+	pending string
+	stopped bool
+}
+
+func newStopFilter(stops []string) *stopFilter {
+	f := &stopFilter{stops: stops}
+	for _, stop := range stops {
+		f.holdLen = max(f.holdLen, len(stop)-1)
+	}
+	return f
+}
+
+// push returns the text that is safe to emit and whether a stop sequence was reached.
+func (f *stopFilter) push(delta string) (string, bool) {
+	if f.stopped {
+		return "", true
+	}
+	buf := f.pending + delta
+	if text, stopped := applyStopSequences(buf, f.stops); stopped {
+		f.stopped = true
+		f.pending = ""
+		return text, true
+	}
+	split := max(len(buf)-f.holdLen, 0)
+	for split > 0 && split < len(buf) && !utf8.RuneStart(buf[split]) {
+		split--
+	}
+	f.pending = buf[split:]
+	return buf[:split], false
+}
+
+// flush returns any held-back text once the stream has ended.
+func (f *stopFilter) flush() string {
+	if f.stopped {
+		return ""
+	}
+	rest := f.pending
+	f.pending = ""
+	return rest
+}
+
+type legacyLogprobs struct {
+	Tokens        []string             `json:"tokens"`
+	TokenLogprobs []float64            `json:"token_logprobs"`
+	TopLogprobs   []map[string]float64 `json:"top_logprobs"`
+	TextOffset    []int                `json:"text_offset"`
+}
+
+// buildLegacyLogprobs converts chat logprobs.content entries into the completions logprobs
+// object. Offsets start at offset; tokens starting at or beyond limit are dropped unless
+// limit is negative. It returns the rendered object (nil when empty) and the next offset.
+func buildLegacyLogprobs(entries []gjson.Result, offset, limit int) ([]byte, int) {
+	if len(entries) == 0 {
+		return nil, offset
+	}
+	out := legacyLogprobs{}
+	for _, entry := range entries {
+		token := entry.Get("token").String()
+		if limit >= 0 && offset >= limit {
+			break
+		}
+		out.Tokens = append(out.Tokens, token)
+		out.TokenLogprobs = append(out.TokenLogprobs, entry.Get("logprob").Float())
+		out.TextOffset = append(out.TextOffset, offset)
+		var top map[string]float64
+		if alternatives := entry.Get("top_logprobs").Array(); len(alternatives) > 0 {
+			top = make(map[string]float64, len(alternatives))
+			for _, alt := range alternatives {
+				top[alt.Get("token").String()] = alt.Get("logprob").Float()
+			}
+		}
+		out.TopLogprobs = append(out.TopLogprobs, top)
+		offset += len(token)
+	}
+	if len(out.Tokens) == 0 {
+		return nil, offset
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, offset
+	}
+	return data, offset
+}
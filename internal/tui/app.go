@@ -44,6 +44,8 @@ type App struct {
 	logs      logsTabModel
 
 	client *Client
+	// principal is the connected management account; nil means unrestricted.
+	principal *managementPrincipal
 
 	width  int
 	height int
@@ -54,8 +56,9 @@ type App struct {
 }
 
 type authConnectMsg struct {
-	cfg map[string]any
-	err error
+	cfg       map[string]any
+	principal *managementPrincipal
+	err       error
 }
 
 // NewApp creates the root TUI application model.
@@ -140,6 +143,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		a.authError = ""
 		a.authenticated = true
+		a.principal = msg.principal
 		a.logsEnabled = a.standalone || isLogsEnabledFromConfig(msg.cfg)
 		a.refreshTabs()
 		a.initialized = [7]bool{}
@@ -306,8 +310,20 @@ func (a *App) refreshTabs() {
 	}
 }
 
+// tabAllowed reports whether the connected principal may use a tab.
+// Tabs that only fail on individual actions stay visible.
+func (a App) tabAllowed(tab int) bool {
+	switch tab {
+	case tabAPIKeys:
+		return a.principal.allows("keys")
+	case tabOAuth:
+		return a.principal.allows("credentials")
+	}
+	return true
+}
+
 func (a *App) initTabIfNeeded(_ int) tea.Cmd {
-	if a.initialized[a.activeTab] {
+	if a.initialized[a.activeTab] || !a.tabAllowed(a.activeTab) {
 		return nil
 	}
 	a.initialized[a.activeTab] = true
@@ -349,6 +365,12 @@ func (a App) View() string {
 	sb.WriteString("\n")
 
 	// Content
+	if !a.tabAllowed(a.activeTab) {
+		sb.WriteString(warningStyle.Render(fmt.Sprintf(T("permission_denied"), a.principal.Role)))
+		sb.WriteString("\n")
+		sb.WriteString(a.renderStatusBar())
+		return sb.String()
+	}
 	switch a.activeTab {
 	case tabDashboard:
 		sb.WriteString(a.dashboard.View())
@@ -411,6 +433,9 @@ func (a App) renderTabBar() string {
 
 func (a App) renderStatusBar() string {
 	left := strings.TrimRight(T("status_left"), " ")
+	if a.principal != nil && a.principal.Name != "" {
+		left += fmt.Sprintf(" • %s (%s)", a.principal.Name, a.principal.Role)
+	}
 	right := strings.TrimRight(T("status_right"), " ")
 
 	width := a.width
@@ -489,7 +514,12 @@ func (a App) connectWithPassword(password string) tea.Cmd {
 	return func() tea.Msg {
 		a.client.SetSecretKey(password)
 		cfg, errGetConfig := a.client.GetConfig()
-		return authConnectMsg{cfg: cfg, err: errGetConfig}
+		if errGetConfig != nil {
+			return authConnectMsg{err: errGetConfig}
+		}
+		// Servers without management accounts lack /whoami; treat them as unrestricted.
+		principal, _ := a.client.WhoAmI()
+		return authConnectMsg{cfg: cfg, principal: principal}
 	}
 }
 
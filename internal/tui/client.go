@@ -125,6 +125,45 @@ func (c *Client) GetConfig() (map[string]any, error) {
 	return c.getJSON("/v0/management/config")
 }
 
+// managementPrincipal describes the account the TUI is connected as.
+type managementPrincipal struct {
+	Name        string
+	Role        string
+	Permissions []string
+}
+
+// allows reports whether the principal holds perm.
+func (p *managementPrincipal) allows(perm string) bool {
+	if p == nil {
+		return true
+	}
+	for _, granted := range p.Permissions {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// WhoAmI fetches the authenticated management principal and its permissions.
+func (c *Client) WhoAmI() (*managementPrincipal, error) {
+	data, err := c.get("/v0/management/whoami")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Principal struct {
+			Name string `json:"name"`
+			Role string `json:"role"`
+		} `json:"principal"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &managementPrincipal{Name: result.Principal.Name, Role: result.Principal.Role, Permissions: result.Permissions}, nil
+}
+
 // GetConfigYAML fetches the raw config.yaml content.
 func (c *Client) GetConfigYAML() (string, error) {
 	data, err := c.get("/v0/management/config.yaml")
@@ -315,6 +354,10 @@ func (c *Client) GetOpenAICompat() ([]map[string]any, error) {
 	return c.getWrappedKeyList("/v0/management/openai-compatibility", "openai-compatibility")
 }
 
+func (c *Client) GetOAuthProviders() ([]map[string]any, error) {
+	return c.getWrappedKeyList("/v0/management/oauth-providers", "oauth-providers")
+}
+
 // getWrappedKeyList fetches a wrapped list from the API.
 func (c *Client) getWrappedKeyList(path, key string) ([]map[string]any, error) {
 	wrapper, err := c.getJSON(path)
@@ -93,6 +93,7 @@ var zhStrings = map[string]string{
 	"auth_gate_connecting":        "正在连接...",
 	"auth_gate_connect_fail":      "连接失败：%s",
 	"auth_gate_password_required": "请输入密码",
+	"permission_denied":           " 当前角色（%s）无权访问此页面",
 
 	// ── Dashboard ──
 	"dashboard_title":  "📊 仪表盘",
@@ -174,6 +175,7 @@ var zhStrings = map[string]string{
 	"oauth_timeout":      "OAuth 流程超时 (5 分钟)",
 	"oauth_press_esc":    "  按 [Esc] 取消",
 	"oauth_auth_url":     "  授权链接:",
+	"oauth_user_code":    "用户代码: %s",
 	"oauth_remote_hint":  "  远程浏览器模式：在浏览器中打开上述链接完成授权后，将回调 URL 粘贴到下方。",
 	"oauth_callback_url": "  回调 URL:",
 	"oauth_press_c":      "  按 [c] 输入回调 URL • [Esc] 返回",
@@ -244,6 +246,7 @@ var enStrings = map[string]string{
 	"auth_gate_connecting":        "Connecting...",
 	"auth_gate_connect_fail":      "Connection failed: %s",
 	"auth_gate_password_required": "password is required",
+	"permission_denied":           " Your role (%s) does not have access to this tab",
 
 	// ── Dashboard ──
 	"dashboard_title":  "📊 Dashboard",
@@ -325,6 +328,7 @@ var enStrings = map[string]string{
 	"oauth_timeout":      "OAuth flow timed out (5 minutes)",
 	"oauth_press_esc":    "  Press [Esc] to cancel",
 	"oauth_auth_url":     "  Authorization URL:",
+	"oauth_user_code":    "User code: %s",
 	"oauth_remote_hint":  "  Remote browser mode: Open the URL above in browser, paste the callback URL below after authorization.",
 	"oauth_callback_url": "  Callback URL:",
 	"oauth_press_c":      "  Press [c] to enter callback URL • [Esc] to go back",
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -16,29 +17,31 @@ type oauthProvider struct {
 	name    string
 	apiPath string // management API path
 	emoji   string
+	key     string // provider key expected by /oauth-callback
 }
 
 var oauthProviders = []oauthProvider{
-	{"Gemini CLI", "gemini-cli-auth-url", "🟦"},
-	{"Claude (Anthropic)", "anthropic-auth-url", "🟧"},
-	{"Codex (OpenAI)", "codex-auth-url", "🟩"},
-	{"Antigravity", "antigravity-auth-url", "🟪"},
-	{"Qwen", "qwen-auth-url", "🟨"},
-	{"Kimi", "kimi-auth-url", "🟫"},
-	{"IFlow", "iflow-auth-url", "⬜"},
+	{"Gemini CLI", "gemini-cli-auth-url", "🟦", "gemini"},
+	{"Claude (Anthropic)", "anthropic-auth-url", "🟧", "anthropic"},
+	{"Codex (OpenAI)", "codex-auth-url", "🟩", "codex"},
+	{"Antigravity", "antigravity-auth-url", "🟪", "antigravity"},
+	{"Qwen", "qwen-auth-url", "🟨", "qwen"},
+	{"Kimi", "kimi-auth-url", "🟫", "kimi"},
+	{"IFlow", "iflow-auth-url", "⬜", "iflow"},
 }
 
 // oauthTabModel handles OAuth login flows.
 type oauthTabModel struct {
-	client   *Client
-	viewport viewport.Model
-	cursor   int
-	state    oauthState
-	message  string
-	err      error
-	width    int
-	height   int
-	ready    bool
+	client    *Client
+	providers []oauthProvider // built-in providers followed by oauth-providers from config
+	viewport  viewport.Model
+	cursor    int
+	state     oauthState
+	message   string
+	err       error
+	width     int
+	height    int
+	ready     bool
 
 	// Remote browser mode
 	authURL       string // auth URL to display
@@ -61,6 +64,7 @@ const (
 // Messages
 type oauthStartMsg struct {
 	url          string
+	userCode     string
 	state        string
 	providerName string
 	err          error
@@ -76,6 +80,10 @@ type oauthCallbackSubmitMsg struct {
 	err error
 }
 
+type oauthProvidersMsg struct {
+	providers []oauthProvider
+}
+
 func newOAuthTabModel(client *Client) oauthTabModel {
 	ti := textinput.New()
 	ti.Placeholder = "http://localhost:.../auth/callback?code=...&state=..."
@@ -83,12 +91,30 @@ func newOAuthTabModel(client *Client) oauthTabModel {
 	ti.Prompt = "  回调 URL: "
 	return oauthTabModel{
 		client:        client,
+		providers:     oauthProviders,
 		callbackInput: ti,
 	}
 }
 
 func (m oauthTabModel) Init() tea.Cmd {
-	return nil
+	return m.loadProviders
+}
+
+// loadProviders appends the declarative OAuth providers configured on the server.
+func (m oauthTabModel) loadProviders() tea.Msg {
+	entries, err := m.client.GetOAuthProviders()
+	if err != nil {
+		return oauthProvidersMsg{providers: oauthProviders}
+	}
+	providers := append([]oauthProvider(nil), oauthProviders...)
+	for _, entry := range entries {
+		name := getString(entry, "name")
+		if name == "" {
+			continue
+		}
+		providers = append(providers, oauthProvider{name, "oauth2-auth-url?provider=" + url.QueryEscape(name), "🔑", name})
+	}
+	return oauthProvidersMsg{providers: providers}
 }
 
 func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
@@ -96,6 +122,13 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 	case localeChangedMsg:
 		m.viewport.SetContent(m.renderContent())
 		return m, nil
+	case oauthProvidersMsg:
+		m.providers = msg.providers
+		if m.cursor >= len(m.providers) {
+			m.cursor = 0
+		}
+		m.viewport.SetContent(m.renderContent())
+		return m, nil
 	case oauthStartMsg:
 		if msg.err != nil {
 			m.state = oauthError
@@ -112,6 +145,9 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 		m.callbackInput.Focus()
 		m.inputActive = true
 		m.message = ""
+		if msg.userCode != "" {
+			m.message = warningStyle.Render(fmt.Sprintf(T("oauth_user_code"), msg.userCode))
+		}
 		m.viewport.SetContent(m.renderContent())
 		// Also start polling in the background
 		return m, tea.Batch(textinput.Blink, m.pollOAuthStatus(msg.state))
@@ -211,14 +247,14 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 			}
 			return m, nil
 		case "down", "j":
-			if m.cursor < len(oauthProviders)-1 {
+			if m.cursor < len(m.providers)-1 {
 				m.cursor++
 				m.viewport.SetContent(m.renderContent())
 			}
 			return m, nil
 		case "enter":
-			if m.cursor >= 0 && m.cursor < len(oauthProviders) {
-				provider := oauthProviders[m.cursor]
+			if m.cursor >= 0 && m.cursor < len(m.providers) {
+				provider := m.providers[m.cursor]
 				m.state = oauthPending
 				m.message = warningStyle.Render(fmt.Sprintf(T("oauth_initiating"), provider.name))
 				m.viewport.SetContent(m.renderContent())
@@ -246,13 +282,21 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 func (m oauthTabModel) startOAuth(provider oauthProvider) tea.Cmd {
 	return func() tea.Msg {
 		// Call the auth URL endpoint with is_webui=true
-		data, err := m.client.getJSON("/v0/management/" + provider.apiPath + "?is_webui=true")
+		sep := "?"
+		if strings.Contains(provider.apiPath, "?") {
+			sep = "&"
+		}
+		data, err := m.client.getJSON("/v0/management/" + provider.apiPath + sep + "is_webui=true")
 		if err != nil {
 			return oauthStartMsg{err: fmt.Errorf("failed to start %s login: %w", provider.name, err)}
 		}
 
 		authURL := getString(data, "url")
 		state := getString(data, "state")
+		if authURL == "" && state == "" && getString(data, "status") == "ok" {
+			// Non-interactive flows (client credentials) finish within the request.
+			return oauthPollMsg{done: true, message: T("oauth_success")}
+		}
 		if authURL == "" {
 			return oauthStartMsg{err: fmt.Errorf("no auth URL returned for %s", provider.name)}
 		}
@@ -260,7 +304,7 @@ func (m oauthTabModel) startOAuth(provider oauthProvider) tea.Cmd {
 		// Try to open browser (best effort)
 		_ = openBrowser(authURL)
 
-		return oauthStartMsg{url: authURL, state: state, providerName: provider.name}
+		return oauthStartMsg{url: authURL, userCode: getString(data, "user_code"), state: state, providerName: provider.name}
 	}
 }
 
@@ -268,25 +312,9 @@ func (m oauthTabModel) submitCallback(callbackURL string) tea.Cmd {
 	return func() tea.Msg {
 		// Determine provider from current context
 		providerKey := ""
-		for _, p := range oauthProviders {
+		for _, p := range m.providers {
 			if p.name == m.providerName {
-				// Map provider name to the canonical key the API expects
-				switch p.apiPath {
-				case "gemini-cli-auth-url":
-					providerKey = "gemini"
-				case "anthropic-auth-url":
-					providerKey = "anthropic"
-				case "codex-auth-url":
-					providerKey = "codex"
-				case "antigravity-auth-url":
-					providerKey = "antigravity"
-				case "qwen-auth-url":
-					providerKey = "qwen"
-				case "kimi-auth-url":
-					providerKey = "kimi"
-				case "iflow-auth-url":
-					providerKey = "iflow"
-				}
+				providerKey = p.key
 				break
 			}
 		}
@@ -389,7 +417,7 @@ func (m oauthTabModel) renderContent() string {
 	sb.WriteString(helpStyle.Render(T("oauth_select")))
 	sb.WriteString("\n\n")
 
-	for i, p := range oauthProviders {
+	for i, p := range m.providers {
 		isSelected := i == m.cursor
 		prefix := "  "
 		if isSelected {
@@ -66,6 +66,10 @@ type RequestStatistics struct {
 	failureCount  int64
 	totalTokens   int64
 
+	// deniedRequests counts requests rejected by IP access rules before authentication.
+	deniedRequests int64
+	deniedByAPI    map[string]int64
+
 	apis map[string]*apiStats
 
 	requestsByDay  map[string]int64
@@ -114,6 +118,9 @@ type StatisticsSnapshot struct {
 	FailureCount  int64 `json:"failure_count"`
 	TotalTokens   int64 `json:"total_tokens"`
 
+	DeniedRequests int64            `json:"denied_requests,omitempty"`
+	DeniedByAPI    map[string]int64 `json:"denied_by_api,omitempty"`
+
 	APIs map[string]APISnapshot `json:"apis"`
 
 	RequestsByDay  map[string]int64 `json:"requests_by_day"`
@@ -145,6 +152,7 @@ func GetRequestStatistics() *RequestStatistics { return defaultRequestStatistics
 func NewRequestStatistics() *RequestStatistics {
 	return &RequestStatistics{
 		apis:           make(map[string]*apiStats),
+		deniedByAPI:    make(map[string]int64),
 		requestsByDay:  make(map[string]int64),
 		requestsByHour: make(map[int]int64),
 		tokensByDay:    make(map[string]int64),
@@ -162,6 +170,8 @@ func (s *RequestStatistics) Reset() {
 	s.successCount = 0
 	s.failureCount = 0
 	s.totalTokens = 0
+	s.deniedRequests = 0
+	s.deniedByAPI = make(map[string]int64)
 	s.apis = make(map[string]*apiStats)
 	s.requestsByDay = make(map[string]int64)
 	s.requestsByHour = make(map[int]int64)
@@ -375,6 +385,13 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.DeniedRequests = s.deniedRequests
+	if len(s.deniedByAPI) > 0 {
+		result.DeniedByAPI = make(map[string]int64, len(s.deniedByAPI))
+		for k, v := range s.deniedByAPI {
+			result.DeniedByAPI[k] = v
+		}
+	}
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
@@ -420,6 +437,31 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	return result
 }
 
+// RecordDenied counts a request rejected by IP access rules. apiKey is the client key
+// the request presented, or empty when it could not be attributed.
+func (s *RequestStatistics) RecordDenied(apiKey string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.deniedRequests++
+	if apiKey = strings.TrimSpace(apiKey); apiKey != "" {
+		s.deniedByAPI[apiKey]++
+	}
+	s.mu.Unlock()
+	s.dirty.Store(true)
+}
+
+// DeniedCount returns how many requests presenting apiKey were rejected by IP access rules.
+func (s *RequestStatistics) DeniedCount(apiKey string) int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.deniedByAPI[strings.TrimSpace(apiKey)]
+}
+
 type MergeResult struct {
 	Added   int64 `json:"added"`
 	Skipped int64 `json:"skipped"`
@@ -451,6 +493,13 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 		}
 	}
 
+	// Denial counters carry no per-request details, so keep the larger value to make
+	// repeated imports idempotent.
+	s.deniedRequests = max(s.deniedRequests, snapshot.DeniedRequests)
+	for apiName, count := range snapshot.DeniedByAPI {
+		s.deniedByAPI[apiName] = max(s.deniedByAPI[apiName], count)
+	}
+
 	for apiName, apiSnapshot := range snapshot.APIs {
 		apiName = strings.TrimSpace(apiName)
 		if apiName == "" {
@@ -0,0 +1,292 @@
+package usagerecord
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// AuditChange is one changed leaf of a redacted before/after snapshot.
+type AuditChange struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// AuditEntry records a single management mutation. Entries are append-only;
+// they are only removed by the audit log retention cleanup.
+type AuditEntry struct {
+	ID         int64         `json:"id"`
+	Timestamp  time.Time     `json:"timestamp"`
+	Actor      string        `json:"actor"`
+	ActorRole  string        `json:"actor_role"`
+	AccountID  string        `json:"account_id,omitempty"`
+	IP         string        `json:"ip"`
+	Method     string        `json:"method"`
+	Route      string        `json:"route"`
+	Path       string        `json:"path"`
+	StatusCode int           `json:"status_code"`
+	Success    bool          `json:"success"`
+	Error      string        `json:"error,omitempty"`
+	Changes    []AuditChange `json:"changes"`
+	Truncated  bool          `json:"truncated,omitempty"`
+}
+
+// AuditQuery filters audit log listings and exports.
+type AuditQuery struct {
+	Page      int
+	PageSize  int
+	Actor     string
+	Route     string
+	Method    string
+	StartTime string
+	EndTime   string
+	Success   *bool
+}
+
+// AuditListResult contains a page of audit entries, newest first.
+type AuditListResult struct {
+	Entries    []AuditEntry `json:"entries"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
+
+const auditSchema = `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		actor_role TEXT NOT NULL DEFAULT '',
+		account_id TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		method TEXT NOT NULL DEFAULT '',
+		route TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL DEFAULT '',
+		status_code INTEGER NOT NULL DEFAULT 0,
+		success INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		changes TEXT NOT NULL DEFAULT '[]',
+		truncated INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp DESC);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_route ON audit_log(route);
+`
+
+// InsertAudit appends an audit entry. Unlike usage records it is written synchronously
+// so a mutation is never applied without its record.
+func (s *Store) InsertAudit(ctx context.Context, entry *AuditEntry) error {
+	if s.isClosed() {
+		return fmt.Errorf("store is closed")
+	}
+	changes := entry.Changes
+	if changes == nil {
+		changes = []AuditChange{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+	INSERT INTO audit_log (
+		timestamp, actor, actor_role, account_id, ip, method, route, path,
+		status_code, success, error, changes, truncated
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Timestamp.Local().Format(time.RFC3339),
+		entry.Actor,
+		entry.ActorRole,
+		entry.AccountID,
+		entry.IP,
+		entry.Method,
+		entry.Route,
+		entry.Path,
+		entry.StatusCode,
+		boolToInt(entry.Success),
+		entry.Error,
+		string(changesJSON),
+		boolToInt(entry.Truncated),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	entry.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListAudit returns a page of audit entries matching query, newest first.
+func (s *Store) ListAudit(ctx context.Context, query AuditQuery) (*AuditListResult, error) {
+	if s.isClosed() {
+		return nil, fmt.Errorf("store is closed")
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = 20
+	}
+	if query.PageSize > 200 {
+		query.PageSize = 200
+	}
+
+	whereClause, args := auditWhere(query)
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log "+whereClause, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, auditSelect+whereClause+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, query.PageSize, (query.Page-1)*query.PageSize)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0, query.PageSize)
+	for rows.Next() {
+		entry, errScan := scanAuditEntry(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit entries: %w", err)
+	}
+
+	totalPages := int((total + int64(query.PageSize) - 1) / int64(query.PageSize))
+	return &AuditListResult{
+		Entries:    entries,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ExportAudit writes every entry matching query to w as JSON lines, oldest first.
+// Paging fields of query are ignored.
+func (s *Store) ExportAudit(ctx context.Context, query AuditQuery, w io.Writer) (int64, error) {
+	if s.isClosed() {
+		return 0, fmt.Errorf("store is closed")
+	}
+	whereClause, args := auditWhere(query)
+	rows, err := s.db.QueryContext(ctx, auditSelect+whereClause+" ORDER BY id ASC", args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	var count int64
+	for rows.Next() {
+		entry, errScan := scanAuditEntry(rows)
+		if errScan != nil {
+			return count, errScan
+		}
+		if err = enc.Encode(entry); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to read audit entries: %w", err)
+	}
+	return count, bw.Flush()
+}
+
+func (s *Store) deleteAuditOlderThanCutoffBatch(ctx context.Context, cutoff string, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = defaultRetentionBatchSize
+	}
+	if s.isClosed() {
+		return 0, fmt.Errorf("store is closed")
+	}
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM audit_log
+		WHERE id IN (
+			SELECT id FROM audit_log WHERE timestamp < ? ORDER BY id ASC LIMIT ?
+		)`, cutoff, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const auditSelect = `
+	SELECT id, timestamp, actor, actor_role, account_id, ip, method, route, path,
+		status_code, success, error, changes, truncated
+	FROM audit_log `
+
+func auditWhere(query AuditQuery) (string, []any) {
+	var conditions []string
+	var args []any
+	if query.Actor != "" {
+		conditions = append(conditions, "(actor LIKE ? OR account_id = ?)")
+		args = append(args, "%"+query.Actor+"%", query.Actor)
+	}
+	if query.Route != "" {
+		conditions = append(conditions, "route LIKE ?")
+		args = append(args, "%"+query.Route+"%")
+	}
+	if query.Method != "" {
+		conditions = append(conditions, "method = ?")
+		args = append(args, strings.ToUpper(query.Method))
+	}
+	if query.StartTime != "" {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, ParseTimeParam(query.StartTime))
+	}
+	if query.EndTime != "" {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, ParseTimeParam(query.EndTime))
+	}
+	if query.Success != nil {
+		conditions = append(conditions, "success = ?")
+		args = append(args, boolToInt(*query.Success))
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func scanAuditEntry(rows *sql.Rows) (AuditEntry, error) {
+	var (
+		entry       AuditEntry
+		timestamp   string
+		success     int
+		truncated   int
+		changesJSON string
+	)
+	if err := rows.Scan(&entry.ID, &timestamp, &entry.Actor, &entry.ActorRole, &entry.AccountID, &entry.IP,
+		&entry.Method, &entry.Route, &entry.Path, &entry.StatusCode, &success, &entry.Error, &changesJSON, &truncated); err != nil {
+		return entry, fmt.Errorf("failed to scan audit entry: %w", err)
+	}
+	entry.Timestamp, _ = time.Parse(time.RFC3339, timestamp)
+	entry.Success = success == 1
+	entry.Truncated = truncated == 1
+	if err := json.Unmarshal([]byte(changesJSON), &entry.Changes); err != nil || entry.Changes == nil {
+		entry.Changes = []AuditChange{}
+	}
+	return entry, nil
+}
+
+func boolToInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
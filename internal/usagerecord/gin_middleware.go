@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 )
@@ -214,6 +215,16 @@ func patchUsageRecordFinal(store *Store, c *gin.Context, recordID int64, start t
 	if strings.TrimSpace(apiKey) != "" {
 		apiKeyMasked = MaskAPIKey(apiKey)
 	}
+	// Token-based principals carry a non-secret identity that attributes usage better than a mask.
+	tenant := ""
+	if v, exists := c.Get("accessMetadata"); exists {
+		if metadata, ok := v.(map[string]string); ok {
+			if metadata[sdkaccess.MetadataIdentity] != "" {
+				apiKeyMasked = metadata[sdkaccess.MetadataIdentity]
+			}
+			tenant = metadata[sdkaccess.MetadataTenant]
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -222,6 +233,7 @@ func patchUsageRecordFinal(store *Store, c *gin.Context, recordID int64, start t
 		IP:              &ip,
 		APIKey:          &apiKey,
 		APIKeyMasked:    &apiKeyMasked,
+		Tenant:          &tenant,
 		IsStreaming:     &isStreaming,
 		DurationMs:      &durationMs,
 		StatusCode:      &statusCode,
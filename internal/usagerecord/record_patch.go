@@ -15,6 +15,7 @@ type RecordPatch struct {
 	IP              *string
 	APIKey          *string
 	APIKeyMasked    *string
+	Tenant          *string
 	Model           *string
 	Provider        *string
 	IsStreaming     *bool
@@ -63,6 +64,9 @@ func (s *Store) PatchByID(ctx context.Context, id int64, patch RecordPatch) (int
 	if patch.APIKeyMasked != nil {
 		add("api_key_masked = ?", *patch.APIKeyMasked)
 	}
+	if patch.Tenant != nil {
+		add("tenant = ?", *patch.Tenant)
+	}
 	if patch.Model != nil {
 		add("model = ?", *patch.Model)
 	}
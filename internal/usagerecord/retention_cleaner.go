@@ -21,6 +21,9 @@ const (
 // It performs deletions in small batches to minimize write-lock time and reduce performance impact.
 type RetentionCleaner struct {
 	store *Store
+	// deleteBatch removes up to batchSize rows older than cutoff; label names them in logs.
+	deleteBatch func(ctx context.Context, cutoff string, batchSize int) (int64, error)
+	label       string
 
 	retentionDays atomic.Int64
 	started       atomic.Bool
@@ -37,14 +40,25 @@ type RetentionCleaner struct {
 }
 
 func NewRetentionCleaner(store *Store, retentionDays int) *RetentionCleaner {
+	return newRetentionCleaner(store, retentionDays, "usage record", store.deleteOlderThanCutoffBatch)
+}
+
+// NewAuditRetentionCleaner periodically deletes audit log entries older than retentionDays.
+func NewAuditRetentionCleaner(store *Store, retentionDays int) *RetentionCleaner {
+	return newRetentionCleaner(store, retentionDays, "audit log", store.deleteAuditOlderThanCutoffBatch)
+}
+
+func newRetentionCleaner(store *Store, retentionDays int, label string, deleteBatch func(context.Context, string, int) (int64, error)) *RetentionCleaner {
 	cleaner := &RetentionCleaner{
-		store:      store,
-		stop:       make(chan struct{}),
-		done:       make(chan struct{}),
-		interval:   defaultRetentionInterval,
-		batchSize:  defaultRetentionBatchSize,
-		pause:      defaultRetentionPause,
-		maxRuntime: defaultRetentionMaxRuntime,
+		store:       store,
+		deleteBatch: deleteBatch,
+		label:       label,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		interval:    defaultRetentionInterval,
+		batchSize:   defaultRetentionBatchSize,
+		pause:       defaultRetentionPause,
+		maxRuntime:  defaultRetentionMaxRuntime,
 	}
 	cleaner.retentionDays.Store(int64(retentionDays))
 	return cleaner
@@ -132,13 +146,13 @@ func (c *RetentionCleaner) runOnce() {
 			break
 		}
 
-		deleted, err := c.store.deleteOlderThanCutoffBatch(ctx, cutoff, c.batchSize)
+		deleted, err := c.deleteBatch(ctx, cutoff, c.batchSize)
 		if err != nil {
 			if ctx.Err() != nil {
-				log.WithError(err).Debug("usage record retention cleanup timed out")
+				log.WithError(err).Debugf("%s retention cleanup timed out", c.label)
 				break
 			}
-			log.WithError(err).Warn("usage record retention cleanup failed")
+			log.WithError(err).Warnf("%s retention cleanup failed", c.label)
 			break
 		}
 		if deleted == 0 {
@@ -158,7 +172,7 @@ func (c *RetentionCleaner) runOnce() {
 	}
 
 	if totalDeleted > 0 {
-		log.Infof("usage record retention cleanup: deleted %d records older than %d days", totalDeleted, days)
+		log.Infof("%s retention cleanup: deleted %d records older than %d days", c.label, totalDeleted, days)
 	}
 }
 
@@ -109,6 +109,7 @@ type Record struct {
 	IP                     string            `json:"ip"`
 	APIKey                 string            `json:"api_key"`
 	APIKeyMasked           string            `json:"api_key_masked"`
+	Tenant                 string            `json:"tenant,omitempty"`
 	Model                  string            `json:"model"`
 	Provider               string            `json:"provider"`
 	UpstreamProvider       string            `json:"upstream_provider,omitempty"`
@@ -137,6 +138,7 @@ type ListQuery struct {
 	Page        int    `form:"page"`
 	PageSize    int    `form:"page_size"`
 	APIKey      string `form:"api_key"`
+	Tenant      string `form:"tenant"`
 	Model       string `form:"model"`
 	Provider    string `form:"provider"`
 	StartTime   string `form:"start_time"`
@@ -252,7 +254,7 @@ func NewStore(dataDir string) (*Store, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	dbPath := filepath.Join(dataDir, "usage_records.db")
+	dbPath := DatabasePath(dataDir)
 
 	// SQLite tuning:
 	// - WAL allows concurrent readers with a single writer.
@@ -321,6 +323,7 @@ func (s *Store) initSchema() error {
 		ip TEXT NOT NULL DEFAULT '',
 		api_key TEXT NOT NULL DEFAULT '',
 		api_key_masked TEXT NOT NULL DEFAULT '',
+		tenant TEXT NOT NULL DEFAULT '',
 		model TEXT NOT NULL DEFAULT '',
 		provider TEXT NOT NULL DEFAULT '',
 		is_streaming INTEGER NOT NULL DEFAULT 0,
@@ -385,12 +388,19 @@ func (s *Store) initSchema() error {
 	if _, err := s.db.Exec(schema); err != nil {
 		return err
 	}
+	if _, err := s.db.Exec(auditSchema); err != nil {
+		return err
+	}
 
 	// Hotfix/Migration: Add ip column if it doesn't exist
 	// Ignore errors as column might already exist
 	_, _ = s.db.Exec("ALTER TABLE usage_records ADD COLUMN ip TEXT NOT NULL DEFAULT ''")
 	_, _ = s.db.Exec("ALTER TABLE usage_records ADD COLUMN cached_tokens INTEGER NOT NULL DEFAULT 0")
 	_, _ = s.db.Exec("ALTER TABLE usage_records ADD COLUMN reasoning_tokens INTEGER NOT NULL DEFAULT 0")
+	_, _ = s.db.Exec("ALTER TABLE usage_records ADD COLUMN tenant TEXT NOT NULL DEFAULT ''")
+	if _, err := s.db.Exec("CREATE INDEX IF NOT EXISTS idx_usage_records_tenant ON usage_records(tenant)"); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -413,12 +423,12 @@ func (s *Store) Insert(ctx context.Context, record *Record) error {
 
 	query := `
 	INSERT INTO usage_records (
-		request_id, timestamp, ip, api_key, api_key_masked, model, provider,
+		request_id, timestamp, ip, api_key, api_key_masked, tenant, model, provider,
 		is_streaming, input_tokens, output_tokens, total_tokens,
 		cached_tokens, reasoning_tokens,
 		duration_ms, status_code, success, request_url, request_method,
 		request_headers, request_body, response_headers, response_body
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	isStreaming := 0
@@ -436,6 +446,7 @@ func (s *Store) Insert(ctx context.Context, record *Record) error {
 		record.IP,
 		record.APIKey,
 		record.APIKeyMasked,
+		record.Tenant,
 		record.Model,
 		record.Provider,
 		isStreaming,
@@ -506,6 +517,8 @@ func listCacheKey(query ListQuery) string {
 	b.WriteString(strconv.Itoa(query.PageSize))
 	b.WriteString("&api_key=")
 	b.WriteString(query.APIKey)
+	b.WriteString("&tenant=")
+	b.WriteString(query.Tenant)
 	b.WriteString("&model=")
 	b.WriteString(query.Model)
 	b.WriteString("&provider=")
@@ -550,6 +563,10 @@ func (s *Store) listUncached(ctx context.Context, query ListQuery) (*ListResult,
 		conditions = append(conditions, "api_key LIKE ?")
 		args = append(args, "%"+query.APIKey+"%")
 	}
+	if query.Tenant != "" {
+		conditions = append(conditions, "tenant = ?")
+		args = append(args, query.Tenant)
+	}
 	if query.Model != "" {
 		conditions = append(conditions, "model LIKE ?")
 		args = append(args, "%"+query.Model+"%")
@@ -613,7 +630,7 @@ func (s *Store) listUncached(ctx context.Context, query ListQuery) (*ListResult,
 	// Build main query
 	offset := (query.Page - 1) * query.PageSize
 	selectQuery := fmt.Sprintf(`
-		SELECT id, request_id, timestamp, ip, api_key, api_key_masked, model, provider,
+		SELECT id, request_id, timestamp, ip, api_key, api_key_masked, tenant, model, provider,
 			COALESCE((
 				SELECT provider
 				FROM request_candidates rc
@@ -662,7 +679,7 @@ func (s *Store) listUncached(ctx context.Context, query ListQuery) (*ListResult,
 		var upstreamHasRetry int
 
 		err := rows.Scan(
-			&r.ID, &r.RequestID, &timestamp, &r.IP, &r.APIKey, &r.APIKeyMasked,
+			&r.ID, &r.RequestID, &timestamp, &r.IP, &r.APIKey, &r.APIKeyMasked, &r.Tenant,
 			&r.Model, &r.Provider, &r.UpstreamProvider, &r.UpstreamAPIKeyMasked, &r.UpstreamCandidateCount, &upstreamHasRetry,
 			&isStreaming, &r.InputTokens,
 			&r.OutputTokens, &r.TotalTokens, &r.CachedTokens, &r.ReasoningTokens, &r.DurationMs, &r.StatusCode,
@@ -719,7 +736,7 @@ func (s *Store) GetByID(ctx context.Context, id int64) (*Record, error) {
 	}
 
 	query := `
-		SELECT id, request_id, timestamp, ip, api_key, api_key_masked, model, provider,
+		SELECT id, request_id, timestamp, ip, api_key, api_key_masked, tenant, model, provider,
 			COALESCE((
 				SELECT provider
 				FROM request_candidates rc
@@ -760,7 +777,7 @@ func (s *Store) GetByID(ctx context.Context, id int64) (*Record, error) {
 	var upstreamHasRetry int
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&r.ID, &r.RequestID, &timestamp, &r.IP, &r.APIKey, &r.APIKeyMasked,
+		&r.ID, &r.RequestID, &timestamp, &r.IP, &r.APIKey, &r.APIKeyMasked, &r.Tenant,
 		&r.Model, &r.Provider, &r.UpstreamProvider, &r.UpstreamAPIKeyMasked, &r.UpstreamCandidateCount, &upstreamHasRetry,
 		&isStreaming, &r.InputTokens,
 		&r.OutputTokens, &r.TotalTokens, &r.CachedTokens, &r.ReasoningTokens, &r.DurationMs, &r.StatusCode,
@@ -813,6 +830,45 @@ func (s *Store) DeleteOlderThan(ctx context.Context, age time.Duration) (int64,
 	return result.RowsAffected()
 }
 
+// Snapshot writes a transactionally consistent copy of the database to dest using
+// VACUUM INTO, so it is safe to call while requests are being recorded. dest must
+// not exist.
+func (s *Store) Snapshot(ctx context.Context, dest string) error {
+	if s.isClosed() {
+		return fmt.Errorf("store is closed")
+	}
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", dest); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// SnapshotDatabase writes a consistent copy of the usage records database found in
+// dataDir to dest without starting a Store. It is used when the server is not running.
+func SnapshotDatabase(ctx context.Context, dataDir, dest string) error {
+	dbPath := DatabasePath(dataDir)
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("usage records database not found: %w", err)
+	}
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	if _, err = db.ExecContext(ctx, "VACUUM INTO ?", dest); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// DatabasePath returns the usage records database file inside dataDir.
+func DatabasePath(dataDir string) string {
+	if dataDir == "" {
+		dataDir = "."
+	}
+	return filepath.Join(dataDir, "usage_records.db")
+}
+
 // Close closes the database connection.
 func (s *Store) Close() error {
 	if s == nil {
@@ -0,0 +1,42 @@
+package usagerecord
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreSnapshotIsReadable(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer store.Close()
+
+	if err = store.Insert(context.Background(), &Record{RequestID: "req-1", Timestamp: time.Now(), Model: "gpt-5", Provider: "codex"}); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+
+	snapshotDir := t.TempDir()
+	if err = store.Snapshot(context.Background(), DatabasePath(snapshotDir)); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	copyStore, err := NewStore(snapshotDir)
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer copyStore.Close()
+	result, err := copyStore.List(context.Background(), ListQuery{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if result.Total != 1 {
+		t.Fatalf("snapshot records = %d, want 1", result.Total)
+	}
+
+	again := filepath.Join(t.TempDir(), "usage.db")
+	if err = SnapshotDatabase(context.Background(), snapshotDir, again); err != nil {
+		t.Fatalf("SnapshotDatabase() error = %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/confighistory"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	"gopkg.in/yaml.v3"
@@ -114,8 +115,9 @@ func (w *Watcher) reloadConfig() bool {
 		log.Debugf("log level updated - debug mode changed from %t to %t", oldConfig.Debug, newConfig.Debug)
 	}
 
+	var details []string
 	if oldConfig != nil {
-		details := diff.BuildConfigChangeDetails(oldConfig, newConfig)
+		details = diff.BuildConfigChangeDetails(oldConfig, newConfig)
 		if len(details) > 0 {
 			log.Debugf("config changes detected:")
 			for _, d := range details {
@@ -130,7 +132,28 @@ func (w *Watcher) reloadConfig() bool {
 	retryConfigChanged := oldConfig != nil && (oldConfig.RequestRetry != newConfig.RequestRetry || oldConfig.MaxRetryInterval != newConfig.MaxRetryInterval || oldConfig.MaxRetryCredentials != newConfig.MaxRetryCredentials)
 	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix || !reflect.DeepEqual(oldConfig.OAuthModelAlias, newConfig.OAuthModelAlias) || retryConfigChanged)
 
+	w.recordConfigVersion(newConfig, "reload", details)
+
 	log.Infof("config successfully reloaded, triggering client reload")
 	w.reloadClients(authDirChanged, affectedOAuthProviders, forceAuthRefresh)
 	return true
 }
+
+// recordConfigVersion snapshots the accepted config file into the config history.
+func (w *Watcher) recordConfigVersion(cfg *config.Config, source string, changes []string) {
+	history := confighistory.ForConfig(w.configPath)
+	if history == nil || cfg == nil {
+		return
+	}
+	history.SetLimit(cfg.ConfigHistoryLimit)
+	data, err := os.ReadFile(w.configPath)
+	if err != nil {
+		log.WithError(err).Debug("failed to read config for history snapshot")
+		return
+	}
+	if version, added, errRecord := history.Record(data, source, changes); errRecord != nil {
+		log.WithError(errRecord).Warn("failed to record config version")
+	} else if added {
+		log.Debugf("recorded config version %d (%s)", version.ID, version.Source)
+	}
+}
@@ -3,6 +3,7 @@ package diff
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -71,6 +72,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if !slices.Equal(oldCfg.IPAccess.Allow, newCfg.IPAccess.Allow) {
+		changes = append(changes, fmt.Sprintf("ip-access.allow: %d -> %d entries", len(oldCfg.IPAccess.Allow), len(newCfg.IPAccess.Allow)))
+	}
+	if !slices.Equal(oldCfg.IPAccess.Deny, newCfg.IPAccess.Deny) {
+		changes = append(changes, fmt.Sprintf("ip-access.deny: %d -> %d entries", len(oldCfg.IPAccess.Deny), len(newCfg.IPAccess.Deny)))
+	}
+	if !slices.Equal(oldCfg.IPAccess.TrustedProxies, newCfg.IPAccess.TrustedProxies) {
+		changes = append(changes, fmt.Sprintf("ip-access.trusted-proxies: %d -> %d entries", len(oldCfg.IPAccess.TrustedProxies), len(newCfg.IPAccess.TrustedProxies)))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -105,6 +115,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].api-key: updated", i))
 			}
@@ -140,6 +153,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("claude[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("claude[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("claude[%d].api-key: updated", i))
 			}
@@ -186,6 +202,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("codex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("codex[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
 			if o.Websockets != n.Websockets {
 				changes = append(changes, fmt.Sprintf("codex[%d].websockets: %t -> %t", i, o.Websockets, n.Websockets))
 			}
@@ -271,6 +290,10 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	if accounts := diffManagementAccounts(oldCfg.RemoteManagement.Accounts, newCfg.RemoteManagement.Accounts); len(accounts) > 0 {
+		changes = append(changes, accounts...)
+	}
+
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
 		changes = append(changes, "openai-compatibility:")
@@ -295,6 +318,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("vertex[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].api-key: updated", i))
 			}
@@ -314,6 +340,259 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// AWS Bedrock credentials
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock-api-key count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if strings.TrimSpace(o.Region) != strings.TrimSpace(n.Region) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].region: %s -> %s", i, strings.TrimSpace(o.Region), strings.TrimSpace(n.Region)))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
+			if strings.TrimSpace(o.ModelIDPrefix) != strings.TrimSpace(n.ModelIDPrefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].model-id-prefix: %s -> %s", i, strings.TrimSpace(o.ModelIDPrefix), strings.TrimSpace(n.ModelIDPrefix)))
+			}
+			if strings.TrimSpace(o.Profile) != strings.TrimSpace(n.Profile) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].profile: %s -> %s", i, strings.TrimSpace(o.Profile), strings.TrimSpace(n.Profile)))
+			}
+			if strings.TrimSpace(o.AccessKeyID) != strings.TrimSpace(n.AccessKeyID) || strings.TrimSpace(o.SecretAccessKey) != strings.TrimSpace(n.SecretAccessKey) || strings.TrimSpace(o.SessionToken) != strings.TrimSpace(n.SessionToken) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if ComputeBedrockModelsHash(o.Models) != ComputeBedrockModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Azure OpenAI resources
+	if len(oldCfg.AzureOpenAIKey) != len(newCfg.AzureOpenAIKey) {
+		changes = append(changes, fmt.Sprintf("azure-openai count: %d -> %d", len(oldCfg.AzureOpenAIKey), len(newCfg.AzureOpenAIKey)))
+	} else {
+		for i := range oldCfg.AzureOpenAIKey {
+			o := oldCfg.AzureOpenAIKey[i]
+			n := newCfg.AzureOpenAIKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.APIVersion) != strings.TrimSpace(n.APIVersion) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].api-version: %s -> %s", i, strings.TrimSpace(o.APIVersion), strings.TrimSpace(n.APIVersion)))
+			}
+			if strings.TrimSpace(o.ResponsesAPIVersion) != strings.TrimSpace(n.ResponsesAPIVersion) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].responses-api-version: %s -> %s", i, strings.TrimSpace(o.ResponsesAPIVersion), strings.TrimSpace(n.ResponsesAPIVersion)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) || !equalAzureEntra(o.Entra, n.Entra) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].credentials: updated", i))
+			}
+			if ComputeAzureOpenAIModelsHash(o.Models) != ComputeAzureOpenAIModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Local backends
+	if len(oldCfg.LocalProviders) != len(newCfg.LocalProviders) {
+		changes = append(changes, fmt.Sprintf("local-providers count: %d -> %d", len(oldCfg.LocalProviders), len(newCfg.LocalProviders)))
+	} else {
+		for i := range oldCfg.LocalProviders {
+			o := oldCfg.LocalProviders[i]
+			n := newCfg.LocalProviders[i]
+			if strings.TrimSpace(o.Name) != strings.TrimSpace(n.Name) {
+				changes = append(changes, fmt.Sprintf("local[%d].name: %s -> %s", i, strings.TrimSpace(o.Name), strings.TrimSpace(n.Name)))
+			}
+			if strings.TrimSpace(o.Type) != strings.TrimSpace(n.Type) {
+				changes = append(changes, fmt.Sprintf("local[%d].type: %s -> %s", i, strings.TrimSpace(o.Type), strings.TrimSpace(n.Type)))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("local[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if o.DiscoveryEvery() != n.DiscoveryEvery() {
+				changes = append(changes, fmt.Sprintf("local[%d].discovery-interval: %s -> %s", i, o.DiscoveryEvery(), n.DiscoveryEvery()))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("local[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("local[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.Tenant) != strings.TrimSpace(n.Tenant) {
+				changes = append(changes, fmt.Sprintf("local[%d].tenant: %s -> %s", i, strings.TrimSpace(o.Tenant), strings.TrimSpace(n.Tenant)))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("local[%d].api-key: updated", i))
+			}
+			if ComputeLocalModelsHash(o.Models) != ComputeLocalModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("local[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("local[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("local[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Declarative OAuth providers
+	if len(oldCfg.OAuthProviders) != len(newCfg.OAuthProviders) {
+		changes = append(changes, fmt.Sprintf("oauth-providers count: %d -> %d", len(oldCfg.OAuthProviders), len(newCfg.OAuthProviders)))
+	} else {
+		for i := range oldCfg.OAuthProviders {
+			o := oldCfg.OAuthProviders[i]
+			n := newCfg.OAuthProviders[i]
+			if o.Name != n.Name {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].name: %s -> %s", i, o.Name, n.Name))
+			}
+			if o.Flow != n.Flow {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].flow: %s -> %s", i, o.Flow, n.Flow))
+			}
+			if o.ClientID != n.ClientID {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].client-id: updated", i))
+			}
+			if o.ClientSecret != n.ClientSecret {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].client-secret: updated", i))
+			}
+			if o.AuthorizeURL != n.AuthorizeURL || o.TokenURL != n.TokenURL || o.DeviceAuthorizationURL != n.DeviceAuthorizationURL {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].endpoints: updated", i))
+			}
+			if strings.Join(o.Scopes, " ") != strings.Join(n.Scopes, " ") {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].scopes: %s -> %s", i, strings.Join(o.Scopes, " "), strings.Join(n.Scopes, " ")))
+			}
+			if o.RefreshLeadDuration() != n.RefreshLeadDuration() {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].refresh-lead: %s -> %s", i, o.RefreshLeadDuration(), n.RefreshLeadDuration()))
+			}
+			if o.BaseURL != n.BaseURL {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].base-url: %s -> %s", i, o.BaseURL, n.BaseURL))
+			}
+			if o.Format != n.Format {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].format: %s -> %s", i, o.Format, n.Format))
+			}
+			if o.ProxyURL != n.ProxyURL {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if ComputeOAuthProviderModelsHash(o.Models) != ComputeOAuthProviderModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("oauth-providers[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Executor plugins
+	if len(oldCfg.Plugins) != len(newCfg.Plugins) {
+		changes = append(changes, fmt.Sprintf("plugins count: %d -> %d", len(oldCfg.Plugins), len(newCfg.Plugins)))
+	} else {
+		for i := range oldCfg.Plugins {
+			o := oldCfg.Plugins[i]
+			n := newCfg.Plugins[i]
+			if o.Name != n.Name {
+				changes = append(changes, fmt.Sprintf("plugins[%d].name: %s -> %s", i, o.Name, n.Name))
+			}
+			if o.Command != n.Command || strings.Join(o.Args, " ") != strings.Join(n.Args, " ") {
+				changes = append(changes, fmt.Sprintf("plugins[%d].command: updated", i))
+			}
+			if !equalStringMap(o.Env, n.Env) {
+				changes = append(changes, fmt.Sprintf("plugins[%d].env: updated", i))
+			}
+			if o.SocketPath() != n.SocketPath() {
+				changes = append(changes, fmt.Sprintf("plugins[%d].socket: %s -> %s", i, o.SocketPath(), n.SocketPath()))
+			}
+			if o.Format != n.Format {
+				changes = append(changes, fmt.Sprintf("plugins[%d].format: %s -> %s", i, o.Format, n.Format))
+			}
+			if o.HealthEvery() != n.HealthEvery() {
+				changes = append(changes, fmt.Sprintf("plugins[%d].health-check-interval: %s -> %s", i, o.HealthEvery(), n.HealthEvery()))
+			}
+			if o.DiscoveryEvery() != n.DiscoveryEvery() {
+				changes = append(changes, fmt.Sprintf("plugins[%d].discovery-interval: %s -> %s", i, o.DiscoveryEvery(), n.DiscoveryEvery()))
+			}
+			if o.Disabled != n.Disabled {
+				changes = append(changes, fmt.Sprintf("plugins[%d].disabled: %t -> %t", i, o.Disabled, n.Disabled))
+			}
+			if ComputePluginModelsHash(o.Models) != ComputePluginModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("plugins[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("plugins[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Attributes, n.Attributes) {
+				changes = append(changes, fmt.Sprintf("plugins[%d].attributes: updated", i))
+			}
+		}
+	}
+
+	// Proxy pools
+	if len(oldCfg.ProxyPools) != len(newCfg.ProxyPools) {
+		changes = append(changes, fmt.Sprintf("proxy-pools count: %d -> %d", len(oldCfg.ProxyPools), len(newCfg.ProxyPools)))
+	} else {
+		for i := range oldCfg.ProxyPools {
+			o := oldCfg.ProxyPools[i]
+			n := newCfg.ProxyPools[i]
+			if o.Name != n.Name {
+				changes = append(changes, fmt.Sprintf("proxy-pools[%d].name: %s -> %s", i, o.Name, n.Name))
+			}
+			if strings.Join(o.Proxies, ",") != strings.Join(n.Proxies, ",") {
+				changes = append(changes, fmt.Sprintf("proxy-pools[%d].proxies: updated (%d -> %d entries)", i, len(o.Proxies), len(n.Proxies)))
+			}
+			if strings.Join(o.Providers, ",") != strings.Join(n.Providers, ",") {
+				changes = append(changes, fmt.Sprintf("proxy-pools[%d].providers: %v -> %v", i, o.Providers, n.Providers))
+			}
+			if o.HealthCheck != n.HealthCheck {
+				changes = append(changes, fmt.Sprintf("proxy-pools[%d].health-check: updated", i))
+			}
+			if o.FailureCooldown != n.FailureCooldown {
+				changes = append(changes, fmt.Sprintf("proxy-pools[%d].failure-cooldown: %s -> %s", i, o.FailureCooldown, n.FailureCooldown))
+			}
+		}
+	}
+
 	return changes
 }
 
@@ -406,7 +685,7 @@ func equalUpstreamAPIKeys(a, b []config.AmpUpstreamAPIKeyEntry) bool {
 }
 
 // apiKeyEntriesEqual compares two slices of ApiKeyEntry for equality.
-// Only compares key values and active status, ignores metadata like usage counts.
+// Only compares key values, active status, name and expiry, ignores metadata like usage counts.
 func apiKeyEntriesEqual(a, b []config.ApiKeyEntry) bool {
 	if len(a) != len(b) {
 		return false
@@ -421,6 +700,52 @@ func apiKeyEntriesEqual(a, b []config.ApiKeyEntry) bool {
 		if strings.TrimSpace(a[i].Name) != strings.TrimSpace(b[i].Name) {
 			return false
 		}
+		if strings.TrimSpace(a[i].ExpiresAt) != strings.TrimSpace(b[i].ExpiresAt) {
+			return false
+		}
+		if !slices.Equal(a[i].IPAllow, b[i].IPAllow) || !slices.Equal(a[i].IPDeny, b[i].IPDeny) {
+			return false
+		}
+		if a[i].Tenant != b[i].Tenant {
+			return false
+		}
 	}
 	return true
 }
+
+// diffManagementAccounts reports added, removed and changed management accounts by ID.
+// Token hashes are never printed.
+func diffManagementAccounts(oldAccounts, newAccounts []config.ManagementAccount) []string {
+	oldByID := make(map[string]config.ManagementAccount, len(oldAccounts))
+	for _, account := range oldAccounts {
+		oldByID[account.ID] = account
+	}
+	var changes []string
+	for _, account := range newAccounts {
+		prev, ok := oldByID[account.ID]
+		delete(oldByID, account.ID)
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("remote-management.accounts: added %s (%s)", account.Name, account.Role))
+		case prev.Role != account.Role:
+			changes = append(changes, fmt.Sprintf("remote-management.accounts[%s].role: %s -> %s", account.Name, prev.Role, account.Role))
+		case prev.Disabled != account.Disabled:
+			changes = append(changes, fmt.Sprintf("remote-management.accounts[%s].disabled: %t -> %t", account.Name, prev.Disabled, account.Disabled))
+		case prev.TokenHash != account.TokenHash:
+			changes = append(changes, fmt.Sprintf("remote-management.accounts[%s].token: updated", account.Name))
+		}
+	}
+	for _, account := range oldAccounts {
+		if _, removed := oldByID[account.ID]; removed {
+			changes = append(changes, fmt.Sprintf("remote-management.accounts: removed %s", account.Name))
+		}
+	}
+	return changes
+}
+
+func equalAzureEntra(a, b *config.AzureEntraAuth) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -26,6 +26,24 @@ func ComputeOpenAICompatModelsHash(models []config.OpenAICompatibilityModel) str
 	return hashJoined(keys)
 }
 
+// ComputeModelDiscoveryHash returns a stable hash for model discovery settings, or an
+// empty string when discovery is disabled.
+func ComputeModelDiscoveryHash(discovery *config.ModelDiscovery) string {
+	interval := discovery.DiscoveryEvery()
+	if interval <= 0 {
+		return ""
+	}
+	include := append([]string(nil), discovery.Include...)
+	exclude := append([]string(nil), discovery.Exclude...)
+	sort.Strings(include)
+	sort.Strings(exclude)
+	return hashJoined([]string{
+		interval.String(),
+		"include=" + strings.ToLower(strings.Join(include, ",")),
+		"exclude=" + strings.ToLower(strings.Join(exclude, ",")),
+	})
+}
+
 // ComputeVertexCompatModelsHash returns a stable hash for Vertex-compatible models.
 func ComputeVertexCompatModelsHash(models []config.VertexCompatModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
@@ -56,6 +74,81 @@ func ComputeClaudeModelsHash(models []config.ClaudeModel) string {
 	return hashJoined(keys)
 }
 
+// ComputeBedrockModelsHash returns a stable hash for Bedrock model aliases.
+func ComputeBedrockModelsHash(models []config.BedrockModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeAzureOpenAIModelsHash returns a stable hash for Azure OpenAI deployment aliases.
+func ComputeAzureOpenAIModelsHash(models []config.AzureOpenAIModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeLocalModelsHash returns a stable hash for local backend model aliases.
+func ComputeLocalModelsHash(models []config.LocalModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeOAuthProviderModelsHash returns a stable hash for declarative OAuth provider models.
+func ComputeOAuthProviderModelsHash(models []config.OAuthProviderModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputePluginModelsHash returns a stable hash for executor plugin models.
+func ComputePluginModelsHash(models []config.PluginModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
 // ComputeCodexModelsHash returns a stable hash for Codex model aliases.
 func ComputeCodexModelsHash(models []config.CodexModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
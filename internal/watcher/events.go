@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,6 +27,19 @@ func matchProvider(provider string, targets []string) (string, bool) {
 	return p, false
 }
 
+// rewatchConfig re-adds the watch on the config file after it was replaced.
+func (w *Watcher) rewatchConfig() {
+	if w.watcher == nil {
+		return
+	}
+	if _, err := os.Stat(w.configPath); err != nil {
+		return
+	}
+	if err := w.watcher.Add(w.configPath); err != nil {
+		log.Warnf("failed to re-watch config file %s: %v", w.configPath, err)
+	}
+}
+
 func (w *Watcher) start(ctx context.Context) error {
 	if errAddConfig := w.watcher.Add(w.configPath); errAddConfig != nil {
 		log.Errorf("failed to watch config file %s: %v", w.configPath, errAddConfig)
@@ -39,6 +53,11 @@ func (w *Watcher) start(ctx context.Context) error {
 	}
 	log.Debugf("watching auth directory: %s", w.authDir)
 
+	w.clientsMutex.RLock()
+	cfg := w.config
+	w.clientsMutex.RUnlock()
+	w.recordConfigVersion(cfg, "startup", nil)
+
 	go w.processEvents(ctx)
 
 	w.reloadClients(true, nil, false)
@@ -66,7 +85,7 @@ func (w *Watcher) processEvents(ctx context.Context) {
 
 func (w *Watcher) handleEvent(event fsnotify.Event) {
 	// Filter only relevant events: config file or auth-dir JSON files.
-	configOps := fsnotify.Write | fsnotify.Create | fsnotify.Rename
+	configOps := fsnotify.Write | fsnotify.Create | fsnotify.Rename | fsnotify.Remove
 	normalizedName := w.normalizeAuthPath(event.Name)
 	normalizedConfigPath := w.normalizeAuthPath(w.configPath)
 	normalizedAuthDir := w.normalizeAuthPath(w.authDir)
@@ -84,6 +103,11 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	// Handle config file changes
 	if isConfigEvent {
 		log.Debugf("config file change details - operation: %s, timestamp: %s", event.Op.String(), now.Format("2006-01-02 15:04:05.000"))
+		if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			// An atomic replace (temp file renamed over the config) drops the watch on
+			// the old file; watch the new one.
+			w.rewatchConfig()
+		}
 		w.scheduleConfigReload()
 		return
 	}
@@ -125,7 +149,7 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 }
 
 func (w *Watcher) authFileUnchanged(path string) (bool, error) {
-	data, errRead := os.ReadFile(path)
+	data, errRead := encryption.ReadFile(path)
 	if errRead != nil {
 		return false, errRead
 	}
@@ -35,6 +35,14 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
+	// AWS Bedrock
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
+	// Azure OpenAI
+	out = append(out, s.synthesizeAzureOpenAIKeys(ctx)...)
+	// Local backends
+	out = append(out, s.synthesizeLocalProviders(ctx)...)
+	// Executor plugins
+	out = append(out, s.synthesizePlugins(ctx)...)
 
 	return out, nil
 }
@@ -70,6 +78,9 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(entry.Headers, attrs)
+		if tenant := strings.TrimSpace(entry.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   "gemini",
@@ -118,6 +129,9 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		if tenant := strings.TrimSpace(ck.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -137,6 +151,248 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 	return out
 }
 
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		bk := cfg.BedrockKey[i]
+		key := strings.TrimSpace(bk.GetAPIKey())
+		region := strings.TrimSpace(bk.Region)
+		if key == "" || region == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(bk.Prefix)
+		base := strings.TrimSpace(bk.BaseURL)
+		id, token := idGen.Next("bedrock:apikey", key, base)
+		attrs := map[string]string{
+			"source":  fmt.Sprintf("config:bedrock[%s]", token),
+			"api_key": key,
+			"region":  region,
+		}
+		if v := strings.TrimSpace(bk.SecretAccessKey); v != "" {
+			attrs["secret_access_key"] = v
+		}
+		if v := strings.TrimSpace(bk.SessionToken); v != "" {
+			attrs["session_token"] = v
+		}
+		if v := strings.TrimSpace(bk.Profile); v != "" {
+			attrs["profile"] = v
+		}
+		if v := strings.TrimSpace(bk.ModelIDPrefix); v != "" {
+			attrs["model_id_prefix"] = v
+		}
+		if bk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(bk.Priority)
+		}
+		if base != "" {
+			attrs["base_url"] = base
+		}
+		if hash := diff.ComputeBedrockModelsHash(bk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(bk.Headers, attrs)
+		if tenant := strings.TrimSpace(bk.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-" + region,
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(bk.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		applyConfigAuthDisabled(a, bk.Disabled)
+		ApplyAuthExcludedModelsMeta(a, cfg, bk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeAzureOpenAIKeys creates Auth entries for Azure OpenAI resources.
+func (s *ConfigSynthesizer) synthesizeAzureOpenAIKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.AzureOpenAIKey))
+	for i := range cfg.AzureOpenAIKey {
+		ak := cfg.AzureOpenAIKey[i]
+		key := strings.TrimSpace(ak.GetAPIKey())
+		base := strings.TrimSpace(ak.BaseURL)
+		if key == "" || base == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(ak.Prefix)
+		id, token := idGen.Next("azure-openai:apikey", key, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:azure-openai[%s]", token),
+			"api_key":  key,
+			"base_url": base,
+		}
+		if entra := ak.Entra; entra != nil && strings.TrimSpace(ak.APIKey) == "" {
+			attrs["entra_tenant_id"] = strings.TrimSpace(entra.TenantID)
+			attrs["client_id"] = strings.TrimSpace(entra.ClientID)
+			attrs["client_secret"] = strings.TrimSpace(entra.ClientSecret)
+			if v := strings.TrimSpace(entra.AuthorityHost); v != "" {
+				attrs["authority_host"] = v
+			}
+			if v := strings.TrimSpace(entra.Scope); v != "" {
+				attrs["scope"] = v
+			}
+		}
+		if v := strings.TrimSpace(ak.APIVersion); v != "" {
+			attrs["api_version"] = v
+		}
+		if v := strings.TrimSpace(ak.ResponsesAPIVersion); v != "" {
+			attrs["responses_api_version"] = v
+		}
+		if ak.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(ak.Priority)
+		}
+		if hash := diff.ComputeAzureOpenAIModelsHash(ak.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(ak.Headers, attrs)
+		if tenant := strings.TrimSpace(ak.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
+		label := strings.TrimSpace(ak.Name)
+		if label == "" {
+			label = "azure-openai"
+		}
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "azure-openai",
+			Label:      label,
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(ak.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		applyConfigAuthDisabled(a, ak.Disabled)
+		ApplyAuthExcludedModelsMeta(a, cfg, ak.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeLocalProviders creates Auth entries for self-hosted model servers.
+func (s *ConfigSynthesizer) synthesizeLocalProviders(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.LocalProviders))
+	for i := range cfg.LocalProviders {
+		lp := cfg.LocalProviders[i]
+		name := strings.TrimSpace(lp.Name)
+		base := strings.TrimSpace(lp.BaseURL)
+		if name == "" || base == "" {
+			continue
+		}
+		id, token := idGen.Next("local", name, base)
+		attrs := map[string]string{
+			"source":       fmt.Sprintf("config:local[%s]", token),
+			"local_name":   name,
+			"backend_type": lp.Type,
+			"base_url":     base,
+		}
+		if key := strings.TrimSpace(lp.APIKey); key != "" {
+			attrs["api_key"] = key
+		}
+		if interval := lp.DiscoveryEvery(); interval > 0 {
+			attrs["discovery_interval"] = interval.String()
+		}
+		if lp.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(lp.Priority)
+		}
+		if hash := diff.ComputeLocalModelsHash(lp.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(lp.Headers, attrs)
+		if tenant := strings.TrimSpace(lp.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "local",
+			Label:      name,
+			Prefix:     strings.TrimSpace(lp.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(lp.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		applyConfigAuthDisabled(a, lp.Disabled)
+		ApplyAuthExcludedModelsMeta(a, cfg, lp.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizePlugins creates one Auth entry per executor plugin.
+func (s *ConfigSynthesizer) synthesizePlugins(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.Plugins))
+	for i := range cfg.Plugins {
+		p := cfg.Plugins[i]
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			continue
+		}
+		id, token := idGen.Next("plugin", name, p.SocketPath())
+		attrs := map[string]string{
+			"source":      fmt.Sprintf("config:plugin[%s]", token),
+			"plugin_name": name,
+		}
+		if interval := p.DiscoveryEvery(); interval > 0 {
+			attrs["discovery_interval"] = interval.String()
+		}
+		if p.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(p.Priority)
+		}
+		if hash := diff.ComputePluginModelsHash(p.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		if tenant := strings.TrimSpace(p.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
+		for key, value := range p.Attributes {
+			if _, exists := attrs[key]; !exists {
+				attrs[key] = value
+			}
+		}
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   name,
+			Label:      name,
+			Prefix:     strings.TrimSpace(p.Prefix),
+			Status:     coreauth.StatusActive,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		applyConfigAuthDisabled(a, p.Disabled)
+		ApplyAuthExcludedModelsMeta(a, cfg, p.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
 // synthesizeCodexKeys creates Auth entries for Codex API keys.
 func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreauth.Auth {
 	cfg := ctx.Config
@@ -169,6 +425,9 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		if tenant := strings.TrimSpace(ck.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -228,6 +487,13 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			if interval := compat.DiscoverModels.DiscoveryEvery(); interval > 0 {
+				attrs["discovery_interval"] = interval.String()
+				attrs["discovery_hash"] = diff.ComputeModelDiscoveryHash(compat.DiscoverModels)
+			}
+			if tenant := strings.TrimSpace(compat.Tenant); tenant != "" {
+				attrs["tenant"] = tenant
+			}
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -259,6 +525,13 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			if interval := compat.DiscoverModels.DiscoveryEvery(); interval > 0 {
+				attrs["discovery_interval"] = interval.String()
+				attrs["discovery_hash"] = diff.ComputeModelDiscoveryHash(compat.DiscoverModels)
+			}
+			if tenant := strings.TrimSpace(compat.Tenant); tenant != "" {
+				attrs["tenant"] = tenant
+			}
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -307,6 +580,9 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(compat.Headers, attrs)
+		if tenant := strings.TrimSpace(compat.Tenant); tenant != "" {
+			attrs["tenant"] = tenant
+		}
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   providerName,
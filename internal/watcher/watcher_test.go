@@ -299,6 +299,47 @@ func TestStartAndStopSuccess(t *testing.T) {
 	}
 }
 
+func TestConfigAtomicReplaceKeepsWatching(t *testing.T) {
+	tmpDir := t.TempDir()
+	authDir := filepath.Join(tmpDir, "auth")
+	if err := os.MkdirAll(authDir, 0o755); err != nil {
+		t.Fatalf("failed to create auth dir: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("auth-dir: "+authDir+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	var reloads int32
+	w, err := NewWatcher(configPath, authDir, func(*config.Config) {
+		atomic.AddInt32(&reloads, 1)
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	w.SetConfig(&config.Config{AuthDir: authDir})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err = w.Start(ctx); err != nil {
+		t.Fatalf("expected Start to succeed: %v", err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	for i, port := range []string{"8001", "8002"} {
+		want := int32(i + 2)
+		if err = config.WriteConfigFile(configPath, []byte("auth-dir: "+authDir+"\nport: "+port+"\n")); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		deadline := time.Now().Add(3 * time.Second)
+		for atomic.LoadInt32(&reloads) < want && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if got := atomic.LoadInt32(&reloads); got != want {
+			t.Fatalf("replace %d: reloads = %d, want %d", i+1, got, want)
+		}
+	}
+}
+
 func TestStartFailsWhenConfigMissing(t *testing.T) {
 	tmpDir := t.TempDir()
 	authDir := filepath.Join(tmpDir, "auth")
@@ -0,0 +1,32 @@
+package wildcard
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"gpt-5", "gpt-5", true},
+		{"gpt-5", "gpt-5-mini", false},
+		{"*", "", true},
+		{"*", "anything", true},
+		{"gpt-*", "gpt-5", true},
+		{"*-5", "gpt-5", true},
+		{"gemini-*-pro", "gemini-2.5-pro", true},
+		{"gemini-*-pro", "gemini-pro", false},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "acb", false},
+		{"ab*ba", "aba", false},
+		{"auth.*", "auth.disabled", true},
+		{"auth.*", "quota.exceeded", false},
+		{"", "", false},
+		{"GPT-*", "gpt-5", false},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
+)
+
+// ModelAllowed reports whether model passes the MetadataAllowedModels restriction in
+// metadata. Matching is case-insensitive and ignores a trailing thinking suffix such as
+// "(8192)".
+func ModelAllowed(metadata map[string]string, model string) bool {
+	raw, ok := metadata[MetadataAllowedModels]
+	if !ok {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	if idx := strings.LastIndex(model, "("); idx > 0 && strings.HasSuffix(model, ")") {
+		model = model[:idx]
+	}
+	for _, pattern := range strings.Split(raw, ",") {
+		if wildcard.Match(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
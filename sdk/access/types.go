@@ -30,6 +30,26 @@ const (
 
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
+
+	// AccessProviderTypeJWT is the built-in provider validating JWT/OIDC bearer tokens.
+	AccessProviderTypeJWT = "jwt"
+
+	// AccessProviderTypeClientCert is the built-in provider accepting verified TLS client certificates.
+	AccessProviderTypeClientCert = "client-cert"
+)
+
+const (
+	// MetadataAllowedModels restricts the authenticated client to a comma-separated list
+	// of model patterns. Patterns may contain "*" wildcards; absent means no restriction.
+	MetadataAllowedModels = "allowed-models"
+
+	// MetadataIdentity carries a non-secret display identity for the principal (for example
+	// a token's email claim). Usage records store it in place of a masked key.
+	MetadataIdentity = "identity"
+
+	// MetadataTenant names the tenant the principal belongs to. The scheduler only selects
+	// credentials of that tenant or shared ones; absent means the client is not in a tenant.
+	MetadataTenant = "tenant"
 )
 
 // MakeInlineAPIKeyProvider constructs an inline API key provider configuration.
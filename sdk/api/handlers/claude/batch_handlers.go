@@ -0,0 +1,696 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/claudebatch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	anthropicBaseURL        = "https://api.anthropic.com"
+	defaultAnthropicVersion = "2023-06-01"
+	filesAPIBeta            = "files-api-2025-04-14"
+	maxListLimit            = 1000
+	defaultListLimit        = 20
+)
+
+// ClaudeBatchAPIHandler serves the Anthropic Message Batches and Files APIs.
+//
+// Batches whose models are all served by an Anthropic API-key credential are passed
+// through to Anthropic and pinned to that credential, so polling, results and
+// cancellation hit the key that owns the batch. Other batches are emulated locally by
+// executing each request through the auth manager. Files are always passed through.
+// Batches and files are only visible to the client that created them, identified by
+// its tenant or, without one, by its API key.
+type ClaudeBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	routes *claudebatch.RouteTable
+	runner *claudebatch.Runner
+	next   atomic.Uint64
+}
+
+// NewClaudeBatchAPIHandler creates a batch and files handler that records credential pins in routes.
+func NewClaudeBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, routes *claudebatch.RouteTable) *ClaudeBatchAPIHandler {
+	if routes == nil {
+		routes = claudebatch.NewRouteTable("")
+	}
+	h := &ClaudeBatchAPIHandler{BaseAPIHandler: apiHandlers, routes: routes}
+	h.runner = claudebatch.NewRunner(h.executeBatchRequest, 0)
+	return h
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *ClaudeBatchAPIHandler) HandlerType() string {
+	return Claude
+}
+
+// Models returns a list of models supported by this handler.
+func (h *ClaudeBatchAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("claude")
+}
+
+// CreateBatch handles POST /v1/messages/batches.
+func (h *ClaudeBatchAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeClaudeError(c, http.StatusBadRequest, "Invalid request: body must be a JSON object")
+		return
+	}
+	items := gjson.GetBytes(rawJSON, "requests")
+	if !items.IsArray() {
+		writeClaudeError(c, http.StatusBadRequest, "requests: field required")
+		return
+	}
+
+	requests := make([]claudebatch.Request, 0, len(items.Array()))
+	models := make(map[string]struct{})
+	var fileIDs []string
+	items.ForEach(func(_, item gjson.Result) bool {
+		params := item.Get("params")
+		requests = append(requests, claudebatch.Request{CustomID: item.Get("custom_id").String(), Params: []byte(params.Raw)})
+		models[params.Get("model").String()] = struct{}{}
+		fileIDs = append(fileIDs, referencedFileIDs(params)...)
+		return true
+	})
+
+	for model := range models {
+		if model == "" {
+			continue
+		}
+		if errMsg := h.CheckModelAccess(c, model); errMsg != nil {
+			writeClaudeError(c, errMsg.StatusCode, errMsg.Error.Error())
+			return
+		}
+	}
+
+	owner := requestOwner(c)
+	if auth := h.selectBatchAuth(owner, requestTenant(c), models, fileIDs); auth != nil {
+		resp, errForward := h.forward(c, auth, http.MethodPost, "/v1/messages/batches", "", rawJSON, "application/json", false)
+		if errForward != nil {
+			writeClaudeError(c, http.StatusBadGateway, errForward.Error())
+			return
+		}
+		h.relayJSON(c, resp, func(body []byte) []byte {
+			if id := gjson.GetBytes(body, "id").String(); id != "" {
+				h.routes.PinBatch(id, auth.ID, owner)
+			}
+			return h.rewriteResultsURL(c, body)
+		})
+		return
+	}
+
+	batch, errSubmit := h.runner.Submit(handlers.DetachedContext(c), owner, requests)
+	if errSubmit != nil {
+		writeClaudeError(c, http.StatusBadRequest, errSubmit.Error())
+		return
+	}
+	c.Data(http.StatusOK, "application/json", claudebatch.MarshalBatch(batch, ""))
+}
+
+// GetBatch handles GET /v1/messages/batches/:id.
+func (h *ClaudeBatchAPIHandler) GetBatch(c *gin.Context) {
+	id := c.Param("id")
+	if h.runner.Owns(id, requestOwner(c)) {
+		batch, err := h.runner.Get(id)
+		if err != nil {
+			writeClaudeError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/json", claudebatch.MarshalBatch(batch, resultsURL(c, id)))
+		return
+	}
+	auth, ok := h.pinnedBatchAuth(c, id)
+	if !ok {
+		return
+	}
+	resp, err := h.forward(c, auth, http.MethodGet, "/v1/messages/batches/"+url.PathEscape(id), "", nil, "", false)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	h.relayJSON(c, resp, func(body []byte) []byte { return h.rewriteResultsURL(c, body) })
+}
+
+// ListBatches handles GET /v1/messages/batches. Emulated batches and batches pinned to
+// upstream credentials are merged into a single page ordered by creation time.
+func (h *ClaudeBatchAPIHandler) ListBatches(c *gin.Context) {
+	owner := requestOwner(c)
+	entries := make([]gjson.Result, 0)
+	for _, batch := range h.runner.List(owner) {
+		entries = append(entries, gjson.ParseBytes(claudebatch.MarshalBatch(batch, resultsURL(c, batch.ID))))
+	}
+	for _, authID := range h.routes.AuthIDs(owner) {
+		pinned := h.routes.BatchIDs(authID, owner)
+		if len(pinned) == 0 {
+			continue
+		}
+		for _, item := range h.listUpstream(c, authID, "/v1/messages/batches", false) {
+			if _, ok := pinned[item.Get("id").String()]; ok {
+				entries = append(entries, gjson.ParseBytes(h.rewriteResultsURL(c, []byte(item.Raw))))
+			}
+		}
+	}
+	writeListPage(c, entries)
+}
+
+// CancelBatch handles POST /v1/messages/batches/:id/cancel.
+func (h *ClaudeBatchAPIHandler) CancelBatch(c *gin.Context) {
+	id := c.Param("id")
+	if h.runner.Owns(id, requestOwner(c)) {
+		batch, err := h.runner.Cancel(id)
+		if err != nil {
+			writeClaudeError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/json", claudebatch.MarshalBatch(batch, resultsURL(c, id)))
+		return
+	}
+	auth, ok := h.pinnedBatchAuth(c, id)
+	if !ok {
+		return
+	}
+	resp, err := h.forward(c, auth, http.MethodPost, "/v1/messages/batches/"+url.PathEscape(id)+"/cancel", "", nil, "", false)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	h.relayJSON(c, resp, func(body []byte) []byte { return h.rewriteResultsURL(c, body) })
+}
+
+// DeleteBatch handles DELETE /v1/messages/batches/:id.
+func (h *ClaudeBatchAPIHandler) DeleteBatch(c *gin.Context) {
+	id := c.Param("id")
+	if h.runner.Owns(id, requestOwner(c)) {
+		if err := h.runner.Delete(id); err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, claudebatch.ErrNotEnded) {
+				status = http.StatusBadRequest
+			}
+			writeClaudeError(c, status, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "type": "message_batch_deleted"})
+		return
+	}
+	auth, ok := h.pinnedBatchAuth(c, id)
+	if !ok {
+		return
+	}
+	resp, err := h.forward(c, auth, http.MethodDelete, "/v1/messages/batches/"+url.PathEscape(id), "", nil, "", false)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	h.relayJSON(c, resp, func(body []byte) []byte {
+		h.routes.RemoveBatch(id)
+		return body
+	})
+}
+
+// BatchResults handles GET /v1/messages/batches/:id/results and returns JSONL.
+func (h *ClaudeBatchAPIHandler) BatchResults(c *gin.Context) {
+	id := c.Param("id")
+	if h.runner.Owns(id, requestOwner(c)) {
+		results, err := h.runner.Results(id)
+		if err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, claudebatch.ErrNotEnded) {
+				status = http.StatusBadRequest
+			}
+			writeClaudeError(c, status, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/x-jsonl", results)
+		return
+	}
+	auth, ok := h.pinnedBatchAuth(c, id)
+	if !ok {
+		return
+	}
+	resp, err := h.forward(c, auth, http.MethodGet, "/v1/messages/batches/"+url.PathEscape(id)+"/results", "", nil, "", false)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	relayStream(c, resp)
+}
+
+// UploadFile handles POST /v1/files. The multipart body is forwarded unchanged to an
+// Anthropic API-key credential and the returned file ID is pinned to it.
+func (h *ClaudeBatchAPIHandler) UploadFile(c *gin.Context) {
+	auths := h.anthropicAPIKeyAuths(requestTenant(c))
+	if len(auths) == 0 {
+		writeClaudeError(c, http.StatusNotImplemented, "Files API requires an Anthropic API key credential")
+		return
+	}
+	auth := auths[int(h.next.Add(1)-1)%len(auths)]
+	owner := requestOwner(c)
+	body, err := c.GetRawData()
+	if err != nil {
+		writeClaudeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	resp, err := h.forward(c, auth, http.MethodPost, "/v1/files", "", body, c.GetHeader("Content-Type"), true)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	h.relayJSON(c, resp, func(respBody []byte) []byte {
+		if id := gjson.GetBytes(respBody, "id").String(); id != "" {
+			h.routes.PinFile(id, auth.ID, owner)
+		}
+		return respBody
+	})
+}
+
+// ListFiles handles GET /v1/files, returning files uploaded through this proxy.
+func (h *ClaudeBatchAPIHandler) ListFiles(c *gin.Context) {
+	owner := requestOwner(c)
+	entries := make([]gjson.Result, 0)
+	for _, authID := range h.routes.AuthIDs(owner) {
+		pinned := h.routes.FileIDs(authID, owner)
+		if len(pinned) == 0 {
+			continue
+		}
+		for _, item := range h.listUpstream(c, authID, "/v1/files", true) {
+			if _, ok := pinned[item.Get("id").String()]; ok {
+				entries = append(entries, item)
+			}
+		}
+	}
+	writeListPage(c, entries)
+}
+
+// GetFile handles GET /v1/files/:id.
+func (h *ClaudeBatchAPIHandler) GetFile(c *gin.Context) {
+	h.forwardFile(c, http.MethodGet, "", false)
+}
+
+// FileContent handles GET /v1/files/:id/content.
+func (h *ClaudeBatchAPIHandler) FileContent(c *gin.Context) {
+	h.forwardFile(c, http.MethodGet, "/content", true)
+}
+
+// DeleteFile handles DELETE /v1/files/:id.
+func (h *ClaudeBatchAPIHandler) DeleteFile(c *gin.Context) {
+	h.forwardFile(c, http.MethodDelete, "", false)
+}
+
+func (h *ClaudeBatchAPIHandler) forwardFile(c *gin.Context, method, suffix string, raw bool) {
+	id := c.Param("id")
+	authID, ok := h.routes.FileAuth(id, requestOwner(c))
+	if !ok {
+		writeClaudeError(c, http.StatusNotFound, fmt.Sprintf("File not found: %s", id))
+		return
+	}
+	auth, ok := h.AuthManager.GetByID(authID)
+	if !ok {
+		writeClaudeError(c, http.StatusNotFound, fmt.Sprintf("File %s belongs to credential %s, which is no longer available", id, authID))
+		return
+	}
+	resp, err := h.forward(c, auth, method, "/v1/files/"+url.PathEscape(id)+suffix, "", nil, "", true)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	if raw {
+		relayStream(c, resp)
+		return
+	}
+	h.relayJSON(c, resp, func(body []byte) []byte {
+		if method == http.MethodDelete {
+			h.routes.RemoveFile(id)
+		}
+		return body
+	})
+}
+
+// executeBatchRequest runs one emulated batch entry through the auth manager.
+func (h *ClaudeBatchAPIHandler) executeBatchRequest(ctx context.Context, owner string, params []byte) ([]byte, int, error) {
+	if authID := pinnedFileAuth(h.routes, owner, gjson.ParseBytes(params)); authID != "" {
+		ctx = handlers.WithPinnedAuthID(ctx, authID)
+	}
+	model := gjson.GetBytes(params, "model").String()
+	resp, _, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), model, params, "")
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		err := errMsg.Error
+		if err == nil {
+			err = errors.New(http.StatusText(status))
+		}
+		return nil, status, err
+	}
+	return decompressClaudeResponse(resp), http.StatusOK, nil
+}
+
+// selectBatchAuth returns an Anthropic API-key credential available to tenant that can
+// serve every model in the batch, preferring the credential holding any file owner
+// referenced. It returns nil when the batch must be emulated.
+func (h *ClaudeBatchAPIHandler) selectBatchAuth(owner, tenant string, models map[string]struct{}, fileIDs []string) *coreauth.Auth {
+	auths := h.anthropicAPIKeyAuths(tenant)
+	if len(auths) == 0 || len(models) == 0 {
+		return nil
+	}
+	for _, fileID := range fileIDs {
+		if authID, ok := h.routes.FileAuth(fileID, owner); ok {
+			for _, auth := range auths {
+				if auth.ID == authID && supportsAll(auth.ID, models) {
+					return auth
+				}
+			}
+			return nil
+		}
+	}
+	candidates := make([]*coreauth.Auth, 0, len(auths))
+	for _, auth := range auths {
+		if supportsAll(auth.ID, models) {
+			candidates = append(candidates, auth)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[int(h.next.Add(1)-1)%len(candidates)]
+}
+
+func supportsAll(authID string, models map[string]struct{}) bool {
+	reg := registry.GetGlobalRegistry()
+	for model := range models {
+		if model == "" || !reg.ClientSupportsModel(authID, model) {
+			return false
+		}
+	}
+	return true
+}
+
+// anthropicAPIKeyAuths lists active Claude credentials available to tenant that use an
+// API key against Anthropic itself.
+func (h *ClaudeBatchAPIHandler) anthropicAPIKeyAuths(tenant string) []*coreauth.Auth {
+	if h.AuthManager == nil {
+		return nil
+	}
+	out := make([]*coreauth.Auth, 0)
+	for _, auth := range h.AuthManager.List() {
+		if isAnthropicAPIKeyAuth(auth) && auth.AvailableToTenant(tenant) {
+			out = append(out, auth)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func isAnthropicAPIKeyAuth(auth *coreauth.Auth) bool {
+	if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled || !strings.EqualFold(auth.Provider, "claude") {
+		return false
+	}
+	if auth.Attributes == nil || strings.TrimSpace(auth.Attributes["api_key"]) == "" {
+		return false
+	}
+	base := strings.TrimSpace(auth.Attributes["base_url"])
+	if base == "" {
+		return true
+	}
+	parsed, err := url.Parse(base)
+	return err == nil && strings.EqualFold(parsed.Host, "api.anthropic.com")
+}
+
+func (h *ClaudeBatchAPIHandler) pinnedBatchAuth(c *gin.Context, id string) (*coreauth.Auth, bool) {
+	authID, ok := h.routes.BatchAuth(id, requestOwner(c))
+	if !ok {
+		writeClaudeError(c, http.StatusNotFound, fmt.Sprintf("Batch not found: %s", id))
+		return nil, false
+	}
+	auth, ok := h.AuthManager.GetByID(authID)
+	if !ok {
+		writeClaudeError(c, http.StatusNotFound, fmt.Sprintf("Batch %s belongs to credential %s, which is no longer available", id, authID))
+		return nil, false
+	}
+	return auth, true
+}
+
+// forward sends a request to the Anthropic API with the credential injected by the executor.
+func (h *ClaudeBatchAPIHandler) forward(c *gin.Context, auth *coreauth.Auth, method, path, rawQuery string, body []byte, contentType string, files bool) (*http.Response, error) {
+	base := strings.TrimSpace(auth.Attributes["base_url"])
+	if base == "" {
+		base = anthropicBaseURL
+	}
+	target := strings.TrimRight(base, "/") + path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	headers := http.Header{}
+	version := strings.TrimSpace(c.GetHeader("anthropic-version"))
+	if version == "" {
+		version = defaultAnthropicVersion
+	}
+	headers.Set("anthropic-version", version)
+	beta := strings.TrimSpace(c.GetHeader("anthropic-beta"))
+	if files && !strings.Contains(beta, "files-api") {
+		if beta != "" {
+			beta += ","
+		}
+		beta += filesAPIBeta
+	}
+	if beta != "" {
+		headers.Set("anthropic-beta", beta)
+	}
+	if contentType != "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	ctx := c.Request.Context()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	return h.AuthManager.HttpRequest(ctx, auth, req)
+}
+
+// listUpstream fetches the first page of an upstream list endpoint for authID.
+func (h *ClaudeBatchAPIHandler) listUpstream(c *gin.Context, authID, path string, files bool) []gjson.Result {
+	auth, ok := h.AuthManager.GetByID(authID)
+	if !ok {
+		return nil
+	}
+	resp, err := h.forward(c, auth, http.MethodGet, path, "limit="+strconv.Itoa(maxListLimit), nil, "", files)
+	if err != nil {
+		log.Warnf("claude batch: list %s for %s failed: %v", path, authID, err)
+		return nil
+	}
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Warnf("claude batch: list %s for %s returned status %d", path, authID, resp.StatusCode)
+		return nil
+	}
+	return gjson.GetBytes(body, "data").Array()
+}
+
+// relayJSON copies an upstream JSON response, applying onSuccess to 2xx bodies.
+func (h *ClaudeBatchAPIHandler) relayJSON(c *gin.Context, resp *http.Response, onSuccess func([]byte) []byte) {
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && onSuccess != nil {
+		body = onSuccess(body)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(resp.StatusCode, contentType, body)
+}
+
+func relayStream(c *gin.Context, resp *http.Response) {
+	defer closeBody(resp)
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Disposition"} {
+		if value := resp.Header.Get(key); value != "" {
+			c.Header(key, value)
+		}
+	}
+	c.Status(resp.StatusCode)
+	if _, err := io.Copy(c.Writer, resp.Body); err != nil {
+		log.Warnf("claude batch: relay body failed: %v", err)
+	}
+}
+
+func closeBody(resp *http.Response) {
+	if errClose := resp.Body.Close(); errClose != nil {
+		log.Debugf("claude batch: close body error: %v", errClose)
+	}
+}
+
+// rewriteResultsURL points results_url at this proxy so clients fetch results with their proxy key.
+func (h *ClaudeBatchAPIHandler) rewriteResultsURL(c *gin.Context, body []byte) []byte {
+	id := gjson.GetBytes(body, "id").String()
+	if id == "" || gjson.GetBytes(body, "results_url").Type != gjson.String {
+		return body
+	}
+	updated, err := sjson.SetBytes(body, "results_url", resultsURL(c, id))
+	if err != nil {
+		return body
+	}
+	return updated
+}
+
+func resultsURL(c *gin.Context, id string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); forwarded != "" {
+		scheme = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host := c.Request.Host
+	if forwardedHost := strings.TrimSpace(c.GetHeader("X-Forwarded-Host")); forwardedHost != "" {
+		host = forwardedHost
+	}
+	return fmt.Sprintf("%s://%s/v1/messages/batches/%s/results", scheme, host, url.PathEscape(id))
+}
+
+// writeListPage renders entries (newest first) as an Anthropic list page honoring
+// limit, before_id and after_id.
+func writeListPage(c *gin.Context, entries []gjson.Result) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Get("created_at").Time().After(entries[j].Get("created_at").Time())
+	})
+	limit := defaultListLimit
+	if raw := c.Query("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = min(parsed, maxListLimit)
+		}
+	}
+	start, end := 0, len(entries)
+	if afterID := c.Query("after_id"); afterID != "" {
+		for i, entry := range entries {
+			if entry.Get("id").String() == afterID {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if beforeID := c.Query("before_id"); beforeID != "" {
+		for i, entry := range entries {
+			if entry.Get("id").String() == beforeID {
+				end = i
+				break
+			}
+		}
+		start = max(start, end-limit)
+	}
+	if start > end {
+		start = end
+	}
+	hasMore := end-start > limit
+	if hasMore {
+		end = start + limit
+	}
+
+	out := []byte(`{"data":[],"has_more":false,"first_id":null,"last_id":null}`)
+	for _, entry := range entries[start:end] {
+		out, _ = sjson.SetRawBytes(out, "data.-1", []byte(entry.Raw))
+	}
+	out, _ = sjson.SetBytes(out, "has_more", hasMore)
+	if end > start {
+		out, _ = sjson.SetBytes(out, "first_id", entries[start].Get("id").String())
+		out, _ = sjson.SetBytes(out, "last_id", entries[end-1].Get("id").String())
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+func writeClaudeError(c *gin.Context, status int, message string) {
+	body := []byte(`{"type":"error","error":{"type":"","message":""}}`)
+	body, _ = sjson.SetBytes(body, "error.type", claudebatch.ErrorType(status))
+	body, _ = sjson.SetBytes(body, "error.message", message)
+	c.Data(status, "application/json", body)
+}
+
+// referencedFileIDs collects Files API IDs used by document and image blocks.
+func referencedFileIDs(params gjson.Result) []string {
+	var ids []string
+	params.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		msg.Get("content").ForEach(func(_, block gjson.Result) bool {
+			if block.Get("source.type").String() == "file" {
+				if id := block.Get("source.file_id").String(); id != "" {
+					ids = append(ids, id)
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return ids
+}
+
+// pinnedFileAuth returns the credential holding the first file referenced by params
+// that owner uploaded.
+func pinnedFileAuth(routes *claudebatch.RouteTable, owner string, params gjson.Result) string {
+	for _, id := range referencedFileIDs(params) {
+		if authID, ok := routes.FileAuth(id, owner); ok {
+			return authID
+		}
+	}
+	return ""
+}
+
+// requestOwner identifies the client behind a request: its tenant when the access
+// provider attached one, otherwise a digest of its API key principal. It is empty
+// when client authentication is disabled.
+func requestOwner(c *gin.Context) string {
+	if tenant := requestTenant(c); tenant != "" {
+		return "tenant:" + tenant
+	}
+	principal := strings.TrimSpace(c.GetString("apiKey"))
+	if principal == "" {
+		return ""
+	}
+	if !config.IsHashedAPIKey(principal) {
+		principal = config.HashAPIKey(principal)
+	}
+	return "key:" + principal
+}
+
+// requestTenant returns the tenant the access provider attached to the client, if any.
+func requestTenant(c *gin.Context) string {
+	raw, ok := c.Get("accessMetadata")
+	if !ok {
+		return ""
+	}
+	metadata, ok := raw.(map[string]string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(metadata[sdkaccess.MetadataTenant])
+}
@@ -0,0 +1,338 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/claudebatch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type batchTestExecutor struct {
+	provider string
+	upstream *httptest.Server
+
+	mu       sync.Mutex
+	authKeys []string
+	callers  []string
+}
+
+func (e *batchTestExecutor) Identifier() string { return e.provider }
+
+func (e *batchTestExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok {
+		e.mu.Lock()
+		e.callers = append(e.callers, ginCtx.GetString("apiKey"))
+		e.mu.Unlock()
+	}
+	if strings.Contains(string(req.Payload), "fail") {
+		return coreexecutor.Response{}, errors.New("boom")
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"ok"}]}`)}, nil
+}
+
+func (e *batchTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *batchTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *batchTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+// HttpRequest redirects Anthropic calls to the fake upstream and records the key used.
+func (e *batchTestExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	e.mu.Lock()
+	e.authKeys = append(e.authKeys, auth.Attributes["api_key"])
+	e.mu.Unlock()
+	target := e.upstream.URL + req.URL.Path
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	out, err := http.NewRequestWithContext(ctx, req.Method, target, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	out.Header = req.Header.Clone()
+	out.Header.Set("x-api-key", auth.Attributes["api_key"])
+	return http.DefaultClient.Do(out)
+}
+
+func newBatchTestRouter(t *testing.T, executor *batchTestExecutor, auths ...*coreauth.Auth) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register auth: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "batch-test-model"}})
+		authID := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+	}
+
+	h := NewClaudeBatchAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager), claudebatch.NewRouteTable(""))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("x-api-key"); key != "" {
+			c.Set("apiKey", key)
+		}
+		if models := c.GetHeader("x-allowed-models"); models != "" {
+			c.Set("accessMetadata", map[string]string{sdkaccess.MetadataAllowedModels: models})
+		}
+	})
+	router.POST("/v1/messages/batches", h.CreateBatch)
+	router.GET("/v1/messages/batches", h.ListBatches)
+	router.GET("/v1/messages/batches/:id", h.GetBatch)
+	router.GET("/v1/messages/batches/:id/results", h.BatchResults)
+	router.POST("/v1/files", h.UploadFile)
+	router.GET("/v1/files/:id", h.GetFile)
+	return router
+}
+
+func doBatchRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	return doBatchRequestAs(router, "", method, path, body)
+}
+
+func doBatchRequestAs(router *gin.Engine, clientKey, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if clientKey != "" {
+		req.Header.Set("x-api-key", clientKey)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestEmulatedBatchReturnsAnthropicResults(t *testing.T) {
+	executor := &batchTestExecutor{provider: "batch-test-provider"}
+	router := newBatchTestRouter(t, executor, &coreauth.Auth{ID: "batch-emulated", Provider: executor.provider, Status: coreauth.StatusActive})
+
+	body := `{"requests":[
+		{"custom_id":"a","params":{"model":"batch-test-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}},
+		{"custom_id":"b","params":{"model":"batch-test-model","max_tokens":16,"messages":[{"role":"user","content":"fail"}]}}]}`
+	resp := doBatchRequest(router, http.MethodPost, "/v1/messages/batches", body)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	if !strings.HasPrefix(id, "msgbatch_") || gjson.Get(resp.Body.String(), "type").String() != "message_batch" {
+		t.Fatalf("unexpected batch: %s", resp.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp = doBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id, "")
+		if gjson.Get(resp.Body.String(), "processing_status").String() == claudebatch.StatusEnded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not finish: %s", resp.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	batch := gjson.Parse(resp.Body.String())
+	if batch.Get("request_counts.succeeded").Int() != 1 || batch.Get("request_counts.errored").Int() != 1 {
+		t.Fatalf("unexpected counts: %s", batch.Get("request_counts").Raw)
+	}
+	if !strings.HasSuffix(batch.Get("results_url").String(), "/v1/messages/batches/"+id+"/results") {
+		t.Fatalf("results_url = %q", batch.Get("results_url").String())
+	}
+
+	resp = doBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id+"/results", "")
+	scanner := bufio.NewScanner(strings.NewReader(resp.Body.String()))
+	var lines []gjson.Result
+	for scanner.Scan() {
+		lines = append(lines, gjson.Parse(scanner.Text()))
+	}
+	if len(lines) != 2 {
+		t.Fatalf("results = %q", resp.Body.String())
+	}
+	if lines[0].Get("custom_id").String() != "a" || lines[0].Get("result.type").String() != "succeeded" || lines[0].Get("result.message.content.0.text").String() != "ok" {
+		t.Fatalf("unexpected first result: %s", lines[0].Raw)
+	}
+	if lines[1].Get("result.type").String() != "errored" || lines[1].Get("result.error.error.message").String() != "boom" {
+		t.Fatalf("unexpected second result: %s", lines[1].Raw)
+	}
+}
+
+func TestPassthroughBatchIsPinnedToCreatingKey(t *testing.T) {
+	var mu sync.Mutex
+	owner := map[string]string{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("x-api-key")
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+			owner["msgbatch_up"] = key
+			_, _ = w.Write([]byte(`{"id":"msgbatch_up","type":"message_batch","processing_status":"in_progress","created_at":"2026-01-01T00:00:00Z","results_url":null}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_up":
+			if owner["msgbatch_up"] != key {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"type":"error","error":{"type":"not_found_error","message":"wrong key"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"msgbatch_up","type":"message_batch","processing_status":"ended","created_at":"2026-01-01T00:00:00Z","results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_up/results"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			if !strings.Contains(r.Header.Get("anthropic-beta"), "files-api") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			owner["file_up"] = key
+			_, _ = w.Write([]byte(`{"id":"file_up","type":"file","filename":"a.pdf"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file_up":
+			if owner["file_up"] != key {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"file_up","type":"file"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	executor := &batchTestExecutor{provider: "claude", upstream: upstream}
+	router := newBatchTestRouter(t, executor,
+		&coreauth.Auth{ID: "batch-key-1", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "sk-one"}},
+		&coreauth.Auth{ID: "batch-key-2", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "sk-two"}},
+	)
+
+	body := `{"requests":[{"custom_id":"a","params":{"model":"batch-test-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}}]}`
+	resp := doBatchRequest(router, http.MethodPost, "/v1/messages/batches", body)
+	if resp.Code != http.StatusOK || gjson.Get(resp.Body.String(), "id").String() != "msgbatch_up" {
+		t.Fatalf("create status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	// Poll several times; every poll must use the creating key even though selection rotates.
+	for i := 0; i < 3; i++ {
+		resp = doBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_up", "")
+		if resp.Code != http.StatusOK {
+			t.Fatalf("poll %d status = %d, body = %s", i, resp.Code, resp.Body.String())
+		}
+		if got := gjson.Get(resp.Body.String(), "results_url").String(); got != "http://example.com/v1/messages/batches/msgbatch_up/results" {
+			t.Fatalf("results_url = %q", got)
+		}
+	}
+
+	resp = doBatchRequest(router, http.MethodPost, "/v1/files", "file-bytes")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	for i := 0; i < 3; i++ {
+		if resp = doBatchRequest(router, http.MethodGet, "/v1/files/file_up", ""); resp.Code != http.StatusOK {
+			t.Fatalf("file get %d status = %d", i, resp.Code)
+		}
+	}
+
+	if resp = doBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_unknown", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("unknown batch status = %d", resp.Code)
+	}
+}
+
+func TestBatchesAreScopedToCreatingClient(t *testing.T) {
+	executor := &batchTestExecutor{provider: "batch-test-provider"}
+	router := newBatchTestRouter(t, executor, &coreauth.Auth{ID: "batch-scoped", Provider: executor.provider, Status: coreauth.StatusActive})
+
+	body := `{"requests":[{"custom_id":"a","params":{"model":"batch-test-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}}]}`
+	resp := doBatchRequestAs(router, "client-a", http.MethodPost, "/v1/messages/batches", body)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+
+	if resp = doBatchRequestAs(router, "client-b", http.MethodGet, "/v1/messages/batches/"+id, ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("other client get status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp = doBatchRequestAs(router, "client-b", http.MethodGet, "/v1/messages/batches/"+id+"/results", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("other client results status = %d", resp.Code)
+	}
+	if resp = doBatchRequestAs(router, "client-b", http.MethodGet, "/v1/messages/batches", ""); len(gjson.Get(resp.Body.String(), "data").Array()) != 0 {
+		t.Fatalf("other client list = %s", resp.Body.String())
+	}
+	if resp = doBatchRequestAs(router, "client-a", http.MethodGet, "/v1/messages/batches", ""); gjson.Get(resp.Body.String(), "data.0.id").String() != id {
+		t.Fatalf("owner list = %s", resp.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for gjson.Get(doBatchRequestAs(router, "client-a", http.MethodGet, "/v1/messages/batches/"+id, "").Body.String(), "processing_status").String() != claudebatch.StatusEnded {
+		if time.Now().After(deadline) {
+			t.Fatal("batch did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.callers) != 1 || executor.callers[0] != "client-a" {
+		t.Fatalf("batch entries ran as %v, want the creating client", executor.callers)
+	}
+}
+
+func TestBatchRejectsModelsOutsideClientAllowList(t *testing.T) {
+	executor := &batchTestExecutor{provider: "batch-test-provider"}
+	router := newBatchTestRouter(t, executor, &coreauth.Auth{ID: "batch-restricted", Provider: executor.provider, Status: coreauth.StatusActive})
+
+	body := `{"requests":[{"custom_id":"a","params":{"model":"batch-test-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages/batches", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", "restricted-client")
+	req.Header.Set("x-allowed-models", "other-model-*")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("create status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if got := gjson.Get(resp.Body.String(), "error.type").String(); got != "permission_error" {
+		t.Fatalf("error type = %q", got)
+	}
+	if resp = doBatchRequestAs(router, "restricted-client", http.MethodGet, "/v1/messages/batches", ""); len(gjson.Get(resp.Body.String(), "data").Array()) != 0 {
+		t.Fatalf("rejected batch was queued: %s", resp.Body.String())
+	}
+}
+
+func TestAnthropicAPIKeyAuthsHonorTenants(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "tenant-shared", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "sk-shared"}},
+		{ID: "tenant-a-key", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "sk-a", "tenant": "a"}},
+		{ID: "tenant-b-key", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "sk-b", "tenant": "b"}},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register auth: %v", err)
+		}
+	}
+	h := NewClaudeBatchAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager), claudebatch.NewRouteTable(""))
+
+	ids := func(tenant string) string {
+		var out []string
+		for _, auth := range h.anthropicAPIKeyAuths(tenant) {
+			out = append(out, auth.ID)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := ids("a"); got != "tenant-a-key,tenant-shared" {
+		t.Fatalf("tenant a auths = %s", got)
+	}
+	if got := ids(""); got != "tenant-shared" {
+		t.Fatalf("untenanted auths = %s", got)
+	}
+}
@@ -16,8 +16,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/remotemedia"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scripting"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -56,6 +59,13 @@ type pinnedAuthContextKey struct{}
 type selectedAuthCallbackContextKey struct{}
 type executionSessionContextKey struct{}
 
+// DetachedContext returns a context for work that outlives the request, such as queued
+// batch entries. It carries a copy of c so execution still sees the client's identity,
+// tenant and model allow-list, but it is not canceled when the request ends.
+func DetachedContext(c *gin.Context) context.Context {
+	return context.WithValue(context.Background(), "gin", c.Copy())
+}
+
 // WithPinnedAuthID returns a child context that requests execution on a specific auth ID.
 func WithPinnedAuthID(ctx context.Context, authID string) context.Context {
 	authID = strings.TrimSpace(authID)
@@ -208,9 +218,32 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if executionSessionID := executionSessionIDFromContext(ctx); executionSessionID != "" {
 		meta[coreexecutor.ExecutionSessionMetadataKey] = executionSessionID
 	}
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		meta[coreexecutor.TenantMetadataKey] = tenant
+	}
 	return meta
 }
 
+// tenantFromContext returns the tenant the access provider attached to the client, if any.
+func tenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	raw, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return ""
+	}
+	metadata, ok := raw.(map[string]string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(metadata[sdkaccess.MetadataTenant])
+}
+
 func pinnedAuthIDFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -261,6 +294,12 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// mediaFetcher inlines remote image/file URLs for backends that need base64 data.
+	mediaFetcher *remotemedia.Fetcher
+
+	// scripts runs the configured Lua hooks on requests, responses and stream chunks.
+	scripts *scripting.Engine
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -273,10 +312,15 @@ type BaseAPIHandler struct {
 // Returns:
 //   - *BaseAPIHandler: A new API handlers instance
 func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *BaseAPIHandler {
-	return &BaseAPIHandler{
+	h := &BaseAPIHandler{
 		Cfg:         cfg,
 		AuthManager: authManager,
 	}
+	if cfg != nil {
+		h.mediaFetcher = remotemedia.NewFetcher(cfg.RemoteMedia, cfg.ProxyURL)
+		h.scripts = scripting.NewEngine(cfg.Scripting)
+	}
+	return h
 }
 
 // UpdateClients updates the handlers' client list and configuration.
@@ -285,7 +329,22 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *B
 // Parameters:
 //   - clients: The new slice of AI service clients
 //   - cfg: The new application configuration
-func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) { h.Cfg = cfg }
+func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) {
+	h.Cfg = cfg
+	if cfg == nil {
+		return
+	}
+	if h.scripts == nil {
+		h.scripts = scripting.NewEngine(cfg.Scripting)
+	} else {
+		h.scripts.Update(cfg.Scripting)
+	}
+	if h.mediaFetcher == nil {
+		h.mediaFetcher = remotemedia.NewFetcher(cfg.RemoteMedia, cfg.ProxyURL)
+		return
+	}
+	h.mediaFetcher.Update(cfg.RemoteMedia, cfg.ProxyURL)
+}
 
 // GetAlt extracts the 'alt' parameter from the request query string.
 // It checks both 'alt' and '$alt' parameters and returns the appropriate value.
@@ -468,7 +527,18 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	modelName, rawJSON, errMsg := h.runRequestScripts(ctx, handlerType, modelName, rawJSON, false)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		errMsg = checkModelAccess(ctx, normalizedModel)
+	}
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON, errMsg = h.inlineRemoteMedia(ctx, handlerType, providers, rawJSON)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
@@ -505,16 +575,31 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	payload, errMsg = h.runResponseScripts(ctx, handlerType, modelName, resp.Payload)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	if !PassthroughHeadersEnabled(h.Cfg) {
-		return resp.Payload, nil, nil
+		return payload, nil, nil
 	}
-	return resp.Payload, FilterUpstreamHeaders(resp.Headers), nil
+	return payload, FilterUpstreamHeaders(resp.Headers), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	modelName, rawJSON, errMsg := h.runRequestScripts(ctx, handlerType, modelName, rawJSON, false)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		errMsg = checkModelAccess(ctx, normalizedModel)
+	}
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON, errMsg = h.inlineRemoteMedia(ctx, handlerType, providers, rawJSON)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
@@ -561,7 +646,24 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	modelName, rawJSON, errMsg := h.runRequestScripts(ctx, handlerType, modelName, rawJSON, true)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		errMsg = checkModelAccess(ctx, normalizedModel)
+	}
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	rawJSON, errMsg = h.inlineRemoteMedia(ctx, handlerType, providers, rawJSON)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -615,6 +717,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		}
 	}
 	chunks := streamResult.Chunks
+	chunkCtx := scriptContext(ctx)
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
@@ -716,16 +819,26 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					return
 				}
 				if len(chunk.Payload) > 0 {
-					if handlerType == "openai-response" {
-						if err := validateSSEDataJSON(chunk.Payload); err != nil {
-							_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: err})
+					payloads, errScript := h.runChunkScripts(chunkCtx, handlerType, modelName, chunk.Payload)
+					if errScript != nil {
+						_ = sendErr(errScript)
+						return
+					}
+					for _, payload := range payloads {
+						if len(payload) == 0 {
+							continue
+						}
+						if handlerType == "openai-response" {
+							if err := validateSSEDataJSON(payload); err != nil {
+								_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: err})
+								return
+							}
+						}
+						sentPayload = true
+						if okSendData := sendData(cloneBytes(payload)); !okSendData {
 							return
 						}
 					}
-					sentPayload = true
-					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
-						return
-					}
 				}
 			}
 		}
@@ -810,6 +923,50 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	return providers, resolvedModelName, nil
 }
 
+// checkModelAccess rejects models outside the allow-list the access provider attached
+// to the authenticated client (see sdkaccess.MetadataAllowedModels).
+func checkModelAccess(ctx context.Context, model string) *interfaces.ErrorMessage {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	raw, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return nil
+	}
+	metadata, ok := raw.(map[string]string)
+	if !ok || sdkaccess.ModelAllowed(metadata, model) {
+		return nil
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("model %s is not allowed for this client", model)}
+}
+
+// CheckModelAccess applies the client's model allow-list to model ahead of execution,
+// resolving auto models the same way execution does.
+func (h *BaseAPIHandler) CheckModelAccess(c *gin.Context, model string) *interfaces.ErrorMessage {
+	if _, normalized, errMsg := h.getRequestDetails(model); errMsg == nil {
+		model = normalized
+	}
+	return checkModelAccess(context.WithValue(context.Background(), "gin", c), model)
+}
+
+// inlineRemoteMedia downloads remote image/file URLs in the request when remote media
+// fetching is enabled and at least one candidate provider cannot fetch URLs itself.
+func (h *BaseAPIHandler) inlineRemoteMedia(ctx context.Context, handlerType string, providers []string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if h.mediaFetcher == nil || !h.mediaFetcher.Enabled() || !remotemedia.NeedsInline(providers) {
+		return rawJSON, nil
+	}
+	updated, err := h.mediaFetcher.InlineRemoteURLs(ctx, handlerType, rawJSON)
+	if err != nil {
+		status := http.StatusBadRequest
+		if se, ok := err.(interface{ StatusCode() int }); ok && se.StatusCode() > 0 {
+			status = se.StatusCode()
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err}
+	}
+	return updated, nil
+}
+
 func cloneBytes(src []byte) []byte {
 	if len(src) == 0 {
 		return nil
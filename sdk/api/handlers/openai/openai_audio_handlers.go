@@ -0,0 +1,549 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxAudioUploadBytes mirrors the 25 MB upload limit of the OpenAI audio API.
+	maxAudioUploadBytes = 25 << 20
+	// ttsSampleRate is the PCM sample rate returned by Gemini TTS models.
+	ttsSampleRate = 24000
+)
+
+// geminiFamilyProviders lists executors that accept Gemini inline audio parts.
+var geminiFamilyProviders = map[string]struct{}{
+	"gemini":      {},
+	"gemini-cli":  {},
+	"vertex":      {},
+	"aistudio":    {},
+	"antigravity": {},
+}
+
+// openAIVoiceMapping maps OpenAI voice names onto Gemini prebuilt voices.
+// Unknown voices are passed through so Gemini voice names can be used directly.
+var openAIVoiceMapping = map[string]string{
+	"alloy":   "Kore",
+	"ash":     "Orus",
+	"ballad":  "Umbriel",
+	"coral":   "Aoede",
+	"echo":    "Puck",
+	"fable":   "Fenrir",
+	"nova":    "Leda",
+	"onyx":    "Charon",
+	"sage":    "Sulafat",
+	"shimmer": "Zephyr",
+	"verse":   "Achird",
+}
+
+// OpenAIAudioAPIHandler serves the OpenAI Audio API (/v1/audio/*) using
+// Gemini-family models for transcription, translation and text-to-speech.
+type OpenAIAudioAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// transcriptSegment is one timed span of a transcription.
+type transcriptSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// transcriptResult is the structured output requested from Gemini.
+type transcriptResult struct {
+	Language string              `json:"language"`
+	Segments []transcriptSegment `json:"segments"`
+}
+
+// NewOpenAIAudioAPIHandler creates a new OpenAI Audio API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OpenAIAudioAPIHandler: A new OpenAI Audio API handlers instance
+func NewOpenAIAudioAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIAudioAPIHandler {
+	return &OpenAIAudioAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+// Audio requests are built in Gemini format before execution.
+func (h *OpenAIAudioAPIHandler) HandlerType() string {
+	return Gemini
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIAudioAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels("openai")
+}
+
+// Transcriptions handles the /v1/audio/transcriptions endpoint.
+func (h *OpenAIAudioAPIHandler) Transcriptions(c *gin.Context) {
+	h.handleAudioToText(c, false)
+}
+
+// Translations handles the /v1/audio/translations endpoint.
+// The audio is transcribed and translated into English in a single call.
+func (h *OpenAIAudioAPIHandler) Translations(c *gin.Context) {
+	h.handleAudioToText(c, true)
+}
+
+func (h *OpenAIAudioAPIHandler) handleAudioToText(c *gin.Context, translate bool) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid multipart request: %v", err))
+		return
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		writeOpenAIError(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	audio, mimeType, err := readAudioUpload(files[0])
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	responseFormat := strings.TrimSpace(formValue(form, "response_format"))
+	switch responseFormat {
+	case "":
+		responseFormat = "json"
+	case "json", "text", "srt", "vtt", "verbose_json":
+	default:
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("unsupported response_format %q", responseFormat))
+		return
+	}
+
+	modelName, errModel := resolveAudioModel(strings.TrimSpace(formValue(form, "model")), isTranscriptionModel)
+	if errModel != nil {
+		writeOpenAIError(c, http.StatusBadRequest, errModel.Error())
+		return
+	}
+
+	prompt := buildTranscriptionPrompt(translate, strings.TrimSpace(formValue(form, "language")), strings.TrimSpace(formValue(form, "prompt")))
+	geminiReq := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseMimeType":"application/json"}}`)
+	geminiReq, _ = sjson.SetBytes(geminiReq, "contents.0.parts.0.inlineData.mimeType", mimeType)
+	geminiReq, _ = sjson.SetBytes(geminiReq, "contents.0.parts.0.inlineData.data", base64.StdEncoding.EncodeToString(audio))
+	geminiReq, _ = sjson.SetBytes(geminiReq, "contents.0.parts.1.text", prompt)
+	geminiReq, _ = sjson.SetRawBytes(geminiReq, "generationConfig.responseSchema", []byte(transcriptionResponseSchema))
+	if temperature := strings.TrimSpace(formValue(form, "temperature")); temperature != "" {
+		if value, errParse := strconv.ParseFloat(temperature, 64); errParse == nil {
+			geminiReq, _ = sjson.SetBytes(geminiReq, "generationConfig.temperature", value)
+		}
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, geminiReq, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	result, errParse := parseTranscriptResponse(resp)
+	if errParse != nil {
+		h.WriteErrorResponse(c, errParse)
+		cliCancel(errParse.Error)
+		return
+	}
+	if translate {
+		result.Language = "english"
+	}
+
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	contentType, body := renderTranscript(result, responseFormat, translate)
+	c.Data(http.StatusOK, contentType, body)
+	cliCancel()
+}
+
+// Speech handles the /v1/audio/speech endpoint using Gemini TTS models.
+// Gemini returns raw 24 kHz PCM, which is served as WAV or PCM.
+func (h *OpenAIAudioAPIHandler) Speech(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		writeOpenAIError(c, http.StatusBadRequest, "Invalid request: body must be valid JSON")
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	input := root.Get("input").String()
+	if strings.TrimSpace(input) == "" {
+		writeOpenAIError(c, http.StatusBadRequest, "input is required")
+		return
+	}
+
+	format := strings.TrimSpace(root.Get("response_format").String())
+	switch format {
+	case "":
+		format = "wav"
+	case "wav", "pcm":
+	default:
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("response_format %q is not supported by Gemini TTS; use wav or pcm", format))
+		return
+	}
+
+	modelName, errModel := resolveAudioModel(strings.TrimSpace(root.Get("model").String()), isSpeechModel)
+	if errModel != nil {
+		writeOpenAIError(c, http.StatusBadRequest, errModel.Error())
+		return
+	}
+
+	text := input
+	instructions := strings.TrimSpace(root.Get("instructions").String())
+	if speed := root.Get("speed").Float(); speed > 0 && speed != 1 {
+		pace := "faster"
+		if speed < 1 {
+			pace = "slower"
+		}
+		instructions = strings.TrimSpace(fmt.Sprintf("%s Speak at about %.2fx normal speed (%s than usual).", instructions, speed, pace))
+	}
+	if instructions != "" {
+		text = fmt.Sprintf("%s\n\n%s", instructions, input)
+	}
+
+	voice := strings.TrimSpace(root.Get("voice").String())
+	if mapped, ok := openAIVoiceMapping[strings.ToLower(voice)]; ok {
+		voice = mapped
+	}
+	if voice == "" {
+		voice = openAIVoiceMapping["alloy"]
+	}
+
+	geminiReq := []byte(`{"contents":[{"role":"user","parts":[{"text":""}]}],"generationConfig":{"responseModalities":["AUDIO"]}}`)
+	geminiReq, _ = sjson.SetBytes(geminiReq, "contents.0.parts.0.text", text)
+	geminiReq, _ = sjson.SetBytes(geminiReq, "generationConfig.speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName", voice)
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, geminiReq, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	pcm, errAudio := extractSpeechAudio(resp)
+	if errAudio != nil {
+		h.WriteErrorResponse(c, errAudio)
+		cliCancel(errAudio.Error)
+		return
+	}
+
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	if format == "pcm" {
+		c.Data(http.StatusOK, "audio/pcm", pcm)
+	} else {
+		c.Data(http.StatusOK, "audio/wav", wrapPCMAsWAV(pcm, ttsSampleRate))
+	}
+	cliCancel()
+}
+
+const transcriptionResponseSchema = `{"type":"OBJECT","properties":{"language":{"type":"STRING"},"segments":{"type":"ARRAY","items":{"type":"OBJECT","properties":{"start":{"type":"NUMBER"},"end":{"type":"NUMBER"},"text":{"type":"STRING"}},"required":["start","end","text"]}}},"required":["segments"]}`
+
+func buildTranscriptionPrompt(translate bool, language, hint string) string {
+	var sb strings.Builder
+	if translate {
+		sb.WriteString("Transcribe the attached audio and translate the speech into English. ")
+	} else {
+		sb.WriteString("Transcribe the attached audio verbatim in the language that is spoken. ")
+		if language != "" {
+			fmt.Fprintf(&sb, "The spoken language is %q (ISO-639-1). ", language)
+		}
+	}
+	sb.WriteString("Split the transcript into segments of at most a few sentences. ")
+	sb.WriteString("For every segment return start and end offsets in seconds from the beginning of the audio and the text. ")
+	sb.WriteString("Set language to the full English name of the spoken language in lower case. ")
+	sb.WriteString("Do not add commentary, speaker labels or descriptions of non-speech sounds.")
+	if hint != "" {
+		fmt.Fprintf(&sb, "\n\nContext and spelling hints from the user: %s", hint)
+	}
+	return sb.String()
+}
+
+// parseTranscriptResponse extracts the structured transcript from a Gemini response.
+// Models that ignore the schema still produce a single plain-text segment.
+func parseTranscriptResponse(resp []byte) (transcriptResult, *interfaces.ErrorMessage) {
+	var result transcriptResult
+	text := geminiResponseText(resp)
+	if strings.TrimSpace(text) == "" {
+		if blockReason := gjson.GetBytes(resp, "promptFeedback.blockReason").String(); blockReason != "" {
+			return result, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("transcription blocked: %s", blockReason)}
+		}
+		return result, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("upstream returned an empty transcription")}
+	}
+	cleaned := strings.TrimSpace(text)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimSuffix(strings.TrimPrefix(cleaned, "```"), "```")
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &result); err != nil || len(result.Segments) == 0 {
+		result = transcriptResult{Segments: []transcriptSegment{{Text: strings.TrimSpace(text)}}}
+	}
+	for i := range result.Segments {
+		result.Segments[i].Text = strings.TrimSpace(result.Segments[i].Text)
+	}
+	return result, nil
+}
+
+func geminiResponseText(resp []byte) string {
+	root := gjson.ParseBytes(resp)
+	if root.Get("response").Exists() && !root.Get("candidates").Exists() {
+		root = root.Get("response")
+	}
+	var sb strings.Builder
+	root.Get("candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		if part.Get("thought").Bool() {
+			return true
+		}
+		sb.WriteString(part.Get("text").String())
+		return true
+	})
+	return sb.String()
+}
+
+// renderTranscript formats a transcript according to the OpenAI response_format.
+func renderTranscript(result transcriptResult, format string, translate bool) (string, []byte) {
+	texts := make([]string, 0, len(result.Segments))
+	for _, seg := range result.Segments {
+		if seg.Text != "" {
+			texts = append(texts, seg.Text)
+		}
+	}
+	fullText := strings.Join(texts, " ")
+
+	switch format {
+	case "text":
+		return "text/plain; charset=utf-8", []byte(fullText + "\n")
+	case "srt":
+		var sb strings.Builder
+		for i, seg := range result.Segments {
+			fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatSubtitleTimestamp(seg.Start, ","), formatSubtitleTimestamp(segmentEnd(seg), ","), seg.Text)
+		}
+		return "text/plain; charset=utf-8", []byte(sb.String())
+	case "vtt":
+		var sb strings.Builder
+		sb.WriteString("WEBVTT\n\n")
+		for _, seg := range result.Segments {
+			fmt.Fprintf(&sb, "%s --> %s\n%s\n\n", formatSubtitleTimestamp(seg.Start, "."), formatSubtitleTimestamp(segmentEnd(seg), "."), seg.Text)
+		}
+		return "text/vtt; charset=utf-8", []byte(sb.String())
+	case "verbose_json":
+		task := "transcribe"
+		if translate {
+			task = "translate"
+		}
+		out := []byte(`{"task":"","language":"","duration":0,"text":"","segments":[]}`)
+		out, _ = sjson.SetBytes(out, "task", task)
+		out, _ = sjson.SetBytes(out, "language", result.Language)
+		out, _ = sjson.SetBytes(out, "text", fullText)
+		duration := 0.0
+		for i, seg := range result.Segments {
+			out, _ = sjson.SetBytes(out, fmt.Sprintf("segments.%d.id", i), i)
+			out, _ = sjson.SetBytes(out, fmt.Sprintf("segments.%d.start", i), seg.Start)
+			out, _ = sjson.SetBytes(out, fmt.Sprintf("segments.%d.end", i), segmentEnd(seg))
+			out, _ = sjson.SetBytes(out, fmt.Sprintf("segments.%d.text", i), seg.Text)
+			duration = max(duration, segmentEnd(seg))
+		}
+		out, _ = sjson.SetBytes(out, "duration", duration)
+		return "application/json", out
+	default:
+		out, _ := sjson.SetBytes([]byte(`{"text":""}`), "text", fullText)
+		return "application/json", out
+	}
+}
+
+func segmentEnd(seg transcriptSegment) float64 {
+	if seg.End < seg.Start {
+		return seg.Start
+	}
+	return seg.End
+}
+
+// formatSubtitleTimestamp renders seconds as HH:MM:SS<sep>mmm.
+func formatSubtitleTimestamp(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	totalMillis := int64(seconds*1000 + 0.5)
+	hours := totalMillis / 3_600_000
+	minutes := (totalMillis / 60_000) % 60
+	secs := (totalMillis / 1000) % 60
+	millis := totalMillis % 1000
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", hours, minutes, secs, sep, millis)
+}
+
+// extractSpeechAudio returns the decoded PCM bytes from a Gemini TTS response.
+func extractSpeechAudio(resp []byte) ([]byte, *interfaces.ErrorMessage) {
+	root := gjson.ParseBytes(resp)
+	if root.Get("response").Exists() && !root.Get("candidates").Exists() {
+		root = root.Get("response")
+	}
+	var pcm []byte
+	root.Get("candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		inline := part.Get("inlineData")
+		if !inline.Exists() {
+			inline = part.Get("inline_data")
+		}
+		if data := inline.Get("data").String(); data != "" {
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			if err == nil {
+				pcm = append(pcm, decoded...)
+			}
+		}
+		return true
+	})
+	if len(pcm) == 0 {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("upstream returned no audio")}
+	}
+	return pcm, nil
+}
+
+// wrapPCMAsWAV prefixes 16-bit mono little-endian PCM with a RIFF/WAVE header.
+func wrapPCMAsWAV(pcm []byte, sampleRate int) []byte {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	byteRate := sampleRate * channels * bitsPerSample / 8
+	var buf bytes.Buffer
+	buf.Grow(44 + len(pcm))
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(byteRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(channels*bitsPerSample/8))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
+
+// readAudioUpload loads the uploaded audio file and determines a Gemini-compatible MIME type.
+func readAudioUpload(fh *multipart.FileHeader) ([]byte, string, error) {
+	if fh.Size > maxAudioUploadBytes {
+		return nil, "", fmt.Errorf("file %s exceeds %d bytes", fh.Filename, maxAudioUploadBytes)
+	}
+	file, err := fh.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file %s: %w", fh.Filename, err)
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(io.LimitReader(file, maxAudioUploadBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file %s: %w", fh.Filename, err)
+	}
+	if len(data) > maxAudioUploadBytes {
+		return nil, "", fmt.Errorf("file %s exceeds %d bytes", fh.Filename, maxAudioUploadBytes)
+	}
+	mimeType := detectAudioMimeType(fh.Filename, fh.Header.Get("Content-Type"), data)
+	if mimeType == "" {
+		return nil, "", fmt.Errorf("file %s is not a supported audio format", fh.Filename)
+	}
+	return data, mimeType, nil
+}
+
+// detectAudioMimeType resolves the MIME type from the file extension using misc.MimeTypes,
+// then the declared Content-Type, then content sniffing. Vendor "x-" prefixes are normalized.
+func detectAudioMimeType(filename, declared string, data []byte) string {
+	candidates := make([]string, 0, 3)
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	switch ext {
+	case "mpga", "mpeg":
+		candidates = append(candidates, "audio/mpeg")
+	case "mp4":
+		candidates = append(candidates, "audio/mp4")
+	case "webm":
+		candidates = append(candidates, "audio/webm")
+	default:
+		if mimeType, ok := misc.MimeTypes[ext]; ok {
+			candidates = append(candidates, mimeType)
+		}
+	}
+	candidates = append(candidates, strings.TrimSpace(strings.Split(declared, ";")[0]))
+	candidates = append(candidates, http.DetectContentType(data))
+
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(candidate)
+		if strings.HasPrefix(candidate, "video/") {
+			candidate = "audio/" + strings.TrimPrefix(candidate, "video/")
+		}
+		if !strings.HasPrefix(candidate, "audio/") {
+			continue
+		}
+		switch candidate {
+		case "audio/x-wav", "audio/wave", "audio/vnd.wave":
+			return "audio/wav"
+		case "audio/mp3":
+			return "audio/mpeg"
+		}
+		return "audio/" + strings.TrimPrefix(strings.TrimPrefix(candidate, "audio/"), "x-")
+	}
+	return ""
+}
+
+// resolveAudioModel validates the requested model or picks a registered default.
+// OpenAI model names such as whisper-1 or tts-1 fall back to a Gemini-family model.
+func resolveAudioModel(requested string, fallback func(string) bool) (string, error) {
+	if requested != "" {
+		providers := registry.GetGlobalRegistry().GetModelProviders(requested)
+		if len(providers) > 0 {
+			for _, provider := range providers {
+				if _, ok := geminiFamilyProviders[provider]; ok {
+					return requested, nil
+				}
+			}
+			return "", fmt.Errorf("model %s does not accept Gemini audio requests", requested)
+		}
+	}
+	for _, model := range registry.GetGlobalRegistry().GetAvailableModels("openai") {
+		id, _ := model["id"].(string)
+		if id == "" || !fallback(id) {
+			continue
+		}
+		for _, provider := range registry.GetGlobalRegistry().GetModelProviders(id) {
+			if _, ok := geminiFamilyProviders[provider]; ok {
+				return id, nil
+			}
+		}
+	}
+	if requested != "" {
+		return "", fmt.Errorf("model %s is not available and no Gemini audio model is configured", requested)
+	}
+	return "", fmt.Errorf("model is required: no Gemini audio model is available")
+}
+
+func isTranscriptionModel(modelID string) bool {
+	lower := strings.ToLower(modelID)
+	return strings.HasPrefix(lower, "gemini") && !strings.Contains(lower, "-image") && !strings.Contains(lower, "-tts") && !strings.Contains(lower, "embedding")
+}
+
+func isSpeechModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "-tts")
+}
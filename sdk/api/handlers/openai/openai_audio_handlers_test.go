@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type audioCaptureExecutor struct {
+	model    string
+	payload  []byte
+	response string
+}
+
+func (e *audioCaptureExecutor) Identifier() string { return "gemini" }
+
+func (e *audioCaptureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.model = req.Model
+	e.payload = append([]byte(nil), req.Payload...)
+	return coreexecutor.Response{Payload: []byte(e.response)}, nil
+}
+
+func (e *audioCaptureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *audioCaptureExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *audioCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *audioCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newAudioTestRouter(t *testing.T, executor *audioCaptureExecutor, models ...string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "audio-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	infos := make([]*registry.ModelInfo, 0, len(models))
+	for _, model := range models {
+		infos = append(infos, &registry.ModelInfo{ID: model})
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, infos)
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIAudioAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/audio/transcriptions", h.Transcriptions)
+	router.POST("/v1/audio/speech", h.Speech)
+	return router
+}
+
+func multipartAudioRequest(t *testing.T, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "clip.mp3")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	_, _ = part.Write([]byte("ID3fake-audio"))
+	for key, value := range fields {
+		_ = writer.WriteField(key, value)
+	}
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestTranscriptionsFallsBackToGeminiModelAndRendersSRT(t *testing.T) {
+	executor := &audioCaptureExecutor{response: `{"candidates":[{"content":{"parts":[{"text":"{\"language\":\"english\",\"segments\":[{\"start\":0,\"end\":1.5,\"text\":\"Hello there.\"},{\"start\":1.5,\"end\":62.25,\"text\":\"General Kenobi.\"}]}"}]}}]}`}
+	router := newAudioTestRouter(t, executor, "gemini-audio-test")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, multipartAudioRequest(t, map[string]string{"model": "whisper-1", "response_format": "srt"}))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if executor.model != "gemini-audio-test" {
+		t.Fatalf("model = %q, want gemini-audio-test", executor.model)
+	}
+	if got := gjson.GetBytes(executor.payload, "contents.0.parts.0.inlineData.mimeType").String(); got != "audio/mpeg" {
+		t.Fatalf("mimeType = %q, want audio/mpeg", got)
+	}
+	want := "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n2\n00:00:01,500 --> 00:01:02,250\nGeneral Kenobi.\n\n"
+	if resp.Body.String() != want {
+		t.Fatalf("srt body = %q, want %q", resp.Body.String(), want)
+	}
+}
+
+func TestTranscriptionsPlainTextFallback(t *testing.T) {
+	executor := &audioCaptureExecutor{response: `{"candidates":[{"content":{"parts":[{"text":"just words"}]}}]}`}
+	router := newAudioTestRouter(t, executor, "gemini-audio-test")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, multipartAudioRequest(t, map[string]string{"model": "gemini-audio-test"}))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if got := gjson.Get(resp.Body.String(), "text").String(); got != "just words" {
+		t.Fatalf("text = %q", got)
+	}
+}
+
+func TestSpeechReturnsWAV(t *testing.T) {
+	executor := &audioCaptureExecutor{response: `{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"audio/L16;codec=pcm;rate=24000","data":"AAEAAQ=="}}]}}]}`}
+	router := newAudioTestRouter(t, executor, "gemini-speech-test-tts")
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/speech", strings.NewReader(`{"model":"tts-1","input":"hi","voice":"echo"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if got := gjson.GetBytes(executor.payload, "generationConfig.speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName").String(); got != "Puck" {
+		t.Fatalf("voice = %q, want Puck", got)
+	}
+	body := resp.Body.Bytes()
+	if len(body) != 48 || string(body[:4]) != "RIFF" || string(body[8:12]) != "WAVE" {
+		t.Fatalf("unexpected wav body (%d bytes)", len(body))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/audio/speech", strings.NewReader(`{"input":"hi","response_format":"mp3"}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("mp3 status = %d, want 400", resp.Code)
+	}
+}
+
+func TestDetectAudioMimeType(t *testing.T) {
+	cases := map[string]string{
+		"a.wav":  "audio/wav",
+		"a.flac": "audio/flac",
+		"a.m4a":  "audio/mp4",
+		"a.mpga": "audio/mpeg",
+		"a.webm": "audio/webm",
+		"a.ogg":  "audio/ogg",
+	}
+	for name, want := range cases {
+		if got := detectAudioMimeType(name, "", []byte("data")); got != want {
+			t.Fatalf("detectAudioMimeType(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := detectAudioMimeType("notes.txt", "text/plain", []byte("hello")); got != "" {
+		t.Fatalf("expected text file to be rejected, got %q", got)
+	}
+}
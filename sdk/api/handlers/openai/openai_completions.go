@@ -0,0 +1,461 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// The legacy completions endpoint runs one request per prompt and choice in the
+// openai-completions format; the translators turn each into a chat request for the chosen
+// backend and apply suffix, stop, echo and logprobs to the result. This file validates the
+// request, fans it out and merges the choices.
+
+const (
+	maxCompletionChoices   = 128
+	maxCompletionLogprobs  = 5
+	maxCompletionStops     = 4
+	completionsConcurrency = 8
+)
+
+// completionsNoLogprobProviders lists backends whose responses never carry token log probabilities.
+var completionsNoLogprobProviders = map[string]struct{}{
+	"gemini":      {},
+	"gemini-cli":  {},
+	"vertex":      {},
+	"aistudio":    {},
+	"antigravity": {},
+	"claude":      {},
+	"codex":       {},
+}
+
+// completionsRequest is a validated /v1/completions request.
+type completionsRequest struct {
+	raw          []byte
+	model        string
+	prompts      []string
+	n            int
+	logprobs     int // -1 when not requested
+	stream       bool
+	includeUsage bool
+
+	id      string
+	created int64
+}
+
+// parseCompletionsRequest validates a legacy completions request. Parameters that cannot be
+// emulated on top of chat completions are rejected with a descriptive error.
+func parseCompletionsRequest(rawJSON []byte) (*completionsRequest, error) {
+	if !gjson.ValidBytes(rawJSON) || !gjson.ParseBytes(rawJSON).IsObject() {
+		return nil, errors.New("Invalid request: body must be a JSON object")
+	}
+	root := gjson.ParseBytes(rawJSON)
+	req := &completionsRequest{
+		raw:          rawJSON,
+		model:        root.Get("model").String(),
+		n:            1,
+		logprobs:     -1,
+		stream:       root.Get("stream").Bool(),
+		includeUsage: root.Get("stream_options.include_usage").Bool(),
+		id:           newCompletionID(),
+		created:      time.Now().Unix(),
+	}
+	if req.model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	prompt := root.Get("prompt")
+	switch {
+	case !prompt.Exists() || prompt.Type == gjson.Null:
+		req.prompts = []string{""}
+	case prompt.Type == gjson.String:
+		req.prompts = []string{prompt.String()}
+	case prompt.IsArray():
+		for _, item := range prompt.Array() {
+			if item.Type != gjson.String {
+				return nil, errors.New("prompt: token arrays are not supported, send the prompt as text")
+			}
+			req.prompts = append(req.prompts, item.String())
+		}
+		if len(req.prompts) == 0 {
+			return nil, errors.New("prompt must not be an empty array")
+		}
+	default:
+		return nil, errors.New("prompt must be a string or an array of strings")
+	}
+
+	suffix := root.Get("suffix").String()
+	if n := root.Get("n"); n.Exists() && n.Type != gjson.Null {
+		if n.Type != gjson.Number || n.Int() < 1 {
+			return nil, errors.New("n must be a positive integer")
+		}
+		req.n = int(n.Int())
+	}
+	if bestOf := root.Get("best_of"); bestOf.Exists() && bestOf.Type != gjson.Null {
+		if bestOf.Type != gjson.Number || int(bestOf.Int()) < req.n {
+			return nil, errors.New("best_of must be an integer greater than or equal to n")
+		}
+		if int(bestOf.Int()) > req.n {
+			return nil, errors.New("best_of greater than n is not supported: backends do not return the sequence log probabilities needed to rank candidates")
+		}
+	}
+	if total := len(req.prompts) * req.n; total > maxCompletionChoices {
+		return nil, fmt.Errorf("too many choices requested: prompts x n = %d, maximum is %d", total, maxCompletionChoices)
+	}
+
+	echo := root.Get("echo").Bool()
+	if logprobs := root.Get("logprobs"); logprobs.Exists() && logprobs.Type != gjson.Null {
+		if logprobs.Type != gjson.Number || logprobs.Int() < 0 || logprobs.Int() > maxCompletionLogprobs {
+			return nil, fmt.Errorf("logprobs must be an integer between 0 and %d", maxCompletionLogprobs)
+		}
+		req.logprobs = int(logprobs.Int())
+	}
+	if echo && req.logprobs >= 0 {
+		return nil, errors.New("echo with logprobs is not supported: backends do not return prompt token log probabilities")
+	}
+	if echo && suffix != "" {
+		return nil, errors.New("echo is not supported together with suffix")
+	}
+
+	if stop := root.Get("stop"); stop.Exists() && stop.Type != gjson.Null {
+		var stops int
+		switch {
+		case stop.Type == gjson.String:
+			if stop.String() != "" {
+				stops = 1
+			}
+		case stop.IsArray():
+			for _, item := range stop.Array() {
+				if item.Type != gjson.String {
+					return nil, errors.New("stop must be a string or an array of strings")
+				}
+				if item.String() != "" {
+					stops++
+				}
+			}
+		default:
+			return nil, errors.New("stop must be a string or an array of strings")
+		}
+		if stops > maxCompletionStops {
+			return nil, fmt.Errorf("stop accepts at most %d sequences", maxCompletionStops)
+		}
+	}
+	return req, nil
+}
+
+// checkCompletionsSupport rejects parameters the backends serving the model cannot honour.
+func checkCompletionsSupport(req *completionsRequest) error {
+	if req.logprobs < 0 {
+		return nil
+	}
+	baseModel := thinking.ParseSuffix(util.ResolveAutoModel(req.model)).ModelName
+	for _, provider := range util.GetProviderName(baseModel) {
+		if _, ok := completionsNoLogprobProviders[provider]; ok {
+			return fmt.Errorf("logprobs is not supported for model %s: the %s backend does not return token log probabilities", req.model, provider)
+		}
+	}
+	return nil
+}
+
+// choiceRequest builds the request for a single prompt and choice.
+func (r *completionsRequest) choiceRequest(prompt string) []byte {
+	out, _ := sjson.SetBytes(r.raw, "prompt", prompt)
+	out, _ = sjson.DeleteBytes(out, "n")
+	out, _ = sjson.DeleteBytes(out, "best_of")
+	return out
+}
+
+// completionsConcurrency returns how many choices may run at once. Request logging records upstream
+// attempts on the shared gin context, so choices run sequentially when it is enabled.
+func (h *OpenAIAPIHandler) completionsConcurrency() int {
+	if h.Cfg != nil && h.Cfg.RequestLog {
+		return 1
+	}
+	return completionsConcurrency
+}
+
+// handleCompletionsNonStreamingResponse runs one request per prompt and choice and merges the
+// translated choices into a single text_completion response.
+func (h *OpenAIAPIHandler) handleCompletionsNonStreamingResponse(c *gin.Context, req *completionsRequest) {
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+
+	total := len(req.prompts) * req.n
+	results := make([]gjson.Result, total)
+	var (
+		mu              sync.Mutex
+		wg              sync.WaitGroup
+		firstErr        *interfaces.ErrorMessage
+		upstreamHeaders http.Header
+	)
+	sem := make(chan struct{}, h.completionsConcurrency())
+	for idx := 0; idx < total; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			mu.Lock()
+			failed := firstErr != nil
+			mu.Unlock()
+			if failed {
+				return
+			}
+			resp, headers, errMsg := h.ExecuteWithAuthManager(cliCtx, constant.OpenAICompletions, req.model, req.choiceRequest(req.prompts[idx/req.n]), "")
+			mu.Lock()
+			defer mu.Unlock()
+			if errMsg != nil {
+				if firstErr == nil {
+					firstErr = errMsg
+				}
+				return
+			}
+			if upstreamHeaders == nil {
+				upstreamHeaders = headers
+			}
+			results[idx] = gjson.ParseBytes(resp)
+		}(idx)
+	}
+	wg.Wait()
+	stopKeepAlive()
+	if firstErr != nil {
+		h.WriteErrorResponse(c, firstErr)
+		cliCancel(firstErr.Error)
+		return
+	}
+
+	out := []byte(`{"id":"","object":"text_completion","created":0,"model":"","choices":[]}`)
+	out, _ = sjson.SetBytes(out, "id", req.id)
+	out, _ = sjson.SetBytes(out, "created", req.created)
+	out, _ = sjson.SetBytes(out, "model", req.model)
+	usage := newCompletionUsage()
+	for idx, result := range results {
+		choice, _ := sjson.SetBytes([]byte(result.Get("choices.0").Raw), "index", idx)
+		out, _ = sjson.SetRawBytes(out, "choices.-1", choice)
+		usage.add(idx/req.n, result.Get("usage"))
+	}
+	out, _ = sjson.SetRawBytes(out, "usage", usage.json())
+
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(out)
+	cliCancel()
+}
+
+// handleCompletionsStreamingResponse streams every choice as text_completion chunks. Choices
+// are interleaved and distinguished by index, as in the upstream OpenAI API.
+func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, req *completionsRequest) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Streaming not supported",
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	data := make(chan []byte)
+	errs := make(chan *interfaces.ErrorMessage, 1)
+	headers := make(chan http.Header, 1)
+	go h.runCompletionStreams(cliCtx, req, data, errs, headers)
+
+	setSSEHeaders := func() {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("Access-Control-Allow-Origin", "*")
+		select {
+		case upstreamHeaders := <-headers:
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+		default:
+		}
+	}
+
+	// Peek at the first event so upstream failures still produce a proper error status.
+	select {
+	case <-c.Request.Context().Done():
+		cliCancel(c.Request.Context().Err())
+	case errMsg := <-errs:
+		h.WriteErrorResponse(c, errMsg)
+		if errMsg != nil {
+			cliCancel(errMsg.Error)
+		} else {
+			cliCancel(nil)
+		}
+	case chunk, ok := <-data:
+		setSSEHeaders()
+		if !ok {
+			_, _ = fmt.Fprintf(c.Writer, "data: [DONE]\n\n")
+			flusher.Flush()
+			cliCancel(nil)
+			return
+		}
+		_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+		flusher.Flush()
+		h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, data, errs)
+	}
+}
+
+// runCompletionStreams executes one stream per choice and re-indexes its chunks. The data
+// channel is closed once every choice has finished or the first error has been reported.
+func (h *OpenAIAPIHandler) runCompletionStreams(ctx context.Context, req *completionsRequest, data chan<- []byte, errs chan<- *interfaces.ErrorMessage, headers chan<- http.Header) {
+	defer close(data)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg          sync.WaitGroup
+		usageMu     sync.Mutex
+		headersOnce sync.Once
+	)
+	usage := newCompletionUsage()
+	fail := func(errMsg *interfaces.ErrorMessage) {
+		select {
+		case errs <- errMsg:
+		default:
+		}
+		cancel()
+	}
+	emit := func(chunk []byte) bool {
+		select {
+		case data <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	sem := make(chan struct{}, h.completionsConcurrency())
+	total := len(req.prompts) * req.n
+	for idx := 0; idx < total; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			chunks, upstreamHeaders, upstreamErrs := h.ExecuteStreamWithAuthManager(ctx, constant.OpenAICompletions, req.model, req.choiceRequest(req.prompts[idx/req.n]), "")
+			headersOnce.Do(func() { headers <- upstreamHeaders })
+
+			for chunks != nil || upstreamErrs != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errMsg, ok := <-upstreamErrs:
+					if !ok {
+						upstreamErrs = nil
+						continue
+					}
+					if errMsg != nil {
+						fail(errMsg)
+						return
+					}
+				case chunk, ok := <-chunks:
+					if !ok {
+						chunks = nil
+						continue
+					}
+					if !gjson.ValidBytes(chunk) {
+						continue
+					}
+					root := gjson.ParseBytes(chunk)
+					if chunkUsage := root.Get("usage"); chunkUsage.IsObject() {
+						usageMu.Lock()
+						usage.add(idx/req.n, chunkUsage)
+						usageMu.Unlock()
+					}
+					choice := root.Get("choices.0")
+					if !choice.Exists() {
+						continue
+					}
+					if !emit(req.streamChunk(idx, choice)) {
+						return
+					}
+				}
+			}
+		}(idx)
+	}
+	wg.Wait()
+
+	if ctx.Err() == nil && req.includeUsage {
+		chunk := []byte(`{"id":"","object":"text_completion","created":0,"model":"","choices":[]}`)
+		chunk, _ = sjson.SetBytes(chunk, "id", req.id)
+		chunk, _ = sjson.SetBytes(chunk, "created", req.created)
+		chunk, _ = sjson.SetBytes(chunk, "model", req.model)
+		chunk, _ = sjson.SetRawBytes(chunk, "usage", usage.json())
+		emit(chunk)
+	}
+}
+
+// streamChunk rewraps a translated choice chunk with the response envelope and its index.
+func (r *completionsRequest) streamChunk(index int, choice gjson.Result) []byte {
+	out := []byte(`{"id":"","object":"text_completion","created":0,"model":"","choices":[]}`)
+	out, _ = sjson.SetBytes(out, "id", r.id)
+	out, _ = sjson.SetBytes(out, "created", r.created)
+	out, _ = sjson.SetBytes(out, "model", r.model)
+	out, _ = sjson.SetRawBytes(out, "choices.-1", []byte(choice.Raw))
+	out, _ = sjson.SetBytes(out, "choices.0.index", index)
+	return out
+}
+
+// completionUsage sums usage across choices, counting prompt tokens once per prompt.
+type completionUsage struct {
+	promptTokens     int64
+	completionTokens int64
+	seenPrompts      map[int]struct{}
+}
+
+func newCompletionUsage() *completionUsage {
+	return &completionUsage{seenPrompts: make(map[int]struct{})}
+}
+
+func (u *completionUsage) add(promptIdx int, usage gjson.Result) {
+	if !usage.Exists() {
+		return
+	}
+	if _, seen := u.seenPrompts[promptIdx]; !seen {
+		u.seenPrompts[promptIdx] = struct{}{}
+		u.promptTokens += usage.Get("prompt_tokens").Int()
+	}
+	u.completionTokens += usage.Get("completion_tokens").Int()
+}
+
+func (u *completionUsage) json() []byte {
+	out := []byte(`{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}`)
+	out, _ = sjson.SetBytes(out, "prompt_tokens", u.promptTokens)
+	out, _ = sjson.SetBytes(out, "completion_tokens", u.completionTokens)
+	out, _ = sjson.SetBytes(out, "total_tokens", u.promptTokens+u.completionTokens)
+	return out
+}
+
+func newCompletionID() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("cmpl-%d", time.Now().UnixNano())
+	}
+	return "cmpl-" + hex.EncodeToString(buf)
+}
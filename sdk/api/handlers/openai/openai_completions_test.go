@@ -0,0 +1,219 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+type completionsTestExecutor struct {
+	provider string
+	text     string
+	chunks   []string
+
+	mu       sync.Mutex
+	payloads [][]byte
+}
+
+func (e *completionsTestExecutor) Identifier() string { return e.provider }
+
+// Execute and ExecuteStream translate like an OpenAI-compatible executor, so the handler
+// sees what a real chat backend would produce.
+func (e *completionsTestExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	body := e.record(req, opts, false)
+	resp := `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":` +
+		jsonString(e.text) + `},"finish_reason":"length"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, sdktranslator.FormatOpenAI, opts.SourceFormat, req.Model, opts.OriginalRequest, body, []byte(resp), &param)
+	return coreexecutor.Response{Payload: []byte(out)}, nil
+}
+
+func (e *completionsTestExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	body := e.record(req, opts, true)
+	lines := make([]string, 0, len(e.chunks)+2)
+	for _, text := range e.chunks {
+		lines = append(lines, `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":`+jsonString(text)+`}}]}`)
+	}
+	lines = append(lines, `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"length"}],"usage":{"prompt_tokens":5,"completion_tokens":3}}`, "data: [DONE]")
+	out := make(chan coreexecutor.StreamChunk, len(lines)*2)
+	var param any
+	for _, line := range lines {
+		for _, chunk := range sdktranslator.TranslateStream(ctx, sdktranslator.FormatOpenAI, opts.SourceFormat, req.Model, opts.OriginalRequest, body, []byte(line), &param) {
+			out <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+		}
+	}
+	close(out)
+	return &coreexecutor.StreamResult{Chunks: out}, nil
+}
+
+func (e *completionsTestExecutor) record(req coreexecutor.Request, opts coreexecutor.Options, stream bool) []byte {
+	body := sdktranslator.TranslateRequest(opts.SourceFormat, sdktranslator.FormatOpenAI, req.Model, req.Payload, stream)
+	e.mu.Lock()
+	e.payloads = append(e.payloads, body)
+	e.mu.Unlock()
+	return body
+}
+
+func (e *completionsTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *completionsTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *completionsTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func newCompletionsTestRouter(t *testing.T, executor *completionsTestExecutor, model string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "completions-auth-" + executor.provider, Provider: executor.provider, Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/completions", h.Completions)
+	return router
+}
+
+func postCompletions(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestCompletionsMultipleChoicesWithStopAndEcho(t *testing.T) {
+	executor := &completionsTestExecutor{provider: "completions-test-provider", text: "world\nEND more"}
+	router := newCompletionsTestRouter(t, executor, "completions-test-model")
+
+	resp := postCompletions(router, `{"model":"completions-test-model","prompt":["hello "],"n":2,"stop":"\nEND","echo":true}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	body := gjson.Parse(resp.Body.String())
+	if body.Get("object").String() != "text_completion" || !strings.HasPrefix(body.Get("id").String(), "cmpl-") {
+		t.Fatalf("unexpected envelope: %s", resp.Body.String())
+	}
+	choices := body.Get("choices").Array()
+	if len(choices) != 2 {
+		t.Fatalf("choices = %d, want 2", len(choices))
+	}
+	for i, choice := range choices {
+		if choice.Get("index").Int() != int64(i) || choice.Get("text").String() != "hello world" || choice.Get("finish_reason").String() != "stop" {
+			t.Fatalf("unexpected choice %d: %s", i, choice.Raw)
+		}
+	}
+	if body.Get("usage.prompt_tokens").Int() != 5 || body.Get("usage.completion_tokens").Int() != 6 {
+		t.Fatalf("unexpected usage: %s", body.Get("usage").Raw)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executions = %d, want 2", len(executor.payloads))
+	}
+	if got := gjson.GetBytes(executor.payloads[0], "stop.0").String(); got != "\nEND" {
+		t.Fatalf("stop forwarded = %q", got)
+	}
+}
+
+func TestCompletionsFillInTheMiddlePrompt(t *testing.T) {
+	executor := &completionsTestExecutor{provider: "completions-test-provider", text: "return a + b"}
+	router := newCompletionsTestRouter(t, executor, "completions-test-model")
+
+	resp := postCompletions(router, `{"model":"completions-test-model","prompt":"def add(a, b):\n    ","suffix":"\n\nprint(add(1, 2))"}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	user := gjson.GetBytes(executor.payloads[0], "messages.1.content").String()
+	if user != "def add(a, b):\n    <FILL_ME>\n\nprint(add(1, 2))" {
+		t.Fatalf("fim prompt = %q", user)
+	}
+	if got := gjson.Get(resp.Body.String(), "choices.0.finish_reason").String(); got != "length" {
+		t.Fatalf("finish_reason = %q, want length", got)
+	}
+}
+
+func TestCompletionsStreamingAppliesStopAcrossChunks(t *testing.T) {
+	executor := &completionsTestExecutor{provider: "completions-test-provider", chunks: []string{"one two ST", "OP three"}}
+	router := newCompletionsTestRouter(t, executor, "completions-test-model")
+
+	resp := postCompletions(router, `{"model":"completions-test-model","prompt":"count:","stream":true,"stop":["STOP"],"stream_options":{"include_usage":true}}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	var text strings.Builder
+	finish := ""
+	var usage gjson.Result
+	for _, line := range strings.Split(resp.Body.String(), "\n") {
+		payload, ok := strings.CutPrefix(line, "data: ")
+		if !ok || payload == "[DONE]" {
+			continue
+		}
+		chunk := gjson.Parse(payload)
+		text.WriteString(chunk.Get("choices.0.text").String())
+		if reason := chunk.Get("choices.0.finish_reason").String(); reason != "" {
+			finish = reason
+		}
+		if chunk.Get("usage").Exists() {
+			usage = chunk.Get("usage")
+		}
+	}
+	if text.String() != "one two " || finish != "stop" {
+		t.Fatalf("streamed text = %q, finish = %q", text.String(), finish)
+	}
+	if usage.Get("total_tokens").Int() != 8 {
+		t.Fatalf("usage = %s", usage.Raw)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(resp.Body.String()), "data: [DONE]") {
+		t.Fatalf("missing [DONE]: %s", resp.Body.String())
+	}
+}
+
+func TestCompletionsRejectsUnsupportedParameters(t *testing.T) {
+	executor := &completionsTestExecutor{provider: "claude", text: "x"}
+	router := newCompletionsTestRouter(t, executor, "completions-claude-model")
+
+	cases := map[string]string{
+		"best_of":       `{"model":"completions-claude-model","prompt":"a","best_of":3}`,
+		"token prompt":  `{"model":"completions-claude-model","prompt":[1,2,3]}`,
+		"echo logprobs": `{"model":"completions-claude-model","prompt":"a","echo":true,"logprobs":1}`,
+		"logprobs":      `{"model":"completions-claude-model","prompt":"a","logprobs":2}`,
+	}
+	for name, body := range cases {
+		resp := postCompletions(router, body)
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, body = %s", name, resp.Code, resp.Body.String())
+		}
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("rejected requests reached the backend")
+	}
+}
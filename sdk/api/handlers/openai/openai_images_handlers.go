@@ -0,0 +1,540 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxImagesPerRequest mirrors the upper bound OpenAI enforces for the n parameter.
+	maxImagesPerRequest = 10
+	// maxImageUploadBytes caps each uploaded image for /v1/images/edits.
+	maxImageUploadBytes = 20 << 20
+)
+
+// imageAspectRatios lists the aspect ratios accepted by Gemini image models.
+var imageAspectRatios = []struct {
+	label string
+	ratio float64
+}{
+	{"1:1", 1},
+	{"2:3", 2.0 / 3.0},
+	{"3:2", 3.0 / 2.0},
+	{"3:4", 3.0 / 4.0},
+	{"4:3", 4.0 / 3.0},
+	{"4:5", 4.0 / 5.0},
+	{"5:4", 5.0 / 4.0},
+	{"9:16", 9.0 / 16.0},
+	{"16:9", 16.0 / 9.0},
+	{"21:9", 21.0 / 9.0},
+}
+
+// OpenAIImagesAPIHandler serves the OpenAI Images API (/v1/images/*) on top of
+// Gemini-family image models. Requests are expressed in Gemini format so the
+// existing translators route them to Gemini, Vertex, Gemini CLI or Antigravity.
+type OpenAIImagesAPIHandler struct {
+	*handlers.BaseAPIHandler
+	store *imageFileStore
+}
+
+// imageRequest is the normalized form of a generation or edit request.
+type imageRequest struct {
+	Model          string
+	Prompt         string
+	N              int
+	Size           string
+	Quality        string
+	ResponseFormat string
+	Images         []imageInput
+}
+
+type imageInput struct {
+	MimeType string
+	Data     []byte
+}
+
+// generatedImage is one image extracted from a Gemini response.
+type generatedImage struct {
+	MimeType      string
+	Data          string
+	RevisedPrompt string
+}
+
+type imageUsage struct {
+	InputTokens  int64
+	OutputTokens int64
+	TotalTokens  int64
+}
+
+// NewOpenAIImagesAPIHandler creates a new OpenAI Images API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OpenAIImagesAPIHandler: A new OpenAI Images API handlers instance
+func NewOpenAIImagesAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIImagesAPIHandler {
+	return &OpenAIImagesAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		store:          defaultImageStore(),
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+// Image requests are built in Gemini format before execution.
+func (h *OpenAIImagesAPIHandler) HandlerType() string {
+	return Gemini
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIImagesAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels("openai")
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+func (h *OpenAIImagesAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		writeOpenAIError(c, http.StatusBadRequest, "Invalid request: body must be valid JSON")
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	req := imageRequest{
+		Model:          strings.TrimSpace(root.Get("model").String()),
+		Prompt:         root.Get("prompt").String(),
+		N:              int(root.Get("n").Int()),
+		Size:           strings.TrimSpace(root.Get("size").String()),
+		Quality:        strings.TrimSpace(root.Get("quality").String()),
+		ResponseFormat: strings.TrimSpace(root.Get("response_format").String()),
+	}
+	h.handleImageRequest(c, &req)
+}
+
+// ImageEdits handles the multipart /v1/images/edits endpoint.
+func (h *OpenAIImagesAPIHandler) ImageEdits(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid multipart request: %v", err))
+		return
+	}
+	req := imageRequest{
+		Model:          strings.TrimSpace(formValue(form, "model")),
+		Prompt:         formValue(form, "prompt"),
+		Size:           strings.TrimSpace(formValue(form, "size")),
+		Quality:        strings.TrimSpace(formValue(form, "quality")),
+		ResponseFormat: strings.TrimSpace(formValue(form, "response_format")),
+	}
+	if rawN := strings.TrimSpace(formValue(form, "n")); rawN != "" {
+		n, errN := strconv.Atoi(rawN)
+		if errN != nil {
+			writeOpenAIError(c, http.StatusBadRequest, "n must be an integer")
+			return
+		}
+		req.N = n
+	}
+
+	files := append([]*multipart.FileHeader(nil), form.File["image"]...)
+	files = append(files, form.File["image[]"]...)
+	if len(files) == 0 {
+		writeOpenAIError(c, http.StatusBadRequest, "image is required")
+		return
+	}
+	for _, fh := range files {
+		input, errRead := readImageUpload(fh)
+		if errRead != nil {
+			writeOpenAIError(c, http.StatusBadRequest, errRead.Error())
+			return
+		}
+		req.Images = append(req.Images, input)
+	}
+	if masks := form.File["mask"]; len(masks) > 0 {
+		mask, errRead := readImageUpload(masks[0])
+		if errRead != nil {
+			writeOpenAIError(c, http.StatusBadRequest, errRead.Error())
+			return
+		}
+		req.Images = append(req.Images, mask)
+		req.Prompt = strings.TrimSpace(req.Prompt + "\n\nThe last image is a mask: only edit the areas where the mask is transparent.")
+	}
+	h.handleImageRequest(c, &req)
+}
+
+// ServeImageFile serves images stored for response_format=url.
+func (h *OpenAIImagesAPIHandler) ServeImageFile(c *gin.Context) {
+	path, ok := h.store.Path(c.Param("name"))
+	if !ok {
+		writeOpenAIError(c, http.StatusNotFound, "image not found or expired")
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.File(path)
+}
+
+func (h *OpenAIImagesAPIHandler) handleImageRequest(c *gin.Context, req *imageRequest) {
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeOpenAIError(c, http.StatusBadRequest, "prompt is required")
+		return
+	}
+	if req.N == 0 {
+		req.N = 1
+	}
+	if req.N < 1 || req.N > maxImagesPerRequest {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("n must be between 1 and %d", maxImagesPerRequest))
+		return
+	}
+	switch req.ResponseFormat {
+	case "":
+		req.ResponseFormat = "b64_json"
+	case "b64_json", "url":
+	default:
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("unsupported response_format %q", req.ResponseFormat))
+		return
+	}
+	if req.Model == "" {
+		req.Model = defaultImageModel()
+		if req.Model == "" {
+			writeOpenAIError(c, http.StatusBadRequest, "model is required: no image-capable model is available")
+			return
+		}
+	}
+	aspectRatio, imageSize, errSize := mapImageSize(req.Size, req.Quality)
+	if errSize != nil {
+		writeOpenAIError(c, http.StatusBadRequest, errSize.Error())
+		return
+	}
+
+	geminiReq := buildGeminiImageRequest(req, aspectRatio, imageSize)
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+
+	var (
+		images          []generatedImage
+		usage           imageUsage
+		upstreamHeaders http.Header
+	)
+	for i := 0; i < req.N; i++ {
+		resp, headers, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), req.Model, geminiReq, "")
+		if errMsg != nil {
+			stopKeepAlive()
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		if upstreamHeaders == nil {
+			upstreamHeaders = headers
+		}
+		produced, produceUsage, errParse := parseGeminiImageResponse(resp)
+		if errParse != nil {
+			stopKeepAlive()
+			h.WriteErrorResponse(c, errParse)
+			cliCancel(errParse.Error)
+			return
+		}
+		images = append(images, produced...)
+		usage.InputTokens += produceUsage.InputTokens
+		usage.OutputTokens += produceUsage.OutputTokens
+		usage.TotalTokens += produceUsage.TotalTokens
+	}
+	stopKeepAlive()
+
+	out, errBuild := h.buildImagesResponse(c, req, images, usage)
+	if errBuild != nil {
+		h.WriteErrorResponse(c, errBuild)
+		cliCancel(errBuild.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(out)
+	cliCancel()
+}
+
+func (h *OpenAIImagesAPIHandler) buildImagesResponse(c *gin.Context, req *imageRequest, images []generatedImage, usage imageUsage) ([]byte, *interfaces.ErrorMessage) {
+	out := []byte(`{"created":0,"data":[]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	baseURL := ""
+	if h.Cfg != nil {
+		baseURL = h.Cfg.PublicBaseURL
+	}
+	for idx, img := range images {
+		item := []byte(`{}`)
+		if req.ResponseFormat == "url" {
+			decoded, errDecode := base64.StdEncoding.DecodeString(img.Data)
+			if errDecode != nil {
+				return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("upstream returned invalid image data: %w", errDecode)}
+			}
+			name, errPut := h.store.Put(decoded, img.MimeType)
+			if errPut != nil {
+				return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errPut}
+			}
+			item, _ = sjson.SetBytes(item, "url", imageFileURL(c, baseURL, name))
+		} else {
+			item, _ = sjson.SetBytes(item, "b64_json", img.Data)
+		}
+		if img.RevisedPrompt != "" {
+			item, _ = sjson.SetBytes(item, "revised_prompt", img.RevisedPrompt)
+		}
+		out, _ = sjson.SetRawBytes(out, fmt.Sprintf("data.%d", idx), item)
+	}
+	if usage.TotalTokens > 0 || usage.InputTokens > 0 || usage.OutputTokens > 0 {
+		total := usage.TotalTokens
+		if total == 0 {
+			total = usage.InputTokens + usage.OutputTokens
+		}
+		out, _ = sjson.SetBytes(out, "usage.input_tokens", usage.InputTokens)
+		out, _ = sjson.SetBytes(out, "usage.output_tokens", usage.OutputTokens)
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", total)
+	}
+	return out, nil
+}
+
+// buildGeminiImageRequest renders the normalized request as a Gemini generateContent payload.
+func buildGeminiImageRequest(req *imageRequest, aspectRatio, imageSize string) []byte {
+	out := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`)
+	partIdx := 0
+	for _, img := range req.Images {
+		out, _ = sjson.SetBytes(out, fmt.Sprintf("contents.0.parts.%d.inlineData.mimeType", partIdx), img.MimeType)
+		out, _ = sjson.SetBytes(out, fmt.Sprintf("contents.0.parts.%d.inlineData.data", partIdx), base64.StdEncoding.EncodeToString(img.Data))
+		partIdx++
+	}
+	out, _ = sjson.SetBytes(out, fmt.Sprintf("contents.0.parts.%d.text", partIdx), req.Prompt)
+	if aspectRatio != "" {
+		out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.aspectRatio", aspectRatio)
+	}
+	if imageSize != "" {
+		out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.imageSize", imageSize)
+	}
+	return out
+}
+
+// parseGeminiImageResponse extracts inline images, accompanying text and usage from a Gemini response.
+func parseGeminiImageResponse(resp []byte) ([]generatedImage, imageUsage, *interfaces.ErrorMessage) {
+	root := gjson.ParseBytes(resp)
+	if root.Get("response").Exists() && !root.Get("candidates").Exists() {
+		root = root.Get("response")
+	}
+
+	usage := imageUsage{
+		InputTokens:  root.Get("usageMetadata.promptTokenCount").Int(),
+		OutputTokens: root.Get("usageMetadata.candidatesTokenCount").Int(),
+		TotalTokens:  root.Get("usageMetadata.totalTokenCount").Int(),
+	}
+
+	var (
+		images       []generatedImage
+		texts        []string
+		finishReason string
+	)
+	root.Get("candidates").ForEach(func(_, candidate gjson.Result) bool {
+		if reason := candidate.Get("finishReason").String(); reason != "" {
+			finishReason = reason
+		}
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if part.Get("thought").Bool() {
+				return true
+			}
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			if inline.Exists() {
+				data := inline.Get("data").String()
+				if data == "" {
+					return true
+				}
+				mimeType := inline.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inline.Get("mime_type").String()
+				}
+				images = append(images, generatedImage{MimeType: mimeType, Data: data})
+				return true
+			}
+			if text := strings.TrimSpace(part.Get("text").String()); text != "" {
+				texts = append(texts, text)
+			}
+			return true
+		})
+		return true
+	})
+
+	if len(images) == 0 {
+		if blockReason := root.Get("promptFeedback.blockReason").String(); blockReason != "" {
+			return nil, usage, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("image generation blocked: %s", blockReason)}
+		}
+		switch finishReason {
+		case "SAFETY", "IMAGE_SAFETY", "PROHIBITED_CONTENT", "BLOCKLIST", "SPII", "RECITATION":
+			return nil, usage, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("image generation blocked: %s", finishReason)}
+		}
+		msg := "upstream returned no image"
+		if len(texts) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, strings.Join(texts, " "))
+		}
+		return nil, usage, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("%s", msg)}
+	}
+
+	revised := strings.Join(texts, "\n")
+	for i := range images {
+		images[i].RevisedPrompt = revised
+	}
+	return images, usage, nil
+}
+
+// mapImageSize converts OpenAI size/quality values into Gemini imageConfig fields.
+// Sizes may be WIDTHxHEIGHT, a bare aspect ratio such as 16:9, or "auto".
+func mapImageSize(size, quality string) (aspectRatio, imageSize string, err error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size != "" && size != "auto" {
+		if strings.Contains(size, ":") {
+			for _, candidate := range imageAspectRatios {
+				if candidate.label == size {
+					aspectRatio = candidate.label
+					break
+				}
+			}
+			if aspectRatio == "" {
+				return "", "", fmt.Errorf("unsupported size %q", size)
+			}
+		} else {
+			widthRaw, heightRaw, found := strings.Cut(size, "x")
+			width, errW := strconv.Atoi(widthRaw)
+			height, errH := strconv.Atoi(heightRaw)
+			if !found || errW != nil || errH != nil || width <= 0 || height <= 0 {
+				return "", "", fmt.Errorf("unsupported size %q: expected WIDTHxHEIGHT", size)
+			}
+			aspectRatio = nearestAspectRatio(float64(width) / float64(height))
+			switch longest := max(width, height); {
+			case longest > 2048:
+				imageSize = "4K"
+			case longest > 1536:
+				imageSize = "2K"
+			}
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(quality)) {
+	case "hd", "high":
+		if imageSize == "" {
+			imageSize = "2K"
+		}
+	}
+	return aspectRatio, imageSize, nil
+}
+
+func nearestAspectRatio(ratio float64) string {
+	best := imageAspectRatios[0].label
+	bestDelta := math.MaxFloat64
+	for _, candidate := range imageAspectRatios {
+		delta := math.Abs(math.Log(ratio / candidate.ratio))
+		if delta < bestDelta {
+			best = candidate.label
+			bestDelta = delta
+		}
+	}
+	return best
+}
+
+// defaultImageModel picks the first registered model that can emit images.
+func defaultImageModel() string {
+	for _, model := range registry.GetGlobalRegistry().GetAvailableModels("openai") {
+		id, _ := model["id"].(string)
+		if id == "" {
+			continue
+		}
+		if isImageOutputModel(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// isImageOutputModel reports whether a model advertises IMAGE output, falling back
+// to the "-image" naming convention used by the Gemini catalog.
+func isImageOutputModel(modelID string) bool {
+	for _, provider := range registry.GetGlobalRegistry().GetModelProviders(modelID) {
+		if info := registry.GetGlobalRegistry().GetModelInfo(modelID, provider); info != nil {
+			for _, modality := range info.SupportedOutputModalities {
+				if strings.EqualFold(modality, "IMAGE") {
+					return true
+				}
+			}
+		}
+	}
+	lower := strings.ToLower(modelID)
+	return strings.Contains(lower, "-image") && !strings.HasPrefix(lower, "imagen")
+}
+
+func readImageUpload(fh *multipart.FileHeader) (imageInput, error) {
+	if fh.Size > maxImageUploadBytes {
+		return imageInput{}, fmt.Errorf("image %s exceeds %d bytes", fh.Filename, maxImageUploadBytes)
+	}
+	file, err := fh.Open()
+	if err != nil {
+		return imageInput{}, fmt.Errorf("failed to open image %s: %w", fh.Filename, err)
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(io.LimitReader(file, maxImageUploadBytes+1))
+	if err != nil {
+		return imageInput{}, fmt.Errorf("failed to read image %s: %w", fh.Filename, err)
+	}
+	if len(data) > maxImageUploadBytes {
+		return imageInput{}, fmt.Errorf("image %s exceeds %d bytes", fh.Filename, maxImageUploadBytes)
+	}
+	mimeType := strings.TrimSpace(strings.Split(fh.Header.Get("Content-Type"), ";")[0])
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return imageInput{}, fmt.Errorf("file %s is not a supported image", fh.Filename)
+	}
+	return imageInput{MimeType: mimeType, Data: data}, nil
+}
+
+func formValue(form *multipart.Form, key string) string {
+	if form == nil {
+		return ""
+	}
+	if values := form.Value[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// imageFileURL builds an absolute URL to a stored image. It uses the configured public base
+// URL when set and otherwise the inbound request host. X-Forwarded-* headers are ignored since
+// any client could set them to point the returned links elsewhere.
+func imageFileURL(c *gin.Context, baseURL, name string) string {
+	if baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/"); baseURL != "" {
+		return baseURL + "/v1/images/files/" + name
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/v1/images/files/%s", scheme, c.Request.Host, name)
+}
+
+// writeOpenAIError writes an OpenAI-style error body for validation failures detected locally.
+func writeOpenAIError(c *gin.Context, status int, message string) {
+	c.Data(status, "application/json", handlers.BuildErrorResponseBody(status, message))
+}
@@ -0,0 +1,211 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type imageCaptureExecutor struct {
+	payloads [][]byte
+	response string
+}
+
+func (e *imageCaptureExecutor) Identifier() string { return "image-test-provider" }
+
+func (e *imageCaptureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, append([]byte(nil), req.Payload...))
+	return coreexecutor.Response{Payload: []byte(e.response)}, nil
+}
+
+func (e *imageCaptureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *imageCaptureExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *imageCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *imageCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newImageTestRouter(t *testing.T, executor *imageCaptureExecutor) (*gin.Engine, *OpenAIImagesAPIHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "image-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-image-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	h := NewOpenAIImagesAPIHandler(base)
+	h.store = newImageFileStore(t.TempDir(), 0)
+	router := gin.New()
+	router.POST("/v1/images/generations", h.ImageGenerations)
+	router.GET("/v1/images/files/:name", h.ServeImageFile)
+	return router, h
+}
+
+func TestImageGenerationsReturnsBase64(t *testing.T) {
+	executor := &imageCaptureExecutor{response: `{"candidates":[{"content":{"parts":[{"text":"a red cat"},{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":1290,"totalTokenCount":1295}}`}
+	router, _ := newImageTestRouter(t, executor)
+
+	body := `{"model":"test-image-model","prompt":"a cat","n":2,"size":"1792x1024"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executor calls = %d, want 2", len(executor.payloads))
+	}
+	sent := gjson.ParseBytes(executor.payloads[0])
+	if got := sent.Get("generationConfig.imageConfig.aspectRatio").String(); got != "16:9" {
+		t.Fatalf("aspectRatio = %q, want 16:9", got)
+	}
+	if got := sent.Get("generationConfig.imageConfig.imageSize").String(); got != "2K" {
+		t.Fatalf("imageSize = %q, want 2K", got)
+	}
+	if got := sent.Get("contents.0.parts.0.text").String(); got != "a cat" {
+		t.Fatalf("prompt = %q", got)
+	}
+
+	out := gjson.Parse(resp.Body.String())
+	if n := len(out.Get("data").Array()); n != 2 {
+		t.Fatalf("data length = %d, want 2", n)
+	}
+	if got := out.Get("data.0.b64_json").String(); got != "aGVsbG8=" {
+		t.Fatalf("b64_json = %q", got)
+	}
+	if got := out.Get("data.0.revised_prompt").String(); got != "a red cat" {
+		t.Fatalf("revised_prompt = %q", got)
+	}
+	if got := out.Get("usage.total_tokens").Int(); got != 2590 {
+		t.Fatalf("usage.total_tokens = %d, want 2590", got)
+	}
+}
+
+func TestImageGenerationsURLResponseIsServed(t *testing.T) {
+	executor := &imageCaptureExecutor{response: `{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}}]}}]}`}
+	router, _ := newImageTestRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a cat","response_format":"url"}`))
+	req.Host = "proxy.local:8317"
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+
+	url := gjson.Get(resp.Body.String(), "data.0.url").String()
+	prefix := "http://proxy.local:8317"
+	if !strings.HasPrefix(url, prefix+"/v1/images/files/") {
+		t.Fatalf("url = %q", url)
+	}
+
+	fileReq := httptest.NewRequest(http.MethodGet, strings.TrimPrefix(url, prefix), nil)
+	fileResp := httptest.NewRecorder()
+	router.ServeHTTP(fileResp, fileReq)
+	if fileResp.Code != http.StatusOK {
+		t.Fatalf("file status = %d", fileResp.Code)
+	}
+	if fileResp.Body.String() != "hello" {
+		t.Fatalf("file body = %q, want hello", fileResp.Body.String())
+	}
+}
+
+func TestImageFileURLIgnoresForwardedHeaders(t *testing.T) {
+	executor := &imageCaptureExecutor{response: `{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}}]}}]}`}
+	router, h := newImageTestRouter(t, executor)
+
+	send := func() string {
+		req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a cat","response_format":"url"}`))
+		req.Host = "proxy.local:8317"
+		req.Header.Set("X-Forwarded-Host", "attacker.example")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		return gjson.Get(resp.Body.String(), "data.0.url").String()
+	}
+
+	if url := send(); !strings.HasPrefix(url, "http://proxy.local:8317/v1/images/files/") {
+		t.Fatalf("url = %q, want request host", url)
+	}
+	h.Cfg.PublicBaseURL = "https://ai.example.com/"
+	if url := send(); !strings.HasPrefix(url, "https://ai.example.com/v1/images/files/") {
+		t.Fatalf("url = %q, want public base URL", url)
+	}
+}
+
+func TestImageGenerationsSafetyBlockIsClientError(t *testing.T) {
+	executor := &imageCaptureExecutor{response: `{"candidates":[{"finishReason":"IMAGE_SAFETY"}]}`}
+	router, _ := newImageTestRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"x"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", resp.Code)
+	}
+}
+
+func TestMapImageSize(t *testing.T) {
+	cases := []struct {
+		size, quality string
+		aspect, image string
+		wantErr       bool
+	}{
+		{size: "1024x1024", aspect: "1:1"},
+		{size: "1024x1792", aspect: "9:16", image: "2K"},
+		{size: "1536x1024", aspect: "3:2"},
+		{size: "3:4", aspect: "3:4"},
+		{size: "auto", quality: "hd", image: "2K"},
+		{size: "4096x4096", aspect: "1:1", image: "4K"},
+		{size: "7:3", wantErr: true},
+		{size: "big", wantErr: true},
+	}
+	for _, tc := range cases {
+		aspect, image, err := mapImageSize(tc.size, tc.quality)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("mapImageSize(%q) expected error", tc.size)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("mapImageSize(%q) error: %v", tc.size, err)
+		}
+		if aspect != tc.aspect || image != tc.image {
+			t.Fatalf("mapImageSize(%q, %q) = (%q, %q), want (%q, %q)", tc.size, tc.quality, aspect, image, tc.aspect, tc.image)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package openai
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultImageFileTTL controls how long generated images stay downloadable via URL.
+const defaultImageFileTTL = time.Hour
+
+var imageFileNamePattern = regexp.MustCompile(`^[a-f0-9]{32}\.(png|jpeg|webp|gif)$`)
+
+// imageFileStore keeps generated images in a temporary directory so they can be
+// served back to clients that request response_format=url.
+type imageFileStore struct {
+	mu  sync.Mutex
+	dir string
+	ttl time.Duration
+}
+
+var (
+	sharedImageStore     *imageFileStore
+	sharedImageStoreOnce sync.Once
+)
+
+// defaultImageStore returns the process-wide image store rooted in the OS temp directory.
+func defaultImageStore() *imageFileStore {
+	sharedImageStoreOnce.Do(func() {
+		sharedImageStore = newImageFileStore(filepath.Join(os.TempDir(), "cli-proxy-api-images"), defaultImageFileTTL)
+	})
+	return sharedImageStore
+}
+
+func newImageFileStore(dir string, ttl time.Duration) *imageFileStore {
+	if ttl <= 0 {
+		ttl = defaultImageFileTTL
+	}
+	return &imageFileStore{dir: dir, ttl: ttl}
+}
+
+// Put writes the image bytes to disk and returns the generated file name.
+func (s *imageFileStore) Put(data []byte, mimeType string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", fmt.Errorf("create image store dir: %w", err)
+	}
+	s.sweepLocked(time.Now())
+
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", fmt.Errorf("generate image id: %w", err)
+	}
+	name := hex.EncodeToString(raw[:]) + "." + imageExtension(mimeType)
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o600); err != nil {
+		return "", fmt.Errorf("write image file: %w", err)
+	}
+	return name, nil
+}
+
+// Path resolves a stored file name to an on-disk path, rejecting unknown or expired entries.
+func (s *imageFileStore) Path(name string) (string, bool) {
+	if !imageFileNamePattern.MatchString(name) {
+		return "", false
+	}
+	path := filepath.Join(s.dir, name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	if time.Since(info.ModTime()) > s.ttl {
+		_ = os.Remove(path)
+		return "", false
+	}
+	return path, true
+}
+
+func (s *imageFileStore) sweepLocked(now time.Time) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !imageFileNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil || now.Sub(info.ModTime()) <= s.ttl {
+			continue
+		}
+		if errRemove := os.Remove(filepath.Join(s.dir, entry.Name())); errRemove != nil {
+			log.Debugf("image store: failed to remove expired file %s: %v", entry.Name(), errRemove)
+		}
+	}
+}
+
+func imageExtension(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg", "image/jpg":
+		return "jpeg"
+	case "image/webp":
+		return "webp"
+	case "image/gif":
+		return "gif"
+	default:
+		return "png"
+	}
+}
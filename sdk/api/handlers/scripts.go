@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scripting"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// scriptContext attaches the client request path so script hooks can match routes.
+func scriptContext(ctx context.Context) context.Context {
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		return scripting.WithPath(ctx, ginCtx.Request.URL.Path)
+	}
+	return ctx
+}
+
+// runRequestScripts lets script hooks rewrite the client payload and model before the
+// request is routed.
+func (h *BaseAPIHandler) runRequestScripts(ctx context.Context, handlerType, modelName string, rawJSON []byte, stream bool) (string, []byte, *interfaces.ErrorMessage) {
+	if !h.scripts.Enabled() {
+		return modelName, rawJSON, nil
+	}
+	out, err := h.scripts.Request(scriptContext(ctx), sdktranslator.RequestEnvelope{
+		Format: sdktranslator.FromString(handlerType),
+		Model:  modelName,
+		Stream: stream,
+		Body:   rawJSON,
+	})
+	if err != nil {
+		return modelName, rawJSON, scriptErrorMessage(err)
+	}
+	return out.Model, out.Body, nil
+}
+
+// runResponseScripts lets script hooks rewrite a non-streaming response in the client format.
+func (h *BaseAPIHandler) runResponseScripts(ctx context.Context, handlerType, modelName string, payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	if !h.scripts.Enabled() {
+		return payload, nil
+	}
+	out, err := h.scripts.Response(scriptContext(ctx), sdktranslator.ResponseEnvelope{
+		Format: sdktranslator.FromString(handlerType),
+		Model:  modelName,
+		Body:   payload,
+	})
+	if err != nil {
+		return nil, scriptErrorMessage(err)
+	}
+	return out.Body, nil
+}
+
+// runChunkScripts lets script hooks rewrite or drop a stream chunk in the client format.
+func (h *BaseAPIHandler) runChunkScripts(ctx context.Context, handlerType, modelName string, chunk []byte) ([][]byte, *interfaces.ErrorMessage) {
+	if !h.scripts.Enabled() {
+		return [][]byte{chunk}, nil
+	}
+	out, err := h.scripts.Response(ctx, sdktranslator.ResponseEnvelope{
+		Format: sdktranslator.FromString(handlerType),
+		Model:  modelName,
+		Stream: true,
+		Chunks: []string{string(chunk)},
+	})
+	if err != nil {
+		return nil, scriptErrorMessage(err)
+	}
+	chunks := make([][]byte, 0, len(out.Chunks))
+	for _, c := range out.Chunks {
+		chunks = append(chunks, []byte(c))
+	}
+	return chunks, nil
+}
+
+func scriptErrorMessage(err error) *interfaces.ErrorMessage {
+	status := http.StatusInternalServerError
+	if se, ok := err.(interface{ StatusCode() int }); ok && se.StatusCode() > 0 {
+		status = se.StatusCode()
+	}
+	return &interfaces.ErrorMessage{StatusCode: status, Error: err}
+}
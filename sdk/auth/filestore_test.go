@@ -1,6 +1,15 @@
 package auth
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/encryption"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
 
 func TestExtractAccessToken(t *testing.T) {
 	t.Parallel()
@@ -78,3 +87,47 @@ func TestExtractAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestFileTokenStoreEncryptsMetadata(t *testing.T) {
+	encoded, _ := encryption.GenerateKey()
+	raw, _ := encryption.ParseKey(encoded)
+	keyring, err := encryption.NewKeyring(raw)
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	encryption.SetDefault(keyring)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	dir := t.TempDir()
+	// A plaintext file written before encryption was enabled stays readable.
+	if err = os.WriteFile(filepath.Join(dir, "legacy.json"), []byte(`{"type":"codex","email":"old@example.com"}`), 0o600); err != nil {
+		t.Fatalf("write legacy: %v", err)
+	}
+
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	_, err = store.Save(context.Background(), &cliproxyauth.Auth{
+		ID:       "claude.json",
+		FileName: "claude.json",
+		Metadata: map[string]any{"type": "claude", "email": "new@example.com", "access_token": "secret-token"},
+	})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	onDisk, _ := os.ReadFile(filepath.Join(dir, "claude.json"))
+	if !encryption.IsSealed(onDisk) || strings.Contains(string(onDisk), "secret-token") {
+		t.Fatalf("auth file not encrypted: %s", onDisk)
+	}
+
+	auths, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	emails := map[string]bool{}
+	for _, a := range auths {
+		emails[a.Attributes["email"]] = true
+	}
+	if len(auths) != 2 || !emails["old@example.com"] || !emails["new@example.com"] {
+		t.Fatalf("unexpected auths: %+v", emails)
+	}
+}
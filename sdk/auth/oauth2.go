@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/oauth2"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/browser"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// OAuth2Authenticator logs in to a provider declared under oauth-providers.
+type OAuth2Authenticator struct {
+	provider config.OAuthProvider
+}
+
+// NewOAuth2Authenticator constructs an authenticator for a declarative OAuth provider.
+func NewOAuth2Authenticator(provider config.OAuthProvider) Authenticator {
+	return &OAuth2Authenticator{provider: provider}
+}
+
+// OAuth2Authenticators returns an authenticator for every declarative OAuth provider in cfg.
+func OAuth2Authenticators(cfg *config.Config) []Authenticator {
+	if cfg == nil {
+		return nil
+	}
+	out := make([]Authenticator, 0, len(cfg.OAuthProviders))
+	for i := range cfg.OAuthProviders {
+		out = append(out, NewOAuth2Authenticator(cfg.OAuthProviders[i]))
+	}
+	return out
+}
+
+// RegisterOAuth2Providers registers the refresh lead of every declarative OAuth provider
+// so the core manager refreshes their tokens ahead of expiry. It is safe to call again
+// after a config reload.
+func RegisterOAuth2Providers(cfg *config.Config) {
+	for _, a := range OAuth2Authenticators(cfg) {
+		lead := a.RefreshLead()
+		coreauth.RegisterRefreshLeadProvider(a.Provider(), func() *time.Duration { return lead })
+	}
+}
+
+// Provider returns the configured provider name.
+func (a *OAuth2Authenticator) Provider() string {
+	return a.provider.Name
+}
+
+// RefreshLead returns the configured refresh lead.
+func (a *OAuth2Authenticator) RefreshLead() *time.Duration {
+	lead := a.provider.RefreshLeadDuration()
+	return &lead
+}
+
+// Login runs the provider's configured grant and returns the resulting auth record.
+func (a *OAuth2Authenticator) Login(ctx context.Context, cfg *config.Config, opts *LoginOptions) (*coreauth.Auth, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("cliproxy auth: configuration is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts == nil {
+		opts = &LoginOptions{}
+	}
+	client := oauth2.NewClient(cfg, &a.provider)
+	var (
+		token *oauth2.Token
+		err   error
+	)
+	switch a.provider.Flow {
+	case config.OAuthFlowClientCredentials:
+		token, err = client.ClientCredentials(ctx)
+	case config.OAuthFlowDeviceCode:
+		token, err = a.loginDevice(ctx, client, opts)
+	default:
+		token, err = a.loginAuthorizationCode(ctx, client, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.provider.Name, err)
+	}
+	fmt.Printf("\n%s authentication successful!\n", a.provider.Name)
+	return NewOAuth2Record(&a.provider, token), nil
+}
+
+func (a *OAuth2Authenticator) loginDevice(ctx context.Context, client *oauth2.Client, opts *LoginOptions) (*oauth2.Token, error) {
+	code, err := client.StartDeviceFlow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verificationURL := code.VerificationURL()
+	fmt.Printf("\nTo authenticate, please visit:\n%s\n\n", verificationURL)
+	if code.UserCode != "" {
+		fmt.Printf("User code: %s\n\n", code.UserCode)
+	}
+	if !opts.NoBrowser && browser.IsAvailable() {
+		if errOpen := browser.OpenURL(verificationURL); errOpen != nil {
+			log.Warnf("Failed to open browser automatically: %v", errOpen)
+		}
+	}
+	fmt.Println("Waiting for authorization...")
+	return client.PollDeviceToken(ctx, code)
+}
+
+func (a *OAuth2Authenticator) loginAuthorizationCode(ctx context.Context, client *oauth2.Client, opts *LoginOptions) (*oauth2.Token, error) {
+	port, redirectURI := a.provider.Callback()
+	if opts.CallbackPort > 0 {
+		port = opts.CallbackPort
+	}
+	callbackPath := "/oauth2/" + a.provider.Name + "/callback"
+	if parsed, errParse := url.Parse(redirectURI); errParse == nil && parsed.Path != "" {
+		callbackPath = parsed.Path
+	}
+	var pkce *oauth2.PKCECodes
+	if a.provider.PKCE {
+		codes, errPKCE := oauth2.GeneratePKCECodes()
+		if errPKCE != nil {
+			return nil, errPKCE
+		}
+		pkce = codes
+	}
+	state, err := misc.GenerateRandomState()
+	if err != nil {
+		return nil, fmt.Errorf("generate state: %w", err)
+	}
+	authURL, err := client.AuthorizationURL(state, redirectURI, pkce)
+	if err != nil {
+		return nil, err
+	}
+
+	server, errListen := oauth2.ListenForCallback(port, callbackPath)
+	if errListen != nil {
+		if opts.Prompt == nil {
+			return nil, errListen
+		}
+		log.Warnf("%v; paste the callback URL manually", errListen)
+	} else {
+		defer server.Close()
+	}
+
+	fmt.Printf("Visit the following URL to continue authentication:\n%s\n", authURL)
+	if !opts.NoBrowser && browser.IsAvailable() {
+		if errOpen := browser.OpenURL(authURL); errOpen != nil {
+			log.Warnf("Failed to open browser automatically: %v", errOpen)
+		}
+	}
+	fmt.Println("Waiting for authentication callback...")
+
+	var results <-chan *oauth2.CallbackResult
+	if server != nil {
+		results = server.Results()
+	}
+	var promptC <-chan time.Time
+	if opts.Prompt != nil {
+		promptDelay := 15 * time.Second
+		if server == nil {
+			promptDelay = 0
+		}
+		promptTimer := time.NewTimer(promptDelay)
+		defer promptTimer.Stop()
+		promptC = promptTimer.C
+	}
+	timeout := time.NewTimer(5 * time.Minute)
+	defer timeout.Stop()
+
+	var result *oauth2.CallbackResult
+	for result == nil {
+		select {
+		case res := <-results:
+			result = res
+		case <-promptC:
+			promptC = nil
+			input, errPrompt := opts.Prompt(fmt.Sprintf("Paste the %s callback URL (or press Enter to keep waiting): ", a.provider.Name))
+			if errPrompt != nil {
+				return nil, errPrompt
+			}
+			parsed, errParse := misc.ParseOAuthCallback(input)
+			if errParse != nil {
+				return nil, errParse
+			}
+			if parsed != nil {
+				result = &oauth2.CallbackResult{Code: parsed.Code, State: parsed.State, Error: parsed.Error}
+			}
+		case <-timeout.C:
+			return nil, fmt.Errorf("authentication timed out")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("authentication failed: %s", result.Error)
+	}
+	if result.State != state {
+		return nil, fmt.Errorf("invalid state")
+	}
+	if strings.TrimSpace(result.Code) == "" {
+		return nil, fmt.Errorf("missing authorization code")
+	}
+	return client.ExchangeCode(ctx, result.Code, redirectURI, pkce)
+}
+
+// NewOAuth2Record builds the auth record persisted for a declarative OAuth provider token.
+// The provider's prefix and priority are copied into the record metadata.
+func NewOAuth2Record(p *config.OAuthProvider, token *oauth2.Token) *coreauth.Auth {
+	provider := p.Name
+	metadata := oauth2.ApplyToken(nil, provider, token)
+	if p.Prefix != "" {
+		metadata["prefix"] = p.Prefix
+	}
+	if p.Priority != 0 {
+		metadata["priority"] = p.Priority
+	}
+	identity := oauth2.IdentityFromIDToken(token.IDToken)
+	label := provider
+	fileName := fmt.Sprintf("%s-%d.json", provider, time.Now().UnixMilli())
+	if identity != "" {
+		metadata["email"] = identity
+		label = identity
+		fileName = fmt.Sprintf("%s-%s.json", provider, sanitizeOAuth2FileComponent(identity))
+	}
+	return &coreauth.Auth{
+		ID:       fileName,
+		Provider: provider,
+		FileName: fileName,
+		Label:    label,
+		Prefix:   p.Prefix,
+		Metadata: metadata,
+	}
+}
+
+// sanitizeOAuth2FileComponent keeps identity-derived file names free of path separators.
+func sanitizeOAuth2FileComponent(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '@', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
@@ -42,6 +42,12 @@ type ProviderExecutor interface {
 	HttpRequest(ctx context.Context, auth *Auth, req *http.Request) (*http.Response, error)
 }
 
+// ModelLister is implemented by executors that can enumerate the models an auth's
+// upstream currently serves. Returned names are upstream model IDs.
+type ModelLister interface {
+	ListModels(ctx context.Context, auth *Auth) ([]string, error)
+}
+
 // ExecutionSessionCloser allows executors to release per-session runtime resources.
 type ExecutionSessionCloser interface {
 	CloseExecutionSession(sessionID string)
@@ -158,6 +164,12 @@ func (NoopHook) OnResult(context.Context, Result) {}
 // OnCandidate implements Hook.
 func (NoopHook) OnCandidate(context.Context, Candidate) {}
 
+// RefreshFailureHook is an optional Hook extension notified when a background token
+// refresh fails. The auth is a snapshot taken before the refresh attempt.
+type RefreshFailureHook interface {
+	OnRefreshFailed(ctx context.Context, auth *Auth, err error)
+}
+
 // Manager orchestrates auth lifecycle, selection, execution, and persistence.
 type Manager struct {
 	store     Store
@@ -293,6 +305,16 @@ func (m *Manager) SetHook(hook Hook) {
 	m.mu.Unlock()
 }
 
+// Hook returns the currently installed hook so callers can wrap it.
+func (m *Manager) Hook() Hook {
+	if m == nil {
+		return NoopHook{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.hook
+}
+
 // SetStore swaps the underlying persistence store.
 func (m *Manager) SetStore(store Store) {
 	m.mu.Lock()
@@ -696,8 +718,7 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 		if strings.TrimSpace(auth.ID) == "" {
 			continue
 		}
-		kind, _ := auth.AccountInfo()
-		if !strings.EqualFold(strings.TrimSpace(kind), "api_key") {
+		if !usesConfigModelAlias(auth) {
 			continue
 		}
 
@@ -720,6 +741,18 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 			if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
+		case "bedrock":
+			if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "azure-openai":
+			if entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "local":
+			if entry := resolveLocalProviderConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
 		default:
 			// OpenAI-compat uses config selection from auth.Attributes.
 			providerKey := ""
@@ -743,6 +776,16 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 	m.apiKeyModelAlias.Store(out)
 }
 
+// usesConfigModelAlias reports whether auth's models are aliased by its config entry:
+// API-key credentials, and local backends which usually run without a key.
+func usesConfigModelAlias(auth *Auth) bool {
+	kind, _ := auth.AccountInfo()
+	if strings.EqualFold(strings.TrimSpace(kind), "api_key") {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(auth.Provider), "local")
+}
+
 func compileAPIKeyModelAliasForModels[T interface {
 	GetName() string
 	GetAlias() string
@@ -1411,6 +1454,21 @@ func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	}
 }
 
+// tenantFromMetadata returns the caller's tenant; credentials of other tenants are skipped.
+func tenantFromMetadata(meta map[string]any) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	switch val := meta[cliproxyexecutor.TenantMetadataKey].(type) {
+	case string:
+		return strings.TrimSpace(val)
+	case []byte:
+		return strings.TrimSpace(string(val))
+	default:
+		return ""
+	}
+}
+
 func publishSelectedAuthMetadata(meta map[string]any, authID string) {
 	if len(meta) == 0 {
 		return
@@ -1445,8 +1503,7 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		return requestedModel
 	}
 
-	kind, _ := auth.AccountInfo()
-	if !strings.EqualFold(strings.TrimSpace(kind), "api_key") {
+	if !usesConfigModelAlias(auth) {
 		return requestedModel
 	}
 
@@ -1478,6 +1535,14 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		upstreamModel = resolveUpstreamModelForCodexAPIKey(cfg, auth, requestedModel)
 	case "vertex":
 		upstreamModel = resolveUpstreamModelForVertexAPIKey(cfg, auth, requestedModel)
+	case "bedrock":
+		upstreamModel = resolveUpstreamModelForBedrockAPIKey(cfg, auth, requestedModel)
+	case "azure-openai":
+		upstreamModel = resolveUpstreamModelForAzureOpenAIAPIKey(cfg, auth, requestedModel)
+	case "local":
+		if entry := resolveLocalProviderConfig(cfg, auth); entry != nil {
+			upstreamModel = resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+		}
 	default:
 		upstreamModel = resolveUpstreamModelForOpenAICompatAPIKey(cfg, auth, requestedModel)
 	}
@@ -1562,6 +1627,37 @@ func resolveVertexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internal
 	return resolveAPIKeyConfig(cfg.VertexCompatAPIKey, auth)
 }
 
+func resolveBedrockAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
+func resolveAzureOpenAIAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.AzureOpenAIKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.AzureOpenAIKey, auth)
+}
+
+// resolveLocalProviderConfig matches local backends by name; they have no credential to key on.
+func resolveLocalProviderConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.LocalProvider {
+	if cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	name := strings.TrimSpace(auth.Attributes["local_name"])
+	if name == "" {
+		return nil
+	}
+	for i := range cfg.LocalProviders {
+		if strings.EqualFold(cfg.LocalProviders[i].Name, name) {
+			return &cfg.LocalProviders[i]
+		}
+	}
+	return nil
+}
+
 func resolveUpstreamModelForGeminiAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveGeminiAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -1594,6 +1690,22 @@ func resolveUpstreamModelForVertexAPIKey(cfg *internalconfig.Config, auth *Auth,
 	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
 }
 
+func resolveUpstreamModelForBedrockAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveBedrockAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
+func resolveUpstreamModelForAzureOpenAIAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
 func resolveUpstreamModelForOpenAICompatAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	providerKey := ""
 	compatName := ""
@@ -2261,6 +2373,7 @@ func shouldRetrySchedulerPick(err error) bool {
 
 func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	tenant := tenantFromMetadata(opts.Metadata)
 
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
@@ -2285,6 +2398,9 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		if pinnedAuthID != "" && candidate.ID != pinnedAuthID {
 			continue
 		}
+		if !candidate.AvailableToTenant(tenant) {
+			continue
+		}
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
@@ -2354,6 +2470,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 
 func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	tenant := tenantFromMetadata(opts.Metadata)
 
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
@@ -2385,6 +2502,9 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		if pinnedAuthID != "" && candidate.ID != pinnedAuthID {
 			continue
 		}
+		if !candidate.AvailableToTenant(tenant) {
+			continue
+		}
 		providerKey := strings.TrimSpace(strings.ToLower(candidate.Provider))
 		if providerKey == "" {
 			continue
@@ -2845,6 +2965,9 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 			}
 		}
 		m.mu.Unlock()
+		if hook, ok := m.Hook().(RefreshFailureHook); ok {
+			hook.OnRefreshFailed(ctx, auth.Clone(), err)
+		}
 		return
 	}
 	if updated == nil {
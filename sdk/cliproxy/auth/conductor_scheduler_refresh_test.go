@@ -161,3 +161,39 @@ func TestManager_PickNext_RebuildsSchedulerAfterModelCooldownError(t *testing.T)
 		t.Fatalf("pickNext() auth = %v, want %q", got, newAuth.ID)
 	}
 }
+
+type failingRefreshExecutor struct {
+	schedulerProviderTestExecutor
+}
+
+func (e failingRefreshExecutor) Refresh(ctx context.Context, auth *Auth) (*Auth, error) {
+	return nil, errors.New("invalid_grant")
+}
+
+type refreshFailureRecorder struct {
+	NoopHook
+	failures []string
+}
+
+func (h *refreshFailureRecorder) OnRefreshFailed(ctx context.Context, auth *Auth, err error) {
+	h.failures = append(h.failures, auth.ID+": "+err.Error())
+}
+
+func TestManager_RefreshAuth_NotifiesRefreshFailureHook(t *testing.T) {
+	ctx := context.Background()
+	hook := &refreshFailureRecorder{}
+	manager := NewManager(nil, &RoundRobinSelector{}, hook)
+	manager.RegisterExecutor(failingRefreshExecutor{schedulerProviderTestExecutor{provider: "gemini"}})
+	if _, errRegister := manager.Register(ctx, &Auth{ID: "refresh-fail", Provider: "gemini"}); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+
+	manager.refreshAuth(ctx, "refresh-fail")
+
+	if len(hook.failures) != 1 || hook.failures[0] != "refresh-fail: invalid_grant" {
+		t.Fatalf("failures = %v", hook.failures)
+	}
+	if manager.Hook() != Hook(hook) {
+		t.Fatalf("Hook() did not return the installed hook")
+	}
+}
@@ -501,3 +501,95 @@ func TestManager_SchedulerTracksMarkResultCooldownAndRecovery(t *testing.T) {
 		t.Fatalf("len(seen) = %d, want %d", len(seen), 2)
 	}
 }
+
+func TestSchedulerPick_TenantOnlySeesOwnAndSharedAuths(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newSchedulerForTest(
+		&RoundRobinSelector{},
+		&Auth{ID: "shared", Provider: "gemini"},
+		&Auth{ID: "team-a", Provider: "gemini", Attributes: map[string]string{"tenant": "a"}},
+		&Auth{ID: "team-b", Provider: "gemini", Metadata: map[string]any{"tenant": "b"}},
+	)
+
+	cases := []struct {
+		tenant string
+		want   map[string]bool
+	}{
+		{tenant: "", want: map[string]bool{"shared": true}},
+		{tenant: "a", want: map[string]bool{"shared": true, "team-a": true}},
+		{tenant: "b", want: map[string]bool{"shared": true, "team-b": true}},
+	}
+	for _, tc := range cases {
+		opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.TenantMetadataKey: tc.tenant}}
+		single := make(map[string]bool)
+		mixed := make(map[string]bool)
+		for i := 0; i < 6; i++ {
+			got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", opts, nil)
+			if errPick != nil {
+				t.Fatalf("tenant %q: pickSingle() error = %v", tc.tenant, errPick)
+			}
+			single[got.ID] = true
+		}
+		for i := 0; i < 6; i++ {
+			got, _, errPick := scheduler.pickMixed(context.Background(), []string{"gemini"}, "", opts, nil)
+			if errPick != nil {
+				t.Fatalf("tenant %q: pickMixed() error = %v", tc.tenant, errPick)
+			}
+			mixed[got.ID] = true
+		}
+		for name, seen := range map[string]map[string]bool{"pickSingle": single, "pickMixed": mixed} {
+			if len(seen) != len(tc.want) {
+				t.Fatalf("tenant %q: %s picked %v, want %v", tc.tenant, name, seen, tc.want)
+			}
+			for id := range seen {
+				if !tc.want[id] {
+					t.Fatalf("tenant %q: %s picked foreign auth %q", tc.tenant, name, id)
+				}
+			}
+		}
+	}
+}
+
+func TestSchedulerPick_TenantPinnedToForeignAuthFails(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newSchedulerForTest(
+		&RoundRobinSelector{},
+		&Auth{ID: "team-b", Provider: "gemini", Attributes: map[string]string{"tenant": "b"}},
+	)
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.TenantMetadataKey:     "a",
+		cliproxyexecutor.PinnedAuthMetadataKey: "team-b",
+	}}
+	if got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", opts, nil); errPick == nil {
+		t.Fatalf("pickSingle() = %q, want error", got.ID)
+	}
+	if got, _, errPick := scheduler.pickMixed(context.Background(), []string{"gemini"}, "", opts, nil); errPick == nil {
+		t.Fatalf("pickMixed() = %q, want error", got.ID)
+	}
+}
+
+func TestManagerLegacyPath_FiltersByTenant(t *testing.T) {
+	t.Parallel()
+
+	selector := &trackingSelector{}
+	manager := NewManager(nil, selector, nil)
+	manager.executors["gemini"] = schedulerTestExecutor{}
+	manager.auths["shared"] = &Auth{ID: "shared", Provider: "gemini"}
+	manager.auths["team-a"] = &Auth{ID: "team-a", Provider: "gemini", Attributes: map[string]string{"tenant": "a"}}
+	manager.auths["team-b"] = &Auth{ID: "team-b", Provider: "gemini", Attributes: map[string]string{"tenant": "b"}}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.TenantMetadataKey: "a"}}
+	if _, _, errPick := manager.pickNext(context.Background(), "gemini", "", opts, map[string]struct{}{}); errPick != nil {
+		t.Fatalf("pickNext() error = %v", errPick)
+	}
+	if len(selector.lastAuthID) != 2 {
+		t.Fatalf("candidates = %v, want shared and team-a", selector.lastAuthID)
+	}
+	for _, id := range selector.lastAuthID {
+		if id == "team-b" {
+			t.Fatalf("candidates = %v, include another tenant's auth", selector.lastAuthID)
+		}
+	}
+}
@@ -218,6 +218,9 @@ func (a *Auth) ProxyInfo() string {
 	if proxyStr == "" {
 		return ""
 	}
+	if name, ok := strings.CutPrefix(proxyStr, "pool:"); ok {
+		return "via proxy pool " + strings.TrimSpace(name)
+	}
 	if idx := strings.Index(proxyStr, "://"); idx > 0 {
 		return "via " + proxyStr[:idx] + " proxy"
 	}
@@ -243,6 +246,33 @@ func (a *Auth) DisableCoolingOverride() (bool, bool) {
 	return false, false
 }
 
+// Tenant returns the tenant owning the credential. Config credentials carry it in the
+// "tenant" attribute and auth files in the "tenant" metadata key; empty means the
+// credential is shared by all tenants.
+func (a *Auth) Tenant() string {
+	if a == nil {
+		return ""
+	}
+	if a.Attributes != nil {
+		if v := strings.TrimSpace(a.Attributes["tenant"]); v != "" {
+			return v
+		}
+	}
+	if a.Metadata != nil {
+		if v, ok := a.Metadata["tenant"].(string); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// AvailableToTenant reports whether a caller in tenant may use the credential. Shared
+// credentials are available to everyone; tenant credentials only to their tenant.
+func (a *Auth) AvailableToTenant(tenant string) bool {
+	owner := a.Tenant()
+	return owner == "" || owner == strings.TrimSpace(tenant)
+}
+
 // ToolPrefixDisabled returns whether the proxy_ tool name prefix should be
 // skipped for this auth. When true, tool names are sent to Anthropic unchanged.
 // The value is read from metadata key "tool_prefix_disabled" (or "tool-prefix-disabled").
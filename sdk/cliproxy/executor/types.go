@@ -19,6 +19,8 @@ const (
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// TenantMetadataKey restricts credential selection to the named tenant and shared credentials.
+	TenantMetadataKey = "tenant"
 )
 
 // Request encapsulates the translated payload that will be sent to a provider executor.
@@ -0,0 +1,144 @@
+package cliproxy
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// modelDiscoveryTick is how often auths are checked for a due discovery run; each auth
+// is polled at its own "discovery_interval" attribute.
+const modelDiscoveryTick = 5 * time.Second
+
+// modelDiscovery caches the models discovered per auth. Auths opt in through the
+// "discovery_interval" attribute and an executor implementing coreauth.ModelLister.
+type modelDiscovery struct {
+	mu      sync.RWMutex
+	models  map[string][]string
+	checked map[string]time.Time
+}
+
+func newModelDiscovery() *modelDiscovery {
+	return &modelDiscovery{models: make(map[string][]string), checked: make(map[string]time.Time)}
+}
+
+// Models returns the last discovered model names for authID.
+func (d *modelDiscovery) Models(authID string) []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return slices.Clone(d.models[authID])
+}
+
+// store records models for authID and reports whether the set changed.
+func (d *modelDiscovery) store(authID string, models []string) bool {
+	models = slices.Clone(models)
+	slices.Sort(models)
+	models = slices.Compact(models)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous, known := d.models[authID]
+	d.models[authID] = models
+	return !known || !slices.Equal(previous, models)
+}
+
+// due marks authID as checked at now when its interval has elapsed.
+func (d *modelDiscovery) due(authID string, interval time.Duration, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.checked[authID]; ok && now.Sub(last) < interval {
+		return false
+	}
+	d.checked[authID] = now
+	return true
+}
+
+// prune drops cached state for auths that no longer exist.
+func (d *modelDiscovery) prune(live map[string]struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id := range d.checked {
+		if _, ok := live[id]; !ok {
+			delete(d.checked, id)
+			delete(d.models, id)
+		}
+	}
+}
+
+// discoveryInterval returns the auth's discovery interval, or zero when it does not opt in.
+func discoveryInterval(auth *coreauth.Auth) time.Duration {
+	if auth == nil || auth.Attributes == nil {
+		return 0
+	}
+	raw := strings.TrimSpace(auth.Attributes["discovery_interval"])
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// runModelDiscovery polls discovery-enabled auths until ctx is cancelled.
+func (s *Service) runModelDiscovery(ctx context.Context) {
+	s.discoverModels(ctx, time.Now())
+	ticker := time.NewTicker(modelDiscoveryTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.discoverModels(ctx, now)
+		}
+	}
+}
+
+// discoverModels lists models for every due auth and re-registers auths whose model
+// set changed, so models appearing on or vanishing from the upstream follow it.
+func (s *Service) discoverModels(ctx context.Context, now time.Time) {
+	if s == nil || s.coreManager == nil || s.discovery == nil {
+		return
+	}
+	live := make(map[string]struct{})
+	for _, auth := range s.coreManager.List() {
+		if auth == nil || auth.ID == "" {
+			continue
+		}
+		live[auth.ID] = struct{}{}
+		interval := discoveryInterval(auth)
+		if auth.Disabled || interval <= 0 || !s.discovery.due(auth.ID, interval, now) {
+			continue
+		}
+		exec, ok := s.coreManager.Executor(auth.Provider)
+		if !ok {
+			continue
+		}
+		lister, ok := exec.(coreauth.ModelLister)
+		if !ok {
+			continue
+		}
+		models, err := lister.ListModels(ctx, auth)
+		if err != nil {
+			log.Warnf("model discovery failed for %s (%s): %v", auth.Label, auth.ID, err)
+			continue
+		}
+		if !s.discovery.store(auth.ID, models) {
+			continue
+		}
+		log.Infof("model discovery: %s now serves %d model(s)", auth.Label, len(models))
+		if latest, found := s.coreManager.GetByID(auth.ID); found {
+			s.refreshModelRegistrationForAuth(latest)
+		}
+	}
+	s.discovery.prune(live)
+}
@@ -0,0 +1,148 @@
+package cliproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestDiscoverModels_RegistersAndUnregistersLocalModels(t *testing.T) {
+	var tags atomic.Value
+	tags.Store(`{"models":[{"name":"llama3.1:8b"},{"name":"qwen2.5:7b"}]}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(tags.Load().(string)))
+	}))
+	defer server.Close()
+
+	service := &Service{
+		cfg: &config.Config{LocalProviders: []config.LocalProvider{{
+			Name:    "ollama",
+			Type:    "ollama",
+			BaseURL: server.URL,
+			Models:  []config.LocalModel{{Name: "llama3.1:8b", Alias: "local-fallback"}},
+		}}},
+		coreManager: coreauth.NewManager(nil, nil, nil),
+		discovery:   newModelDiscovery(),
+	}
+	auth := &coreauth.Auth{
+		ID:       "local-ollama",
+		Provider: "local",
+		Label:    "ollama",
+		Status:   coreauth.StatusActive,
+		Attributes: map[string]string{
+			"local_name":         "ollama",
+			"backend_type":       "ollama",
+			"base_url":           server.URL,
+			"discovery_interval": "1m",
+		},
+	}
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.UnregisterClient(auth.ID)
+	t.Cleanup(func() { modelRegistry.UnregisterClient(auth.ID) })
+
+	ctx := context.Background()
+	if _, err := service.coreManager.Register(ctx, auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	service.ensureExecutorsForAuth(auth)
+	service.registerModelsForAuth(auth)
+
+	registeredIDs := func() []string {
+		var ids []string
+		for _, model := range modelRegistry.GetModelsForClient(auth.ID) {
+			ids = append(ids, model.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	if got := registeredIDs(); !slices.Equal(got, []string{"local-fallback"}) {
+		t.Fatalf("before discovery = %v", got)
+	}
+
+	now := time.Now()
+	service.discoverModels(ctx, now)
+	if got := registeredIDs(); !slices.Equal(got, []string{"llama3.1:8b", "local-fallback", "qwen2.5:7b"}) {
+		t.Fatalf("after discovery = %v", got)
+	}
+
+	tags.Store(`{"models":[{"name":"llama3.1:8b"}]}`)
+	service.discoverModels(ctx, now.Add(30*time.Second))
+	if got := registeredIDs(); len(got) != 3 {
+		t.Fatalf("discovery ran before its interval elapsed: %v", got)
+	}
+	service.discoverModels(ctx, now.Add(2*time.Minute))
+	if got := registeredIDs(); !slices.Equal(got, []string{"llama3.1:8b", "local-fallback"}) {
+		t.Fatalf("after model removal = %v", got)
+	}
+}
+
+func TestDiscoverModels_OpenAICompatFiltersAndMergesAliases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer sk-compat" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"moonshotai/kimi-k2"},{"id":"qwen/qwen3-coder"},{"id":"qwen/qwen3-coder:free"},{"id":"text-embedding-3-small"}]}`))
+	}))
+	defer server.Close()
+
+	service := &Service{
+		cfg: &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+			Name:    "router",
+			BaseURL: server.URL + "/v1",
+			Models:  []config.OpenAICompatibilityModel{{Name: "moonshotai/kimi-k2", Alias: "kimi"}},
+			DiscoverModels: &config.ModelDiscovery{
+				Enabled: true,
+				Include: []string{"moonshotai/*", "qwen/*"},
+				Exclude: []string{"*:free"},
+			},
+		}}},
+		coreManager: coreauth.NewManager(nil, nil, nil),
+		discovery:   newModelDiscovery(),
+	}
+	auth := &coreauth.Auth{
+		ID:       "compat-router",
+		Provider: "router",
+		Label:    "router",
+		Status:   coreauth.StatusActive,
+		Attributes: map[string]string{
+			"compat_name":        "router",
+			"provider_key":       "router",
+			"base_url":           server.URL + "/v1",
+			"api_key":            "sk-compat",
+			"discovery_interval": "10m",
+		},
+	}
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.UnregisterClient(auth.ID)
+	t.Cleanup(func() { modelRegistry.UnregisterClient(auth.ID) })
+
+	ctx := context.Background()
+	if _, err := service.coreManager.Register(ctx, auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	service.ensureExecutorsForAuth(auth)
+	service.registerModelsForAuth(auth)
+	service.discoverModels(ctx, time.Now())
+
+	var ids []string
+	for _, model := range modelRegistry.GetModelsForClient(auth.ID) {
+		ids = append(ids, model.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"kimi", "qwen/qwen3-coder"}; !slices.Equal(ids, want) {
+		t.Fatalf("registered models = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,167 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Client calls a plugin over its Unix socket. It is safe for concurrent use; every call
+// uses its own connection.
+type Client struct {
+	socket string
+}
+
+// NewClient returns a client for the plugin listening on socket.
+func NewClient(socket string) *Client {
+	return &Client{socket: socket}
+}
+
+// Call invokes method and decodes the result into result, which may be nil. Replies with
+// an error are returned as *Error.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	conn, reader, err := c.send(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	return c.readResponse(ctx, reader, result)
+}
+
+// Stream invokes a streaming method. The initial reply is decoded into result before
+// Stream returns; the chunks that follow are delivered on the returned channel, which is
+// closed once the plugin ends the stream or ctx is done.
+func (c *Client) Stream(ctx context.Context, method string, params, result any) (<-chan cliproxyexecutor.StreamChunk, error) {
+	conn, reader, err := c.send(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	if err = c.readResponse(ctx, reader, result); err != nil {
+		stop()
+		_ = conn.Close()
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer stop()
+		defer func() { _ = conn.Close() }()
+		emit := func(chunk cliproxyexecutor.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			msg, errRead := readMessage(reader)
+			if errRead != nil {
+				if ctx.Err() != nil {
+					errRead = ctx.Err()
+				} else if errRead == io.EOF {
+					errRead = io.ErrUnexpectedEOF
+				}
+				emit(cliproxyexecutor.StreamChunk{Err: fmt.Errorf("plugin stream: %w", errRead)})
+				return
+			}
+			switch msg.Method {
+			case NotifyStreamChunk:
+				var chunk StreamChunk
+				if errDecode := json.Unmarshal(msg.Params, &chunk); errDecode != nil {
+					emit(cliproxyexecutor.StreamChunk{Err: fmt.Errorf("plugin stream: invalid chunk: %w", errDecode)})
+					return
+				}
+				if !emit(cliproxyexecutor.StreamChunk{Payload: []byte(chunk.Payload)}) {
+					return
+				}
+			case NotifyStreamEnd:
+				var end StreamEnd
+				if len(msg.Params) > 0 {
+					_ = json.Unmarshal(msg.Params, &end)
+				}
+				if end.Error != nil {
+					emit(cliproxyexecutor.StreamChunk{Err: end.Error})
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// send dials the socket and writes the request line.
+func (c *Client) send(ctx context.Context, method string, params any) (net.Conn, *bufio.Reader, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", c.socket)
+	if err != nil {
+		return nil, nil, &Error{Code: CodeUnavailable, Message: fmt.Sprintf("plugin unavailable: %v", err)}
+	}
+	id := int64(1)
+	req := message{JSONRPC: "2.0", ID: &id, Method: method}
+	if params != nil {
+		if req.Params, err = json.Marshal(params); err != nil {
+			_ = conn.Close()
+			return nil, nil, fmt.Errorf("plugin: encode params: %w", err)
+		}
+	}
+	if err = writeMessage(conn, &req); err != nil {
+		_ = conn.Close()
+		return nil, nil, &Error{Code: CodeUnavailable, Message: fmt.Sprintf("plugin unavailable: %v", err)}
+	}
+	return conn, bufio.NewReader(conn), nil
+}
+
+// readResponse reads lines until the reply to the request arrives.
+func (c *Client) readResponse(ctx context.Context, reader *bufio.Reader, result any) error {
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("plugin: read response: %w", err)
+		}
+		if msg.ID == nil {
+			continue
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err = json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("plugin: decode result: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+func readMessage(reader *bufio.Reader) (*message, error) {
+	line, err := reader.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	var msg message
+	if errDecode := json.Unmarshal(line, &msg); errDecode != nil {
+		return nil, fmt.Errorf("invalid message: %w", errDecode)
+	}
+	return &msg, nil
+}
+
+func writeMessage(w io.Writer, msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,297 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	healthCallTimeout   = 5 * time.Second
+	maxHealthFailures   = 3
+	minRestartBackoff   = time.Second
+	maxRestartBackoff   = 30 * time.Second
+	stableRunDuration   = time.Minute
+	gracefulStopTimeout = 5 * time.Second
+)
+
+// Host supervises configured plugins. Plugins with a command are launched, health-checked
+// and restarted with backoff when they exit or stop answering; plugins without a command
+// are only health-checked.
+type Host struct {
+	mu      sync.Mutex
+	plugins map[string]*process
+}
+
+// NewHost returns an empty host; call Sync to start plugins.
+func NewHost() *Host {
+	return &Host{plugins: make(map[string]*process)}
+}
+
+// Sync starts plugins that are new or whose launch settings changed and stops plugins
+// that were removed or disabled.
+func (h *Host) Sync(plugins []config.ExecutorPlugin) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wanted := make(map[string]config.ExecutorPlugin, len(plugins))
+	for _, p := range plugins {
+		if !p.Disabled {
+			wanted[p.Name] = p
+		}
+	}
+	for name, proc := range h.plugins {
+		spec, ok := wanted[name]
+		if ok && proc.fingerprint == fingerprint(spec) {
+			continue
+		}
+		proc.stop()
+		delete(h.plugins, name)
+	}
+	for name, spec := range wanted {
+		if _, ok := h.plugins[name]; ok {
+			continue
+		}
+		proc := newProcess(spec)
+		h.plugins[name] = proc
+		go proc.run()
+	}
+}
+
+// Healthy reports whether the named plugin passed its last health check.
+func (h *Host) Healthy(name string) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	proc := h.plugins[name]
+	h.mu.Unlock()
+	return proc != nil && proc.isHealthy()
+}
+
+// Stop terminates all plugins and waits for them to exit.
+func (h *Host) Stop() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, proc := range h.plugins {
+		proc.stop()
+		delete(h.plugins, name)
+	}
+}
+
+// fingerprint covers the settings that require a restart when they change.
+func fingerprint(p config.ExecutorPlugin) string {
+	data, _ := json.Marshal([]any{p.Command, p.Args, p.Env, p.SocketPath(), p.HealthEvery(), p.StartupWithin()})
+	return string(data)
+}
+
+type process struct {
+	spec        config.ExecutorPlugin
+	fingerprint string
+	client      *Client
+	ctx         context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+func newProcess(spec config.ExecutorPlugin) *process {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &process{
+		spec:        spec,
+		fingerprint: fingerprint(spec),
+		client:      NewClient(spec.SocketPath()),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+func (p *process) stop() {
+	p.cancel()
+	<-p.done
+}
+
+func (p *process) isHealthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.healthy
+}
+
+func (p *process) setHealthy(healthy bool) {
+	p.mu.Lock()
+	p.healthy = healthy
+	p.mu.Unlock()
+}
+
+// run keeps the plugin alive until the process is stopped.
+func (p *process) run() {
+	defer close(p.done)
+	backoff := minRestartBackoff
+	for {
+		started := time.Now()
+		err := p.runOnce()
+		if p.ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) >= stableRunDuration {
+			backoff = minRestartBackoff
+		}
+		log.Warnf("plugin %s: %v; restarting in %s", p.spec.Name, err, backoff)
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRestartBackoff)
+	}
+}
+
+// runOnce launches the plugin when it has a command, waits for it to become healthy and
+// then health-checks it until it exits or fails repeatedly.
+func (p *process) runOnce() error {
+	var exited <-chan struct{}
+	var child *child
+	if p.spec.Command != "" {
+		var err error
+		if child, err = p.launch(); err != nil {
+			return err
+		}
+		exited = child.exited
+		defer p.terminate(child)
+	}
+
+	if err := p.waitHealthy(exited); err != nil {
+		return err
+	}
+	p.setHealthy(true)
+	defer p.setHealthy(false)
+	log.Infof("plugin %s: healthy on %s", p.spec.Name, p.spec.SocketPath())
+
+	ticker := time.NewTicker(p.spec.HealthEvery())
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-exited:
+			return fmt.Errorf("process exited: %v", child.err)
+		case <-ticker.C:
+			if err := p.health(); err != nil {
+				failures++
+				log.Debugf("plugin %s: health check failed (%d/%d): %v", p.spec.Name, failures, maxHealthFailures, err)
+				if failures >= maxHealthFailures {
+					return fmt.Errorf("health check failed: %w", err)
+				}
+				continue
+			}
+			failures = 0
+		}
+	}
+}
+
+// child is a launched plugin process; exited is closed once it has been reaped.
+type child struct {
+	cmd    *exec.Cmd
+	exited chan struct{}
+	err    error
+}
+
+func (p *process) launch() (*child, error) {
+	socket := p.spec.SocketPath()
+	_ = os.Remove(socket)
+	cmd := exec.Command(p.spec.Command, p.spec.Args...)
+	cmd.Env = append(os.Environ(), EnvSocket+"="+socket, EnvName+"="+p.spec.Name)
+	for key, value := range p.spec.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	logger := log.WithField("plugin", p.spec.Name)
+	stdout := logger.WriterLevel(log.InfoLevel)
+	stderr := logger.WriterLevel(log.WarnLevel)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Start(); err != nil {
+		_ = stdout.Close()
+		_ = stderr.Close()
+		return nil, fmt.Errorf("start %s: %w", p.spec.Command, err)
+	}
+	c := &child{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		c.err = cmd.Wait()
+		_ = stdout.Close()
+		_ = stderr.Close()
+		close(c.exited)
+	}()
+	return c, nil
+}
+
+// terminate asks the process to exit and kills it after a grace period.
+func (p *process) terminate(c *child) {
+	defer func() { _ = os.Remove(p.spec.SocketPath()) }()
+	select {
+	case <-c.exited:
+		return
+	default:
+	}
+	if err := c.cmd.Process.Signal(os.Interrupt); err != nil {
+		_ = c.cmd.Process.Kill()
+	}
+	select {
+	case <-c.exited:
+	case <-time.After(gracefulStopTimeout):
+		_ = c.cmd.Process.Kill()
+		<-c.exited
+	}
+}
+
+// waitHealthy polls Health until it succeeds, the process exits or the startup timeout
+// elapses.
+func (p *process) waitHealthy(exited <-chan struct{}) error {
+	deadline := time.NewTimer(p.spec.StartupWithin())
+	defer deadline.Stop()
+	poll := time.NewTicker(100 * time.Millisecond)
+	defer poll.Stop()
+	var lastErr error
+	for {
+		if lastErr = p.health(); lastErr == nil {
+			return nil
+		}
+		select {
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		case <-exited:
+			return errors.New("process exited during startup")
+		case <-deadline.C:
+			return fmt.Errorf("not healthy within %s: %w", p.spec.StartupWithin(), lastErr)
+		case <-poll.C:
+		}
+	}
+}
+
+func (p *process) health() error {
+	ctx, cancel := context.WithTimeout(p.ctx, healthCallTimeout)
+	defer cancel()
+	var result HealthResult
+	if err := p.client.Call(ctx, MethodHealth, nil, &result); err != nil {
+		return err
+	}
+	if result.Status != "" && result.Status != "ok" {
+		return errors.New("status " + result.Status)
+	}
+	return nil
+}
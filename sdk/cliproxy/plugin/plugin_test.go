@@ -0,0 +1,163 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type upstreamErr struct{}
+
+func (upstreamErr) Error() string              { return "rate limited" }
+func (upstreamErr) StatusCode() int            { return http.StatusTooManyRequests }
+func (upstreamErr) RetryAfter() *time.Duration { d := 7 * time.Second; return &d }
+
+type testHandler struct{}
+
+func (testHandler) Execute(_ context.Context, params *ExecuteParams) (*ExecuteResult, error) {
+	if params.Model == "limited" {
+		return nil, upstreamErr{}
+	}
+	return &ExecuteResult{Payload: params.Auth.Attributes["region"] + ":" + params.Payload, Headers: http.Header{"X-Plugin": {"1"}}}, nil
+}
+
+func (testHandler) ExecuteStream(_ context.Context, params *ExecuteParams) (*ExecuteResult, <-chan cliproxyexecutor.StreamChunk, error) {
+	out := make(chan cliproxyexecutor.StreamChunk, 3)
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("data: one")}
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("data: two")}
+	if params.Model == "broken" {
+		out <- cliproxyexecutor.StreamChunk{Err: upstreamErr{}}
+	}
+	close(out)
+	return &ExecuteResult{}, out, nil
+}
+
+// socketPath keeps socket paths short enough for the Unix socket limit.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "plg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "p.sock")
+}
+
+func startServer(t *testing.T, h Handler) *Client {
+	t.Helper()
+	socket := socketPath(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := Serve(ctx, socket, h); err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	}()
+	t.Cleanup(func() { cancel(); <-done })
+	client := NewClient(socket)
+	deadline := time.Now().Add(2 * time.Second)
+	for client.Call(context.Background(), MethodHealth, nil, nil) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("plugin server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return client
+}
+
+func TestCallExecuteAndErrors(t *testing.T) {
+	client := startServer(t, testHandler{})
+	ctx := context.Background()
+
+	var result ExecuteResult
+	params := ExecuteParams{Auth: Auth{ID: "a", Attributes: map[string]string{"region": "eu"}}, Model: "m", Payload: `{"x":1}`}
+	if err := client.Call(ctx, MethodExecute, params, &result); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result.Payload != `eu:{"x":1}` || result.Headers.Get("X-Plugin") != "1" {
+		t.Fatalf("result = %+v", result)
+	}
+
+	params.Model = "limited"
+	err := client.Call(ctx, MethodExecute, params, &result)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.StatusCode() != http.StatusTooManyRequests || rpcErr.RetryAfter() == nil || *rpcErr.RetryAfter() != 7*time.Second {
+		t.Fatalf("expected 429 with retry-after, got %#v", err)
+	}
+
+	if err = client.Call(ctx, MethodCountTokens, params, nil); !IsMethodNotFound(err) {
+		t.Fatalf("expected method not found, got %v", err)
+	}
+
+	if err = NewClient(socketPath(t)).Call(ctx, MethodHealth, nil, nil); !errors.As(err, &rpcErr) || rpcErr.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	client := startServer(t, testHandler{})
+
+	var result ExecuteResult
+	chunks, err := client.Stream(context.Background(), MethodExecuteStream, ExecuteParams{Model: "m"}, &result)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var lines []string
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		lines = append(lines, string(chunk.Payload))
+	}
+	if len(lines) != 2 || lines[0] != "data: one" || lines[1] != "data: two" {
+		t.Fatalf("lines = %q", lines)
+	}
+
+	chunks, err = client.Stream(context.Background(), MethodExecuteStream, ExecuteParams{Model: "broken"}, &result)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var last cliproxyexecutor.StreamChunk
+	for chunk := range chunks {
+		last = chunk
+	}
+	var rpcErr *Error
+	if !errors.As(last.Err, &rpcErr) || rpcErr.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected stream error, got %v", last.Err)
+	}
+}
+
+func TestHostHealthChecksExternalPlugin(t *testing.T) {
+	socket := socketPath(t)
+	host := NewHost()
+	defer host.Stop()
+	host.Sync([]config.ExecutorPlugin{{Name: "ext", Socket: socket, StartupTimeout: "5s"}})
+	if host.Healthy("ext") {
+		t.Fatal("plugin healthy before it is served")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = Serve(ctx, socket, testHandler{}) }()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !host.Healthy("ext") {
+		if time.Now().After(deadline) {
+			t.Fatal("plugin never became healthy")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	host.Sync(nil)
+	if host.Healthy("ext") {
+		t.Fatal("removed plugin still reported healthy")
+	}
+}
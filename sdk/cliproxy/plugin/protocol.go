@@ -0,0 +1,180 @@
+// Package plugin implements out-of-process executors. A plugin is a process, written in
+// any language, that serves newline-delimited JSON-RPC 2.0 on a Unix socket. The proxy
+// opens one connection per call, writes a single request line and reads the reply.
+//
+// Methods are Health, Execute, ExecuteStream, CountTokens, Refresh and ListModels. For
+// ExecuteStream the plugin first answers the request (result or error), then sends
+// "stream.chunk" notifications carrying one line of the provider stream each, and
+// finishes with a "stream.end" notification whose params may carry an error.
+//
+// Only Health, Execute and ExecuteStream are required; the proxy falls back to local
+// behaviour when the other methods answer with method-not-found.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ProtocolVersion is reported by Health and bumped on incompatible protocol changes.
+const ProtocolVersion = 1
+
+// Environment variables passed to launched plugin processes.
+const (
+	EnvSocket = "CLIPROXY_PLUGIN_SOCKET"
+	EnvName   = "CLIPROXY_PLUGIN_NAME"
+)
+
+// RPC method names.
+const (
+	MethodHealth        = "Health"
+	MethodExecute       = "Execute"
+	MethodExecuteStream = "ExecuteStream"
+	MethodCountTokens   = "CountTokens"
+	MethodRefresh       = "Refresh"
+	MethodListModels    = "ListModels"
+
+	NotifyStreamChunk = "stream.chunk"
+	NotifyStreamEnd   = "stream.end"
+)
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+	// CodeUpstream reports a failure of the backend the plugin talks to; Data.Status
+	// carries the HTTP status to surface to clients.
+	CodeUpstream = -32000
+	// CodeUnavailable is produced locally when the plugin socket cannot be reached.
+	CodeUnavailable = -32001
+)
+
+// message is a single JSON-RPC line: a request, a response or a notification.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Auth is the credential view passed to plugins.
+type Auth struct {
+	ID         string            `json:"id"`
+	Provider   string            `json:"provider"`
+	Label      string            `json:"label,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Metadata   map[string]any    `json:"metadata,omitempty"`
+}
+
+// AuthParams are the params of Refresh and ListModels.
+type AuthParams struct {
+	Auth Auth `json:"auth"`
+}
+
+// ExecuteParams are the params of Execute, ExecuteStream and CountTokens. Payload is
+// already translated to the plugin's configured format.
+type ExecuteParams struct {
+	Auth    Auth   `json:"auth"`
+	Model   string `json:"model"`
+	Payload string `json:"payload"`
+	Stream  bool   `json:"stream,omitempty"`
+	Alt     string `json:"alt,omitempty"`
+}
+
+// ExecuteResult is the result of Execute and the initial result of ExecuteStream.
+type ExecuteResult struct {
+	Payload string      `json:"payload,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+}
+
+// StreamChunk is the params of a stream.chunk notification.
+type StreamChunk struct {
+	Payload string `json:"payload"`
+}
+
+// StreamEnd is the params of a stream.end notification.
+type StreamEnd struct {
+	Error *Error `json:"error,omitempty"`
+}
+
+// CountTokensResult is the result of CountTokens.
+type CountTokensResult struct {
+	Tokens int64 `json:"tokens"`
+}
+
+// RefreshResult is the result of Refresh. Returned keys replace the stored ones.
+type RefreshResult struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Metadata   map[string]any    `json:"metadata,omitempty"`
+}
+
+// ListModelsResult is the result of ListModels.
+type ListModelsResult struct {
+	Models []string `json:"models"`
+}
+
+// HealthResult is the result of Health.
+type HealthResult struct {
+	Status  string `json:"status"`
+	Version int    `json:"version,omitempty"`
+}
+
+// Error is a JSON-RPC error. It implements the StatusCode and RetryAfter hooks the auth
+// manager uses for cooldowns.
+type Error struct {
+	Code    int        `json:"code"`
+	Message string     `json:"message"`
+	Data    *ErrorData `json:"data,omitempty"`
+}
+
+// ErrorData carries the HTTP semantics of an error.
+type ErrorData struct {
+	Status     int `json:"status,omitempty"`
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("plugin error %d", e.Code)
+}
+
+// StatusCode returns Data.Status, or a status derived from the error code.
+func (e *Error) StatusCode() int {
+	if e.Data != nil && e.Data.Status > 0 {
+		return e.Data.Status
+	}
+	switch e.Code {
+	case CodeMethodNotFound:
+		return http.StatusNotImplemented
+	case CodeInvalidParams:
+		return http.StatusBadRequest
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// RetryAfter returns Data.RetryAfter in seconds, or nil.
+func (e *Error) RetryAfter() *time.Duration {
+	if e.Data == nil || e.Data.RetryAfter <= 0 {
+		return nil
+	}
+	d := time.Duration(e.Data.RetryAfter) * time.Second
+	return &d
+}
+
+// IsMethodNotFound reports whether err is a method-not-found reply.
+func IsMethodNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == CodeMethodNotFound
+}
@@ -0,0 +1,229 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Handler implements a plugin written in Go. CountTokens, Refresh, ListModels and Health
+// are optional and detected through TokenCounter, Refresher, ModelLister and
+// HealthChecker.
+type Handler interface {
+	Execute(ctx context.Context, params *ExecuteParams) (*ExecuteResult, error)
+	// ExecuteStream returns the initial result and a channel of stream lines. A chunk with
+	// Err set ends the stream with that error.
+	ExecuteStream(ctx context.Context, params *ExecuteParams) (*ExecuteResult, <-chan cliproxyexecutor.StreamChunk, error)
+}
+
+// TokenCounter is implemented by handlers that count tokens themselves.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, params *ExecuteParams) (*CountTokensResult, error)
+}
+
+// Refresher is implemented by handlers that refresh credentials.
+type Refresher interface {
+	Refresh(ctx context.Context, auth *Auth) (*RefreshResult, error)
+}
+
+// ModelLister is implemented by handlers that report their models.
+type ModelLister interface {
+	ListModels(ctx context.Context, auth *Auth) (*ListModelsResult, error)
+}
+
+// HealthChecker is implemented by handlers with a custom readiness check.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// Serve listens on socket and dispatches calls to h until ctx is done. A stale socket
+// file is removed first. When socket is empty the CLIPROXY_PLUGIN_SOCKET environment
+// variable set by the proxy is used.
+func Serve(ctx context.Context, socket string, h Handler) error {
+	if socket == "" {
+		socket = os.Getenv(EnvSocket)
+	}
+	if socket == "" {
+		return fmt.Errorf("plugin: no socket path (set %s)", EnvSocket)
+	}
+	_ = os.Remove(socket)
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("plugin: listen on %s: %w", socket, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer stop()
+	defer func() { _ = os.Remove(socket) }()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, errAccept := ln.Accept()
+		if errAccept != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(errAccept, &netErr) && netErr.Timeout() {
+				time.Sleep(50 * time.Millisecond)
+				continue
+			}
+			return errAccept
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(ctx, conn, h)
+		}()
+	}
+}
+
+// serveConn answers the single request written on conn.
+func serveConn(parent context.Context, conn net.Conn, h Handler) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	defer func() { _ = conn.Close() }()
+
+	req, err := readMessage(bufio.NewReader(conn))
+	if err != nil {
+		_ = writeMessage(conn, &message{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}})
+		return
+	}
+	reply := func(result any, errCall error) {
+		msg := message{JSONRPC: "2.0", ID: req.ID}
+		if errCall != nil {
+			msg.Error = toError(errCall)
+		} else {
+			msg.Result, _ = json.Marshal(result)
+		}
+		_ = writeMessage(conn, &msg)
+	}
+	notify := func(method string, params any) error {
+		raw, _ := json.Marshal(params)
+		return writeMessage(conn, &message{JSONRPC: "2.0", Method: method, Params: raw})
+	}
+
+	switch req.Method {
+	case MethodHealth:
+		if checker, ok := h.(HealthChecker); ok {
+			if errHealth := checker.Health(ctx); errHealth != nil {
+				reply(nil, errHealth)
+				return
+			}
+		}
+		reply(&HealthResult{Status: "ok", Version: ProtocolVersion}, nil)
+	case MethodExecute:
+		var params ExecuteParams
+		if !decodeParams(req, &params, reply) {
+			return
+		}
+		reply(h.Execute(ctx, &params))
+	case MethodExecuteStream:
+		var params ExecuteParams
+		if !decodeParams(req, &params, reply) {
+			return
+		}
+		result, chunks, errExec := h.ExecuteStream(ctx, &params)
+		if errExec != nil {
+			reply(nil, errExec)
+			return
+		}
+		if result == nil {
+			result = &ExecuteResult{}
+		}
+		reply(result, nil)
+		var end StreamEnd
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				end.Error = toError(chunk.Err)
+				break
+			}
+			if notify(NotifyStreamChunk, StreamChunk{Payload: string(chunk.Payload)}) != nil {
+				return
+			}
+		}
+		_ = notify(NotifyStreamEnd, end)
+	case MethodCountTokens:
+		counter, ok := h.(TokenCounter)
+		if !ok {
+			reply(nil, methodNotFound(req.Method))
+			return
+		}
+		var params ExecuteParams
+		if !decodeParams(req, &params, reply) {
+			return
+		}
+		reply(counter.CountTokens(ctx, &params))
+	case MethodRefresh:
+		refresher, ok := h.(Refresher)
+		if !ok {
+			reply(nil, methodNotFound(req.Method))
+			return
+		}
+		var params AuthParams
+		if !decodeParams(req, &params, reply) {
+			return
+		}
+		reply(refresher.Refresh(ctx, &params.Auth))
+	case MethodListModels:
+		lister, ok := h.(ModelLister)
+		if !ok {
+			reply(nil, methodNotFound(req.Method))
+			return
+		}
+		var params AuthParams
+		if !decodeParams(req, &params, reply) {
+			return
+		}
+		reply(lister.ListModels(ctx, &params.Auth))
+	default:
+		reply(nil, methodNotFound(req.Method))
+	}
+}
+
+func decodeParams(req *message, v any, reply func(any, error)) bool {
+	if len(req.Params) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		reply(nil, &Error{Code: CodeInvalidParams, Message: err.Error()})
+		return false
+	}
+	return true
+}
+
+func methodNotFound(method string) *Error {
+	return &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not implemented", method)}
+}
+
+// toError converts a handler error to a protocol error, keeping the HTTP status and
+// retry hint of errors that expose them.
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	out := &Error{Code: CodeInternal, Message: err.Error()}
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) && sc.StatusCode() > 0 {
+		out.Code = CodeUpstream
+		out.Data = &ErrorData{Status: sc.StatusCode()}
+		var ra interface{ RetryAfter() *time.Duration }
+		if errors.As(err, &ra) {
+			if d := ra.RetryAfter(); d != nil && *d > 0 {
+				out.Data.RetryAfter = int(d.Round(time.Second) / time.Second)
+			}
+		}
+	}
+	return out
+}
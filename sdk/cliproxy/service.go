@@ -13,14 +13,18 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/proxypool"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wildcard"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/plugin"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -89,6 +93,18 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// discovery caches models discovered from upstreams such as local backends.
+	discovery *modelDiscovery
+
+	// discoveryCancel stops the model discovery loop.
+	discoveryCancel context.CancelFunc
+
+	// plugins supervises out-of-process executor plugins.
+	plugins *plugin.Host
+
+	// notifier delivers operational events to webhook targets.
+	notifier *notify.Notifier
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -100,6 +116,19 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 	usage.RegisterPlugin(plugin)
 }
 
+// startNotifier creates the webhook notifier and subscribes it to auth manager,
+// model registry and usage events, keeping any hooks installed before it.
+func (s *Service) startNotifier() {
+	s.notifier = notify.NewNotifier(s.cfg)
+	if s.coreManager != nil {
+		s.notifier.TrackAuths(s.coreManager.List())
+		s.coreManager.SetHook(s.notifier.AuthHook(s.coreManager.Hook()))
+	}
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.SetHook(s.notifier.RegistryHook(modelRegistry.Hook()))
+	usage.RegisterPlugin(s.notifier)
+}
+
 // newDefaultAuthManager creates a default authentication manager with all supported providers.
 func newDefaultAuthManager() *sdkAuth.Manager {
 	return sdkAuth.NewManager(
@@ -424,6 +453,14 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewOpenAICompatExecutor(compatProviderKey, s.cfg))
 		return
 	}
+	if entry := s.cfg.FindOAuthProvider(a.Provider); entry != nil {
+		s.coreManager.RegisterExecutor(executor.NewOAuth2Executor(entry.Name, s.cfg))
+		return
+	}
+	if entry := s.cfg.FindPlugin(a.Provider); entry != nil {
+		s.coreManager.RegisterExecutor(executor.NewPluginExecutor(entry.Name, s.cfg))
+		return
+	}
 	switch strings.ToLower(a.Provider) {
 	case "gemini":
 		s.coreManager.RegisterExecutor(executor.NewGeminiExecutor(s.cfg))
@@ -440,6 +477,12 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
 	case "claude":
 		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
+	case "azure-openai":
+		s.coreManager.RegisterExecutor(executor.NewAzureOpenAIExecutor(s.cfg))
+	case "local":
+		s.coreManager.RegisterExecutor(executor.NewLocalExecutor(s.cfg))
 	case "qwen":
 		s.coreManager.RegisterExecutor(executor.NewQwenExecutor(s.cfg))
 	case "iflow":
@@ -516,6 +559,10 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	sdkAuth.RegisterOAuth2Providers(s.cfg)
+	s.plugins = plugin.NewHost()
+	s.plugins.Sync(s.cfg.Plugins)
+	proxypool.Default().Update(s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -543,6 +590,8 @@ func (s *Service) Run(ctx context.Context) error {
 
 	// handlers no longer depend on legacy clients; pass nil slice initially
 	s.server = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
+	// Installed after the server so the notifier wraps the hooks it sets.
+	s.startNotifier()
 
 	if s.authManager == nil {
 		s.authManager = newDefaultAuthManager()
@@ -671,6 +720,10 @@ func (s *Service) Run(ctx context.Context) error {
 
 		s.applyRetryConfig(newCfg)
 		s.applyPprofConfig(newCfg)
+		sdkAuth.RegisterOAuth2Providers(newCfg)
+		s.plugins.Sync(newCfg.Plugins)
+		proxypool.Default().Update(newCfg)
+		s.notifier.Update(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -702,6 +755,12 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 	log.Info("file watcher started for config and auth directory changes")
 
+	if s.coreManager != nil {
+		discoveryCtx, discoveryCancel := context.WithCancel(context.Background())
+		s.discoveryCancel = discoveryCancel
+		go s.runModelDiscovery(discoveryCtx)
+	}
+
 	// Prefer core auth manager auto refresh if available.
 	if s.coreManager != nil {
 		interval := 15 * time.Minute
@@ -742,9 +801,15 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		if s.watcherCancel != nil {
 			s.watcherCancel()
 		}
+		if s.discoveryCancel != nil {
+			s.discoveryCancel()
+		}
+		s.plugins.Stop()
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
 		}
+		s.notifier.Stop()
+		proxypool.Default().Stop()
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
 				log.Errorf("failed to stop file watcher: %v", err)
@@ -894,6 +959,34 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		// Bedrock serves the Claude catalogue; the executor maps names to Bedrock model IDs.
+		models = registry.GetClaudeModels()
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildBedrockConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		// Azure serves only the deployments listed in config.
+		if entry := s.resolveConfigAzureOpenAIKey(a); entry != nil {
+			models = buildAzureOpenAIConfigModels(entry)
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "local":
+		// Local backends serve configured models plus whatever discovery last reported.
+		if entry := s.resolveConfigLocalProvider(a); entry != nil {
+			models = buildLocalModels(entry, s.discovery.Models(a.ID))
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
 	case "codex":
 		codexPlanType := ""
 		if a.Attributes != nil {
@@ -930,6 +1023,20 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		models = registry.GetKimiModels()
 		models = applyExcludedModels(models, excluded)
 	default:
+		// Declarative OAuth providers serve the models listed in config.
+		if entry := s.cfg.FindOAuthProvider(provider); entry != nil && !compatDetected {
+			models = buildConfigModels(entry.Models, entry.Name, entry.Format)
+			models = applyExcludedModels(models, entry.ExcludedModels)
+			models = applyExcludedModels(models, excluded)
+			break
+		}
+		// Executor plugins serve configured models plus whatever discovery last reported.
+		if entry := s.cfg.FindPlugin(provider); entry != nil && !compatDetected {
+			models = buildPluginModels(entry, s.discovery.Models(a.ID))
+			models = applyExcludedModels(models, entry.ExcludedModels)
+			models = applyExcludedModels(models, excluded)
+			break
+		}
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
 			providerKey := provider
@@ -991,6 +1098,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 							UserDefined: true,
 						})
 					}
+					ms = appendDiscoveredCompatModels(ms, compat, s.discovery.Models(a.ID))
 					// Register and return
 					if len(ms) > 0 {
 						if providerKey == "" {
@@ -1170,6 +1278,62 @@ func (s *Service) resolveConfigVertexCompatKey(auth *coreauth.Auth) *config.Vert
 	return nil
 }
 
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	if attrKey == "" {
+		return nil
+	}
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if strings.EqualFold(entry.GetAPIKey(), attrKey) && (attrBase == "" || strings.EqualFold(entry.BaseURL, attrBase)) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigAzureOpenAIKey(auth *coreauth.Auth) *config.AzureOpenAIKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	if attrKey == "" {
+		return nil
+	}
+	for i := range s.cfg.AzureOpenAIKey {
+		entry := &s.cfg.AzureOpenAIKey[i]
+		if strings.EqualFold(entry.GetAPIKey(), attrKey) && (attrBase == "" || strings.EqualFold(entry.BaseURL, attrBase)) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigLocalProvider(auth *coreauth.Auth) *config.LocalProvider {
+	if auth == nil || s.cfg == nil || auth.Attributes == nil {
+		return nil
+	}
+	name := strings.TrimSpace(auth.Attributes["local_name"])
+	for i := range s.cfg.LocalProviders {
+		entry := &s.cfg.LocalProviders[i]
+		if name != "" && strings.EqualFold(entry.Name, name) {
+			return entry
+		}
+	}
+	return nil
+}
+
 func (s *Service) resolveConfigCodexKey(auth *coreauth.Auth) *config.CodexKey {
 	if auth == nil || s.cfg == nil {
 		return nil
@@ -1232,7 +1396,7 @@ func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 		modelID := strings.ToLower(strings.TrimSpace(model.ID))
 		blocked := false
 		for _, pattern := range patterns {
-			if matchWildcard(pattern, modelID) {
+			if wildcard.Match(pattern, modelID) {
 				blocked = true
 				break
 			}
@@ -1286,50 +1450,6 @@ func applyModelPrefixes(models []*ModelInfo, prefix string, forceModelPrefix boo
 	return out
 }
 
-// matchWildcard performs case-insensitive wildcard matching where '*' matches any substring.
-func matchWildcard(pattern, value string) bool {
-	if pattern == "" {
-		return false
-	}
-
-	// Fast path for exact match (no wildcard present).
-	if !strings.Contains(pattern, "*") {
-		return pattern == value
-	}
-
-	parts := strings.Split(pattern, "*")
-	// Handle prefix.
-	if prefix := parts[0]; prefix != "" {
-		if !strings.HasPrefix(value, prefix) {
-			return false
-		}
-		value = value[len(prefix):]
-	}
-
-	// Handle suffix.
-	if suffix := parts[len(parts)-1]; suffix != "" {
-		if !strings.HasSuffix(value, suffix) {
-			return false
-		}
-		value = value[:len(value)-len(suffix)]
-	}
-
-	// Handle middle segments in order.
-	for i := 1; i < len(parts)-1; i++ {
-		segment := parts[i]
-		if segment == "" {
-			continue
-		}
-		idx := strings.Index(value, segment)
-		if idx < 0 {
-			return false
-		}
-		value = value[idx+len(segment):]
-	}
-
-	return true
-}
-
 type modelEntry interface {
 	GetName() string
 	GetAlias() string
@@ -1401,6 +1521,78 @@ func buildClaudeConfigModels(entry *config.ClaudeKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "anthropic", "claude")
 }
 
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "anthropic", "claude")
+}
+
+func buildAzureOpenAIConfigModels(entry *config.AzureOpenAIKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "azure", "openai")
+}
+
+// buildLocalModels merges configured models with discovered ones; discovered models are
+// exposed under their own names.
+func buildLocalModels(entry *config.LocalProvider, discovered []string) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	models := append([]config.LocalModel(nil), entry.Models...)
+	for _, name := range discovered {
+		models = append(models, config.LocalModel{Name: name, Alias: name})
+	}
+	return buildConfigModels(models, entry.Name, "openai")
+}
+
+// buildPluginModels merges a plugin's configured models with discovered ones.
+func buildPluginModels(entry *config.ExecutorPlugin, discovered []string) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	models := append([]config.PluginModel(nil), entry.Models...)
+	for _, name := range discovered {
+		models = append(models, config.PluginModel{Name: name, Alias: name})
+	}
+	return buildConfigModels(models, entry.Name, entry.Format)
+}
+
+// appendDiscoveredCompatModels adds discovered models that pass the provider's discovery
+// filters and are not already exposed by an explicit alias or model name.
+func appendDiscoveredCompatModels(models []*ModelInfo, compat *config.OpenAICompatibility, discovered []string) []*ModelInfo {
+	if compat == nil || compat.DiscoverModels.DiscoveryEvery() <= 0 || len(discovered) == 0 {
+		return models
+	}
+	seen := make(map[string]struct{}, len(models)+len(compat.Models))
+	for _, model := range models {
+		seen[strings.ToLower(model.ID)] = struct{}{}
+	}
+	for _, model := range compat.Models {
+		seen[strings.ToLower(strings.TrimSpace(model.Name))] = struct{}{}
+	}
+	now := time.Now().Unix()
+	for _, name := range discovered {
+		key := strings.ToLower(name)
+		if _, exists := seen[key]; exists || !compat.DiscoverModels.Allows(name) {
+			continue
+		}
+		seen[key] = struct{}{}
+		models = append(models, &ModelInfo{
+			ID:          name,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     compat.Name,
+			Type:        "openai-compatibility",
+			DisplayName: name,
+			UserDefined: true,
+		})
+	}
+	return models
+}
+
 func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -11,6 +11,9 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type RemoteMediaConfig = internalconfig.RemoteMediaConfig
+type ScriptingConfig = internalconfig.ScriptingConfig
+type ScriptHook = internalconfig.ScriptHook
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
@@ -26,9 +29,27 @@ type ClaudeKey = internalconfig.ClaudeKey
 type ApiKeyEntry = internalconfig.ApiKeyEntry
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
+type BedrockKey = internalconfig.BedrockKey
+type BedrockModel = internalconfig.BedrockModel
+type AzureOpenAIKey = internalconfig.AzureOpenAIKey
+type AzureOpenAIModel = internalconfig.AzureOpenAIModel
+type AzureEntraAuth = internalconfig.AzureEntraAuth
+type LocalProvider = internalconfig.LocalProvider
+type LocalModel = internalconfig.LocalModel
+type OAuthProvider = internalconfig.OAuthProvider
+type OAuthProviderModel = internalconfig.OAuthProviderModel
+type ExecutorPlugin = internalconfig.ExecutorPlugin
+type PluginModel = internalconfig.PluginModel
+type NotificationsConfig = internalconfig.NotificationsConfig
+type NotificationTarget = internalconfig.NotificationTarget
+type NotificationBudget = internalconfig.NotificationBudget
+type CredentialBundleConfig = internalconfig.CredentialBundleConfig
+type ProxyPool = internalconfig.ProxyPool
+type ProxyHealthCheck = internalconfig.ProxyHealthCheck
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type ModelDiscovery = internalconfig.ModelDiscovery
 
 type TLS = internalconfig.TLSConfig
 
@@ -23,13 +23,21 @@ const (
 	ModeProxy
 	// ModeInvalid means the proxy setting is present but malformed or unsupported.
 	ModeInvalid
+	// ModePool means the setting references a named proxy pool ("pool:<name>") that the
+	// caller resolves to a member proxy.
+	ModePool
 )
 
+// PoolPrefix starts proxy settings that reference a named proxy pool.
+const PoolPrefix = "pool:"
+
 // Setting is the normalized interpretation of a proxy configuration value.
 type Setting struct {
 	Raw  string
 	Mode Mode
 	URL  *url.URL
+	// Pool is the referenced pool name when Mode is ModePool.
+	Pool string
 }
 
 // Parse normalizes a proxy configuration value into inherit, direct, or proxy modes.
@@ -47,6 +55,16 @@ func Parse(raw string) (Setting, error) {
 		return setting, nil
 	}
 
+	if len(trimmed) > len(PoolPrefix) && strings.EqualFold(trimmed[:len(PoolPrefix)], PoolPrefix) {
+		setting.Mode = ModePool
+		setting.Pool = strings.TrimSpace(trimmed[len(PoolPrefix):])
+		if setting.Pool != "" {
+			return setting, nil
+		}
+		setting.Mode = ModeInvalid
+		return setting, fmt.Errorf("proxy pool reference missing name")
+	}
+
 	parsedURL, errParse := url.Parse(trimmed)
 	if errParse != nil {
 		setting.Mode = ModeInvalid
@@ -110,6 +128,8 @@ func BuildHTTPTransport(raw string) (*http.Transport, Mode, error) {
 			}, setting.Mode, nil
 		}
 		return &http.Transport{Proxy: http.ProxyURL(setting.URL)}, setting.Mode, nil
+	case ModePool:
+		return nil, setting.Mode, fmt.Errorf("proxy pool %q cannot be used here", setting.Pool)
 	default:
 		return nil, setting.Mode, nil
 	}
@@ -133,7 +153,19 @@ func BuildDialer(raw string) (proxy.Dialer, Mode, error) {
 			return nil, setting.Mode, fmt.Errorf("create proxy dialer failed: %w", errDialer)
 		}
 		return dialer, setting.Mode, nil
+	case ModePool:
+		return nil, setting.Mode, fmt.Errorf("proxy pool %q cannot be used here", setting.Pool)
 	default:
 		return nil, setting.Mode, nil
 	}
 }
+
+// Redact returns raw with any proxy password masked, for logs and API responses.
+func Redact(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	parsedURL, errParse := url.Parse(trimmed)
+	if errParse != nil || parsedURL.User == nil {
+		return trimmed
+	}
+	return parsedURL.Redacted()
+}
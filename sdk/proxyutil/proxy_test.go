@@ -21,6 +21,8 @@ func TestParse(t *testing.T) {
 		{name: "https", input: "https://proxy.example.com:8443", want: ModeProxy},
 		{name: "socks5", input: "socks5://proxy.example.com:1080", want: ModeProxy},
 		{name: "invalid", input: "bad-value", want: ModeInvalid, wantErr: true},
+		{name: "pool", input: "pool:residential", want: ModePool},
+		{name: "pool without name", input: "pool: ", want: ModeInvalid, wantErr: true},
 	}
 
 	for _, tt := range tests {